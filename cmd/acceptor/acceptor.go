@@ -1,9 +1,9 @@
 package acceptor
 
 import (
-	"os"
-	"os/signal"
-	"syscall"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/rs/zerolog"
 	"github.com/spf13/cobra"
@@ -11,6 +11,12 @@ import (
 	"sylr.dev/fix/config"
 	"sylr.dev/fix/pkg/acceptor"
 	"sylr.dev/fix/pkg/acceptor/application"
+	"sylr.dev/fix/pkg/acceptor/state"
+	"sylr.dev/fix/pkg/errors"
+	"sylr.dev/fix/pkg/health"
+	"sylr.dev/fix/pkg/instrument"
+	"sylr.dev/fix/pkg/replay"
+	"sylr.dev/fix/pkg/shutdown"
 	"sylr.dev/fix/pkg/utils"
 )
 
@@ -18,6 +24,13 @@ var (
 	optionNatsEmbeded      bool
 	optionNatsURL          string
 	optionNatsOrderSubject string
+	optionInstruments      string
+	optionMarketData       string
+	optionMarketDataSpeed  float64
+	optionStateDriver      string
+	optionStateDSN         string
+	optionStateListen      string
+	optionShutdownTimeout  time.Duration
 )
 
 var AcceptorCmd = &cobra.Command{
@@ -25,16 +38,40 @@ var AcceptorCmd = &cobra.Command{
 	Short:             "Launch a FIX acceptor",
 	Long:              "Launch a FIX acceptor.",
 	RunE:              Execute,
-	PersistentPreRunE: utils.MakePersistentPreRunE(acceptor.ValidateOptions),
+	PersistentPreRunE: utils.MakePersistentPreRunE(validateOptions),
 }
 
 func init() {
 	AcceptorCmd.Flags().StringVar(&optionNatsURL, "nats-url", "nats://127.0.0.1:4222", "NATS URL used to forward FIX messages")
 	AcceptorCmd.Flags().StringVar(&optionNatsOrderSubject, "nats-order-subject", "orders.{{.Symbol}}.{{.Side}}.{{.Type}}", "NATS order subject")
+	AcceptorCmd.Flags().StringVar(&optionInstruments, "instruments", "", "Instrument catalog (JSON or CSV) SecurityListRequest messages are answered with")
+	AcceptorCmd.Flags().StringVar(&optionMarketData, "marketdata-replay", "", "Capture, message log or session log file (or directory of them) whose market data MarketDataRequest messages are answered with")
+	AcceptorCmd.Flags().Float64Var(&optionMarketDataSpeed, "marketdata-replay-speed", 1, "Speed of the market data replay relative to the recording (0 for no pacing)")
+	AcceptorCmd.Flags().StringVar(&optionStateDriver, "state-driver", "sqlite3", "Driver of the database the simulated state is persisted in ("+strings.Join(state.Drivers, ", ")+")")
+	AcceptorCmd.Flags().StringVar(&optionStateDSN, "state-dsn", "", "Data source name of the database the orders, executions and instruments are persisted in")
+	AcceptorCmd.Flags().StringVar(&optionStateListen, "state-listen", "", "Address the persisted state is served on over HTTP (requires --state-dsn)")
 	utils.AddBothBoolFlags(AcceptorCmd.Flags(), &optionNatsEmbeded, "nats-embeded", "", true, "Launch embeded NATS server")
 
 	acceptor.AddPersistentFlags(AcceptorCmd)
 	acceptor.AddPersistentFlagCompletions(AcceptorCmd)
+	health.AddMetricsFlags(AcceptorCmd)
+	shutdown.AddFlags(AcceptorCmd, &optionShutdownTimeout)
+}
+
+func validateOptions(cmd *cobra.Command, args []string) error {
+	if err := acceptor.ValidateOptions(cmd, args); err != nil {
+		return err
+	}
+
+	if optionMarketDataSpeed < 0 {
+		return fmt.Errorf("%w: --marketdata-replay-speed can't be negative", errors.Options)
+	}
+
+	if len(optionStateListen) > 0 && len(optionStateDSN) == 0 {
+		return fmt.Errorf("%w: --state-listen requires --state-dsn", errors.Options)
+	}
+
+	return nil
 }
 
 func Execute(cmd *cobra.Command, args []string) error {
@@ -67,6 +104,36 @@ func Execute(cmd *cobra.Command, args []string) error {
 		NATSOrderSubject: optionNatsOrderSubject,
 	}
 
+	if len(optionInstruments) > 0 {
+		acceptorOptions.Instruments, err = instrument.Load(optionInstruments)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(optionMarketData) > 0 {
+		acceptorOptions.MarketData, err = replay.Load(optionMarketData)
+		if err != nil {
+			return err
+		}
+		acceptorOptions.MarketDataSpeed = optionMarketDataSpeed
+	}
+
+	if len(sessions[0].AppDataDictionaries) > 0 {
+		acceptorOptions.AppDataDictionaries, err = sessions[0].GetFIXAppDictionaries()
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(optionStateDSN) > 0 {
+		acceptorOptions.State, err = state.Open(optionStateDriver, optionStateDSN)
+		if err != nil {
+			return err
+		}
+		defer acceptorOptions.State.Close()
+	}
+
 	app, err := application.NewAcceptor(&acceptorOptions)
 	if err != nil {
 		return err
@@ -74,6 +141,7 @@ func Execute(cmd *cobra.Command, args []string) error {
 
 	app.TransportDataDictionary = transportDict
 	app.AppDataDictionary = appDict
+	app.Settings = settings
 	app.Logger = logger
 
 	var quickfixLogger *zerolog.Logger
@@ -91,16 +159,25 @@ func Execute(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	defer func() {
-		acceptor.Stop()
-	}()
+	ctx := cmd.Context()
 
-	interrupt := make(chan os.Signal, 1)
-	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	if len(optionStateListen) > 0 {
+		go func() {
+			if err := acceptorOptions.State.Serve(ctx, optionStateListen, logger); err != nil {
+				logger.Error().Err(err).Msg("Acceptor state server")
+			}
+		}()
+	}
 
-	<-interrupt
-	acceptor.Stop()
-	os.Exit(0)
+	<-ctx.Done()
 
-	return nil
+	steps := []shutdown.Step{
+		shutdown.Stop("log out the sessions", acceptor.Stop),
+		shutdown.Stop("close the NATS connection", app.Close),
+	}
+	if acceptorOptions.State != nil {
+		steps = append(steps, shutdown.Step{Name: "close the state database", Run: acceptorOptions.State.Close})
+	}
+
+	return shutdown.Run(logger, optionShutdownTimeout, steps...)
 }
@@ -1,19 +1,52 @@
 package bridge
 
 import (
+	"fmt"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
+	"time"
 
+	"github.com/quickfixgo/quickfix"
 	"github.com/rs/zerolog"
 	"github.com/spf13/cobra"
 	"sylr.dev/fix/pkg/acceptor"
 
 	"sylr.dev/fix/config"
 	"sylr.dev/fix/pkg/acceptor/application"
+	"sylr.dev/fix/pkg/cli/complete"
+	"sylr.dev/fix/pkg/errors"
+	"sylr.dev/fix/pkg/ha"
+	"sylr.dev/fix/pkg/health"
+	"sylr.dev/fix/pkg/positions"
+	"sylr.dev/fix/pkg/shutdown"
+	"sylr.dev/fix/pkg/store"
 	"sylr.dev/fix/pkg/utils"
 )
 
+var (
+	optionPositions      bool
+	optionTargetSession  string
+	optionNatsURL        string
+	optionNatsSubject    string
+	optionNatsBucket     string
+	optionNatsMappingTTL time.Duration
+	optionNatsInstance   string
+	optionNatsTimeout    time.Duration
+	optionHANatsURL      string
+	optionHABucket       string
+	optionHALease        time.Duration
+	optionHALockFile     string
+	optionMappingDriver  string
+	optionMappingDSN     string
+	optionMappingBucket  string
+	optionMappingTTL     time.Duration
+
+	optionShutdownTimeout time.Duration
+)
+
 var BridgeCmd = &cobra.Command{
 	Use:               "bridge",
 	Short:             "Launch a FIX bridge",
@@ -25,6 +58,27 @@ var BridgeCmd = &cobra.Command{
 func init() {
 	acceptor.AddPersistentFlags(BridgeCmd)
 	acceptor.AddPersistentFlagCompletions(BridgeCmd)
+	health.AddMetricsFlags(BridgeCmd)
+	shutdown.AddFlags(BridgeCmd, &optionShutdownTimeout)
+
+	BridgeCmd.Flags().BoolVar(&optionPositions, "positions", false, "Net the fills of the exchanges into positions, written on SIGUSR1 and before exiting")
+	BridgeCmd.Flags().StringVar(&optionTargetSession, "target-session", "", "Exchange session the client messages are sent to when several are logged on (the first one logged on if empty)")
+	BridgeCmd.Flags().StringVar(&optionNatsURL, "nats-url", "", "URL of the NATS JetStream server the bridge instances share their orders and exchange sessions through")
+	BridgeCmd.Flags().StringVar(&optionNatsSubject, "nats-subject", "fix.bridge", "Prefix of the NATS subjects the bridge instances forward messages on")
+	BridgeCmd.Flags().StringVar(&optionNatsBucket, "nats-bucket", "fix-bridge-orders", "JetStream key-value bucket the order mapping is shared in")
+	BridgeCmd.Flags().DurationVar(&optionNatsMappingTTL, "nats-mapping-ttl", 7*24*time.Hour, "Time the order mapping is kept for when creating the bucket (0 for ever)")
+	BridgeCmd.Flags().StringVar(&optionNatsInstance, "nats-instance", "", "Name of the bridge instance (host name and process ID if empty)")
+	BridgeCmd.Flags().DurationVar(&optionNatsTimeout, "nats-timeout", 5*time.Second, "Time messages forwarded to other bridge instances wait for them to be sent")
+	BridgeCmd.Flags().StringVar(&optionHANatsURL, "ha-nats-url", "", "URL of the NATS JetStream server the active/standby bridge instances elect their leader through")
+	BridgeCmd.Flags().StringVar(&optionHABucket, "ha-bucket", "fix-bridge-leaders", "JetStream key-value bucket the leader lock is held in")
+	BridgeCmd.Flags().DurationVar(&optionHALease, "ha-lease", ha.DefaultLease, "Time the leader lock is held for without being renewed (the TTL of the bucket when creating it)")
+	BridgeCmd.Flags().StringVar(&optionHALockFile, "ha-lock-file", "", "Lock file on shared storage the active/standby bridge instances elect their leader through")
+	BridgeCmd.Flags().StringVar(&optionMappingDriver, "mapping-driver", "sqlite3", "Store the order mapping is persisted in ("+strings.Join(application.BridgeMappingDrivers, ", ")+")")
+	BridgeCmd.Flags().StringVar(&optionMappingDSN, "mapping-dsn", "", "Data source name of the database, or URL of the NATS JetStream server, the order mapping is persisted in")
+	BridgeCmd.Flags().StringVar(&optionMappingBucket, "mapping-bucket", "fix-bridge-mapping", "JetStream key-value bucket the order mapping is persisted in with --mapping-driver nats")
+	BridgeCmd.Flags().DurationVar(&optionMappingTTL, "mapping-ttl", 7*24*time.Hour, "Time the persisted order mapping is kept for (0 for ever)")
+
+	BridgeCmd.RegisterFlagCompletionFunc("target-session", complete.Session)
 }
 
 func Execute(cmd *cobra.Command, args []string) error {
@@ -56,6 +110,100 @@ func Execute(cmd *cobra.Command, args []string) error {
 	app.TransportDataDictionary = transportDict
 	app.AppDataDictionary = appDict
 	app.Logger = logger
+	if optionPositions {
+		app.Positions = positions.NewBook()
+	}
+	if len(optionTargetSession) > 0 {
+		session, err := context.GetSession(optionTargetSession)
+		if err != nil {
+			return fmt.Errorf("%w: --target-session %s", err, optionTargetSession)
+		}
+		if session.BeginString == quickfix.BeginStringFIXT11 {
+			return fmt.Errorf("%w: --target-session %s is a client session", errors.Options, optionTargetSession)
+		}
+		sessionID := session.AcceptorSessionID()
+		app.TargetSession = &sessionID
+	}
+	if context.Routing != nil {
+		if app.Routes, err = routes(context); err != nil {
+			return err
+		}
+	}
+	if context.DropCopy != nil {
+		app.DropCopy = &application.BridgeDropCopy{Scrub: context.DropCopy.Scrub}
+		for _, name := range context.DropCopy.Sessions {
+			session, err := context.GetSession(name)
+			if err != nil {
+				return fmt.Errorf("%w: drop copy session %s", err, name)
+			}
+			app.DropCopy.Sessions = append(app.DropCopy.Sessions, session.AcceptorSessionID())
+		}
+	}
+
+	ctx := cmd.Context()
+
+	// Only the leader holds the sessions, the standbys wait for it to go away
+	var lost <-chan struct{}
+	var elector *ha.Elector
+	if len(optionHANatsURL) > 0 || len(optionHALockFile) > 0 {
+		// The standby goes on with the sequence numbers of the leader
+		if !store.IsShared(settings) {
+			return fmt.Errorf("%w: --ha-nats-url and --ha-lock-file require a sql, jetstream or redis message store shared by the instances", errors.Options)
+		}
+
+		elector, err = ha.NewElector(&ha.Options{
+			NATSURL:  optionHANatsURL,
+			Bucket:   optionHABucket,
+			Key:      context.Name,
+			Lease:    optionHALease,
+			LockFile: optionHALockFile,
+		}, logger)
+		if err != nil {
+			return err
+		}
+
+		logger.Info().Msg("Standing by until elected leader")
+		if err := elector.Campaign(ctx); err != nil {
+			elector.Resign()
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		// Resigning again once shut down is harmless
+		defer elector.Resign()
+		lost = elector.Lost()
+	}
+
+	// Closes the order mapping and the cluster on early returns, the shutdown
+	// closing them otherwise
+	defer app.Close()
+
+	if len(optionMappingDSN) > 0 {
+		err = app.PersistOrderMapping(&application.BridgeMappingOptions{
+			Driver: optionMappingDriver,
+			DSN:    optionMappingDSN,
+			Bucket: optionMappingBucket,
+			TTL:    optionMappingTTL,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(optionNatsURL) > 0 {
+		err = app.JoinCluster(&application.BridgeClusterOptions{
+			NATSURL:  optionNatsURL,
+			Subject:  optionNatsSubject,
+			Bucket:   optionNatsBucket,
+			TTL:      optionNatsMappingTTL,
+			Instance: optionNatsInstance,
+			Timeout:  optionNatsTimeout,
+		})
+		if err != nil {
+			return err
+		}
+	}
 
 	var quickfixLogger *zerolog.Logger
 	if options.QuickFixLogging {
@@ -72,16 +220,99 @@ func Execute(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	defer func() {
-		bridge.Stop()
-	}()
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	summary := make(chan os.Signal, 1)
+	// Notify would relay all the signals given none
+	if signals := positions.SummarySignals(); optionPositions && len(signals) > 0 {
+		signal.Notify(summary, signals...)
+		defer signal.Stop(summary)
+	}
+
+LOOP:
+	for {
+		select {
+		case <-ctx.Done():
+			break LOOP
+
+		case <-hangup:
+			logger.Info().Msg("SIGHUP received, reloading configuration and rotating logs")
+
+			if err := config.Reload(cmd.Flags().Changed("config"), config.Context.ToQuickFixAcceptorSettings); err != nil {
+				logger.Error().Err(err).Msg("Could not reload the configuration")
+			}
+			if err := utils.RotateQuickFixLogs(); err != nil {
+				logger.Error().Err(err).Msg("Could not rotate the logs")
+			}
+
+		case <-lost:
+			logger.Error().Msg("Leadership lost, logging the sessions out")
+			err = errors.HALeadershipLost
+			break LOOP
+
+		case <-summary:
+			if err := app.Positions.Write(os.Stdout, options.Output); err != nil {
+				logger.Error().Err(err).Msg("Could not write the positions")
+			}
+		}
+	}
+
+	steps := []shutdown.Step{
+		shutdown.Stop("log out the sessions", bridge.Stop),
+	}
+	if optionPositions {
+		steps = append(steps, shutdown.Step{Name: "write the positions", Run: func() error {
+			return app.Positions.Write(os.Stdout, options.Output)
+		}})
+	}
+	if len(optionNatsURL) > 0 || len(optionMappingDSN) > 0 {
+		steps = append(steps, shutdown.Stop("close the bridge cluster and order mapping", app.Close))
+	}
+	if elector != nil {
+		steps = append(steps, shutdown.Step{Name: "resign the leadership", Run: elector.Resign})
+	}
 
-	interrupt := make(chan os.Signal, 1)
-	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	return errors.Join(err, shutdown.Run(logger, optionShutdownTimeout, steps...))
+}
+
+// routes returns the routes of the routing rules of the context.
+func routes(context *config.Context) ([]*application.BridgeRoute, error) {
+	var routes []*application.BridgeRoute
+	for i, rule := range context.Routing.Rules {
+		route := &application.BridgeRoute{
+			Name:       rule.Name,
+			Symbols:    rule.Symbols,
+			Accounts:   rule.Accounts,
+			Parties:    rule.Parties,
+			RoundRobin: rule.Strategy == config.RoutingStrategyRoundRobin,
+		}
+		if len(route.Name) == 0 {
+			route.Name = "#" + strconv.Itoa(i+1)
+		}
+		if rule.ToExchange != nil {
+			transform := application.BridgeTransform(*rule.ToExchange)
+			route.ToExchange = &transform
+		}
+		if rule.ToClient != nil {
+			transform := application.BridgeTransform(*rule.ToClient)
+			route.ToClient = &transform
+		}
 
-	<-interrupt
-	bridge.Stop()
-	os.Exit(0)
+		for _, name := range rule.Sessions {
+			session, err := context.GetSession(name)
+			if err != nil {
+				return nil, fmt.Errorf("%w: routing rule %s session %s", err, route.Name, name)
+			}
+			if session.BeginString == quickfix.BeginStringFIXT11 {
+				return nil, fmt.Errorf("%w: routing rule %s session %s is a client session", errors.Config, route.Name, name)
+			}
+			route.Sessions = append(route.Sessions, session.AcceptorSessionID())
+		}
+
+		routes = append(routes, route)
+	}
 
-	return nil
+	return routes, nil
 }
@@ -3,9 +3,7 @@ package amendorder
 import (
 	"fmt"
 	"os"
-	"os/signal"
 	"strings"
-	"syscall"
 	"time"
 
 	"github.com/google/uuid"
@@ -17,6 +15,7 @@ import (
 	"github.com/quickfixgo/field"
 	"github.com/quickfixgo/fixt11"
 	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/quickfix/datadictionary"
 	"github.com/quickfixgo/tag"
 
 	"sylr.dev/fix/config"
@@ -26,6 +25,8 @@ import (
 	"sylr.dev/fix/pkg/errors"
 	"sylr.dev/fix/pkg/initiator"
 	"sylr.dev/fix/pkg/initiator/application"
+	"sylr.dev/fix/pkg/risk"
+	"sylr.dev/fix/pkg/sessiond"
 	"sylr.dev/fix/pkg/utils"
 )
 
@@ -42,6 +43,7 @@ var (
 	optionExecReportsTimeout         time.Duration
 	optionExecReportsTimeoutReset    bool
 	optionStopOnFinalState           bool
+	optionSocket                     string
 )
 
 var AmendOrderCmd = &cobra.Command{
@@ -72,6 +74,7 @@ func init() {
 	AmendOrderCmd.Flags().BoolVar(&optionExecReportsTimeoutReset, "exec-reports-timeout-reset", false, "Reset execution reports timeout each time an execution report is received")
 
 	AmendOrderCmd.Flags().BoolVar(&optionStopOnFinalState, "stop-on-final-state", false, "Stop application when receiving an order with a final state")
+	AmendOrderCmd.Flags().StringVar(&optionSocket, "socket", "", "Unix socket of the session served by `fix session hold --serve`, used when expecting a single execution report (default $HOME/.fix/sessions/<context>.sock if it exists)")
 
 	AmendOrderCmd.MarkFlagRequired("side")
 	AmendOrderCmd.MarkFlagRequired("type")
@@ -81,7 +84,7 @@ func init() {
 	AmendOrderCmd.RegisterFlagCompletionFunc("side", complete.OrderSide)
 	AmendOrderCmd.RegisterFlagCompletionFunc("type", complete.OrderType)
 	AmendOrderCmd.RegisterFlagCompletionFunc("expiry", complete.OrderTimeInForce)
-	AmendOrderCmd.RegisterFlagCompletionFunc("symbol", cobra.NoFileCompletions)
+	AmendOrderCmd.RegisterFlagCompletionFunc("symbol", complete.Symbol)
 }
 
 func Validate(cmd *cobra.Command, args []string) error {
@@ -129,6 +132,7 @@ func Execute(cmd *cobra.Command, args []string) error {
 	}
 
 	session := sessions[0]
+	logger = session.GetLogger()
 	initiatorConfig, err := context.GetInitiator()
 	if err != nil {
 		return err
@@ -149,6 +153,8 @@ func Execute(cmd *cobra.Command, args []string) error {
 	app.Settings = settings
 	app.TransportDataDictionary = transportDict
 	app.AppDataDictionary = appDict
+	app.OutputFormat = options.Output
+	app.Resumable = initiatorConfig.Reconnect.OnDisconnect
 
 	var quickfixLogger *zerolog.Logger
 	if options.QuickFixLogging {
@@ -165,47 +171,61 @@ func Execute(cmd *cobra.Command, args []string) error {
 		timeout = 5 * time.Second
 	}
 
-	init, err := initiator.Initiate(app, settings, quickfixLogger)
-	if err != nil {
-		return err
-	}
-
-	// Start session
-	if err = init.Start(); err != nil {
-		return err
+	// The session held by `fix session hold --serve` spares the logon, its
+	// requests being answered by a single message
+	var client *sessiond.Client
+	if optionExecReports == 1 {
+		if client, err = sessiond.Served(optionSocket, context.Name, settings); err != nil {
+			return err
+		}
 	}
 
-	defer func() {
-		app.Stop()
-		init.Stop()
-	}()
-
-	// Wait for session connection
 	var sessionId quickfix.SessionID
-	var ok bool
-	select {
-	case <-time.After(timeout):
-		return errors.ConnectionTimeout
-	case sessionId, ok = <-app.Connected:
-		if !ok {
-			return errors.FixLogout
+	if client != nil {
+		defer func() {
+			app.Stop()
+			client.Close()
+		}()
+		sessionId = client.SessionID()
+	} else {
+		var init *quickfix.Initiator
+		init, sessionId, err = initiator.Connect(cmd.Context(), app, settings, quickfixLogger, app.Connected, timeout, initiatorConfig.Reconnect)
+		if err != nil {
+			app.Stop()
+			return err
 		}
+
+		defer func() {
+			app.Stop()
+			init.Stop()
+		}()
+	}
+
+	// The risk limits may need the market price of the symbol
+	priced := strings.ToLower(optionOrderType) != "market"
+	if err := risk.SessionChecker(sessionId).RequestReferencePrice(cmd.Context(), *session, sessionId, optionOrderSymbol, priced, timeout); err != nil {
+		return err
 	}
 
 	// Prepare order
-	order, err := buildMessage(*session)
+	order, err := buildMessage(*session, appDict)
 	if err != nil {
 		return err
 	}
 
 	// Send the order
-	err = quickfix.SendToTarget(order, sessionId)
+	if client != nil {
+		err = client.Exchange(app, order, optionExecReportsTimeout, transportDict, appDict)
+	} else {
+		err = quickfix.SendToTarget(order, sessionId)
+	}
 	if err != nil {
 		return err
 	}
 
-	interrupt := make(chan os.Signal, 1)
-	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	ids := []string{optionClOrdID, optionOrigClOrdID, optionOrderID}
+
+	ctx := cmd.Context()
 
 	execReports := 0
 	var waitTimeout <-chan time.Time
@@ -218,19 +238,33 @@ func Execute(cmd *cobra.Command, args []string) error {
 LOOP:
 	for {
 		select {
-		case signal := <-interrupt:
-			logger.Debug().Msgf("Received signal: %s", signal)
+		case <-ctx.Done():
+			logger.Debug().Msg("Interrupted")
 			break LOOP
 
 		case <-waitTimeout:
+			if execReports == 0 {
+				return fmt.Errorf("%w: expecting execution reports", errors.ResponseTimeout)
+			}
 			logger.Warn().Msgf("Timeout while expecting execution reports (%d/%d)", execReports, optionExecReports)
 			break LOOP
 
+		case <-app.Disconnected:
+			sessionId, err = initiator.Reconnect(cmd.Context(), app.Connected, app.Disconnected, timeout, initiatorConfig.Reconnect)
+			if err != nil {
+				return err
+			}
+
 		case msg, ok := <-app.FromAppMessages:
 			if !ok {
 				break LOOP
 			}
 
+			// Ignore the responses to other requests
+			if !utils.QuickFixMessageCorrelates(msg, ids...) {
+				continue LOOP
+			}
+
 			if err := processResponse(app, msg); err != nil {
 				if errors.Is(err, quickfix.InvalidMessageType()) {
 					continue LOOP
@@ -260,7 +294,7 @@ LOOP:
 	return nil
 }
 
-func buildMessage(session config.Session) (quickfix.Messagable, error) {
+func buildMessage(session config.Session, appDict *datadictionary.DataDictionary) (quickfix.Messagable, error) {
 	eSide, err := dict.OrderSideStringToEnum(optionOrderSide)
 	if err != nil {
 		return nil, err
@@ -297,8 +331,8 @@ func buildMessage(session config.Session) (quickfix.Messagable, error) {
 			message.Body.Set(field.NewOrdType(eType))
 			message.Body.Set(field.NewTimeInForce(eExpiry))
 			message.Body.Set(field.NewSymbol(optionOrderSymbol))
-			message.Body.Set(field.NewOrderQty(decimal.NewFromInt(optionOrderQuantity), 2))
-			message.Body.Set(field.NewPrice(decimal.NewFromFloat(optionOrderPrice), 2))
+			message.Body.Set(field.NewOrderQty(utils.QuickFixDecimal(appDict, tag.OrderQty, optionOrderSymbol, decimal.NewFromInt(optionOrderQuantity))))
+			message.Body.Set(field.NewPrice(utils.QuickFixDecimal(appDict, tag.Price, optionOrderSymbol, decimal.NewFromFloat(optionOrderPrice))))
 			partyIdOptions.EnrichMessageBody(&message.Body, session)
 
 		default:
@@ -335,9 +369,9 @@ func processResponse(app *application.NewOrder, msg *quickfix.Message) error {
 	err := msg.Header.GetField(tag.MsgType, &msgType)
 	if err != nil {
 		return err
-	} else if msgType.Value() == enum.MsgType_REJECT ||
-		msgType.Value() == enum.MsgType_BUSINESS_MESSAGE_REJECT ||
-		msgType.Value() == enum.MsgType_ORDER_CANCEL_REJECT {
+	} else if err := utils.QuickFixRejectError(msg, errors.FixOrderRejected); err != nil {
+		return err
+	} else if msgType.Value() == enum.MsgType_ORDER_CANCEL_REJECT {
 		return makeError(errors.FixOrderRejected)
 	} else if msgType.Value() != enum.MsgType_EXECUTION_REPORT {
 		return quickfix.InvalidMessageType()
@@ -349,7 +383,7 @@ func processResponse(app *application.NewOrder, msg *quickfix.Message) error {
 		return err
 	}
 
-	app.WriteMessageBodyAsTable(os.Stdout, msg)
+	app.WriteMessage(os.Stdout, msg)
 	switch ordStatus.Value() {
 	case enum.OrdStatus_NEW:
 		break
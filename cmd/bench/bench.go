@@ -0,0 +1,47 @@
+package bench
+
+import (
+	"github.com/spf13/cobra"
+
+	benchload "sylr.dev/fix/cmd/bench/load"
+	benchorder "sylr.dev/fix/cmd/bench/order"
+	"sylr.dev/fix/pkg/health"
+	"sylr.dev/fix/pkg/initiator"
+	"sylr.dev/fix/pkg/utils"
+)
+
+var BenchCmd = &cobra.Command{
+	Use:   "bench",
+	Short: "Benchmark a FIX acceptor",
+	Long:  "Measure the latency and the throughput of a FIX acceptor after initiating a session with it.",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := utils.ValidateRequiredFlags(cmd); err != nil {
+			return err
+		}
+
+		if err := initiator.ValidateOptions(cmd, args); err != nil {
+			return err
+		}
+
+		if cmd.HasParent() {
+			parent := cmd.Parent()
+			if parent.PersistentPreRunE != nil {
+				return parent.PersistentPreRunE(parent, args)
+			}
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	initiator.AddPersistentFlags(BenchCmd)
+	initiator.AddPersistentFlagCompletions(BenchCmd)
+	initiator.AddPersistentFlagCompletions(benchload.BenchLoadCmd)
+	initiator.AddPersistentFlagCompletions(benchorder.BenchOrderCmd)
+	health.AddMetricsFlags(benchload.BenchLoadCmd)
+	initiator.AddExitOnDisconnectFlag(benchload.BenchLoadCmd)
+
+	BenchCmd.AddCommand(benchload.BenchLoadCmd)
+	BenchCmd.AddCommand(benchorder.BenchOrderCmd)
+}
@@ -0,0 +1,20 @@
+//go:build !unix
+
+package benchload
+
+import (
+	"runtime/metrics"
+)
+
+// cpuSeconds returns the CPU time consumed by the process as estimated by the
+// Go runtime, which only updates it at each garbage collection.
+func cpuSeconds() float64 {
+	samples := []metrics.Sample{{Name: "/cpu/classes/total:cpu-seconds"}}
+	metrics.Read(samples)
+
+	if samples[0].Value.Kind() != metrics.KindFloat64 {
+		return 0
+	}
+
+	return samples[0].Value.Float64()
+}
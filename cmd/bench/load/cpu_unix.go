@@ -0,0 +1,17 @@
+//go:build unix
+
+package benchload
+
+import (
+	"syscall"
+)
+
+// cpuSeconds returns the user and system CPU time consumed by the process.
+func cpuSeconds() float64 {
+	var rusage syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &rusage); err != nil {
+		return 0
+	}
+
+	return float64(rusage.Utime.Nano()+rusage.Stime.Nano()) / 1e9
+}
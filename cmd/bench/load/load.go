@@ -0,0 +1,671 @@
+package benchload
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/olekukonko/tablewriter"
+	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
+	"github.com/spf13/cobra"
+
+	"github.com/quickfixgo/enum"
+	"github.com/quickfixgo/field"
+	"github.com/quickfixgo/fixt11"
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/quickfix/datadictionary"
+	"github.com/quickfixgo/tag"
+
+	"sylr.dev/fix/config"
+	"sylr.dev/fix/pkg/cli/complete"
+	"sylr.dev/fix/pkg/dict"
+	"sylr.dev/fix/pkg/errors"
+	"sylr.dev/fix/pkg/initiator"
+	"sylr.dev/fix/pkg/initiator/application"
+	"sylr.dev/fix/pkg/utils"
+)
+
+const (
+	ProfileConstant = "constant"
+	ProfileRamp     = "ramp"
+	ProfileBurst    = "burst"
+)
+
+var profiles = []string{ProfileConstant, ProfileRamp, ProfileBurst}
+
+var (
+	optionProfile                    string
+	optionDuration                   time.Duration
+	optionRate, optionStartRate      string
+	optionBurstSize                  int
+	optionBurstInterval              time.Duration
+	optionInterval                   time.Duration
+	optionOrderSide, optionOrderType string
+	optionOrderSymbols               []string
+	optionOrderQuantity              int64
+	optionOrderPrice                 float64
+	optionPriceJitter                float64
+	optionMDSymbols, optionMDTypes   []string
+	optionMDDepth                    int
+
+	rate, startRate float64
+)
+
+var BenchLoadCmd = &cobra.Command{
+	Use:     "load",
+	Aliases: []string{"orders"},
+	Short:   "Put a sustained load on an acceptor",
+	Long: "Send orders following a load profile while being subscribed to market data and report the " +
+		"throughput, the error rates and the resource usage every --interval and for the whole run.\n\n" +
+		"Profiles:\n" +
+		"  constant  --rate orders per second\n" +
+		"  ramp      from --start-rate to --rate orders per second over --duration\n" +
+		"  burst     --burst-size orders at once every --burst-interval\n\n" +
+		"Each order is for one of the --symbol picked at random, its price being randomized by up to " +
+		"--price-jitter of --price.",
+	Example: "  fix bench load --profile ramp --start-rate 10 --rate 500/s --duration 1m --side buy --type limit --symbol EURUSD --price 1.0 --md-symbol EURUSD\n" +
+		"  fix bench orders --rate 500/s --duration 60s --side buy --type limit --symbol EURUSD,GBPUSD --price 1.0 --price-jitter 0.01\n" +
+		"  fix bench load --profile burst --burst-size 200 --burst-interval 5s --side buy --type limit --symbol EURUSD --price 1.0",
+	Args:              cobra.ExactArgs(0),
+	ValidArgsFunction: cobra.NoFileCompletions,
+	PersistentPreRunE: utils.MakePersistentPreRunE(Validate),
+	RunE:              Execute,
+}
+
+func init() {
+	BenchLoadCmd.Flags().StringVar(&optionProfile, "profile", ProfileConstant, "Load profile (constant, ramp, burst)")
+	BenchLoadCmd.Flags().DurationVar(&optionDuration, "duration", 30*time.Second, "Duration of the run")
+	BenchLoadCmd.Flags().StringVar(&optionRate, "rate", "10", "Orders per second, or per minute with a /m suffix (final rate of the ramp profile)")
+	BenchLoadCmd.Flags().StringVar(&optionStartRate, "start-rate", "0", "Orders per second, or per minute with a /m suffix, at the beginning of the ramp profile")
+	BenchLoadCmd.Flags().IntVar(&optionBurstSize, "burst-size", 100, "Orders per burst of the burst profile")
+	BenchLoadCmd.Flags().DurationVar(&optionBurstInterval, "burst-interval", time.Second, "Interval between the bursts of the burst profile")
+	BenchLoadCmd.Flags().DurationVar(&optionInterval, "interval", 5*time.Second, "Interval between reports")
+
+	BenchLoadCmd.Flags().StringVar(&optionOrderSide, "side", "", "Order side (buy, sell ... etc)")
+	BenchLoadCmd.Flags().StringVar(&optionOrderType, "type", "", "Order type (market, limit, stop ... etc)")
+	BenchLoadCmd.Flags().StringSliceVar(&optionOrderSymbols, "symbol", nil, "Order symbols, each order being for one of them picked at random")
+	BenchLoadCmd.Flags().Int64Var(&optionOrderQuantity, "quantity", 1, "Order quantity")
+	BenchLoadCmd.Flags().Float64Var(&optionOrderPrice, "price", 0.0, "Order price")
+	BenchLoadCmd.Flags().Float64Var(&optionPriceJitter, "price-jitter", 0, "Fraction of --price each order price is randomized by (e.g. 0.01 for up to 1% above or below)")
+
+	BenchLoadCmd.Flags().StringSliceVar(&optionMDSymbols, "md-symbol", nil, "Symbols to subscribe to market data for during the run")
+	BenchLoadCmd.Flags().StringSliceVar(&optionMDTypes, "md-types", []string{"bid", "offer"}, "Market data entry types")
+	BenchLoadCmd.Flags().IntVar(&optionMDDepth, "md-depth", 0, "Market data depth (0 for full book)")
+
+	BenchLoadCmd.RegisterFlagCompletionFunc("rate", cobra.NoFileCompletions)
+	BenchLoadCmd.RegisterFlagCompletionFunc("start-rate", cobra.NoFileCompletions)
+	BenchLoadCmd.RegisterFlagCompletionFunc("profile", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return profiles, cobra.ShellCompDirectiveNoFileComp
+	})
+	BenchLoadCmd.RegisterFlagCompletionFunc("side", complete.OrderSide)
+	BenchLoadCmd.RegisterFlagCompletionFunc("type", complete.OrderType)
+	BenchLoadCmd.RegisterFlagCompletionFunc("symbol", complete.Symbol)
+	BenchLoadCmd.RegisterFlagCompletionFunc("md-symbol", complete.Symbol)
+	BenchLoadCmd.RegisterFlagCompletionFunc("md-types", complete.MDEntryTypes)
+}
+
+func Validate(cmd *cobra.Command, args []string) error {
+	if utils.Search(profiles, optionProfile) < 0 {
+		return fmt.Errorf("%w: unknown profile %s", errors.Options, optionProfile)
+	}
+	if optionDuration <= 0 {
+		return fmt.Errorf("%w: --duration must be greater than 0", errors.Options)
+	}
+	if optionInterval <= 0 {
+		return fmt.Errorf("%w: --interval must be greater than 0", errors.Options)
+	}
+
+	var err error
+	if rate, err = parseRate(optionRate); err != nil {
+		return fmt.Errorf("%w: invalid --rate `%s`", errors.Options, optionRate)
+	}
+	if startRate, err = parseRate(optionStartRate); err != nil {
+		return fmt.Errorf("%w: invalid --start-rate `%s`", errors.Options, optionStartRate)
+	}
+	if rate < 0 || startRate < 0 || optionBurstSize < 0 {
+		return fmt.Errorf("%w: rates and burst size can not be negative", errors.Options)
+	}
+	if optionProfile == ProfileBurst && optionBurstInterval <= 0 {
+		return fmt.Errorf("%w: --burst-interval must be greater than 0", errors.Options)
+	}
+
+	for _, t := range optionMDTypes {
+		if _, ok := dict.MDEntryTypes[strings.ToUpper(t)]; !ok {
+			return fmt.Errorf("%w: unknown market data entry type %s", errors.Options, t)
+		}
+	}
+
+	if !sendsOrders() {
+		if len(optionMDSymbols) == 0 {
+			return fmt.Errorf("%w: no orders to send and no market data to subscribe to", errors.Options)
+		}
+		return nil
+	}
+
+	if len(optionOrderSymbols) == 0 {
+		return errors.OptionsNoSymbolGiven
+	}
+	if optionPriceJitter < 0 || optionPriceJitter >= 1 {
+		return fmt.Errorf("%w: --price-jitter must be between 0 and 1", errors.Options)
+	}
+
+	sides := utils.PrettyOptionValues(dict.OrderSides)
+	search := utils.Search(sides, strings.ToLower(optionOrderSide))
+	if search < 0 {
+		return errors.OptionOrderSideUnknown
+	}
+
+	types := utils.PrettyOptionValues(dict.OrderTypes)
+	search = utils.Search(types, strings.ToLower(optionOrderType))
+	if search < 0 {
+		return errors.OptionOrderTypeUnknown
+	}
+
+	if strings.ToLower(optionOrderType) == "market" && optionOrderPrice > 0 {
+		return errors.OptionsInvalidMarketPrice
+	} else if strings.ToLower(optionOrderType) != "market" && optionOrderPrice == 0 {
+		return errors.OptionsNoPriceGiven
+	}
+
+	return nil
+}
+
+// parseRate parses a rate of orders per second, given as a number optionally
+// followed by /s, or by /m for a rate per minute.
+func parseRate(s string) (float64, error) {
+	per := 1.0
+	switch {
+	case strings.HasSuffix(s, "/m"):
+		per = 60
+		s = strings.TrimSuffix(s, "/m")
+	case strings.HasSuffix(s, "/s"):
+		s = strings.TrimSuffix(s, "/s")
+	}
+
+	r, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	return r / per, nil
+}
+
+// sendsOrders tells whether the profile sends any order.
+func sendsOrders() bool {
+	switch optionProfile {
+	case ProfileRamp:
+		return rate > 0 || startRate > 0
+	case ProfileBurst:
+		return optionBurstSize > 0
+	default:
+		return rate > 0
+	}
+}
+
+// due returns the number of orders which should have been sent after elapsed
+// according to the profile.
+func due(elapsed time.Duration) int {
+	t := elapsed.Seconds()
+
+	switch optionProfile {
+	case ProfileRamp:
+		// Integral of the rate growing linearly over the duration
+		d := optionDuration.Seconds()
+		return int(startRate*t + (rate-startRate)*t*t/(2*d))
+	case ProfileBurst:
+		return optionBurstSize * (int(elapsed/optionBurstInterval) + 1)
+	default:
+		return int(rate * t)
+	}
+}
+
+// targetRate returns the rate of orders the profile aims at after elapsed.
+func targetRate(elapsed time.Duration) float64 {
+	switch optionProfile {
+	case ProfileRamp:
+		return startRate + (rate-startRate)*elapsed.Seconds()/optionDuration.Seconds()
+	case ProfileBurst:
+		return float64(optionBurstSize) / optionBurstInterval.Seconds()
+	default:
+		return rate
+	}
+}
+
+// counters are the events of the run.
+type counters struct {
+	OrdersSent     int `json:"ordersSent"`
+	ExecReports    int `json:"execReports"`
+	Rejects        int `json:"rejects"`
+	MDUpdates      int `json:"mdUpdates"`
+	MDRejects      int `json:"mdRejects"`
+	SessionRejects int `json:"sessionRejects"`
+}
+
+func (c counters) sub(o counters) counters {
+	return counters{
+		OrdersSent:     c.OrdersSent - o.OrdersSent,
+		ExecReports:    c.ExecReports - o.ExecReports,
+		Rejects:        c.Rejects - o.Rejects,
+		MDUpdates:      c.MDUpdates - o.MDUpdates,
+		MDRejects:      c.MDRejects - o.MDRejects,
+		SessionRejects: c.SessionRejects - o.SessionRejects,
+	}
+}
+
+// usage is the resource usage of the process.
+type usage struct {
+	CPUSeconds float64 `json:"cpuSeconds"`
+	HeapBytes  uint64  `json:"heapBytes"`
+	SysBytes   uint64  `json:"sysBytes"`
+	Goroutines int     `json:"goroutines"`
+}
+
+func readUsage() usage {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	return usage{
+		CPUSeconds: cpuSeconds(),
+		HeapBytes:  mem.HeapAlloc,
+		SysBytes:   mem.Sys,
+		Goroutines: runtime.NumGoroutine(),
+	}
+}
+
+// report is the outcome of an interval or of the whole run.
+type report struct {
+	Elapsed         time.Duration        `json:"elapsed"`
+	TargetRate      float64              `json:"targetRate"`
+	Counters        counters             `json:"counters"`
+	OrdersPerSecond float64              `json:"ordersPerSecond"`
+	MDPerSecond     float64              `json:"mdUpdatesPerSecond"`
+	ErrorRate       float64              `json:"errorRate"`
+	Outstanding     int                  `json:"outstanding"`
+	CPU             float64              `json:"cpu"`
+	Usage           usage                `json:"usage"`
+	Latency         utils.LatencySummary `json:"latency"`
+	Final           bool                 `json:"final,omitempty"`
+}
+
+func newReport(elapsed, period time.Duration, c counters, cpuSeconds float64, u usage, outstanding int, latencies utils.Latencies) report {
+	r := report{
+		Elapsed:     elapsed,
+		TargetRate:  targetRate(elapsed),
+		Counters:    c,
+		Outstanding: outstanding,
+		Usage:       u,
+		Latency:     latencies.Summary(),
+	}
+
+	if s := period.Seconds(); s > 0 {
+		r.OrdersPerSecond = float64(c.OrdersSent) / s
+		r.MDPerSecond = float64(c.MDUpdates) / s
+		r.CPU = cpuSeconds / s
+	}
+	if requests := c.OrdersSent + len(optionMDSymbols); requests > 0 {
+		r.ErrorRate = float64(c.Rejects+c.MDRejects+c.SessionRejects) / float64(requests)
+	}
+
+	return r
+}
+
+func Execute(cmd *cobra.Command, args []string) error {
+	options := config.GetOptions()
+	logger := config.GetLogger()
+
+	context, err := config.GetCurrentContext()
+	if err != nil {
+		return err
+	}
+
+	sessions, err := context.GetSessions()
+	if err != nil {
+		return err
+	}
+
+	session := sessions[0]
+	logger = session.GetLogger()
+	initiatorConfig, err := context.GetInitiator()
+	if err != nil {
+		return err
+	}
+
+	if session.BeginString != quickfix.BeginStringFIXT11 || session.DefaultApplVerID != "FIX.5.0SP2" {
+		return errors.FixVersionNotImplemented
+	}
+
+	transportDict, appDict, err := session.GetFIXDictionaries()
+	if err != nil {
+		return err
+	}
+
+	settings, err := context.ToQuickFixInitiatorSettings()
+	if err != nil {
+		return err
+	}
+
+	// Logging every message would skew the measurements
+	appLogger := logger
+	if options.Verbose == 0 && logger.GetLevel() < zerolog.WarnLevel {
+		l := logger.Level(zerolog.WarnLevel)
+		appLogger = &l
+	}
+
+	app := application.NewInitiator()
+	app.Logger = appLogger
+	app.Settings = settings
+	app.TransportDataDictionary = transportDict
+	app.AppDataDictionary = appDict
+	app.OutputFormat = options.Output
+
+	var quickfixLogger *zerolog.Logger
+	if options.QuickFixLogging {
+		quickfixLogger = logger
+	}
+
+	// Choose right timeout cli option > config > default value (5s)
+	var timeout time.Duration
+	if options.Timeout != time.Duration(0) {
+		timeout = options.Timeout
+	} else if initiatorConfig.SocketTimeout != time.Duration(0) {
+		timeout = initiatorConfig.SocketTimeout
+	} else {
+		timeout = 5 * time.Second
+	}
+
+	init, sessionId, err := initiator.Connect(cmd.Context(), app, settings, quickfixLogger, app.Connected, timeout, initiatorConfig.Reconnect)
+	if err != nil {
+		app.Stop()
+		return err
+	}
+
+	defer func() {
+		app.Stop()
+		init.Stop()
+	}()
+
+	// Messages sent are not needed
+	go func() {
+		for range app.ToAppMessages {
+		}
+	}()
+
+	ctx := cmd.Context()
+
+	var total, previous counters
+	var latencies, intervalLatencies utils.Latencies
+	outstanding := make(map[string]time.Time)
+
+	// Market data subscriptions last for the whole run
+	var mdReqIDs []string
+	for _, symbol := range optionMDSymbols {
+		mdReqID := uuid.NewString()
+		if err := quickfix.SendToTarget(buildMarketDataRequest(mdReqID, symbol, enum.SubscriptionRequestType_SNAPSHOT_PLUS_UPDATES), sessionId); err != nil {
+			return err
+		}
+		mdReqIDs = append(mdReqIDs, mdReqID)
+	}
+	defer func() {
+		for i, mdReqID := range mdReqIDs {
+			_ = quickfix.SendToTarget(buildMarketDataRequest(mdReqID, optionMDSymbols[i], enum.SubscriptionRequestType_DISABLE_PREVIOUS_SNAPSHOT_PLUS_UPDATE_REQUEST), sessionId)
+		}
+	}()
+
+	start := time.Now()
+	startUsage := readUsage()
+	lastReport, lastUsage := start, startUsage
+
+	tick := time.NewTicker(10 * time.Millisecond)
+	defer tick.Stop()
+	reportTick := time.NewTicker(optionInterval)
+	defer reportTick.Stop()
+	end := time.After(optionDuration)
+
+	if options.Output != utils.OutputFormatJSON {
+		fmt.Printf("%8s %9s %9s %9s %9s %8s %9s %7s %6s %9s %9s\n", "ELAPSED", "TARGET/S", "ORDERS/S", "ACKS", "REJECTS", "ERRORS", "MD/S", "ERROR%", "CPU%", "HEAP", "P99")
+	}
+
+	writeReport := func(r report) error {
+		if options.Output == utils.OutputFormatJSON {
+			return json.NewEncoder(os.Stdout).Encode(r)
+		}
+
+		fmt.Printf("%8s %9.1f %9.1f %9d %9d %8d %9.1f %7.2f %6.1f %9s %9s\n",
+			r.Elapsed.Round(time.Second),
+			r.TargetRate,
+			r.OrdersPerSecond,
+			r.Counters.ExecReports,
+			r.Counters.Rejects,
+			r.Counters.MDRejects+r.Counters.SessionRejects,
+			r.MDPerSecond,
+			100*r.ErrorRate,
+			100*r.CPU,
+			formatBytes(r.Usage.HeapBytes),
+			utils.FormatLatency(r.Latency.P99),
+		)
+
+		return nil
+	}
+
+	var loopErr error
+
+LOOP:
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Debug().Msg("Interrupted")
+			break LOOP
+
+		case <-end:
+			break LOOP
+
+		case now := <-tick.C:
+			if !sendsOrders() {
+				continue
+			}
+			for n := due(now.Sub(start)) - total.OrdersSent; n > 0; n-- {
+				clOrdID := uuid.NewString()
+				order, err := buildOrderMessage(appDict, clOrdID)
+				if err != nil {
+					return err
+				}
+				outstanding[clOrdID] = time.Now()
+				if err := quickfix.SendToTarget(order, sessionId); err != nil {
+					return err
+				}
+				total.OrdersSent++
+			}
+
+		case now := <-reportTick.C:
+			u := readUsage()
+			r := newReport(now.Sub(start), now.Sub(lastReport), total.sub(previous), u.CPUSeconds-lastUsage.CPUSeconds, u, len(outstanding), intervalLatencies)
+			if err := writeReport(r); err != nil {
+				return err
+			}
+			previous, lastReport, lastUsage = total, now, u
+			intervalLatencies = nil
+
+		case msg, ok := <-app.FromAppMessages:
+			if !ok {
+				loopErr = errors.FixLogout
+				break LOOP
+			}
+			received := time.Now()
+
+			msgType, _ := msg.MsgType()
+			switch enum.MsgType(msgType) {
+			case enum.MsgType_EXECUTION_REPORT:
+				id, _ := msg.Body.GetString(tag.ClOrdID)
+				sent, ok := outstanding[id]
+				if !ok {
+					continue
+				}
+				delete(outstanding, id)
+				latencies = append(latencies, received.Sub(sent))
+				intervalLatencies = append(intervalLatencies, received.Sub(sent))
+
+				status, _ := msg.Body.GetString(tag.OrdStatus)
+				if enum.OrdStatus(status) == enum.OrdStatus_REJECTED {
+					total.Rejects++
+				} else {
+					total.ExecReports++
+				}
+
+			case enum.MsgType_MARKET_DATA_SNAPSHOT_FULL_REFRESH, enum.MsgType_MARKET_DATA_INCREMENTAL_REFRESH:
+				total.MDUpdates++
+
+			case enum.MsgType_MARKET_DATA_REQUEST_REJECT:
+				total.MDRejects++
+
+			case enum.MsgType_REJECT, enum.MsgType_BUSINESS_MESSAGE_REJECT:
+				total.SessionRejects++
+			}
+		}
+	}
+
+	now := time.Now()
+	u := readUsage()
+	r := newReport(now.Sub(start), now.Sub(start), total, u.CPUSeconds-startUsage.CPUSeconds, u, len(outstanding), latencies)
+	r.Final = true
+
+	if options.Output == utils.OutputFormatJSON {
+		if err := json.NewEncoder(os.Stdout).Encode(r); err != nil {
+			return err
+		}
+	} else {
+		fmt.Println()
+		writeSummary(r)
+	}
+
+	return loopErr
+}
+
+func writeSummary(r report) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"METRIC", "VALUE"})
+	table.SetBorders(tablewriter.Border{Left: false, Top: false, Right: false, Bottom: true})
+	table.SetColumnSeparator(" ")
+	table.SetCenterSeparator("-")
+	table.SetAutoWrapText(false)
+	table.SetColumnAlignment([]int{tablewriter.ALIGN_LEFT, tablewriter.ALIGN_RIGHT})
+
+	table.AppendBulk([][]string{
+		{"Duration", r.Elapsed.Round(time.Millisecond).String()},
+		{"Orders sent", fmt.Sprint(r.Counters.OrdersSent)},
+		{"Orders/s", fmt.Sprintf("%.1f", r.OrdersPerSecond)},
+		{"Execution reports", fmt.Sprint(r.Counters.ExecReports)},
+		{"Rejected orders", fmt.Sprint(r.Counters.Rejects)},
+		{"Outstanding orders", fmt.Sprint(r.Outstanding)},
+		{"Market data updates", fmt.Sprint(r.Counters.MDUpdates)},
+		{"Market data updates/s", fmt.Sprintf("%.1f", r.MDPerSecond)},
+		{"Market data rejects", fmt.Sprint(r.Counters.MDRejects)},
+		{"Session/business rejects", fmt.Sprint(r.Counters.SessionRejects)},
+		{"Error rate", fmt.Sprintf("%.2f%%", 100*r.ErrorRate)},
+		{"Ack latency p50", utils.FormatLatency(r.Latency.P50)},
+		{"Ack latency p95", utils.FormatLatency(r.Latency.P95)},
+		{"Ack latency p99", utils.FormatLatency(r.Latency.P99)},
+		{"CPU", fmt.Sprintf("%.1f%%", 100*r.CPU)},
+		{"Heap", formatBytes(r.Usage.HeapBytes)},
+		{"Memory obtained from the OS", formatBytes(r.Usage.SysBytes)},
+		{"Goroutines", fmt.Sprint(r.Usage.Goroutines)},
+	})
+
+	table.Render()
+}
+
+func formatBytes(b uint64) string {
+	if b < 1024 {
+		return fmt.Sprintf("%dB", b)
+	}
+
+	exp := int(math.Log(float64(b)) / math.Log(1024))
+	if exp > 4 {
+		exp = 4
+	}
+
+	return fmt.Sprintf("%.1f%ciB", float64(b)/math.Pow(1024, float64(exp)), "KMGT"[exp-1])
+}
+
+func buildOrderMessage(appDict *datadictionary.DataDictionary, clOrdID string) (quickfix.Messagable, error) {
+	eside, err := dict.OrderSideStringToEnum(optionOrderSide)
+	if err != nil {
+		return nil, err
+	}
+
+	etype, err := dict.OrderTypeStringToEnum(optionOrderType)
+	if err != nil {
+		return nil, err
+	}
+
+	message := quickfix.NewMessage()
+	header := fixt11.NewHeader(&message.Header)
+	header.Set(field.NewMsgType(enum.MsgType_ORDER_SINGLE))
+	message.Body.Set(field.NewClOrdID(clOrdID))
+	message.Body.Set(field.NewSide(eside))
+	message.Body.Set(field.NewTransactTime(time.Now()))
+	message.Body.Set(field.NewOrdType(etype))
+	symbol := optionOrderSymbols[rand.Intn(len(optionOrderSymbols))]
+
+	message.Body.Set(field.NewSymbol(symbol))
+	message.Body.Set(field.NewOrderQty(utils.QuickFixDecimal(appDict, tag.OrderQty, symbol, decimal.NewFromInt(optionOrderQuantity))))
+	message.Body.Set(field.NewTimeInForce(enum.TimeInForce_DAY))
+
+	if etype != enum.OrdType_MARKET {
+		message.Body.Set(field.NewPrice(utils.QuickFixDecimal(appDict, tag.Price, symbol, jitteredPrice())))
+	}
+
+	return message, nil
+}
+
+// jitteredPrice returns --price randomized by up to --price-jitter of it, with
+// enough decimals for the jitter to take a hundred steps.
+func jitteredPrice() decimal.Decimal {
+	if optionPriceJitter == 0 {
+		return decimal.NewFromFloat(optionOrderPrice)
+	}
+
+	price := optionOrderPrice * (1 + optionPriceJitter*(2*rand.Float64()-1))
+	scale := int32(math.Ceil(-math.Log10(optionOrderPrice*optionPriceJitter))) + 2
+
+	return decimal.NewFromFloat(price).Round(scale)
+}
+
+func buildMarketDataRequest(mdReqID, symbol string, subType enum.SubscriptionRequestType) *quickfix.Message {
+	message := quickfix.NewMessage()
+	header := fixt11.NewHeader(&message.Header)
+	header.Set(field.NewMsgType(enum.MsgType_MARKET_DATA_REQUEST))
+	message.Body.Set(field.NewMDReqID(mdReqID))
+	message.Body.Set(field.NewSubscriptionRequestType(subType))
+	message.Body.Set(field.NewMarketDepth(optionMDDepth))
+	message.Body.Set(field.NewMDUpdateType(enum.MDUpdateType_INCREMENTAL_REFRESH))
+
+	entryTypes := quickfix.NewRepeatingGroup(
+		tag.NoMDEntryTypes,
+		quickfix.GroupTemplate{
+			quickfix.GroupElement(tag.MDEntryType),
+		},
+	)
+	for _, t := range optionMDTypes {
+		entryTypes.Add().Set(field.NewMDEntryType(dict.MDEntryTypes[strings.ToUpper(t)]))
+	}
+	message.Body.SetGroup(entryTypes)
+
+	relatedSym := quickfix.NewRepeatingGroup(
+		tag.NoRelatedSym,
+		quickfix.GroupTemplate{
+			quickfix.GroupElement(tag.Symbol),
+		},
+	)
+	relatedSym.Add().Set(field.NewSymbol(symbol))
+	message.Body.SetGroup(relatedSym)
+
+	return message
+}
@@ -0,0 +1,479 @@
+package benchorder
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/olekukonko/tablewriter"
+	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
+	"github.com/spf13/cobra"
+
+	"github.com/quickfixgo/enum"
+	"github.com/quickfixgo/field"
+	"github.com/quickfixgo/fixt11"
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/quickfix/datadictionary"
+	"github.com/quickfixgo/tag"
+
+	"sylr.dev/fix/config"
+	"sylr.dev/fix/pkg/cli/complete"
+	"sylr.dev/fix/pkg/dict"
+	"sylr.dev/fix/pkg/errors"
+	"sylr.dev/fix/pkg/initiator"
+	"sylr.dev/fix/pkg/initiator/application"
+	"sylr.dev/fix/pkg/utils"
+)
+
+var (
+	optionOrderSide, optionOrderType string
+	optionOrderSymbol                string
+	optionOrderExpiry                string
+	optionOrderQuantity              int64
+	optionOrderPrice                 float64
+	optionCount                      int
+	optionWarmup                     int
+	optionConcurrency                int
+	optionCancel                     bool
+	optionExecReportsTimeout         time.Duration
+	optionHistogramBuckets           int
+)
+
+var BenchOrderCmd = &cobra.Command{
+	Use:   "order",
+	Short: "Measure the order round-trip latency",
+	Long: "Send orders and measure the time between sending each of them and receiving its first " +
+		"execution report. With --cancel, each order is canceled as soon as it is acknowledged and " +
+		"the cancel round-trip is measured as well. The first --warmup orders are not measured.",
+	Example:           "  fix bench order --side buy --type limit --symbol EURUSD --price 1.0 --count 1000 --concurrency 10 --cancel",
+	Args:              cobra.ExactArgs(0),
+	ValidArgsFunction: cobra.NoFileCompletions,
+	PersistentPreRunE: utils.MakePersistentPreRunE(Validate),
+	RunE:              Execute,
+}
+
+func init() {
+	BenchOrderCmd.Flags().StringVar(&optionOrderSide, "side", "", "Order side (buy, sell ... etc)")
+	BenchOrderCmd.Flags().StringVar(&optionOrderType, "type", "", "Order type (market, limit, stop ... etc)")
+	BenchOrderCmd.Flags().StringVar(&optionOrderSymbol, "symbol", "", "Order symbol")
+	BenchOrderCmd.Flags().Int64Var(&optionOrderQuantity, "quantity", 1, "Order quantity")
+	BenchOrderCmd.Flags().StringVar(&optionOrderExpiry, "expiry", "day", "Order expiry (day, good_till_cancel ... etc)")
+	BenchOrderCmd.Flags().Float64Var(&optionOrderPrice, "price", 0.0, "Order price")
+
+	BenchOrderCmd.Flags().IntVar(&optionCount, "count", 100, "Number of orders measured")
+	BenchOrderCmd.Flags().IntVar(&optionWarmup, "warmup", 10, "Number of orders sent before measuring")
+	BenchOrderCmd.Flags().IntVar(&optionConcurrency, "concurrency", 1, "Number of orders awaiting their execution report at the same time")
+	BenchOrderCmd.Flags().BoolVar(&optionCancel, "cancel", false, "Cancel each order once acknowledged")
+	BenchOrderCmd.Flags().DurationVar(&optionExecReportsTimeout, "exec-reports-timeout", 5*time.Second, "Give up on an order if its execution report is not received within timeout")
+	BenchOrderCmd.Flags().IntVar(&optionHistogramBuckets, "buckets", 10, "Number of buckets of the latency histograms")
+
+	BenchOrderCmd.MarkFlagRequired("side")
+	BenchOrderCmd.MarkFlagRequired("type")
+	BenchOrderCmd.MarkFlagRequired("symbol")
+
+	BenchOrderCmd.RegisterFlagCompletionFunc("side", complete.OrderSide)
+	BenchOrderCmd.RegisterFlagCompletionFunc("type", complete.OrderType)
+	BenchOrderCmd.RegisterFlagCompletionFunc("expiry", complete.OrderTimeInForce)
+	BenchOrderCmd.RegisterFlagCompletionFunc("symbol", complete.Symbol)
+}
+
+func Validate(cmd *cobra.Command, args []string) error {
+	sides := utils.PrettyOptionValues(dict.OrderSides)
+	search := utils.Search(sides, strings.ToLower(optionOrderSide))
+	if search < 0 {
+		return errors.OptionOrderSideUnknown
+	}
+
+	types := utils.PrettyOptionValues(dict.OrderTypes)
+	search = utils.Search(types, strings.ToLower(optionOrderType))
+	if search < 0 {
+		return errors.OptionOrderTypeUnknown
+	}
+
+	if strings.ToLower(optionOrderType) == "market" && optionOrderPrice > 0 {
+		return errors.OptionsInvalidMarketPrice
+	} else if strings.ToLower(optionOrderType) != "market" && optionOrderPrice == 0 {
+		return errors.OptionsNoPriceGiven
+	}
+
+	if optionCount < 1 {
+		return fmt.Errorf("%w: --count must be greater than 0", errors.Options)
+	}
+	if optionWarmup < 0 {
+		return fmt.Errorf("%w: --warmup can not be negative", errors.Options)
+	}
+	if optionConcurrency < 1 {
+		return fmt.Errorf("%w: --concurrency must be greater than 0", errors.Options)
+	}
+	if optionExecReportsTimeout <= 0 {
+		return fmt.Errorf("%w: --exec-reports-timeout must be greater than 0", errors.Options)
+	}
+
+	return nil
+}
+
+const (
+	phaseNew    = "new order"
+	phaseCancel = "cancel"
+)
+
+// pending is an order or a cancel awaiting its execution report.
+type pending struct {
+	clOrdID string
+	seqNum  int
+	phase   string
+	sent    time.Time
+	measure bool
+}
+
+// measurements are the outcomes of one of the phases.
+type measurements struct {
+	Latencies utils.Latencies
+	Rejected  int
+	Timeouts  int
+}
+
+type jsonPhase struct {
+	Phase     string                `json:"phase"`
+	Rejected  int                   `json:"rejected"`
+	Timeouts  int                   `json:"timeouts"`
+	Latency   utils.LatencySummary  `json:"latency"`
+	Histogram []utils.LatencyBucket `json:"histogram"`
+}
+
+func Execute(cmd *cobra.Command, args []string) error {
+	options := config.GetOptions()
+	logger := config.GetLogger()
+
+	context, err := config.GetCurrentContext()
+	if err != nil {
+		return err
+	}
+
+	sessions, err := context.GetSessions()
+	if err != nil {
+		return err
+	}
+
+	session := sessions[0]
+	logger = session.GetLogger()
+	initiatorConfig, err := context.GetInitiator()
+	if err != nil {
+		return err
+	}
+
+	transportDict, appDict, err := session.GetFIXDictionaries()
+	if err != nil {
+		return err
+	}
+
+	settings, err := context.ToQuickFixInitiatorSettings()
+	if err != nil {
+		return err
+	}
+
+	// Logging every message would skew the measurements
+	appLogger := logger
+	if options.Verbose == 0 && logger.GetLevel() < zerolog.WarnLevel {
+		l := logger.Level(zerolog.WarnLevel)
+		appLogger = &l
+	}
+
+	app := application.NewInitiator()
+	app.Logger = appLogger
+	app.Settings = settings
+	app.TransportDataDictionary = transportDict
+	app.AppDataDictionary = appDict
+	app.OutputFormat = options.Output
+
+	var quickfixLogger *zerolog.Logger
+	if options.QuickFixLogging {
+		quickfixLogger = logger
+	}
+
+	// Choose right timeout cli option > config > default value (5s)
+	var timeout time.Duration
+	if options.Timeout != time.Duration(0) {
+		timeout = options.Timeout
+	} else if initiatorConfig.SocketTimeout != time.Duration(0) {
+		timeout = initiatorConfig.SocketTimeout
+	} else {
+		timeout = 5 * time.Second
+	}
+
+	init, sessionId, err := initiator.Connect(cmd.Context(), app, settings, quickfixLogger, app.Connected, timeout, initiatorConfig.Reconnect)
+	if err != nil {
+		app.Stop()
+		return err
+	}
+
+	defer func() {
+		app.Stop()
+		init.Stop()
+	}()
+
+	// Messages sent are not needed
+	go func() {
+		for range app.ToAppMessages {
+		}
+	}()
+
+	ctx := cmd.Context()
+
+	results := map[string]*measurements{
+		phaseNew:    {},
+		phaseCancel: {},
+	}
+	inflight := make(map[string]*pending)
+	seqNums := make(map[int]string)
+	total := optionWarmup + optionCount
+	sent := 0
+
+	send := func(p *pending, message quickfix.Messagable) error {
+		inflight[p.clOrdID] = p
+		p.sent = time.Now()
+		if err := quickfix.SendToTarget(message, sessionId); err != nil {
+			return err
+		}
+
+		// Session level and business rejects may only refer to the sequence
+		// number of the message
+		if seqNum, err := message.ToMessage().Header.GetInt(tag.MsgSeqNum); err == nil {
+			p.seqNum = seqNum
+			seqNums[seqNum] = p.clOrdID
+		}
+
+		return nil
+	}
+
+	sendOrder := func() error {
+		p := &pending{clOrdID: uuid.NewString(), phase: phaseNew, measure: sent >= optionWarmup}
+		sent++
+
+		order, err := buildOrderMessage(*session, appDict, p.clOrdID)
+		if err != nil {
+			return err
+		}
+
+		return send(p, order)
+	}
+
+	// Once an order or its cancel is done, the next order is sent
+	done := func() error {
+		if sent < total {
+			return sendOrder()
+		}
+		return nil
+	}
+
+	logger.Debug().Msgf("Sending %d orders (%d warm-up) with a concurrency of %d", total, optionWarmup, optionConcurrency)
+
+	for i := 0; i < optionConcurrency && sent < total; i++ {
+		if err := sendOrder(); err != nil {
+			return err
+		}
+	}
+
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	start := time.Now()
+
+LOOP:
+	for len(inflight) > 0 {
+		select {
+		case <-ctx.Done():
+			logger.Debug().Msg("Interrupted")
+			break LOOP
+
+		case now := <-ticker.C:
+			for id, p := range inflight {
+				if now.Sub(p.sent) < optionExecReportsTimeout {
+					continue
+				}
+				delete(inflight, id)
+				delete(seqNums, p.seqNum)
+				if p.measure {
+					results[p.phase].Timeouts++
+				}
+				if err := done(); err != nil {
+					return err
+				}
+			}
+
+		case msg, ok := <-app.FromAppMessages:
+			if !ok {
+				return errors.FixLogout
+			}
+			received := time.Now()
+
+			id, rejected := utils.QuickFixResponseClOrdID(msg, seqNums)
+			p, ok := inflight[id]
+			if !ok {
+				continue
+			}
+			delete(inflight, id)
+			delete(seqNums, p.seqNum)
+
+			if p.measure {
+				results[p.phase].Latencies = append(results[p.phase].Latencies, received.Sub(p.sent))
+				if rejected {
+					results[p.phase].Rejected++
+				}
+			}
+
+			if optionCancel && p.phase == phaseNew && !rejected {
+				c := &pending{clOrdID: uuid.NewString(), phase: phaseCancel, measure: p.measure}
+				cancel, err := buildCancelMessage(*session, appDict, c.clOrdID, p.clOrdID)
+				if err != nil {
+					return err
+				}
+				if err := send(c, cancel); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if err := done(); err != nil {
+				return err
+			}
+		}
+	}
+
+	elapsed := time.Since(start)
+
+	phases := []string{phaseNew}
+	if optionCancel {
+		phases = append(phases, phaseCancel)
+	}
+
+	timeouts := 0
+	for _, phase := range phases {
+		timeouts += results[phase].Timeouts
+	}
+
+	switch options.Output {
+	case utils.OutputFormatJSON:
+		out := make([]jsonPhase, 0, len(phases))
+		for _, phase := range phases {
+			r := results[phase]
+			out = append(out, jsonPhase{
+				Phase:     phase,
+				Rejected:  r.Rejected,
+				Timeouts:  r.Timeouts,
+				Latency:   r.Latencies.Summary(),
+				Histogram: r.Latencies.Histogram(optionHistogramBuckets),
+			})
+		}
+		if err := json.NewEncoder(os.Stdout).Encode(out); err != nil {
+			return err
+		}
+
+	default:
+		writeSummary(phases, results)
+		for _, phase := range phases {
+			fmt.Printf("\n%s latency\n", strings.ToUpper(phase[:1])+phase[1:])
+			utils.WriteLatencyHistogram(os.Stdout, results[phase].Latencies.Histogram(optionHistogramBuckets), 40)
+		}
+		fmt.Printf("\n%d orders sent in %s (%.1f orders/s)\n", sent, elapsed.Round(time.Millisecond), float64(sent)/elapsed.Seconds())
+	}
+
+	if timeouts > 0 {
+		return fmt.Errorf("%w: %d execution report(s) not received", errors.ResponseTimeout, timeouts)
+	}
+
+	return nil
+}
+
+func writeSummary(phases []string, results map[string]*measurements) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"PHASE", "COUNT", "REJECTED", "TIMEOUTS", "MIN", "MEAN", "P50", "P95", "P99", "MAX"})
+	table.SetBorders(tablewriter.Border{Left: false, Top: false, Right: false, Bottom: true})
+	table.SetColumnSeparator(" ")
+	table.SetCenterSeparator("-")
+	table.SetAutoWrapText(false)
+
+	for _, phase := range phases {
+		r := results[phase]
+		s := r.Latencies.Summary()
+		table.Append([]string{
+			phase,
+			fmt.Sprint(s.Count),
+			fmt.Sprint(r.Rejected),
+			fmt.Sprint(r.Timeouts),
+			utils.FormatLatency(s.Min),
+			utils.FormatLatency(s.Mean),
+			utils.FormatLatency(s.P50),
+			utils.FormatLatency(s.P95),
+			utils.FormatLatency(s.P99),
+			utils.FormatLatency(s.Max),
+		})
+	}
+
+	table.Render()
+}
+
+func buildOrderMessage(session config.Session, appDict *datadictionary.DataDictionary, clOrdID string) (quickfix.Messagable, error) {
+	eside, err := dict.OrderSideStringToEnum(optionOrderSide)
+	if err != nil {
+		return nil, err
+	}
+
+	etype, err := dict.OrderTypeStringToEnum(optionOrderType)
+	if err != nil {
+		return nil, err
+	}
+
+	eExpiry, err := dict.OrderTimeInForceStringToEnum(optionOrderExpiry)
+	if err != nil {
+		return nil, err
+	}
+
+	if session.BeginString != quickfix.BeginStringFIXT11 || session.DefaultApplVerID != "FIX.5.0SP2" {
+		return nil, errors.FixVersionNotImplemented
+	}
+
+	message := quickfix.NewMessage()
+	header := fixt11.NewHeader(&message.Header)
+	header.Set(field.NewMsgType(enum.MsgType_ORDER_SINGLE))
+	message.Body.Set(field.NewClOrdID(clOrdID))
+	message.Body.Set(field.NewSide(eside))
+	message.Body.Set(field.NewTransactTime(time.Now()))
+	message.Body.Set(field.NewOrdType(etype))
+	message.Body.Set(field.NewSymbol(optionOrderSymbol))
+	message.Body.Set(field.NewOrderQty(utils.QuickFixDecimal(appDict, tag.OrderQty, optionOrderSymbol, decimal.NewFromInt(optionOrderQuantity))))
+	message.Body.Set(field.NewTimeInForce(eExpiry))
+
+	if etype != enum.OrdType_MARKET {
+		message.Body.Set(field.NewPrice(utils.QuickFixDecimal(appDict, tag.Price, optionOrderSymbol, decimal.NewFromFloat(optionOrderPrice))))
+	}
+
+	return message, nil
+}
+
+func buildCancelMessage(session config.Session, appDict *datadictionary.DataDictionary, clOrdID, origClOrdID string) (quickfix.Messagable, error) {
+	eside, err := dict.OrderSideStringToEnum(optionOrderSide)
+	if err != nil {
+		return nil, err
+	}
+
+	if session.BeginString != quickfix.BeginStringFIXT11 || session.DefaultApplVerID != "FIX.5.0SP2" {
+		return nil, errors.FixVersionNotImplemented
+	}
+
+	message := quickfix.NewMessage()
+	header := fixt11.NewHeader(&message.Header)
+	header.Set(field.NewMsgType(enum.MsgType_ORDER_CANCEL_REQUEST))
+	message.Body.Set(field.NewClOrdID(clOrdID))
+	message.Body.Set(field.NewOrigClOrdID(origClOrdID))
+	message.Body.Set(field.NewSide(eside))
+	message.Body.Set(field.NewTransactTime(time.Now()))
+	message.Body.Set(field.NewSymbol(optionOrderSymbol))
+	message.Body.Set(field.NewOrderQty(utils.QuickFixDecimal(appDict, tag.OrderQty, optionOrderSymbol, decimal.NewFromInt(optionOrderQuantity))))
+
+	return message, nil
+}
@@ -0,0 +1,115 @@
+package blotter
+
+import (
+	"sync"
+	"time"
+
+	"github.com/quickfixgo/enum"
+	"github.com/quickfixgo/quickfix"
+	"github.com/rs/zerolog"
+
+	"sylr.dev/fix/pkg/utils"
+)
+
+// blotterApp is an initiator application which notifies the execution reports
+// and cancel rejects received, recorded by the order tracker of the session,
+// and survives the logouts of the session.
+type blotterApp struct {
+	utils.BaseApplication
+
+	Connected chan quickfix.SessionID
+	// Updates is notified when the orders recorded changed
+	Updates chan struct{}
+
+	loggedOn bool
+	since    time.Time
+	mux      sync.Mutex
+}
+
+func newBlotterApp() *blotterApp {
+	return &blotterApp{
+		Connected: make(chan quickfix.SessionID, 1),
+		Updates:   make(chan struct{}, 1),
+		since:     time.Now(),
+	}
+}
+
+// state tells whether the session is logged on and since when.
+func (app *blotterApp) state() (bool, time.Time) {
+	app.mux.Lock()
+	defer app.mux.Unlock()
+
+	return app.loggedOn, app.since
+}
+
+func (app *blotterApp) setLoggedOn(loggedOn bool) {
+	app.mux.Lock()
+	app.loggedOn = loggedOn
+	app.since = time.Now()
+	app.mux.Unlock()
+
+	app.update()
+}
+
+func (app *blotterApp) update() {
+	select {
+	case app.Updates <- struct{}{}:
+	default:
+	}
+}
+
+// Notification of a session begin created.
+func (app *blotterApp) OnCreate(sessionID quickfix.SessionID) {
+	app.Logger.Debug().Msgf("New session: %s", sessionID)
+}
+
+// Notification of a session successfully logging on.
+func (app *blotterApp) OnLogon(sessionID quickfix.SessionID) {
+	app.Logger.Debug().Msgf("Logon: %s", sessionID)
+	app.setLoggedOn(true)
+
+	select {
+	case app.Connected <- sessionID:
+	default:
+	}
+}
+
+// Notification of a session logging off or disconnecting.
+func (app *blotterApp) OnLogout(sessionID quickfix.SessionID) {
+	app.Logger.Debug().Msgf("Logout: %s", sessionID)
+	app.setLoggedOn(false)
+}
+
+// Notification of admin message being sent to target.
+func (app *blotterApp) ToAdmin(message *quickfix.Message, sessionID quickfix.SessionID) {
+	app.PrepareLogon(message, sessionID)
+
+	app.LogMessage(zerolog.TraceLevel, message, sessionID, true)
+}
+
+// Notification of admin message being received from target.
+func (app *blotterApp) FromAdmin(message *quickfix.Message, sessionID quickfix.SessionID) quickfix.MessageRejectError {
+	app.LogMessage(zerolog.TraceLevel, message, sessionID, false)
+	return nil
+}
+
+// Notification of app message being sent to target.
+func (app *blotterApp) ToApp(message *quickfix.Message, sessionID quickfix.SessionID) error {
+	app.LogMessage(zerolog.TraceLevel, message, sessionID, true)
+	return nil
+}
+
+// Notification of app message being received from target. The session log
+// records the messages before they reach the application so the order tracker
+// is up to date when notified.
+func (app *blotterApp) FromApp(message *quickfix.Message, sessionID quickfix.SessionID) quickfix.MessageRejectError {
+	app.LogMessage(zerolog.TraceLevel, message, sessionID, false)
+
+	typ, _ := message.MsgType()
+	switch enum.MsgType(typ) {
+	case enum.MsgType_EXECUTION_REPORT, enum.MsgType_ORDER_CANCEL_REJECT:
+		app.update()
+	}
+
+	return nil
+}
@@ -0,0 +1,301 @@
+package blotter
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/quickfixgo/enum"
+	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"sylr.dev/fix/config"
+	"sylr.dev/fix/pkg/initiator"
+	"sylr.dev/fix/pkg/initiator/application"
+	"sylr.dev/fix/pkg/ordertracker"
+	"sylr.dev/fix/pkg/utils"
+)
+
+var (
+	optionDatabase   string
+	optionRefresh    time.Duration
+	optionSince      time.Duration
+	optionSymbol     string
+	optionExecutions int
+)
+
+var BlotterCmd = &cobra.Command{
+	Use:   "blotter",
+	Short: "Live blotter of the working orders and recent executions of a session",
+	Long: "Log on and show, continuously updated, the working orders and the recent executions of the session " +
+		"recorded by the order tracker, orders sent from other shells included. The execution reports received " +
+		"by the blotter session are recorded as they arrive.",
+	Example:           "  fix blotter --context venue --since 8h",
+	Args:              cobra.ExactArgs(0),
+	ValidArgsFunction: cobra.NoFileCompletions,
+	PersistentPreRunE: utils.MakePersistentPreRunE(initiator.ValidateOptions),
+	RunE:              Execute,
+}
+
+func init() {
+	initiator.AddPersistentFlags(BlotterCmd)
+	initiator.AddPersistentFlagCompletions(BlotterCmd)
+	initiator.AddExitOnDisconnectFlag(BlotterCmd)
+
+	BlotterCmd.Flags().StringVar(&optionDatabase, "db", "", "Order tracker database (defaults to the OrderTrackerPath of the session or "+ordertracker.DefaultPath()+")")
+	BlotterCmd.Flags().DurationVar(&optionRefresh, "refresh", time.Second, "Refresh interval")
+	BlotterCmd.Flags().DurationVar(&optionSince, "since", 24*time.Hour, "Only show the orders created and the executions received during this last duration")
+	BlotterCmd.Flags().StringVar(&optionSymbol, "symbol", "", "Only show the orders of this symbol")
+	BlotterCmd.Flags().IntVar(&optionExecutions, "executions", 20, "Number of recent executions shown")
+}
+
+func Execute(cmd *cobra.Command, args []string) error {
+	options := config.GetOptions()
+
+	context, err := config.GetCurrentContext()
+	if err != nil {
+		return err
+	}
+
+	sessions, err := context.GetSessions()
+	if err != nil {
+		return err
+	}
+
+	session := sessions[0]
+	logger := session.GetLogger()
+	initiatorConfig, err := context.GetInitiator()
+	if err != nil {
+		return err
+	}
+
+	settings, err := context.ToQuickFixInitiatorSettings()
+	if err != nil {
+		return err
+	}
+
+	// The execution reports received by the blotter are recorded in the
+	// database it shows.
+	path := optionDatabase
+	for _, s := range settings.SessionSettings() {
+		if len(path) == 0 && s.HasSetting("OrderTrackerPath") {
+			if path, err = s.Setting("OrderTrackerPath"); err != nil {
+				return err
+			}
+		}
+		if len(path) == 0 {
+			path = os.ExpandEnv(ordertracker.DefaultPath())
+		}
+		s.Set("OrderTrackerPath", path)
+	}
+
+	tracker, err := ordertracker.Open(path)
+	if err != nil {
+		return err
+	}
+
+	app := newBlotterApp()
+	app.Logger = logger
+	app.Settings = settings
+
+	var quickfixLogger *zerolog.Logger
+	if options.QuickFixLogging {
+		quickfixLogger = logger
+	}
+
+	// Choose right timeout cli option > config > default value (5s)
+	var timeout time.Duration
+	if options.Timeout != time.Duration(0) {
+		timeout = options.Timeout
+	} else if initiatorConfig.SocketTimeout != time.Duration(0) {
+		timeout = initiatorConfig.SocketTimeout
+	} else {
+		timeout = 5 * time.Second
+	}
+
+	init, sessionId, err := initiator.Connect(cmd.Context(), app, settings, quickfixLogger, app.Connected, timeout, initiatorConfig.Reconnect)
+	if err != nil {
+		return err
+	}
+
+	defer init.Stop()
+
+	b := &blotter{
+		session: sessionId.String(),
+		app:     app,
+		tracker: tracker,
+		tty:     term.IsTerminal(int(os.Stdout.Fd())),
+	}
+
+	if b.tty {
+		// Alternate screen, hidden cursor
+		fmt.Print("\x1b[?1049h\x1b[?25l")
+		defer fmt.Print("\x1b[?25h\x1b[?1049l")
+	}
+
+	ctx := cmd.Context()
+
+	refresh := time.NewTicker(optionRefresh)
+	defer refresh.Stop()
+
+	if err := b.draw(); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Debug().Msg("Interrupted")
+			return nil
+
+		case <-app.Connected:
+			// quickfix logs the session on again by itself after a disconnection
+
+		// Orders sent from other shells only show on refresh
+		case <-refresh.C:
+			if err := b.draw(); err != nil {
+				return err
+			}
+
+		case <-app.Updates:
+			if err := b.draw(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// blotter holds the state of the screen.
+type blotter struct {
+	session string
+	app     *blotterApp
+	tracker *ordertracker.Tracker
+	tty     bool
+	// last identifies the orders and executions last written when not on a
+	// terminal, which are only written again when they change
+	last string
+}
+
+func (b *blotter) draw() error {
+	listOptions := ordertracker.ListOptions{
+		Session: b.session,
+		Symbol:  optionSymbol,
+		Since:   time.Now().Add(-optionSince),
+		Working: true,
+	}
+
+	orders, err := b.tracker.List(listOptions)
+	if err != nil {
+		return err
+	}
+
+	listOptions.Working = false
+	listOptions.Limit = optionExecutions
+	executions, err := b.tracker.Executions(listOptions)
+	if err != nil {
+		return err
+	}
+
+	state := "logged out"
+	loggedOn, since := b.app.state()
+	if loggedOn {
+		state = "logged on"
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "fix blotter - %s %s for %s - %s\n\n", b.session, state, time.Since(since).Truncate(time.Second), utils.FormatTime(time.Now()))
+
+	fmt.Fprintf(&buf, "Working orders (%d)\n", len(orders))
+	table := newTable(&buf)
+	table.SetHeader([]string{"AGE", "CLORDID", "ORDERID", "SYMBOL", "SIDE", "TYPE", "PRICE", "QTY", "FILLED", "AVGPX", "STATE"})
+	for _, order := range orders {
+		table.Append([]string{
+			time.Since(order.CreatedAt).Truncate(time.Second).String(),
+			order.ClOrdID,
+			order.OrderID,
+			order.Symbol,
+			order.SideName(),
+			order.TypeName(),
+			order.Price,
+			order.OrderQty,
+			order.CumQty,
+			order.AvgPx,
+			string(application.TrackedOrderState(order)),
+		})
+	}
+	table.Render()
+
+	fmt.Fprintf(&buf, "\nRecent executions\n")
+	table = newTable(&buf)
+	table.SetHeader([]string{"TIME", "CLORDID", "SYMBOL", "SIDE", "QTY", "PRICE", "STATE"})
+	for _, execution := range executions {
+		table.Append([]string{
+			utils.FormatTimeOnly(execution.Time),
+			execution.ClOrdID,
+			execution.Symbol,
+			execution.SideName(),
+			execution.LastQty,
+			execution.LastPx,
+			string(application.OrderStateFromOrdStatus(enum.OrdStatus(execution.OrdStatus), decimal.Zero)),
+		})
+	}
+	table.Render()
+
+	if !b.tty {
+		var last strings.Builder
+		fmt.Fprint(&last, loggedOn)
+		for _, order := range orders {
+			fmt.Fprint(&last, order.ClOrdID, order.UpdatedAt.UnixNano())
+		}
+		for _, execution := range executions {
+			fmt.Fprint(&last, execution.ClOrdID, execution.Time.UnixNano())
+		}
+		if last.String() == b.last {
+			return nil
+		}
+		b.last = last.String()
+
+		buf.WriteString("\n")
+		_, err := os.Stdout.Write(buf.Bytes())
+		return err
+	}
+
+	width, height, _ := term.GetSize(int(os.Stdout.Fd()))
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+
+	var out bytes.Buffer
+	out.WriteString("\x1b[H")
+	for i, line := range lines {
+		if height > 0 && i >= height {
+			break
+		}
+		if width > 0 && len(line) > width {
+			line = line[:width]
+		}
+		out.WriteString(line)
+		out.WriteString("\x1b[K")
+		if i < len(lines)-1 {
+			out.WriteString("\r\n")
+		}
+	}
+	out.WriteString("\x1b[J")
+
+	_, err = os.Stdout.Write(out.Bytes())
+
+	return err
+}
+
+func newTable(buf *bytes.Buffer) *tablewriter.Table {
+	table := tablewriter.NewWriter(buf)
+	table.SetBorders(tablewriter.Border{Left: false, Top: false, Right: false, Bottom: true})
+	table.SetColumnSeparator(" ")
+	table.SetCenterSeparator("-")
+	table.SetAutoWrapText(false)
+
+	return table
+}
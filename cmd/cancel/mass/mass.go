@@ -3,9 +3,7 @@ package cancelmassorder
 import (
 	"fmt"
 	"os"
-	"os/signal"
 	"strings"
-	"syscall"
 	"time"
 
 	"github.com/google/uuid"
@@ -26,10 +24,15 @@ import (
 	"sylr.dev/fix/pkg/utils"
 )
 
+// tagSecurityType is missing from the tag package.
+const tagSecurityType quickfix.Tag = 167
+
 var (
 	optionOrderID            string
+	optionType               string
 	optionOrderSides         []string
 	optionOrderSymbols       []string
+	optionSecurityTypes      []string
 	optionExecReportsTimeout time.Duration
 	partyIdOptions           *options.PartyIdOptions
 )
@@ -46,22 +49,25 @@ var MassCancelOrderCmd = &cobra.Command{
 
 func init() {
 	MassCancelOrderCmd.Flags().StringVar(&optionOrderID, "id", "", "Order id (uuid autogenerated if not given)")
-	MassCancelOrderCmd.Flags().StringSliceVar(&optionOrderSides, "sides", []string{}, "Order sides (buy, sell ... etc)")
-	MassCancelOrderCmd.Flags().StringSliceVar(&optionOrderSymbols, "symbols", []string{}, "Order symbols")
+	MassCancelOrderCmd.Flags().StringVar(&optionType, "type", "symbol", "Mass cancel request type (symbol, security_type, all)")
+	MassCancelOrderCmd.Flags().StringSliceVar(&optionOrderSides, "sides", []string{}, "Order sides (buy, sell ... etc), all sides if not given")
+	MassCancelOrderCmd.Flags().StringSliceVar(&optionOrderSymbols, "symbols", []string{}, "Order symbols (symbol type)")
+	MassCancelOrderCmd.Flags().StringSliceVar(&optionSecurityTypes, "security-types", []string{}, "Order security types (security_type type)")
 	MassCancelOrderCmd.Flags().DurationVar(&optionExecReportsTimeout, "exec-reports-timeout", 5*time.Second, "Log out if execution reports not received within timeout (0s wait indefinitely)")
 
 	partyIdOptions = options.NewPartyIdOptions(MassCancelOrderCmd)
 
-	MassCancelOrderCmd.MarkFlagRequired("sides")
-	MassCancelOrderCmd.MarkFlagRequired("symbols")
-
+	MassCancelOrderCmd.RegisterFlagCompletionFunc("type", complete.MassCancelRequestType)
 	MassCancelOrderCmd.RegisterFlagCompletionFunc("sides", complete.OrderSide)
+	MassCancelOrderCmd.RegisterFlagCompletionFunc("symbols", complete.Symbol)
 }
 
 func Validate(cmd *cobra.Command, args []string) error {
-	if len(optionOrderSides) == 0 {
-		return errors.OptionOrderSideUnknown
+	types := utils.PrettyOptionValues(dict.MassCancelRequestTypes)
+	if utils.Search(types, strings.ToLower(optionType)) < 0 {
+		return errors.OptionMassCancelRequestUnknown
 	}
+
 	sides := utils.PrettyOptionValues(dict.OrderSides)
 	for _, side := range optionOrderSides {
 		search := utils.Search(sides, strings.ToLower(side))
@@ -70,8 +76,15 @@ func Validate(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	if len(optionOrderSymbols) == 0 {
-		return errors.OptionsNoSymbolGiven
+	switch strings.ToLower(optionType) {
+	case "symbol":
+		if len(optionOrderSymbols) == 0 {
+			return errors.OptionsNoSymbolGiven
+		}
+	case "security_type":
+		if len(optionSecurityTypes) == 0 {
+			return errors.OptionsNoSecurityTypeGiven
+		}
 	}
 
 	return partyIdOptions.Validate()
@@ -92,6 +105,7 @@ func Execute(cmd *cobra.Command, args []string) error {
 	}
 
 	session := sessions[0]
+	logger = session.GetLogger()
 	initiatorConfig, err := context.GetInitiator()
 	if err != nil {
 		return err
@@ -112,6 +126,7 @@ func Execute(cmd *cobra.Command, args []string) error {
 	app.Settings = settings
 	app.TransportDataDictionary = transportDict
 	app.AppDataDictionary = appDict
+	app.OutputFormat = options.Output
 
 	var quickfixLogger *zerolog.Logger
 	if options.QuickFixLogging {
@@ -128,13 +143,9 @@ func Execute(cmd *cobra.Command, args []string) error {
 		timeout = 5 * time.Second
 	}
 
-	init, err := initiator.Initiate(app, settings, quickfixLogger)
+	init, sessionId, err := initiator.Connect(cmd.Context(), app, settings, quickfixLogger, app.Connected, timeout, initiatorConfig.Reconnect)
 	if err != nil {
-		return err
-	}
-
-	// Start session
-	if err = init.Start(); err != nil {
+		app.Stop()
 		return err
 	}
 
@@ -143,22 +154,26 @@ func Execute(cmd *cobra.Command, args []string) error {
 		init.Stop()
 	}()
 
-	// Wait for session connection
-	var sessionId quickfix.SessionID
-	var ok bool
-	select {
-	case <-time.After(timeout):
-		return errors.ConnectionTimeout
-	case sessionId, ok = <-app.Connected:
-		if !ok {
-			return errors.FixLogout
-		}
+	// One request per symbol or security type, and per side when given
+	var targets []string
+	switch strings.ToLower(optionType) {
+	case "symbol":
+		targets = optionOrderSymbols
+	case "security_type":
+		targets = optionSecurityTypes
+	default:
+		targets = []string{""}
 	}
 
-	for _, symbol := range optionOrderSymbols {
-		for _, side := range optionOrderSides {
+	sides := optionOrderSides
+	if len(sides) == 0 {
+		sides = []string{""}
+	}
+
+	for _, target := range targets {
+		for _, side := range sides {
 			// Prepare mass cancel message
-			cancelMsg, err := buildMessage(*session, symbol, side)
+			cancelMsg, err := buildMessage(*session, target, side)
 			if err != nil {
 				return err
 			}
@@ -171,8 +186,7 @@ func Execute(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	interrupt := make(chan os.Signal, 1)
-	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	ctx := cmd.Context()
 
 	var waitTimeout <-chan time.Time
 	if optionExecReportsTimeout > 0 {
@@ -181,17 +195,16 @@ func Execute(cmd *cobra.Command, args []string) error {
 		waitTimeout = make(<-chan time.Time)
 	}
 
-	awaitingMessages := len(optionOrderSymbols) * len(optionOrderSides)
+	awaitingMessages := len(targets) * len(sides)
 LOOP:
 	for {
 		select {
-		case signal := <-interrupt:
-			logger.Debug().Msgf("Received signal: %s", signal)
+		case <-ctx.Done():
+			logger.Debug().Msg("Interrupted")
 			break LOOP
 
 		case <-waitTimeout:
-			logger.Warn().Msgf("Timeout while expecting execution report or cancel request reject")
-			break LOOP
+			return fmt.Errorf("%w: expecting order mass cancel report (%d missing)", errors.ResponseTimeout, awaitingMessages)
 
 		case msg, ok := <-app.FromAppMessages:
 			if !ok {
@@ -215,8 +228,10 @@ LOOP:
 	return nil
 }
 
-func buildMessage(session config.Session, symbol, side string) (quickfix.Messagable, error) {
-	eside, err := dict.OrderSideStringToEnum(side)
+// buildMessage returns the OrderMassCancelRequest of the orders of the symbol
+// or of the security type, depending on --type, and of the side, if any.
+func buildMessage(session config.Session, target, side string) (quickfix.Messagable, error) {
+	requestType, err := dict.MassCancelRequestTypeStringToEnum(optionType)
 	if err != nil {
 		return nil, err
 	}
@@ -232,10 +247,21 @@ func buildMessage(session config.Session, symbol, side string) (quickfix.Messaga
 			} else {
 				message.Body.Set(field.NewClOrdID(optionOrderID))
 			}
-			message.Body.Set(field.NewMassCancelRequestType(enum.MassCancelRequestType_CANCEL_ORDERS_FOR_A_SECURITY))
+			message.Body.Set(field.NewMassCancelRequestType(requestType))
 			message.Body.Set(field.NewTransactTime(time.Now()))
-			message.Body.Set(field.NewSide(eside))
-			message.Body.Set(field.NewSymbol(symbol))
+			if len(side) > 0 {
+				eside, err := dict.OrderSideStringToEnum(side)
+				if err != nil {
+					return nil, err
+				}
+				message.Body.Set(field.NewSide(eside))
+			}
+			switch requestType {
+			case enum.MassCancelRequestType_CANCEL_ORDERS_FOR_A_SECURITY:
+				message.Body.Set(field.NewSymbol(target))
+			case enum.MassCancelRequestType_CANCEL_ORDERS_FOR_A_SECURITYTYPE:
+				message.Body.SetString(tagSecurityType, target)
+			}
 			partyIdOptions.EnrichMessageBody(&message.Body, session)
 
 			return message, nil
@@ -274,12 +300,12 @@ func processResponse(app *application.CancelOrder, msg *quickfix.Message) error
 		return err
 	}
 
-	if msgType.Value() == enum.MsgType_REJECT || msgType.Value() == enum.MsgType_BUSINESS_MESSAGE_REJECT {
-		return makeError(errors.FixOrderRejected)
+	if err := utils.QuickFixRejectError(msg, errors.FixOrderRejected); err != nil {
+		return err
 	}
 
 	if msgType.Value() == enum.MsgType_ORDER_MASS_CANCEL_REPORT {
-		app.WriteMessageBodyAsTable(os.Stdout, msg)
+		app.WriteMessage(os.Stdout, msg)
 		resp := field.MassCancelResponseField{}
 		if err = msg.Body.GetField(tag.MassCancelResponse, &resp); err != nil {
 			return err
@@ -3,9 +3,7 @@ package cancelorder
 import (
 	"fmt"
 	"os"
-	"os/signal"
 	"strings"
-	"syscall"
 	"time"
 
 	"github.com/quickfixgo/enum"
@@ -22,6 +20,7 @@ import (
 	"sylr.dev/fix/pkg/errors"
 	"sylr.dev/fix/pkg/initiator"
 	"sylr.dev/fix/pkg/initiator/application"
+	"sylr.dev/fix/pkg/sessiond"
 	"sylr.dev/fix/pkg/utils"
 )
 
@@ -32,6 +31,7 @@ var (
 	optionClientOrderID      string
 	optionOrigClientOrderID  string
 	optionExecReportsTimeout time.Duration
+	optionSocket             string
 	partyIdOptions           *options.PartyIdOptions
 )
 
@@ -53,6 +53,7 @@ func init() {
 	CancelOrderCmd.Flags().StringVar(&optionOrderSide, "side", "", "Order side (buy, sell ... etc)")
 	CancelOrderCmd.Flags().StringVar(&optionOrderSymbol, "symbol", "", "Order symbol")
 	CancelOrderCmd.Flags().DurationVar(&optionExecReportsTimeout, "exec-reports-timeout", 5*time.Second, "Log out if execution reports not received within timeout (0s wait indefinitely)")
+	CancelOrderCmd.Flags().StringVar(&optionSocket, "socket", "", "Unix socket of the session served by `fix session hold --serve` (default $HOME/.fix/sessions/<context>.sock if it exists)")
 
 	partyIdOptions = options.NewPartyIdOptions(CancelOrderCmd)
 
@@ -60,6 +61,7 @@ func init() {
 	CancelOrderCmd.MarkFlagRequired("side")
 
 	CancelOrderCmd.RegisterFlagCompletionFunc("side", complete.OrderSide)
+	CancelOrderCmd.RegisterFlagCompletionFunc("symbol", complete.Symbol)
 }
 
 func Validate(cmd *cobra.Command, args []string) error {
@@ -87,6 +89,7 @@ func Execute(cmd *cobra.Command, args []string) error {
 	}
 
 	session := sessions[0]
+	logger = session.GetLogger()
 	initiatorConfig, err := context.GetInitiator()
 	if err != nil {
 		return err
@@ -107,6 +110,8 @@ func Execute(cmd *cobra.Command, args []string) error {
 	app.Settings = settings
 	app.TransportDataDictionary = transportDict
 	app.AppDataDictionary = appDict
+	app.OutputFormat = options.Output
+	app.Resumable = initiatorConfig.Reconnect.OnDisconnect
 
 	var quickfixLogger *zerolog.Logger
 	if options.QuickFixLogging {
@@ -123,31 +128,31 @@ func Execute(cmd *cobra.Command, args []string) error {
 		timeout = 5 * time.Second
 	}
 
-	init, err := initiator.Initiate(app, settings, quickfixLogger)
+	// The session held by `fix session hold --serve` spares the logon
+	client, err := sessiond.Served(optionSocket, context.Name, settings)
 	if err != nil {
 		return err
 	}
 
-	// Start session
-	if err = init.Start(); err != nil {
-		return err
-	}
-
-	defer func() {
-		app.Stop()
-		init.Stop()
-	}()
-
-	// Wait for session connection
 	var sessionId quickfix.SessionID
-	var ok bool
-	select {
-	case <-time.After(timeout):
-		return errors.ConnectionTimeout
-	case sessionId, ok = <-app.Connected:
-		if !ok {
-			return errors.FixLogout
+	if client != nil {
+		defer func() {
+			app.Stop()
+			client.Close()
+		}()
+		sessionId = client.SessionID()
+	} else {
+		var init *quickfix.Initiator
+		init, sessionId, err = initiator.Connect(cmd.Context(), app, settings, quickfixLogger, app.Connected, timeout, initiatorConfig.Reconnect)
+		if err != nil {
+			app.Stop()
+			return err
 		}
+
+		defer func() {
+			app.Stop()
+			init.Stop()
+		}()
 	}
 
 	// Prepare cancel message
@@ -157,13 +162,18 @@ func Execute(cmd *cobra.Command, args []string) error {
 	}
 
 	// Send the cancel message
-	err = quickfix.SendToTarget(cancelMsg, sessionId)
+	if client != nil {
+		err = client.Exchange(app, cancelMsg, optionExecReportsTimeout, transportDict, appDict)
+	} else {
+		err = quickfix.SendToTarget(cancelMsg, sessionId)
+	}
 	if err != nil {
 		return err
 	}
 
-	interrupt := make(chan os.Signal, 1)
-	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	ids := []string{optionClientOrderID, optionOrigClientOrderID, optionOrderID}
+
+	ctx := cmd.Context()
 
 	var waitTimeout <-chan time.Time
 	if optionExecReportsTimeout > 0 {
@@ -175,19 +185,29 @@ func Execute(cmd *cobra.Command, args []string) error {
 LOOP:
 	for {
 		select {
-		case signal := <-interrupt:
-			logger.Debug().Msgf("Received signal: %s", signal)
+		case <-ctx.Done():
+			logger.Debug().Msg("Interrupted")
 			break LOOP
 
 		case <-waitTimeout:
-			logger.Warn().Msgf("Timeout while expecting execution report or cancel request reject")
-			break LOOP
+			return fmt.Errorf("%w: expecting execution report or cancel request reject", errors.ResponseTimeout)
+
+		case <-app.Disconnected:
+			sessionId, err = initiator.Reconnect(cmd.Context(), app.Connected, app.Disconnected, timeout, initiatorConfig.Reconnect)
+			if err != nil {
+				return err
+			}
 
 		case msg, ok := <-app.FromAppMessages:
 			if !ok {
 				break LOOP
 			}
 
+			// Ignore the responses to other requests
+			if !utils.QuickFixMessageCorrelates(msg, ids...) {
+				continue LOOP
+			}
+
 			if err := processResponse(app, msg); err != nil {
 				if errors.Is(err, quickfix.InvalidMessageType()) {
 					continue LOOP
@@ -264,17 +284,17 @@ func processResponse(app *application.CancelOrder, msg *quickfix.Message) error
 		return err
 	}
 
-	if msgType.Value() == enum.MsgType_REJECT || msgType.Value() == enum.MsgType_BUSINESS_MESSAGE_REJECT {
-		return makeError(errors.FixOrderRejected)
+	if err := utils.QuickFixRejectError(msg, errors.FixOrderRejected); err != nil {
+		return err
 	}
 
 	if msgType.Value() == enum.MsgType_ORDER_CANCEL_REJECT {
-		app.WriteMessageBodyAsTable(os.Stdout, msg)
+		app.WriteMessage(os.Stdout, msg)
 		return makeError(errors.FixOrderRejected)
 	}
 
 	if msgType.Value() == enum.MsgType_EXECUTION_REPORT {
-		app.WriteMessageBodyAsTable(os.Stdout, msg)
+		app.WriteMessage(os.Stdout, msg)
 		ordStatus := field.OrdStatusField{}
 		if err = msg.Body.GetField(tag.OrdStatus, &ordStatus); err != nil {
 			return err
@@ -3,8 +3,6 @@ package cancelorder
 import (
 	"fmt"
 	"os"
-	"os/signal"
-	"syscall"
 	"time"
 
 	"github.com/google/uuid"
@@ -50,7 +48,7 @@ func init() {
 
 	partyIdOptions = options.NewPartyIdOptions(CancelQuoteCmd)
 
-	CancelQuoteCmd.RegisterFlagCompletionFunc("side", complete.OrderSide)
+	CancelQuoteCmd.RegisterFlagCompletionFunc("symbols", complete.Symbol)
 }
 
 func Validate(cmd *cobra.Command, args []string) error {
@@ -78,6 +76,7 @@ func Execute(cmd *cobra.Command, args []string) error {
 	}
 
 	session := sessions[0]
+	logger = session.GetLogger()
 	initiatorConfig, err := context.GetInitiator()
 	if err != nil {
 		return err
@@ -98,6 +97,7 @@ func Execute(cmd *cobra.Command, args []string) error {
 	app.Settings = settings
 	app.TransportDataDictionary = transportDict
 	app.AppDataDictionary = appDict
+	app.OutputFormat = options.Output
 
 	var quickfixLogger *zerolog.Logger
 	if options.QuickFixLogging {
@@ -114,13 +114,9 @@ func Execute(cmd *cobra.Command, args []string) error {
 		timeout = 5 * time.Second
 	}
 
-	init, err := initiator.Initiate(app, settings, quickfixLogger)
+	init, sessionId, err := initiator.Connect(cmd.Context(), app, settings, quickfixLogger, app.Connected, timeout, initiatorConfig.Reconnect)
 	if err != nil {
-		return err
-	}
-
-	// Start session
-	if err = init.Start(); err != nil {
+		app.Stop()
 		return err
 	}
 
@@ -129,18 +125,6 @@ func Execute(cmd *cobra.Command, args []string) error {
 		init.Stop()
 	}()
 
-	// Wait for session connection
-	var sessionId quickfix.SessionID
-	var ok bool
-	select {
-	case <-time.After(timeout):
-		return errors.ConnectionTimeout
-	case sessionId, ok = <-app.Connected:
-		if !ok {
-			return errors.FixLogout
-		}
-	}
-
 	// Prepare cancel message
 	cancelMsg, err := buildMessage(*session)
 	if err != nil {
@@ -153,8 +137,7 @@ func Execute(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	interrupt := make(chan os.Signal, 1)
-	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	ctx := cmd.Context()
 
 	var waitTimeout <-chan time.Time
 	if optionExecReportsTimeout > 0 {
@@ -166,13 +149,12 @@ func Execute(cmd *cobra.Command, args []string) error {
 LOOP:
 	for {
 		select {
-		case signal := <-interrupt:
-			logger.Debug().Msgf("Received signal: %s", signal)
+		case <-ctx.Done():
+			logger.Debug().Msg("Interrupted")
 			break LOOP
 
 		case <-waitTimeout:
-			logger.Warn().Msgf("Timeout while expecting execution report or cancel request reject")
-			break LOOP
+			return fmt.Errorf("%w: expecting quote status report, execution report or cancel request reject", errors.ResponseTimeout)
 
 		case msg, ok := <-app.FromAppMessages:
 			if !ok {
@@ -259,17 +241,26 @@ func processResponse(app *application.CancelOrder, msg *quickfix.Message) error
 		return err
 	}
 
-	if msgType.Value() == enum.MsgType_REJECT || msgType.Value() == enum.MsgType_BUSINESS_MESSAGE_REJECT {
-		return makeError(errors.FixOrderRejected)
+	if err := utils.QuickFixRejectError(msg, errors.FixOrderRejected); err != nil {
+		return err
 	}
 
 	if msgType.Value() == enum.MsgType_ORDER_CANCEL_REJECT {
-		app.WriteMessageBodyAsTable(os.Stdout, msg)
+		app.WriteMessage(os.Stdout, msg)
 		return makeError(errors.FixOrderRejected)
 	}
 
-	if msgType.Value() == enum.MsgType_EXECUTION_REPORT {
-		app.WriteMessageBodyAsTable(os.Stdout, msg)
+	if msgType.Value() == enum.MsgType_QUOTE_STATUS_REPORT {
+		app.WriteMessage(os.Stdout, msg)
+		quoteStatus := field.QuoteStatusField{}
+		if err = msg.Body.GetField(tag.QuoteStatus, &quoteStatus); err != nil {
+			return err
+		}
+		if quoteStatus.Value() == enum.QuoteStatus_REJECTED {
+			return makeError(errors.FixOrderRejected)
+		}
+	} else if msgType.Value() == enum.MsgType_EXECUTION_REPORT {
+		app.WriteMessage(os.Stdout, msg)
 		ordStatus := field.OrdStatusField{}
 		if err = msg.Body.GetField(tag.OrdStatus, &ordStatus); err != nil {
 			return err
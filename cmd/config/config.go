@@ -0,0 +1,19 @@
+package config
+
+import (
+	"github.com/spf13/cobra"
+
+	"sylr.dev/fix/cmd/config/migrate"
+	"sylr.dev/fix/cmd/config/schema"
+)
+
+var ConfigCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage fix configuration",
+	Long:  "Manage fix configuration.",
+}
+
+func init() {
+	ConfigCmd.AddCommand(migrate.MigrateCmd)
+	ConfigCmd.AddCommand(schema.SchemaCmd)
+}
@@ -0,0 +1,109 @@
+package migrate
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"sylr.dev/fix/config"
+	"sylr.dev/fix/pkg/errors"
+	"sylr.dev/fix/pkg/utils"
+
+	yaml "sylr.dev/yaml/v3"
+)
+
+var (
+	optionDryRun bool
+	optionYes    bool
+)
+
+var MigrateCmd = &cobra.Command{
+	Use:               "migrate",
+	Short:             "Upgrade the configuration to the current layout",
+	Long:              "Upgrade the configuration file given by --config to the current layout (renamed keys, restructured contexts) and print the changes before writing them.",
+	Args:              cobra.ExactArgs(0),
+	ValidArgsFunction: cobra.NoFileCompletions,
+	RunE:              Execute,
+}
+
+func init() {
+	MigrateCmd.Flags().BoolVar(&optionDryRun, "dry-run", false, "Only print the changes")
+	MigrateCmd.Flags().BoolVarP(&optionYes, "yes", "y", false, "Write the changes without asking for confirmation")
+}
+
+func Execute(cmd *cobra.Command, args []string) error {
+	options := config.GetOptions()
+
+	content, err := os.ReadFile(options.Config)
+	if err != nil {
+		return err
+	}
+
+	// Normalize the original document so that the diff only shows the changes
+	// made by the migration and not the formatting ones.
+	node := yaml.Node{}
+	if err := yaml.Unmarshal(content, &node); err != nil {
+		return fmt.Errorf("%w: %s: %w", errors.ConfigInvalid, options.Config, err)
+	}
+	original, err := config.EncodeYAML(&node)
+	if err != nil {
+		return err
+	}
+
+	migrated, migrations, err := config.MigrateYAML(content)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %w", errors.ConfigInvalid, options.Config, err)
+	}
+
+	if len(migrations) == 0 {
+		fmt.Printf("Configuration %s is up to date.\n", options.Config)
+		return nil
+	}
+
+	for _, m := range migrations {
+		fmt.Printf("%s:%d: %s\n", options.Config, m.Line, m.Description)
+	}
+	fmt.Println()
+
+	for _, line := range utils.LineDiff(string(original), string(migrated)) {
+		if !strings.HasPrefix(line, " ") {
+			fmt.Println(line)
+		}
+	}
+	fmt.Println()
+
+	if err := config.CheckYAML(options.Config, migrated); err != nil {
+		fmt.Fprintf(os.Stderr, "The migrated configuration still needs to be fixed by hand: %s\n", err)
+	}
+
+	if optionDryRun {
+		return nil
+	}
+
+	if !optionYes {
+		if !options.Interactive {
+			return fmt.Errorf("%w: use --yes to write the changes in non interactive mode", errors.Options)
+		}
+
+		fmt.Printf("Write changes to %s? [y/N] ", options.Config)
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if strings.ToLower(strings.TrimSpace(answer)) != "y" {
+			return nil
+		}
+	}
+
+	if err := os.WriteFile(options.Config+".bak", content, 0600); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(options.Config, migrated, 0600); err != nil {
+		return err
+	}
+
+	fmt.Printf("Configuration %s migrated, previous version saved as %s.bak\n", options.Config, options.Config)
+
+	return nil
+}
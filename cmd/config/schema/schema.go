@@ -0,0 +1,26 @@
+package schema
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"sylr.dev/fix/config"
+)
+
+var SchemaCmd = &cobra.Command{
+	Use:               "schema",
+	Short:             "Print the JSON Schema of the configuration",
+	Long:              "Print the JSON Schema of the configuration file which can be used by editors or CI to validate configuration files.",
+	Args:              cobra.ExactArgs(0),
+	ValidArgsFunction: cobra.NoFileCompletions,
+	RunE:              Execute,
+}
+
+func Execute(cmd *cobra.Command, args []string) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(config.JSONSchema())
+}
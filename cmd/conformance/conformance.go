@@ -0,0 +1,221 @@
+package conformance
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+
+	"sylr.dev/fix/config"
+	"sylr.dev/fix/pkg/conformance"
+	"sylr.dev/fix/pkg/errors"
+	"sylr.dev/fix/pkg/initiator"
+	"sylr.dev/fix/pkg/report"
+	"sylr.dev/fix/pkg/store"
+	"sylr.dev/fix/pkg/utils"
+)
+
+var (
+	optionTests  []string
+	optionReport string
+)
+
+var ConformanceCmd = &cobra.Command{
+	Use:   "conformance",
+	Short: "Run session level conformance tests against a FIX acceptor",
+	Long: "Run a battery of session level tests against the acceptor of the initiator and report the compliance " +
+		"of the counterparty per test. Every test logs on with ResetSeqNumFlag; once done, the session is logged on " +
+		"and out once more and the persistent store of the initiator, if any, is reset to match the counterparty.",
+	Example:           "  fix conformance --context venue --test sequence-gap --test resend-request",
+	Args:              cobra.ExactArgs(0),
+	ValidArgsFunction: cobra.NoFileCompletions,
+	PersistentPreRunE: utils.MakePersistentPreRunE(Validate),
+	RunE:              Execute,
+}
+
+func init() {
+	initiator.AddPersistentFlags(ConformanceCmd)
+	initiator.AddPersistentFlagCompletions(ConformanceCmd)
+
+	ConformanceCmd.Flags().StringSliceVar(&optionTests, "test", nil, "Tests to run (all by default)")
+	ConformanceCmd.Flags().StringVar(&optionReport, "report", "", "Write an HTML report of the run with the decoded messages into this file")
+	ConformanceCmd.RegisterFlagCompletionFunc("test", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return conformance.TestNames(), cobra.ShellCompDirectiveNoFileComp
+	})
+
+	var long strings.Builder
+	long.WriteString(ConformanceCmd.Long)
+	long.WriteString("\n\nTests:\n")
+	all, _ := conformance.Tests()
+	for _, test := range all {
+		fmt.Fprintf(&long, "  %-18s %s\n", test.Name, test.Description)
+	}
+	ConformanceCmd.Long = long.String()
+}
+
+func Validate(cmd *cobra.Command, args []string) error {
+	if _, ok := conformance.Tests(optionTests...); !ok {
+		return fmt.Errorf("%w: unknown test, expected one of %s", errors.Options, strings.Join(conformance.TestNames(), ", "))
+	}
+
+	return initiator.ValidateOptions(cmd, args)
+}
+
+func Execute(cmd *cobra.Command, args []string) error {
+	options := config.GetOptions()
+	logger := config.GetLogger()
+
+	context, err := config.GetCurrentContext()
+	if err != nil {
+		return err
+	}
+
+	initiatorConfig, err := context.GetInitiator()
+	if err != nil {
+		return err
+	}
+
+	sessions, err := context.GetSessions()
+	if err != nil {
+		return err
+	}
+
+	// Choose right timeout cli option > config > default value (5s)
+	var timeout time.Duration
+	if options.Timeout != time.Duration(0) {
+		timeout = options.Timeout
+	} else if initiatorConfig.SocketTimeout != time.Duration(0) {
+		timeout = initiatorConfig.SocketTimeout
+	} else {
+		timeout = 5 * time.Second
+	}
+
+	tests, _ := conformance.Tests(optionTests...)
+	results := make([]*conformance.Result, 0, len(tests))
+	failed := 0
+
+	ctx := cmd.Context()
+
+	for _, test := range tests {
+		if ctx.Err() != nil {
+			logger.Debug().Msg("Interrupted")
+			break
+		}
+
+		logger.Debug().Msgf("Running test %s", test.Name)
+
+		result := test.Run(initiatorConfig, sessions[0], timeout)
+		if result.Status != conformance.StatusPass {
+			failed++
+		}
+		results = append(results, result)
+	}
+
+	if err := resynchronize(context, initiatorConfig, sessions[0], timeout); err != nil {
+		logger.Warn().Msgf("Could not resynchronize session %s: %v", sessions[0].Name, err)
+	}
+
+	if err := writeResults(results, options.Output); err != nil {
+		return err
+	}
+
+	if len(optionReport) > 0 {
+		transportDict, appDict, err := sessions[0].GetFIXDictionaries()
+		if err != nil {
+			return err
+		}
+
+		r := &report.Report{
+			Title:     "Conformance report",
+			Context:   context.Name,
+			Session:   sessions[0].Name,
+			Generated: time.Now(),
+		}
+		for _, result := range results {
+			r.Sections = append(r.Sections, result.Section())
+		}
+		if err := r.WriteHTMLFile(optionReport, transportDict, appDict); err != nil {
+			return err
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d test(s) out of %d failed", failed, len(results))
+	}
+
+	return nil
+}
+
+// resynchronize leaves the counterparty and the store of the session, when
+// persistent, with the same sequence numbers after the tests.
+func resynchronize(context *config.Context, initiatorConfig *config.Initiator, session *config.Session, timeout time.Duration) error {
+	logger := config.GetLogger()
+
+	sender, target, err := conformance.Resynchronize(initiatorConfig, session, timeout)
+	if err != nil {
+		return err
+	}
+
+	stores, err := store.ContextSessions(context)
+	if err != nil {
+		return err
+	}
+
+	for _, st := range stores {
+		if st.Name != session.Name || !store.IsPersistent(st.Settings) {
+			continue
+		}
+
+		s, err := st.Open()
+		if err != nil {
+			return err
+		}
+		defer s.Close()
+
+		// Stored messages would conflict with the ones sent after the reset
+		if err := s.Reset(); err != nil {
+			return err
+		}
+		if err := s.SetNextSenderMsgSeqNum(sender); err != nil {
+			return err
+		}
+		if err := s.SetNextTargetMsgSeqNum(target); err != nil {
+			return err
+		}
+
+		logger.Info().Msgf("Store of session %s reset with next sender seqnum %d and next target seqnum %d", session.Name, sender, target)
+	}
+
+	return nil
+}
+
+func writeResults(results []*conformance.Result, output string) error {
+	if output == utils.OutputFormatJSON {
+		return json.NewEncoder(os.Stdout).Encode(results)
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"TEST", "STATUS", "DURATION", "DETAILS"})
+	table.SetBorders(tablewriter.Border{Left: false, Top: false, Right: false, Bottom: true})
+	table.SetColumnSeparator(" ")
+	table.SetCenterSeparator("-")
+	table.SetAutoWrapText(false)
+
+	passed := 0
+	for _, result := range results {
+		if result.Status == conformance.StatusPass {
+			passed++
+		}
+		table.Append([]string{result.Test, strings.ToUpper(result.Status), utils.FormatLatency(result.Duration), result.Details})
+	}
+
+	table.Render()
+
+	fmt.Printf("\n%d test(s): %d passed, %d failed\n", len(results), passed, len(results)-passed)
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package daemon
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"sylr.dev/fix/config"
+	"sylr.dev/fix/pkg/daemon"
+	"sylr.dev/fix/pkg/initiator"
+	"sylr.dev/fix/pkg/utils"
+)
+
+var DaemonCmd = &cobra.Command{
+	Use:   "daemon <manifest>",
+	Short: "Run several workloads in one process",
+	Long: "Run the workloads of a YAML manifest concurrently in one process, each one on the session of its own " +
+		"context, sharing the logger and the metrics. A workload which ends is restarted according to its " +
+		"restart policy (always, on-failure or never, on-failure by default) after its restart delay. " +
+		"Workloads without context run on the one given by --context.",
+	Example:           "  fix daemon --context feed-a feeds.yaml",
+	Args:              cobra.ExactArgs(1),
+	PersistentPreRunE: utils.MakePersistentPreRunE(initiator.ValidateOptions),
+	RunE:              Execute,
+}
+
+func init() {
+	initiator.AddPersistentFlags(DaemonCmd)
+	initiator.AddPersistentFlagCompletions(DaemonCmd)
+
+	DaemonCmd.Long += fmt.Sprintf("\n\nKinds of workloads: %s.", strings.Join(daemon.Kinds(), ", "))
+}
+
+func Execute(cmd *cobra.Command, args []string) error {
+	logger := config.GetLogger()
+
+	manifest, err := daemon.Load(args[0])
+	if err != nil {
+		return err
+	}
+
+	for _, w := range manifest.Workloads {
+		if len(w.Context) == 0 {
+			continue
+		}
+		if _, err := config.GetContext(w.Context); err != nil {
+			return fmt.Errorf("workload %s: %w", w.Name, err)
+		}
+	}
+
+	logger.Info().Msgf("Running %d workload(s)", len(manifest.Workloads))
+
+	return daemon.Run(cmd.Context(), manifest, logger)
+}
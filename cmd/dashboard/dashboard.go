@@ -0,0 +1,316 @@
+package dashboard
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/quickfixgo/quickfix"
+
+	"sylr.dev/fix/config"
+	"sylr.dev/fix/pkg/errors"
+	"sylr.dev/fix/pkg/initiator"
+	"sylr.dev/fix/pkg/utils"
+)
+
+var optionRefresh time.Duration
+
+var DashboardCmd = &cobra.Command{
+	Use:   "dashboard",
+	Short: "Terminal dashboard of the sessions of a context",
+	Long: "Start all the sessions of the context and show their connection state, sequence numbers, " +
+		"message counters and most recent messages in real time. Sessions can be logged out and " +
+		"logged on again from the dashboard.",
+	Example:           "  fix dashboard --context venue",
+	Args:              cobra.ExactArgs(0),
+	ValidArgsFunction: cobra.NoFileCompletions,
+	Annotations: map[string]string{
+		initiator.MultiSessionsAnnotation: "true",
+	},
+	PersistentPreRunE: utils.MakePersistentPreRunE(initiator.ValidateOptions),
+	RunE:              Execute,
+}
+
+func init() {
+	initiator.AddPersistentFlags(DashboardCmd)
+	initiator.AddPersistentFlagCompletions(DashboardCmd)
+
+	DashboardCmd.Flags().DurationVar(&optionRefresh, "refresh", time.Second, "Refresh interval")
+}
+
+// dashboard holds the state of the screen.
+type dashboard struct {
+	context  string
+	sessions *initiator.Sessions[*monitor]
+	selected int
+	all      bool
+	status   string
+	width    int
+	height   int
+
+	rates    []float64
+	previous []int
+	lastTick time.Time
+}
+
+func Execute(cmd *cobra.Command, args []string) error {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return fmt.Errorf("%w: the dashboard needs a terminal", errors.Options)
+	}
+
+	context, err := config.GetCurrentContext()
+	if err != nil {
+		return err
+	}
+
+	sessions, err := initiator.InitiateContext(context, nil, func(session *config.Session, settings *quickfix.Settings) (*monitor, error) {
+		_, appDict, err := session.GetFIXDictionaries()
+		if err != nil {
+			return nil, err
+		}
+
+		return newMonitor(session.Name, settings, appDict), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return err
+	}
+	defer term.Restore(fd, state)
+
+	// Alternate screen, hidden cursor
+	fmt.Print("\x1b[?1049h\x1b[?25l")
+	defer fmt.Print("\x1b[?25h\x1b[?1049l")
+
+	if err = sessions.Start(); err != nil {
+		return err
+	}
+	defer sessions.Stop()
+
+	d := &dashboard{
+		context:  context.Name,
+		sessions: sessions,
+		rates:    make([]float64, len(sessions.Apps)),
+		previous: make([]int, len(sessions.Apps)),
+		lastTick: time.Now(),
+	}
+
+	keys := make(chan byte)
+	go func() {
+		buf := make([]byte, 16)
+		for {
+			n, err := os.Stdin.Read(buf)
+			if err != nil {
+				close(keys)
+				return
+			}
+			for _, b := range buf[:n] {
+				keys <- b
+			}
+		}
+	}()
+
+	ctx := cmd.Context()
+
+	refresh := time.NewTicker(optionRefresh)
+	defer refresh.Stop()
+
+	d.draw()
+
+	escape := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case <-refresh.C:
+			d.tick()
+			d.draw()
+
+		case key, ok := <-keys:
+			if !ok {
+				return nil
+			}
+
+			// Arrows are sent as ESC [ A and ESC [ B
+			switch {
+			case escape == 0 && key == 0x1b:
+				escape = 1
+				continue
+			case escape == 1 && key == '[':
+				escape = 2
+				continue
+			case escape == 2:
+				escape = 0
+				switch key {
+				case 'A':
+					key = 'k'
+				case 'B':
+					key = 'j'
+				}
+			default:
+				escape = 0
+			}
+
+			switch key {
+			case 'q', 0x03:
+				return nil
+			case 'k':
+				if d.selected > 0 {
+					d.selected--
+				}
+			case 'j':
+				if d.selected < len(sessions.Apps)-1 {
+					d.selected++
+				}
+			case 'a':
+				d.all = !d.all
+			case 'l':
+				app := sessions.Apps[d.selected]
+				if err := logon(app); err != nil {
+					d.status = fmt.Sprintf("Logon of %s failed: %s", app.Name, err)
+				} else {
+					d.status = ""
+				}
+			case 'o':
+				logout(sessions.Apps[d.selected])
+				d.status = ""
+			}
+			d.draw()
+		}
+	}
+}
+
+// tick computes the message rates since the previous tick.
+func (d *dashboard) tick() {
+	now := time.Now()
+	elapsed := now.Sub(d.lastTick).Seconds()
+	d.lastTick = now
+
+	for i, app := range d.sessions.Apps {
+		s, _ := app.App.snapshot()
+		total := s.Sent + s.Received
+		if elapsed > 0 {
+			d.rates[i] = float64(total-d.previous[i]) / elapsed
+		}
+		d.previous[i] = total
+	}
+}
+
+func (d *dashboard) draw() {
+	if width, height, err := term.GetSize(int(os.Stdout.Fd())); err == nil {
+		d.width, d.height = width, height
+	}
+
+	var lines []string
+	lines = append(lines, fmt.Sprintf("fix dashboard - context %s - %s", d.context, utils.FormatTime(time.Now())), "")
+
+	var buf bytes.Buffer
+	table := tablewriter.NewWriter(&buf)
+	table.SetHeader([]string{"", "SESSION", "SESSION ID", "STATE", "SINCE", "SEQNUM OUT", "SEQNUM IN", "SENT", "RECEIVED", "REJECTS", "MSG/S", "LAST HEARTBEAT"})
+	table.SetBorders(tablewriter.Border{Left: false, Top: false, Right: false, Bottom: true})
+	table.SetColumnSeparator(" ")
+	table.SetCenterSeparator("-")
+	table.SetAutoWrapText(false)
+	table.SetAutoFormatHeaders(false)
+
+	var recent []recentMessage
+	for i, app := range d.sessions.Apps {
+		s, messages := app.App.snapshot()
+
+		marker := ""
+		if i == d.selected {
+			marker = ">"
+		}
+		if d.all || i == d.selected {
+			recent = append(recent, messages...)
+		}
+
+		var sessionID string
+		for id := range app.Settings.SessionSettings() {
+			sessionID = id.String()
+		}
+
+		heartbeat := ""
+		if !s.LastHeartbeat.IsZero() {
+			heartbeat = utils.FormatTimeOnly(s.LastHeartbeat)
+		}
+
+		table.Append([]string{
+			marker,
+			app.Name,
+			sessionID,
+			s.State,
+			time.Since(s.StateSince).Truncate(time.Second).String(),
+			strconv.Itoa(s.LastSentSeqNum),
+			strconv.Itoa(s.LastRecvSeqNum),
+			strconv.Itoa(s.Sent),
+			strconv.Itoa(s.Received),
+			strconv.Itoa(s.Rejects),
+			strconv.FormatFloat(d.rates[i], 'f', 1, 64),
+			heartbeat,
+		})
+	}
+	table.Render()
+	lines = append(lines, strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")...)
+
+	title := "Recent messages of " + d.sessions.Apps[d.selected].Name
+	if d.all {
+		title = "Recent messages of all sessions"
+		// Messages of the different sessions are merged by time
+		sort.SliceStable(recent, func(i, j int) bool { return recent[i].Time.Before(recent[j].Time) })
+	}
+	lines = append(lines, "", title)
+
+	// Keep the room for the footer
+	room := d.height - len(lines) - 3
+	if room < 0 {
+		room = 0
+	}
+	if len(recent) > room {
+		recent = recent[len(recent)-room:]
+	}
+	for _, m := range recent {
+		direction := "<-"
+		if m.Sent {
+			direction = "->"
+		}
+		lines = append(lines, fmt.Sprintf("%s %s %-8s %6d %-24s %s", utils.FormatTimeOnly(m.Time), direction, m.Session, m.SeqNum, m.MsgType, strings.ReplaceAll(m.Raw, "\001", "|")))
+	}
+
+	for len(lines) < d.height-2 {
+		lines = append(lines, "")
+	}
+	lines = append(lines, d.status, "up/down: select  l: logon  o: logout  a: all sessions  q: quit")
+
+	var out bytes.Buffer
+	out.WriteString("\x1b[H")
+	for i, line := range lines {
+		if d.height > 0 && i >= d.height {
+			break
+		}
+		if d.width > 0 && len(line) > d.width {
+			line = line[:d.width]
+		}
+		out.WriteString(line)
+		out.WriteString("\x1b[K")
+		if i < len(lines)-1 {
+			out.WriteString("\r\n")
+		}
+	}
+	out.WriteString("\x1b[J")
+
+	os.Stdout.Write(out.Bytes())
+}
@@ -0,0 +1,290 @@
+package dashboard
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/quickfix/datadictionary"
+
+	"sylr.dev/fix/pkg/initiator"
+	"sylr.dev/fix/pkg/initiator/application"
+)
+
+const maxRecentMessages = 200
+
+const (
+	stateConnecting = "connecting"
+	stateLoggedOn   = "logged on"
+	stateLoggedOut  = "logged out"
+	stateLoggingOut = "logging out"
+	stateStopped    = "stopped"
+)
+
+// recentMessage is a message sent or received by a session.
+type recentMessage struct {
+	Time    time.Time
+	Session string
+	Sent    bool
+	SeqNum  int
+	MsgType string
+	Raw     string
+}
+
+// stats is a snapshot of the state of a session.
+type stats struct {
+	State          string
+	StateSince     time.Time
+	LastSentSeqNum int
+	LastRecvSeqNum int
+	Sent           int
+	Received       int
+	Rejects        int
+	LastReceived   time.Time
+	LastHeartbeat  time.Time
+}
+
+// monitor is the application of a session of the dashboard. It records the
+// messages and state of the session and delegates everything else to an
+// application.Initiator, which is renewed after each logout as it can not be
+// logged on twice.
+type monitor struct {
+	name      string
+	settings  *quickfix.Settings
+	appDict   *datadictionary.DataDictionary
+	sessionID quickfix.SessionID
+	inner     *application.Initiator
+	stats     stats
+	recent    []recentMessage
+	mu        sync.Mutex
+}
+
+func newMonitor(name string, settings *quickfix.Settings, appDict *datadictionary.DataDictionary) *monitor {
+	m := &monitor{
+		name:     name,
+		settings: settings,
+		appDict:  appDict,
+		stats:    stats{State: stateConnecting, StateSince: time.Now()},
+	}
+	m.inner = m.newInner()
+
+	return m
+}
+
+// newInner returns a fresh application whose channels are drained.
+func (m *monitor) newInner() *application.Initiator {
+	logger := zerolog.Nop()
+
+	app := application.NewInitiator()
+	app.Settings = m.settings
+	app.Logger = &logger
+	app.SessionID = m.sessionID
+
+	go func() {
+		connected, fromApp, toApp := app.Connected, app.FromAppMessages, app.ToAppMessages
+		for connected != nil || fromApp != nil || toApp != nil {
+			select {
+			case _, ok := <-connected:
+				if !ok {
+					connected = nil
+				}
+			case _, ok := <-fromApp:
+				if !ok {
+					fromApp = nil
+				}
+			case _, ok := <-toApp:
+				if !ok {
+					toApp = nil
+				}
+			}
+		}
+	}()
+
+	return app
+}
+
+func (m *monitor) setState(state string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.stats.State = state
+	m.stats.StateSince = time.Now()
+}
+
+// snapshot returns the stats and the recent messages of the session.
+func (m *monitor) snapshot() (stats, []recentMessage) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	recent := make([]recentMessage, len(m.recent))
+	copy(recent, m.recent)
+
+	return m.stats, recent
+}
+
+func (m *monitor) record(message *quickfix.Message, sent bool) {
+	msgType, _ := message.MsgType()
+	seqNum, _ := message.Header.GetInt(quickfix.Tag(34))
+
+	name := msgType
+	if m.appDict != nil {
+		if def, ok := m.appDict.Messages[msgType]; ok {
+			name = def.Name
+		}
+	}
+	switch msgType {
+	case "0":
+		name = "Heartbeat"
+	case "1":
+		name = "TestRequest"
+	case "2":
+		name = "ResendRequest"
+	case "3":
+		name = "Reject"
+	case "4":
+		name = "SequenceReset"
+	case "5":
+		name = "Logout"
+	case "A":
+		name = "Logon"
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if sent {
+		m.stats.Sent++
+		m.stats.LastSentSeqNum = seqNum
+	} else {
+		m.stats.Received++
+		m.stats.LastRecvSeqNum = seqNum
+		m.stats.LastReceived = now
+		switch msgType {
+		case "0":
+			m.stats.LastHeartbeat = now
+		case "3", "j":
+			m.stats.Rejects++
+		}
+	}
+
+	m.recent = append(m.recent, recentMessage{
+		Time:    now,
+		Session: m.name,
+		Sent:    sent,
+		SeqNum:  seqNum,
+		MsgType: name,
+		Raw:     message.String(),
+	})
+	if len(m.recent) > maxRecentMessages {
+		m.recent = m.recent[len(m.recent)-maxRecentMessages:]
+	}
+}
+
+func (m *monitor) current() *application.Initiator {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.inner
+}
+
+// Stop stops the current application.
+func (m *monitor) Stop() {
+	m.current().Stop()
+}
+
+func (m *monitor) OnCreate(sessionID quickfix.SessionID) {
+	m.mu.Lock()
+	m.sessionID = sessionID
+	m.mu.Unlock()
+
+	m.current().OnCreate(sessionID)
+}
+
+func (m *monitor) OnLogon(sessionID quickfix.SessionID) {
+	m.setState(stateLoggedOn)
+	m.current().OnLogon(sessionID)
+}
+
+func (m *monitor) OnLogout(sessionID quickfix.SessionID) {
+	m.current().OnLogout(sessionID)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.inner = m.newInner()
+	if m.stats.State != stateLoggingOut && m.stats.State != stateStopped {
+		m.stats.State = stateLoggedOut
+		m.stats.StateSince = time.Now()
+	}
+}
+
+func (m *monitor) ToAdmin(message *quickfix.Message, sessionID quickfix.SessionID) {
+	m.current().ToAdmin(message, sessionID)
+	m.record(message, true)
+}
+
+func (m *monitor) FromAdmin(message *quickfix.Message, sessionID quickfix.SessionID) quickfix.MessageRejectError {
+	m.record(message, false)
+	return m.current().FromAdmin(message, sessionID)
+}
+
+func (m *monitor) ToApp(message *quickfix.Message, sessionID quickfix.SessionID) error {
+	err := m.current().ToApp(message, sessionID)
+	m.record(message, true)
+
+	return err
+}
+
+func (m *monitor) FromApp(message *quickfix.Message, sessionID quickfix.SessionID) quickfix.MessageRejectError {
+	m.record(message, false)
+	return m.current().FromApp(message, sessionID)
+}
+
+// logon starts a new initiator for the session if it is not running.
+func logon(app *initiator.SessionApp[*monitor]) error {
+	m := app.App
+	m.mu.Lock()
+	if m.stats.State != stateStopped {
+		m.mu.Unlock()
+		return nil
+	}
+	m.stats.State = stateConnecting
+	m.stats.StateSince = time.Now()
+	m.mu.Unlock()
+
+	init, err := initiator.Initiate(m, app.Settings, nil)
+	if err != nil {
+		m.setState(stateStopped)
+		return err
+	}
+	if err := init.Start(); err != nil {
+		m.setState(stateStopped)
+		return err
+	}
+	app.Initiator = init
+
+	return nil
+}
+
+// logout stops the initiator of the session, which logs it out, in the
+// background.
+func logout(app *initiator.SessionApp[*monitor]) {
+	m := app.App
+	m.mu.Lock()
+	if m.stats.State == stateStopped || m.stats.State == stateLoggingOut {
+		m.mu.Unlock()
+		return
+	}
+	m.stats.State = stateLoggingOut
+	m.stats.StateSince = time.Now()
+	init := app.Initiator
+	m.mu.Unlock()
+
+	go func() {
+		initiator.Stop(init, app.Settings)
+		m.setState(stateStopped)
+	}()
+}
@@ -0,0 +1,190 @@
+package decode
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+
+	"sylr.dev/fix/config"
+	"sylr.dev/fix/pkg/dictionary"
+	"sylr.dev/fix/pkg/errors"
+	"sylr.dev/fix/pkg/utils"
+)
+
+var (
+	optionFile   string
+	optionVerify bool
+)
+
+var DecodeCmd = &cobra.Command{
+	Use:   "decode [message...]",
+	Short: "Decode raw FIX messages",
+	Long: "Decode raw FIX messages given as arguments, read from a file or from stdin, one per line, " +
+		"using the configured data dictionaries. Fields can be delimited by SOH, pipes or carets " +
+		"and lines can contain anything before the message (e.g. log timestamps). With --verify, " +
+		"BodyLength and CheckSum are recomputed and compared to the ones of the messages, giving the " +
+		"byte offsets involved, instead of decoding them.",
+	Example: "  grep 35=8 session.log | fix decode --session venue -o raw\n" +
+		"  fix decode --verify '8=FIX.4.4|9=12|35=0|49=A|56=B|34=1|52=20240101-00:00:00|10=000|'",
+	ValidArgsFunction: cobra.NoFileCompletions,
+	PersistentPreRunE: utils.MakePersistentPreRunE(dictionary.ValidateDictionaryOptions),
+	RunE:              Execute,
+}
+
+func init() {
+	dictionary.AddPersistentFlags(DecodeCmd)
+
+	DecodeCmd.Flags().StringVarP(&optionFile, "file", "f", "", "File to read messages from (- for stdin)")
+	DecodeCmd.Flags().BoolVar(&optionVerify, "verify", false, "Verify BodyLength and CheckSum instead of decoding")
+
+	dictionary.AddPersistentFlagCompletions(DecodeCmd)
+}
+
+func Execute(cmd *cobra.Command, args []string) error {
+	options := config.GetOptions()
+	logger := config.GetLogger()
+
+	transportDict, appDict, err := dictionary.GetFIXDictionaries()
+	if err != nil {
+		return err
+	}
+
+	var messages []string
+	if len(args) > 0 {
+		for _, arg := range args {
+			if raw := utils.QuickFixRawMessage(arg); len(raw) > 0 {
+				messages = append(messages, raw)
+			}
+		}
+	} else {
+		var r io.Reader = os.Stdin
+		if len(optionFile) > 0 && optionFile != "-" {
+			file, err := os.Open(optionFile)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			r = file
+		}
+
+		if messages, err = utils.ReadQuickFixRawMessages(r); err != nil {
+			return err
+		}
+	}
+
+	if optionVerify {
+		return verify(messages, options.Output)
+	}
+
+	printer := utils.QuickFixAppMessageLogger{
+		Logger:                  logger,
+		TransportDataDictionary: transportDict,
+		AppDataDictionary:       appDict,
+		OutputFormat:            options.Output,
+	}
+
+	failed := 0
+	for i, raw := range messages {
+		// Hand-edited messages often have a wrong BodyLength which would
+		// prevent them from being parsed.
+		if fixed, err := utils.QuickFixRawMessageSetBodyLength(raw); err == nil && fixed != raw {
+			logger.Warn().Msgf("Message #%d has a wrong BodyLength", i+1)
+			raw = fixed
+		}
+
+		message, err := utils.ParseQuickFixRawMessage(raw, transportDict, appDict)
+		if err != nil {
+			logger.Error().Err(err).Msgf("Unable to parse message #%d", i+1)
+			failed++
+			continue
+		}
+
+		if options.Output == utils.OutputFormatTable {
+			if i > 0 {
+				fmt.Println()
+			}
+			printer.WriteMessageAsTable(os.Stdout, message)
+		} else {
+			printer.WriteMessage(os.Stdout, message)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%w: %d message(s) out of %d", errors.FixMessageParse, failed, len(messages))
+	}
+
+	return nil
+}
+
+type verification struct {
+	Message int `json:"message"`
+	utils.QuickFixRawCheck
+}
+
+// verify reports the BodyLength and CheckSum discrepancies of the messages.
+func verify(messages []string, output string) error {
+	logger := config.GetLogger()
+
+	var verifications []verification
+	invalid := 0
+
+	for i, raw := range messages {
+		checks, err := utils.QuickFixRawVerify(raw)
+		if err != nil {
+			logger.Error().Err(err).Msgf("Unable to verify message #%d", i+1)
+			invalid++
+			continue
+		}
+
+		valid := true
+		for _, check := range checks {
+			verifications = append(verifications, verification{Message: i + 1, QuickFixRawCheck: check})
+			valid = valid && check.Valid
+		}
+		if !valid {
+			invalid++
+		}
+	}
+
+	if output == utils.OutputFormatJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		for _, v := range verifications {
+			if err := encoder.Encode(v); err != nil {
+				return err
+			}
+		}
+	} else if len(verifications) > 0 {
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"MESSAGE", "TAG", "FIELD", "OFFSET", "VALUE", "EXPECTED", "STATUS", "TEXT"})
+		table.SetBorders(tablewriter.Border{Left: false, Top: false, Right: false, Bottom: true})
+		table.SetColumnSeparator(" ")
+		table.SetCenterSeparator("-")
+		table.SetAutoWrapText(false)
+		table.SetColumnAlignment([]int{
+			tablewriter.ALIGN_RIGHT, tablewriter.ALIGN_RIGHT, tablewriter.ALIGN_LEFT, tablewriter.ALIGN_RIGHT,
+			tablewriter.ALIGN_RIGHT, tablewriter.ALIGN_RIGHT, tablewriter.ALIGN_LEFT, tablewriter.ALIGN_LEFT,
+		})
+		for _, v := range verifications {
+			status := "OK"
+			if !v.Valid {
+				status = "MISMATCH"
+			}
+			table.Append([]string{
+				strconv.Itoa(v.Message), strconv.Itoa(v.Tag), v.Field, strconv.Itoa(v.Offset),
+				v.Value, v.Expected, status, v.Text,
+			})
+		}
+		table.Render()
+	}
+
+	if invalid > 0 {
+		return fmt.Errorf("%w: %d message(s) out of %d", errors.FixMessageInvalid, invalid, len(messages))
+	}
+
+	return nil
+}
@@ -0,0 +1,22 @@
+package dictionary
+
+import (
+	"github.com/spf13/cobra"
+
+	"sylr.dev/fix/cmd/dictionary/field"
+	"sylr.dev/fix/cmd/dictionary/message"
+	"sylr.dev/fix/cmd/dictionary/update"
+)
+
+var DictionaryCmd = &cobra.Command{
+	Use:     "dictionary",
+	Aliases: []string{"dict"},
+	Short:   "Manage and query FIX data dictionaries",
+	Long:    "Manage and query FIX data dictionaries.",
+}
+
+func init() {
+	DictionaryCmd.AddCommand(field.DictionaryFieldCmd)
+	DictionaryCmd.AddCommand(message.DictionaryMessageCmd)
+	DictionaryCmd.AddCommand(update.DictionaryUpdateCmd)
+}
@@ -0,0 +1,115 @@
+package field
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/quickfixgo/quickfix/datadictionary"
+	"github.com/spf13/cobra"
+
+	"sylr.dev/fix/pkg/dictionary"
+	"sylr.dev/fix/pkg/errors"
+	"sylr.dev/fix/pkg/utils"
+)
+
+var DictionaryFieldCmd = &cobra.Command{
+	Use:               "field <tag|name>",
+	Short:             "Look up a field in the data dictionaries",
+	Long:              "Look up a field by tag number or by name in the data dictionaries and list its enum values.",
+	Example:           "  fix dict field 54\n  fix dict field ordtype",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: cobra.NoFileCompletions,
+	PersistentPreRunE: utils.MakePersistentPreRunE(dictionary.ValidateDictionaryOptions),
+	RunE:              Execute,
+}
+
+func init() {
+	dictionary.AddPersistentFlags(DictionaryFieldCmd)
+	dictionary.AddPersistentFlagCompletions(DictionaryFieldCmd)
+}
+
+func Execute(cmd *cobra.Command, args []string) error {
+	transportDict, appDict, err := dictionary.GetFIXDictionaries()
+	if err != nil {
+		return err
+	}
+
+	fieldType := lookup(args[0], appDict, transportDict)
+	if fieldType == nil {
+		return fmt.Errorf("%w: unknown field `%s`", errors.Options, args[0])
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"TAG", "NAME", "TYPE"})
+	table.SetBorders(tablewriter.Border{Left: false, Top: false, Right: false, Bottom: true})
+	table.SetColumnSeparator(" ")
+	table.SetCenterSeparator("-")
+	table.Append([]string{strconv.Itoa(fieldType.Tag()), fieldType.Name(), fieldType.Type})
+	table.Render()
+
+	if len(fieldType.Enums) == 0 {
+		return nil
+	}
+
+	values := make([]string, 0, len(fieldType.Enums))
+	for value := range fieldType.Enums {
+		values = append(values, value)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		a, errA := strconv.Atoi(values[i])
+		b, errB := strconv.Atoi(values[j])
+		if errA == nil && errB == nil {
+			return a < b
+		}
+		return values[i] < values[j]
+	})
+
+	fmt.Println()
+
+	table = tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"VALUE", "DESCRIPTION"})
+	table.SetBorders(tablewriter.Border{Left: false, Top: false, Right: false, Bottom: true})
+	table.SetColumnSeparator(" ")
+	table.SetCenterSeparator("-")
+	table.SetColumnAlignment([]int{tablewriter.ALIGN_RIGHT, tablewriter.ALIGN_LEFT})
+	for _, value := range values {
+		table.Append([]string{value, fieldType.Enums[value].Description})
+	}
+	table.Render()
+
+	return nil
+}
+
+// lookup searches the field by tag number or by name, case insensitively,
+// in the given dictionaries.
+func lookup(search string, dicts ...*datadictionary.DataDictionary) *datadictionary.FieldType {
+	tag, err := strconv.Atoi(search)
+
+	for _, dict := range dicts {
+		if dict == nil {
+			continue
+		}
+
+		if err == nil {
+			if fieldType, ok := dict.FieldTypeByTag[tag]; ok {
+				return fieldType
+			}
+			continue
+		}
+
+		if fieldType, ok := dict.FieldTypeByName[search]; ok {
+			return fieldType
+		}
+		for name, fieldType := range dict.FieldTypeByName {
+			if strings.EqualFold(name, search) {
+				return fieldType
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,133 @@
+package message
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/quickfixgo/quickfix/datadictionary"
+	"github.com/spf13/cobra"
+
+	"sylr.dev/fix/pkg/dictionary"
+	"sylr.dev/fix/pkg/errors"
+	"sylr.dev/fix/pkg/utils"
+)
+
+var (
+	optionRequired bool
+)
+
+var DictionaryMessageCmd = &cobra.Command{
+	Use:               "message [msgtype|name]",
+	Aliases:           []string{"msg"},
+	Short:             "Look up a message in the data dictionaries",
+	Long:              "List the fields of a message, given by MsgType or by name, or list all the messages when none is given.",
+	Example:           "  fix dict message D --required\n  fix dict message executionreport",
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: cobra.NoFileCompletions,
+	PersistentPreRunE: utils.MakePersistentPreRunE(dictionary.ValidateDictionaryOptions),
+	RunE:              Execute,
+}
+
+func init() {
+	dictionary.AddPersistentFlags(DictionaryMessageCmd)
+
+	DictionaryMessageCmd.Flags().BoolVar(&optionRequired, "required", false, "Only list required fields")
+
+	dictionary.AddPersistentFlagCompletions(DictionaryMessageCmd)
+}
+
+func Execute(cmd *cobra.Command, args []string) error {
+	_, appDict, err := dictionary.GetFIXDictionaries()
+	if err != nil {
+		return err
+	}
+
+	if appDict == nil {
+		return fmt.Errorf("%w: no application data dictionary", errors.Options)
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetBorders(tablewriter.Border{Left: false, Top: false, Right: false, Bottom: true})
+	table.SetColumnSeparator(" ")
+	table.SetCenterSeparator("-")
+
+	if len(args) == 0 {
+		msgTypes := make([]string, 0, len(appDict.Messages))
+		for msgType := range appDict.Messages {
+			msgTypes = append(msgTypes, msgType)
+		}
+		sort.Slice(msgTypes, func(i, j int) bool {
+			if len(msgTypes[i]) != len(msgTypes[j]) {
+				return len(msgTypes[i]) < len(msgTypes[j])
+			}
+			return msgTypes[i] < msgTypes[j]
+		})
+
+		table.SetHeader([]string{"MSGTYPE", "NAME"})
+		for _, msgType := range msgTypes {
+			table.Append([]string{msgType, appDict.Messages[msgType].Name})
+		}
+		table.Render()
+
+		return nil
+	}
+
+	msgDef := lookup(args[0], appDict)
+	if msgDef == nil {
+		return fmt.Errorf("%w: unknown message `%s`", errors.Options, args[0])
+	}
+
+	table.SetHeader([]string{"TAG", "NAME", "REQUIRED"})
+	table.SetColumnAlignment([]int{tablewriter.ALIGN_RIGHT, tablewriter.ALIGN_LEFT, tablewriter.ALIGN_LEFT})
+	appendParts(table, msgDef.Parts, 0)
+	table.Render()
+
+	return nil
+}
+
+// appendParts appends the fields, components and repeating groups in their
+// declaration order, the content of components and groups being indented.
+func appendParts(table *tablewriter.Table, parts []datadictionary.MessagePart, depth int) {
+	indent := strings.Repeat("  ", depth)
+
+	for _, part := range parts {
+		if optionRequired && !part.Required() {
+			continue
+		}
+
+		required := "N"
+		if part.Required() {
+			required = "Y"
+		}
+
+		switch p := part.(type) {
+		case *datadictionary.FieldDef:
+			table.Append([]string{strconv.Itoa(p.Tag()), indent + p.Name(), required})
+			if p.IsGroup() {
+				appendParts(table, p.Parts, depth+1)
+			}
+
+		case datadictionary.Component:
+			table.Append([]string{"", indent + "<" + p.Name() + ">", required})
+			appendParts(table, p.Parts(), depth+1)
+		}
+	}
+}
+
+func lookup(search string, dict *datadictionary.DataDictionary) *datadictionary.MessageDef {
+	if msgDef, ok := dict.Messages[search]; ok {
+		return msgDef
+	}
+
+	for _, msgDef := range dict.Messages {
+		if strings.EqualFold(msgDef.Name, search) {
+			return msgDef
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package update
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"sylr.dev/fix/config"
+	"sylr.dev/fix/pkg/dictionary"
+	"sylr.dev/fix/pkg/utils"
+)
+
+var DictionaryUpdateCmd = &cobra.Command{
+	Use:               "update",
+	Short:             "Refresh remote data dictionaries",
+	Long:              "Download again the data dictionaries referenced by URL in the sessions and refresh the local cache.",
+	Args:              cobra.ExactArgs(0),
+	ValidArgsFunction: cobra.NoFileCompletions,
+	PersistentPreRunE: utils.MakePersistentPreRunE(dictionary.ValidateOptions),
+	RunE:              Execute,
+}
+
+func Execute(cmd *cobra.Command, args []string) error {
+	done := make(map[string]bool)
+
+	for _, session := range config.GetConfig().Sessions {
+		for _, location := range []string{session.TransportDataDictionary, session.AppDataDictionary} {
+			if done[location] || !config.IsRemoteDictionary(location) {
+				continue
+			}
+
+			path, err := config.UpdateDictionary(location)
+			if err != nil {
+				return err
+			}
+
+			done[location] = true
+			fmt.Printf("Dictionary %s cached in %s\n", location, path)
+		}
+	}
+
+	if len(done) == 0 {
+		fmt.Println("No remote dictionary found in configuration.")
+	}
+
+	return nil
+}
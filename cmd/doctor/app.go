@@ -0,0 +1,95 @@
+package doctor
+
+import (
+	"sync"
+	"time"
+
+	"github.com/quickfixgo/enum"
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/tag"
+
+	"sylr.dev/fix/pkg/initiator/application"
+)
+
+// sessionLogon holds what is needed of the logon exchange.
+type sessionLogon struct {
+	sentSeqNum  int
+	seqNum      int
+	sendingTime time.Time
+	received    time.Time
+	resetSeqNum bool
+	heartBtInt  int
+}
+
+// doctorApp is an initiator application which records the administrative
+// messages exchanged with the counterparty.
+type doctorApp struct {
+	*application.Initiator
+
+	mux    sync.Mutex
+	logon  sessionLogon
+	logout bool
+	text   string
+}
+
+func newDoctorApp() *doctorApp {
+	return &doctorApp{Initiator: application.NewInitiator()}
+}
+
+// Notification of admin message being sent to target.
+func (app *doctorApp) ToAdmin(message *quickfix.Message, sessionID quickfix.SessionID) {
+	app.Initiator.ToAdmin(message, sessionID)
+
+	if msgType, err := message.MsgType(); err == nil && msgType == string(enum.MsgType_LOGON) {
+		app.mux.Lock()
+		app.logon.sentSeqNum, _ = message.Header.GetInt(tag.MsgSeqNum)
+		app.mux.Unlock()
+	}
+}
+
+// Notification of admin message being received from target.
+func (app *doctorApp) FromAdmin(message *quickfix.Message, sessionID quickfix.SessionID) quickfix.MessageRejectError {
+	received := time.Now()
+
+	msgType, err := message.MsgType()
+	if err != nil {
+		return app.Initiator.FromAdmin(message, sessionID)
+	}
+
+	app.mux.Lock()
+	switch msgType {
+	case string(enum.MsgType_LOGON):
+		app.logon.received = received
+		app.logon.seqNum, _ = message.Header.GetInt(tag.MsgSeqNum)
+		app.logon.sendingTime, _ = message.Header.GetTime(tag.SendingTime)
+		app.logon.heartBtInt, _ = message.Body.GetInt(tag.HeartBtInt)
+		app.logon.resetSeqNum, _ = message.Body.GetBool(tag.ResetSeqNumFlag)
+	case string(enum.MsgType_LOGOUT):
+		app.logout = true
+		app.text, _ = message.Body.GetString(tag.Text)
+	}
+	app.mux.Unlock()
+
+	return app.Initiator.FromAdmin(message, sessionID)
+}
+
+func (app *doctorApp) receivedLogon() sessionLogon {
+	app.mux.Lock()
+	defer app.mux.Unlock()
+
+	return app.logon
+}
+
+func (app *doctorApp) logoutReceived() bool {
+	app.mux.Lock()
+	defer app.mux.Unlock()
+
+	return app.logout
+}
+
+func (app *doctorApp) logoutText() string {
+	app.mux.Lock()
+	defer app.mux.Unlock()
+
+	return app.text
+}
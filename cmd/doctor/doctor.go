@@ -0,0 +1,378 @@
+package doctor
+
+import (
+	stdcontext "context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/quickfixgo/quickfix"
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+
+	"sylr.dev/fix/config"
+	"sylr.dev/fix/pkg/initiator"
+	"sylr.dev/fix/pkg/store"
+	"sylr.dev/fix/pkg/utils"
+)
+
+// Statuses of the checks.
+const (
+	statusOK   = "ok"
+	statusWarn = "warn"
+	statusFail = "fail"
+	statusSkip = "skip"
+)
+
+var (
+	optionMaxClockSkew   time.Duration
+	optionCertExpiryWarn time.Duration
+)
+
+var DoctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Diagnose the connectivity of the sessions of a context",
+	Long: "Resolve the host of the initiator, test its TCP and TLS reachability, validate the dictionaries, " +
+		"log on and log out each session of the context, check the sequence numbers and the clock skew " +
+		"with the counterparty and print a checklist of the results.",
+	Example:           "  fix doctor --context venue",
+	Args:              cobra.ExactArgs(0),
+	ValidArgsFunction: cobra.NoFileCompletions,
+	Annotations: map[string]string{
+		initiator.MultiSessionsAnnotation: "true",
+	},
+	PersistentPreRunE: utils.MakePersistentPreRunE(initiator.ValidateOptions),
+	RunE:              Execute,
+}
+
+func init() {
+	initiator.AddPersistentFlags(DoctorCmd)
+	initiator.AddPersistentFlagCompletions(DoctorCmd)
+
+	DoctorCmd.Flags().DurationVar(&optionMaxClockSkew, "max-clock-skew", time.Second, "Clock skew above which a warning is raised")
+	DoctorCmd.Flags().DurationVar(&optionCertExpiryWarn, "cert-expiry-warning", 30*24*time.Hour, "Warn when the server certificate expires within this duration")
+}
+
+// check is a line of the report.
+type check struct {
+	Session string `json:"session,omitempty"`
+	Check   string `json:"check"`
+	Status  string `json:"status"`
+	Details string `json:"details"`
+}
+
+type report struct {
+	checks []check
+}
+
+func (r *report) add(session, name, status, details string, args ...any) {
+	if len(args) > 0 {
+		details = fmt.Sprintf(details, args...)
+	}
+	r.checks = append(r.checks, check{Session: session, Check: name, Status: status, Details: details})
+}
+
+func (r *report) failed() int {
+	n := 0
+	for _, c := range r.checks {
+		if c.Status == statusFail {
+			n++
+		}
+	}
+	return n
+}
+
+func Execute(cmd *cobra.Command, args []string) error {
+	options := config.GetOptions()
+
+	context, err := config.GetCurrentContext()
+	if err != nil {
+		return err
+	}
+
+	initiatorConfig, err := context.GetInitiator()
+	if err != nil {
+		return err
+	}
+
+	sessions, err := context.GetSessions()
+	if err != nil {
+		return err
+	}
+
+	// Choose right timeout cli option > config > default value (5s)
+	var timeout time.Duration
+	if options.Timeout != time.Duration(0) {
+		timeout = options.Timeout
+	} else if initiatorConfig.SocketTimeout != time.Duration(0) {
+		timeout = initiatorConfig.SocketTimeout
+	} else {
+		timeout = 5 * time.Second
+	}
+
+	ctx := cmd.Context()
+	r := &report{}
+
+	// The sessions share the host of the initiator, the TLS handshake is
+	// checked with the TLS settings of the first one.
+	networkConfig := initiatorConfig
+	if len(sessions) > 0 {
+		networkConfig = sessions[0].TLS.ApplyTo(initiatorConfig)
+	}
+	reachable := checkNetwork(ctx, r, networkConfig, timeout)
+
+	for i, session := range sessions {
+		// Make a copy of the context which has only one session.
+		contextSingleSession := *context
+		contextSingleSession.Sessions = context.Sessions[i : i+1]
+
+		checkSession(ctx, r, &contextSingleSession, session, reachable, timeout)
+	}
+
+	if err := writeReport(r, options.Output); err != nil {
+		return err
+	}
+
+	if n := r.failed(); n > 0 {
+		return fmt.Errorf("%d check(s) failed", n)
+	}
+
+	return nil
+}
+
+// checkNetwork resolves the host of the initiator and connects to it, it
+// returns whether the host is reachable.
+func checkNetwork(ctx stdcontext.Context, r *report, initiatorConfig *config.Initiator, timeout time.Duration) bool {
+	host := initiatorConfig.SocketConnectHost
+	address := net.JoinHostPort(host, strconv.Itoa(initiatorConfig.SocketConnectPort))
+
+	// DNS
+	if net.ParseIP(host) != nil {
+		r.add("", "resolve host", statusOK, "%s is an IP address", host)
+	} else {
+		lookupCtx, cancel := stdcontext.WithTimeout(ctx, timeout)
+		start := time.Now()
+		addrs, err := net.DefaultResolver.LookupHost(lookupCtx, host)
+		cancel()
+		if err != nil {
+			r.add("", "resolve host", statusFail, "%s: %s", host, err)
+			r.add("", "tcp connect", statusSkip, "host could not be resolved")
+			r.add("", "tls handshake", statusSkip, "host could not be resolved")
+			return false
+		}
+		r.add("", "resolve host", statusOK, "%s resolved to %s in %s", host, strings.Join(addrs, ", "), utils.FormatLatency(time.Since(start)))
+	}
+
+	// TCP
+	start := time.Now()
+	dialer := &net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		r.add("", "tcp connect", statusFail, "%s", err)
+		r.add("", "tls handshake", statusSkip, "host is not reachable")
+		return false
+	}
+	r.add("", "tcp connect", statusOK, "connected to %s in %s", conn.RemoteAddr(), utils.FormatLatency(time.Since(start)))
+	conn.Close()
+
+	// TLS
+	if !initiatorConfig.SocketUseSSL {
+		r.add("", "tls handshake", statusSkip, "SocketUseSSL is disabled")
+		return true
+	}
+
+	tlsConfig, err := initiator.TLSConfig(initiatorConfig)
+	if err != nil {
+		r.add("", "tls handshake", statusFail, "%s", err)
+		return false
+	}
+
+	start = time.Now()
+	tlsDialer := &tls.Dialer{NetDialer: dialer, Config: tlsConfig}
+	tlsConn, err := tlsDialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		r.add("", "tls handshake", statusFail, "%s", err)
+		return false
+	}
+	defer tlsConn.Close()
+
+	state := tlsConn.(*tls.Conn).ConnectionState()
+	details := fmt.Sprintf("%s in %s", tls.VersionName(state.Version), utils.FormatLatency(time.Since(start)))
+	status := statusOK
+
+	if len(state.PeerCertificates) > 0 {
+		cert := state.PeerCertificates[0]
+		details += fmt.Sprintf(", certificate %s expires %s", cert.Subject.CommonName, utils.FormatTime(cert.NotAfter))
+		if time.Until(cert.NotAfter) < optionCertExpiryWarn {
+			status = statusWarn
+		}
+	}
+	if initiatorConfig.SocketInsecureSkipVerify {
+		details += ", certificate not verified"
+		status = statusWarn
+	}
+	r.add("", "tls handshake", status, details)
+
+	return true
+}
+
+// checkSession validates the dictionaries of the session then logs it on and
+// out, context must only have this session.
+func checkSession(ctx stdcontext.Context, r *report, context *config.Context, session *config.Session, reachable bool, timeout time.Duration) {
+	options := config.GetOptions()
+	name := session.Name
+
+	transportDict, appDict, err := session.GetFIXDictionaries()
+	if err != nil {
+		r.add(name, "dictionaries", statusFail, "%s", err)
+		r.add(name, "logon", statusSkip, "invalid dictionaries")
+		return
+	}
+	r.add(name, "dictionaries", statusOK, "transport %s, application %s", dictionaryVersion(transportDict.FIXType, transportDict.Major, transportDict.Minor, transportDict.ServicePack), dictionaryVersion(appDict.FIXType, appDict.Major, appDict.Minor, appDict.ServicePack))
+
+	if !reachable {
+		r.add(name, "logon", statusSkip, "host is not reachable")
+		return
+	}
+
+	settings, err := context.ToQuickFixInitiatorSettings()
+	if err != nil {
+		r.add(name, "logon", statusFail, "%s", err)
+		return
+	}
+
+	// Sequence numbers expected before logging on
+	expectedSender, expectedTarget, persistent := 1, 1, false
+	if stores, err := store.ContextSessions(context); err == nil && len(stores) == 1 && store.IsPersistent(stores[0].Settings) {
+		if s, err := stores[0].Open(); err == nil {
+			expectedSender, expectedTarget, persistent = s.NextSenderMsgSeqNum(), s.NextTargetMsgSeqNum(), true
+			s.Close()
+		}
+	}
+	if session.ResetOnLogon {
+		expectedSender, expectedTarget = 1, 1
+	}
+
+	// Messages would clutter the report
+	logger := session.GetLogger()
+	if options.Verbose == 0 && logger.GetLevel() < zerolog.WarnLevel {
+		l := logger.Level(zerolog.WarnLevel)
+		logger = &l
+	}
+
+	var quickfixLogger *zerolog.Logger
+	if options.QuickFixLogging {
+		quickfixLogger = logger
+	}
+
+	app := newDoctorApp()
+	app.Logger = logger
+	app.Settings = settings
+	app.TransportDataDictionary = transportDict
+	app.AppDataDictionary = appDict
+
+	// The counterparty may send application messages right after the logon
+	go func() {
+		for range app.FromAppMessages {
+		}
+	}()
+
+	start := time.Now()
+	init, _, err := initiator.Connect(ctx, app, settings, quickfixLogger, app.Connected, timeout, config.ReconnectPolicy{})
+	if err != nil {
+		app.Stop()
+		details := err.Error()
+		if text := app.logoutText(); len(text) > 0 {
+			details = fmt.Sprintf("%s: %s", details, text)
+		}
+		r.add(name, "logon", statusFail, details)
+		r.add(name, "sequence numbers", statusSkip, "not logged on")
+		r.add(name, "clock skew", statusSkip, "not logged on")
+		r.add(name, "logout", statusSkip, "not logged on")
+		return
+	}
+
+	logon := app.receivedLogon()
+	r.add(name, "logon", statusOK, "logged on in %s, heartbeat interval %ds", utils.FormatLatency(time.Since(start)), logon.heartBtInt)
+
+	// Sequence numbers
+	details := fmt.Sprintf("sent %d (expected %d), received %d (expected %d)", logon.sentSeqNum, expectedSender, logon.seqNum, expectedTarget)
+	if !persistent {
+		details += ", no persistent store"
+	}
+	switch {
+	case logon.resetSeqNum:
+		r.add(name, "sequence numbers", statusWarn, "%s, reset requested by the counterparty", details)
+	case logon.seqNum > expectedTarget:
+		r.add(name, "sequence numbers", statusWarn, "%s, %d message(s) missed, resend requested", details, logon.seqNum-expectedTarget)
+	default:
+		r.add(name, "sequence numbers", statusOK, details)
+	}
+
+	// Clock skew, the transit time is part of it
+	if logon.sendingTime.IsZero() {
+		r.add(name, "clock skew", statusWarn, "no SendingTime in logon")
+	} else {
+		skew := logon.received.Sub(logon.sendingTime)
+		abs := skew
+		if abs < 0 {
+			abs = -abs
+		}
+		details := fmt.Sprintf("counterparty clock is %s %s", utils.FormatLatency(abs), map[bool]string{true: "behind", false: "ahead"}[skew >= 0])
+		switch {
+		case abs > 2*time.Minute:
+			r.add(name, "clock skew", statusFail, "%s, messages may be rejected", details)
+		case abs > optionMaxClockSkew:
+			r.add(name, "clock skew", statusWarn, details)
+		default:
+			r.add(name, "clock skew", statusOK, details)
+		}
+	}
+
+	// Logout
+	start = time.Now()
+	app.Stop()
+	init.Stop()
+	_ = quickfix.UnregisterSession(app.SessionID)
+
+	if app.logoutReceived() {
+		r.add(name, "logout", statusOK, "acknowledged in %s", utils.FormatLatency(time.Since(start)))
+	} else {
+		r.add(name, "logout", statusWarn, "not acknowledged by the counterparty")
+	}
+}
+
+func dictionaryVersion(fixType string, major, minor, servicePack int) string {
+	v := fmt.Sprintf("%s.%d.%d", fixType, major, minor)
+	if servicePack > 0 {
+		v += fmt.Sprintf("SP%d", servicePack)
+	}
+	return v
+}
+
+func writeReport(r *report, output string) error {
+	if output == utils.OutputFormatJSON {
+		return json.NewEncoder(os.Stdout).Encode(r.checks)
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"SESSION", "CHECK", "STATUS", "DETAILS"})
+	table.SetBorders(tablewriter.Border{Left: false, Top: false, Right: false, Bottom: true})
+	table.SetColumnSeparator(" ")
+	table.SetCenterSeparator("-")
+	table.SetAutoWrapText(false)
+
+	for _, c := range r.checks {
+		table.Append([]string{c.Session, c.Check, strings.ToUpper(c.Status), c.Details})
+	}
+
+	table.Render()
+
+	return nil
+}
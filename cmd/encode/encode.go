@@ -0,0 +1,192 @@
+package encode
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/quickfixgo/quickfix"
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+
+	"sylr.dev/fix/config"
+	"sylr.dev/fix/pkg/dictionary"
+	"sylr.dev/fix/pkg/errors"
+	"sylr.dev/fix/pkg/initiator"
+	"sylr.dev/fix/pkg/initiator/application"
+	"sylr.dev/fix/pkg/utils"
+)
+
+var (
+	optionFile      string
+	optionDelimiter string
+	optionSend      bool
+	optionWait      bool
+)
+
+var EncodeCmd = &cobra.Command{
+	Use:   "encode",
+	Short: "Encode messages described in JSON/YAML",
+	Long: "Build raw FIX messages out of their JSON/YAML description (the FIX JSON encoding, " +
+		"fields being given by name or by tag number) read from a file or from stdin, " +
+		"and optionally send them on a session.",
+	Example: "  fix encode --file order.yaml\n" +
+		"  fix decode -o json < messages.log | fix encode --send --context venue",
+	Args:              cobra.ExactArgs(0),
+	ValidArgsFunction: cobra.NoFileCompletions,
+	PersistentPreRunE: utils.MakePersistentPreRunE(Validate),
+	RunE:              Execute,
+}
+
+func init() {
+	initiator.AddPersistentFlags(EncodeCmd)
+	dictionary.AddDictionaryPersistentFlags(EncodeCmd)
+
+	EncodeCmd.Flags().StringVarP(&optionFile, "file", "f", "", "File to read messages from (- for stdin)")
+	EncodeCmd.Flags().StringVar(&optionDelimiter, "delimiter", "|", "Field delimiter of the raw messages written")
+	EncodeCmd.Flags().BoolVar(&optionSend, "send", false, "Send the messages on the session instead of writing them")
+	utils.AddBothBoolFlags(EncodeCmd.Flags(), &optionWait, "wait", "", true, "Wait for a response to each message sent")
+
+	initiator.AddPersistentFlagCompletions(EncodeCmd)
+}
+
+func Validate(cmd *cobra.Command, args []string) error {
+	if optionSend {
+		return initiator.ValidateOptions(cmd, args)
+	}
+
+	return dictionary.ValidateDictionaryOptions(cmd, args)
+}
+
+func Execute(cmd *cobra.Command, args []string) error {
+	transportDict, appDict, err := dictionary.GetFIXDictionaries()
+	if err != nil {
+		return err
+	}
+
+	var r io.Reader = os.Stdin
+	if len(optionFile) > 0 && optionFile != "-" {
+		file, err := os.Open(optionFile)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		r = file
+	}
+
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	messages, err := utils.QuickFixMessagesFromYAML(content, transportDict, appDict)
+	if err != nil {
+		return err
+	}
+
+	if !optionSend {
+		for _, message := range messages {
+			fmt.Println(strings.ReplaceAll(message.String(), "\001", optionDelimiter))
+		}
+		return nil
+	}
+
+	return send(cmd, messages)
+}
+
+func send(cmd *cobra.Command, messages []*quickfix.Message) error {
+	options := config.GetOptions()
+	logger := config.GetLogger()
+
+	context, err := config.GetCurrentContext()
+	if err != nil {
+		return err
+	}
+
+	sessions, err := context.GetSessions()
+	if err != nil {
+		return err
+	}
+
+	session := sessions[0]
+	logger = session.GetLogger()
+	initiatorConfig, err := context.GetInitiator()
+	if err != nil {
+		return err
+	}
+
+	transportDict, appDict, err := dictionary.GetFIXDictionaries()
+	if err != nil {
+		return err
+	}
+
+	settings, err := context.ToQuickFixInitiatorSettings()
+	if err != nil {
+		return err
+	}
+
+	app := application.NewInitiator()
+	app.Logger = logger
+	app.Settings = settings
+	app.TransportDataDictionary = transportDict
+	app.AppDataDictionary = appDict
+	app.OutputFormat = options.Output
+
+	var quickfixLogger *zerolog.Logger
+	if options.QuickFixLogging {
+		quickfixLogger = logger
+	}
+
+	// Choose right timeout cli option > config > default value (5s)
+	var timeout time.Duration
+	if options.Timeout != time.Duration(0) {
+		timeout = options.Timeout
+	} else if initiatorConfig.SocketTimeout != time.Duration(0) {
+		timeout = initiatorConfig.SocketTimeout
+	} else {
+		timeout = 5 * time.Second
+	}
+
+	init, sessionId, err := initiator.Connect(cmd.Context(), app, settings, quickfixLogger, app.Connected, timeout, initiatorConfig.Reconnect)
+	if err != nil {
+		app.Stop()
+		return err
+	}
+
+	defer func() {
+		app.Stop()
+		init.Stop()
+	}()
+
+	for _, message := range messages {
+		err = quickfix.SendToTarget(message, sessionId)
+		if err != nil {
+			return err
+		}
+
+		// Drain the message sent
+		select {
+		case <-app.ToAppMessages:
+		default:
+		}
+
+		if !optionWait {
+			continue
+		}
+
+		select {
+		case <-time.After(timeout):
+			return errors.ResponseTimeout
+
+		case responseMessage, ok := <-app.FromAppMessages:
+			if !ok {
+				return errors.FixLogout
+			}
+			app.WriteMessage(os.Stdout, responseMessage)
+		}
+	}
+
+	return nil
+}
@@ -1,38 +1,85 @@
 package cmd
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/pprof"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rs/zerolog"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 
 	"sylr.dev/fix/cmd/amend"
+	"sylr.dev/fix/cmd/bench"
+	"sylr.dev/fix/cmd/blotter"
 	"sylr.dev/fix/cmd/cancel"
+	configcmd "sylr.dev/fix/cmd/config"
+	"sylr.dev/fix/cmd/conformance"
+	"sylr.dev/fix/cmd/daemon"
+	"sylr.dev/fix/cmd/dashboard"
+	"sylr.dev/fix/cmd/decode"
+	"sylr.dev/fix/cmd/dictionary"
+	"sylr.dev/fix/cmd/doctor"
+	"sylr.dev/fix/cmd/encode"
+	"sylr.dev/fix/cmd/fuzz"
 	initcmd "sylr.dev/fix/cmd/init"
-	"sylr.dev/fix/cmd/initiator"
+	initiatorcmd "sylr.dev/fix/cmd/initiator"
+	"sylr.dev/fix/cmd/lint"
 	"sylr.dev/fix/cmd/list"
 	"sylr.dev/fix/cmd/marketdata"
 	"sylr.dev/fix/cmd/new"
+	"sylr.dev/fix/cmd/orders"
+	"sylr.dev/fix/cmd/pcap"
 	"sylr.dev/fix/cmd/probe"
+	"sylr.dev/fix/cmd/scenario"
+	"sylr.dev/fix/cmd/send"
+	"sylr.dev/fix/cmd/session"
+	"sylr.dev/fix/cmd/shell"
 	"sylr.dev/fix/cmd/status"
+	"sylr.dev/fix/cmd/store"
+	"sylr.dev/fix/cmd/tail"
+	"sylr.dev/fix/cmd/template"
 	"sylr.dev/fix/config"
+	"sylr.dev/fix/pkg/errors"
+	"sylr.dev/fix/pkg/health"
+	"sylr.dev/fix/pkg/initiator"
+	"sylr.dev/fix/pkg/tracing"
+	"sylr.dev/fix/pkg/utils"
 )
 
 var Version = "dev"
 
+// stdout is where the result is written in quiet mode, os.Stdout being
+// discarded.
+var stdout = os.Stdout
+
 // rootCmd represents the base command when called without any subcommands
 var FixCmd = &cobra.Command{
 	Use:          "fix",
 	SilenceUsage: true,
 	Version:      Version,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-		InitHTTP(cmd, args)
+		options := config.GetOptions()
+		if err := ValidateOutput(cmd, args); err != nil {
+			return err
+		}
+		if err := utils.SetTimeRendering(options.TimeZone, options.TimePrecision); err != nil {
+			return err
+		}
+		if err := InitHTTP(cmd, args); err != nil {
+			return err
+		}
+		if err := InitTracing(cmd, args); err != nil {
+			return err
+		}
 		return InitLogger(cmd, args)
 	},
 }
@@ -40,49 +87,227 @@ var FixCmd = &cobra.Command{
 func init() {
 	options := config.GetOptions()
 
+	cobra.OnInitialize(InitQuiet)
+
 	FixCmd.AddCommand(amend.AmendCmd)
+	FixCmd.AddCommand(bench.BenchCmd)
+	FixCmd.AddCommand(blotter.BlotterCmd)
 	FixCmd.AddCommand(cancel.CancelCmd)
+	FixCmd.AddCommand(configcmd.ConfigCmd)
+	FixCmd.AddCommand(conformance.ConformanceCmd)
+	FixCmd.AddCommand(daemon.DaemonCmd)
+	FixCmd.AddCommand(dashboard.DashboardCmd)
+	FixCmd.AddCommand(decode.DecodeCmd)
+	FixCmd.AddCommand(dictionary.DictionaryCmd)
+	FixCmd.AddCommand(doctor.DoctorCmd)
+	FixCmd.AddCommand(encode.EncodeCmd)
+	FixCmd.AddCommand(fuzz.FuzzCmd)
 	FixCmd.AddCommand(initcmd.InitCmd)
-	FixCmd.AddCommand(initiator.InitiatorCmd)
+	FixCmd.AddCommand(initiatorcmd.InitiatorCmd)
+	FixCmd.AddCommand(lint.LintCmd)
 	FixCmd.AddCommand(list.ListCmd)
 	FixCmd.AddCommand(marketdata.MarketDataCmd)
 	FixCmd.AddCommand(new.NewCmd)
+	FixCmd.AddCommand(orders.OrdersCmd)
+	FixCmd.AddCommand(pcap.PcapCmd)
 	FixCmd.AddCommand(probe.ProbeCmd)
+	FixCmd.AddCommand(scenario.ScenarioCmd)
+	FixCmd.AddCommand(send.SendCmd)
+	FixCmd.AddCommand(session.SessionCmd)
+	FixCmd.AddCommand(shell.ShellCmd)
 	FixCmd.AddCommand(status.StatusCmd)
+	FixCmd.AddCommand(store.StoreCmd)
+	FixCmd.AddCommand(tail.TailCmd)
+	FixCmd.AddCommand(template.TemplateCmd)
 
 	configPath := filepath.Join("$HOME", ".fix", "config")
 
 	FixCmd.PersistentFlags().StringVar(&options.Config, "config", os.ExpandEnv(configPath), "Config file")
 	FixCmd.PersistentFlags().CountVarP(&options.Verbose, "verbose", "v", "Increase verbosity")
+	FixCmd.PersistentFlags().BoolVarP(&options.Quiet, "quiet", "q", false, "Suppress tables and logs, the outcome being given by the exit code (and a JSON line with -o json)")
+	FixCmd.PersistentFlags().BoolVar(&options.NoColor, "no-color", false, "Disable colorized output (also disabled by NO_COLOR)")
 	FixCmd.PersistentFlags().BoolVar(&options.LogCaller, "log-caller", false, "Add caller info to log lines")
+	FixCmd.PersistentFlags().StringVar(&options.LogFormat, "log-format", LogFormatConsole, fmt.Sprintf("Log format (%s)", strings.Join(LogFormats, ", ")))
+	FixCmd.PersistentFlags().StringVar(&options.LogFile, "log-file", "", "File the logs are appended to instead of the standard output, rotated on SIGHUP")
+	FixCmd.PersistentFlags().StringVar(&options.LogLevel, "log-level", "", "Log level (trace, debug, info, warn, error), overriding --verbose and the LogLevel of the sessions")
 	FixCmd.PersistentFlags().BoolVar(&options.Interactive, "interactive", true, "Enable interactive mode")
 	FixCmd.PersistentFlags().BoolP("help", "h", false, "Help for fix")
 	FixCmd.PersistentFlags().Bool("version", false, "Version for fix")
 	FixCmd.PersistentFlags().BoolVar(&options.Metrics, "metrics", false, "Enable metrics")
 	FixCmd.PersistentFlags().BoolVar(&options.PProf, "pprof", false, "Enable pprof")
 	FixCmd.PersistentFlags().IntVar(&options.HTTPPort, "port", 8080, "HTTP port")
+	FixCmd.PersistentFlags().StringVar(&options.OTLPEndpoint, "otlp-endpoint", otlpEndpointFromEnv(), "OpenTelemetry OTLP/HTTP endpoint to export traces to")
+	FixCmd.PersistentFlags().StringVarP(&options.Output, "output", "o", utils.OutputFormatTable, fmt.Sprintf("Output format (%s)", strings.Join(utils.OutputFormats, ", ")))
+
+	FixCmd.PersistentFlags().StringVar(&options.TimeZone, "timezone", "UTC", "Timezone timestamps are printed in (UTC, local or a name like Europe/Paris)")
+	FixCmd.PersistentFlags().StringVar(&options.TimePrecision, "time-precision", utils.TimePrecisionAuto, fmt.Sprintf("Precision of the printed timestamps (%s)", strings.Join(utils.TimePrecisions, ", ")))
+
+	FixCmd.RegisterFlagCompletionFunc("time-precision", cobra.FixedCompletions(utils.TimePrecisions, cobra.ShellCompDirectiveNoFileComp))
+	FixCmd.RegisterFlagCompletionFunc("output", cobra.FixedCompletions(utils.OutputFormats, cobra.ShellCompDirectiveNoFileComp))
+	FixCmd.RegisterFlagCompletionFunc("log-format", cobra.FixedCompletions(LogFormats, cobra.ShellCompDirectiveNoFileComp))
+	FixCmd.RegisterFlagCompletionFunc("log-level", cobra.FixedCompletions([]string{"trace", "debug", "info", "warn", "error"}, cobra.ShellCompDirectiveNoFileComp))
+}
+
+func ValidateOutput(cmd *cobra.Command, args []string) error {
+	options := config.GetOptions()
+
+	for _, format := range utils.OutputFormats {
+		if options.Output == format {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: unknown output format `%s`", errors.Options, options.Output)
 }
 
+// InitQuiet discards the standard output and the errors printed by cobra in
+// quiet mode. It is run before any PersistentPreRunE so that nothing is
+// printed when options fail to validate.
+func InitQuiet() {
+	options := config.GetOptions()
+
+	if !options.Quiet {
+		return
+	}
+
+	FixCmd.SilenceErrors = true
+	if devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0); err == nil {
+		os.Stdout = devNull
+	}
+}
+
+// ExitCode returns the exit code of the command which returned err. It depends
+// on the class of the error (rejected, timeout, logout, configuration ...) so
+// that scripts can tell them apart. In quiet mode, the outcome is also written
+// as a single JSON line when the output is json, otherwise the resends which
+// occurred are recalled on the error output after the one of the command.
+func ExitCode(err error) int {
+	options := config.GetOptions()
+
+	// The commands stop as if interrupted when a session drops with
+	// --exit-on-disconnect
+	if dropped := initiator.DisconnectError(); dropped != nil && (err == nil || errors.Is(err, context.Canceled)) {
+		err = dropped
+	}
+
+	result := utils.NewResult(err)
+	if options.Quiet && options.Output == utils.OutputFormatJSON {
+		json.NewEncoder(stdout).Encode(result)
+	} else if !options.Quiet {
+		for _, warning := range result.Warnings {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+		}
+	}
+
+	return result.ExitCode
+}
+
+func otlpEndpointFromEnv() string {
+	if endpoint := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"); len(endpoint) > 0 {
+		return endpoint
+	}
+
+	return os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+}
+
+func InitTracing(cmd *cobra.Command, args []string) error {
+	options := config.GetOptions()
+
+	if len(options.OTLPEndpoint) == 0 {
+		return nil
+	}
+
+	serviceName := os.Getenv("OTEL_SERVICE_NAME")
+	if len(serviceName) == 0 {
+		serviceName = "fix"
+	}
+
+	return tracing.Init(options.OTLPEndpoint, serviceName)
+}
+
+// Log formats.
+const (
+	LogFormatConsole = "console"
+	LogFormatJSON    = "json"
+)
+
+// LogFormats are the values of --log-format.
+var LogFormats = []string{LogFormatConsole, LogFormatJSON}
+
 func InitLogger(cmd *cobra.Command, args []string) error {
 	options := config.GetOptions()
 	zerolog.TimeFieldFormat = time.RFC3339Nano
-	consoleWriter := zerolog.ConsoleWriter{
-		Out:        os.Stdout,
-		TimeFormat: "Jan 2 15:04:05.000-0700",
+
+	_, noColor := os.LookupEnv("NO_COLOR")
+	utils.ColorOutput = !options.NoColor && !noColor && term.IsTerminal(int(os.Stdout.Fd()))
+
+	level := config.IntToZerologLevel(options.Verbose)
+	if len(options.LogLevel) > 0 {
+		var err error
+		if level, err = zerolog.ParseLevel(options.LogLevel); err != nil {
+			return fmt.Errorf("%w: invalid log level `%s`", errors.Options, options.LogLevel)
+		}
+	}
+
+	var out io.Writer = os.Stdout
+	if len(options.LogFile) > 0 {
+		// Fail now rather than on the first log line
+		file, err := os.OpenFile(options.LogFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+		if err != nil {
+			return fmt.Errorf("%w: %s", errors.Options, err)
+		}
+		file.Close()
+
+		rotating := &utils.RotatingFile{Path: options.LogFile}
+		utils.SetLogFile(rotating)
+		out = rotating
+	}
+
+	var writer io.Writer
+	switch options.LogFormat {
+	case LogFormatConsole:
+		writer = zerolog.ConsoleWriter{
+			Out:        out,
+			NoColor:    !utils.ColorOutput || len(options.LogFile) > 0,
+			TimeFormat: "Jan 2 15:04:05.000-0700",
+		}
+	case LogFormatJSON:
+		writer = out
+	default:
+		return fmt.Errorf("%w: unknown log format `%s`", errors.Options, options.LogFormat)
+	}
+
+	multi := zerolog.MultiLevelWriter(writer)
+	logger := zerolog.New(multi).With().Timestamp().Logger().Level(level)
+	// Quiet mode silences the terminal, not the log file
+	if options.Quiet && len(options.LogFile) == 0 {
+		logger = logger.Level(zerolog.Disabled)
 	}
-	multi := zerolog.MultiLevelWriter(consoleWriter)
-	logger := zerolog.New(multi).With().Timestamp().Logger().Level(config.IntToZerologLevel(options.Verbose))
 
 	if options.LogCaller {
 		logger = logger.With().Caller().Logger()
 	}
 	config.SetLogger(&logger)
+	utils.ClockDriftLogger = &logger
+	utils.ResendLogger = &logger
+	tracing.Logger = &logger
 	return nil
 }
 
+// metricsListening is the address --metrics-listen is served on.
+var metricsListening string
+
 func InitHTTP(cmd *cobra.Command, args []string) error {
 	options := config.GetOptions()
 
+	// The shell runs several commands in the same process
+	if len(options.MetricsListen) > 0 && options.MetricsListen != metricsListening {
+		if err := health.Listen(options.MetricsListen); err != nil {
+			return err
+		}
+		metricsListening = options.MetricsListen
+	}
+
 	if !options.Metrics && !options.PProf {
 		return nil
 	}
@@ -0,0 +1,312 @@
+package fuzz
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/quickfixgo/enum"
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/tag"
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+
+	"sylr.dev/fix/config"
+	"sylr.dev/fix/pkg/errors"
+	"sylr.dev/fix/pkg/fuzz"
+	"sylr.dev/fix/pkg/initiator"
+	"sylr.dev/fix/pkg/initiator/application"
+	"sylr.dev/fix/pkg/utils"
+)
+
+var (
+	optionMsgTypes      []string
+	optionCount         int
+	optionSeed          int64
+	optionOptionalRatio float64
+	optionMutationRatio float64
+	optionInterval      time.Duration
+	optionRecord        string
+)
+
+var FuzzCmd = &cobra.Command{
+	Use:   "fuzz",
+	Short: "Fire random messages at an acceptor",
+	Long: "Generate valid and near-valid messages out of the data dictionary (random optional fields, " +
+		"missing required fields, invalid enum values, wrong types, boundary values, unknown tags), " +
+		"send them on the session and record the responses.",
+	Example:           "  fix fuzz --context uat --msg-type D,F --count 1000 --record fuzz.jsonl",
+	Args:              cobra.ExactArgs(0),
+	ValidArgsFunction: cobra.NoFileCompletions,
+	PersistentPreRunE: utils.MakePersistentPreRunE(Validate),
+	RunE:              Execute,
+}
+
+func init() {
+	initiator.AddPersistentFlags(FuzzCmd)
+
+	FuzzCmd.Flags().StringSliceVar(&optionMsgTypes, "msg-type", []string{}, "Types of the messages to generate")
+	FuzzCmd.Flags().IntVar(&optionCount, "count", 100, "Number of messages to send")
+	FuzzCmd.Flags().Int64Var(&optionSeed, "seed", 0, "Random seed (defaults to the current time)")
+	FuzzCmd.Flags().Float64Var(&optionOptionalRatio, "optional-ratio", 0.2, "Probability for optional fields to be set")
+	FuzzCmd.Flags().Float64Var(&optionMutationRatio, "mutation-ratio", 0.2, "Probability for messages to be near-valid")
+	FuzzCmd.Flags().DurationVar(&optionInterval, "interval", 0, "Interval between messages")
+	FuzzCmd.Flags().StringVar(&optionRecord, "record", "", "File in which to record the messages sent and received (JSON lines)")
+
+	FuzzCmd.MarkFlagRequired("msg-type")
+
+	initiator.AddPersistentFlagCompletions(FuzzCmd)
+	FuzzCmd.RegisterFlagCompletionFunc("msg-type", cobra.NoFileCompletions)
+}
+
+func Validate(cmd *cobra.Command, args []string) error {
+	if optionCount <= 0 {
+		return fmt.Errorf("%w: --count must be positive", errors.Options)
+	}
+
+	for _, ratio := range []float64{optionOptionalRatio, optionMutationRatio} {
+		if ratio < 0 || ratio > 1 {
+			return fmt.Errorf("%w: ratios must be between 0 and 1", errors.Options)
+		}
+	}
+
+	return initiator.ValidateOptions(cmd, args)
+}
+
+type record struct {
+	Time      time.Time `json:"time"`
+	Direction string    `json:"direction"`
+	SeqNum    int       `json:"seqNum,omitempty"`
+	Mutation  string    `json:"mutation,omitempty"`
+	Raw       string    `json:"raw"`
+}
+
+type stats struct {
+	Sent     int
+	Rejected int
+}
+
+type recorder struct {
+	mux       sync.Mutex
+	encoder   *json.Encoder
+	mutations map[int]string
+	stats     map[string]*stats
+	responses int
+}
+
+func (r *recorder) record(direction string, message *quickfix.Message, mutation string) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	seqNum, _ := message.Header.GetInt(tag.MsgSeqNum)
+
+	if direction == "->" {
+		r.mutations[seqNum] = mutation
+		if _, ok := r.stats[mutation]; !ok {
+			r.stats[mutation] = &stats{}
+		}
+		r.stats[mutation].Sent++
+	} else {
+		r.responses++
+
+		msgType, _ := message.MsgType()
+		if msgType == string(enum.MsgType_REJECT) || msgType == string(enum.MsgType_BUSINESS_MESSAGE_REJECT) {
+			if ref, err := message.Body.GetInt(tag.RefSeqNum); err == nil {
+				if m, ok := r.mutations[ref]; ok {
+					mutation = m
+					r.stats[m].Rejected++
+				}
+			}
+		}
+	}
+
+	if r.encoder != nil {
+		r.encoder.Encode(record{
+			Time:      time.Now().UTC(),
+			Direction: direction,
+			SeqNum:    seqNum,
+			Mutation:  mutation,
+			Raw:       strings.ReplaceAll(message.String(), "\001", "|"),
+		})
+	}
+}
+
+func Execute(cmd *cobra.Command, args []string) error {
+	options := config.GetOptions()
+	logger := config.GetLogger()
+
+	context, err := config.GetCurrentContext()
+	if err != nil {
+		return err
+	}
+
+	sessions, err := context.GetSessions()
+	if err != nil {
+		return err
+	}
+
+	session := sessions[0]
+	logger = session.GetLogger()
+	initiatorConfig, err := context.GetInitiator()
+	if err != nil {
+		return err
+	}
+
+	transportDict, appDict, err := session.GetFIXDictionaries()
+	if err != nil {
+		return err
+	}
+
+	if appDict == nil {
+		return fmt.Errorf("%w: fuzzing requires an application data dictionary", errors.Config)
+	}
+
+	seed := optionSeed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	logger.Info().Msgf("Fuzzing with seed %d", seed)
+
+	generator := fuzz.NewGenerator(appDict, seed)
+	generator.OptionalRatio = optionOptionalRatio
+	generator.MutationRatio = optionMutationRatio
+
+	for _, msgType := range optionMsgTypes {
+		if _, ok := appDict.Messages[msgType]; !ok {
+			return fmt.Errorf("%w: unknown message type `%s`", errors.Options, msgType)
+		}
+	}
+
+	rec := &recorder{
+		mutations: make(map[int]string),
+		stats:     make(map[string]*stats),
+	}
+
+	if len(optionRecord) > 0 {
+		file, err := os.Create(optionRecord)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		rec.encoder = json.NewEncoder(file)
+	}
+
+	settings, err := context.ToQuickFixInitiatorSettings()
+	if err != nil {
+		return err
+	}
+
+	app := application.NewFuzz()
+	app.Logger = logger
+	app.Settings = settings
+	app.TransportDataDictionary = transportDict
+	app.AppDataDictionary = appDict
+
+	var quickfixLogger *zerolog.Logger
+	if options.QuickFixLogging {
+		quickfixLogger = logger
+	}
+
+	// Choose right timeout cli option > config > default value (5s)
+	var timeout time.Duration
+	if options.Timeout != time.Duration(0) {
+		timeout = options.Timeout
+	} else if initiatorConfig.SocketTimeout != time.Duration(0) {
+		timeout = initiatorConfig.SocketTimeout
+	} else {
+		timeout = 5 * time.Second
+	}
+
+	init, sessionId, err := initiator.Connect(cmd.Context(), app, settings, quickfixLogger, app.Connected, timeout, initiatorConfig.Reconnect)
+	if err != nil {
+		app.Stop()
+		return err
+	}
+
+	defer func() {
+		app.Stop()
+		init.Stop()
+	}()
+
+	// Record the responses
+	logout := make(chan struct{})
+	go func() {
+		defer close(logout)
+		for {
+			select {
+			case message, ok := <-app.FromAppMessages:
+				if !ok {
+					return
+				}
+				rec.record("<-", message, "")
+			case message, ok := <-app.FromAdminMessages:
+				if !ok {
+					return
+				}
+				rec.record("<-", message, "")
+			}
+		}
+	}()
+
+	for i := 0; i < optionCount; i++ {
+		message, mutation, err := generator.Generate(optionMsgTypes[i%len(optionMsgTypes)])
+		if err != nil {
+			return err
+		}
+
+		if err = quickfix.SendToTarget(message, sessionId); err != nil {
+			return err
+		}
+
+		select {
+		case sent := <-app.ToAppMessages:
+			rec.record("->", sent, mutation)
+		case <-logout:
+			return errors.FixLogout
+		}
+
+		if optionInterval > 0 {
+			time.Sleep(optionInterval)
+		}
+	}
+
+	// Give the acceptor some time to respond to the last messages
+	select {
+	case <-time.After(timeout):
+	case <-logout:
+	}
+
+	writeStats(os.Stdout, rec)
+
+	return nil
+}
+
+func writeStats(w io.Writer, rec *recorder) {
+	rec.mux.Lock()
+	defer rec.mux.Unlock()
+
+	mutations := make([]string, 0, len(rec.stats))
+	for mutation := range rec.stats {
+		mutations = append(mutations, mutation)
+	}
+	sort.Strings(mutations)
+
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"MUTATION", "SENT", "REJECTED"})
+	table.SetBorders(tablewriter.Border{Left: false, Top: false, Right: false, Bottom: true})
+	table.SetColumnSeparator(" ")
+	table.SetCenterSeparator("-")
+	for _, mutation := range mutations {
+		s := rec.stats[mutation]
+		table.Append([]string{mutation, strconv.Itoa(s.Sent), strconv.Itoa(s.Rejected)})
+	}
+	table.SetFooter([]string{"responses", "", strconv.Itoa(rec.responses)})
+	table.Render()
+}
@@ -1,9 +1,6 @@
 package initiator
 
 import (
-	"os"
-	"os/signal"
-	"syscall"
 	"time"
 
 	"github.com/rs/zerolog"
@@ -16,6 +13,7 @@ import (
 
 	"sylr.dev/fix/config"
 	"sylr.dev/fix/pkg/errors"
+	"sylr.dev/fix/pkg/health"
 	"sylr.dev/fix/pkg/initiator"
 	"sylr.dev/fix/pkg/initiator/application"
 	"sylr.dev/fix/pkg/utils"
@@ -24,8 +22,11 @@ import (
 var InitiatorCmd = &cobra.Command{
 	Use:   "initiator",
 	Short: "Launch a FIX initiator",
-	Long:  "Launch a FIX initiator and wait for messages.",
+	Long:  "Launch a FIX initiator for each session of the context and wait for messages.",
 	RunE:  Execute,
+	Annotations: map[string]string{
+		initiator.MultiSessionsAnnotation: "true",
+	},
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 		if err := utils.ValidateRequiredFlags(cmd); err != nil {
 			return err
@@ -49,6 +50,8 @@ var InitiatorCmd = &cobra.Command{
 func init() {
 	initiator.AddPersistentFlags(InitiatorCmd)
 	initiator.AddPersistentFlagCompletions(InitiatorCmd)
+	health.AddMetricsFlags(InitiatorCmd)
+	initiator.AddExitOnDisconnectFlag(InitiatorCmd)
 }
 
 func Execute(cmd *cobra.Command, args []string) error {
@@ -60,90 +63,89 @@ func Execute(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	sessions, err := context.GetSessions()
-	if err != nil {
-		return err
-	}
-
-	acceptor, err := context.GetInitiator()
+	initiatorConfig, err := context.GetInitiator()
 	if err != nil {
 		return err
 	}
 
-	settings, err := context.ToQuickFixInitiatorSettings()
-	if err != nil {
-		return err
-	}
-
-	session := sessions[0]
-	transportDict, appDict, err := session.GetFIXDictionaries()
-	if err != nil {
-		return err
-	}
-
-	app := application.NewInitiator()
-	app.Settings = settings
-	app.TransportDataDictionary = transportDict
-	app.AppDataDictionary = appDict
-	app.Logger = logger
-
 	var quickfixLogger *zerolog.Logger
 	if options.QuickFixLogging {
 		quickfixLogger = logger
 	}
 
-	init, err := initiator.Initiate(app, settings, quickfixLogger)
+	sessions, err := initiator.InitiateContext(context, quickfixLogger, func(session *config.Session, settings *quickfix.Settings) (*application.Initiator, error) {
+		transportDict, appDict, err := session.GetFIXDictionaries()
+		if err != nil {
+			return nil, err
+		}
+
+		sessionLogger := session.GetLogger().With().Str("session", session.Name).Logger()
+
+		app := application.NewInitiator()
+		app.Settings = settings
+		app.TransportDataDictionary = transportDict
+		app.AppDataDictionary = appDict
+		app.Logger = &sessionLogger
+
+		return app, nil
+	})
 	if err != nil {
 		return err
 	}
 
-	// Start session
-	if err = init.Start(); err != nil {
+	connected := initiator.Multiplex(sessions, func(app *application.Initiator) chan quickfix.SessionID { return app.Connected })
+	fromAppMessages := initiator.Multiplex(sessions, func(app *application.Initiator) chan *quickfix.Message { return app.FromAppMessages })
+
+	// Start sessions
+	if err = sessions.Start(); err != nil {
 		return err
 	}
 
-	defer func() {
-		app.Stop()
-		init.Stop()
-	}()
+	defer sessions.Stop()
 
 	// Choose right timeout cli option > config > default value (5s)
 	var timeout time.Duration
 	if options.Timeout != time.Duration(0) {
 		timeout = options.Timeout
-	} else if acceptor.SocketTimeout != time.Duration(0) {
-		timeout = acceptor.SocketTimeout
+	} else if initiatorConfig.SocketTimeout != time.Duration(0) {
+		timeout = initiatorConfig.SocketTimeout
 	} else {
 		timeout = 5 * time.Second
 	}
 
-	// Wait for session connection
-	select {
-	case <-time.After(timeout):
-		return errors.ConnectionTimeout
-	case _, ok := <-app.Connected:
-		if !ok {
-			return errors.FixLogout
-		}
-	}
+	// Wait for all sessions connection
+	waitTimeout := time.After(timeout)
+	for n := 0; n < len(sessions.Apps); n++ {
+		select {
+		case <-waitTimeout:
+			return errors.ConnectionTimeout
+		case sessionId, ok := <-connected:
+			if !ok {
+				return errors.FixLogout
+			}
 
-	message := quickfix.NewMessage()
-	message.Header.SetField(tag.MsgType, field.NewMsgType(enum.MsgType_HEARTBEAT))
-	err = quickfix.SendToTarget(message, app.SessionID)
-	if err != nil {
-		return err
+			message := quickfix.NewMessage()
+			message.Header.SetField(tag.MsgType, field.NewMsgType(enum.MsgType_HEARTBEAT))
+			err = quickfix.SendToTarget(message, sessionId.Value)
+			if err != nil {
+				return err
+			}
+		}
 	}
 
-	interrupt := make(chan os.Signal, 1)
-	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	ctx := cmd.Context()
 
 LOOP:
 	for {
 		select {
-		case signal := <-interrupt:
-			logger.Debug().Msgf("Received signal: %s", signal)
+		case <-ctx.Done():
+			logger.Debug().Msg("Interrupted")
 			break LOOP
-		case _, ok := <-app.FromAppMessages:
+		case sessionId, ok := <-connected:
+			if ok {
+				logger.Info().Str("session", sessionId.Session).Msg("Session reconnected")
+			}
+		case _, ok := <-fromAppMessages:
 			if !ok {
 				return errors.FixLogout
 			}
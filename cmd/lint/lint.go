@@ -0,0 +1,133 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+
+	"sylr.dev/fix/config"
+	"sylr.dev/fix/pkg/dictionary"
+	"sylr.dev/fix/pkg/errors"
+	"sylr.dev/fix/pkg/utils"
+)
+
+var (
+	optionFile string
+)
+
+var LintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Validate raw FIX messages",
+	Long: "Validate raw FIX messages read from a file or from stdin, one per line, against the data " +
+		"dictionaries: BodyLength, CheckSum, required fields, enum values and fields ordering. " +
+		"Use -o json to get one JSON object per issue. Exits with an error if any message is invalid.",
+	Example:           "  fix lint --session venue --file messages.log -o json",
+	Args:              cobra.ExactArgs(0),
+	ValidArgsFunction: cobra.NoFileCompletions,
+	PersistentPreRunE: utils.MakePersistentPreRunE(dictionary.ValidateDictionaryOptions),
+	RunE:              Execute,
+}
+
+func init() {
+	dictionary.AddPersistentFlags(LintCmd)
+
+	LintCmd.Flags().StringVarP(&optionFile, "file", "f", "", "File to read messages from (- for stdin)")
+
+	dictionary.AddPersistentFlagCompletions(LintCmd)
+}
+
+type report struct {
+	Message int    `json:"message"`
+	MsgType string `json:"msgType,omitempty"`
+	utils.QuickFixLintIssue
+}
+
+func Execute(cmd *cobra.Command, args []string) error {
+	options := config.GetOptions()
+
+	transportDict, appDict, err := dictionary.GetFIXDictionaries()
+	if err != nil {
+		return err
+	}
+
+	var r io.Reader = os.Stdin
+	if len(optionFile) > 0 && optionFile != "-" {
+		file, err := os.Open(optionFile)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		r = file
+	}
+
+	messages, err := utils.ReadQuickFixRawMessages(r)
+	if err != nil {
+		return err
+	}
+
+	var reports []report
+	invalid := 0
+
+	for i, raw := range messages {
+		issues := utils.QuickFixLint(raw, transportDict, appDict)
+		if len(issues) == 0 {
+			continue
+		}
+
+		invalid++
+		for _, issue := range issues {
+			reports = append(reports, report{Message: i + 1, MsgType: msgType(raw), QuickFixLintIssue: issue})
+		}
+	}
+
+	if options.Output == utils.OutputFormatJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		for _, report := range reports {
+			if err := encoder.Encode(report); err != nil {
+				return err
+			}
+		}
+	} else if len(reports) > 0 {
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"MESSAGE", "MSGTYPE", "TAG", "REASON", "TEXT"})
+		table.SetBorders(tablewriter.Border{Left: false, Top: false, Right: false, Bottom: true})
+		table.SetColumnSeparator(" ")
+		table.SetCenterSeparator("-")
+		table.SetAutoWrapText(false)
+		table.SetColumnAlignment([]int{tablewriter.ALIGN_RIGHT, tablewriter.ALIGN_LEFT, tablewriter.ALIGN_RIGHT, tablewriter.ALIGN_LEFT, tablewriter.ALIGN_LEFT})
+		for _, report := range reports {
+			tag := ""
+			if report.Tag > 0 {
+				tag = strconv.Itoa(report.Tag)
+			}
+			table.Append([]string{strconv.Itoa(report.Message), report.MsgType, tag, report.Reason, report.Text})
+		}
+		table.Render()
+	}
+
+	if invalid > 0 {
+		return fmt.Errorf("%w: %d message(s) out of %d", errors.FixMessageInvalid, invalid, len(messages))
+	}
+
+	return nil
+}
+
+func msgType(raw string) string {
+	idx := strings.Index(raw, "\00135=")
+	if idx < 0 {
+		return ""
+	}
+
+	value := raw[idx+4:]
+	if end := strings.Index(value, "\001"); end >= 0 {
+		value = value[:end]
+	}
+
+	return value
+}
@@ -0,0 +1,241 @@
+package listsecurity
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/quickfix/datadictionary"
+	"github.com/quickfixgo/tag"
+
+	"sylr.dev/fix/pkg/errors"
+	"sylr.dev/fix/pkg/initiator/application"
+	"sylr.dev/fix/pkg/instrument"
+	"sylr.dev/fix/pkg/utils"
+)
+
+// security is an instrument of a security list.
+type security struct {
+	Symbol         string
+	SecurityID     string
+	SecurityType   string
+	Product        string
+	Currency       string
+	TickSize       string
+	LotSize        string
+	TradingStatus  string
+	SecurityStatus string
+	Updated        time.Time
+}
+
+func newSecurity(instrument *quickfix.Group, updated time.Time) *security {
+	s := &security{Updated: updated}
+	s.Symbol, _ = instrument.GetString(tag.Symbol)
+	s.SecurityID, _ = instrument.GetString(tag.SecurityID)
+	s.SecurityType, _ = instrument.GetString(application.TagSecurityType)
+	s.Product, _ = instrument.GetString(application.TagProduct)
+	s.Currency, _ = instrument.GetString(tag.Currency)
+	s.TickSize, _ = instrument.GetString(application.TagMinPriceIncrement)
+	s.TradingStatus, _ = instrument.GetString(tag.SecurityTradingStatus)
+	s.SecurityStatus, _ = instrument.GetString(application.TagSecurityStatus)
+
+	if lot, err := instrument.GetString(application.TagRoundLot); err == nil {
+		s.LotSize = lot
+	} else {
+		s.LotSize, _ = instrument.GetString(application.TagMinTradeVol)
+	}
+
+	return s
+}
+
+// key identifies the security in the list.
+func (s *security) key() string {
+	if len(s.SecurityID) > 0 {
+		return s.SecurityID
+	}
+
+	return s.Symbol
+}
+
+// catalog returns the securities as the instruments of a catalog.
+func catalog(list []*security) []instrument.Instrument {
+	instruments := make([]instrument.Instrument, 0, len(list))
+	for _, s := range list {
+		instruments = append(instruments, instrument.Instrument{
+			Symbol:         s.Symbol,
+			SecurityID:     s.SecurityID,
+			SecurityType:   s.SecurityType,
+			Product:        s.Product,
+			Currency:       s.Currency,
+			TickSize:       s.TickSize,
+			LotSize:        s.LotSize,
+			TradingStatus:  s.TradingStatus,
+			SecurityStatus: s.SecurityStatus,
+		})
+	}
+
+	return instruments
+}
+
+// securityFilters are the criteria the securities listed must match, any of
+// the values of a criterion matching it.
+type securityFilters struct {
+	Symbols         []string
+	Products        []string
+	Currencies      []string
+	TradingStatuses []string
+	// SecurityStatuses are the trading statuses given as SecurityStatus values
+	SecurityStatuses []string
+}
+
+// newSecurityFilters resolves the values of the filters given by enum
+// description to their FIX values.
+func newSecurityFilters(symbols, products, currencies, statuses []string, transportDict, appDict *datadictionary.DataDictionary) (*securityFilters, error) {
+	resolve := func(t quickfix.Tag, values []string) ([]string, error) {
+		exprs := make([]string, 0, len(values))
+		for _, value := range values {
+			exprs = append(exprs, fmt.Sprintf("%d=%s", t, value))
+		}
+
+		matchers, err := utils.ParseQuickFixFieldMatchers(exprs, transportDict, appDict)
+		if err != nil {
+			return nil, err
+		}
+
+		resolved := make([]string, 0, len(matchers))
+		for _, m := range matchers {
+			resolved = append(resolved, m.Value)
+		}
+
+		return resolved, nil
+	}
+
+	for _, symbol := range symbols {
+		if _, err := path.Match(symbol, ""); err != nil {
+			return nil, fmt.Errorf("%w: invalid symbol pattern %q: %s", errors.Options, symbol, err)
+		}
+	}
+
+	f := &securityFilters{Symbols: symbols, Currencies: currencies}
+
+	var err error
+	if f.Products, err = resolve(application.TagProduct, products); err != nil {
+		return nil, err
+	}
+	if f.TradingStatuses, err = resolve(tag.SecurityTradingStatus, statuses); err != nil {
+		return nil, err
+	}
+	if f.SecurityStatuses, err = resolve(application.TagSecurityStatus, statuses); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func (f *securityFilters) match(s *security) bool {
+	if f == nil {
+		return true
+	}
+
+	if len(f.Symbols) > 0 {
+		matched := false
+		for _, pattern := range f.Symbols {
+			if ok, _ := path.Match(pattern, s.Symbol); ok {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	if len(f.Products) > 0 && utils.Search(f.Products, s.Product) < 0 {
+		return false
+	}
+
+	if len(f.Currencies) > 0 && utils.Search(f.Currencies, s.Currency) < 0 {
+		return false
+	}
+
+	if len(f.TradingStatuses) > 0 && utils.Search(f.TradingStatuses, s.TradingStatus) < 0 && utils.Search(f.SecurityStatuses, s.SecurityStatus) < 0 {
+		return false
+	}
+
+	return true
+}
+
+// describe returns the value with its enum description, if any.
+func describe(appDict *datadictionary.DataDictionary, t quickfix.Tag, value string) string {
+	if len(value) == 0 || appDict == nil {
+		return value
+	}
+
+	if fieldType, ok := appDict.FieldTypeByTag[int(t)]; ok {
+		if enum, ok := fieldType.Enums[value]; ok {
+			return fmt.Sprintf("%s (%s)", value, enum.Description)
+		}
+	}
+
+	return value
+}
+
+// sortedSecurities returns the securities sorted by symbol.
+func sortedSecurities(securities map[string]*security) []*security {
+	list := make([]*security, 0, len(securities))
+	for _, s := range securities {
+		list = append(list, s)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Symbol != list[j].Symbol {
+			return list[i].Symbol < list[j].Symbol
+		}
+		return list[i].SecurityID < list[j].SecurityID
+	})
+
+	return list
+}
+
+// writeSecuritiesTable writes one line per security, with the update time
+// when watching the list.
+func writeSecuritiesTable(w io.Writer, list []*security, appDict *datadictionary.DataDictionary, updated bool) {
+	header := []string{"SYMBOL", "SECURITY ID", "TYPE", "PRODUCT", "CURRENCY", "TICK SIZE", "LOT SIZE", "STATUS"}
+	if updated {
+		header = append(header, "UPDATED")
+	}
+
+	table := tablewriter.NewWriter(w)
+	table.SetHeader(header)
+	table.SetBorders(tablewriter.Border{Left: false, Top: false, Right: false, Bottom: true})
+	table.SetColumnSeparator(" ")
+	table.SetCenterSeparator("-")
+	table.SetAutoWrapText(false)
+
+	for _, s := range list {
+		status := describe(appDict, tag.SecurityTradingStatus, s.TradingStatus)
+		if len(status) == 0 {
+			status = describe(appDict, application.TagSecurityStatus, s.SecurityStatus)
+		}
+
+		row := []string{
+			s.Symbol,
+			s.SecurityID,
+			describe(appDict, application.TagSecurityType, s.SecurityType),
+			describe(appDict, application.TagProduct, s.Product),
+			s.Currency,
+			s.TickSize,
+			s.LotSize,
+			status,
+		}
+		if updated {
+			row = append(row, utils.FormatTime(s.Updated))
+		}
+		table.Append(row)
+	}
+
+	table.Render()
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/quickfixgo/enum"
 	"github.com/quickfixgo/quickfix"
 	"github.com/rs/zerolog"
 	"github.com/spf13/cobra"
@@ -16,18 +17,37 @@ import (
 	"sylr.dev/fix/pkg/errors"
 	"sylr.dev/fix/pkg/initiator"
 	"sylr.dev/fix/pkg/initiator/application"
+	"sylr.dev/fix/pkg/instrument"
+	"sylr.dev/fix/pkg/sessiond"
 	"sylr.dev/fix/pkg/utils"
 )
 
 var (
-	optionType string
+	optionType            string
+	optionWatch           bool
+	optionSymbols         []string
+	optionProducts        []string
+	optionCurrencies      []string
+	optionTradingStatuses []string
+	optionSocket          string
 )
 
+var errSecurityListRejected = fmt.Errorf("%w: security list request rejected", errors.Fix)
+
 var ListSecurityCmd = &cobra.Command{
-	Use:               "security",
-	Aliases:           []string{"securities"},
-	Short:             "List securities",
-	Long:              "Send a securitylist FIX Message after initiating a session with a FIX acceptor.",
+	Use:     "security",
+	Aliases: []string{"securities"},
+	Short:   "List securities",
+	Long: "Send a securitylist FIX Message after initiating a session with a FIX acceptor. " +
+		"With --watch, the session is kept open and the list is refreshed as securities are added, " +
+		"modified or removed by the acceptor.\n\n" +
+		"The securities can be filtered by symbol (glob pattern), product, currency and trading status, " +
+		"products and statuses being given by value or by description.\n\n" +
+		"With -o json or -o csv, the securities are written as an instrument catalog that " +
+		"`fix marketdata validator --symbols-file` and `fix acceptor --instruments` read.",
+	Example: "  fix list security --context venue --product currency --currency EUR --symbol 'EUR*'\n" +
+		"  fix list security --context venue --trading-status ready_to_trade --watch\n" +
+		"  fix list security --context venue -o csv > instruments.csv",
 	Args:              cobra.ExactArgs(0),
 	ValidArgsFunction: cobra.NoFileCompletions,
 	PersistentPreRunE: utils.MakePersistentPreRunE(Validate),
@@ -36,6 +56,13 @@ var ListSecurityCmd = &cobra.Command{
 
 func init() {
 	ListSecurityCmd.Flags().StringVar(&optionType, "type", "symbol", "Securities type (symbol, product ... etc)")
+	ListSecurityCmd.Flags().BoolVar(&optionWatch, "watch", false, "Subscribe to the security list updates and refresh the list")
+	ListSecurityCmd.Flags().BoolVar(&optionWatch, "subscribe", false, "Alias of --watch")
+	ListSecurityCmd.Flags().StringVar(&optionSocket, "socket", "", "Unix socket of the session served by `fix session hold --serve`, used without --watch (default $HOME/.fix/sessions/<context>.sock if it exists)")
+	ListSecurityCmd.Flags().StringSliceVar(&optionSymbols, "symbol", nil, "Only list the securities whose symbol matches these glob patterns")
+	ListSecurityCmd.Flags().StringSliceVar(&optionProducts, "product", nil, "Only list the securities of these products")
+	ListSecurityCmd.Flags().StringSliceVar(&optionCurrencies, "currency", nil, "Only list the securities in these currencies")
+	ListSecurityCmd.Flags().StringSliceVar(&optionTradingStatuses, "trading-status", nil, "Only list the securities with these trading statuses")
 
 	ListSecurityCmd.RegisterFlagCompletionFunc("type", complete.SecurityListRequestType)
 }
@@ -70,6 +97,7 @@ func Execute(cmd *cobra.Command, args []string) error {
 	}
 
 	session := sessions[0]
+	logger = session.GetLogger()
 	transportDict, appDict, err := session.GetFIXDictionaries()
 	if err != nil {
 		return err
@@ -85,27 +113,13 @@ func Execute(cmd *cobra.Command, args []string) error {
 	app.Settings = settings
 	app.TransportDataDictionary = transportDict
 	app.AppDataDictionary = appDict
+	app.OutputFormat = options.Output
 
 	var quickfixLogger *zerolog.Logger
 	if options.QuickFixLogging {
 		quickfixLogger = logger
 	}
 
-	init, err := initiator.Initiate(app, settings, quickfixLogger)
-	if err != nil {
-		return err
-	}
-
-	// Start session
-	if err = init.Start(); err != nil {
-		return err
-	}
-
-	defer func() {
-		app.Stop()
-		init.Stop()
-	}()
-
 	// Choose right timeout cli option > config > default value (5s)
 	var timeout time.Duration
 	if options.Timeout != time.Duration(0) {
@@ -116,16 +130,39 @@ func Execute(cmd *cobra.Command, args []string) error {
 		timeout = 5 * time.Second
 	}
 
-	// Wait for session connection
+	// The session held by `fix session hold --serve` spares the logon, its
+	// requests being answered by a single message
+	var client *sessiond.Client
+	if !optionWatch {
+		if client, err = sessiond.Served(optionSocket, context.Name, settings); err != nil {
+			return err
+		}
+	}
+
 	var sessionId quickfix.SessionID
-	var ok bool
-	select {
-	case <-time.After(timeout):
-		return errors.ConnectionTimeout
-	case sessionId, ok = <-app.Connected:
-		if !ok {
-			return errors.FixLogout
+	if client != nil {
+		defer func() {
+			app.Stop()
+			client.Close()
+		}()
+		sessionId = client.SessionID()
+	} else {
+		var init *quickfix.Initiator
+		init, sessionId, err = initiator.Connect(cmd.Context(), app, settings, quickfixLogger, app.Connected, timeout, acceptor.Reconnect)
+		if err != nil {
+			app.Stop()
+			return err
 		}
+
+		defer func() {
+			app.Stop()
+			init.Stop()
+		}()
+	}
+
+	filters, err := newSecurityFilters(optionSymbols, optionProducts, optionCurrencies, optionTradingStatuses, transportDict, appDict)
+	if err != nil {
+		return err
 	}
 
 	// Prepare securitylist
@@ -134,8 +171,16 @@ func Execute(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if optionWatch {
+		return watch(cmd.Context(), app, securitylist, sessionId, timeout, filters)
+	}
+
 	// Send the order
-	err = quickfix.SendToTarget(securitylist, sessionId)
+	if client != nil {
+		err = client.Exchange(app, securitylist, timeout, transportDict, appDict)
+	} else {
+		err = quickfix.SendToTarget(securitylist, sessionId)
+	}
 	if err != nil {
 		return err
 	}
@@ -148,7 +193,49 @@ func Execute(cmd *cobra.Command, args []string) error {
 	case responseMessage = <-app.FromAppMessages:
 	}
 
-	app.WriteMessageBodyAsTable(os.Stdout, responseMessage)
+	if responseMessage == nil {
+		return errors.FixLogout
+	}
+
+	if msgType, _ := responseMessage.MsgType(); msgType != string(enum.MsgType_SECURITY_LIST) {
+		app.WriteMessage(os.Stdout, responseMessage)
+		return utils.QuickFixRejectError(responseMessage, errSecurityListRejected)
+	}
+
+	keep := func(instrument *quickfix.Group) bool {
+		return filters.match(newSecurity(instrument, time.Now()))
+	}
+
+	if app.OutputFormat != utils.OutputFormatTable && app.OutputFormat != utils.OutputFormatJSON && app.OutputFormat != utils.OutputFormatCSV {
+		filtered, err := application.FilterSecurityList(responseMessage, appDict, keep)
+		if err != nil {
+			return err
+		}
+		app.WriteMessage(os.Stdout, filtered)
+		return nil
+	}
+
+	instruments, err := application.SecurityListInstruments(responseMessage, appDict)
+	if err != nil {
+		return err
+	}
+
+	securities := make(map[string]*security)
+	for _, instrument := range instruments {
+		if s := newSecurity(instrument, time.Now()); filters.match(s) {
+			securities[s.key()] = s
+		}
+	}
+
+	switch app.OutputFormat {
+	case utils.OutputFormatJSON:
+		return instrument.WriteJSON(os.Stdout, catalog(sortedSecurities(securities)))
+	case utils.OutputFormatCSV:
+		return instrument.WriteCSV(os.Stdout, catalog(sortedSecurities(securities)))
+	}
+
+	utils.RecordResultMessage(responseMessage, transportDict, appDict)
+	writeSecuritiesTable(os.Stdout, sortedSecurities(securities), appDict, false)
 
 	return nil
 }
@@ -0,0 +1,153 @@
+package listsecurity
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/quickfixgo/enum"
+	"github.com/quickfixgo/field"
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/tag"
+	"golang.org/x/term"
+
+	"sylr.dev/fix/pkg/errors"
+	"sylr.dev/fix/pkg/initiator/application"
+	"sylr.dev/fix/pkg/utils"
+)
+
+// tagSecurityUpdateAction is missing from the tag package.
+const tagSecurityUpdateAction quickfix.Tag = 980
+
+// watch subscribes to the security list updates and refreshes the list until
+// interrupted or logged out.
+func watch(ctx context.Context, app *application.SecurityList, request quickfix.Messagable, sessionID quickfix.SessionID, timeout time.Duration, filters *securityFilters) error {
+	request.ToMessage().Body.Set(field.NewSubscriptionRequestType(enum.SubscriptionRequestType_SNAPSHOT_PLUS_UPDATES))
+
+	if err := quickfix.SendToTarget(request, sessionID); err != nil {
+		return err
+	}
+
+	securities := make(map[string]*security)
+	snapshotDone := false
+
+	// Only the first response is expected within the timeout
+	responseTimeout := time.After(timeout)
+
+	for {
+		select {
+		case <-ctx.Done():
+			// Let the acceptor know we are no longer interested in the updates
+			if unsubscribe, err := BuildMessage(sessionID); err == nil {
+				unsubscribe.ToMessage().Body.Set(field.NewSubscriptionRequestType(enum.SubscriptionRequestType_DISABLE_PREVIOUS_SNAPSHOT_PLUS_UPDATE_REQUEST))
+				_ = quickfix.SendToTarget(unsubscribe, sessionID)
+			}
+			return nil
+
+		case <-responseTimeout:
+			return errors.ResponseTimeout
+
+		case message, ok := <-app.FromAppMessages:
+			if !ok {
+				return errors.FixLogout
+			}
+			responseTimeout = nil
+
+			msgType, _ := message.MsgType()
+			if err := utils.QuickFixRejectError(message, errSecurityListRejected); err != nil {
+				app.WriteMessage(os.Stdout, message)
+				return err
+			}
+
+			// A new snapshot replaces the list
+			if msgType == string(enum.MsgType_SECURITY_LIST) {
+				if snapshotDone {
+					securities = make(map[string]*security)
+					snapshotDone = false
+				}
+				if last, err := message.Body.GetBool(tag.LastFragment); err != nil || last {
+					snapshotDone = true
+				}
+			}
+
+			if err := applySecurityListMessage(securities, message, msgType, app, filters); err != nil {
+				return err
+			}
+
+			if app.OutputFormat != utils.OutputFormatTable {
+				filtered, err := application.FilterSecurityList(message, app.AppDataDictionary, func(instrument *quickfix.Group) bool {
+					return filters.match(newSecurity(instrument, time.Now()))
+				})
+				if err != nil {
+					return err
+				}
+				app.WriteMessage(os.Stdout, filtered)
+				continue
+			}
+
+			writeSecurities(securities, app)
+		}
+	}
+}
+
+// applySecurityListMessage adds, updates or removes the securities of a
+// SecurityList or SecurityListUpdateReport message, only adding the ones
+// matching the filters.
+func applySecurityListMessage(securities map[string]*security, message *quickfix.Message, msgType string, app *application.SecurityList, filters *securityFilters) error {
+	now := time.Now()
+
+	instruments, err := application.SecurityListInstruments(message, app.AppDataDictionary)
+	if err != nil {
+		return err
+	}
+
+	// Action of the whole update report, entries can override it
+	action := "A"
+	if value, err := message.Body.GetString(tagSecurityUpdateAction); err == nil {
+		action = value
+	}
+
+	for _, instrument := range instruments {
+		s := newSecurity(instrument, now)
+		entryAction := action
+		if value, err := instrument.GetString(application.TagListUpdateAction); err == nil {
+			entryAction = value
+		}
+
+		key := s.key()
+		if len(key) == 0 {
+			continue
+		}
+
+		if msgType == string(enum.MsgType_SECURITY_LIST_UPDATE_REPORT) && entryAction == "D" {
+			delete(securities, key)
+			continue
+		}
+
+		// A security no longer matching, e.g. after a status change, is removed
+		if !filters.match(s) {
+			delete(securities, key)
+			continue
+		}
+
+		securities[key] = s
+	}
+
+	return nil
+}
+
+func writeSecurities(securities map[string]*security, app *application.SecurityList) {
+	list := sortedSecurities(securities)
+
+	// Redraw the list in place in a terminal
+	if term.IsTerminal(int(os.Stdout.Fd())) {
+		fmt.Print("\x1b[H\x1b[2J")
+	} else {
+		fmt.Println()
+	}
+
+	fmt.Printf("%d securities, updated %s\n\n", len(list), utils.FormatTime(time.Now()))
+
+	writeSecuritiesTable(os.Stdout, list, app.AppDataDictionary, true)
+}
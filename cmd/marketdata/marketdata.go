@@ -3,7 +3,9 @@ package marketdata
 import (
 	"github.com/spf13/cobra"
 
+	markedatareplay "sylr.dev/fix/cmd/marketdata/replay"
 	markedatarequest "sylr.dev/fix/cmd/marketdata/request"
+	"sylr.dev/fix/pkg/health"
 	"sylr.dev/fix/pkg/initiator"
 	"sylr.dev/fix/pkg/utils"
 )
@@ -37,6 +39,8 @@ var MarketDataCmd = &cobra.Command{
 func init() {
 	initiator.AddPersistentFlags(MarketDataCmd)
 	initiator.AddPersistentFlags(markedatarequest.MarketDataRequestCmd)
+	health.AddMetricsFlags(markedatarequest.MarketDataRequestCmd)
+	initiator.AddExitOnDisconnectFlag(markedatarequest.MarketDataRequestCmd)
 
 	if err := initiator.AddPersistentFlagCompletions(MarketDataCmd); err != nil {
 		panic(err)
@@ -46,4 +50,5 @@ func init() {
 	}
 
 	MarketDataCmd.AddCommand(markedatarequest.MarketDataRequestCmd)
+	MarketDataCmd.AddCommand(markedatareplay.MarketDataReplayCmd)
 }
@@ -5,11 +5,14 @@ package marketdata
 
 import (
 	markedatavalidator "sylr.dev/fix/cmd/marketdata/validator"
+	"sylr.dev/fix/pkg/health"
 	"sylr.dev/fix/pkg/initiator"
 )
 
 func init() {
 	initiator.AddPersistentFlags(markedatavalidator.MarketDataValidatorCmd)
+	health.AddMetricsFlags(markedatavalidator.MarketDataValidatorCmd)
+	initiator.AddExitOnDisconnectFlag(markedatavalidator.MarketDataValidatorCmd)
 
 	if err := initiator.AddPersistentFlagCompletions(markedatavalidator.MarketDataValidatorCmd); err != nil {
 		panic(err)
@@ -0,0 +1,212 @@
+package marketdatareplay
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/quickfixgo/enum"
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/tag"
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+
+	"sylr.dev/fix/config"
+	"sylr.dev/fix/pkg/cli/complete"
+	"sylr.dev/fix/pkg/dictionary"
+	"sylr.dev/fix/pkg/errors"
+	"sylr.dev/fix/pkg/initiator/application"
+	"sylr.dev/fix/pkg/replay"
+	"sylr.dev/fix/pkg/utils"
+)
+
+var (
+	optionSymbols   []string
+	optionSpeed     string
+	optionRealtime  bool
+	optionBook      bool
+	optionBookDepth int
+	optionValidate  bool
+
+	speed float64
+)
+
+// replayer handles a replayed message as if it was received on the session.
+type replayer func(message *quickfix.Message, sessionID quickfix.SessionID) quickfix.MessageRejectError
+
+// newValidator returns the replayer validating the market data of the symbols,
+// nil unless built with the validator.
+var newValidator func(ctx context.Context, logger *zerolog.Logger, symbols []string) replayer
+
+var MarketDataReplayCmd = &cobra.Command{
+	Use:   "replay <path>",
+	Short: "Replay recorded market data",
+	Long: "Replay the snapshots and incremental refreshes recorded in a file or in the files of a directory " +
+		"(see --record) without any session, building the order books of the symbols out of them and " +
+		"validating them with --validate, as fast as possible unless --speed or --realtime is given.",
+	Args:              cobra.ExactArgs(1),
+	PersistentPreRunE: Validate,
+	RunE:              Execute,
+}
+
+func init() {
+	dictionary.AddPersistentFlags(MarketDataReplayCmd)
+
+	MarketDataReplayCmd.Flags().StringSliceVar(&optionSymbols, "symbol", []string{}, "Symbols to replay (all the recorded ones if none is given)")
+	MarketDataReplayCmd.Flags().StringVar(&optionSpeed, "speed", "0", "Speed of the replay relative to the recording, e.g. 10x (0 for no pacing)")
+	MarketDataReplayCmd.Flags().BoolVar(&optionRealtime, "realtime", false, "Replay with the pacing of the recording (same as --speed 1x)")
+	MarketDataReplayCmd.Flags().BoolVar(&optionBook, "book", true, "Print the order books of the symbols at the end of the replay")
+	MarketDataReplayCmd.Flags().IntVar(&optionBookDepth, "book-depth", 10, "Number of price levels of each side printed with --book (0 for all)")
+
+	dictionary.AddPersistentFlagCompletions(MarketDataReplayCmd)
+	MarketDataReplayCmd.RegisterFlagCompletionFunc("symbol", complete.Symbol)
+	MarketDataReplayCmd.RegisterFlagCompletionFunc("speed", cobra.NoFileCompletions)
+}
+
+// Validate validates the options, the replay not requiring a session unlike
+// the other marketdata commands whose validation is skipped for the one of the
+// root command.
+func Validate(cmd *cobra.Command, args []string) error {
+	if err := utils.ValidateRequiredFlags(cmd); err != nil {
+		return err
+	}
+
+	if err := utils.ReconcileBoolFlags(cmd.Flags()); err != nil {
+		return err
+	}
+
+	if err := dictionary.ValidateDictionaryOptions(cmd, args); err != nil {
+		return err
+	}
+
+	if optionRealtime && cmd.Flags().Changed("speed") {
+		return fmt.Errorf("%w: can't use --speed with --realtime", errors.Options)
+	}
+
+	var err error
+	if optionRealtime {
+		speed = 1
+	} else if speed, err = strconv.ParseFloat(strings.TrimSuffix(strings.ToLower(optionSpeed), "x"), 64); err != nil || speed < 0 {
+		return fmt.Errorf("%w: invalid speed `%s`", errors.Options, optionSpeed)
+	}
+
+	if root := cmd.Root(); root != cmd && root.PersistentPreRunE != nil {
+		return root.PersistentPreRunE(root, args)
+	}
+
+	return nil
+}
+
+func Execute(cmd *cobra.Command, args []string) error {
+	logger := config.GetLogger()
+	ctx := cmd.Context()
+
+	transportDict, appDict, err := dictionary.GetFIXDictionaries()
+	if err != nil {
+		return err
+	}
+
+	recorded, err := replay.Load(args[0])
+	if err != nil {
+		return err
+	}
+
+	messages, symbols := marketData(recorded, optionSymbols)
+	if len(messages) == 0 {
+		return fmt.Errorf("no market data found in %s", args[0])
+	}
+
+	book := application.NewOrderBook()
+	book.AppDataDictionary = appDict
+
+	var validate replayer
+	if optionValidate {
+		validate = newValidator(ctx, logger, symbols)
+	}
+
+	logger.Info().Int("messages", len(messages)).Strs("symbols", symbols).Float64("speed", speed).Msg("Replaying market data")
+
+	replayed := 0
+	err = replay.Play(ctx, messages, speed, func(m replay.Message) error {
+		message, err := utils.ParseQuickFixRawMessage(m.Raw, transportDict, appDict)
+		if err != nil {
+			logger.Warn().Err(err).Msg("Could not parse recorded message")
+			return nil
+		}
+
+		if err := book.Apply(message); err != nil {
+			logger.Error().Err(err).Msg("Could not update the order book")
+		}
+
+		if validate != nil {
+			if rej := validate(message, sessionID(message)); rej != nil {
+				logger.Error().Err(rej).Msg("Invalid market data")
+			}
+		}
+
+		replayed++
+		return nil
+	})
+	if err != nil && err != context.Canceled {
+		return err
+	}
+
+	logger.Info().Int("messages", replayed).Msg("Market data replayed")
+
+	if optionBook {
+		book.Render(os.Stdout, optionBookDepth)
+	}
+
+	return nil
+}
+
+// marketData returns the snapshots and incremental refreshes of the symbols
+// among the recorded messages, of all the symbols if none is given, and the
+// symbols replayed.
+func marketData(recorded []replay.Message, symbols []string) ([]replay.Message, []string) {
+	wanted := make(map[string]bool, len(symbols))
+	for _, symbol := range symbols {
+		wanted[symbol] = true
+	}
+
+	seen := make(map[string]bool)
+	var messages []replay.Message
+	for _, m := range recorded {
+		switch enum.MsgType(m.MsgType()) {
+		case enum.MsgType_MARKET_DATA_SNAPSHOT_FULL_REFRESH, enum.MsgType_MARKET_DATA_INCREMENTAL_REFRESH:
+		default:
+			continue
+		}
+
+		keep := false
+		for _, symbol := range m.Values(int(tag.Symbol)) {
+			if len(wanted) == 0 || wanted[symbol] {
+				seen[symbol] = true
+				keep = true
+			}
+		}
+		if keep {
+			messages = append(messages, m)
+		}
+	}
+
+	replayed := make([]string, 0, len(seen))
+	for symbol := range seen {
+		replayed = append(replayed, symbol)
+	}
+	sort.Strings(replayed)
+
+	return messages, replayed
+}
+
+// sessionID returns the session the message was received on.
+func sessionID(message *quickfix.Message) quickfix.SessionID {
+	beginString, _ := message.Header.GetString(tag.BeginString)
+	senderCompID, _ := message.Header.GetString(tag.SenderCompID)
+	targetCompID, _ := message.Header.GetString(tag.TargetCompID)
+
+	return quickfix.SessionID{BeginString: beginString, SenderCompID: targetCompID, TargetCompID: senderCompID}
+}
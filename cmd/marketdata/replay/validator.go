@@ -0,0 +1,23 @@
+//go:build validator
+// +build validator
+
+package marketdatareplay
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"sylr.dev/fix/pkg/initiator/application"
+)
+
+func init() {
+	MarketDataReplayCmd.Flags().BoolVar(&optionValidate, "validate", false, "Validate the market data as the marketdata validator does")
+
+	newValidator = func(ctx context.Context, logger *zerolog.Logger, symbols []string) replayer {
+		options := application.MarketDataValidatorOptions{Symbols: symbols}
+
+		return application.NewMarketDataValidator(ctx, logger, options, time.Duration(0)).Replay
+	}
+}
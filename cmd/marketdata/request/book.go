@@ -0,0 +1,115 @@
+package marketdatarequest
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+
+	"sylr.dev/fix/pkg/initiator/application"
+)
+
+// bookRefreshPeriod is the minimum period between two draws of the books.
+const bookRefreshPeriod = 100 * time.Millisecond
+
+// bookView draws the order books, in the alternate screen of the terminal or,
+// when not on a terminal, as text written each time they change.
+type bookView struct {
+	book    *application.OrderBook
+	tty     bool
+	started bool
+	dirty   bool
+	// last is the text last written when not on a terminal
+	last string
+}
+
+func newBookView(book *application.OrderBook) *bookView {
+	return &bookView{
+		book:  book,
+		tty:   term.IsTerminal(int(os.Stdout.Fd())),
+		dirty: true,
+	}
+}
+
+func (v *bookView) start() {
+	if v.tty {
+		// Alternate screen, hidden cursor
+		fmt.Print("\x1b[?1049h\x1b[?25l")
+		v.started = true
+	}
+}
+
+// stop leaves the alternate screen, the books being written on the terminal
+// by the next draw.
+func (v *bookView) stop() {
+	if v.started {
+		fmt.Print("\x1b[?25h\x1b[?1049l")
+		v.started = false
+		v.dirty = true
+	}
+}
+
+// changed tells the view the books changed since the last draw.
+func (v *bookView) changed() {
+	v.dirty = true
+}
+
+func (v *bookView) draw() error {
+	if !v.dirty {
+		return nil
+	}
+	v.dirty = false
+
+	buf := v.render()
+
+	if !v.started {
+		if buf.String() == v.last {
+			return nil
+		}
+		v.last = buf.String()
+
+		buf.WriteString("\n")
+		_, err := os.Stdout.Write(buf.Bytes())
+		return err
+	}
+
+	width, height, _ := term.GetSize(int(os.Stdout.Fd()))
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+
+	var out bytes.Buffer
+	out.WriteString("\x1b[H")
+	for i, line := range lines {
+		if height > 0 && i >= height {
+			break
+		}
+		if width > 0 && len(line) > width {
+			line = line[:width]
+		}
+		out.WriteString(line)
+		out.WriteString("\x1b[K")
+		if i < len(lines)-1 {
+			out.WriteString("\r\n")
+		}
+	}
+	out.WriteString("\x1b[J")
+
+	_, err := os.Stdout.Write(out.Bytes())
+
+	return err
+}
+
+func (v *bookView) render() *bytes.Buffer {
+	var buf bytes.Buffer
+
+	if len(v.book.Symbols()) == 0 {
+		buf.WriteString("Waiting for market data\n")
+		return &buf
+	}
+
+	v.book.Render(&buf, optionBookDepth)
+
+	return &buf
+}
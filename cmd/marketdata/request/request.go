@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -20,10 +22,12 @@ import (
 
 	"sylr.dev/fix/config"
 	"sylr.dev/fix/pkg/cli/complete"
+	"sylr.dev/fix/pkg/cli/options"
 	"sylr.dev/fix/pkg/dict"
 	"sylr.dev/fix/pkg/errors"
 	"sylr.dev/fix/pkg/initiator"
 	"sylr.dev/fix/pkg/initiator/application"
+	"sylr.dev/fix/pkg/sessiond"
 	"sylr.dev/fix/pkg/utils"
 )
 
@@ -36,6 +40,10 @@ var (
 	optionPrintData   bool
 	optionPrintNews   bool
 	optionMarketDepth int
+	optionBook        bool
+	optionBookDepth   int
+	optionSocket      string
+	recordOptions     *options.RecordOptions
 
 	SubType      enum.SubscriptionRequestType
 	MDUpdateType enum.MDUpdateType
@@ -49,6 +57,9 @@ var MarketDataRequestCmd = &cobra.Command{
 	ValidArgsFunction: cobra.NoFileCompletions,
 	PersistentPreRunE: utils.MakePersistentPreRunE(Validate),
 	RunE:              Execute,
+	Annotations: map[string]string{
+		initiator.MultiSessionsAnnotation: "true",
+	},
 }
 
 func init() {
@@ -60,11 +71,16 @@ func init() {
 	MarketDataRequestCmd.Flags().BoolVar(&optionPrintData, "print-data", true, "Print data")
 	MarketDataRequestCmd.Flags().BoolVar(&optionPrintNews, "news", true, "Print news")
 	MarketDataRequestCmd.Flags().IntVar(&optionMarketDepth, "depth", 0, "Market depth (default value: 0 - full book)")
+	MarketDataRequestCmd.Flags().BoolVar(&optionBook, "book", false, "Display the order books of the symbols instead of the refreshes")
+	MarketDataRequestCmd.Flags().IntVar(&optionBookDepth, "book-depth", 10, "Number of price levels of each side displayed with --book (0 for all)")
+	MarketDataRequestCmd.Flags().StringVar(&optionSocket, "socket", "", "Unix socket of the session served by `fix session hold --serve`, used for snapshots (default $HOME/.fix/sessions/<context>.sock if it exists)")
 
-	MarketDataRequestCmd.RegisterFlagCompletionFunc("symbol", cobra.NoFileCompletions)
+	MarketDataRequestCmd.RegisterFlagCompletionFunc("symbol", complete.Symbol)
 	MarketDataRequestCmd.RegisterFlagCompletionFunc("type", complete.MDEntryTypes)
 	MarketDataRequestCmd.RegisterFlagCompletionFunc("sub-type", complete.SubscriptionRequestTypes)
 	MarketDataRequestCmd.RegisterFlagCompletionFunc("update-type", complete.MDUpdateTypes)
+
+	recordOptions = options.NewRecordOptions(MarketDataRequestCmd)
 }
 
 func Validate(cmd *cobra.Command, args []string) error {
@@ -100,18 +116,55 @@ func Validate(cmd *cobra.Command, args []string) error {
 		optionMDReqID = uuid.NewString()
 	}
 
-	return nil
+	return recordOptions.Validate()
+}
+
+// result is the outcome of the subscription made on a session.
+type result struct {
+	MDReqID   string `json:"mdReqID"`
+	Snapshots int    `json:"snapshots"`
+	Refreshes int    `json:"refreshes"`
 }
 
 func Execute(cmd *cobra.Command, args []string) error {
 	options := config.GetOptions()
-	logger := config.GetLogger()
 
 	context, err := config.GetCurrentContext()
 	if err != nil {
 		return err
 	}
 
+	if len(context.Sessions) == 1 {
+		return execute(cmd, context, &result{}, "marketdata")
+	}
+
+	if optionBook {
+		return fmt.Errorf("%w: can't use --book with several sessions", errors.Options)
+	}
+
+	// The subscription is made on every session of the context at once
+	results := initiator.FanOut(context, func(c *config.Context) (result, error) {
+		res := result{}
+		err := execute(cmd, c, &res, "marketdata-"+c.Sessions[0])
+		return res, err
+	})
+
+	err = initiator.WriteResults(os.Stdout, options.Output, results, []string{"MDREQID", "SNAPSHOTS", "REFRESHES"}, func(r result) []string {
+		return []string{r.MDReqID, strconv.Itoa(r.Snapshots), strconv.Itoa(r.Refreshes)}
+	})
+	if err != nil {
+		return err
+	}
+
+	return initiator.ResultsError(results)
+}
+
+// execute subscribes on the session of the context, the outcome being written
+// into res and the messages recorded into files named after recordPrefix.
+func execute(cmd *cobra.Command, context *config.Context, res *result, recordPrefix string) error {
+	options := config.GetOptions()
+	logger := config.GetLogger()
+
 	sessions, err := context.GetSessions()
 	if err != nil {
 		return err
@@ -123,6 +176,7 @@ func Execute(cmd *cobra.Command, args []string) error {
 	}
 
 	session := sessions[0]
+	logger = session.GetLogger()
 	transportDict, appDict, err := session.GetFIXDictionaries()
 	if err != nil {
 		return err
@@ -133,32 +187,42 @@ func Execute(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	app := application.NewMarketDataRequest(optionPrintData, optionPrintNews)
+	app := application.NewMarketDataRequest(optionPrintData && !optionBook, optionPrintNews && !optionBook)
 	app.Logger = logger
 	app.Settings = settings
 	app.TransportDataDictionary = transportDict
 	app.AppDataDictionary = appDict
-
-	var quickfixLogger *zerolog.Logger
-	if options.QuickFixLogging {
-		quickfixLogger = logger
+	app.OutputFormat = options.Output
+	app.Resumable = ctxInitiator.Reconnect.OnDisconnect
+
+	// The requests sent again on logon get new MDReqIDs
+	var idsMux sync.Mutex
+	ids := []string{optionMDReqID}
+	app.OnResubscribe = func(previous, mdReqID string) {
+		idsMux.Lock()
+		defer idsMux.Unlock()
+		ids = append(ids, mdReqID)
 	}
 
-	init, err := initiator.Initiate(app, settings, quickfixLogger)
+	app.Recorder, err = recordOptions.Recorder(recordPrefix, transportDict, appDict)
 	if err != nil {
 		return err
 	}
+	if app.Recorder != nil {
+		defer app.Recorder.Close()
+	}
 
-	// Start session
-	err = init.Start()
-	if err != nil {
-		return err
+	var view *bookView
+	if optionBook {
+		app.Book = application.NewOrderBook()
+		app.Book.AppDataDictionary = appDict
+		view = newBookView(app.Book)
 	}
 
-	defer func() {
-		app.Stop()
-		init.Stop()
-	}()
+	var quickfixLogger *zerolog.Logger
+	if options.QuickFixLogging {
+		quickfixLogger = logger
+	}
 
 	// Choose right timeout cli option > config > default value (5s)
 	var timeout time.Duration
@@ -170,53 +234,161 @@ func Execute(cmd *cobra.Command, args []string) error {
 		timeout = 5 * time.Second
 	}
 
-	// Wait for session connection
+	// The session held by `fix session hold --serve` spares the logon, its
+	// requests being answered by a single message
+	var client *sessiond.Client
+	if SubType == enum.SubscriptionRequestType_SNAPSHOT {
+		if client, err = sessiond.Served(optionSocket, context.Name, settings); err != nil {
+			return err
+		}
+	}
+
 	var sessionId quickfix.SessionID
-	var ok bool
-	select {
-	case <-time.After(timeout):
-		return errors.ConnectionTimeout
-	case sessionId, ok = <-app.Connected:
-		if !ok {
-			return errors.FixLogout
+	if client != nil {
+		defer func() {
+			app.Stop()
+			client.Close()
+		}()
+		sessionId = client.SessionID()
+	} else {
+		var init *quickfix.Initiator
+		init, sessionId, err = initiator.Connect(cmd.Context(), app, settings, quickfixLogger, app.Connected, timeout, ctxInitiator.Reconnect)
+		if err != nil {
+			app.Stop()
+			return err
 		}
+
+		defer func() {
+			app.Stop()
+			init.Stop()
+		}()
 	}
 
+	res.MDReqID = optionMDReqID
+
 	// Prepare securitylist
-	securitylist, err := buildMessage(*session)
+	securitylist, err := buildMessage(*session, optionMDReqID, SubType)
 	if err != nil {
 		return err
 	}
 
 	// Send the order
-	err = quickfix.SendToTarget(securitylist, sessionId)
+	if client != nil {
+		err = client.Exchange(app, securitylist, timeout, transportDict, appDict)
+	} else {
+		err = quickfix.SendToTarget(securitylist, sessionId)
+	}
 	if err != nil {
 		return err
 	}
 
-	interrupt := make(chan os.Signal, 1)
-	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	ctx := cmd.Context()
+
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	// The book is drawn at most every bookRefreshPeriod
+	var redraw <-chan time.Time
+	if view != nil {
+		view.start()
+		defer view.stop()
+
+		ticker := time.NewTicker(bookRefreshPeriod)
+		defer ticker.Stop()
+		redraw = ticker.C
+	}
 
 LOOP:
 	for {
 		select {
-		case signal := <-interrupt:
-			logger.Debug().Msgf("Received signal: %s", signal)
+		case <-ctx.Done():
+			logger.Debug().Msg("Interrupted")
 
 			break LOOP
-		case _, ok := <-app.FromAppMessages:
+
+		case <-redraw:
+			if err := view.draw(); err != nil {
+				return err
+			}
+
+		case <-hangup:
+			logger.Info().Msg("SIGHUP received, reloading configuration, rotating logs and subscribing again")
+
+			if err := config.Reload(cmd.Flags().Changed("config"), sessionSettings(session.Name)); err != nil {
+				logger.Error().Err(err).Msg("Could not reload the configuration")
+			}
+			if err := utils.RotateQuickFixLogs(); err != nil {
+				logger.Error().Err(err).Msg("Could not rotate the logs")
+			}
+			if app.Recorder != nil {
+				if err := app.Recorder.Rotate(); err != nil {
+					logger.Error().Err(err).Msg("Could not rotate the recorded files")
+				}
+			}
+			if err := app.Resubscribe(sessionId, true); err != nil {
+				logger.Error().Err(err).Msg("Could not subscribe again")
+			}
+
+		case msg, ok := <-app.FromAppMessages:
+			if ok {
+				// Ignore the responses to other requests
+				idsMux.Lock()
+				correlates := utils.QuickFixMessageCorrelates(msg.ToMessage(), ids...)
+				idsMux.Unlock()
+				if !correlates {
+					continue LOOP
+				}
+			}
+			if view != nil {
+				view.changed()
+			}
+			if ok {
+				res.count(msg)
+			}
 			if !ok || SubType == enum.SubscriptionRequestType_SNAPSHOT {
 				break LOOP
 			}
 		}
 	}
 
+	// The last state of the books stays on the terminal
+	if view != nil {
+		view.stop()
+		return view.draw()
+	}
+
 	return nil
 }
 
-func buildMessage(session config.Session) (quickfix.Messagable, error) {
-	mdReqID := field.NewMDReqID(optionMDReqID)
-	subReqType := field.NewSubscriptionRequestType(dict.SubscriptionRequestTypes[strings.ToUpper(optionSubType)])
+// count counts the snapshot or incremental refresh received.
+func (r *result) count(msg quickfix.Messagable) {
+	msgType, _ := msg.ToMessage().MsgType()
+
+	switch enum.MsgType(msgType) {
+	case enum.MsgType_MARKET_DATA_SNAPSHOT_FULL_REFRESH:
+		r.Snapshots++
+	case enum.MsgType_MARKET_DATA_INCREMENTAL_REFRESH:
+		r.Refreshes++
+	}
+}
+
+// sessionSettings returns the function turning the reloaded context into the
+// settings of the session, the context holding several when fanning out.
+func sessionSettings(name string) func(config.Context) (*quickfix.Settings, error) {
+	return func(c config.Context) (*quickfix.Settings, error) {
+		single, err := c.SelectSessions(name)
+		if err != nil {
+			return nil, err
+		}
+
+		return single.ToQuickFixInitiatorSettings()
+	}
+}
+
+func buildMessage(session config.Session, id string, subType enum.SubscriptionRequestType) (quickfix.Messagable, error) {
+	mdReqID := field.NewMDReqID(id)
+	subReqType := field.NewSubscriptionRequestType(subType)
 	marketDepth := field.NewMarketDepth(optionMarketDepth)
 
 	// Message
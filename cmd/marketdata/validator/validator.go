@@ -13,13 +13,18 @@ import (
 	"github.com/spf13/cobra"
 
 	"sylr.dev/fix/config"
+	"sylr.dev/fix/pkg/cli/complete"
+	"sylr.dev/fix/pkg/cli/options"
 	"sylr.dev/fix/pkg/initiator"
 	"sylr.dev/fix/pkg/initiator/application"
+	"sylr.dev/fix/pkg/instrument"
 	"sylr.dev/fix/pkg/utils"
 )
 
 var (
-	validatorOptions application.MarketDataValidatorOptions
+	validatorOptions  application.MarketDataValidatorOptions
+	optionSymbolsFile string
+	recordOptions     *options.RecordOptions
 )
 
 var MarketDataValidatorCmd = &cobra.Command{
@@ -34,10 +39,12 @@ var MarketDataValidatorCmd = &cobra.Command{
 
 func init() {
 	MarketDataValidatorCmd.Flags().StringSliceVar(&validatorOptions.Symbols, "symbol", []string{}, "Symbol")
+	MarketDataValidatorCmd.Flags().StringVar(&optionSymbolsFile, "symbols-file", "", "Instrument catalog (JSON or CSV) to read symbols from")
 	MarketDataValidatorCmd.Flags().BoolVar(&validatorOptions.TradeHistory, "trade-history", false, "Subscribe to trade history")
-	MarketDataValidatorCmd.Flags().BoolVar(&validatorOptions.ExitOnDisconnect, "exit-on-disconnect", false, "Subscribe to trade history")
 
-	_ = MarketDataValidatorCmd.RegisterFlagCompletionFunc("symbol", cobra.NoFileCompletions)
+	_ = MarketDataValidatorCmd.RegisterFlagCompletionFunc("symbol", complete.Symbol)
+
+	recordOptions = options.NewRecordOptions(MarketDataValidatorCmd)
 }
 
 func Validate(cmd *cobra.Command, args []string) error {
@@ -46,7 +53,15 @@ func Validate(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	return nil
+	if len(optionSymbolsFile) > 0 {
+		instruments, err := instrument.Load(optionSymbolsFile)
+		if err != nil {
+			return err
+		}
+		validatorOptions.Symbols = append(validatorOptions.Symbols, instrument.Symbols(instruments)...)
+	}
+
+	return recordOptions.Validate()
 }
 
 func Execute(cmd *cobra.Command, args []string) error {
@@ -69,6 +84,7 @@ func Execute(cmd *cobra.Command, args []string) error {
 	}
 
 	session := sessions[0]
+	logger = session.GetLogger()
 	transportDict, appDict, err := session.GetFIXDictionaries()
 	if err != nil {
 		return err
@@ -79,11 +95,20 @@ func Execute(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	app := application.NewMarketDataValidator(logger, validatorOptions, buildTimeoutDuration(ctxInitiator))
+	validatorOptions.ExitOnDisconnect = options.ExitOnDisconnect
+	app := application.NewMarketDataValidator(cmd.Context(), logger, validatorOptions, buildTimeoutDuration(ctxInitiator))
 	app.Settings = settings
 	app.TransportDataDictionary = transportDict
 	app.AppDataDictionary = appDict
 
+	app.Recorder, err = recordOptions.Recorder("marketdata", transportDict, appDict)
+	if err != nil {
+		return err
+	}
+	if app.Recorder != nil {
+		defer app.Recorder.Close()
+	}
+
 	var quickfixLogger *zerolog.Logger
 	if options.QuickFixLogging {
 		quickfixLogger = logger
@@ -105,20 +130,39 @@ func Execute(cmd *cobra.Command, args []string) error {
 		init.Stop()
 	}()
 
-	interrupt := make(chan os.Signal, 1)
-	signal.Notify(interrupt, syscall.SIGINT, syscall.SIGTERM)
+	ctx := cmd.Context()
+
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
 
 LOOP:
 	for {
 		select {
-		case sig := <-interrupt:
-			switch sig {
-			case syscall.SIGINT, syscall.SIGTERM:
-				logger.Debug().Msgf("Received signal: %v", sig)
-				break LOOP
-			default:
-				logger.Info().Msgf("Received unhandled signal: %v", sig)
+		case <-ctx.Done():
+			logger.Debug().Msg("Interrupted")
+			break LOOP
+
+		case <-hangup:
+			logger.Info().Msg("SIGHUP received, reloading configuration, rotating logs and subscribing again")
+
+			if err := config.Reload(cmd.Flags().Changed("config"), config.Context.ToQuickFixInitiatorSettings); err != nil {
+				logger.Error().Err(err).Msg("Could not reload the configuration")
 			}
+			if err := utils.RotateQuickFixLogs(); err != nil {
+				logger.Error().Err(err).Msg("Could not rotate the logs")
+			}
+			if app.Recorder != nil {
+				if err := app.Recorder.Rotate(); err != nil {
+					logger.Error().Err(err).Msg("Could not rotate the recorded files")
+				}
+			}
+			if err := app.Resubscribe(); err != nil {
+				logger.Error().Err(err).Msg("Could not subscribe again")
+			}
+
+		case err := <-app.Errors:
+			return err
 
 		case msg, ok := <-app.AppInfoChan:
 			if !ok {
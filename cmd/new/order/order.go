@@ -4,8 +4,8 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
-	"syscall"
 	"time"
 
 	"github.com/google/uuid"
@@ -17,6 +17,7 @@ import (
 	"github.com/quickfixgo/field"
 	"github.com/quickfixgo/fixt11"
 	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/quickfix/datadictionary"
 	"github.com/quickfixgo/tag"
 
 	"sylr.dev/fix/config"
@@ -26,6 +27,9 @@ import (
 	"sylr.dev/fix/pkg/errors"
 	"sylr.dev/fix/pkg/initiator"
 	"sylr.dev/fix/pkg/initiator/application"
+	"sylr.dev/fix/pkg/positions"
+	"sylr.dev/fix/pkg/risk"
+	"sylr.dev/fix/pkg/sessiond"
 	"sylr.dev/fix/pkg/utils"
 )
 
@@ -41,10 +45,13 @@ var (
 	optionExecReportsTimeout         time.Duration
 	optionExecReportsTimeoutReset    bool
 	optionStopOnFinalState           bool
+	optionFollow                     bool
 	optionUpdatePeriod               time.Duration
 	optionUpdateOrderQuantity        float64
 	optionUpdateOrderPrice           float64
 	optionUpdateFillOrderId          bool
+	optionPositions                  bool
+	optionSocket                     string
 )
 
 var NewOrderCmd = &cobra.Command{
@@ -55,6 +62,9 @@ var NewOrderCmd = &cobra.Command{
 	ValidArgsFunction: cobra.NoFileCompletions,
 	PersistentPreRunE: utils.MakePersistentPreRunE(Validate),
 	RunE:              Execute,
+	Annotations: map[string]string{
+		initiator.MultiSessionsAnnotation: "true",
+	},
 }
 
 func init() {
@@ -74,6 +84,9 @@ func init() {
 	NewOrderCmd.Flags().BoolVar(&optionExecReportsTimeoutReset, "exec-reports-timeout-reset", false, "Reset execution reports timeout each time an execution report is received")
 
 	NewOrderCmd.Flags().BoolVar(&optionStopOnFinalState, "stop-on-final-state", false, "Stop application when receiving an order with a final state")
+	NewOrderCmd.Flags().BoolVar(&optionFollow, "follow", false, "Stream the execution reports of the order until it reaches a final state or until interrupted")
+	NewOrderCmd.Flags().BoolVar(&optionPositions, "positions", false, "Net the fills received into positions, written on SIGUSR1 and before exiting")
+	NewOrderCmd.Flags().StringVar(&optionSocket, "socket", "", "Unix socket of the session served by `fix session hold --serve`, used when expecting a single execution report (default $HOME/.fix/sessions/<context>.sock if it exists)")
 
 	NewOrderCmd.Flags().DurationVar(&optionUpdatePeriod, "update-period", 0, "Period for recurring order price/quantity updates")
 	NewOrderCmd.Flags().Float64Var(&optionUpdateOrderQuantity, "update-order-quantity", 0.0, "Update order quantity after each period")
@@ -88,7 +101,7 @@ func init() {
 	NewOrderCmd.RegisterFlagCompletionFunc("side", complete.OrderSide)
 	NewOrderCmd.RegisterFlagCompletionFunc("type", complete.OrderType)
 	NewOrderCmd.RegisterFlagCompletionFunc("expiry", complete.OrderTimeInForce)
-	NewOrderCmd.RegisterFlagCompletionFunc("symbol", cobra.NoFileCompletions)
+	NewOrderCmd.RegisterFlagCompletionFunc("symbol", complete.Symbol)
 	NewOrderCmd.RegisterFlagCompletionFunc("origination", complete.OrderOriginationRole)
 }
 
@@ -109,6 +122,15 @@ func Validate(cmd *cobra.Command, args []string) error {
 		optionOrderID = uuid.NewString()
 	}
 
+	if optionFollow {
+		if cmd.Flags().Changed("exec-reports") || cmd.Flags().Changed("exec-reports-timeout") {
+			return fmt.Errorf("%w: can't use --exec-reports/--exec-reports-timeout with --follow", errors.Options)
+		}
+		optionExecReports = 0
+		optionExecReportsTimeout = 0
+		optionStopOnFinalState = true
+	}
+
 	if len(optionOrderOrigination) > 0 {
 		originations := utils.PrettyOptionValues(dict.OrderOriginations)
 		search = utils.Search(originations, strings.ToLower(optionOrderOrigination))
@@ -126,21 +148,56 @@ func Validate(cmd *cobra.Command, args []string) error {
 	return partyIdOptions.Validate()
 }
 
+// result is the outcome of the order sent on a session.
+type result struct {
+	ClOrdID     string                 `json:"clOrdID"`
+	OrderID     string                 `json:"orderID,omitempty"`
+	State       application.OrderState `json:"state,omitempty"`
+	ExecReports int                    `json:"execReports"`
+}
+
 func Execute(cmd *cobra.Command, args []string) error {
 	options := config.GetOptions()
-	logger := config.GetLogger()
 
 	context, err := config.GetCurrentContext()
 	if err != nil {
 		return err
 	}
 
+	if len(context.Sessions) == 1 {
+		return execute(cmd, context, &result{})
+	}
+
+	// The order is sent on every session of the context at once
+	results := initiator.FanOut(context, func(c *config.Context) (result, error) {
+		res := result{}
+		err := execute(cmd, c, &res)
+		return res, err
+	})
+
+	err = initiator.WriteResults(os.Stdout, options.Output, results, []string{"CLORDID", "ORDERID", "STATE", "EXEC REPORTS"}, func(r result) []string {
+		return []string{r.ClOrdID, r.OrderID, string(r.State), strconv.Itoa(r.ExecReports)}
+	})
+	if err != nil {
+		return err
+	}
+
+	return initiator.ResultsError(results)
+}
+
+// execute sends the order on the session of the context, its outcome being
+// written into res.
+func execute(cmd *cobra.Command, context *config.Context, res *result) error {
+	options := config.GetOptions()
+	logger := config.GetLogger()
+
 	sessions, err := context.GetSessions()
 	if err != nil {
 		return err
 	}
 
 	session := sessions[0]
+	logger = session.GetLogger()
 	initiatorConfig, err := context.GetInitiator()
 	if err != nil {
 		return err
@@ -161,6 +218,19 @@ func Execute(cmd *cobra.Command, args []string) error {
 	app.Settings = settings
 	app.TransportDataDictionary = transportDict
 	app.AppDataDictionary = appDict
+	app.OutputFormat = options.Output
+	app.Resumable = initiatorConfig.Reconnect.OnDisconnect
+	if optionPositions {
+		app.Positions = positions.NewBook()
+	}
+
+	res.ClOrdID = optionOrderID
+	defer func() {
+		if order, ok := app.Orders.Get(optionOrderID); ok {
+			res.OrderID = order.OrderID
+			res.State = order.State
+		}
+	}()
 
 	var quickfixLogger *zerolog.Logger
 	if options.QuickFixLogging {
@@ -177,47 +247,61 @@ func Execute(cmd *cobra.Command, args []string) error {
 		timeout = 5 * time.Second
 	}
 
-	init, err := initiator.Initiate(app, settings, quickfixLogger)
-	if err != nil {
-		return err
-	}
-
-	// Start session
-	if err = init.Start(); err != nil {
-		return err
+	// The session held by `fix session hold --serve` spares the logon, its
+	// requests being answered by a single message
+	var client *sessiond.Client
+	if optionExecReports == 1 && optionUpdatePeriod == 0 {
+		if client, err = sessiond.Served(optionSocket, context.Name, settings); err != nil {
+			return err
+		}
 	}
 
-	defer func() {
-		app.Stop()
-		init.Stop()
-	}()
-
-	// Wait for session connection
 	var sessionId quickfix.SessionID
-	var ok bool
-	select {
-	case <-time.After(timeout):
-		return errors.ConnectionTimeout
-	case sessionId, ok = <-app.Connected:
-		if !ok {
-			return errors.FixLogout
+	if client != nil {
+		defer func() {
+			app.Stop()
+			client.Close()
+		}()
+		sessionId = client.SessionID()
+	} else {
+		var init *quickfix.Initiator
+		init, sessionId, err = initiator.Connect(cmd.Context(), app, settings, quickfixLogger, app.Connected, timeout, initiatorConfig.Reconnect)
+		if err != nil {
+			app.Stop()
+			return err
 		}
+
+		defer func() {
+			app.Stop()
+			init.Stop()
+		}()
+	}
+
+	// The risk limits may need the market price of the symbol
+	priced := strings.ToLower(optionOrderType) != "market"
+	if err := risk.SessionChecker(sessionId).RequestReferencePrice(cmd.Context(), *session, sessionId, optionOrderSymbol, priced, timeout); err != nil {
+		return err
 	}
 
 	// Prepare order
-	order, err := buildMessage(*session)
+	order, err := buildMessage(*session, appDict)
 	if err != nil {
 		return err
 	}
 
 	// Send the order
-	err = quickfix.SendToTarget(order, sessionId)
+	if client != nil {
+		err = client.Exchange(app, order, optionExecReportsTimeout, transportDict, appDict)
+	} else {
+		err = quickfix.SendToTarget(order, sessionId)
+	}
 	if err != nil {
 		return err
 	}
 
-	interrupt := make(chan os.Signal, 1)
-	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	ids := []string{optionOrderID}
+
+	ctx := cmd.Context()
 
 	execReports := 0
 	var waitTimeout <-chan time.Time
@@ -234,20 +318,30 @@ func Execute(cmd *cobra.Command, args []string) error {
 
 	var lastExecutionReport *quickfix.Message
 
+	summary := make(chan os.Signal, 1)
+	// Notify would relay all the signals given none
+	if signals := positions.SummarySignals(); optionPositions && len(signals) > 0 {
+		signal.Notify(summary, signals...)
+		defer signal.Stop(summary)
+	}
+
 LOOP:
 	for {
 		select {
-		case signal := <-interrupt:
-			logger.Debug().Msgf("Received signal: %s", signal)
+		case <-ctx.Done():
+			logger.Debug().Msg("Interrupted")
 			break LOOP
 
 		case <-waitTimeout:
+			if execReports == 0 {
+				return fmt.Errorf("%w: expecting execution reports", errors.ResponseTimeout)
+			}
 			logger.Warn().Msgf("Timeout while expecting execution reports (%d/%d)", execReports, optionExecReports)
 			break LOOP
 
 		case <-updatePeriod:
 			// Prepare order
-			orderUpdateMsg, err := buildCancelReplaceMessage(*session, lastExecutionReport)
+			orderUpdateMsg, err := buildCancelReplaceMessage(*session, appDict, lastExecutionReport)
 			if err != nil {
 				return err
 			}
@@ -258,11 +352,33 @@ LOOP:
 				return err
 			}
 
+		case <-summary:
+			if err := app.Positions.Write(os.Stdout, options.Output); err != nil {
+				return err
+			}
+			continue LOOP
+
+		case <-app.Disconnected:
+			sessionId, err = initiator.Reconnect(cmd.Context(), app.Connected, app.Disconnected, timeout, initiatorConfig.Reconnect)
+			if err != nil {
+				return err
+			}
+
 		case msg, ok := <-app.FromAppMessages:
 			if !ok {
 				break LOOP
 			}
 
+			// Ignore the responses to other requests
+			if !utils.QuickFixMessageCorrelates(msg, ids...) {
+				continue LOOP
+			}
+
+			// Follow the order through its replacements
+			if orderID, err := msg.Body.GetString(tag.OrderID); err == nil && utils.Search(ids, orderID) < 0 {
+				ids = append(ids, orderID)
+			}
+
 			if err := processResponse(app, msg); err != nil {
 				if errors.Is(err, quickfix.InvalidMessageType()) {
 					continue LOOP
@@ -275,7 +391,7 @@ LOOP:
 				lastExecutionReport = msg
 			}
 
-			if optionStopOnFinalState && isFinalStatus(msg) {
+			if order, ok := app.Orders.Get(optionOrderID); ok && optionStopOnFinalState && order.State.Final() {
 				break LOOP
 			}
 
@@ -285,6 +401,7 @@ LOOP:
 			}
 
 			execReports = execReports + 1
+			res.ExecReports = execReports
 		}
 
 		if optionExecReports != 0 && execReports >= optionExecReports {
@@ -297,10 +414,14 @@ LOOP:
 		}
 	}
 
+	if optionPositions {
+		return app.Positions.Write(os.Stdout, options.Output)
+	}
+
 	return nil
 }
 
-func buildMessage(session config.Session) (quickfix.Messagable, error) {
+func buildMessage(session config.Session, appDict *datadictionary.DataDictionary) (quickfix.Messagable, error) {
 	eside, err := dict.OrderSideStringToEnum(optionOrderSide)
 	if err != nil {
 		return nil, err
@@ -345,11 +466,11 @@ func buildMessage(session config.Session) (quickfix.Messagable, error) {
 	}
 
 	message.Body.Set(field.NewSymbol(optionOrderSymbol))
-	message.Body.Set(field.NewOrderQty(decimal.NewFromInt(optionOrderQuantity), 2))
+	message.Body.Set(field.NewOrderQty(utils.QuickFixDecimal(appDict, tag.OrderQty, optionOrderSymbol, decimal.NewFromInt(optionOrderQuantity))))
 	message.Body.Set(field.NewTimeInForce(eExpiry))
 
 	if etype != enum.OrdType_MARKET {
-		message.Body.Set(field.NewPrice(decimal.NewFromFloat(optionOrderPrice), 2))
+		message.Body.Set(field.NewPrice(utils.QuickFixDecimal(appDict, tag.Price, optionOrderSymbol, decimal.NewFromFloat(optionOrderPrice))))
 	}
 
 	if len(optionOrderOrigination) > 0 {
@@ -359,7 +480,7 @@ func buildMessage(session config.Session) (quickfix.Messagable, error) {
 	return message, nil
 }
 
-func buildCancelReplaceMessage(session config.Session, executionReport *quickfix.Message) (quickfix.Messagable, error) {
+func buildCancelReplaceMessage(session config.Session, appDict *datadictionary.DataDictionary, executionReport *quickfix.Message) (quickfix.Messagable, error) {
 	if executionReport == nil {
 		return nil, fmt.Errorf("missing execution report")
 	}
@@ -417,8 +538,8 @@ func buildCancelReplaceMessage(session config.Session, executionReport *quickfix
 			message.Body.Set(ordType)
 			message.Body.Set(field.NewTimeInForce(eExpiry))
 			message.Body.Set(field.NewSymbol(optionOrderSymbol))
-			message.Body.Set(field.NewOrderQty(totalQty.Value().Add(decimal.NewFromFloat(optionUpdateOrderQuantity)), 2))
-			message.Body.Set(field.NewPrice(price.Value().Add(decimal.NewFromFloat(optionUpdateOrderPrice)), 2))
+			message.Body.Set(field.NewOrderQty(utils.QuickFixDecimal(appDict, tag.OrderQty, optionOrderSymbol, totalQty.Value().Add(decimal.NewFromFloat(optionUpdateOrderQuantity)))))
+			message.Body.Set(field.NewPrice(utils.QuickFixDecimal(appDict, tag.Price, optionOrderSymbol, price.Value().Add(decimal.NewFromFloat(optionUpdateOrderPrice)))))
 			partyIdOptions.EnrichMessageBody(&message.Body, session)
 
 		default:
@@ -455,9 +576,9 @@ func processResponse(app *application.NewOrder, msg *quickfix.Message) error {
 	err := msg.Header.GetField(tag.MsgType, &msgType)
 	if err != nil {
 		return err
-	} else if msgType.Value() == enum.MsgType_REJECT ||
-		msgType.Value() == enum.MsgType_BUSINESS_MESSAGE_REJECT ||
-		msgType.Value() == enum.MsgType_ORDER_CANCEL_REJECT {
+	} else if err := utils.QuickFixRejectError(msg, errors.FixOrderRejected); err != nil {
+		return err
+	} else if msgType.Value() == enum.MsgType_ORDER_CANCEL_REJECT {
 		return makeError(errors.FixOrderRejected)
 	} else if msgType.Value() != enum.MsgType_EXECUTION_REPORT {
 		return quickfix.InvalidMessageType()
@@ -469,7 +590,7 @@ func processResponse(app *application.NewOrder, msg *quickfix.Message) error {
 		return err
 	}
 
-	app.WriteMessageBodyAsTable(os.Stdout, msg)
+	app.WriteMessage(os.Stdout, msg)
 	switch ordStatus.Value() {
 	case enum.OrdStatus_NEW:
 		break
@@ -507,28 +628,3 @@ func processResponse(app *application.NewOrder, msg *quickfix.Message) error {
 
 	return nil
 }
-
-func isFinalStatus(msg *quickfix.Message) bool {
-	ordStatus := field.OrdStatusField{}
-
-	if err := msg.Body.GetField(tag.OrdStatus, &ordStatus); err != nil {
-		return false
-	}
-
-	switch ordStatus.Value() {
-	case enum.OrdStatus_FILLED:
-		return true
-	case enum.OrdStatus_DONE_FOR_DAY:
-		return true
-	case enum.OrdStatus_STOPPED:
-		return true
-	case enum.OrdStatus_EXPIRED:
-		return true
-	case enum.OrdStatus_CANCELED:
-		return true
-	case enum.OrdStatus_REJECTED:
-		return true
-	default:
-		return false
-	}
-}
@@ -0,0 +1,467 @@
+package neworders
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/olekukonko/tablewriter"
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+	"golang.org/x/time/rate"
+
+	"github.com/quickfixgo/enum"
+	"github.com/quickfixgo/field"
+	"github.com/quickfixgo/fixt11"
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/quickfix/datadictionary"
+	"github.com/quickfixgo/tag"
+
+	"sylr.dev/fix/config"
+	"sylr.dev/fix/pkg/dict"
+	"sylr.dev/fix/pkg/errors"
+	"sylr.dev/fix/pkg/initiator"
+	"sylr.dev/fix/pkg/initiator/application"
+	"sylr.dev/fix/pkg/orderbatch"
+	"sylr.dev/fix/pkg/risk"
+	"sylr.dev/fix/pkg/utils"
+)
+
+var (
+	optionFile               string
+	optionRate               float64
+	optionConcurrency        int
+	optionExecReportsTimeout time.Duration
+
+	orders []orderbatch.Order
+)
+
+var NewOrdersCmd = &cobra.Command{
+	Use:   "orders",
+	Short: "New single orders read from a file",
+	Long: "Send the orders of a CSV or JSON file (see --file) after initiating a session with a FIX acceptor, " +
+		"at most --rate orders per second and --concurrency orders awaiting their first execution report at " +
+		"the same time, then print whether each of them was accepted or rejected.",
+	Example:           "  fix new orders --file orders.csv --rate 100 --concurrency 10",
+	Args:              cobra.ExactArgs(0),
+	ValidArgsFunction: cobra.NoFileCompletions,
+	PersistentPreRunE: utils.MakePersistentPreRunE(Validate),
+	RunE:              Execute,
+}
+
+func init() {
+	NewOrdersCmd.Flags().StringVar(&optionFile, "file", "", "File of the orders, CSV if its extension is .csv and JSON otherwise")
+	NewOrdersCmd.Flags().Float64Var(&optionRate, "rate", 0, "Orders sent per second (0 for no limit)")
+	NewOrdersCmd.Flags().IntVar(&optionConcurrency, "concurrency", 1, "Number of orders awaiting their execution report at the same time")
+	NewOrdersCmd.Flags().DurationVar(&optionExecReportsTimeout, "exec-reports-timeout", 5*time.Second, "Give up on an order if its execution report is not received within timeout")
+
+	NewOrdersCmd.MarkFlagRequired("file")
+
+	NewOrdersCmd.RegisterFlagCompletionFunc("file", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"csv", "json", "jsonl"}, cobra.ShellCompDirectiveFilterFileExt
+	})
+	NewOrdersCmd.RegisterFlagCompletionFunc("rate", cobra.NoFileCompletions)
+	NewOrdersCmd.RegisterFlagCompletionFunc("concurrency", cobra.NoFileCompletions)
+}
+
+func Validate(cmd *cobra.Command, args []string) error {
+	if optionRate < 0 {
+		return fmt.Errorf("%w: --rate can not be negative", errors.Options)
+	}
+	if optionConcurrency < 1 {
+		return fmt.Errorf("%w: --concurrency must be greater than 0", errors.Options)
+	}
+	if optionExecReportsTimeout <= 0 {
+		return fmt.Errorf("%w: --exec-reports-timeout must be greater than 0", errors.Options)
+	}
+
+	var err error
+	if orders, err = orderbatch.Load(optionFile); err != nil {
+		return err
+	}
+	if len(orders) == 0 {
+		return fmt.Errorf("%w: no order found in %s", errors.Options, optionFile)
+	}
+
+	// The orders are checked before any of them is sent
+	for i := range orders {
+		if err := validateOrder(&orders[i]); err != nil {
+			return fmt.Errorf("%w in order %d", err, i+1)
+		}
+	}
+
+	return nil
+}
+
+func validateOrder(order *orderbatch.Order) error {
+	if _, err := dict.OrderSideStringToEnum(order.Side); err != nil {
+		return err
+	}
+
+	etype, err := dict.OrderTypeStringToEnum(order.Type)
+	if err != nil {
+		return err
+	}
+
+	if len(order.Expiry) == 0 {
+		order.Expiry = "day"
+	}
+	if _, err := dict.OrderTimeInForceStringToEnum(order.Expiry); err != nil {
+		return err
+	}
+
+	if etype == enum.OrdType_MARKET && !order.Price.IsZero() {
+		return errors.OptionsInvalidMarketPrice
+	} else if etype != enum.OrdType_MARKET && !order.Price.IsPositive() {
+		return errors.OptionsNoPriceGiven
+	}
+
+	for _, party := range order.Parties {
+		if _, ok := dict.PartyIDSources[strings.ToUpper(party.Source)]; !ok {
+			return fmt.Errorf("%w: `%s`", errors.OptionOrderIDSourceUnknown, party.Source)
+		}
+		if _, ok := dict.PartyRoles[strings.ToUpper(party.Role)]; !ok {
+			return fmt.Errorf("%w: `%s`", errors.OptionOrderRoleUnknown, party.Role)
+		}
+	}
+
+	if len(order.ClOrdID) == 0 {
+		order.ClOrdID = uuid.NewString()
+	}
+
+	return nil
+}
+
+const (
+	statusAccepted = "accepted"
+	statusRejected = "rejected"
+	statusRefused  = "refused"
+	statusTimeout  = "timeout"
+	statusNotSent  = "not sent"
+)
+
+// result is the outcome of an order of the file.
+type result struct {
+	ClOrdID string `json:"clOrdID"`
+	Symbol  string `json:"symbol"`
+	Side    string `json:"side"`
+	Status  string `json:"status"`
+	OrderID string `json:"orderID,omitempty"`
+	Text    string `json:"text,omitempty"`
+}
+
+// pending is an order awaiting its execution report.
+type pending struct {
+	index  int
+	seqNum int
+	sent   time.Time
+}
+
+func Execute(cmd *cobra.Command, args []string) error {
+	options := config.GetOptions()
+	logger := config.GetLogger()
+
+	context, err := config.GetCurrentContext()
+	if err != nil {
+		return err
+	}
+
+	sessions, err := context.GetSessions()
+	if err != nil {
+		return err
+	}
+
+	session := sessions[0]
+	logger = session.GetLogger()
+	initiatorConfig, err := context.GetInitiator()
+	if err != nil {
+		return err
+	}
+
+	transportDict, appDict, err := session.GetFIXDictionaries()
+	if err != nil {
+		return err
+	}
+
+	settings, err := context.ToQuickFixInitiatorSettings()
+	if err != nil {
+		return err
+	}
+
+	app := application.NewInitiator()
+	app.Logger = logger
+	app.Settings = settings
+	app.TransportDataDictionary = transportDict
+	app.AppDataDictionary = appDict
+	app.OutputFormat = options.Output
+
+	var quickfixLogger *zerolog.Logger
+	if options.QuickFixLogging {
+		quickfixLogger = logger
+	}
+
+	// Choose right timeout cli option > config > default value (5s)
+	var timeout time.Duration
+	if options.Timeout != time.Duration(0) {
+		timeout = options.Timeout
+	} else if initiatorConfig.SocketTimeout != time.Duration(0) {
+		timeout = initiatorConfig.SocketTimeout
+	} else {
+		timeout = 5 * time.Second
+	}
+
+	init, sessionId, err := initiator.Connect(cmd.Context(), app, settings, quickfixLogger, app.Connected, timeout, initiatorConfig.Reconnect)
+	if err != nil {
+		app.Stop()
+		return err
+	}
+
+	defer func() {
+		app.Stop()
+		init.Stop()
+	}()
+
+	// Messages sent are not needed
+	go func() {
+		for range app.ToAppMessages {
+		}
+	}()
+
+	ctx := cmd.Context()
+
+	// The risk limits may need the market price of the symbols, requested
+	// once per symbol
+	checker := risk.SessionChecker(sessionId)
+	requested := make(map[string]struct{})
+	for _, order := range orders {
+		priced := strings.ToLower(order.Type) != "market"
+		if _, ok := requested[order.Symbol]; ok || !checker.NeedsReferencePrice(order.Symbol, priced) {
+			continue
+		}
+		requested[order.Symbol] = struct{}{}
+		if err := checker.RequestReferencePrice(ctx, *session, sessionId, order.Symbol, priced, timeout); err != nil {
+			return err
+		}
+	}
+
+	results := make([]result, len(orders))
+	for i, order := range orders {
+		results[i] = result{ClOrdID: order.ClOrdID, Symbol: order.Symbol, Side: order.Side, Status: statusNotSent}
+	}
+
+	limit := rate.Inf
+	if optionRate > 0 {
+		limit = rate.Limit(optionRate)
+	}
+	limiter := rate.NewLimiter(limit, 1)
+
+	inflight := make(map[string]*pending)
+	seqNums := make(map[int]string)
+	next := 0
+
+	// ready fires when the next order can be sent, nil when the concurrency
+	// is reached or when all the orders were sent
+	var ready <-chan time.Time
+	schedule := func() {
+		if ready == nil && next < len(orders) && len(inflight) < optionConcurrency {
+			ready = time.After(limiter.Reserve().Delay())
+		}
+	}
+
+	send := func() error {
+		p := &pending{index: next}
+		order := &orders[next]
+		next++
+
+		message, err := buildMessage(*session, appDict, order)
+		if err != nil {
+			return err
+		}
+
+		inflight[order.ClOrdID] = p
+		p.sent = time.Now()
+		if err := quickfix.SendToTarget(message, sessionId); errors.Is(err, errors.RiskLimitExceeded) {
+			// The order is reported as refused, the next ones being sent
+			delete(inflight, order.ClOrdID)
+			results[p.index].Status = statusRefused
+			results[p.index].Text = err.Error()
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		// Session level and business rejects may only refer to the sequence
+		// number of the message
+		if seqNum, err := message.ToMessage().Header.GetInt(tag.MsgSeqNum); err == nil {
+			p.seqNum = seqNum
+			seqNums[seqNum] = order.ClOrdID
+		}
+
+		return nil
+	}
+
+	done := func(id string, p *pending) {
+		delete(inflight, id)
+		delete(seqNums, p.seqNum)
+		schedule()
+	}
+
+	logger.Debug().Msgf("Sending %d orders with a concurrency of %d", len(orders), optionConcurrency)
+
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	start := time.Now()
+	schedule()
+
+LOOP:
+	for next < len(orders) || len(inflight) > 0 {
+		select {
+		case <-ctx.Done():
+			logger.Debug().Msg("Interrupted")
+			break LOOP
+
+		case <-ready:
+			ready = nil
+			if err := send(); err != nil {
+				return err
+			}
+			schedule()
+
+		case now := <-ticker.C:
+			for id, p := range inflight {
+				if now.Sub(p.sent) < optionExecReportsTimeout {
+					continue
+				}
+				results[p.index].Status = statusTimeout
+				done(id, p)
+			}
+
+		case msg, ok := <-app.FromAppMessages:
+			if !ok {
+				return errors.FixLogout
+			}
+
+			id, rejected := utils.QuickFixResponseClOrdID(msg, seqNums)
+			p, ok := inflight[id]
+			if !ok {
+				continue
+			}
+
+			r := &results[p.index]
+			r.Status = statusAccepted
+			if rejected {
+				r.Status = statusRejected
+			}
+			r.OrderID, _ = msg.Body.GetString(tag.OrderID)
+			r.Text, _ = msg.Body.GetString(tag.Text)
+
+			done(id, p)
+		}
+	}
+
+	elapsed := time.Since(start)
+
+	counts := make(map[string]int)
+	for _, r := range results {
+		counts[r.Status]++
+	}
+
+	switch options.Output {
+	case utils.OutputFormatJSON:
+		if err := json.NewEncoder(os.Stdout).Encode(results); err != nil {
+			return err
+		}
+
+	default:
+		writeResults(results)
+		fmt.Printf("\n%d accepted, %d rejected, %d refused, %d timed out, %d not sent in %s\n",
+			counts[statusAccepted], counts[statusRejected], counts[statusRefused], counts[statusTimeout], counts[statusNotSent], elapsed.Round(time.Millisecond))
+	}
+
+	if counts[statusRejected] > 0 {
+		return fmt.Errorf("%w: %d of %d orders", errors.FixOrderRejected, counts[statusRejected], len(orders))
+	}
+	if counts[statusRefused] > 0 {
+		return fmt.Errorf("%w: %d of %d orders refused", errors.RiskLimitExceeded, counts[statusRefused], len(orders))
+	}
+	if counts[statusTimeout] > 0 {
+		return fmt.Errorf("%w: %d execution report(s) not received", errors.ResponseTimeout, counts[statusTimeout])
+	}
+
+	return nil
+}
+
+func writeResults(results []result) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"CLORDID", "SYMBOL", "SIDE", "STATUS", "ORDERID", "TEXT"})
+	table.SetBorders(tablewriter.Border{Left: false, Top: false, Right: false, Bottom: true})
+	table.SetColumnSeparator(" ")
+	table.SetCenterSeparator("-")
+	table.SetAutoWrapText(false)
+
+	for _, r := range results {
+		table.Append([]string{r.ClOrdID, r.Symbol, r.Side, r.Status, r.OrderID, r.Text})
+	}
+
+	table.Render()
+}
+
+func buildMessage(session config.Session, appDict *datadictionary.DataDictionary, order *orderbatch.Order) (quickfix.Messagable, error) {
+	eside, err := dict.OrderSideStringToEnum(order.Side)
+	if err != nil {
+		return nil, err
+	}
+
+	etype, err := dict.OrderTypeStringToEnum(order.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	eExpiry, err := dict.OrderTimeInForceStringToEnum(order.Expiry)
+	if err != nil {
+		return nil, err
+	}
+
+	if session.BeginString != quickfix.BeginStringFIXT11 || session.DefaultApplVerID != "FIX.5.0SP2" {
+		return nil, errors.FixVersionNotImplemented
+	}
+
+	message := quickfix.NewMessage()
+	header := fixt11.NewHeader(&message.Header)
+	header.Set(field.NewMsgType(enum.MsgType_ORDER_SINGLE))
+	message.Body.Set(field.NewClOrdID(order.ClOrdID))
+	message.Body.Set(field.NewSide(eside))
+	message.Body.Set(field.NewTransactTime(time.Now()))
+	message.Body.Set(field.NewOrdType(etype))
+	message.Body.Set(field.NewSymbol(order.Symbol))
+	message.Body.Set(field.NewOrderQty(utils.QuickFixDecimal(appDict, tag.OrderQty, order.Symbol, order.Quantity)))
+	message.Body.Set(field.NewTimeInForce(eExpiry))
+
+	if etype != enum.OrdType_MARKET {
+		message.Body.Set(field.NewPrice(utils.QuickFixDecimal(appDict, tag.Price, order.Symbol, order.Price)))
+	}
+
+	if len(order.Parties) > 0 {
+		parties := quickfix.NewRepeatingGroup(
+			tag.NoPartyIDs,
+			quickfix.GroupTemplate{
+				quickfix.GroupElement(tag.PartyID),
+				quickfix.GroupElement(tag.PartyIDSource),
+				quickfix.GroupElement(tag.PartyRole),
+			},
+		)
+		for _, p := range order.Parties {
+			party := parties.Add()
+			party.Set(field.NewPartyID(p.ID))
+			party.Set(field.NewPartyIDSource(dict.PartyIDSources[strings.ToUpper(p.Source)]))
+			party.Set(field.NewPartyRole(dict.PartyRoles[strings.ToUpper(p.Role)]))
+		}
+		message.Body.SetGroup(parties)
+	}
+
+	return message, nil
+}
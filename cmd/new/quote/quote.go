@@ -3,9 +3,7 @@ package newquote
 import (
 	"fmt"
 	"os"
-	"os/signal"
 	"strings"
-	"syscall"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,6 +11,7 @@ import (
 	"github.com/quickfixgo/field"
 	"github.com/quickfixgo/fixt11"
 	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/quickfix/datadictionary"
 	"github.com/quickfixgo/tag"
 	"github.com/rs/zerolog"
 	"github.com/shopspring/decimal"
@@ -64,6 +63,10 @@ func init() {
 	NewQuoteCmd.Flags().Int64SliceVar(&optionSellQuantities, "sell-quantities", []int64{}, "Quote sell quantities")
 	NewQuoteCmd.Flags().Float64SliceVar(&optionBuyPrices, "buy-prices", []float64{}, "Quote buy prices")
 	NewQuoteCmd.Flags().Float64SliceVar(&optionSellPrices, "sell-prices", []float64{}, "Quote sell prices")
+	NewQuoteCmd.Flags().Int64SliceVar(&optionBuyQuantities, "bid-size", []int64{}, "Alias of --buy-quantities")
+	NewQuoteCmd.Flags().Int64SliceVar(&optionSellQuantities, "offer-size", []int64{}, "Alias of --sell-quantities")
+	NewQuoteCmd.Flags().Float64SliceVar(&optionBuyPrices, "bid-px", []float64{}, "Alias of --buy-prices")
+	NewQuoteCmd.Flags().Float64SliceVar(&optionSellPrices, "offer-px", []float64{}, "Alias of --sell-prices")
 	NewQuoteCmd.Flags().BoolVar(&optionAutoPriceUpdate, "auto-price-update", false, "Generate price oscillation to send an infinity of quote updates")
 	NewQuoteCmd.Flags().IntVar(&optionNbPriceUpdates, "nb-price-updates", -1, "Number of quote updates")
 	NewQuoteCmd.Flags().StringVar(&optionOrderOrigination, "origination", "", "Order origination")
@@ -82,7 +85,7 @@ func init() {
 
 	NewQuoteCmd.MarkFlagRequired("symbol")
 
-	NewQuoteCmd.RegisterFlagCompletionFunc("symbol", cobra.NoFileCompletions)
+	NewQuoteCmd.RegisterFlagCompletionFunc("symbol", complete.Symbol)
 	NewQuoteCmd.RegisterFlagCompletionFunc("origination", complete.OrderOriginationRole)
 }
 
@@ -125,6 +128,7 @@ func Execute(cmd *cobra.Command, args []string) error {
 	}
 
 	session := sessions[0]
+	logger = session.GetLogger()
 	initiatorConfig, err := context.GetInitiator()
 	if err != nil {
 		return err
@@ -145,6 +149,7 @@ func Execute(cmd *cobra.Command, args []string) error {
 	app.Settings = settings
 	app.TransportDataDictionary = transportDict
 	app.AppDataDictionary = appDict
+	app.OutputFormat = options.Output
 
 	var quickfixLogger *zerolog.Logger
 	if options.QuickFixLogging {
@@ -161,13 +166,9 @@ func Execute(cmd *cobra.Command, args []string) error {
 		timeout = 5 * time.Second
 	}
 
-	init, err := initiator.Initiate(app, settings, quickfixLogger)
+	init, sessionId, err := initiator.Connect(cmd.Context(), app, settings, quickfixLogger, app.Connected, timeout, initiatorConfig.Reconnect)
 	if err != nil {
-		return err
-	}
-
-	// Start session
-	if err = init.Start(); err != nil {
+		app.Stop()
 		return err
 	}
 
@@ -176,20 +177,8 @@ func Execute(cmd *cobra.Command, args []string) error {
 		init.Stop()
 	}()
 
-	// Wait for session connection
-	var sessionId quickfix.SessionID
-	var ok bool
-	select {
-	case <-time.After(timeout):
-		return errors.ConnectionTimeout
-	case sessionId, ok = <-app.Connected:
-		if !ok {
-			return errors.FixLogout
-		}
-	}
-
 	// Prepare quote
-	quote, err := buildMessage(*session)
+	quote, err := buildMessage(*session, appDict)
 	if err != nil {
 		return err
 	}
@@ -200,8 +189,7 @@ func Execute(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	interrupt := make(chan os.Signal, 1)
-	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	ctx := cmd.Context()
 
 	execReports := 0
 	var waitTimeout <-chan time.Time
@@ -219,11 +207,14 @@ func Execute(cmd *cobra.Command, args []string) error {
 LOOP:
 	for {
 		select {
-		case signal := <-interrupt:
-			logger.Debug().Msgf("Received signal: %s", signal)
+		case <-ctx.Done():
+			logger.Debug().Msg("Interrupted")
 			break LOOP
 
 		case <-waitTimeout:
+			if execReports == 0 {
+				return fmt.Errorf("%w: expecting execution reports", errors.ResponseTimeout)
+			}
 			logger.Warn().Msgf("Timeout while expecting execution reports (%d/%d)", execReports, optionExecReports)
 			break LOOP
 
@@ -264,7 +255,7 @@ LOOP:
 			}
 
 			// Prepare quote
-			quoteMsg, err := buildMessage(*session)
+			quoteMsg, err := buildMessage(*session, appDict)
 			if err != nil {
 				return err
 			}
@@ -312,7 +303,7 @@ LOOP:
 	return nil
 }
 
-func buildMessage(session config.Session) (quickfix.Messagable, error) {
+func buildMessage(session config.Session, appDict *datadictionary.DataDictionary) (quickfix.Messagable, error) {
 	// Message
 	message := quickfix.NewMessage()
 	header := fixt11.NewHeader(&message.Header)
@@ -340,23 +331,23 @@ func buildMessage(session config.Session) (quickfix.Messagable, error) {
 
 	message.Body.Set(field.NewSymbol(optionSymbol))
 	if optionAutoPriceUpdate {
-		priceAdjustment := 0.01 * float64(priceIteration%100)
+		priceAdjustment := decimal.New(int64(priceIteration%100), -2)
 		if len(optionBuyPrices) == 1 {
-			message.Body.Set(field.NewBidSize(decimal.NewFromInt(optionBuyQuantities[0]), 2))
-			message.Body.Set(field.NewBidPx(decimal.NewFromFloat(optionBuyPrices[0]-priceAdjustment), 2))
+			message.Body.Set(field.NewBidSize(utils.QuickFixDecimal(appDict, tag.BidSize, optionSymbol, decimal.NewFromInt(optionBuyQuantities[0]))))
+			message.Body.Set(field.NewBidPx(utils.QuickFixDecimal(appDict, tag.BidPx, optionSymbol, decimal.NewFromFloat(optionBuyPrices[0]).Sub(priceAdjustment))))
 		}
 		if len(optionSellPrices) == 1 {
-			message.Body.Set(field.NewOfferSize(decimal.NewFromInt(optionSellQuantities[0]), 2))
-			message.Body.Set(field.NewOfferPx(decimal.NewFromFloat(optionSellPrices[0]+priceAdjustment), 2))
+			message.Body.Set(field.NewOfferSize(utils.QuickFixDecimal(appDict, tag.OfferSize, optionSymbol, decimal.NewFromInt(optionSellQuantities[0]))))
+			message.Body.Set(field.NewOfferPx(utils.QuickFixDecimal(appDict, tag.OfferPx, optionSymbol, decimal.NewFromFloat(optionSellPrices[0]).Add(priceAdjustment))))
 		}
 	} else {
 		if priceIteration < len(optionBuyPrices) {
-			message.Body.Set(field.NewBidSize(decimal.NewFromInt(optionBuyQuantities[priceIteration]), 2))
-			message.Body.Set(field.NewBidPx(decimal.NewFromFloat(optionBuyPrices[priceIteration]), 2))
+			message.Body.Set(field.NewBidSize(utils.QuickFixDecimal(appDict, tag.BidSize, optionSymbol, decimal.NewFromInt(optionBuyQuantities[priceIteration]))))
+			message.Body.Set(field.NewBidPx(utils.QuickFixDecimal(appDict, tag.BidPx, optionSymbol, decimal.NewFromFloat(optionBuyPrices[priceIteration]))))
 		}
 		if priceIteration < len(optionSellPrices) {
-			message.Body.Set(field.NewOfferSize(decimal.NewFromInt(optionSellQuantities[priceIteration]), 2))
-			message.Body.Set(field.NewOfferPx(decimal.NewFromFloat(optionSellPrices[priceIteration]), 2))
+			message.Body.Set(field.NewOfferSize(utils.QuickFixDecimal(appDict, tag.OfferSize, optionSymbol, decimal.NewFromInt(optionSellQuantities[priceIteration]))))
+			message.Body.Set(field.NewOfferPx(utils.QuickFixDecimal(appDict, tag.OfferPx, optionSymbol, decimal.NewFromFloat(optionSellPrices[priceIteration]))))
 		}
 	}
 
@@ -423,10 +414,10 @@ func processResponse(app *application.NewOrder, msg *quickfix.Message) error {
 	err := msg.Header.GetField(tag.MsgType, &msgType)
 	if err != nil {
 		return err
-	} else if msgType.Value() == enum.MsgType_REJECT || msgType.Value() == enum.MsgType_BUSINESS_MESSAGE_REJECT {
-		return makeError(errors.FixOrderRejected)
+	} else if err := utils.QuickFixRejectError(msg, errors.FixOrderRejected); err != nil {
+		return err
 	} else if msgType.Value() == enum.MsgType_QUOTE_STATUS_REPORT {
-		app.WriteMessageBodyAsTable(os.Stdout, msg)
+		app.WriteMessage(os.Stdout, msg)
 		quoteStatus := field.QuoteStatusField{}
 		err = msg.Body.GetField(tag.QuoteStatus, &quoteStatus)
 		if err != nil {
@@ -447,7 +438,7 @@ func processResponse(app *application.NewOrder, msg *quickfix.Message) error {
 		return err
 	}
 
-	app.WriteMessageBodyAsTable(os.Stdout, msg)
+	app.WriteMessage(os.Stdout, msg)
 	return nil
 }
 
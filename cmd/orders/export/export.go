@@ -0,0 +1,136 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"sylr.dev/fix/pkg/errors"
+	"sylr.dev/fix/pkg/ordertracker"
+	"sylr.dev/fix/pkg/utils"
+)
+
+const (
+	formatCSV     = "csv"
+	formatParquet = "parquet"
+)
+
+var (
+	optionFile    string
+	optionFormat  string
+	optionColumns []string
+	optionFrom    string
+	optionTo      string
+	optionSession string
+	optionSymbol  string
+)
+
+var OrdersExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export the executions recorded",
+	Long: "Export the fills recorded by the order tracker, oldest first, as CSV or Parquet so that they can be loaded " +
+		"into spreadsheets or reconciliation tools. Quantities and prices are doubles in Parquet files.",
+	Example: "  fix orders export --from 2024-03-01 --to 2024-04-01 --file fills.csv\n" +
+		"  fix orders export --from 2024-03-01 --columns time,symbol,side,lastQty,lastPx --file fills.parquet",
+	Args:              cobra.ExactArgs(0),
+	ValidArgsFunction: cobra.NoFileCompletions,
+	PreRunE:           Validate,
+	RunE:              Execute,
+}
+
+func init() {
+	OrdersExportCmd.Flags().StringVar(&optionFile, "file", "-", "File to write the executions to (- for stdout)")
+	OrdersExportCmd.Flags().StringVar(&optionFormat, "format", "", "Export format (csv, parquet), guessed from the file extension by default")
+	OrdersExportCmd.Flags().StringSliceVar(&optionColumns, "columns", nil, fmt.Sprintf("Columns to export (%s)", strings.Join(ordertracker.ExportColumns(), ", ")))
+	OrdersExportCmd.Flags().StringVar(&optionFrom, "from", "", "Only export the executions received from this date or time (2006-01-02 or RFC 3339)")
+	OrdersExportCmd.Flags().StringVar(&optionTo, "to", "", "Only export the executions received before this date or time (2006-01-02 or RFC 3339)")
+	OrdersExportCmd.Flags().StringVar(&optionSession, "session", "", "Only export the executions of this SessionID (e.g. FIX.4.4:SENDER->TARGET)")
+	OrdersExportCmd.Flags().StringVar(&optionSymbol, "symbol", "", "Only export the executions of this symbol")
+
+	OrdersExportCmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions([]string{formatCSV, formatParquet}, cobra.ShellCompDirectiveNoFileComp))
+	OrdersExportCmd.RegisterFlagCompletionFunc("columns", cobra.FixedCompletions(ordertracker.ExportColumns(), cobra.ShellCompDirectiveNoFileComp))
+}
+
+func Validate(cmd *cobra.Command, args []string) error {
+	if err := ordertracker.ValidateExportColumns(optionColumns); err != nil {
+		return fmt.Errorf("%w: --columns: %s", errors.Options, err)
+	}
+
+	if len(optionFormat) == 0 {
+		optionFormat = formatCSV
+		if strings.EqualFold(filepath.Ext(optionFile), "."+formatParquet) {
+			optionFormat = formatParquet
+		}
+	}
+
+	switch optionFormat {
+	case formatCSV:
+	case formatParquet:
+		if optionFile == "-" && term.IsTerminal(int(os.Stdout.Fd())) {
+			return fmt.Errorf("%w: not writing a Parquet file to a terminal, use --file", errors.Options)
+		}
+	default:
+		return fmt.Errorf("%w: unknown export format `%s`", errors.Options, optionFormat)
+	}
+
+	return nil
+}
+
+func Execute(cmd *cobra.Command, args []string) error {
+	listOptions := ordertracker.ListOptions{
+		Session: optionSession,
+		Symbol:  optionSymbol,
+	}
+
+	var err error
+	if len(optionFrom) > 0 {
+		if listOptions.Since, err = utils.ParseTime(optionFrom); err != nil {
+			return fmt.Errorf("%w: --from: %s", errors.Options, err)
+		}
+	}
+	if len(optionTo) > 0 {
+		if listOptions.Until, err = utils.ParseTime(optionTo); err != nil {
+			return fmt.Errorf("%w: --to: %s", errors.Options, err)
+		}
+	}
+
+	tracker, err := ordertracker.OpenSelected()
+	if err != nil {
+		return err
+	}
+	defer tracker.Close()
+
+	executions, err := tracker.Executions(listOptions)
+	if err != nil {
+		return err
+	}
+
+	// Oldest first
+	for i, j := 0, len(executions)-1; i < j; i, j = i+1, j-1 {
+		executions[i], executions[j] = executions[j], executions[i]
+	}
+
+	var out io.Writer = os.Stdout
+	if optionFile != "-" {
+		file, err := os.Create(optionFile)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+
+	switch optionFormat {
+	case formatParquet:
+		err = ordertracker.WriteExecutionsParquet(out, executions, optionColumns)
+	default:
+		err = ordertracker.WriteExecutionsCSV(out, executions, optionColumns)
+	}
+
+	return err
+}
@@ -0,0 +1,98 @@
+package list
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+
+	"sylr.dev/fix/config"
+	"sylr.dev/fix/pkg/initiator/application"
+	"sylr.dev/fix/pkg/ordertracker"
+	"sylr.dev/fix/pkg/utils"
+)
+
+var (
+	optionSession string
+	optionSymbol  string
+	optionSince   time.Duration
+	optionLimit   int
+)
+
+var OrdersListCmd = &cobra.Command{
+	Use:               "list",
+	Short:             "List the orders recorded",
+	Long:              "List the orders recorded by the order tracker, the most recent first.",
+	Example:           "  fix orders list --since 24h --symbol EURUSD",
+	Args:              cobra.ExactArgs(0),
+	ValidArgsFunction: cobra.NoFileCompletions,
+	RunE:              Execute,
+}
+
+func init() {
+	OrdersListCmd.Flags().StringVar(&optionSession, "session", "", "Only list the orders of this SessionID (e.g. FIX.4.4:SENDER->TARGET)")
+	OrdersListCmd.Flags().StringVar(&optionSymbol, "symbol", "", "Only list the orders of this symbol")
+	OrdersListCmd.Flags().DurationVar(&optionSince, "since", 0, "Only list the orders created during this last duration")
+	OrdersListCmd.Flags().IntVar(&optionLimit, "limit", 50, "Maximum number of orders to list (0 for all)")
+}
+
+func Execute(cmd *cobra.Command, args []string) error {
+	options := config.GetOptions()
+
+	tracker, err := ordertracker.OpenSelected()
+	if err != nil {
+		return err
+	}
+	defer tracker.Close()
+
+	listOptions := ordertracker.ListOptions{
+		Session: optionSession,
+		Symbol:  optionSymbol,
+		Limit:   optionLimit,
+	}
+	if optionSince > 0 {
+		listOptions.Since = time.Now().Add(-optionSince)
+	}
+
+	orders, err := tracker.List(listOptions)
+	if err != nil {
+		return err
+	}
+
+	if options.Output == utils.OutputFormatJSON {
+		if orders == nil {
+			orders = []*ordertracker.Order{}
+		}
+		return json.NewEncoder(os.Stdout).Encode(orders)
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"CREATED", "CLORDID", "ORDERID", "SYMBOL", "SIDE", "TYPE", "PRICE", "QTY", "CUMQTY", "AVGPX", "STATE", "SESSION"})
+	table.SetBorders(tablewriter.Border{Left: false, Top: false, Right: false, Bottom: true})
+	table.SetColumnSeparator(" ")
+	table.SetCenterSeparator("-")
+	table.SetAutoWrapText(false)
+
+	for _, order := range orders {
+		table.Append([]string{
+			utils.FormatTime(order.CreatedAt),
+			order.ClOrdID,
+			order.OrderID,
+			order.Symbol,
+			order.SideName(),
+			order.TypeName(),
+			order.Price,
+			order.OrderQty,
+			order.CumQty,
+			order.AvgPx,
+			string(application.TrackedOrderState(order)),
+			order.Session,
+		})
+	}
+
+	table.Render()
+
+	return nil
+}
@@ -0,0 +1,25 @@
+package orders
+
+import (
+	"github.com/spf13/cobra"
+
+	"sylr.dev/fix/cmd/orders/export"
+	"sylr.dev/fix/cmd/orders/list"
+	"sylr.dev/fix/cmd/orders/show"
+	"sylr.dev/fix/pkg/ordertracker"
+)
+
+var OrdersCmd = &cobra.Command{
+	Use:   "orders",
+	Short: "Inspect the orders recorded by the order tracker",
+	Long: "Inspect the orders sent and the execution reports received on the sessions having an OrderTrackerPath,\n" +
+		"from any shell and once the command which sent them has exited.",
+}
+
+func init() {
+	ordertracker.AddPersistentFlags(OrdersCmd)
+
+	OrdersCmd.AddCommand(export.OrdersExportCmd)
+	OrdersCmd.AddCommand(list.OrdersListCmd)
+	OrdersCmd.AddCommand(show.OrdersShowCmd)
+}
@@ -0,0 +1,99 @@
+package show
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+
+	"sylr.dev/fix/config"
+	"sylr.dev/fix/pkg/initiator/application"
+	"sylr.dev/fix/pkg/ordertracker"
+	"sylr.dev/fix/pkg/utils"
+)
+
+var OrdersShowCmd = &cobra.Command{
+	Use:   "show <ClOrdID|OrderID>",
+	Short: "Show an order recorded and its messages",
+	Long: "Show an order recorded by the order tracker along with the requests sent and the execution reports received for it. " +
+		"The order is looked up by the ClOrdID of any of its requests or by the OrderID given by the counterparty.",
+	Example:           "  fix orders show 9f1c2f4e-8d5e-4c7b-9c57-1c8a5b9d3f20",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: cobra.NoFileCompletions,
+	RunE:              Execute,
+}
+
+func Execute(cmd *cobra.Command, args []string) error {
+	options := config.GetOptions()
+
+	tracker, err := ordertracker.OpenSelected()
+	if err != nil {
+		return err
+	}
+	defer tracker.Close()
+
+	order, err := tracker.Find(args[0])
+	if err != nil {
+		return err
+	}
+
+	messages, err := tracker.Messages(order)
+	if err != nil {
+		return err
+	}
+
+	if options.Output == utils.OutputFormatJSON {
+		if messages == nil {
+			messages = []*ordertracker.Message{}
+		}
+		return json.NewEncoder(os.Stdout).Encode(struct {
+			*ordertracker.Order
+			Messages []*ordertracker.Message `json:"messages"`
+		}{order, messages})
+	}
+
+	fmt.Printf("ClOrdID:    %s\n", order.ClOrdID)
+	fmt.Printf("OrderID:    %s\n", order.OrderID)
+	fmt.Printf("Session:    %s\n", order.Session)
+	fmt.Printf("Symbol:     %s\n", order.Symbol)
+	fmt.Printf("Side:       %s\n", order.SideName())
+	fmt.Printf("Type:       %s\n", order.TypeName())
+	fmt.Printf("Price:      %s\n", order.Price)
+	fmt.Printf("Quantity:   %s\n", order.OrderQty)
+	fmt.Printf("Cum qty:    %s\n", order.CumQty)
+	fmt.Printf("Leaves qty: %s\n", order.LeavesQty)
+	fmt.Printf("Avg px:     %s\n", order.AvgPx)
+	fmt.Printf("State:      %s\n", application.TrackedOrderState(order))
+	if len(order.Text) > 0 {
+		fmt.Printf("Text:       %s\n", order.Text)
+	}
+	fmt.Printf("Created:    %s\n", utils.FormatTime(order.CreatedAt))
+	fmt.Printf("Updated:    %s\n\n", utils.FormatTime(order.UpdatedAt))
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"TIME", "DIRECTION", "SEQNUM", "TYPE", "CLORDID", "EXECTYPE", "ORDSTATUS", "MESSAGE"})
+	table.SetBorders(tablewriter.Border{Left: false, Top: false, Right: false, Bottom: true})
+	table.SetColumnSeparator(" ")
+	table.SetCenterSeparator("-")
+	table.SetAutoWrapText(false)
+
+	for _, message := range messages {
+		table.Append([]string{
+			utils.FormatTime(message.Time),
+			message.Direction,
+			strconv.Itoa(message.SeqNum),
+			message.MsgType,
+			message.ClOrdID,
+			message.ExecType,
+			message.OrdStatus,
+			message.Raw,
+		})
+	}
+
+	table.Render()
+
+	return nil
+}
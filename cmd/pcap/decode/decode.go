@@ -0,0 +1,205 @@
+package decode
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+
+	"sylr.dev/fix/config"
+	"sylr.dev/fix/pkg/dictionary"
+	"sylr.dev/fix/pkg/errors"
+	"sylr.dev/fix/pkg/pcap"
+	"sylr.dev/fix/pkg/utils"
+)
+
+var (
+	optionPort uint16
+	optionLint bool
+)
+
+var PcapDecodeCmd = &cobra.Command{
+	Use:   "decode <capture>",
+	Short: "Decode the FIX messages of a packet capture",
+	Long: "Reassemble the TCP streams of a pcap or pcapng capture, extract the FIX messages they carry " +
+		"and decode them using the configured data dictionaries. Each message is given with the time " +
+		"of the packet which completed it and its direction. With --lint, messages are also validated " +
+		"like with the lint command.",
+	Example:           "  fix pcap decode capture.pcap --session venue --port 9876 --lint",
+	Args:              cobra.ExactArgs(1),
+	PersistentPreRunE: utils.MakePersistentPreRunE(dictionary.ValidateDictionaryOptions),
+	RunE:              Execute,
+}
+
+func init() {
+	dictionary.AddPersistentFlags(PcapDecodeCmd)
+
+	PcapDecodeCmd.Flags().Uint16Var(&optionPort, "port", 0, "Only decode the connections using this TCP port")
+	PcapDecodeCmd.Flags().BoolVar(&optionLint, "lint", false, "Validate the messages")
+
+	dictionary.AddPersistentFlagCompletions(PcapDecodeCmd)
+}
+
+type jsonMessage struct {
+	Time        time.Time                 `json:"time"`
+	Source      string                    `json:"source"`
+	Destination string                    `json:"destination"`
+	Message     json.RawMessage           `json:"message,omitempty"`
+	Raw         string                    `json:"raw,omitempty"`
+	Issues      []utils.QuickFixLintIssue `json:"issues,omitempty"`
+}
+
+func Execute(cmd *cobra.Command, args []string) error {
+	options := config.GetOptions()
+	logger := config.GetLogger()
+
+	transportDict, appDict, err := dictionary.GetFIXDictionaries()
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader, err := pcap.NewReader(file)
+	if err != nil {
+		return err
+	}
+
+	printer := utils.QuickFixAppMessageLogger{
+		Logger:                  logger,
+		TransportDataDictionary: transportDict,
+		AppDataDictionary:       appDict,
+		OutputFormat:            options.Output,
+	}
+
+	assembler := pcap.NewAssembler()
+	count, failed, invalid := 0, 0, 0
+
+	for {
+		packet, err := reader.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		segment, ok := pcap.DecodeTCP(packet)
+		if !ok {
+			continue
+		}
+		if optionPort > 0 && segment.Flow.Src.Port != optionPort && segment.Flow.Dst.Port != optionPort {
+			continue
+		}
+
+		for _, m := range assembler.Add(packet.Time, segment) {
+			count++
+
+			var issues []utils.QuickFixLintIssue
+			if optionLint {
+				if issues = utils.QuickFixLint(m.Raw, transportDict, appDict); len(issues) > 0 {
+					invalid++
+				}
+			}
+
+			raw := m.Raw
+			if fixed, err := utils.QuickFixRawMessageSetBodyLength(raw); err == nil && fixed != raw {
+				logger.Warn().Msgf("Message #%d has a wrong BodyLength", count)
+				raw = fixed
+			}
+
+			message, err := utils.ParseQuickFixRawMessage(raw, transportDict, appDict)
+			if err != nil {
+				logger.Error().Err(err).Msgf("Unable to parse message #%d", count)
+				failed++
+			}
+
+			switch options.Output {
+			case utils.OutputFormatJSON:
+				out := jsonMessage{
+					Time:        m.Time,
+					Source:      m.Flow.Src.String(),
+					Destination: m.Flow.Dst.String(),
+					Issues:      issues,
+				}
+				if message != nil {
+					out.Message, err = utils.QuickFixMessageToJSON(message, transportDict, appDict)
+				}
+				if message == nil || err != nil {
+					out.Raw = m.Raw
+				}
+				if err := json.NewEncoder(os.Stdout).Encode(out); err != nil {
+					return err
+				}
+
+			case utils.OutputFormatTable:
+				if count > 1 {
+					fmt.Println()
+				}
+				fmt.Printf("%s %s\n", utils.FormatTime(m.Time), m.Flow)
+				if message != nil {
+					printer.WriteMessageAsTable(os.Stdout, message)
+				}
+				if len(issues) > 0 {
+					writeIssues(os.Stdout, issues)
+				}
+
+			case utils.OutputFormatRaw:
+				fmt.Printf("%s %s ", utils.FormatTime(m.Time), m.Flow)
+				if message != nil {
+					printer.WriteMessageAsRaw(os.Stdout, message)
+				} else {
+					fmt.Println(m.Raw)
+				}
+
+			case utils.OutputFormatYAML:
+				fmt.Printf("# %s %s\n", utils.FormatTime(m.Time), m.Flow)
+				fallthrough
+
+			default:
+				if message != nil {
+					printer.WriteMessage(os.Stdout, message)
+				}
+			}
+		}
+	}
+
+	if assembler.Gaps > 0 {
+		logger.Warn().Msgf("%d gap(s) in the TCP streams, messages may be missing", assembler.Gaps)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%w: %d message(s) out of %d", errors.FixMessageParse, failed, count)
+	}
+	if invalid > 0 {
+		return fmt.Errorf("%w: %d message(s) out of %d", errors.FixMessageInvalid, invalid, count)
+	}
+
+	return nil
+}
+
+func writeIssues(w io.Writer, issues []utils.QuickFixLintIssue) {
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"TAG", "REASON", "TEXT"})
+	table.SetBorders(tablewriter.Border{Left: false, Top: false, Right: false, Bottom: true})
+	table.SetColumnSeparator(" ")
+	table.SetCenterSeparator("-")
+	table.SetAutoWrapText(false)
+	table.SetColumnAlignment([]int{tablewriter.ALIGN_RIGHT, tablewriter.ALIGN_LEFT, tablewriter.ALIGN_LEFT})
+	for _, issue := range issues {
+		tag := ""
+		if issue.Tag > 0 {
+			tag = strconv.Itoa(issue.Tag)
+		}
+		table.Append([]string{tag, issue.Reason, issue.Text})
+	}
+	table.Render()
+}
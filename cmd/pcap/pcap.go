@@ -0,0 +1,17 @@
+package pcap
+
+import (
+	"github.com/spf13/cobra"
+
+	"sylr.dev/fix/cmd/pcap/decode"
+)
+
+var PcapCmd = &cobra.Command{
+	Use:   "pcap",
+	Short: "Extract FIX messages from packet captures",
+	Long:  "Extract the FIX messages exchanged over the TCP connections of pcap or pcapng captures.",
+}
+
+func init() {
+	PcapCmd.AddCommand(decode.PcapDecodeCmd)
+}
@@ -1,9 +1,6 @@
 package probe
 
 import (
-	"os"
-	"os/signal"
-	"syscall"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -30,6 +27,9 @@ var ProbeCmd = &cobra.Command{
 	Long:              "Test fix session.",
 	RunE:              Execute,
 	PersistentPreRunE: utils.MakePersistentPreRunE(Validate),
+	Annotations: map[string]string{
+		initiator.MultiSessionsAnnotation: "true",
+	},
 }
 
 func init() {
@@ -44,11 +44,10 @@ func Validate(_ *cobra.Command, _ []string) error {
 	return nil
 }
 
-func Execute(_ *cobra.Command, _ []string) error {
+func Execute(cmd *cobra.Command, _ []string) error {
 	logger := config.GetLogger()
 
-	interrupt := make(chan os.Signal, 1)
-	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	ctx := cmd.Context()
 
 	time.Sleep(time.Until(time.Now().Truncate(optionProbeInterval).Add(optionProbeInterval)))
 	ticker := time.NewTicker(optionProbeInterval)
@@ -56,8 +55,8 @@ func Execute(_ *cobra.Command, _ []string) error {
 LOOP:
 	for {
 		select {
-		case signal := <-interrupt:
-			logger.Debug().Msgf("Received signal: %s", signal)
+		case <-ctx.Done():
+			logger.Debug().Msg("Interrupted")
 			break LOOP
 
 		case <-ticker.C:
@@ -0,0 +1,45 @@
+package scenariorun
+
+import (
+	"github.com/quickfixgo/quickfix"
+	"github.com/rs/zerolog"
+
+	"sylr.dev/fix/pkg/initiator/application"
+	"sylr.dev/fix/pkg/report"
+)
+
+// scenarioApp is an initiator application which forwards every message
+// received, session level ones included, to the scenario runner.
+type scenarioApp struct {
+	*application.Initiator
+
+	Received chan report.Exchange
+}
+
+func newScenarioApp() *scenarioApp {
+	return &scenarioApp{
+		Initiator: application.NewInitiator(),
+		Received:  make(chan report.Exchange, 1024),
+	}
+}
+
+func (app *scenarioApp) forward(message *quickfix.Message) {
+	select {
+	case app.Received <- report.NewExchange(message, false, 0):
+	default:
+		app.Logger.Warn().Msg("Too many messages awaiting a step, message dropped")
+	}
+}
+
+// Notification of admin message being received from target.
+func (app *scenarioApp) FromAdmin(message *quickfix.Message, sessionID quickfix.SessionID) quickfix.MessageRejectError {
+	app.forward(message)
+	return app.Initiator.FromAdmin(message, sessionID)
+}
+
+// Notification of app message being received from target.
+func (app *scenarioApp) FromApp(message *quickfix.Message, sessionID quickfix.SessionID) quickfix.MessageRejectError {
+	app.LogMessage(zerolog.InfoLevel, message, sessionID, false)
+	app.forward(message)
+	return nil
+}
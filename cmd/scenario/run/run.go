@@ -0,0 +1,203 @@
+package scenariorun
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+
+	"sylr.dev/fix/config"
+	"sylr.dev/fix/pkg/initiator"
+	"sylr.dev/fix/pkg/report"
+	"sylr.dev/fix/pkg/scenario"
+	"sylr.dev/fix/pkg/utils"
+)
+
+var (
+	optionExpectTimeout time.Duration
+	optionReport        string
+)
+
+var ScenarioRunCmd = &cobra.Command{
+	Use:   "run <file>...",
+	Short: "Run scenario files",
+	Long: "Run the steps of the scenario files in order over a FIX session. A step either sends a message, " +
+		"expects a message matching tag predicates within a timeout or sleeps. The steps following a failed " +
+		"one are skipped and a pass/fail summary is printed once all the scenarios have run.",
+	Example: "  fix scenario run --context venue limit-order.yaml cancel.yaml",
+	Args:    cobra.MinimumNArgs(1),
+	RunE:    Execute,
+}
+
+func init() {
+	ScenarioRunCmd.Flags().DurationVar(&optionExpectTimeout, "expect-timeout", scenario.DefaultTimeout, "Timeout of the expect steps which do not set one")
+	ScenarioRunCmd.Flags().StringVar(&optionReport, "report", "", "Write an HTML report of the run with the decoded messages into this file")
+}
+
+func Execute(cmd *cobra.Command, args []string) error {
+	options := config.GetOptions()
+
+	scenarios := make([]*scenario.Scenario, 0, len(args))
+	for _, arg := range args {
+		s, err := scenario.Load(arg)
+		if err != nil {
+			return err
+		}
+		scenarios = append(scenarios, s)
+	}
+
+	context, err := config.GetCurrentContext()
+	if err != nil {
+		return err
+	}
+
+	sessions, err := context.GetSessions()
+	if err != nil {
+		return err
+	}
+
+	session := sessions[0]
+	logger := session.GetLogger()
+	initiatorConfig, err := context.GetInitiator()
+	if err != nil {
+		return err
+	}
+
+	transportDict, appDict, err := session.GetFIXDictionaries()
+	if err != nil {
+		return err
+	}
+
+	settings, err := context.ToQuickFixInitiatorSettings()
+	if err != nil {
+		return err
+	}
+
+	app := newScenarioApp()
+	app.Logger = logger
+	app.Settings = settings
+	app.TransportDataDictionary = transportDict
+	app.AppDataDictionary = appDict
+
+	var quickfixLogger *zerolog.Logger
+	if options.QuickFixLogging {
+		quickfixLogger = logger
+	}
+
+	// Choose right timeout cli option > config > default value (5s)
+	var timeout time.Duration
+	if options.Timeout != time.Duration(0) {
+		timeout = options.Timeout
+	} else if initiatorConfig.SocketTimeout != time.Duration(0) {
+		timeout = initiatorConfig.SocketTimeout
+	} else {
+		timeout = 5 * time.Second
+	}
+
+	init, sessionId, err := initiator.Connect(cmd.Context(), app, settings, quickfixLogger, app.Connected, timeout, initiatorConfig.Reconnect)
+	if err != nil {
+		app.Stop()
+		return err
+	}
+
+	defer func() {
+		app.Stop()
+		init.Stop()
+	}()
+
+	// Messages sent are not needed
+	go func() {
+		for range app.ToAppMessages {
+		}
+	}()
+
+	runner := &scenario.Runner{
+		SessionID:               sessionId,
+		Received:                app.Received,
+		TransportDataDictionary: transportDict,
+		AppDataDictionary:       appDict,
+		Timeout:                 optionExpectTimeout,
+	}
+
+	results := make([]*scenario.Result, 0, len(scenarios))
+	failed := 0
+	for _, s := range scenarios {
+		logger.Debug().Msgf("Running scenario %s", s.Name)
+
+		result := runner.Run(cmd.Context(), s)
+		if !result.Passed {
+			failed++
+		}
+		results = append(results, result)
+	}
+
+	if err := writeResults(results, options.Output); err != nil {
+		return err
+	}
+
+	if len(optionReport) > 0 {
+		r := &report.Report{
+			Title:     "Scenario report",
+			Context:   context.Name,
+			Session:   session.Name,
+			Generated: time.Now(),
+		}
+		for _, result := range results {
+			r.Sections = append(r.Sections, result.Section())
+		}
+		if err := r.WriteHTMLFile(optionReport, transportDict, appDict); err != nil {
+			return err
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d scenario(s) out of %d failed", failed, len(results))
+	}
+
+	return nil
+}
+
+func writeResults(results []*scenario.Result, output string) error {
+	if output == utils.OutputFormatJSON {
+		return json.NewEncoder(os.Stdout).Encode(results)
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"SCENARIO", "STEP", "KIND", "STATUS", "DURATION", "DETAILS"})
+	table.SetBorders(tablewriter.Border{Left: false, Top: false, Right: false, Bottom: true})
+	table.SetColumnSeparator(" ")
+	table.SetCenterSeparator("-")
+	table.SetAutoWrapText(false)
+
+	passed := 0
+	for _, result := range results {
+		if result.Passed {
+			passed++
+		}
+
+		for i, step := range result.Steps {
+			name := ""
+			if i == 0 {
+				name = result.Scenario
+			}
+
+			duration := ""
+			if step.Status != scenario.StatusSkip {
+				duration = utils.FormatLatency(step.Duration)
+			}
+
+			table.Append([]string{name, step.Name, step.Kind, strings.ToUpper(step.Status), duration, step.Details})
+		}
+	}
+
+	table.Render()
+
+	fmt.Printf("\n%d scenario(s): %d passed, %d failed\n", len(results), passed, len(results)-passed)
+
+	return nil
+}
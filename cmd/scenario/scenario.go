@@ -0,0 +1,41 @@
+package scenario
+
+import (
+	"github.com/spf13/cobra"
+
+	scenariorun "sylr.dev/fix/cmd/scenario/run"
+	"sylr.dev/fix/pkg/initiator"
+	"sylr.dev/fix/pkg/utils"
+)
+
+var ScenarioCmd = &cobra.Command{
+	Use:   "scenario",
+	Short: "Run scripted FIX scenarios",
+	Long:  "Run scripted send/expect scenarios over a FIX session after initiating it with a FIX acceptor.",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := utils.ValidateRequiredFlags(cmd); err != nil {
+			return err
+		}
+
+		if err := initiator.ValidateOptions(cmd, args); err != nil {
+			return err
+		}
+
+		if cmd.HasParent() {
+			parent := cmd.Parent()
+			if parent.PersistentPreRunE != nil {
+				return parent.PersistentPreRunE(parent, args)
+			}
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	initiator.AddPersistentFlags(ScenarioCmd)
+	initiator.AddPersistentFlagCompletions(ScenarioCmd)
+	initiator.AddPersistentFlagCompletions(scenariorun.ScenarioRunCmd)
+
+	ScenarioCmd.AddCommand(scenariorun.ScenarioRunCmd)
+}
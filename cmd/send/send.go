@@ -0,0 +1,324 @@
+package send
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/quickfix/datadictionary"
+	"github.com/quickfixgo/tag"
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+
+	"sylr.dev/fix/config"
+	"sylr.dev/fix/pkg/dictionary"
+	"sylr.dev/fix/pkg/errors"
+	"sylr.dev/fix/pkg/initiator"
+	"sylr.dev/fix/pkg/initiator/application"
+	"sylr.dev/fix/pkg/sessiond"
+	"sylr.dev/fix/pkg/template"
+	"sylr.dev/fix/pkg/utils"
+)
+
+var (
+	optionTemplate string
+	optionSet      []string
+	optionFile     string
+	optionRaw      string
+	optionExpect   []string
+	optionWait     bool
+	optionSocket   string
+)
+
+var SendCmd = &cobra.Command{
+	Use:   "send",
+	Short: "Send arbitrary messages on a session",
+	Long: "Send messages built from a template, from their JSON/YAML description (like `fix encode`) or from " +
+		"a raw string on the session of the context, and optionally wait for a response matching Field=Value " +
+		"predicates. This is an escape hatch for the message types without a dedicated command.\n\n" +
+		"Raw messages can be given whole, as written in the logs, or from the MsgType on; the BeginString, " +
+		"BodyLength, CheckSum and session header fields are set when sent.\n\n" +
+		"When the session of the context is served by `fix session hold --serve`, the messages are sent " +
+		"on it instead of logging on.",
+	Example: "  fix send --context venue --template limit-order --set Symbol=EURUSD --set Side=1 --expect MsgType=ExecutionReport\n" +
+		"  fix send --context venue --raw '35=g|335=1|336=DAY' --expect 35=h --expect 335=1",
+	Args:              cobra.ExactArgs(0),
+	ValidArgsFunction: cobra.NoFileCompletions,
+	PersistentPreRunE: utils.MakePersistentPreRunE(Validate),
+	RunE:              Execute,
+}
+
+func init() {
+	initiator.AddPersistentFlags(SendCmd)
+	initiator.AddPersistentFlagCompletions(SendCmd)
+	template.AddPersistentFlags(SendCmd)
+
+	SendCmd.Flags().StringVar(&optionTemplate, "template", "", "Template to render")
+	SendCmd.Flags().StringArrayVar(&optionSet, "set", nil, "Value of a placeholder of the template (Name=Value)")
+	SendCmd.Flags().StringVarP(&optionFile, "file", "f", "", "File to read messages described in JSON/YAML from (- for stdin)")
+	SendCmd.Flags().StringVar(&optionRaw, "raw", "", "Raw message, fields delimited by SOH, pipes or carets")
+	SendCmd.Flags().StringArrayVar(&optionExpect, "expect", nil, "Wait for a response holding this field (Field=Value, implies --wait)")
+	SendCmd.Flags().BoolVar(&optionWait, "wait", false, "Wait for a response to each message sent")
+	SendCmd.Flags().StringVar(&optionSocket, "socket", "", "Unix socket of the session served by `fix session hold --serve` (default $HOME/.fix/sessions/<context>.sock if it exists)")
+
+	SendCmd.RegisterFlagCompletionFunc("template", template.CompleteName)
+}
+
+func Validate(cmd *cobra.Command, args []string) error {
+	sources := 0
+	for _, source := range []string{optionTemplate, optionFile, optionRaw} {
+		if len(source) > 0 {
+			sources++
+		}
+	}
+	if sources != 1 {
+		return fmt.Errorf("%w: exactly one of --template, --file and --raw must be given", errors.Options)
+	}
+
+	if len(optionSet) > 0 && len(optionTemplate) == 0 {
+		return fmt.Errorf("%w: --set can only be used with --template", errors.Options)
+	}
+
+	if _, err := template.ParseValues(optionSet); err != nil {
+		return err
+	}
+
+	return initiator.ValidateOptions(cmd, args)
+}
+
+func Execute(cmd *cobra.Command, args []string) error {
+	options := config.GetOptions()
+	logger := config.GetLogger()
+
+	context, err := config.GetCurrentContext()
+	if err != nil {
+		return err
+	}
+
+	sessions, err := context.GetSessions()
+	if err != nil {
+		return err
+	}
+
+	session := sessions[0]
+	logger = session.GetLogger()
+	initiatorConfig, err := context.GetInitiator()
+	if err != nil {
+		return err
+	}
+
+	transportDict, appDict, err := dictionary.GetFIXDictionaries()
+	if err != nil {
+		return err
+	}
+
+	messages, err := buildMessages(transportDict, appDict)
+	if err != nil {
+		return err
+	}
+
+	matchers, err := utils.ParseQuickFixFieldMatchers(optionExpect, transportDict, appDict)
+	if err != nil {
+		return err
+	}
+
+	settings, err := context.ToQuickFixInitiatorSettings()
+	if err != nil {
+		return err
+	}
+
+	app := application.NewInitiator()
+	app.Logger = logger
+	app.Settings = settings
+	app.TransportDataDictionary = transportDict
+	app.AppDataDictionary = appDict
+	app.OutputFormat = options.Output
+
+	var quickfixLogger *zerolog.Logger
+	if options.QuickFixLogging {
+		quickfixLogger = logger
+	}
+
+	// Choose right timeout cli option > config > default value (5s)
+	var timeout time.Duration
+	if options.Timeout != time.Duration(0) {
+		timeout = options.Timeout
+	} else if initiatorConfig.SocketTimeout != time.Duration(0) {
+		timeout = initiatorConfig.SocketTimeout
+	} else {
+		timeout = 5 * time.Second
+	}
+
+	if client, err := sessiond.Served(optionSocket, context.Name, settings); err != nil {
+		return err
+	} else if client != nil {
+		defer client.Close()
+		return sendOnSocket(client, app, session.BeginString, messages, timeout)
+	}
+
+	ctx := cmd.Context()
+
+	init, sessionId, err := initiator.Connect(ctx, app, settings, quickfixLogger, app.Connected, timeout, initiatorConfig.Reconnect)
+	if err != nil {
+		app.Stop()
+		return err
+	}
+
+	defer func() {
+		app.Stop()
+		init.Stop()
+	}()
+
+	for _, message := range messages {
+		err = quickfix.SendToTarget(message, sessionId)
+		if err != nil {
+			return err
+		}
+
+		// Drain the message sent
+		select {
+		case <-app.ToAppMessages:
+		default:
+		}
+
+		if !optionWait && len(matchers) == 0 {
+			continue
+		}
+
+		deadline := time.After(timeout)
+
+	LOOP:
+		for {
+			select {
+			case <-ctx.Done():
+				logger.Debug().Msg("Interrupted")
+				return nil
+
+			case <-deadline:
+				return errors.ResponseTimeout
+
+			case responseMessage, ok := <-app.FromAppMessages:
+				if !ok {
+					return errors.FixLogout
+				}
+
+				if !utils.QuickFixMessageMatches(responseMessage, matchers) {
+					logger.Debug().Msg("Response not matching, still waiting")
+					continue
+				}
+
+				app.WriteMessage(os.Stdout, responseMessage)
+				break LOOP
+			}
+		}
+	}
+
+	return nil
+}
+
+// sendOnSocket sends the messages on the session served on the socket.
+func sendOnSocket(client *sessiond.Client, app *application.Initiator, beginString string, messages []*quickfix.Message, timeout time.Duration) error {
+	for _, message := range messages {
+		message.Header.SetString(tag.BeginString, beginString)
+
+		response, err := client.Send(sessiond.Request{
+			Raw:     message.String(),
+			Expect:  optionExpect,
+			Wait:    optionWait,
+			Timeout: timeout,
+		})
+		if err != nil {
+			return err
+		}
+
+		if len(response) == 0 {
+			continue
+		}
+
+		responseMessage, err := utils.ParseQuickFixRawMessage(response, app.TransportDataDictionary, app.AppDataDictionary)
+		if err != nil {
+			return fmt.Errorf("%w: %s", errors.FixMessageParse, err)
+		}
+
+		app.WriteMessage(os.Stdout, responseMessage)
+	}
+
+	return nil
+}
+
+// buildMessages builds the messages given with --template, --file or --raw.
+func buildMessages(transportDict, appDict *datadictionary.DataDictionary) ([]*quickfix.Message, error) {
+	options := config.GetOptions()
+
+	switch {
+	case len(optionTemplate) > 0:
+		t, err := template.Load(options.Templates, optionTemplate)
+		if err != nil {
+			return nil, err
+		}
+
+		values, _ := template.ParseValues(optionSet)
+		content, err := t.Render(values)
+		if err != nil {
+			return nil, err
+		}
+
+		messages, err := utils.QuickFixMessagesFromYAML(content, transportDict, appDict)
+		if err != nil {
+			return nil, fmt.Errorf("template %s: %w", t.Name, err)
+		}
+
+		return messages, nil
+
+	case len(optionFile) > 0:
+		var r io.Reader = os.Stdin
+		if optionFile != "-" {
+			file, err := os.Open(optionFile)
+			if err != nil {
+				return nil, err
+			}
+			defer file.Close()
+			r = file
+		}
+
+		content, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+
+		return utils.QuickFixMessagesFromYAML(content, transportDict, appDict)
+
+	default:
+		raw := optionRaw
+		if !strings.Contains(raw, "\001") {
+			raw = strings.NewReplacer("^A", "\001", "|", "\001").Replace(raw)
+		}
+		if !strings.Contains(raw, "8=FIX") {
+			// The session sets the BeginString and the CheckSum is computed
+			// when sent
+			raw = "8=FIX.4.4\0019=0\001" + strings.TrimPrefix(raw, "\001")
+		}
+
+		raw = utils.QuickFixRawMessage(raw)
+		if !strings.Contains(raw, "\00110=") {
+			raw += "10=000\001"
+		}
+
+		raw, err := utils.QuickFixRawMessageSetBodyLength(raw)
+		if err != nil {
+			return nil, err
+		}
+
+		message, err := utils.ParseQuickFixRawMessage(raw, transportDict, appDict)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", errors.FixMessageParse, err)
+		}
+
+		// Drop the raw bytes of the parsed message for it to be built again
+		// with the session header
+		return []*quickfix.Message{utils.QuickFixMessageCopy(message, appDict)}, nil
+	}
+}
@@ -0,0 +1,75 @@
+package sessionhold
+
+import (
+	"github.com/quickfixgo/quickfix"
+	"github.com/rs/zerolog"
+
+	"sylr.dev/fix/pkg/sessiond"
+	"sylr.dev/fix/pkg/utils"
+)
+
+// holdApp is an initiator application which logs every message, session ones
+// included, and survives the logouts of the session.
+type holdApp struct {
+	utils.BaseApplication
+
+	Connected chan quickfix.SessionID
+
+	// server, when set, serves the session to the other commands
+	server *sessiond.Server
+}
+
+func newHoldApp() *holdApp {
+	return &holdApp{
+		Connected: make(chan quickfix.SessionID, 1),
+	}
+}
+
+// Notification of a session begin created.
+func (app *holdApp) OnCreate(sessionID quickfix.SessionID) {
+	app.Logger.Debug().Msgf("New session: %s", sessionID)
+}
+
+// Notification of a session successfully logging on.
+func (app *holdApp) OnLogon(sessionID quickfix.SessionID) {
+	app.Logger.Info().Msgf("Logon: %s", sessionID)
+
+	select {
+	case app.Connected <- sessionID:
+	default:
+	}
+}
+
+// Notification of a session logging off or disconnecting.
+func (app *holdApp) OnLogout(sessionID quickfix.SessionID) {
+	app.Logger.Warn().Msgf("Logout: %s", sessionID)
+}
+
+// Notification of admin message being sent to target.
+func (app *holdApp) ToAdmin(message *quickfix.Message, sessionID quickfix.SessionID) {
+	app.PrepareLogon(message, sessionID)
+	app.PrepareLogout(message)
+
+	app.LogMessage(zerolog.InfoLevel, message, sessionID, true)
+}
+
+// Notification of admin message being received from target.
+func (app *holdApp) FromAdmin(message *quickfix.Message, sessionID quickfix.SessionID) quickfix.MessageRejectError {
+	app.LogMessage(zerolog.InfoLevel, message, sessionID, false)
+	return nil
+}
+
+// Notification of app message being sent to target.
+func (app *holdApp) ToApp(message *quickfix.Message, sessionID quickfix.SessionID) error {
+	app.LogMessage(zerolog.InfoLevel, message, sessionID, true)
+	return nil
+}
+
+// Notification of app message being received from target.
+func (app *holdApp) FromApp(message *quickfix.Message, sessionID quickfix.SessionID) quickfix.MessageRejectError {
+	app.LogMessage(zerolog.InfoLevel, message, sessionID, false)
+	if app.server != nil {
+		app.server.Received(message)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package sessionhold
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+
+	"sylr.dev/fix/config"
+	"sylr.dev/fix/pkg/initiator"
+	"sylr.dev/fix/pkg/sessiond"
+)
+
+var (
+	optionDuration time.Duration
+	optionServe    bool
+	optionSocket   string
+)
+
+var SessionHoldCmd = &cobra.Command{
+	Use:   "hold",
+	Short: "Log on and maintain a session",
+	Long: "Log on and maintain the session, answering heartbeats, test requests and resend requests, " +
+		"until interrupted. Every message exchanged is logged at the info level, which allows to test " +
+		"the session settings of a venue independently of application messages.\n\n" +
+		"With --serve, the session is served on a Unix socket which commands like `fix send` use instead " +
+		"of logging on themselves and which `fix session logout` logs the session out through.",
+	Example: "  fix session hold --context venue\n" +
+		"  fix session hold --context venue --serve &\n" +
+		"  fix send --context venue --raw '35=D|...' --expect 35=8",
+	Args:              cobra.ExactArgs(0),
+	ValidArgsFunction: cobra.NoFileCompletions,
+	RunE:              Execute,
+}
+
+func init() {
+	SessionHoldCmd.Flags().DurationVar(&optionDuration, "duration", 0, "Log out after this duration (0 holds the session until interrupted)")
+	SessionHoldCmd.Flags().BoolVar(&optionServe, "serve", false, "Serve the session to the other commands on a Unix socket")
+	SessionHoldCmd.Flags().StringVar(&optionSocket, "socket", "", "Unix socket the session is served on (default $HOME/.fix/sessions/<context>.sock)")
+}
+
+func Execute(cmd *cobra.Command, args []string) error {
+	options := config.GetOptions()
+
+	context, err := config.GetCurrentContext()
+	if err != nil {
+		return err
+	}
+
+	sessions, err := context.GetSessions()
+	if err != nil {
+		return err
+	}
+
+	session := sessions[0]
+	logger := session.GetLogger()
+	initiatorConfig, err := context.GetInitiator()
+	if err != nil {
+		return err
+	}
+
+	transportDict, appDict, err := session.GetFIXDictionaries()
+	if err != nil {
+		return err
+	}
+
+	settings, err := context.ToQuickFixInitiatorSettings()
+	if err != nil {
+		return err
+	}
+
+	app := newHoldApp()
+	app.Logger = logger
+	app.Settings = settings
+	app.TransportDataDictionary = transportDict
+	app.AppDataDictionary = appDict
+	logout := make(chan struct{})
+	if optionServe {
+		var once sync.Once
+		app.server = sessiond.NewServer(transportDict, appDict, logger)
+		app.server.OnLogout(func(text string) {
+			once.Do(func() {
+				app.LogoutText = text
+				close(logout)
+			})
+		})
+	}
+
+	var quickfixLogger *zerolog.Logger
+	if options.QuickFixLogging {
+		quickfixLogger = logger
+	}
+
+	// Choose right timeout cli option > config > default value (5s)
+	var timeout time.Duration
+	if options.Timeout != time.Duration(0) {
+		timeout = options.Timeout
+	} else if initiatorConfig.SocketTimeout != time.Duration(0) {
+		timeout = initiatorConfig.SocketTimeout
+	} else {
+		timeout = 5 * time.Second
+	}
+
+	init, sessionId, err := initiator.Connect(cmd.Context(), app, settings, quickfixLogger, app.Connected, timeout, initiatorConfig.Reconnect)
+	if err != nil {
+		return err
+	}
+
+	defer init.Stop()
+
+	logger.Info().Msgf("Holding session %s", sessionId)
+
+	ctx := cmd.Context()
+
+	served := make(chan error, 1)
+	if app.server != nil {
+		socket := optionSocket
+		if len(socket) == 0 {
+			socket = sessiond.DefaultSocket(context.Name)
+		}
+
+		go func() {
+			served <- app.server.Serve(ctx, socket, sessionId)
+		}()
+	}
+
+	var deadline <-chan time.Time
+	if optionDuration > 0 {
+		deadline = time.After(optionDuration)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Debug().Msg("Interrupted")
+			return nil
+
+		case <-deadline:
+			return nil
+
+		case <-logout:
+			logger.Info().Msgf("Logging session %s out as requested", sessionId)
+			return nil
+
+		case err := <-served:
+			return err
+
+		case sessionId := <-app.Connected:
+			// quickfix logs the session on again by itself after a disconnection
+			logger.Info().Msgf("Session %s logged on again", sessionId)
+		}
+	}
+}
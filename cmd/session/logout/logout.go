@@ -0,0 +1,120 @@
+package sessionlogout
+
+import (
+	"os"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+
+	"sylr.dev/fix/config"
+	"sylr.dev/fix/pkg/initiator"
+	"sylr.dev/fix/pkg/initiator/application"
+	"sylr.dev/fix/pkg/sessiond"
+)
+
+var (
+	optionText   string
+	optionSocket string
+)
+
+var SessionLogoutCmd = &cobra.Command{
+	Use:   "logout",
+	Short: "Log a session out",
+	Long: "Log the session out with a Logout carrying --text. The session held by `fix session hold --serve` " +
+		"is logged out through its socket, ending the holding process, otherwise the session is logged on " +
+		"then out.",
+	Example:           "  fix session logout --context venue --text 'end of day'",
+	Args:              cobra.ExactArgs(0),
+	ValidArgsFunction: cobra.NoFileCompletions,
+	RunE:              Execute,
+}
+
+func init() {
+	SessionLogoutCmd.Flags().StringVar(&optionText, "text", "", "Text of the Logout")
+	SessionLogoutCmd.Flags().StringVar(&optionSocket, "socket", "", "Unix socket the session is served on (default $HOME/.fix/sessions/<context>.sock)")
+}
+
+func Execute(cmd *cobra.Command, args []string) error {
+	options := config.GetOptions()
+
+	context, err := config.GetCurrentContext()
+	if err != nil {
+		return err
+	}
+
+	sessions, err := context.GetSessions()
+	if err != nil {
+		return err
+	}
+
+	session := sessions[0]
+	logger := session.GetLogger()
+	initiatorConfig, err := context.GetInitiator()
+	if err != nil {
+		return err
+	}
+
+	socket := optionSocket
+	if len(socket) == 0 {
+		if _, err := os.Stat(sessiond.DefaultSocket(context.Name)); err == nil {
+			socket = sessiond.DefaultSocket(context.Name)
+		}
+	}
+
+	if len(socket) > 0 {
+		client, err := sessiond.Dial(socket)
+		if err == nil {
+			defer client.Close()
+
+			sessionId, err := client.Logout(optionText)
+			if err != nil {
+				return err
+			}
+
+			logger.Info().Msgf("Session %s served on %s logged out", sessionId, socket)
+			return nil
+		} else if len(optionSocket) > 0 {
+			return err
+		}
+		logger.Debug().Err(err).Msgf("Session not served on %s, logging on", socket)
+	}
+
+	settings, err := context.ToQuickFixInitiatorSettings()
+	if err != nil {
+		return err
+	}
+
+	app := application.NewInitiator()
+	app.Logger = logger
+	app.Settings = settings
+	app.LogoutText = optionText
+
+	var quickfixLogger *zerolog.Logger
+	if options.QuickFixLogging {
+		quickfixLogger = logger
+	}
+
+	// Choose right timeout cli option > config > default value (5s)
+	var timeout time.Duration
+	if options.Timeout != time.Duration(0) {
+		timeout = options.Timeout
+	} else if initiatorConfig.SocketTimeout != time.Duration(0) {
+		timeout = initiatorConfig.SocketTimeout
+	} else {
+		timeout = 5 * time.Second
+	}
+
+	init, sessionId, err := initiator.Connect(cmd.Context(), app, settings, quickfixLogger, app.Connected, timeout, initiatorConfig.Reconnect)
+	if err != nil {
+		app.Stop()
+		return err
+	}
+
+	app.Stop()
+	init.Stop()
+
+	logger.Info().Msgf("Session %s logged out", sessionId)
+
+	return nil
+}
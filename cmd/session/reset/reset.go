@@ -0,0 +1,128 @@
+package sessionreset
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+
+	"sylr.dev/fix/config"
+	"sylr.dev/fix/pkg/errors"
+	"sylr.dev/fix/pkg/initiator"
+	"sylr.dev/fix/pkg/initiator/application"
+	"sylr.dev/fix/pkg/sessiond"
+	"sylr.dev/fix/pkg/store"
+)
+
+var SessionResetCmd = &cobra.Command{
+	Use:   "reset",
+	Short: "Reset the sequence numbers of a session",
+	Long: "Log the session on with a Logon carrying ResetSeqNumFlag=Y, which resets the sequence numbers of " +
+		"both sides to 1, then log it out. The session must not be held by `fix session hold --serve`.",
+	Example:           "  fix session reset --context venue",
+	Args:              cobra.ExactArgs(0),
+	ValidArgsFunction: cobra.NoFileCompletions,
+	RunE:              Execute,
+}
+
+func Execute(cmd *cobra.Command, args []string) error {
+	options := config.GetOptions()
+
+	context, err := config.GetCurrentContext()
+	if err != nil {
+		return err
+	}
+
+	sessions, err := context.GetSessions()
+	if err != nil {
+		return err
+	}
+
+	session := sessions[0]
+	logger := session.GetLogger()
+	initiatorConfig, err := context.GetInitiator()
+	if err != nil {
+		return err
+	}
+
+	// A second session would be refused by the counterparty or log the one
+	// held out.
+	if conn, err := net.Dial("unix", sessiond.DefaultSocket(context.Name)); err == nil {
+		conn.Close()
+		return fmt.Errorf("%w: the session is held on %s, log it out first", errors.Options, sessiond.DefaultSocket(context.Name))
+	}
+
+	// quickfix only sets ResetSeqNumFlag on logon when the sequence numbers of
+	// the store are already 1.
+	session.ResetOnLogon = true
+	if err := resetStores(context, session.Name); err != nil {
+		return err
+	}
+
+	settings, err := context.ToQuickFixInitiatorSettings()
+	if err != nil {
+		return err
+	}
+
+	app := application.NewInitiator()
+	app.Logger = logger
+	app.Settings = settings
+
+	var quickfixLogger *zerolog.Logger
+	if options.QuickFixLogging {
+		quickfixLogger = logger
+	}
+
+	// Choose right timeout cli option > config > default value (5s)
+	var timeout time.Duration
+	if options.Timeout != time.Duration(0) {
+		timeout = options.Timeout
+	} else if initiatorConfig.SocketTimeout != time.Duration(0) {
+		timeout = initiatorConfig.SocketTimeout
+	} else {
+		timeout = 5 * time.Second
+	}
+
+	init, sessionId, err := initiator.Connect(cmd.Context(), app, settings, quickfixLogger, app.Connected, timeout, initiatorConfig.Reconnect)
+	if err != nil {
+		app.Stop()
+		return err
+	}
+
+	app.Stop()
+	init.Stop()
+
+	logger.Info().Msgf("Session %s reset, its sequence numbers started again from 1", sessionId)
+
+	return nil
+}
+
+// resetStores resets the persistent stores of the session, memory stores
+// starting from 1 anyway.
+func resetStores(context *config.Context, name string) error {
+	stores, err := store.ContextSessions(context)
+	if err != nil {
+		return err
+	}
+
+	for _, st := range stores {
+		if st.Name != name || !store.IsPersistent(st.Settings) {
+			continue
+		}
+
+		s, err := st.Open()
+		if err != nil {
+			return err
+		}
+
+		err = s.Reset()
+		s.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+package session
+
+import (
+	"github.com/spf13/cobra"
+
+	sessionhold "sylr.dev/fix/cmd/session/hold"
+	sessionlogout "sylr.dev/fix/cmd/session/logout"
+	sessionreset "sylr.dev/fix/cmd/session/reset"
+	sessionstatus "sylr.dev/fix/cmd/session/status"
+	"sylr.dev/fix/pkg/initiator"
+	"sylr.dev/fix/pkg/utils"
+)
+
+var SessionCmd = &cobra.Command{
+	Use:   "session",
+	Short: "Manage FIX sessions",
+	Long:  "Manage the FIX sessions of an initiator independently of application messages.",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := utils.ValidateRequiredFlags(cmd); err != nil {
+			return err
+		}
+
+		if err := initiator.ValidateOptions(cmd, args); err != nil {
+			return err
+		}
+
+		if cmd.HasParent() {
+			parent := cmd.Parent()
+			if parent.PersistentPreRunE != nil {
+				return parent.PersistentPreRunE(parent, args)
+			}
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	initiator.AddPersistentFlags(SessionCmd)
+	initiator.AddPersistentFlagCompletions(SessionCmd)
+	initiator.AddPersistentFlagCompletions(sessionhold.SessionHoldCmd)
+	initiator.AddExitOnDisconnectFlag(sessionhold.SessionHoldCmd)
+
+	SessionCmd.AddCommand(sessionhold.SessionHoldCmd)
+	SessionCmd.AddCommand(sessionlogout.SessionLogoutCmd)
+	SessionCmd.AddCommand(sessionreset.SessionResetCmd)
+	SessionCmd.AddCommand(sessionstatus.SessionStatusCmd)
+}
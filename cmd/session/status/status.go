@@ -0,0 +1,95 @@
+package sessionstatus
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+
+	"sylr.dev/fix/config"
+	"sylr.dev/fix/pkg/initiator"
+	"sylr.dev/fix/pkg/sessiond"
+	"sylr.dev/fix/pkg/store"
+	"sylr.dev/fix/pkg/utils"
+)
+
+var optionSocket string
+
+var SessionStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the status of the sessions",
+	Long: "Show the sessions of the context, whether they are held by `fix session hold --serve` and the " +
+		"sequence numbers of their persistent stores, without logging them on.",
+	Example:           "  fix session status --context venue",
+	Args:              cobra.ExactArgs(0),
+	ValidArgsFunction: cobra.NoFileCompletions,
+	RunE:              Execute,
+	Annotations: map[string]string{
+		initiator.MultiSessionsAnnotation: "true",
+	},
+}
+
+func init() {
+	SessionStatusCmd.Flags().StringVar(&optionSocket, "socket", "", "Unix socket the session is served on (default $HOME/.fix/sessions/<context>.sock)")
+}
+
+func Execute(cmd *cobra.Command, args []string) error {
+	logger := config.GetLogger()
+
+	context, err := config.GetCurrentContext()
+	if err != nil {
+		return err
+	}
+
+	sessions, err := store.ContextSessions(context)
+	if err != nil {
+		return err
+	}
+
+	socket := optionSocket
+	if len(socket) == 0 {
+		socket = sessiond.DefaultSocket(context.Name)
+	}
+
+	var held string
+	if client, err := sessiond.Dial(socket); err == nil {
+		held, err = client.Status()
+		client.Close()
+		if err != nil {
+			return err
+		}
+	} else {
+		logger.Debug().Err(err).Msgf("No session served on %s", socket)
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"SESSION", "SESSION ID", "HELD", "NEXT SENDER SEQNUM", "NEXT TARGET SEQNUM", "CREATION TIME"})
+	table.SetBorders(tablewriter.Border{Left: false, Top: false, Right: false, Bottom: true})
+	table.SetColumnSeparator(" ")
+	table.SetCenterSeparator("-")
+
+	for _, session := range sessions {
+		row := []string{session.Name, session.SessionID.String(), "no", "-", "-", "-"}
+		if held == session.SessionID.String() {
+			row[2] = "on " + socket
+		}
+
+		if store.IsPersistent(session.Settings) {
+			s, err := session.Open()
+			if err != nil {
+				return err
+			}
+			row[3] = strconv.Itoa(s.NextSenderMsgSeqNum())
+			row[4] = strconv.Itoa(s.NextTargetMsgSeqNum())
+			row[5] = utils.FormatTime(s.CreationTime())
+			s.Close()
+		}
+
+		table.Append(row)
+	}
+
+	table.Render()
+
+	return nil
+}
@@ -0,0 +1,503 @@
+package shell
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/olekukonko/tablewriter"
+	"github.com/shopspring/decimal"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/quickfixgo/enum"
+	"github.com/quickfixgo/field"
+	"github.com/quickfixgo/fixt11"
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/quickfix/datadictionary"
+	"github.com/quickfixgo/tag"
+
+	"sylr.dev/fix/config"
+	"sylr.dev/fix/pkg/cli/complete"
+	"sylr.dev/fix/pkg/dict"
+	"sylr.dev/fix/pkg/errors"
+	"sylr.dev/fix/pkg/initiator/application"
+	"sylr.dev/fix/pkg/utils"
+)
+
+// order is an order sent from the shell, kept to be able to cancel it.
+type order struct {
+	ClOrdID  string
+	Session  string
+	Side     enum.Side
+	Symbol   string
+	Quantity int64
+}
+
+// subscription is a market data subscription made from the shell, kept to be
+// able to unsubscribe.
+type subscription struct {
+	MDReqID string
+	Session string
+	Symbols []string
+	Types   []string
+	Depth   int
+}
+
+// newCommands returns the commands of the shell. They are built once, their
+// flags being reset before each line is run.
+func (s *shell) newCommands() *cobra.Command {
+	root := &cobra.Command{
+		Use:           "fix>",
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+	root.CompletionOptions.DisableDefaultCmd = true
+	root.SetOut(writerFunc(func(p []byte) (int, error) { s.printf("%s", p); return len(p), nil }))
+	root.SetErr(root.OutOrStdout())
+
+	root.AddCommand(s.newOrderCommand())
+	root.AddCommand(s.newCancelCommand())
+	root.AddCommand(s.newOrdersCommand())
+	root.AddCommand(s.newMarketDataCommand())
+	root.AddCommand(s.newSecurityCommand())
+	root.AddCommand(s.newSessionCommand())
+	root.AddCommand(&cobra.Command{
+		Use:   "sleep <duration>",
+		Short: "Wait, e.g. for responses when commands are piped",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			d, err := time.ParseDuration(args[0])
+			if err != nil {
+				return err
+			}
+			time.Sleep(d)
+			return nil
+		},
+	})
+	root.AddCommand(&cobra.Command{
+		Use:     "exit",
+		Aliases: []string{"quit"},
+		Short:   "Log out and exit the shell",
+		Args:    cobra.ExactArgs(0),
+		Run: func(cmd *cobra.Command, args []string) {
+			s.exiting = true
+		},
+	})
+
+	return root
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) {
+	return f(p)
+}
+
+// resetFlags sets back the flags of the commands to their default values.
+func resetFlags(cmd *cobra.Command) {
+	reset := func(f *pflag.Flag) {
+		if slice, ok := f.Value.(pflag.SliceValue); ok {
+			slice.Replace(nil)
+		} else {
+			f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	}
+
+	cmd.Flags().VisitAll(reset)
+	cmd.PersistentFlags().VisitAll(reset)
+
+	for _, c := range cmd.Commands() {
+		resetFlags(c)
+	}
+}
+
+// send sends the message on the current session.
+func (s *shell) send(build func(session config.Session, appDict *datadictionary.DataDictionary) (*quickfix.Message, error)) error {
+	app, sessionID := s.currentSession()
+	if app == nil {
+		return fmt.Errorf("%w: %s", errors.ConfigSessionNotFound, s.current)
+	}
+
+	session := *app.Session
+	if session.BeginString != quickfix.BeginStringFIXT11 || session.DefaultApplVerID != "FIX.5.0SP2" {
+		return errors.FixVersionNotImplemented
+	}
+
+	message, err := build(session, app.App.AppDataDictionary)
+	if err != nil {
+		return err
+	}
+
+	return quickfix.SendToTarget(message, sessionID)
+}
+
+func (s *shell) newOrderCommand() *cobra.Command {
+	var optionSide, optionType, optionSymbol, optionExpiry, optionID string
+	var optionQuantity int64
+	var optionPrice float64
+
+	cmd := &cobra.Command{
+		Use:   "order",
+		Short: "Send a new single order",
+		Args:  cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			side, err := dict.OrderSideStringToEnum(optionSide)
+			if err != nil {
+				return errors.OptionOrderSideUnknown
+			}
+			ordType, err := dict.OrderTypeStringToEnum(optionType)
+			if err != nil {
+				return errors.OptionOrderTypeUnknown
+			}
+			expiry, err := dict.OrderTimeInForceStringToEnum(optionExpiry)
+			if err != nil {
+				return err
+			}
+			if len(optionSymbol) == 0 {
+				return errors.OptionsNoSymbolGiven
+			}
+			if ordType == enum.OrdType_MARKET && optionPrice > 0 {
+				return errors.OptionsInvalidMarketPrice
+			} else if ordType != enum.OrdType_MARKET && optionPrice == 0 {
+				return errors.OptionsNoPriceGiven
+			}
+
+			clOrdID := optionID
+			if len(clOrdID) == 0 {
+				clOrdID = uuid.NewString()
+			}
+
+			err = s.send(func(session config.Session, appDict *datadictionary.DataDictionary) (*quickfix.Message, error) {
+				message := quickfix.NewMessage()
+				header := fixt11.NewHeader(&message.Header)
+				header.Set(field.NewMsgType(enum.MsgType_ORDER_SINGLE))
+				message.Body.Set(field.NewClOrdID(clOrdID))
+				message.Body.Set(field.NewSide(side))
+				message.Body.Set(field.NewTransactTime(time.Now()))
+				message.Body.Set(field.NewOrdType(ordType))
+				message.Body.Set(field.NewSymbol(optionSymbol))
+				message.Body.Set(field.NewOrderQty(utils.QuickFixDecimal(appDict, tag.OrderQty, optionSymbol, decimal.NewFromInt(optionQuantity))))
+				message.Body.Set(field.NewTimeInForce(expiry))
+				if ordType != enum.OrdType_MARKET {
+					message.Body.Set(field.NewPrice(utils.QuickFixDecimal(appDict, tag.Price, optionSymbol, decimal.NewFromFloat(optionPrice))))
+				}
+				return message, nil
+			})
+			if err != nil {
+				return err
+			}
+
+			s.orders[clOrdID] = &order{
+				ClOrdID:  clOrdID,
+				Session:  s.current,
+				Side:     side,
+				Symbol:   optionSymbol,
+				Quantity: optionQuantity,
+			}
+			s.printf("Order %s sent\n", clOrdID)
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&optionID, "id", "", "Order id (uuid autogenerated if not given)")
+	cmd.Flags().StringVar(&optionSide, "side", "", "Order side (buy, sell ... etc)")
+	cmd.Flags().StringVar(&optionType, "type", "", "Order type (market, limit, stop ... etc)")
+	cmd.Flags().StringVar(&optionSymbol, "symbol", "", "Order symbol")
+	cmd.Flags().Int64Var(&optionQuantity, "quantity", 1, "Order quantity")
+	cmd.Flags().StringVar(&optionExpiry, "expiry", "day", "Order expiry (day, good_till_cancel ... etc)")
+	cmd.Flags().Float64Var(&optionPrice, "price", 0.0, "Order price")
+
+	cmd.RegisterFlagCompletionFunc("side", complete.OrderSide)
+	cmd.RegisterFlagCompletionFunc("type", complete.OrderType)
+	cmd.RegisterFlagCompletionFunc("expiry", complete.OrderTimeInForce)
+
+	return cmd
+}
+
+func (s *shell) newCancelCommand() *cobra.Command {
+	var optionID string
+
+	cmd := &cobra.Command{
+		Use:   "cancel <ClOrdID>",
+		Short: "Cancel an order sent from the shell",
+		Args:  cobra.ExactArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return s.orderIDs(), cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o, ok := s.orders[args[0]]
+			if !ok {
+				return fmt.Errorf("%w: unknown order %s", errors.Options, args[0])
+			}
+			if o.Session != s.current {
+				return fmt.Errorf("%w: order %s has been sent on session %s", errors.Options, o.ClOrdID, o.Session)
+			}
+
+			clOrdID := optionID
+			if len(clOrdID) == 0 {
+				clOrdID = uuid.NewString()
+			}
+
+			err := s.send(func(session config.Session, appDict *datadictionary.DataDictionary) (*quickfix.Message, error) {
+				message := quickfix.NewMessage()
+				message.Header.Set(field.NewMsgType(enum.MsgType_ORDER_CANCEL_REQUEST))
+				message.Body.Set(field.NewClOrdID(clOrdID))
+				message.Body.Set(field.NewOrigClOrdID(o.ClOrdID))
+				message.Body.Set(field.NewSide(o.Side))
+				message.Body.Set(field.NewSymbol(o.Symbol))
+				message.Body.Set(field.NewOrderQty(utils.QuickFixDecimal(appDict, tag.OrderQty, o.Symbol, decimal.NewFromInt(o.Quantity))))
+				message.Body.Set(field.NewTransactTime(time.Now()))
+				return message, nil
+			})
+			if err != nil {
+				return err
+			}
+
+			s.printf("Cancel %s of order %s sent\n", clOrdID, o.ClOrdID)
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&optionID, "id", "", "Cancel request id (uuid autogenerated if not given)")
+
+	return cmd
+}
+
+func (s *shell) orderIDs() []string {
+	ids := make([]string, 0, len(s.orders))
+	for id := range s.orders {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	return ids
+}
+
+func (s *shell) newOrdersCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "orders",
+		Short: "List the orders sent from the shell",
+		Args:  cobra.ExactArgs(0),
+		Run: func(cmd *cobra.Command, args []string) {
+			table := newTable(cmd, []string{"CLORDID", "SESSION", "SIDE", "SYMBOL", "QUANTITY"})
+			for _, id := range s.orderIDs() {
+				o := s.orders[id]
+				side, _ := dict.SearchValue(dict.OrderSides, o.Side)
+				table.Append([]string{o.ClOrdID, o.Session, strings.ToLower(side), o.Symbol, fmt.Sprint(o.Quantity)})
+			}
+			table.Render()
+		},
+	}
+}
+
+func (s *shell) newMarketDataCommand() *cobra.Command {
+	var optionSymbols, optionTypes []string
+	var optionDepth int
+
+	cmd := &cobra.Command{
+		Use:   "md",
+		Short: "Market data subscriptions",
+	}
+
+	subscribe := &cobra.Command{
+		Use:   "subscribe",
+		Short: "Subscribe to market data snapshot plus updates",
+		Args:  cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(optionSymbols) == 0 {
+				return errors.OptionsNoSymbolGiven
+			}
+			types := optionTypes
+			if len(types) == 0 {
+				types = []string{"bid", "offer"}
+			}
+			for _, t := range types {
+				if _, ok := dict.MDEntryTypes[strings.ToUpper(t)]; !ok {
+					return fmt.Errorf("%w: unknown market data entry type %s", errors.Options, t)
+				}
+			}
+
+			sub := &subscription{
+				MDReqID: uuid.NewString(),
+				Session: s.current,
+				Symbols: append([]string(nil), optionSymbols...),
+				Types:   types,
+				Depth:   optionDepth,
+			}
+
+			err := s.send(func(session config.Session, appDict *datadictionary.DataDictionary) (*quickfix.Message, error) {
+				return buildMarketDataRequest(sub, enum.SubscriptionRequestType_SNAPSHOT_PLUS_UPDATES), nil
+			})
+			if err != nil {
+				return err
+			}
+
+			s.subscriptions[sub.MDReqID] = sub
+			s.printf("Subscription %s sent\n", sub.MDReqID)
+
+			return nil
+		},
+	}
+	subscribe.Flags().StringSliceVar(&optionSymbols, "symbol", nil, "Symbols to subscribe to")
+	subscribe.Flags().StringSliceVar(&optionTypes, "types", nil, "Market data entry types (default bid,offer)")
+	subscribe.Flags().IntVar(&optionDepth, "depth", 1, "Market depth (0 for full book)")
+	subscribe.RegisterFlagCompletionFunc("types", complete.MDEntryTypes)
+
+	unsubscribe := &cobra.Command{
+		Use:   "unsubscribe <MDReqID>",
+		Short: "Cancel a market data subscription",
+		Args:  cobra.ExactArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			ids := make([]string, 0, len(s.subscriptions))
+			for id := range s.subscriptions {
+				ids = append(ids, id)
+			}
+			sort.Strings(ids)
+			return ids, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sub, ok := s.subscriptions[args[0]]
+			if !ok {
+				return fmt.Errorf("%w: unknown subscription %s", errors.Options, args[0])
+			}
+			if sub.Session != s.current {
+				return fmt.Errorf("%w: subscription %s has been made on session %s", errors.Options, sub.MDReqID, sub.Session)
+			}
+
+			err := s.send(func(session config.Session, appDict *datadictionary.DataDictionary) (*quickfix.Message, error) {
+				return buildMarketDataRequest(sub, enum.SubscriptionRequestType_DISABLE_PREVIOUS_SNAPSHOT_PLUS_UPDATE_REQUEST), nil
+			})
+			if err != nil {
+				return err
+			}
+
+			delete(s.subscriptions, sub.MDReqID)
+			s.printf("Unsubscription %s sent\n", sub.MDReqID)
+
+			return nil
+		},
+	}
+
+	cmd.AddCommand(subscribe)
+	cmd.AddCommand(unsubscribe)
+
+	return cmd
+}
+
+func buildMarketDataRequest(sub *subscription, subType enum.SubscriptionRequestType) *quickfix.Message {
+	message := quickfix.NewMessage()
+	header := fixt11.NewHeader(&message.Header)
+	header.Set(field.NewMsgType(enum.MsgType_MARKET_DATA_REQUEST))
+	message.Body.Set(field.NewMDReqID(sub.MDReqID))
+	message.Body.Set(field.NewSubscriptionRequestType(subType))
+	message.Body.Set(field.NewMarketDepth(sub.Depth))
+	message.Body.Set(field.NewMDUpdateType(enum.MDUpdateType_INCREMENTAL_REFRESH))
+
+	entryTypes := quickfix.NewRepeatingGroup(
+		tag.NoMDEntryTypes,
+		quickfix.GroupTemplate{
+			quickfix.GroupElement(tag.MDEntryType),
+		},
+	)
+	for _, t := range sub.Types {
+		entryTypes.Add().Set(field.NewMDEntryType(dict.MDEntryTypes[strings.ToUpper(t)]))
+	}
+	message.Body.SetGroup(entryTypes)
+
+	relatedSym := quickfix.NewRepeatingGroup(
+		tag.NoRelatedSym,
+		quickfix.GroupTemplate{
+			quickfix.GroupElement(tag.Symbol),
+		},
+	)
+	for _, sym := range sub.Symbols {
+		relatedSym.Add().Set(field.NewSymbol(sym))
+	}
+	message.Body.SetGroup(relatedSym)
+
+	return message
+}
+
+func (s *shell) newSecurityCommand() *cobra.Command {
+	var optionType string
+
+	cmd := &cobra.Command{
+		Use:   "security",
+		Short: "Security requests",
+	}
+
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "Send a security list request",
+		Args:  cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return s.send(func(session config.Session, appDict *datadictionary.DataDictionary) (*quickfix.Message, error) {
+				return application.BuildSecurityListRequestFix50Sp2Message(strings.ToUpper(optionType))
+			})
+		},
+	}
+	list.Flags().StringVar(&optionType, "type", "all_securities", "Security list request type")
+	list.RegisterFlagCompletionFunc("type", complete.SecurityListRequestType)
+
+	cmd.AddCommand(list)
+
+	return cmd
+}
+
+func (s *shell) newSessionCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "session [name]",
+		Short: "Print the sessions or switch to the given one",
+		Args:  cobra.MaximumNArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			var names []string
+			for _, app := range s.sessions.Apps {
+				names = append(names, app.Name)
+			}
+			return names, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 1 {
+				if _, ok := s.sessions.Get(args[0]); !ok {
+					return fmt.Errorf("%w: %s", errors.ConfigSessionNotInContext, args[0])
+				}
+				s.mu.Lock()
+				s.current = args[0]
+				s.mu.Unlock()
+				return nil
+			}
+
+			table := newTable(cmd, []string{"", "SESSION", "SESSION ID"})
+			for _, app := range s.sessions.Apps {
+				current := ""
+				if app.Name == s.current {
+					current = "*"
+				}
+				s.mu.Lock()
+				sessionID := s.sessionIDs[app.Name]
+				s.mu.Unlock()
+				table.Append([]string{current, app.Name, sessionID.String()})
+			}
+			table.Render()
+
+			return nil
+		},
+	}
+}
+
+func newTable(cmd *cobra.Command, header []string) *tablewriter.Table {
+	table := tablewriter.NewWriter(cmd.OutOrStdout())
+	table.SetHeader(header)
+	table.SetBorders(tablewriter.Border{Left: false, Top: false, Right: false, Bottom: true})
+	table.SetColumnSeparator(" ")
+	table.SetCenterSeparator("-")
+	table.SetAutoWrapText(false)
+
+	return table
+}
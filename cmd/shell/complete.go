@@ -0,0 +1,100 @@
+package shell
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// complete is the terminal callback completing the word under the cursor on
+// tab: commands, flags, and flag and argument values using the same
+// completion functions as the command line.
+func (s *shell) complete(line string, pos int, key rune) (string, int, bool) {
+	if key != '\t' {
+		return "", 0, false
+	}
+
+	prefix := line[:pos]
+	args := strings.Fields(prefix)
+	toComplete := ""
+	if len(args) > 0 && !strings.HasSuffix(prefix, " ") {
+		toComplete = args[len(args)-1]
+		args = args[:len(args)-1]
+	}
+
+	var matches []string
+	for _, candidate := range s.candidates(args, toComplete) {
+		if strings.HasPrefix(candidate, toComplete) {
+			matches = append(matches, candidate)
+		}
+	}
+	if len(matches) == 0 {
+		return "", 0, false
+	}
+
+	completion := commonPrefix(matches)
+	if len(matches) == 1 {
+		completion += " "
+	}
+	if completion == toComplete {
+		return "", 0, false
+	}
+
+	newPrefix := prefix[:len(prefix)-len(toComplete)] + completion
+
+	return newPrefix + line[pos:], len(newPrefix), true
+}
+
+func (s *shell) candidates(args []string, toComplete string) []string {
+	cmd, _, err := s.root.Find(args)
+	if err != nil {
+		return nil
+	}
+
+	var candidates []string
+
+	// Value of a flag
+	if len(args) > 0 && strings.HasPrefix(args[len(args)-1], "--") {
+		name := strings.TrimPrefix(args[len(args)-1], "--")
+		if flag := cmd.Flags().Lookup(name); flag != nil && flag.NoOptDefVal == "" {
+			if fn, ok := cmd.GetFlagCompletionFunc(name); ok {
+				candidates, _ = fn(cmd, nil, toComplete)
+			}
+			return candidates
+		}
+	}
+
+	if strings.HasPrefix(toComplete, "-") {
+		cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+			if !flag.Hidden && flag.Name != "help" {
+				candidates = append(candidates, "--"+flag.Name)
+			}
+		})
+		sort.Strings(candidates)
+		return candidates
+	}
+
+	for _, c := range cmd.Commands() {
+		if c.IsAvailableCommand() {
+			candidates = append(candidates, c.Name())
+		}
+	}
+	if cmd.ValidArgsFunction != nil {
+		values, _ := cmd.ValidArgsFunction(cmd, nil, toComplete)
+		candidates = append(candidates, values...)
+	}
+
+	return candidates
+}
+
+func commonPrefix(values []string) string {
+	prefix := values[0]
+	for _, v := range values[1:] {
+		for !strings.HasPrefix(v, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+
+	return prefix
+}
@@ -0,0 +1,361 @@
+package shell
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/quickfixgo/quickfix"
+
+	"sylr.dev/fix/config"
+	"sylr.dev/fix/pkg/errors"
+	"sylr.dev/fix/pkg/initiator"
+	"sylr.dev/fix/pkg/initiator/application"
+	"sylr.dev/fix/pkg/utils"
+)
+
+var ShellCmd = &cobra.Command{
+	Use:   "shell",
+	Short: "Interactive FIX shell",
+	Long: "Log on the sessions of the context once and send orders, cancels, market data subscriptions " +
+		"and security list requests from an interactive prompt with history and completions. " +
+		"Messages received are printed as they arrive. Commands can also be piped on stdin.",
+	Example:           "  fix shell --context venue",
+	Args:              cobra.ExactArgs(0),
+	ValidArgsFunction: cobra.NoFileCompletions,
+	Annotations: map[string]string{
+		initiator.MultiSessionsAnnotation: "true",
+	},
+	PersistentPreRunE: utils.MakePersistentPreRunE(initiator.ValidateOptions),
+	RunE:              Execute,
+}
+
+func init() {
+	initiator.AddPersistentFlags(ShellCmd)
+	initiator.AddPersistentFlagCompletions(ShellCmd)
+}
+
+// shell holds the sessions of the context and the state of the commands.
+type shell struct {
+	sessions   *initiator.Sessions[*application.Initiator]
+	sessionIDs map[string]quickfix.SessionID
+	current    string
+	mu         sync.Mutex
+
+	out     io.Writer
+	outMu   sync.Mutex
+	root    *cobra.Command
+	exiting bool
+
+	orders        map[string]*order
+	subscriptions map[string]*subscription
+}
+
+func Execute(cmd *cobra.Command, args []string) error {
+	options := config.GetOptions()
+
+	context, err := config.GetCurrentContext()
+	if err != nil {
+		return err
+	}
+
+	initiatorConfig, err := context.GetInitiator()
+	if err != nil {
+		return err
+	}
+
+	// In a terminal, lines must be written through it so that the prompt is
+	// redrawn after the messages received.
+	var terminal *term.Terminal
+	var out io.Writer = os.Stdout
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		state, err := term.MakeRaw(int(os.Stdin.Fd()))
+		if err != nil {
+			return err
+		}
+		defer term.Restore(int(os.Stdin.Fd()), state)
+
+		terminal = term.NewTerminal(struct {
+			io.Reader
+			io.Writer
+		}{os.Stdin, os.Stdout}, "")
+		if width, height, err := term.GetSize(int(os.Stdin.Fd())); err == nil {
+			terminal.SetSize(width, height)
+		}
+		out = terminal
+	}
+
+	s := &shell{
+		out:           out,
+		sessionIDs:    make(map[string]quickfix.SessionID),
+		orders:        make(map[string]*order),
+		subscriptions: make(map[string]*subscription),
+	}
+	s.root = s.newCommands()
+
+	consoleWriter := zerolog.ConsoleWriter{
+		Out:        out,
+		NoColor:    !utils.ColorOutput,
+		TimeFormat: "Jan 2 15:04:05.000-0700",
+	}
+	logger := zerolog.New(consoleWriter).With().Timestamp().Logger().Level(config.GetLogger().GetLevel())
+
+	var quickfixLogger *zerolog.Logger
+	if options.QuickFixLogging {
+		quickfixLogger = &logger
+	}
+
+	s.sessions, err = initiator.InitiateContext(context, quickfixLogger, func(session *config.Session, settings *quickfix.Settings) (*application.Initiator, error) {
+		transportDict, appDict, err := session.GetFIXDictionaries()
+		if err != nil {
+			return nil, err
+		}
+
+		sessionLogger := logger.With().Str("session", session.Name).Logger()
+		if len(session.LogLevel) > 0 && options.Verbose == 0 && !options.Quiet {
+			if level, err := zerolog.ParseLevel(session.LogLevel); err == nil {
+				sessionLogger = sessionLogger.Level(level)
+			}
+		}
+
+		app := application.NewInitiator()
+		app.Settings = settings
+		app.TransportDataDictionary = transportDict
+		app.AppDataDictionary = appDict
+		app.OutputFormat = options.Output
+		app.Logger = &sessionLogger
+
+		return app, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	connected := initiator.Multiplex(s.sessions, func(app *application.Initiator) chan quickfix.SessionID { return app.Connected })
+	fromAppMessages := initiator.Multiplex(s.sessions, func(app *application.Initiator) chan *quickfix.Message { return app.FromAppMessages })
+	toAppMessages := initiator.Multiplex(s.sessions, func(app *application.Initiator) chan *quickfix.Message { return app.ToAppMessages })
+
+	if err = s.sessions.Start(); err != nil {
+		return err
+	}
+
+	defer s.sessions.Stop()
+
+	// Choose right timeout cli option > config > default value (5s)
+	var timeout time.Duration
+	if options.Timeout != time.Duration(0) {
+		timeout = options.Timeout
+	} else if initiatorConfig.SocketTimeout != time.Duration(0) {
+		timeout = initiatorConfig.SocketTimeout
+	} else {
+		timeout = 5 * time.Second
+	}
+
+	// Wait for all sessions to log on
+	waitTimeout := time.After(timeout)
+	for n := 0; n < len(s.sessions.Apps); n++ {
+		select {
+		case <-waitTimeout:
+			return errors.ConnectionTimeout
+		case sessionID, ok := <-connected:
+			if !ok {
+				return errors.FixLogout
+			}
+			s.setSessionID(sessionID.Session, sessionID.Value)
+		}
+	}
+	s.current = s.sessions.Apps[0].Name
+
+	// Messages sent are already logged
+	go func() {
+		for range toAppMessages {
+		}
+	}()
+
+	logout := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case sessionID, ok := <-connected:
+				if ok {
+					s.setSessionID(sessionID.Session, sessionID.Value)
+					s.printf("Session %s reconnected\n", sessionID.Session)
+				}
+			case message, ok := <-fromAppMessages:
+				if !ok {
+					close(logout)
+					return
+				}
+				s.printMessage(message.Session, message.Value)
+			}
+		}
+	}()
+
+	// Lines are read one at a time, the next one being read once the
+	// previous one has been run so that the prompt is up to date.
+	lines := make(chan string)
+	done := make(chan struct{})
+	readErr := make(chan error, 1)
+	go func() {
+		defer close(lines)
+		if terminal != nil {
+			terminal.AutoCompleteCallback = s.complete
+			for {
+				terminal.SetPrompt(s.prompt())
+				line, err := terminal.ReadLine()
+				if err != nil {
+					if err != io.EOF {
+						readErr <- err
+					}
+					return
+				}
+				lines <- line
+				<-done
+			}
+		}
+
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+			<-done
+		}
+		if err := scanner.Err(); err != nil {
+			readErr <- err
+		}
+	}()
+
+	ctx := cmd.Context()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-logout:
+			return errors.FixLogout
+		case err := <-readErr:
+			return err
+		case line, ok := <-lines:
+			if !ok {
+				return nil
+			}
+			s.run(line)
+			if s.exiting {
+				return nil
+			}
+			done <- struct{}{}
+		}
+	}
+}
+
+func (s *shell) setSessionID(session string, sessionID quickfix.SessionID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sessionIDs[session] = sessionID
+}
+
+// currentSession returns the session the commands are sent on.
+func (s *shell) currentSession() (*initiator.SessionApp[*application.Initiator], quickfix.SessionID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	app, _ := s.sessions.Get(s.current)
+
+	return app, s.sessionIDs[s.current]
+}
+
+func (s *shell) prompt() string {
+	return fmt.Sprintf("fix(%s)> ", s.current)
+}
+
+// printf writes to the shell output, serialized with the messages received.
+func (s *shell) printf(format string, a ...any) {
+	s.outMu.Lock()
+	defer s.outMu.Unlock()
+
+	fmt.Fprintf(s.out, format, a...)
+}
+
+func (s *shell) printMessage(session string, message *quickfix.Message) {
+	app, ok := s.sessions.Get(session)
+	if !ok {
+		return
+	}
+
+	msgType, _ := message.MsgType()
+	name := msgType
+	if appDict := app.App.AppDataDictionary; appDict != nil {
+		if def, ok := appDict.Messages[msgType]; ok {
+			name = def.Name
+		}
+	}
+
+	s.outMu.Lock()
+	defer s.outMu.Unlock()
+
+	fmt.Fprintf(s.out, "<- [%s] %s\n", session, name)
+	app.App.WriteMessage(s.out, message)
+}
+
+// run executes a line of the shell.
+func (s *shell) run(line string) {
+	args, err := splitArgs(line)
+	if err != nil {
+		s.printf("Error: %s\n", err)
+		return
+	}
+	if len(args) == 0 {
+		return
+	}
+
+	resetFlags(s.root)
+	s.root.SetArgs(args)
+	if err := s.root.Execute(); err != nil {
+		s.printf("Error: %s\n", err)
+	}
+}
+
+// splitArgs splits a line into arguments, honoring single and double quotes.
+func splitArgs(line string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	var quote rune
+	inArg := false
+
+	for _, r := range line {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			current.WriteRune(r)
+		case r == '\'' || r == '"':
+			quote, inArg = r, true
+		case r == ' ' || r == '\t':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("%w: unterminated quote", errors.Options)
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+
+	return args, nil
+}
@@ -3,8 +3,6 @@ package status_order
 import (
 	"fmt"
 	"os"
-	"os/signal"
-	"syscall"
 	"time"
 
 	"github.com/google/uuid"
@@ -24,6 +22,7 @@ import (
 	"sylr.dev/fix/pkg/errors"
 	"sylr.dev/fix/pkg/initiator"
 	"sylr.dev/fix/pkg/initiator/application"
+	"sylr.dev/fix/pkg/utils"
 )
 
 var (
@@ -78,7 +77,7 @@ func init() {
 	StatusOrderCmd.RegisterFlagCompletionFunc("side", complete.OrderSide)
 	StatusOrderCmd.RegisterFlagCompletionFunc("type", complete.OrderType)
 	StatusOrderCmd.RegisterFlagCompletionFunc("expiry", complete.OrderTimeInForce)
-	StatusOrderCmd.RegisterFlagCompletionFunc("symbol", cobra.NoFileCompletions)
+	StatusOrderCmd.RegisterFlagCompletionFunc("symbol", complete.Symbol)
 }
 
 func Validate(cmd *cobra.Command, args []string) error {
@@ -104,6 +103,7 @@ func Execute(cmd *cobra.Command, args []string) error {
 	}
 
 	session := sessions[0]
+	logger = session.GetLogger()
 	initiatorConfig, err := context.GetInitiator()
 	if err != nil {
 		return err
@@ -124,6 +124,8 @@ func Execute(cmd *cobra.Command, args []string) error {
 	app.Settings = settings
 	app.TransportDataDictionary = transportDict
 	app.AppDataDictionary = appDict
+	app.OutputFormat = options.Output
+	app.Resumable = initiatorConfig.Reconnect.OnDisconnect
 
 	var quickfixLogger *zerolog.Logger
 	if options.QuickFixLogging {
@@ -140,13 +142,9 @@ func Execute(cmd *cobra.Command, args []string) error {
 		timeout = 5 * time.Second
 	}
 
-	init, err := initiator.Initiate(app, settings, quickfixLogger)
+	init, sessionId, err := initiator.Connect(cmd.Context(), app, settings, quickfixLogger, app.Connected, timeout, initiatorConfig.Reconnect)
 	if err != nil {
-		return err
-	}
-
-	// Start session
-	if err = init.Start(); err != nil {
+		app.Stop()
 		return err
 	}
 
@@ -155,18 +153,6 @@ func Execute(cmd *cobra.Command, args []string) error {
 		init.Stop()
 	}()
 
-	// Wait for session connection
-	var sessionId quickfix.SessionID
-	var ok bool
-	select {
-	case <-time.After(timeout):
-		return errors.ConnectionTimeout
-	case sessionId, ok = <-app.Connected:
-		if !ok {
-			return errors.FixLogout
-		}
-	}
-
 	// Prepare order
 	order, err := buildMessage(*session)
 	if err != nil {
@@ -179,8 +165,9 @@ func Execute(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	interrupt := make(chan os.Signal, 1)
-	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	ids := []string{optionOrigClOrdID, optionOrderID}
+
+	ctx := cmd.Context()
 
 	var waitTimeout <-chan time.Time
 	if optionExecReportsTimeout > 0 {
@@ -192,19 +179,29 @@ func Execute(cmd *cobra.Command, args []string) error {
 LOOP:
 	for {
 		select {
-		case signal := <-interrupt:
-			logger.Debug().Msgf("Received signal: %s", signal)
+		case <-ctx.Done():
+			logger.Debug().Msg("Interrupted")
 			break LOOP
 
 		case <-waitTimeout:
-			logger.Warn().Msgf("Timeout while expecting execution report")
-			break LOOP
+			return fmt.Errorf("%w: expecting execution report", errors.ResponseTimeout)
+
+		case <-app.Disconnected:
+			sessionId, err = initiator.Reconnect(cmd.Context(), app.Connected, app.Disconnected, timeout, initiatorConfig.Reconnect)
+			if err != nil {
+				return err
+			}
 
 		case msg, ok := <-app.FromAppMessages:
 			if !ok {
 				break LOOP
 			}
 
+			// Ignore the responses to other requests
+			if !utils.QuickFixMessageCorrelates(msg, ids...) {
+				continue LOOP
+			}
+
 			if err := processResponse(app, msg); err != nil {
 				if errors.Is(err, quickfix.InvalidMessageType()) {
 					continue LOOP
@@ -281,9 +278,9 @@ func processResponse(app *application.NewOrder, msg *quickfix.Message) error {
 	err := msg.Header.GetField(tag.MsgType, &msgType)
 	if err != nil {
 		return err
-	} else if msgType.Value() == enum.MsgType_REJECT ||
-		msgType.Value() == enum.MsgType_BUSINESS_MESSAGE_REJECT ||
-		msgType.Value() == enum.MsgType_ORDER_CANCEL_REJECT {
+	} else if err := utils.QuickFixRejectError(msg, errors.FixOrderRejected); err != nil {
+		return err
+	} else if msgType.Value() == enum.MsgType_ORDER_CANCEL_REJECT {
 		return makeError(errors.FixOrderRejected)
 	} else if msgType.Value() != enum.MsgType_EXECUTION_REPORT {
 		return quickfix.InvalidMessageType()
@@ -295,6 +292,6 @@ func processResponse(app *application.NewOrder, msg *quickfix.Message) error {
 		return err
 	}
 
-	app.WriteMessageBodyAsTable(os.Stdout, msg)
+	app.WriteMessage(os.Stdout, msg)
 	return nil
 }
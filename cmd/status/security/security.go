@@ -3,9 +3,7 @@ package status_security
 import (
 	"fmt"
 	"os"
-	"os/signal"
 	"strings"
-	"syscall"
 	"time"
 
 	"github.com/google/uuid"
@@ -34,6 +32,8 @@ var (
 	SubType enum.SubscriptionRequestType
 )
 
+var errSecurityStatusRejected = fmt.Errorf("%w: security status request rejected", errors.Fix)
+
 var StatusSecurityCmd = &cobra.Command{
 	Use:               "security",
 	Short:             "security status",
@@ -66,6 +66,7 @@ func init() {
 	StatusSecurityCmd.Flags().StringVar(&optionSubType, "subscription-type", "snapshot", "Subscription type")
 	StatusSecurityCmd.Flags().StringVar(&optionSecurityStatReqID, "security-status-request-id", uuid.NewString(), "Security Status Request id")
 	StatusSecurityCmd.RegisterFlagCompletionFunc("subscription-type", complete.SubscriptionRequestTypes)
+	StatusSecurityCmd.RegisterFlagCompletionFunc("symbol", complete.Symbol)
 }
 
 func Validate(cmd *cobra.Command, args []string) error {
@@ -100,6 +101,7 @@ func Execute(cmd *cobra.Command, args []string) error {
 	}
 
 	session := sessions[0]
+	logger = session.GetLogger()
 	initiatior, err := context.GetInitiator()
 	if err != nil {
 		return err
@@ -120,27 +122,13 @@ func Execute(cmd *cobra.Command, args []string) error {
 	app.Settings = settings
 	app.TransportDataDictionary = transportDict
 	app.AppDataDictionary = appDict
+	app.OutputFormat = options.Output
 
 	var quickfixLogger *zerolog.Logger
 	if options.QuickFixLogging {
 		quickfixLogger = logger
 	}
 
-	init, err := initiator.Initiate(app, settings, quickfixLogger)
-	if err != nil {
-		return err
-	}
-
-	// Start session
-	if err = init.Start(); err != nil {
-		return err
-	}
-
-	defer func() {
-		app.Stop()
-		init.Stop()
-	}()
-
 	// Choose right timeout cli option > config > default value (5s)
 	var timeout time.Duration
 	if options.Timeout != time.Duration(0) {
@@ -151,18 +139,17 @@ func Execute(cmd *cobra.Command, args []string) error {
 		timeout = 5 * time.Second
 	}
 
-	// Wait for session connection
-	var sessionId quickfix.SessionID
-	var ok bool
-	select {
-	case <-time.After(timeout):
-		return errors.ConnectionTimeout
-	case sessionId, ok = <-app.Connected:
-		if !ok {
-			return errors.FixLogout
-		}
+	init, sessionId, err := initiator.Connect(cmd.Context(), app, settings, quickfixLogger, app.Connected, timeout, initiatior.Reconnect)
+	if err != nil {
+		app.Stop()
+		return err
 	}
 
+	defer func() {
+		app.Stop()
+		init.Stop()
+	}()
+
 	// Prepare security Status Request
 	ssr, err := buildMessage()
 	if err != nil {
@@ -175,14 +162,13 @@ func Execute(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	interrupt := make(chan os.Signal, 1)
-	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	ctx := cmd.Context()
 
 LOOP:
 	for {
 		select {
-		case signal := <-interrupt:
-			logger.Debug().Msgf("Received signal: %s", signal)
+		case <-ctx.Done():
+			logger.Debug().Msg("Interrupted")
 
 			break LOOP
 
@@ -191,7 +177,11 @@ LOOP:
 				break LOOP
 			}
 
-			app.WriteMessageBodyAsTable(os.Stdout, responseMessage)
+			app.WriteMessage(os.Stdout, responseMessage)
+
+			if err := utils.QuickFixRejectError(responseMessage, errSecurityStatusRejected); err != nil {
+				return err
+			}
 
 			if SubType != enum.SubscriptionRequestType_SNAPSHOT_PLUS_UPDATES {
 				break LOOP
@@ -1,11 +1,13 @@
 package status_tradingsession
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/rs/zerolog"
 	"github.com/spf13/cobra"
 
@@ -23,14 +25,21 @@ import (
 	"sylr.dev/fix/pkg/utils"
 )
 
+// tagTradSesReqID is missing from the tag package.
+const tagTradSesReqID quickfix.Tag = 335
+
 var (
 	optionSubType string
 )
 
+var errTradingSessionStatusRejected = fmt.Errorf("%w: trading session status request rejected", errors.Fix)
+
 var StatusTradingSessionCmd = &cobra.Command{
-	Use:               "tradingsession",
-	Short:             "trading session status",
-	Long:              "Send a Trading Session Status Request after initiating a session with a FIX acceptor.",
+	Use:   "tradingsession",
+	Short: "trading session status",
+	Long: "Send a Trading Session Status Request after initiating a session with a FIX acceptor.\n\n" +
+		"With --subscription-type snapshot_plus_updates, the session is kept open and each Trading Session " +
+		"Status received is printed until interrupted, the subscription being disabled before logging out.",
 	Args:              cobra.ExactArgs(0),
 	ValidArgsFunction: cobra.NoFileCompletions,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
@@ -83,6 +92,7 @@ func Execute(cmd *cobra.Command, args []string) error {
 	}
 
 	session := sessions[0]
+	logger = session.GetLogger()
 	initiatior, err := context.GetInitiator()
 	if err != nil {
 		return err
@@ -103,27 +113,13 @@ func Execute(cmd *cobra.Command, args []string) error {
 	app.Settings = settings
 	app.TransportDataDictionary = transportDict
 	app.AppDataDictionary = appDict
+	app.OutputFormat = options.Output
 
 	var quickfixLogger *zerolog.Logger
 	if options.QuickFixLogging {
 		quickfixLogger = logger
 	}
 
-	init, err := initiator.Initiate(app, settings, quickfixLogger)
-	if err != nil {
-		return err
-	}
-
-	// Start session
-	if err = init.Start(); err != nil {
-		return err
-	}
-
-	defer func() {
-		app.Stop()
-		init.Stop()
-	}()
-
 	// Choose right timeout cli option > config > default value (5s)
 	var timeout time.Duration
 	if options.Timeout != time.Duration(0) {
@@ -134,20 +130,21 @@ func Execute(cmd *cobra.Command, args []string) error {
 		timeout = 5 * time.Second
 	}
 
-	// Wait for session connection
-	var sessionId quickfix.SessionID
-	var ok bool
-	select {
-	case <-time.After(timeout):
-		return errors.ConnectionTimeout
-	case sessionId, ok = <-app.Connected:
-		if !ok {
-			return errors.FixLogout
-		}
+	init, sessionId, err := initiator.Connect(cmd.Context(), app, settings, quickfixLogger, app.Connected, timeout, initiatior.Reconnect)
+	if err != nil {
+		app.Stop()
+		return err
 	}
 
+	defer func() {
+		app.Stop()
+		init.Stop()
+	}()
+
+	reqID := uuid.NewString()
+
 	// Prepare Trading Session Status Request
-	tssr, err := buildMessage()
+	tssr, err := buildMessage(reqID, dict.SubscriptionRequestTypes[strings.ToUpper(optionSubType)])
 	if err != nil {
 		return err
 	}
@@ -158,8 +155,13 @@ func Execute(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if dict.SubscriptionRequestTypes[strings.ToUpper(optionSubType)] == enum.SubscriptionRequestType_SNAPSHOT_PLUS_UPDATES {
+		return stream(cmd.Context(), app, reqID, sessionId, timeout)
+	}
+
 	// Wait for the order response
 	var responseMessage *quickfix.Message
+	var ok bool
 
 	select {
 	case <-time.After(timeout):
@@ -171,18 +173,58 @@ func Execute(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	app.WriteMessageBodyAsTable(os.Stdout, responseMessage)
+	app.WriteMessage(os.Stdout, responseMessage)
 
-	return nil
+	return utils.QuickFixRejectError(responseMessage, errTradingSessionStatusRejected)
+}
+
+// stream prints the TradingSessionStatus messages of the subscription until
+// interrupted or logged out, and unsubscribes when interrupted.
+func stream(ctx context.Context, app *application.TradingSessionStatusRequest, reqID string, sessionID quickfix.SessionID, timeout time.Duration) error {
+	logger := app.Logger
+
+	// Only the first response is expected within the timeout
+	responseTimeout := time.After(timeout)
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Debug().Msg("Interrupted")
+
+			// Let the acceptor know we are no longer interested in the updates
+			unsubscribe, err := buildMessage(reqID, enum.SubscriptionRequestType_DISABLE_PREVIOUS_SNAPSHOT_PLUS_UPDATE_REQUEST)
+			if err != nil {
+				return err
+			}
+
+			return quickfix.SendToTarget(unsubscribe, sessionID)
+
+		case <-responseTimeout:
+			return errors.ResponseTimeout
+
+		case message, ok := <-app.FromAppMessages:
+			if !ok {
+				return errors.FixLogout
+			}
+			responseTimeout = nil
+
+			app.WriteMessage(os.Stdout, message)
+
+			if err := utils.QuickFixRejectError(message, errTradingSessionStatusRejected); err != nil {
+				return err
+			}
+		}
+	}
 }
 
-func buildMessage() (quickfix.Messagable, error) {
+func buildMessage(reqID string, subType enum.SubscriptionRequestType) (quickfix.Messagable, error) {
 	// Message
 	message := quickfix.NewMessage()
 	header := fixt11.NewHeader(&message.Header)
 	header.Set(field.NewMsgType(enum.MsgType_TRADING_SESSION_STATUS_REQUEST))
 
-	utils.QuickFixMessagePartSetString(&message.Body, dict.SubscriptionRequestTypes[strings.ToUpper(optionSubType)], field.NewSubscriptionRequestType)
+	message.Body.SetString(tagTradSesReqID, reqID)
+	utils.QuickFixMessagePartSetString(&message.Body, subType, field.NewSubscriptionRequestType)
 
 	return message, nil
 }
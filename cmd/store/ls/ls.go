@@ -0,0 +1,56 @@
+package ls
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+
+	"sylr.dev/fix/pkg/store"
+	"sylr.dev/fix/pkg/utils"
+)
+
+var StoreLsCmd = &cobra.Command{
+	Use:               "ls",
+	Short:             "List the sessions stores",
+	Long:              "List the sessions of the context along with the sequence numbers and creation time of their stores.",
+	Example:           "  fix store ls --context acceptor",
+	Args:              cobra.ExactArgs(0),
+	ValidArgsFunction: cobra.NoFileCompletions,
+	RunE:              Execute,
+}
+
+func Execute(cmd *cobra.Command, args []string) error {
+	sessions, err := store.SelectedSessions()
+	if err != nil {
+		return err
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"SESSION", "SESSION ID", "NEXT SENDER SEQNUM", "NEXT TARGET SEQNUM", "CREATION TIME"})
+	table.SetBorders(tablewriter.Border{Left: false, Top: false, Right: false, Bottom: true})
+	table.SetColumnSeparator(" ")
+	table.SetCenterSeparator("-")
+
+	for _, session := range sessions {
+		s, err := session.Open()
+		if err != nil {
+			return err
+		}
+
+		table.Append([]string{
+			session.Name,
+			session.SessionID.String(),
+			strconv.Itoa(s.NextSenderMsgSeqNum()),
+			strconv.Itoa(s.NextTargetMsgSeqNum()),
+			utils.FormatTime(s.CreationTime()),
+		})
+
+		s.Close()
+	}
+
+	table.Render()
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package seqnum
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	qconfig "github.com/quickfixgo/quickfix/config"
+	"github.com/spf13/cobra"
+
+	"sylr.dev/fix/config"
+	"sylr.dev/fix/pkg/errors"
+	"sylr.dev/fix/pkg/store"
+)
+
+var (
+	optionSender int
+	optionTarget int
+	optionForce  bool
+)
+
+var StoreSetSeqNumCmd = &cobra.Command{
+	Use:   "set-seqnum",
+	Short: "Set the next sequence numbers of a session store",
+	Long: "Set the next sender and/or target sequence numbers of a session store. " +
+		"The session must be down: the command refuses to run while the acceptor of the context " +
+		"accepts connections unless --force is given. Initiator sessions can't be checked.",
+	Example:           "  fix store set-seqnum --context acceptor --session client1 --sender 1 --target 1",
+	Args:              cobra.ExactArgs(0),
+	ValidArgsFunction: cobra.NoFileCompletions,
+	RunE:              Execute,
+}
+
+func init() {
+	StoreSetSeqNumCmd.Flags().IntVar(&optionSender, "sender", 0, "Next sequence number of the messages sent")
+	StoreSetSeqNumCmd.Flags().IntVar(&optionTarget, "target", 0, "Next sequence number expected from the counterparty")
+	StoreSetSeqNumCmd.Flags().BoolVar(&optionForce, "force", false, "Don't check that the session is down")
+}
+
+func Execute(cmd *cobra.Command, args []string) error {
+	logger := config.GetLogger()
+
+	if optionSender <= 0 && optionTarget <= 0 {
+		return fmt.Errorf("%w: --sender and/or --target must be given", errors.Options)
+	}
+
+	sessions, err := store.SelectedSessions()
+	if err != nil {
+		return err
+	}
+
+	if len(sessions) != 1 {
+		return fmt.Errorf("%w: use --session to select the session", errors.ConfigContextMultipleSessions)
+	}
+
+	session := sessions[0]
+
+	if !optionForce {
+		if session.Acceptor {
+			if err := checkAcceptorDown(session); err != nil {
+				return err
+			}
+		} else {
+			logger.Warn().Msgf("Can't check that initiator session %s is down", session.Name)
+		}
+	}
+
+	s, err := session.Open()
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	if optionSender > 0 {
+		if err := s.SetNextSenderMsgSeqNum(optionSender); err != nil {
+			return err
+		}
+		logger.Info().Msgf("Next sender seqnum of %s set to %d", session.Name, optionSender)
+	}
+
+	if optionTarget > 0 {
+		if err := s.SetNextTargetMsgSeqNum(optionTarget); err != nil {
+			return err
+		}
+		logger.Info().Msgf("Next target seqnum of %s set to %d", session.Name, optionTarget)
+	}
+
+	return nil
+}
+
+// checkAcceptorDown returns an error if the acceptor of the session accepts
+// connections.
+func checkAcceptorDown(session *store.Session) error {
+	settings, ok := session.Settings.SessionSettings()[session.SessionID]
+	if !ok || !settings.HasSetting(qconfig.SocketAcceptPort) {
+		return nil
+	}
+
+	host, _ := settings.Setting(qconfig.SocketAcceptHost)
+	port, err := settings.Setting(qconfig.SocketAcceptPort)
+	if err != nil {
+		return err
+	}
+
+	if len(host) == 0 || host == "0.0.0.0" {
+		host = "127.0.0.1"
+	}
+
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(host, port), time.Second)
+	if err != nil {
+		return nil
+	}
+	conn.Close()
+
+	return fmt.Errorf("%w: acceptor %s is running, stop it or use --force", errors.Options, net.JoinHostPort(host, port))
+}
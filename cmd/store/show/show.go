@@ -0,0 +1,118 @@
+package show
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/quickfixgo/quickfix"
+	"github.com/spf13/cobra"
+
+	"sylr.dev/fix/config"
+	"sylr.dev/fix/pkg/store"
+	"sylr.dev/fix/pkg/utils"
+)
+
+var (
+	optionFrom int
+	optionTo   int
+)
+
+var StoreShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show the sessions stores and their messages",
+	Long: "Show the sequence numbers and creation time of the sessions stores along with the messages they hold, " +
+		"which are the messages sent that can be resent to the counterparty.",
+	Example:           "  fix store show --context acceptor --session client1 --from 100 -o json",
+	Args:              cobra.ExactArgs(0),
+	ValidArgsFunction: cobra.NoFileCompletions,
+	RunE:              Execute,
+}
+
+func init() {
+	StoreShowCmd.Flags().IntVar(&optionFrom, "from", 1, "First sequence number of the messages to show")
+	StoreShowCmd.Flags().IntVar(&optionTo, "to", 0, "Last sequence number of the messages to show (defaults to the last one sent)")
+}
+
+func Execute(cmd *cobra.Command, args []string) error {
+	options := config.GetOptions()
+	logger := config.GetLogger()
+
+	sessions, err := store.SelectedSessions()
+	if err != nil {
+		return err
+	}
+
+	for _, session := range sessions {
+		transportDict, appDict, err := session.Session.GetFIXDictionaries()
+		if err != nil {
+			return err
+		}
+
+		app := utils.QuickFixAppMessageLogger{
+			Logger:                  logger,
+			TransportDataDictionary: transportDict,
+			AppDataDictionary:       appDict,
+			OutputFormat:            options.Output,
+		}
+
+		s, err := session.Open()
+		if err != nil {
+			return err
+		}
+
+		to := optionTo
+		if to == 0 {
+			to = s.NextSenderMsgSeqNum() - 1
+		}
+
+		messages, err := s.GetMessages(optionFrom, to)
+		creationTime := s.CreationTime()
+		nextSender, nextTarget := s.NextSenderMsgSeqNum(), s.NextTargetMsgSeqNum()
+		s.Close()
+		if err != nil {
+			return err
+		}
+
+		if options.Output != utils.OutputFormatTable {
+			for _, raw := range messages {
+				message := quickfix.NewMessage()
+				if err := quickfix.ParseMessage(message, bytes.NewBuffer(raw)); err != nil {
+					return err
+				}
+				app.WriteMessage(os.Stdout, message)
+			}
+			continue
+		}
+
+		fmt.Printf("Session:            %s (%s)\n", session.Name, session.SessionID)
+		fmt.Printf("Creation time:      %s\n", utils.FormatTime(creationTime))
+		fmt.Printf("Next sender seqnum: %d\n", nextSender)
+		fmt.Printf("Next target seqnum: %d\n\n", nextTarget)
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"SEQNUM", "TYPE", "MESSAGE"})
+		table.SetBorders(tablewriter.Border{Left: false, Top: false, Right: false, Bottom: true})
+		table.SetColumnSeparator(" ")
+		table.SetCenterSeparator("-")
+		table.SetAutoWrapText(false)
+
+		for _, raw := range messages {
+			message := quickfix.NewMessage()
+			if err := quickfix.ParseMessage(message, bytes.NewBuffer(raw)); err != nil {
+				return err
+			}
+			seqNum, _ := message.Header.GetInt(34)
+			msgType, _ := message.MsgType()
+			table.Append([]string{strconv.Itoa(seqNum), msgType, strings.ReplaceAll(string(raw), "\001", "|")})
+		}
+
+		table.Render()
+		fmt.Println()
+	}
+
+	return nil
+}
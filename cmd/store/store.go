@@ -0,0 +1,27 @@
+package store
+
+import (
+	"github.com/spf13/cobra"
+
+	"sylr.dev/fix/cmd/store/ls"
+	"sylr.dev/fix/cmd/store/seqnum"
+	"sylr.dev/fix/cmd/store/show"
+	"sylr.dev/fix/pkg/store"
+	"sylr.dev/fix/pkg/utils"
+)
+
+var StoreCmd = &cobra.Command{
+	Use:               "store",
+	Short:             "Inspect the sessions message stores",
+	Long:              "Inspect the file or SQL message stores of the sessions of a context.",
+	PersistentPreRunE: utils.MakePersistentPreRunE(store.ValidateOptions),
+}
+
+func init() {
+	store.AddPersistentFlags(StoreCmd)
+	store.AddPersistentFlagCompletions(StoreCmd)
+
+	StoreCmd.AddCommand(ls.StoreLsCmd)
+	StoreCmd.AddCommand(show.StoreShowCmd)
+	StoreCmd.AddCommand(seqnum.StoreSetSeqNumCmd)
+}
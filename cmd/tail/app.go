@@ -0,0 +1,113 @@
+package tail
+
+import (
+	"io"
+
+	"github.com/quickfixgo/quickfix"
+	"github.com/rs/zerolog"
+
+	"sylr.dev/fix/pkg/utils"
+)
+
+// tailApp is an initiator application which writes the messages received,
+// session ones included, matching its filters and survives the logouts of the
+// session.
+type tailApp struct {
+	utils.BaseApplication
+
+	Connected chan quickfix.SessionID
+	Out       io.Writer
+
+	// Admin tells whether to write the session level messages
+	Admin bool
+	// MsgTypes are the message types written, all of them if empty
+	MsgTypes []utils.QuickFixFieldMatcher
+	// Filters must all match the messages written
+	Filters []utils.QuickFixFieldMatcher
+}
+
+func newTailApp() *tailApp {
+	return &tailApp{
+		Connected: make(chan quickfix.SessionID, 1),
+	}
+}
+
+// Notification of a session begin created.
+func (app *tailApp) OnCreate(sessionID quickfix.SessionID) {
+	app.Logger.Debug().Msgf("New session: %s", sessionID)
+}
+
+// Notification of a session successfully logging on.
+func (app *tailApp) OnLogon(sessionID quickfix.SessionID) {
+	app.Logger.Info().Msgf("Logon: %s", sessionID)
+
+	select {
+	case app.Connected <- sessionID:
+	default:
+	}
+}
+
+// Notification of a session logging off or disconnecting.
+func (app *tailApp) OnLogout(sessionID quickfix.SessionID) {
+	app.Logger.Warn().Msgf("Logout: %s", sessionID)
+}
+
+// Notification of admin message being sent to target.
+func (app *tailApp) ToAdmin(message *quickfix.Message, sessionID quickfix.SessionID) {
+	app.PrepareLogon(message, sessionID)
+
+	app.LogMessage(zerolog.DebugLevel, message, sessionID, true)
+}
+
+// Notification of admin message being received from target.
+func (app *tailApp) FromAdmin(message *quickfix.Message, sessionID quickfix.SessionID) quickfix.MessageRejectError {
+	app.LogMessage(zerolog.DebugLevel, message, sessionID, false)
+
+	if app.Admin {
+		app.write(message)
+	}
+
+	return nil
+}
+
+// Notification of app message being sent to target.
+func (app *tailApp) ToApp(message *quickfix.Message, sessionID quickfix.SessionID) error {
+	app.LogMessage(zerolog.DebugLevel, message, sessionID, true)
+	return nil
+}
+
+// Notification of app message being received from target.
+func (app *tailApp) FromApp(message *quickfix.Message, sessionID quickfix.SessionID) quickfix.MessageRejectError {
+	app.LogMessage(zerolog.DebugLevel, message, sessionID, false)
+	app.write(message)
+
+	return nil
+}
+
+// write writes the message if it matches the filters, quickfix calling the
+// application of a session from a single goroutine.
+func (app *tailApp) write(message *quickfix.Message) {
+	if len(app.MsgTypes) > 0 {
+		matched := false
+		for _, m := range app.MsgTypes {
+			if m.Match(message) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return
+		}
+	}
+
+	if !utils.QuickFixMessageMatches(message, app.Filters) {
+		return
+	}
+
+	if app.OutputFormat == utils.OutputFormatTable {
+		app.WriteMessageAsTable(app.Out, message)
+		return
+	}
+
+	app.WriteMessage(app.Out, message)
+}
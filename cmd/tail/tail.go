@@ -0,0 +1,167 @@
+package tail
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/quickfixgo/quickfix/datadictionary"
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+
+	"sylr.dev/fix/config"
+	"sylr.dev/fix/pkg/errors"
+	"sylr.dev/fix/pkg/initiator"
+	"sylr.dev/fix/pkg/utils"
+)
+
+var (
+	optionMsgTypes []string
+	optionFilters  []string
+	optionAdmin    bool
+)
+
+var TailCmd = &cobra.Command{
+	Use:   "tail",
+	Short: "Stream the messages received on a session",
+	Long: "Log on and write every message received, session level ones included, until interrupted, like " +
+		"tcpdump at the application layer. Messages can be filtered by type (by value or name) and by " +
+		"Field=Value predicates, fields being given by tag or name.",
+	Example: "  fix tail --context venue --msg-type ExecutionReport --filter Symbol=EURUSD\n" +
+		"  fix tail --context venue --no-admin -o json",
+	Args:              cobra.ExactArgs(0),
+	ValidArgsFunction: cobra.NoFileCompletions,
+	PersistentPreRunE: utils.MakePersistentPreRunE(Validate),
+	RunE:              Execute,
+}
+
+func init() {
+	initiator.AddPersistentFlags(TailCmd)
+	initiator.AddPersistentFlagCompletions(TailCmd)
+	initiator.AddExitOnDisconnectFlag(TailCmd)
+
+	TailCmd.Flags().StringSliceVar(&optionMsgTypes, "msg-type", nil, "Only write the messages of these types")
+	TailCmd.Flags().StringArrayVar(&optionFilters, "filter", nil, "Only write the messages holding this field (Field=Value)")
+	utils.AddBothBoolFlags(TailCmd.Flags(), &optionAdmin, "admin", "", true, "Write the session level messages")
+}
+
+func Validate(cmd *cobra.Command, args []string) error {
+	if err := utils.ReconcileBoolFlags(cmd.Flags()); err != nil {
+		return err
+	}
+
+	return initiator.ValidateOptions(cmd, args)
+}
+
+func Execute(cmd *cobra.Command, args []string) error {
+	options := config.GetOptions()
+
+	context, err := config.GetCurrentContext()
+	if err != nil {
+		return err
+	}
+
+	sessions, err := context.GetSessions()
+	if err != nil {
+		return err
+	}
+
+	session := sessions[0]
+	logger := session.GetLogger()
+	initiatorConfig, err := context.GetInitiator()
+	if err != nil {
+		return err
+	}
+
+	transportDict, appDict, err := session.GetFIXDictionaries()
+	if err != nil {
+		return err
+	}
+
+	msgTypes := make([]string, 0, len(optionMsgTypes))
+	for _, msgType := range optionMsgTypes {
+		msgTypes = append(msgTypes, "MsgType="+msgType)
+	}
+	msgTypeMatchers, err := utils.ParseQuickFixFieldMatchers(msgTypes, transportDict, appDict)
+	if err != nil {
+		return err
+	}
+	for i, m := range msgTypeMatchers {
+		if !knownMsgType(m.Value, transportDict, appDict) {
+			return fmt.Errorf("%w: unknown message type %s", errors.Options, optionMsgTypes[i])
+		}
+	}
+
+	filters, err := utils.ParseQuickFixFieldMatchers(optionFilters, transportDict, appDict)
+	if err != nil {
+		return err
+	}
+
+	settings, err := context.ToQuickFixInitiatorSettings()
+	if err != nil {
+		return err
+	}
+
+	app := newTailApp()
+	app.Logger = logger
+	app.Settings = settings
+	app.TransportDataDictionary = transportDict
+	app.AppDataDictionary = appDict
+	app.OutputFormat = options.Output
+	app.Out = os.Stdout
+	app.Admin = optionAdmin
+	app.MsgTypes = msgTypeMatchers
+	app.Filters = filters
+
+	var quickfixLogger *zerolog.Logger
+	if options.QuickFixLogging {
+		quickfixLogger = logger
+	}
+
+	// Choose right timeout cli option > config > default value (5s)
+	var timeout time.Duration
+	if options.Timeout != time.Duration(0) {
+		timeout = options.Timeout
+	} else if initiatorConfig.SocketTimeout != time.Duration(0) {
+		timeout = initiatorConfig.SocketTimeout
+	} else {
+		timeout = 5 * time.Second
+	}
+
+	init, sessionId, err := initiator.Connect(cmd.Context(), app, settings, quickfixLogger, app.Connected, timeout, initiatorConfig.Reconnect)
+	if err != nil {
+		return err
+	}
+
+	defer init.Stop()
+
+	logger.Info().Msgf("Tailing session %s", sessionId)
+
+	ctx := cmd.Context()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Debug().Msg("Interrupted")
+			return nil
+
+		case sessionId := <-app.Connected:
+			// quickfix logs the session on again by itself after a disconnection
+			logger.Info().Msgf("Session %s logged on again", sessionId)
+		}
+	}
+}
+
+// knownMsgType tells whether one of the dictionaries defines the message type.
+func knownMsgType(msgType string, dicts ...*datadictionary.DataDictionary) bool {
+	for _, dict := range dicts {
+		if dict == nil {
+			continue
+		}
+		if _, ok := dict.Messages[msgType]; ok {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,52 @@
+package ls
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+
+	"sylr.dev/fix/config"
+	"sylr.dev/fix/pkg/template"
+	"sylr.dev/fix/pkg/utils"
+)
+
+var TemplateLsCmd = &cobra.Command{
+	Use:               "ls",
+	Short:             "List the message templates",
+	Long:              "List the message templates of the templates directory along with their placeholders and description.",
+	Example:           "  fix template ls --templates ./templates",
+	Args:              cobra.ExactArgs(0),
+	ValidArgsFunction: cobra.NoFileCompletions,
+	RunE:              Execute,
+}
+
+func Execute(cmd *cobra.Command, args []string) error {
+	options := config.GetOptions()
+
+	templates, err := template.List(options.Templates)
+	if err != nil {
+		return err
+	}
+
+	if options.Output == utils.OutputFormatJSON {
+		return json.NewEncoder(os.Stdout).Encode(templates)
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"NAME", "PLACEHOLDERS", "DESCRIPTION"})
+	table.SetBorders(tablewriter.Border{Left: false, Top: false, Right: false, Bottom: true})
+	table.SetColumnSeparator(" ")
+	table.SetCenterSeparator("-")
+	table.SetAutoWrapText(false)
+
+	for _, t := range templates {
+		table.Append([]string{t.Name, strings.Join(t.Placeholders, ", "), t.Description})
+	}
+
+	table.Render()
+
+	return nil
+}
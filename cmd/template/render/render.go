@@ -0,0 +1,77 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"sylr.dev/fix/config"
+	"sylr.dev/fix/pkg/dictionary"
+	"sylr.dev/fix/pkg/template"
+	"sylr.dev/fix/pkg/utils"
+)
+
+var (
+	optionSet       []string
+	optionDelimiter string
+)
+
+var TemplateRenderCmd = &cobra.Command{
+	Use:   "render <template>",
+	Short: "Render a message template",
+	Long: "Expand the placeholders of a message template with the values given with --set and write the " +
+		"resulting raw messages, encoded with the dictionaries of the session.",
+	Example:           "  fix template render limit-order --set Symbol=EURUSD --set Side=1 --context venue",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: template.CompleteName,
+	PersistentPreRunE: utils.MakePersistentPreRunE(Validate),
+	RunE:              Execute,
+}
+
+func init() {
+	dictionary.AddPersistentFlags(TemplateRenderCmd)
+	dictionary.AddPersistentFlagCompletions(TemplateRenderCmd)
+
+	TemplateRenderCmd.Flags().StringArrayVar(&optionSet, "set", nil, "Value of a placeholder (Name=Value)")
+	TemplateRenderCmd.Flags().StringVar(&optionDelimiter, "delimiter", "|", "Field delimiter of the raw messages written")
+}
+
+func Validate(cmd *cobra.Command, args []string) error {
+	if _, err := template.ParseValues(optionSet); err != nil {
+		return err
+	}
+
+	return dictionary.ValidateDictionaryOptions(cmd, args)
+}
+
+func Execute(cmd *cobra.Command, args []string) error {
+	options := config.GetOptions()
+
+	t, err := template.Load(options.Templates, args[0])
+	if err != nil {
+		return err
+	}
+
+	values, _ := template.ParseValues(optionSet)
+	content, err := t.Render(values)
+	if err != nil {
+		return err
+	}
+
+	transportDict, appDict, err := dictionary.GetFIXDictionaries()
+	if err != nil {
+		return err
+	}
+
+	messages, err := utils.QuickFixMessagesFromYAML(content, transportDict, appDict)
+	if err != nil {
+		return fmt.Errorf("template %s: %w", t.Name, err)
+	}
+
+	for _, message := range messages {
+		fmt.Println(strings.ReplaceAll(message.String(), "\001", optionDelimiter))
+	}
+
+	return nil
+}
@@ -0,0 +1,23 @@
+package template
+
+import (
+	"github.com/spf13/cobra"
+
+	"sylr.dev/fix/cmd/template/ls"
+	"sylr.dev/fix/cmd/template/render"
+	"sylr.dev/fix/pkg/template"
+)
+
+var TemplateCmd = &cobra.Command{
+	Use:   "template",
+	Short: "List and render message templates",
+	Long: "List and render the user-defined message templates, messages described in YAML like for `fix encode` " +
+		"in which placeholders like {{.Symbol}}, {{uuid}} or {{now}} are expanded.",
+}
+
+func init() {
+	template.AddPersistentFlags(TemplateCmd)
+
+	TemplateCmd.AddCommand(ls.TemplateLsCmd)
+	TemplateCmd.AddCommand(render.TemplateRenderCmd)
+}
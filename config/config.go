@@ -1,16 +1,25 @@
 package config
 
 import (
+	"encoding/json"
 	"fmt"
+	"math"
+	"math/rand"
 	"os"
+	"path"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/quickfixgo/enum"
 	"github.com/quickfixgo/quickfix"
 	qconfig "github.com/quickfixgo/quickfix/config"
 	"github.com/quickfixgo/quickfix/datadictionary"
 
+	"sylr.dev/fix/config/credentials"
+	"sylr.dev/fix/pkg/auth"
+	"sylr.dev/fix/pkg/dict"
 	"sylr.dev/fix/pkg/errors"
 	"sylr.dev/fix/pkg/utils"
 )
@@ -92,19 +101,36 @@ func GetSession(name string) (*Session, error) {
 }
 
 type cliOptions struct {
-	Config          string
-	Context         string
-	Session         string
-	Acceptor        string
-	Initiator       string
-	Timeout         time.Duration
-	Verbose         int
-	Interactive     bool
-	LogCaller       bool
-	QuickFixLogging bool
-	Metrics         bool
-	PProf           bool
-	HTTPPort        int
+	Config           string
+	Context          string
+	Session          string
+	AllSessions      bool
+	Acceptor         string
+	Initiator        string
+	Timeout          time.Duration
+	ExitOnDisconnect bool
+	Verbose          int
+	Interactive      bool
+	LogCaller        bool
+	LogFormat        string
+	LogFile          string
+	LogLevel         string
+	QuickFixLogging  bool
+	Metrics          bool
+	MetricsListen    string
+	PProf            bool
+	HTTPPort         int
+	OTLPEndpoint     string
+	NoColor          bool
+	Quiet            bool
+	Output           string
+	TimeZone         string
+	TimePrecision    string
+
+	TransportDictionary string
+	AppDictionary       string
+
+	Templates string
 }
 
 type fixConfig struct {
@@ -121,16 +147,59 @@ func (f *fixConfig) Validate() error {
 		return err
 	}
 
+	for _, context := range f.Contexts {
+		if context.Routing != nil {
+			if err := context.Routing.validate(); err != nil {
+				return fmt.Errorf("%w: context %s", err, context.Name)
+			}
+		}
+		if context.DropCopy != nil {
+			if err := context.DropCopy.validate(); err != nil {
+				return fmt.Errorf("%w: context %s", err, context.Name)
+			}
+		}
+	}
+
 	err = validateNames(f.Sessions, errors.ConfigDuplicateSessionName)
 	if err != nil {
 		return err
 	}
 
+	for _, session := range f.Sessions {
+		if err := session.validateLogon(); err != nil {
+			return err
+		}
+		if session.TLS == nil {
+			continue
+		}
+		if err := session.TLS.validate(); err != nil {
+			return fmt.Errorf("%w: session %s", err, session.Name)
+		}
+	}
+
 	err = validateNames(f.Initiators, errors.ConfigDuplicateInitiatorName)
 	if err != nil {
 		return err
 	}
 
+	for _, initiator := range f.Initiators {
+		if initiator.Store == nil {
+			continue
+		}
+		if err := initiator.Store.validate(); err != nil {
+			return fmt.Errorf("%w: initiator %s", err, initiator.Name)
+		}
+	}
+
+	for _, acceptor := range f.Acceptors {
+		if acceptor.Store == nil {
+			continue
+		}
+		if err := acceptor.Store.validate(); err != nil {
+			return fmt.Errorf("%w: acceptor %s", err, acceptor.Name)
+		}
+	}
+
 	return nil
 }
 
@@ -153,16 +222,189 @@ func validateNames[T HasName](slice []T, err error) error {
 }
 
 type Context struct {
-	Name      string   `yaml:"name"`
-	Initiator string   `yaml:"initiator"`
-	Acceptor  string   `yaml:"acceptor"`
-	Sessions  []string `yaml:"sessions"`
+	Name       string           `yaml:"name"`
+	Extends    string           `yaml:"extends,omitempty"`
+	Initiator  string           `yaml:"initiator"`
+	Acceptor   string           `yaml:"acceptor"`
+	Sessions   []string         `yaml:"sessions"`
+	RiskLimits RiskLimits       `yaml:"RiskLimits"`
+	Reconnect  *ReconnectPolicy `yaml:"Reconnect,omitempty"`
+	Routing    *Routing         `yaml:"Routing,omitempty"`
+	DropCopy   *DropCopy        `yaml:"DropCopy,omitempty"`
+}
+
+// DropCopy copies the execution reports a bridge context sends to its clients
+// to Sessions, sessions of the context with their own sequence numbers, the
+// fields of the Scrub tags removed, whole repeating groups for their count
+// tags.
+type DropCopy struct {
+	Sessions []string `yaml:"Sessions"`
+	Scrub    []int    `yaml:"Scrub,omitempty"`
+}
+
+func (d *DropCopy) validate() error {
+	if len(d.Sessions) == 0 {
+		return fmt.Errorf("%w: drop copy has no sessions", errors.ConfigInvalid)
+	}
+	if err := validateTags(d.Scrub); err != nil {
+		return fmt.Errorf("%w: drop copy scrub: %s", errors.ConfigInvalid, err)
+	}
+
+	return nil
+}
+
+// Routing routes the client orders of a bridge context to its exchange
+// sessions: an order goes to the sessions of the first rule it matches, the
+// orders matching no rule going to the default exchange session.
+type Routing struct {
+	Rules []RoutingRule `yaml:"Rules"`
+}
+
+// RoutingRule matches the orders whose Symbol, Account and one of whose
+// PartyIDs match one of its path.Match patterns, the criteria left empty
+// matching every order. Strategy is failover, the first exchange session of
+// Sessions logged on getting the orders, or round-robin, the ones logged on
+// taking turns. ToExchange rewrites the messages sent to its sessions and
+// ToClient the ones received from them.
+type RoutingRule struct {
+	Name     string   `yaml:"Name,omitempty"`
+	Symbols  []string `yaml:"Symbols,omitempty"`
+	Accounts []string `yaml:"Accounts,omitempty"`
+	Parties  []string `yaml:"Parties,omitempty"`
+	Sessions []string `yaml:"Sessions"`
+	Strategy string   `yaml:"Strategy,omitempty"`
+
+	ToExchange *Transform `yaml:"ToExchange,omitempty"`
+	ToClient   *Transform `yaml:"ToClient,omitempty"`
+}
+
+// Transform rewrites the fields of the messages, outside of repeating groups:
+// Tags moves the values of fields to other tags, then Values replaces the
+// values of fields, by tag then value, Remove removes fields and Set sets
+// fields, the header ones, e.g. OnBehalfOfCompID (115), in the header.
+type Transform struct {
+	Tags   map[int]int               `yaml:"Tags,omitempty"`
+	Values map[int]map[string]string `yaml:"Values,omitempty"`
+	Remove []int                     `yaml:"Remove,omitempty"`
+	Set    map[int]string            `yaml:"Set,omitempty"`
+}
+
+// sessionTags are the tags managed by the sessions, which transforms can't
+// change: BeginString, BodyLength, MsgSeqNum, MsgType and CheckSum.
+var sessionTags = []int{8, 9, 34, 35, 10}
+
+func (t *Transform) validate() error {
+	var tags []int
+	for from, to := range t.Tags {
+		tags = append(tags, from, to)
+	}
+	for tag := range t.Values {
+		tags = append(tags, tag)
+	}
+	tags = append(tags, t.Remove...)
+	for tag := range t.Set {
+		tags = append(tags, tag)
+	}
+
+	return validateTags(tags)
+}
+
+func validateTags(tags []int) error {
+	for _, tag := range tags {
+		if tag <= 0 {
+			return fmt.Errorf("invalid tag %d", tag)
+		}
+		for _, st := range sessionTags {
+			if tag == st {
+				return fmt.Errorf("tag %d is managed by the sessions", tag)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Routing strategies.
+const (
+	RoutingStrategyFailover   = "failover"
+	RoutingStrategyRoundRobin = "round-robin"
+)
+
+func (r *Routing) validate() error {
+	for i, rule := range r.Rules {
+		name := rule.Name
+		if len(name) == 0 {
+			name = "#" + strconv.Itoa(i+1)
+		}
+
+		if len(rule.Sessions) == 0 {
+			return fmt.Errorf("%w: routing rule %s has no sessions", errors.ConfigInvalid, name)
+		}
+		if rule.Strategy != "" && rule.Strategy != RoutingStrategyFailover && rule.Strategy != RoutingStrategyRoundRobin {
+			return fmt.Errorf("%w: routing rule %s strategy must be %s or %s, not %q", errors.ConfigInvalid, name, RoutingStrategyFailover, RoutingStrategyRoundRobin, rule.Strategy)
+		}
+		for _, patterns := range [][]string{rule.Symbols, rule.Accounts, rule.Parties} {
+			for _, pattern := range patterns {
+				if _, err := path.Match(pattern, ""); err != nil {
+					return fmt.Errorf("%w: routing rule %s pattern %q: %s", errors.ConfigInvalid, name, pattern, err)
+				}
+			}
+		}
+		for direction, transform := range map[string]*Transform{"ToExchange": rule.ToExchange, "ToClient": rule.ToClient} {
+			if transform == nil {
+				continue
+			}
+			if err := transform.validate(); err != nil {
+				return fmt.Errorf("%w: routing rule %s %s: %s", errors.ConfigInvalid, name, direction, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// RiskLimits are the pre-trade checks the orders sent from a context must
+// pass, the limits left to zero being disabled. PriceCollarPercent is the
+// maximum distance of the price of limit orders from the last market data
+// price of their symbol, requested when not known.
+type RiskLimits struct {
+	MaxOrderQuantity   float64  `yaml:"MaxOrderQuantity"`
+	MaxNotional        float64  `yaml:"MaxNotional"`
+	PriceCollarPercent float64  `yaml:"PriceCollarPercent"`
+	RestrictedSymbols  []string `yaml:"RestrictedSymbols"`
+}
+
+// RiskLimitsSetting is the session setting holding the risk limits of the
+// context, encoded as JSON, from which the initiators check the orders.
+const RiskLimitsSetting = "RiskLimits"
+
+// IsSet tells whether any of the limits is set.
+func (l RiskLimits) IsSet() bool {
+	return l.MaxOrderQuantity > 0 || l.MaxNotional > 0 || l.PriceCollarPercent > 0 || len(l.RestrictedSymbols) > 0
+}
+
+func (l RiskLimits) setQuickFixSettings(sessionSettings *quickfix.SessionSettings) error {
+	if !l.IsSet() {
+		return nil
+	}
+
+	limits, err := json.Marshal(l)
+	if err != nil {
+		return err
+	}
+	sessionSettings.Set(RiskLimitsSetting, string(limits))
+
+	return nil
 }
 
 func (c *Context) GetName() string {
 	return c.Name
 }
 
+func (c *Context) GetExtends() string {
+	return c.Extends
+}
+
 type SQLStoreConfig interface {
 	GetSQLStoreDriver() string
 	GetSQLStoreDataSourceName() string
@@ -170,6 +412,7 @@ type SQLStoreConfig interface {
 
 type common struct {
 	Name                     string        `yaml:"name"`
+	Extends                  string        `yaml:"extends,omitempty"`
 	SocketUseSSL             bool          `yaml:"SocketUseSSL"`
 	SocketInsecureSkipVerify bool          `yaml:"SocketInsecureSkipVerify"`
 	SocketPrivateKeyFile     string        `yaml:"SocketPrivateKeyFile"`
@@ -178,18 +421,118 @@ type common struct {
 	SocketTimeout            time.Duration `yaml:"SocketTimeout"`
 	SQLStoreDriver           string        `yaml:"SQLStoreDriver"`
 	SQLStoreDataSourceName   string        `yaml:"SQLStoreDataSourceName"`
+	FileStorePath            string        `yaml:"FileStorePath"`
 	RejectInvalidMessage     *bool         `yaml:"RejectInvalidMessage,omitempty"`
+	Store                    *Store        `yaml:"store,omitempty"`
+}
+
+// Global settings of the message stores quickfix has no settings for.
+const (
+	StoreTypeSetting           = "StoreType"
+	StoreDataSourceNameSetting = "StoreDataSourceName"
+	StoreBucketSetting         = "StoreBucket"
+	StorePrefixSetting         = "StorePrefix"
+)
+
+// Store is the message store keeping the sequence numbers and the messages of
+// the sessions, replacing SQLStoreDriver, SQLStoreDataSourceName and
+// FileStorePath when set. DSN is the path of the file store, the data source
+// name of the sql one, the URL of the NATS server of the jetstream one and the
+// redis:// URL of the redis one.
+type Store struct {
+	Type string `yaml:"type"`
+	DSN  string `yaml:"dsn,omitempty"`
+	// Driver of the sql store, postgres for postgres:// DSNs and sqlite3
+	// otherwise by default
+	Driver string `yaml:"driver,omitempty"`
+	// Bucket is the key value bucket of the jetstream store
+	Bucket string `yaml:"bucket,omitempty"`
+	// Prefix of the keys of the jetstream and redis stores
+	Prefix string `yaml:"prefix,omitempty"`
+}
+
+// Message store types.
+const (
+	StoreTypeFile      = "file"
+	StoreTypeMemory    = "memory"
+	StoreTypeSQL       = "sql"
+	StoreTypeJetStream = "jetstream"
+	StoreTypeRedis     = "redis"
+)
+
+func (s *Store) validate() error {
+	switch s.Type {
+	case StoreTypeMemory:
+		return nil
+	case StoreTypeFile, StoreTypeJetStream, StoreTypeRedis:
+	case StoreTypeSQL:
+		if driver := s.driver(); driver != "sqlite3" && driver != "postgres" {
+			return fmt.Errorf("%w: store driver must be sqlite3 or postgres, not %q", errors.ConfigInvalid, driver)
+		}
+	default:
+		return fmt.Errorf("%w: store type must be one of file, memory, sql, jetstream or redis, not %q", errors.ConfigInvalid, s.Type)
+	}
+
+	if len(s.DSN) == 0 {
+		return fmt.Errorf("%w: %s store has no dsn", errors.ConfigInvalid, s.Type)
+	}
+
+	return nil
+}
+
+func (s *Store) driver() string {
+	if len(s.Driver) > 0 {
+		return s.Driver
+	}
+	if strings.HasPrefix(s.DSN, "postgres://") || strings.HasPrefix(s.DSN, "postgresql://") {
+		return "postgres"
+	}
+	return "sqlite3"
+}
+
+func (s *Store) setQuickFixGlobalSettings(globalSettings *quickfix.SessionSettings) {
+	dsn := os.ExpandEnv(s.DSN)
+
+	switch s.Type {
+	case StoreTypeFile:
+		globalSettings.Set(qconfig.FileStorePath, dsn)
+	case StoreTypeSQL:
+		globalSettings.Set(qconfig.SQLStoreDriver, s.driver())
+		globalSettings.Set(qconfig.SQLStoreDataSourceName, dsn)
+	case StoreTypeJetStream, StoreTypeRedis:
+		globalSettings.Set(StoreTypeSetting, s.Type)
+		globalSettings.Set(StoreDataSourceNameSetting, dsn)
+		if len(s.Bucket) > 0 {
+			globalSettings.Set(StoreBucketSetting, s.Bucket)
+		}
+		if len(s.Prefix) > 0 {
+			globalSettings.Set(StorePrefixSetting, s.Prefix)
+		}
+	}
 }
 
 func (c *common) GetName() string {
 	return c.Name
 }
 
+func (c *common) GetExtends() string {
+	return c.Extends
+}
+
 func (c *common) GetSQLStoreDriver() string {
+	if c.Store != nil {
+		if c.Store.Type != StoreTypeSQL {
+			return ""
+		}
+		return c.Store.driver()
+	}
 	return c.SQLStoreDriver
 }
 
 func (c *common) GetSQLStoreDataSourceName() string {
+	if c.Store != nil {
+		return c.Store.DSN
+	}
 	return c.SQLStoreDataSourceName
 }
 
@@ -201,12 +544,20 @@ func (c *common) setQuickFixGlobalSettings(globalSettings *quickfix.SessionSetti
 		session.Set(qconfig.RejectInvalidMessage, FixBoolString(*c.RejectInvalidMessage))
 	}
 
-	if len(c.SQLStoreDriver) > 0 {
-		globalSettings.Set(qconfig.SQLStoreDriver, c.SQLStoreDriver)
-	}
+	if c.Store != nil {
+		c.Store.setQuickFixGlobalSettings(globalSettings)
+	} else {
+		if len(c.SQLStoreDriver) > 0 {
+			globalSettings.Set(qconfig.SQLStoreDriver, c.SQLStoreDriver)
+		}
 
-	if len(c.SQLStoreDataSourceName) > 0 {
-		globalSettings.Set(qconfig.SQLStoreDataSourceName, c.SQLStoreDataSourceName)
+		if len(c.SQLStoreDataSourceName) > 0 {
+			globalSettings.Set(qconfig.SQLStoreDataSourceName, c.SQLStoreDataSourceName)
+		}
+
+		if len(c.FileStorePath) > 0 {
+			globalSettings.Set(qconfig.FileStorePath, os.ExpandEnv(c.FileStorePath))
+		}
 	}
 
 	if len(c.SocketPrivateKeyFile) != 0 {
@@ -247,44 +598,254 @@ func (a *Acceptor) GetSQLStoreDataSourceName() string {
 type Initiator struct {
 	common `yaml:",inline"`
 
-	SocketConnectHost string `yaml:"SocketConnectHost"`
-	SocketConnectPort int    `yaml:"SocketConnectPort"`
-	SocketServerName  string `yaml:"SocketServerName"`
+	SocketConnectHost string           `yaml:"SocketConnectHost"`
+	SocketConnectPort int              `yaml:"SocketConnectPort"`
+	SocketServerName  string           `yaml:"SocketServerName"`
+	Reconnect         ReconnectPolicy  `yaml:"Reconnect"`
+	SymbolCompletion  SymbolCompletion `yaml:"SymbolCompletion"`
+}
+
+// SymbolCompletion describes whether the --symbol flags are completed with
+// the symbols of a SecurityList requested to the venue, and how long they
+// are cached.
+type SymbolCompletion struct {
+	Enabled  bool          `yaml:"Enabled"`
+	CacheTTL time.Duration `yaml:"CacheTTL"`
+}
+
+// ReconnectPolicy describes how initiator commands retry to connect when the
+// session is not logged on within the timeout and, with OnDisconnect, when the
+// session drops while they wait for a response. The policy of a context
+// replaces the one of its initiator.
+type ReconnectPolicy struct {
+	Interval     time.Duration `yaml:"Interval"`
+	MaxRetries   int           `yaml:"MaxRetries"`
+	Backoff      float64       `yaml:"Backoff"`
+	MaxInterval  time.Duration `yaml:"MaxInterval"`
+	Jitter       float64       `yaml:"Jitter"`
+	OnDisconnect bool          `yaml:"OnDisconnect"`
+}
+
+// maxReconnectDelay caps the delays of the policies without MaxInterval, the
+// backoff overflowing a time.Duration after a few dozen attempts otherwise.
+const maxReconnectDelay = 24 * time.Hour
+
+// Delay returns the duration to wait after the given failed attempt (starting
+// at 0) before retrying. The interval defaults to 1s and is multiplied by the
+// backoff factor after each attempt, up to MaxInterval, or a day when not set,
+// then randomized by up to Jitter of it (e.g. 0.2 for ±20%) so that clients do
+// not reconnect all at once, even once their backoff reached MaxInterval.
+func (p ReconnectPolicy) Delay(attempt int) time.Duration {
+	interval := p.Interval
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	d := float64(interval)
+	if p.Backoff > 1 {
+		d *= math.Pow(p.Backoff, float64(attempt))
+	}
+
+	// Clamped before the conversion, which overflows beyond math.MaxInt64
+	limit := maxReconnectDelay
+	if p.MaxInterval > 0 {
+		limit = p.MaxInterval
+	}
+	d = math.Min(d, float64(limit))
+
+	if p.Jitter > 0 {
+		d *= 1 + math.Min(p.Jitter, 1)*(2*rand.Float64()-1)
+	}
+
+	return time.Duration(d)
 }
 
 type Session struct {
-	Name                    string `yaml:"name"`
-	BeginString             string `yaml:"BeginString"`
-	DefaultApplVerID        string `yaml:"DefaultApplVerID"`
-	HeartBtInt              int    `yaml:"HeartBtInt"`
-	SenderCompID            string `yaml:"SenderCompID"`
-	SenderSubID             string `yaml:"SenderSubID"`
-	SenderLocationID        string `yaml:"SenderLocationID"`
-	TargetCompID            string `yaml:"TargetCompID"`
-	TargetSubID             string `yaml:"TargetSubID"`
-	TargetLocationID        string `yaml:"TargetLocationID"`
-	SessionQualifier        string `yaml:"SessionQualifier"`
-	Username                string `yaml:"Username"`
-	Password                string `yaml:"Password"`
-	StartTime               string `yaml:"StartTime"`
-	EndTime                 string `yaml:"EndTime"`
-	StartDay                string `yaml:"StartDay"`
-	EndDay                  string `yaml:"EndDay"`
-	TimeZone                string `yaml:"TimeZone"`
-	TransportDataDictionary string `yaml:"TransportDataDictionary"`
-	AppDataDictionary       string `yaml:"AppDataDictionary"`
-	ResetOnLogon            bool   `yaml:"ResetOnLogon"`
-	ResetOnLogout           bool   `yaml:"ResetOnLogout"`
-	ResetOnDisconnect       bool   `yaml:"ResetOnDisconnect"`
-	ReconnectInterval       int    `yaml:"ReconnectInterval"`
+	Name                    string     `yaml:"name"`
+	Extends                 string     `yaml:"extends,omitempty"`
+	BeginString             string     `yaml:"BeginString"`
+	DefaultApplVerID        string     `yaml:"DefaultApplVerID"`
+	HeartBtInt              int        `yaml:"HeartBtInt"`
+	SenderCompID            string     `yaml:"SenderCompID"`
+	SenderSubID             string     `yaml:"SenderSubID"`
+	SenderLocationID        string     `yaml:"SenderLocationID"`
+	TargetCompID            string     `yaml:"TargetCompID"`
+	TargetSubID             string     `yaml:"TargetSubID"`
+	TargetLocationID        string     `yaml:"TargetLocationID"`
+	OnBehalfOfCompID        string     `yaml:"OnBehalfOfCompID"`
+	DeliverToCompID         string     `yaml:"DeliverToCompID"`
+	SessionQualifier        string     `yaml:"SessionQualifier"`
+	Username                string     `yaml:"Username"`
+	Password                string     `yaml:"Password"`
+	StartTime               string     `yaml:"StartTime"`
+	EndTime                 string     `yaml:"EndTime"`
+	StartDay                string     `yaml:"StartDay"`
+	EndDay                  string     `yaml:"EndDay"`
+	TimeZone                string     `yaml:"TimeZone"`
+	TransportDataDictionary string     `yaml:"TransportDataDictionary"`
+	AppDataDictionary       string     `yaml:"AppDataDictionary"`
+	ResetOnLogon            bool       `yaml:"ResetOnLogon"`
+	ResetOnLogout           bool       `yaml:"ResetOnLogout"`
+	ResetOnDisconnect       bool       `yaml:"ResetOnDisconnect"`
+	ReconnectInterval       int        `yaml:"ReconnectInterval"`
+	LogLevel                string     `yaml:"LogLevel"`
+	LogFile                 string     `yaml:"LogFile"`
+	MessageLog              MessageLog `yaml:"MessageLog"`
+	// OrderTrackerPath is the SQLite database in which the orders sent and
+	// the execution reports received are recorded, see `fix orders`.
+	OrderTrackerPath string `yaml:"OrderTrackerPath"`
+	// MaxClockDrift is the difference between the local clock and the
+	// SendingTime of the messages received above which a warning is logged.
+	MaxClockDrift time.Duration `yaml:"MaxClockDrift"`
+	// Throttle limits the rate of the application messages sent.
+	Throttle Throttle `yaml:"Throttle"`
+	// Auth are the providers authenticating the Logon messages of initiator
+	// sessions, applied in order once Username and Password are set.
+	Auth []auth.Provider `yaml:"Auth"`
+	// Credentials is the secret store the Username and Password the session
+	// logs on with are fetched from on every logon, replacing Password.
+	Credentials *credentials.Config `yaml:"Credentials,omitempty"`
+	// InstrumentCatalog is the instrument catalog, JSON or CSV, whose tick and
+	// lot sizes give the decimals the prices and quantities of the symbols are
+	// written and displayed with.
+	InstrumentCatalog string `yaml:"InstrumentCatalog"`
+	// AppDataDictionaries are the application dictionaries of the versions,
+	// given as BeginStrings (FIX.4.2, FIX.4.4 ...), of the messages the
+	// acceptor handles on FIXT.1.1 sessions besides the ones of
+	// DefaultApplVerID, validated against AppDataDictionary.
+	AppDataDictionaries map[string]string `yaml:"AppDataDictionaries"`
+	// TLS makes the initiator connect to the session over TLS, authenticated
+	// with a client certificate when the venue requires mutual TLS.
+	TLS *SessionTLS `yaml:"tls,omitempty"`
+	// EncryptMethod is the EncryptMethod (98) of the Logon messages sent by
+	// initiator sessions, 0 (none) by default.
+	EncryptMethod string `yaml:"encrypt-method,omitempty"`
+	// LogonFields are the fields, by tag, venues require in the body of the
+	// Logon messages on top of the session level ones.
+	LogonFields map[int]string `yaml:"logon-fields,omitempty"`
+}
+
+func (s *Session) validateLogon() error {
+	if len(s.EncryptMethod) > 0 {
+		if method, err := strconv.Atoi(s.EncryptMethod); err != nil || method < 0 || method > 6 {
+			return fmt.Errorf("%w: session %s: encrypt-method must be between 0 and 6", errors.ConfigInvalid, s.Name)
+		}
+	}
+
+	for t := range s.LogonFields {
+		if t <= 0 {
+			return fmt.Errorf("%w: session %s: invalid logon-fields tag %d", errors.ConfigInvalid, s.Name, t)
+		}
+	}
+
+	return nil
+}
+
+// SessionTLS describes the TLS connection of an initiator session, replacing
+// the Socket settings of its initiator. Cert and Key are the PEM files of the
+// client certificate, CA the one of the authorities trusted to sign the
+// certificate of the acceptor, whose name is ServerName when it differs from
+// SocketConnectHost.
+type SessionTLS struct {
+	Cert               string `yaml:"cert"`
+	Key                string `yaml:"key"`
+	CA                 string `yaml:"ca"`
+	InsecureSkipVerify bool   `yaml:"insecure-skip-verify"`
+	ServerName         string `yaml:"server-name"`
+}
+
+func (t *SessionTLS) validate() error {
+	if (len(t.Cert) == 0) != (len(t.Key) == 0) {
+		return fmt.Errorf("%w: tls cert and key must be given together", errors.ConfigInvalid)
+	}
+
+	return nil
+}
+
+// ApplyTo returns a copy of the initiator connecting over TLS as described.
+func (t *SessionTLS) ApplyTo(initiator *Initiator) *Initiator {
+	if t == nil {
+		return initiator
+	}
+
+	withTLS := *initiator
+	withTLS.SocketUseSSL = true
+	withTLS.SocketInsecureSkipVerify = withTLS.SocketInsecureSkipVerify || t.InsecureSkipVerify
+	if len(t.Cert) > 0 {
+		withTLS.SocketCertificateFile = os.ExpandEnv(t.Cert)
+		withTLS.SocketPrivateKeyFile = os.ExpandEnv(t.Key)
+	}
+	if len(t.CA) > 0 {
+		withTLS.SocketCAFile = os.ExpandEnv(t.CA)
+	}
+	if len(t.ServerName) > 0 {
+		withTLS.SocketServerName = t.ServerName
+	}
+
+	return &withTLS
+}
+
+// Throttle describes the maximum rates, per second, at which the application
+// messages and the orders among them are sent on a session, zero meaning no
+// limit. With the block Policy, the default, messages wait to be sent within
+// the rates; with the fail one they are refused.
+type Throttle struct {
+	MessagesPerSecond float64 `yaml:"MessagesPerSecond"`
+	OrdersPerSecond   float64 `yaml:"OrdersPerSecond"`
+	Policy            string  `yaml:"Policy"`
+}
+
+func (t Throttle) setQuickFixSettings(sessionSettings *quickfix.SessionSettings) {
+	if t.MessagesPerSecond > 0 {
+		sessionSettings.Set("ThrottleMessagesPerSecond", strconv.FormatFloat(t.MessagesPerSecond, 'f', -1, 64))
+	}
+	if t.OrdersPerSecond > 0 {
+		sessionSettings.Set("ThrottleOrdersPerSecond", strconv.FormatFloat(t.OrdersPerSecond, 'f', -1, 64))
+	}
+	setSessionSetting(sessionSettings, "ThrottlePolicy", t.Policy)
+}
+
+// MessageLog describes the file in which every message sent or received on a
+// session is written as a JSON line, rotated when it reaches MaxSize megabytes
+// or every RotateEvery, only the MaxBackups most recent files being kept.
+type MessageLog struct {
+	Path        string        `yaml:"Path"`
+	MaxSize     int           `yaml:"MaxSize"`
+	RotateEvery time.Duration `yaml:"RotateEvery"`
+	MaxBackups  int           `yaml:"MaxBackups"`
+}
+
+func (l MessageLog) setQuickFixSettings(sessionSettings *quickfix.SessionSettings) {
+	if len(l.Path) == 0 {
+		return
+	}
+
+	setSessionSetting(sessionSettings, "MessageLogPath", os.ExpandEnv(l.Path))
+	setSessionSetting(sessionSettings, "MessageLogMaxSize", l.MaxSize)
+	setSessionSetting(sessionSettings, "MessageLogRotateEvery", l.RotateEvery)
+	setSessionSetting(sessionSettings, "MessageLogMaxBackups", l.MaxBackups)
 }
 
 func (s *Session) GetName() string {
 	return s.Name
 }
 
+func (s *Session) GetExtends() string {
+	return s.Extends
+}
+
+// GetInitiator returns the initiator of the context, whose reconnect policy is
+// the one of the context if it has one.
 func (c Context) GetInitiator() (*Initiator, error) {
-	return GetInitiator(c.Initiator)
+	initiator, err := GetInitiator(c.Initiator)
+	if err != nil || c.Reconnect == nil {
+		return initiator, err
+	}
+
+	withPolicy := *initiator
+	withPolicy.Reconnect = *c.Reconnect
+
+	return &withPolicy, nil
 }
 
 func (c Context) GetAcceptor() (*Acceptor, error) {
@@ -310,6 +871,41 @@ func (c Context) GetSessions() ([]*Session, error) {
 	return sessions, nil
 }
 
+// SelectSessions returns a copy of the context restricted to the named
+// sessions, which must be part of it, in the order given. All the sessions of
+// the context are kept when no name is given.
+func (c Context) SelectSessions(names ...string) (*Context, error) {
+	selected := c
+	if len(names) == 0 {
+		return &selected, nil
+	}
+
+	selected.Sessions = make([]string, 0, len(names))
+	for _, name := range names {
+		if utils.Search(c.Sessions, name) < 0 {
+			return nil, fmt.Errorf("%w: %s", errors.ConfigSessionNotInContext, name)
+		}
+		if utils.Search(selected.Sessions, name) < 0 {
+			selected.Sessions = append(selected.Sessions, name)
+		}
+	}
+
+	return &selected, nil
+}
+
+// Split returns a copy of the context per session, each having only this
+// session, so that they can be turned into quickfix settings.
+func (c Context) Split() []*Context {
+	contexts := make([]*Context, len(c.Sessions))
+	for i := range c.Sessions {
+		single := c
+		single.Sessions = c.Sessions[i : i+1]
+		contexts[i] = &single
+	}
+
+	return contexts
+}
+
 func (c Context) ToQuickFixInitiatorSettings() (*quickfix.Settings, error) {
 	settings := quickfix.NewSettings()
 	globalSettings := settings.GlobalSettings()
@@ -338,6 +934,13 @@ func (c Context) ToQuickFixInitiatorSettings() (*quickfix.Settings, error) {
 	// Session settings
 	session := sessions[0]
 
+	initiator = session.TLS.ApplyTo(initiator)
+
+	transportDict, appDict, err := session.dictionaryPaths()
+	if err != nil {
+		return nil, err
+	}
+
 	sessionSettings := quickfix.NewSessionSettings()
 	initiator.setQuickFixGlobalSettings(globalSettings, sessionSettings)
 
@@ -345,8 +948,15 @@ func (c Context) ToQuickFixInitiatorSettings() (*quickfix.Settings, error) {
 	setSessionSetting(sessionSettings, qconfig.SocketConnectPort, initiator.SocketConnectPort)
 	setSessionSetting(sessionSettings, qconfig.SocketServerName, initiator.SocketServerName)
 	setSessionSetting(sessionSettings, qconfig.HeartBtInt, session.HeartBtInt)
+	policy := initiator.Reconnect
+	if c.Reconnect != nil {
+		policy = *c.Reconnect
+	}
 	if session.ReconnectInterval > 0 {
 		setSessionSetting(sessionSettings, qconfig.ReconnectInterval, session.ReconnectInterval)
+	} else if policy.OnDisconnect && policy.Interval > 0 {
+		// The initiator reconnects by itself when the session drops
+		sessionSettings.Set(qconfig.ReconnectInterval, policy.Interval.String())
 	}
 	setSessionSetting(sessionSettings, qconfig.BeginString, session.BeginString)
 	setSessionSetting(sessionSettings, qconfig.DefaultApplVerID, session.DefaultApplVerID)
@@ -357,19 +967,56 @@ func (c Context) ToQuickFixInitiatorSettings() (*quickfix.Settings, error) {
 	setSessionSetting(sessionSettings, qconfig.TargetSubID, session.TargetSubID)
 	setSessionSetting(sessionSettings, qconfig.TargetLocationID, session.TargetLocationID)
 	setSessionSetting(sessionSettings, qconfig.SessionQualifier, session.SessionQualifier)
+	setSessionSetting(sessionSettings, "OnBehalfOfCompID", session.OnBehalfOfCompID)
+	setSessionSetting(sessionSettings, "DeliverToCompID", session.DeliverToCompID)
 	setSessionSetting(sessionSettings, qconfig.BeginString, session.BeginString)
 	setSessionSetting(sessionSettings, "Username", session.Username)
 	setSessionSetting(sessionSettings, "Password", session.Password)
+	if session.Credentials != nil {
+		if _, err := credentials.New(*session.Credentials); err != nil {
+			return nil, fmt.Errorf("%w: session %s", err, session.Name)
+		}
+		provider, err := json.Marshal(session.Credentials)
+		if err != nil {
+			return nil, err
+		}
+		sessionSettings.Set(credentials.SettingKey, string(provider))
+	}
+	setSessionSetting(sessionSettings, utils.LogonEncryptMethodSetting, session.EncryptMethod)
+	if len(session.LogonFields) > 0 {
+		fields, err := json.Marshal(session.LogonFields)
+		if err != nil {
+			return nil, err
+		}
+		sessionSettings.Set(utils.LogonFieldsSetting, string(fields))
+	}
 	setSessionSetting(sessionSettings, qconfig.StartTime, session.StartTime)
 	setSessionSetting(sessionSettings, qconfig.EndTime, session.EndTime)
 	setSessionSetting(sessionSettings, qconfig.StartDay, session.StartDay)
 	setSessionSetting(sessionSettings, qconfig.EndDay, session.EndDay)
 	setSessionSetting(sessionSettings, qconfig.TimeZone, session.TimeZone)
-	setSessionSetting(sessionSettings, qconfig.TransportDataDictionary, os.ExpandEnv(session.TransportDataDictionary))
-	setSessionSetting(sessionSettings, qconfig.AppDataDictionary, os.ExpandEnv(session.AppDataDictionary))
+	setSessionSetting(sessionSettings, qconfig.TransportDataDictionary, transportDict)
+	setSessionSetting(sessionSettings, qconfig.AppDataDictionary, appDict)
 	setSessionSetting(sessionSettings, qconfig.ResetOnLogon, session.ResetOnLogon)
 	setSessionSetting(sessionSettings, qconfig.ResetOnLogout, session.ResetOnLogout)
 	setSessionSetting(sessionSettings, qconfig.ResetOnDisconnect, session.ResetOnDisconnect)
+	setSessionSetting(sessionSettings, "LogFile", os.ExpandEnv(session.LogFile))
+	session.MessageLog.setQuickFixSettings(sessionSettings)
+	setSessionSetting(sessionSettings, "OrderTrackerPath", os.ExpandEnv(session.OrderTrackerPath))
+	if len(session.Auth) > 0 {
+		providers, err := json.Marshal(session.Auth)
+		if err != nil {
+			return nil, err
+		}
+		sessionSettings.Set(auth.SettingKey, string(providers))
+	}
+	if session.MaxClockDrift > 0 {
+		sessionSettings.Set("MaxClockDrift", session.MaxClockDrift.String())
+	}
+	session.Throttle.setQuickFixSettings(sessionSettings)
+	if err := c.RiskLimits.setQuickFixSettings(sessionSettings); err != nil {
+		return nil, err
+	}
 	setSessionSetting(sessionSettings, qconfig.SQLStoreDriver, initiator.SQLStoreDriver)
 	setSessionSetting(sessionSettings, qconfig.SQLStoreDataSourceName, os.ExpandEnv(initiator.SQLStoreDataSourceName))
 	setSessionSetting(sessionSettings, qconfig.RejectInvalidMessage, initiator.RejectInvalidMessage)
@@ -441,6 +1088,11 @@ func (c Context) ToQuickFixAcceptorSettings() (*quickfix.Settings, error) {
 	}
 
 	for _, session := range sessions {
+		transportDict, appDict, err := session.dictionaryPaths()
+		if err != nil {
+			return nil, err
+		}
+
 		sessionSettings := quickfix.NewSessionSettings()
 		acceptor.setQuickFixGlobalSettings(globalSettings, sessionSettings)
 
@@ -460,11 +1112,21 @@ func (c Context) ToQuickFixAcceptorSettings() (*quickfix.Settings, error) {
 		setSessionSetting(sessionSettings, qconfig.StartDay, session.StartDay)
 		setSessionSetting(sessionSettings, qconfig.EndDay, session.EndDay)
 		setSessionSetting(sessionSettings, qconfig.TimeZone, session.TimeZone)
-		setSessionSetting(sessionSettings, qconfig.TransportDataDictionary, os.ExpandEnv(session.TransportDataDictionary))
-		setSessionSetting(sessionSettings, qconfig.AppDataDictionary, os.ExpandEnv(session.AppDataDictionary))
+		// The acceptor application validates the messages of the sessions
+		// handling several versions against the dictionary of each of them
+		if len(session.AppDataDictionaries) == 0 {
+			setSessionSetting(sessionSettings, qconfig.TransportDataDictionary, transportDict)
+			setSessionSetting(sessionSettings, qconfig.AppDataDictionary, appDict)
+		}
 		setSessionSetting(sessionSettings, qconfig.ResetOnLogon, session.ResetOnLogon)
 		setSessionSetting(sessionSettings, qconfig.ResetOnLogout, session.ResetOnLogout)
 		setSessionSetting(sessionSettings, qconfig.ResetOnDisconnect, session.ResetOnDisconnect)
+		setSessionSetting(sessionSettings, "LogFile", os.ExpandEnv(session.LogFile))
+		session.MessageLog.setQuickFixSettings(sessionSettings)
+		if session.MaxClockDrift > 0 {
+			sessionSettings.Set("MaxClockDrift", session.MaxClockDrift.String())
+		}
+		session.Throttle.setQuickFixSettings(sessionSettings)
 		setSessionSetting(sessionSettings, qconfig.SQLStoreDriver, acceptor.SQLStoreDriver)
 		setSessionSetting(sessionSettings, qconfig.SQLStoreDataSourceName, os.ExpandEnv(acceptor.SQLStoreDataSourceName))
 		setSessionSetting(sessionSettings, qconfig.RejectInvalidMessage, acceptor.RejectInvalidMessage)
@@ -490,13 +1152,29 @@ func (c Context) ToQuickFixAcceptorSettings() (*quickfix.Settings, error) {
 	return settings, nil
 }
 
+// AcceptorSessionID returns the ID of the session when served by an acceptor,
+// as set up by ToQuickFixAcceptorSettings.
+func (s Session) AcceptorSessionID() quickfix.SessionID {
+	return quickfix.SessionID{
+		BeginString:  s.BeginString,
+		SenderCompID: s.SenderCompID,
+		SenderSubID:  s.SenderSubID,
+		TargetCompID: s.TargetCompID,
+		TargetSubID:  s.TargetSubID,
+	}
+}
+
 func (s Session) GetFIXDictionaries() (*datadictionary.DataDictionary, *datadictionary.DataDictionary, error) {
 	var err error
+	var path string
 	var ok bool
 
 	if len(s.TransportDataDictionary) > 0 {
 		if _, ok = fixDict[s.TransportDataDictionary]; !ok {
-			path := os.ExpandEnv(s.TransportDataDictionary)
+			path, err = DictionaryPath(s.TransportDataDictionary)
+			if err != nil {
+				return nil, nil, err
+			}
 			fixDict[s.TransportDataDictionary], err = datadictionary.Parse(path)
 			if err != nil {
 				return nil, nil, err
@@ -506,7 +1184,10 @@ func (s Session) GetFIXDictionaries() (*datadictionary.DataDictionary, *datadict
 
 	if len(s.AppDataDictionary) > 0 {
 		if _, ok = fixDict[s.AppDataDictionary]; !ok {
-			path := os.ExpandEnv(s.AppDataDictionary)
+			path, err = DictionaryPath(s.AppDataDictionary)
+			if err != nil {
+				return nil, nil, err
+			}
 			fixDict[s.AppDataDictionary], err = datadictionary.Parse(path)
 			if err != nil {
 				return nil, nil, err
@@ -517,6 +1198,69 @@ func (s Session) GetFIXDictionaries() (*datadictionary.DataDictionary, *datadict
 	return fixDict[s.TransportDataDictionary], fixDict[s.AppDataDictionary], nil
 }
 
+// GetFIXAppDictionaries returns the application dictionaries of the versions
+// of the messages handled on the session, DefaultApplVerID and the ones of
+// AppDataDictionaries, keyed by their ApplVerID.
+func (s Session) GetFIXAppDictionaries() (map[enum.ApplVerID]*datadictionary.DataDictionary, error) {
+	_, appDict, err := s.GetFIXDictionaries()
+	if err != nil {
+		return nil, err
+	}
+
+	dicts := make(map[enum.ApplVerID]*datadictionary.DataDictionary)
+
+	if len(s.DefaultApplVerID) > 0 && appDict != nil {
+		applVerID, err := dict.ApplVerID(s.DefaultApplVerID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unknown DefaultApplVerID %s", errors.ConfigDictionary, s.DefaultApplVerID)
+		}
+		dicts[applVerID] = appDict
+	}
+
+	for version, location := range s.AppDataDictionaries {
+		applVerID, err := dict.ApplVerID(version)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unknown application version %s", errors.ConfigDictionary, version)
+		}
+
+		if _, ok := fixDict[location]; !ok {
+			path, err := DictionaryPath(location)
+			if err != nil {
+				return nil, err
+			}
+			fixDict[location], err = datadictionary.Parse(path)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		dicts[applVerID] = fixDict[location]
+	}
+
+	return dicts, nil
+}
+
+// dictionaryPaths returns the local paths of the transport and application
+// dictionaries of the session.
+func (s Session) dictionaryPaths() (string, string, error) {
+	var transport, app string
+	var err error
+
+	if len(s.TransportDataDictionary) > 0 {
+		if transport, err = DictionaryPath(s.TransportDataDictionary); err != nil {
+			return "", "", err
+		}
+	}
+
+	if len(s.AppDataDictionary) > 0 {
+		if app, err = DictionaryPath(s.AppDataDictionary); err != nil {
+			return "", "", err
+		}
+	}
+
+	return transport, app, nil
+}
+
 func FixBoolString(b bool) string {
 	if b {
 		return "Y"
@@ -0,0 +1,165 @@
+package credentials
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+func newAWSSecretsManager(config Config) (Provider, error) {
+	if len(config.SecretID) == 0 {
+		return nil, fmt.Errorf("no SecretID given")
+	}
+
+	region := os.ExpandEnv(config.Region)
+	for _, variable := range []string{"AWS_REGION", "AWS_DEFAULT_REGION"} {
+		if len(region) == 0 {
+			region = os.Getenv(variable)
+		}
+	}
+	if len(region) == 0 {
+		return nil, fmt.Errorf("no Region given and AWS_REGION is not set")
+	}
+
+	endpoint := os.ExpandEnv(config.Endpoint)
+	if len(endpoint) == 0 {
+		endpoint = fmt.Sprintf("https://secretsmanager.%s.amazonaws.com/", region)
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("Endpoint: %w", err)
+	}
+	if len(u.Path) == 0 {
+		u.Path = "/"
+	}
+
+	secretID := os.ExpandEnv(config.SecretID)
+
+	return ProviderFunc(func(ctx context.Context) (Credentials, error) {
+		body, err := json.Marshal(map[string]string{"SecretId": secretID})
+		if err != nil {
+			return Credentials{}, err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(body))
+		if err != nil {
+			return Credentials{}, err
+		}
+		req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+		req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+		if err := signV4(req, body, region, "secretsmanager", time.Now()); err != nil {
+			return Credentials{}, err
+		}
+
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return Credentials{}, err
+		}
+		defer res.Body.Close()
+
+		payload, err := io.ReadAll(res.Body)
+		if err != nil {
+			return Credentials{}, err
+		}
+		if res.StatusCode != http.StatusOK {
+			return Credentials{}, fmt.Errorf("aws secrets manager: %s: %s", res.Status, strings.TrimSpace(string(payload)))
+		}
+
+		var secret struct {
+			SecretString string `json:"SecretString"`
+		}
+		if err := json.Unmarshal(payload, &secret); err != nil {
+			return Credentials{}, fmt.Errorf("aws secrets manager: %w", err)
+		}
+
+		var fields map[string]any
+		if err := json.Unmarshal([]byte(secret.SecretString), &fields); err != nil {
+			return Credentials{Password: secret.SecretString}, nil
+		}
+
+		return fromFields(fields, config)
+	}), nil
+}
+
+// signV4 signs the request, with its headers, with the AWS Signature Version 4
+// of the credentials of the environment.
+func signV4(req *http.Request, body []byte, region, service string, now time.Time) error {
+	accessKey := os.Getenv("AWS_ACCESS_KEY_ID")
+	secretKey := os.Getenv("AWS_SECRET_ACCESS_KEY")
+	if len(accessKey) == 0 || len(secretKey) == 0 {
+		return fmt.Errorf("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY must be set")
+	}
+
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if token := os.Getenv("AWS_SESSION_TOKEN"); len(token) > 0 {
+		req.Header.Set("X-Amz-Security-Token", token)
+	}
+
+	// All the headers of the request are signed, along with its host
+	headers := []string{"host"}
+	for h := range req.Header {
+		if h = strings.ToLower(h); h != "host" && h != "authorization" {
+			headers = append(headers, h)
+		}
+	}
+	sort.Strings(headers)
+
+	var canonicalHeaders strings.Builder
+	for _, h := range headers {
+		values := req.Header.Values(h)
+		if h == "host" {
+			values = []string{req.URL.Host}
+		}
+		trimmed := make([]string, len(values))
+		for i, value := range values {
+			trimmed[i] = strings.Join(strings.Fields(value), " ")
+		}
+		canonicalHeaders.WriteString(h + ":" + strings.Join(trimmed, ",") + "\n")
+	}
+	signedHeaders := strings.Join(headers, ";")
+
+	payloadHash := sha256.Sum256(body)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+
+	scope := strings.Join([]string{date, region, service, "aws4_request"}, "/")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, hex.EncodeToString(requestHash[:])}, "\n")
+
+	key := []byte("AWS4" + secretKey)
+	for _, part := range []string{date, region, service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", accessKey, scope, signedHeaders, signature))
+
+	return nil
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,332 @@
+// Package credentials gets the Username and Password the initiator sessions
+// log on with from an external secret store, so that they are not written in
+// plain text in the configuration and can be rotated without editing it. The
+// sessions log on with the ones last fetched, a new fetch starting in the
+// background on every logon for the next one.
+//
+// The built-in providers are:
+//
+//   - env, which reads the password from the Variable environment variable
+//     and the username from UsernameVariable.
+//   - file, which reads the password from the file at Path, trailing new
+//     lines removed, and the username from UsernamePath.
+//   - vault, which reads the Key (password by default) and UsernameKey
+//     fields of the KV secret at Path from the HashiCorp Vault at Address
+//     ($VAULT_ADDR by default) with Token ($VAULT_TOKEN by default).
+//   - aws-secrets-manager, which reads the secret SecretID from the AWS
+//     Secrets Manager of Region ($AWS_REGION by default) with the credentials
+//     of the AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN
+//     environment variables. JSON secrets have their Key and UsernameKey
+//     fields read, other secrets are the password.
+//
+// The username of the session is kept when the provider gives none. Other
+// providers can be added with Register.
+package credentials
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/quickfixgo/quickfix"
+
+	"sylr.dev/fix/pkg/errors"
+)
+
+// SettingKey is the session setting holding the credentials provider of a
+// session, encoded as JSON.
+const SettingKey = "CredentialsProvider"
+
+// DefaultTimeout is the time a provider has to fetch the credentials.
+const DefaultTimeout = 10 * time.Second
+
+// Config is the configuration of the credentials provider of a session. The
+// values are expanded with the environment.
+type Config struct {
+	Provider string `yaml:"Provider" json:"provider"`
+
+	Variable         string `yaml:"Variable,omitempty" json:"variable,omitempty"`
+	UsernameVariable string `yaml:"UsernameVariable,omitempty" json:"usernameVariable,omitempty"`
+
+	Path         string `yaml:"Path,omitempty" json:"path,omitempty"`
+	UsernamePath string `yaml:"UsernamePath,omitempty" json:"usernamePath,omitempty"`
+
+	Address string `yaml:"Address,omitempty" json:"address,omitempty"`
+	Token   string `yaml:"Token,omitempty" json:"token,omitempty"`
+
+	SecretID string `yaml:"SecretID,omitempty" json:"secretID,omitempty"`
+	Region   string `yaml:"Region,omitempty" json:"region,omitempty"`
+	// Endpoint replaces the endpoint of AWS Secrets Manager in the region
+	Endpoint string `yaml:"Endpoint,omitempty" json:"endpoint,omitempty"`
+
+	Key         string `yaml:"Key,omitempty" json:"key,omitempty"`
+	UsernameKey string `yaml:"UsernameKey,omitempty" json:"usernameKey,omitempty"`
+
+	Timeout time.Duration `yaml:"Timeout,omitempty" json:"timeout,omitempty"`
+}
+
+// Credentials are the Username and Password a session logs on with.
+type Credentials struct {
+	Username string
+	Password string
+}
+
+// Provider fetches the credentials of a session.
+type Provider interface {
+	Credentials(ctx context.Context) (Credentials, error)
+}
+
+// ProviderFunc is a function implementing Provider.
+type ProviderFunc func(ctx context.Context) (Credentials, error)
+
+func (f ProviderFunc) Credentials(ctx context.Context) (Credentials, error) {
+	return f(ctx)
+}
+
+var (
+	providers = make(map[string]func(Config) (Provider, error))
+	mux       sync.RWMutex
+)
+
+func init() {
+	Register("env", newEnv)
+	Register("file", newFile)
+	Register("vault", newVault)
+	Register("aws-secrets-manager", newAWSSecretsManager)
+}
+
+// Register makes a provider available under the name.
+func Register(name string, provider func(Config) (Provider, error)) {
+	mux.Lock()
+	defer mux.Unlock()
+
+	providers[name] = provider
+}
+
+// Names returns the names of the providers registered, sorted.
+func Names() []string {
+	mux.RLock()
+	defer mux.RUnlock()
+
+	return names()
+}
+
+func names() []string {
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// New returns the provider of the configuration.
+func New(config Config) (Provider, error) {
+	mux.RLock()
+	defer mux.RUnlock()
+
+	provider, ok := providers[config.Provider]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown credentials provider %q, expected one of %s", errors.Config, config.Provider, strings.Join(names(), ", "))
+	}
+
+	p, err := provider(config)
+	if err != nil {
+		return nil, fmt.Errorf("%w: credentials provider %s: %s", errors.Config, config.Provider, err)
+	}
+
+	return p, nil
+}
+
+// FromSessionSettings returns the credentials of the session: the ones last
+// fetched by its SettingKey provider if it has one, its Username and Password
+// settings otherwise. Only the first call for a provider waits for it, the
+// next ones starting a fetch in the background for the following call, so that
+// logons are not held up by the provider.
+func FromSessionSettings(settings *quickfix.SessionSettings) (Credentials, error) {
+	var username, password string
+	if settings.HasSetting("Username") {
+		username, _ = settings.Setting("Username")
+	}
+	if settings.HasSetting("Password") {
+		password, _ = settings.Setting("Password")
+	}
+
+	if !settings.HasSetting(SettingKey) {
+		return Credentials{Username: username, Password: password}, nil
+	}
+
+	value, err := settings.Setting(SettingKey)
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	var config Config
+	if err := json.Unmarshal([]byte(value), &config); err != nil {
+		return Credentials{}, fmt.Errorf("%w: %s: %s", errors.Config, SettingKey, err)
+	}
+
+	return prefetched(username, password, value, &config)
+}
+
+// fetch is the last fetch of the credentials of a provider.
+type fetch struct {
+	creds Credentials
+	err   error
+	// done is closed once the first fetch is over.
+	done     chan struct{}
+	fetching bool
+}
+
+var (
+	fetches    = make(map[string]*fetch)
+	fetchesMux sync.Mutex
+)
+
+// prefetched returns the credentials last fetched with the provider of config,
+// keyed by the setting value, and starts fetching them again.
+func prefetched(username, password, value string, config *Config) (Credentials, error) {
+	key := strings.Join([]string{username, password, value}, "\x00")
+
+	fetchesMux.Lock()
+	f, ok := fetches[key]
+	if !ok {
+		f = &fetch{done: make(chan struct{})}
+		fetches[key] = f
+	}
+	if !f.fetching {
+		f.fetching = true
+		go func(first bool) {
+			creds, err := Get(username, password, config)
+
+			fetchesMux.Lock()
+			f.creds, f.err, f.fetching = creds, err, false
+			fetchesMux.Unlock()
+
+			if first {
+				close(f.done)
+			}
+		}(!ok)
+	}
+	fetchesMux.Unlock()
+
+	<-f.done
+
+	fetchesMux.Lock()
+	defer fetchesMux.Unlock()
+
+	return f.creds, f.err
+}
+
+// Get returns the credentials fetched with the provider of config, username
+// being kept when the provider gives none, or username and password when
+// config is nil. No credentials are returned when the provider fails.
+func Get(username, password string, config *Config) (Credentials, error) {
+	if config == nil {
+		return Credentials{Username: username, Password: password}, nil
+	}
+
+	provider, err := New(*config)
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	fetched, err := provider.Credentials(ctx)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("credentials provider %s: %w", config.Provider, err)
+	}
+
+	creds := Credentials{Username: username, Password: fetched.Password}
+	if len(fetched.Username) > 0 {
+		creds.Username = fetched.Username
+	}
+
+	return creds, nil
+}
+
+func newEnv(config Config) (Provider, error) {
+	if len(config.Variable) == 0 {
+		return nil, fmt.Errorf("no Variable given")
+	}
+
+	return ProviderFunc(func(context.Context) (Credentials, error) {
+		password, ok := os.LookupEnv(config.Variable)
+		if !ok {
+			return Credentials{}, fmt.Errorf("%s is not set", config.Variable)
+		}
+
+		creds := Credentials{Password: password}
+		if len(config.UsernameVariable) > 0 {
+			creds.Username = os.Getenv(config.UsernameVariable)
+		}
+
+		return creds, nil
+	}), nil
+}
+
+func newFile(config Config) (Provider, error) {
+	if len(config.Path) == 0 {
+		return nil, fmt.Errorf("no Path given")
+	}
+
+	read := func(path string) (string, error) {
+		content, err := os.ReadFile(os.ExpandEnv(path))
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(content), "\r\n"), nil
+	}
+
+	return ProviderFunc(func(context.Context) (Credentials, error) {
+		var creds Credentials
+		var err error
+
+		if creds.Password, err = read(config.Path); err != nil {
+			return creds, err
+		}
+		if len(config.UsernamePath) > 0 {
+			if creds.Username, err = read(config.UsernamePath); err != nil {
+				return creds, err
+			}
+		}
+
+		return creds, nil
+	}), nil
+}
+
+// fromFields returns the credentials held by the Key and UsernameKey fields of
+// a secret.
+func fromFields(fields map[string]any, config Config) (Credentials, error) {
+	key := config.Key
+	if len(key) == 0 {
+		key = "password"
+	}
+
+	var creds Credentials
+	password, ok := fields[key].(string)
+	if !ok {
+		return creds, fmt.Errorf("secret has no %s field", key)
+	}
+	creds.Password = password
+
+	if len(config.UsernameKey) > 0 {
+		if creds.Username, ok = fields[config.UsernameKey].(string); !ok {
+			return creds, fmt.Errorf("secret has no %s field", config.UsernameKey)
+		}
+	}
+
+	return creds, nil
+}
@@ -0,0 +1,70 @@
+package credentials
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+func newVault(config Config) (Provider, error) {
+	if len(config.Path) == 0 {
+		return nil, fmt.Errorf("no Path given")
+	}
+
+	address := os.ExpandEnv(config.Address)
+	if len(address) == 0 {
+		address = os.Getenv("VAULT_ADDR")
+	}
+	if len(address) == 0 {
+		return nil, fmt.Errorf("no Address given and VAULT_ADDR is not set")
+	}
+
+	url := strings.TrimRight(address, "/") + "/v1/" + strings.TrimLeft(config.Path, "/")
+
+	return ProviderFunc(func(ctx context.Context) (Credentials, error) {
+		token := os.ExpandEnv(config.Token)
+		if len(token) == 0 {
+			token = os.Getenv("VAULT_TOKEN")
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return Credentials{}, err
+		}
+		req.Header.Set("X-Vault-Token", token)
+
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return Credentials{}, err
+		}
+		defer res.Body.Close()
+
+		body, err := io.ReadAll(res.Body)
+		if err != nil {
+			return Credentials{}, err
+		}
+		if res.StatusCode != http.StatusOK {
+			return Credentials{}, fmt.Errorf("vault: %s: %s", res.Status, strings.TrimSpace(string(body)))
+		}
+
+		// KV version 2 secrets nest their fields in data.data.
+		var secret struct {
+			Data map[string]any `json:"data"`
+		}
+		if err := json.Unmarshal(body, &secret); err != nil {
+			return Credentials{}, fmt.Errorf("vault: %w", err)
+		}
+		fields := secret.Data
+		if nested, ok := fields["data"].(map[string]any); ok {
+			if _, ok := fields["metadata"]; ok {
+				fields = nested
+			}
+		}
+
+		return fromFields(fields, config)
+	}), nil
+}
@@ -0,0 +1,158 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"sylr.dev/fix/pkg/errors"
+)
+
+const dictionaryChecksumFragment = "sha256="
+
+var dictionaryHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// IsRemoteDictionary tells whether the dictionary location is a URL which needs
+// to be downloaded.
+func IsRemoteDictionary(location string) bool {
+	return strings.HasPrefix(location, "https://") || strings.HasPrefix(location, "http://")
+}
+
+// DictionaryPath returns the local path of the dictionary. Environment
+// variables are expanded and remote dictionaries are downloaded into the cache
+// directory if they are not already there.
+//
+// Remote locations can be pinned to a given content by adding a
+// `#sha256=<hex>` fragment to the URL, which plain http:// URLs require.
+func DictionaryPath(location string) (string, error) {
+	location = os.ExpandEnv(location)
+	if !IsRemoteDictionary(location) {
+		return location, nil
+	}
+
+	return fetchDictionary(location, false)
+}
+
+// UpdateDictionary downloads again a remote dictionary even if it is already
+// present in the cache and returns its local path.
+func UpdateDictionary(location string) (string, error) {
+	location = os.ExpandEnv(location)
+	if !IsRemoteDictionary(location) {
+		return location, nil
+	}
+
+	return fetchDictionary(location, true)
+}
+
+func DictionaryCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "fix", "dictionaries"), nil
+}
+
+func fetchDictionary(location string, force bool) (string, error) {
+	u, err := url.Parse(location)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s: %w", errors.ConfigDictionary, location, err)
+	}
+
+	expected := strings.TrimPrefix(u.Fragment, dictionaryChecksumFragment)
+	if expected == u.Fragment {
+		expected = ""
+	}
+	u.Fragment = ""
+
+	// Dictionaries downloaded without TLS could have been tampered with
+	if u.Scheme == "http" && len(expected) == 0 {
+		return "", fmt.Errorf("%w: %s: http:// URLs must be pinned with #%s<hex>", errors.ConfigDictionary, u.String(), dictionaryChecksumFragment)
+	}
+
+	dir, err := DictionaryCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	key := sha256.Sum256([]byte(u.String()))
+	file := filepath.Join(dir, hex.EncodeToString(key[:])+".xml")
+
+	if !force {
+		if sum, err := fileChecksum(file); err == nil {
+			// The cached file is used if it has not been altered since it has
+			// been downloaded and if it matches the pinned checksum.
+			if cached, err := os.ReadFile(file + ".sha256"); err == nil && strings.TrimSpace(string(cached)) == sum {
+				if len(expected) == 0 || strings.EqualFold(expected, sum) {
+					return file, nil
+				}
+			}
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+
+	res, err := dictionaryHTTPClient.Get(u.String())
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", errors.ConfigDictionary, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%w: %s: %s", errors.ConfigDictionary, u.String(), res.Status)
+	}
+
+	tmp, err := os.CreateTemp(dir, ".download-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(tmp, hash), res.Body); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("%w: %s: %w", errors.ConfigDictionary, u.String(), err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+
+	sum := hex.EncodeToString(hash.Sum(nil))
+	if len(expected) > 0 && !strings.EqualFold(expected, sum) {
+		return "", fmt.Errorf("%w: %s: expected %s, got %s", errors.ConfigDictionaryChecksumMismatch, u.String(), expected, sum)
+	}
+
+	if err := os.Rename(tmp.Name(), file); err != nil {
+		return "", err
+	}
+
+	if err := os.WriteFile(file+".sha256", []byte(sum+"\n"), 0600); err != nil {
+		return "", err
+	}
+
+	return file, nil
+}
+
+func fileChecksum(file string) (string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+)
+
+// LocalConfigFile is the name of the project-local configuration file which
+// is looked up in the current working directory.
+const LocalConfigFile = ".fix.yaml"
+
+// SystemConfigFile is the system wide configuration file.
+var SystemConfigFile = filepath.Join("/etc", "fix", "config")
+
+// ConfigPaths returns the configuration files to load, from the lowest to the
+// highest precedence. If the configuration file has been given explicitly
+// with --config it is the only one used, otherwise the existing files among
+// the following ones are returned:
+//   - /etc/fix/config
+//   - $XDG_CONFIG_HOME/fix/config
+//   - the --config default value ($HOME/.fix/config)
+//   - .fix.yaml in the current working directory
+func ConfigPaths(explicit bool) []string {
+	if explicit {
+		return []string{options.Config}
+	}
+
+	candidates := []string{SystemConfigFile}
+
+	if dir, err := os.UserConfigDir(); err == nil {
+		candidates = append(candidates, filepath.Join(dir, "fix", "config"))
+	}
+
+	candidates = append(candidates, options.Config)
+
+	if wd, err := os.Getwd(); err == nil {
+		candidates = append(candidates, filepath.Join(wd, LocalConfigFile))
+	}
+
+	paths := make([]string, 0, len(candidates))
+	for _, candidate := range candidates {
+		if st, err := os.Stat(candidate); err == nil && !st.IsDir() && !containsPath(paths, candidate) {
+			paths = append(paths, candidate)
+		}
+	}
+
+	// Keep the historical error message if no configuration file exists.
+	if len(paths) == 0 {
+		return []string{options.Config}
+	}
+
+	return paths
+}
+
+func containsPath(paths []string, path string) bool {
+	for _, p := range paths {
+		if filepath.Clean(p) == filepath.Clean(path) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// merge merges the given configuration into the current one. Items which have
+// the same name are merged together, the fields set in the given configuration
+// overriding the existing ones.
+func (f *fixConfig) merge(o *fixConfig) {
+	f.Contexts = mergeItems(f.Contexts, o.Contexts)
+	f.Acceptors = mergeItems(f.Acceptors, o.Acceptors)
+	f.Initiators = mergeItems(f.Initiators, o.Initiators)
+	f.Sessions = mergeItems(f.Sessions, o.Sessions)
+
+	if len(o.CurrentContext) > 0 {
+		f.CurrentContext = o.CurrentContext
+	}
+}
+
+func mergeItems[T HasName](base []T, override []T) []T {
+	// Only look up the items of the base so that duplicates of the override
+	// are kept and reported by Validate().
+	n := len(base)
+
+ITEMS:
+	for _, item := range override {
+		for i, existing := range base[:n] {
+			if existing.GetName() == item.GetName() {
+				inheritFields(reflect.ValueOf(item).Elem(), reflect.ValueOf(existing).Elem(), "Name")
+				base[i] = item
+				continue ITEMS
+			}
+		}
+		base = append(base, item)
+	}
+
+	return base
+}
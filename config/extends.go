@@ -0,0 +1,109 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+
+	"sylr.dev/fix/pkg/errors"
+	"sylr.dev/fix/pkg/utils"
+)
+
+// Extendable is implemented by config items which can inherit the values of
+// another item of the same kind through the `extends` key.
+type Extendable interface {
+	HasName
+	GetExtends() string
+}
+
+// resolveExtends fills the fields left empty in the items which extend another
+// one with the values of their parent. Parents are resolved first so chains of
+// extends are supported; cycles are reported as errors.
+func resolveExtends[T Extendable](items []T) error {
+	byName := make(map[string]T, len(items))
+	for _, item := range items {
+		byName[item.GetName()] = item
+	}
+
+	resolved := make(map[string]bool, len(items))
+
+	var resolve func(item T, chain []string) error
+	resolve = func(item T, chain []string) error {
+		name := item.GetName()
+		if resolved[name] || len(item.GetExtends()) == 0 {
+			resolved[name] = true
+			return nil
+		}
+
+		for _, n := range chain {
+			if n == name {
+				return fmt.Errorf("%w: %v", errors.ConfigExtendsCycle, append(chain, name))
+			}
+		}
+
+		parent, ok := byName[item.GetExtends()]
+		if !ok {
+			return fmt.Errorf("%w: %s extends %s", errors.ConfigExtendsNotFound, name, item.GetExtends())
+		}
+
+		if err := resolve(parent, append(chain, name)); err != nil {
+			return err
+		}
+
+		inheritFields(reflect.ValueOf(item).Elem(), reflect.ValueOf(parent).Elem(), "Name", "Extends")
+		resolved[name] = true
+
+		return nil
+	}
+
+	for _, item := range items {
+		if err := resolve(item, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// inheritFields copies into dst the fields of src for which dst holds the zero
+// value, except the ones listed in skip.
+//
+// Note that boolean fields can not be reset to false by a child.
+func inheritFields(dst, src reflect.Value, skip ...string) {
+	t := dst.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			inheritFields(dst.Field(i), src.Field(i), skip...)
+			continue
+		}
+
+		if !f.IsExported() || utils.Search(skip, f.Name) >= 0 {
+			continue
+		}
+
+		if dst.Field(i).IsZero() {
+			dst.Field(i).Set(src.Field(i))
+		}
+	}
+}
+
+func (f *fixConfig) resolveExtends() error {
+	if err := resolveExtends(f.Contexts); err != nil {
+		return err
+	}
+
+	if err := resolveExtends(f.Acceptors); err != nil {
+		return err
+	}
+
+	if err := resolveExtends(f.Initiators); err != nil {
+		return err
+	}
+
+	if err := resolveExtends(f.Sessions); err != nil {
+		return err
+	}
+
+	return nil
+}
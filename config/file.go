@@ -16,6 +16,8 @@ import (
 	"golang.org/x/crypto/ssh"
 	"golang.org/x/term"
 
+	"sylr.dev/fix/pkg/errors"
+
 	yage "sylr.dev/yaml/age/v3"
 	yaml "sylr.dev/yaml/v3"
 )
@@ -28,33 +30,81 @@ var (
 )
 
 func ReadYAMLNoAge(path string) (*fixConfig, error) {
+	return ReadYAMLFilesNoAge([]string{path})
+}
+
+// ReadYAMLFilesNoAge reads and merges the given configuration files without
+// decrypting the age encrypted values.
+func ReadYAMLFilesNoAge(paths []string) (*fixConfig, error) {
+	return readYAMLFiles(paths, readYAMLNoAge)
+}
+
+func ReadYAML(path string, interactive bool) (*fixConfig, error) {
+	return ReadYAMLFiles([]string{path}, interactive)
+}
+
+// ReadYAMLFiles reads and merges the given configuration files. Files given
+// last have precedence over the first ones.
+func ReadYAMLFiles(paths []string, interactive bool) (*fixConfig, error) {
+	return readYAMLFiles(paths, func(path string) (*fixConfig, error) {
+		return readYAML(path, interactive)
+	})
+}
+
+func readYAMLFiles(paths []string, read func(string) (*fixConfig, error)) (*fixConfig, error) {
+	fix := &fixConfig{}
+
+	for _, path := range paths {
+		conf, err := read(path)
+		if err != nil {
+			return nil, err
+		}
+
+		fix.merge(conf)
+	}
+
+	if err := fix.resolveExtends(); err != nil {
+		return nil, err
+	}
+
+	return fix, nil
+}
+
+func readYAMLNoAge(path string) (*fixConfig, error) {
 	file, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
 
-	in := bytes.NewBuffer(file)
 	fix := fixConfig{}
+	if err := checkConfigKeys(path, file); err != nil {
+		return nil, err
+	}
+
+	in := bytes.NewBuffer(file)
 	decoder := yaml.NewDecoder(in)
 	decoder.KnownFields(true)
 	err = decoder.Decode(&fix)
 
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: %s: %w", errors.ConfigInvalid, path, err)
 	}
 
 	return &fix, nil
 }
 
-func ReadYAML(path string, interactive bool) (*fixConfig, error) {
+func readYAML(path string, interactive bool) (*fixConfig, error) {
 	file, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
 
-	in := bytes.NewBuffer(file)
 	fix := fixConfig{}
+	if err := checkConfigKeys(path, file); err != nil {
+		return nil, err
+	}
 
+	in := bytes.NewBuffer(file)
 	ids := GetAgeIdentities(interactive)
 	w := yage.Wrapper{
 		Value:      &fix,
@@ -65,12 +115,23 @@ func ReadYAML(path string, interactive bool) (*fixConfig, error) {
 	err = decoder.Decode(&w)
 
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: %s: %w", errors.ConfigInvalid, path, err)
 	}
 
 	return &fix, nil
 }
 
+// checkConfigKeys parses the raw YAML and reports unknown keys and values of
+// the wrong type along with their position in the file.
+func checkConfigKeys(path string, content []byte) error {
+	node := yaml.Node{}
+	if err := yaml.Unmarshal(content, &node); err != nil {
+		return fmt.Errorf("%w: %s: %w", errors.ConfigInvalid, path, err)
+	}
+
+	return checkSchema(path, &node, JSONSchema())
+}
+
 func GetAgeIdentities(interactive bool) []age.Identity {
 	var ids []age.Identity
 	var paths []string
@@ -13,3 +13,22 @@ func GetLogger() *zerolog.Logger {
 func SetLogger(l *zerolog.Logger) {
 	logger = l
 }
+
+// GetLogger returns the logger to use for the session. If the session has a
+// LogLevel it is used unless the verbosity or the log level have been set on
+// the command line.
+func (s Session) GetLogger() *zerolog.Logger {
+	if len(s.LogLevel) == 0 || options.Verbose > 0 || len(options.LogLevel) > 0 || options.Quiet || logger == nil {
+		return logger
+	}
+
+	level, err := zerolog.ParseLevel(s.LogLevel)
+	if err != nil {
+		logger.Warn().Err(err).Str("session", s.Name).Msg("Invalid session LogLevel, ignoring it")
+		return logger
+	}
+
+	l := logger.Level(level)
+
+	return &l
+}
@@ -0,0 +1,145 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	yaml "sylr.dev/yaml/v3"
+)
+
+// renamedKeys lists the keys which have been renamed, by object path.
+var renamedKeys = map[string]map[string]string{
+	"": {
+		"current_context": "current-context",
+		"currentContext":  "current-context",
+		"context":         "contexts",
+		"acceptor":        "acceptors",
+		"initiator":       "initiators",
+		"session":         "sessions",
+	},
+	"contexts": {
+		"session": "sessions",
+	},
+	"sessions": {
+		"reset-on-logon": "ResetOnLogon",
+	},
+}
+
+// Migration is a change applied by MigrateYAML.
+type Migration struct {
+	Line        int
+	Description string
+}
+
+// CheckYAML validates the content of a configuration file against the schema.
+func CheckYAML(path string, content []byte) error {
+	return checkConfigKeys(path, content)
+}
+
+// MigrateYAML upgrades an older configuration to the current layout and
+// returns the migrated document along with the list of changes. The returned
+// document is re-encoded even if no change was made.
+func MigrateYAML(content []byte) ([]byte, []Migration, error) {
+	node := yaml.Node{}
+	if err := yaml.Unmarshal(content, &node); err != nil {
+		return nil, nil, err
+	}
+
+	var migrations []Migration
+	migrateNode(&node, JSONSchema(), "", &migrations)
+
+	out, err := EncodeYAML(&node)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return out, migrations, nil
+}
+
+// EncodeYAML encodes the node using the indentation of the configuration
+// files generated by fix.
+func EncodeYAML(node *yaml.Node) ([]byte, error) {
+	buf := bytes.Buffer{}
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(node); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func migrateNode(node *yaml.Node, schema *Schema, path string, migrations *[]Migration) {
+	if schema == nil {
+		return
+	}
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, n := range node.Content {
+			migrateNode(n, schema, path, migrations)
+		}
+
+	case yaml.SequenceNode:
+		for _, n := range node.Content {
+			migrateNode(n, schema.Items, path, migrations)
+		}
+
+	case yaml.MappingNode:
+		if schema.Properties == nil {
+			return
+		}
+
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+
+			if _, ok := schema.Properties[key.Value]; !ok {
+				if renamed, ok := renamedKeys[path][key.Value]; ok {
+					*migrations = append(*migrations, Migration{key.Line, fmt.Sprintf("rename %q to %q", key.Value, renamed)})
+					key.Value = renamed
+				} else if name := caseInsensitiveKey(key.Value, schema.Properties); len(name) > 0 {
+					*migrations = append(*migrations, Migration{key.Line, fmt.Sprintf("rename %q to %q", key.Value, name)})
+					key.Value = name
+				}
+			}
+
+			property, ok := schema.Properties[key.Value]
+			if !ok {
+				continue
+			}
+
+			// Values which became lists.
+			if property.Type == "array" && (value.Kind == yaml.ScalarNode || value.Kind == yaml.MappingNode) {
+				*migrations = append(*migrations, Migration{value.Line, fmt.Sprintf("turn %q into a list", key.Value)})
+				item := *value
+				*value = yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Content: []*yaml.Node{&item}}
+				if item.Kind == yaml.ScalarNode {
+					value.Style = yaml.FlowStyle
+				}
+			}
+
+			// Durations which used to be given in seconds.
+			if property.Pattern == durationPattern && value.Kind == yaml.ScalarNode && value.ShortTag() == "!!int" {
+				*migrations = append(*migrations, Migration{value.Line, fmt.Sprintf("turn %q into a duration", key.Value)})
+				value.Value += "s"
+				value.Tag = "!!str"
+			}
+
+			migrateNode(value, property, strings.TrimPrefix(path+"."+key.Value, "."), migrations)
+		}
+	}
+}
+
+func caseInsensitiveKey(key string, properties map[string]*Schema) string {
+	for name := range properties {
+		if strings.EqualFold(name, key) {
+			return name
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/quickfixgo/quickfix"
+
+	"sylr.dev/fix/pkg/utils"
+)
+
+var reloadMux sync.Mutex
+
+// Reload reads the configuration files again and applies to the running
+// sessions of the current context the settings which can change without
+// restarting them, see utils.ReloadQuickFixSettings. The configuration in use
+// is kept if the files can not be read or are invalid. Encrypted values can
+// not be prompted for. The sessions of the current context are kept, running
+// sessions can not be added or removed.
+func Reload(explicit bool, toSettings func(Context) (*quickfix.Settings, error)) error {
+	reloadMux.Lock()
+	defer reloadMux.Unlock()
+
+	conf, err := ReadYAMLFiles(ConfigPaths(explicit), false)
+	if err != nil {
+		return fmt.Errorf("unable to read configuration: %w", err)
+	}
+
+	if err := conf.Validate(); err != nil {
+		return err
+	}
+
+	// The context built from --initiator/--session is not in the files
+	if current, err := GetCurrentContext(); err == nil {
+		found := false
+		for _, context := range conf.Contexts {
+			if context.Name == current.Name {
+				context.Sessions = current.Sessions
+				found = true
+			}
+		}
+		if !found && current.Name == options.Context {
+			conf.Contexts = append(conf.Contexts, current)
+		}
+	}
+
+	previous := config
+	config = *conf
+
+	context, err := GetCurrentContext()
+	if err == nil {
+		var settings *quickfix.Settings
+		if settings, err = toSettings(*context); err == nil {
+			err = utils.ReloadQuickFixSettings(settings)
+		}
+	}
+	if err != nil {
+		config = previous
+		return err
+	}
+
+	return nil
+}
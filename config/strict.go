@@ -0,0 +1,170 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"sylr.dev/fix/pkg/errors"
+
+	yaml "sylr.dev/yaml/v3"
+)
+
+// checkSchema walks the YAML node tree and validates it against the JSON
+// Schema of the configuration: every mapping key must be known and scalar
+// values must be of the expected type. It is used in addition to the decoder's
+// KnownFields because the age wrapper decodes through yaml.Node which does not
+// enforce it, and because we want to report the file, the line and the closest
+// known key.
+func checkSchema(path string, node *yaml.Node, schema *Schema) error {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, n := range node.Content {
+			if err := checkSchema(path, n, schema); err != nil {
+				return err
+			}
+		}
+
+	case yaml.SequenceNode:
+		if schema.Type != "array" {
+			return typeError(path, node, schema)
+		}
+		for _, n := range node.Content {
+			if err := checkSchema(path, n, schema.Items); err != nil {
+				return err
+			}
+		}
+
+	case yaml.MappingNode:
+		if schema.Type != "object" {
+			return typeError(path, node, schema)
+		}
+		if schema.Properties == nil {
+			return nil
+		}
+
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			// Merge keys are resolved by the decoder.
+			if key.Value == "<<" {
+				continue
+			}
+
+			property, ok := schema.Properties[key.Value]
+			if !ok {
+				return unknownKeyError(path, key, schema)
+			}
+
+			if err := checkSchema(path, node.Content[i+1], property); err != nil {
+				return err
+			}
+		}
+
+	case yaml.ScalarNode:
+		if !scalarMatches(node, schema) {
+			return typeError(path, node, schema)
+		}
+	}
+
+	return nil
+}
+
+func scalarMatches(node *yaml.Node, schema *Schema) bool {
+	// Encrypted values are only known once decrypted.
+	if strings.HasPrefix(node.Tag, "!crypto/age") || node.ShortTag() == "!!null" {
+		return true
+	}
+
+	switch schema.Type {
+	case "boolean":
+		return node.ShortTag() == "!!bool"
+	case "integer":
+		return node.ShortTag() == "!!int"
+	case "number":
+		return node.ShortTag() == "!!int" || node.ShortTag() == "!!float"
+	case "string":
+		if len(schema.Pattern) > 0 {
+			return regexp.MustCompile(schema.Pattern).MatchString(node.Value)
+		}
+		return true
+	case "array", "object":
+		return false
+	}
+
+	return true
+}
+
+func typeError(path string, node *yaml.Node, schema *Schema) error {
+	expected := schema.Type
+	if len(schema.Pattern) > 0 {
+		expected = fmt.Sprintf("%s matching %s", expected, schema.Pattern)
+	}
+
+	return fmt.Errorf("%w: %s:%d:%d: expected %s", errors.ConfigInvalid, path, node.Line, node.Column, expected)
+}
+
+func unknownKeyError(path string, key *yaml.Node, schema *Schema) error {
+	err := fmt.Errorf("%w: %s:%d:%d: %q", errors.ConfigUnknownKey, path, key.Line, key.Column, key.Value)
+
+	if suggestion := closestKey(key.Value, schema.Properties); len(suggestion) > 0 {
+		err = fmt.Errorf("%w, did you mean %q?", err, suggestion)
+	}
+
+	return err
+}
+
+// closestKey returns the known key which is the closest to the given one or
+// an empty string if none of them are close enough to be a likely typo.
+func closestKey(key string, properties map[string]*Schema) string {
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	best, bestDistance := "", -1
+	lkey := strings.ToLower(key)
+
+	for _, name := range names {
+		d := levenshtein(lkey, strings.ToLower(name))
+		if bestDistance == -1 || d < bestDistance {
+			best, bestDistance = name, d
+		}
+	}
+
+	threshold := len(key) / 3
+	if threshold < 2 {
+		threshold = 2
+	}
+
+	if bestDistance < 0 || bestDistance > threshold {
+		return ""
+	}
+
+	return best
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
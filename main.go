@@ -1,18 +1,31 @@
 package main
 
 import (
+	"context"
 	"os"
+	"os/signal"
+	"syscall"
 
 	_ "github.com/lib/pq"
 	_ "github.com/mattn/go-sqlite3"
 
 	"sylr.dev/fix/cmd"
+	"sylr.dev/fix/pkg/tracing"
 )
 
 func main() {
-	err := cmd.FixCmd.Execute()
+	// Interrupting cancels the context of the command, interrupting a second
+	// time kills the process
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
 
-	if err != nil {
-		os.Exit(1)
-	}
+	err := cmd.FixCmd.ExecuteContext(ctx)
+
+	// Export the spans still pending
+	tracing.Shutdown()
+
+	os.Exit(cmd.ExitCode(err))
 }
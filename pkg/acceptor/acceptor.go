@@ -1,32 +1,24 @@
 package acceptor
 
 import (
-	"github.com/quickfixgo/quickfix/store/sql"
 	"github.com/rs/zerolog"
 
 	"github.com/quickfixgo/quickfix"
 
+	"sylr.dev/fix/pkg/store"
 	"sylr.dev/fix/pkg/utils"
 )
 
 func NewAcceptor(app quickfix.Application, settings *quickfix.Settings, logger *zerolog.Logger) (*quickfix.Acceptor, error) {
-	var msgStoreFactory quickfix.MessageStoreFactory
-
-	if settings.GlobalSettings().HasSetting("SQLStoreDriver") {
-		driver, err := settings.GlobalSettings().Setting("SQLStoreDriver")
-		if err != nil {
-			return nil, err
-		}
-		switch driver {
-		case "sqlite3":
-		case "postgres":
-			msgStoreFactory = sql.NewStoreFactory(settings)
-		}
+	msgStoreFactory, err := store.NewMessageStoreFactory(settings)
+	if err != nil {
+		return nil, err
 	}
 
-	if msgStoreFactory == nil {
-		msgStoreFactory = quickfix.NewMemoryStoreFactory()
+	app, err = utils.NewQuickFixThrottledApplication(app, settings)
+	if err != nil {
+		return nil, err
 	}
 
-	return quickfix.NewAcceptor(app, msgStoreFactory, settings, utils.NewQuickFixLogFactory(logger))
+	return quickfix.NewAcceptor(app, msgStoreFactory, settings, utils.NewQuickFixSessionLogFactory(logger, settings))
 }
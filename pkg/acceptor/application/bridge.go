@@ -1,19 +1,26 @@
 package application
 
 import (
+	"context"
+	"sync"
+	"time"
+
 	"github.com/rs/zerolog"
 
 	"github.com/quickfixgo/enum"
 	"github.com/quickfixgo/quickfix"
 	"github.com/quickfixgo/tag"
 
+	"sylr.dev/fix/pkg/health"
+	"sylr.dev/fix/pkg/positions"
+	"sylr.dev/fix/pkg/tracing"
 	"sylr.dev/fix/pkg/utils"
 )
 
 func NewBridge() *Bridge {
 	bridge := Bridge{
 		connectedExchanges: []quickfix.SessionID{},
-		orderMapping:       make(map[string]quickfix.SessionID),
+		orderMapping:       make(map[string]bridgeMapping),
 		router:             quickfix.NewMessageRouter(),
 	}
 
@@ -45,13 +52,58 @@ type Bridge struct {
 	utils.QuickFixAppMessageLogger
 
 	connectedExchanges []quickfix.SessionID
-	orderMapping       map[string]quickfix.SessionID
+	orderMapping       map[string]bridgeMapping
+	exchangesMux       sync.RWMutex
+	orderMappingMux    sync.RWMutex
+
+	// mappingStore, when set, persists the order mapping
+	mappingStore bridgeMappingStore
+	mappingDone  chan struct{}
+	mappingWG    sync.WaitGroup
+
+	closeOnce sync.Once
+
+	// cluster, when set, shares the order mapping and the exchanges with the
+	// other instances
+	cluster *bridgeCluster
 
 	router   *quickfix.MessageRouter
 	Settings *quickfix.Settings
+
+	// Positions, when set, nets the fills of the execution reports received
+	// from the exchanges.
+	Positions *positions.Book
+
+	// TargetSession, when set, is the exchange session the client messages
+	// are sent to, the first exchange session logged on being used otherwise.
+	TargetSession *quickfix.SessionID
+
+	// Routes, when set, route the client messages to the exchange sessions of
+	// the first one they match, the ones matching none being sent to the
+	// default exchange session. Cancels and replaces follow their original
+	// order.
+	Routes []*BridgeRoute
+
+	// DropCopy, when set, copies the execution reports sent to the clients to
+	// drop copy sessions, which are neither client nor exchange sessions.
+	DropCopy *BridgeDropCopy
 }
 
+// Close leaves the cluster and closes the order mapping store, once the order
+// mapping expiry stopped. Calling it again does nothing.
 func (app *Bridge) Close() {
+	app.closeOnce.Do(func() {
+		if app.cluster != nil {
+			app.cluster.close()
+		}
+		if app.mappingStore != nil {
+			close(app.mappingDone)
+			app.mappingWG.Wait()
+			if err := app.mappingStore.Close(); err != nil {
+				app.Logger.Error().Err(err).Msg("Could not close the bridge order mapping")
+			}
+		}
+	})
 }
 
 // OnCreate notifies session creation.
@@ -62,25 +114,62 @@ func (app *Bridge) OnCreate(sessionID quickfix.SessionID) {
 // OnLogon notifies session successfully logging on.
 func (app *Bridge) OnLogon(sessionID quickfix.SessionID) {
 	app.Logger.Debug().Msgf("Logon: %s", sessionID)
-	if !sessionID.IsFIXT() {
+	health.LoggedOn(sessionID)
+	if !sessionID.IsFIXT() && !app.isDropCopy(sessionID) {
+		app.exchangesMux.Lock()
 		app.connectedExchanges = append(app.connectedExchanges, sessionID)
+		if len(app.connectedExchanges) > 1 && app.TargetSession == nil {
+			app.Logger.Warn().Msgf("Several exchange sessions logged on, client messages are sent to %s", app.connectedExchanges[0])
+		}
+		app.exchangesMux.Unlock()
+
+		if app.cluster != nil {
+			app.cluster.exchangesChanged()
+		}
 	}
 }
 
 // OnLogout notifies session logging off or disconnecting.
 func (app *Bridge) OnLogout(sessionID quickfix.SessionID) {
 	app.Logger.Debug().Msgf("Logout: %s", sessionID)
-	if !sessionID.IsFIXT() {
+	health.LoggedOut(sessionID)
+	if !sessionID.IsFIXT() && !app.isDropCopy(sessionID) {
+		app.exchangesMux.Lock()
 		for i, s := range app.connectedExchanges {
 			if s == sessionID {
 				app.connectedExchanges = append(app.connectedExchanges[:i], app.connectedExchanges[i+1:]...)
 				break
 			}
 		}
-		app.connectedExchanges = append(app.connectedExchanges, sessionID)
+		app.exchangesMux.Unlock()
+
+		if app.cluster != nil {
+			app.cluster.exchangesChanged()
+		}
 	}
 }
 
+// exchange returns the exchange session the client messages are sent to.
+func (app *Bridge) exchange() (quickfix.SessionID, bool) {
+	app.exchangesMux.RLock()
+	defer app.exchangesMux.RUnlock()
+
+	if app.TargetSession != nil {
+		for _, s := range app.connectedExchanges {
+			if s == *app.TargetSession {
+				return s, true
+			}
+		}
+		return quickfix.SessionID{}, false
+	}
+
+	if len(app.connectedExchanges) == 0 {
+		return quickfix.SessionID{}, false
+	}
+
+	return app.connectedExchanges[0], true
+}
+
 // ToAdmin notifies admin message being sent to target.
 func (app *Bridge) ToAdmin(message *quickfix.Message, sessionID quickfix.SessionID) {
 	app.LogMessage(zerolog.TraceLevel, message, sessionID, true)
@@ -101,6 +190,9 @@ func (app *Bridge) ToApp(message *quickfix.Message, sessionID quickfix.SessionID
 // FromApp notifies app message being received from target.
 func (app *Bridge) FromApp(message *quickfix.Message, sessionID quickfix.SessionID) quickfix.MessageRejectError {
 	app.LogMessage(zerolog.TraceLevel, message, sessionID, true)
+	if app.isDropCopy(sessionID) {
+		return quickfix.UnsupportedMessageType()
+	}
 	return app.router.Route(message, sessionID)
 }
 
@@ -130,28 +222,71 @@ func (app *Bridge) onQuoteCancelClient(msg *quickfix.Message, sessionID quickfix
 	return app.forwardClientMessageToExchange(msg, sessionID)
 }
 
-func (app *Bridge) forwardClientMessageToExchange(msg *quickfix.Message, sessionID quickfix.SessionID) quickfix.MessageRejectError {
-	if len(app.connectedExchanges) == 0 {
-		return quickfix.NewMessageRejectError("No connected exchanges", int(tag.BusinessRejectReason), nil)
+// routingID returns the id the responses to the message are routed by, the
+// QuoteID of the quote messages and the ClOrdID of the others.
+func routingID(msg *quickfix.Message) (string, quickfix.MessageRejectError) {
+	msgType, _ := msg.MsgType()
+
+	switch enum.MsgType(msgType) {
+	case enum.MsgType_QUOTE, enum.MsgType_QUOTE_CANCEL, enum.MsgType_QUOTE_STATUS_REPORT:
+		quoteId, err := msg.Body.GetString(tag.QuoteID)
+		if err != nil {
+			return "", quickfix.NewMessageRejectError("Missing QuoteID", int(tag.BusinessRejectReason), nil)
+		}
+		return quoteId, nil
 	}
-	target := app.connectedExchanges[0]
 
 	clOrdId, err := msg.Body.GetString(tag.ClOrdID)
 	if err != nil {
-		return quickfix.NewMessageRejectError("Missing ClOrdID", int(tag.BusinessRejectReason), nil)
+		return "", quickfix.NewMessageRejectError("Missing ClOrdID", int(tag.BusinessRejectReason), nil)
 	}
 
-	app.orderMapping[clOrdId] = sessionID
+	return clOrdId, nil
+}
 
-	if err := quickfix.SendToTarget(msg, target); err != nil {
-		return quickfix.NewMessageRejectError(err.Error(), int(tag.BusinessRejectReason), nil)
+func (app *Bridge) forwardClientMessageToExchange(msg *quickfix.Message, sessionID quickfix.SessionID) quickfix.MessageRejectError {
+	target, connected := app.route(msg)
+	if !connected && app.cluster == nil {
+		return quickfix.NewMessageRejectError("No connected exchanges", int(tag.BusinessRejectReason), nil)
 	}
-	return nil
+
+	clOrdId, rerr := routingID(msg)
+	if rerr != nil {
+		return rerr
+	}
+
+	mapping := bridgeMapping{Session: sessionID, Time: time.Now()}
+	if connected {
+		mapping.Exchange = target
+	}
+	app.orderMappingMux.Lock()
+	app.orderMapping[clOrdId] = mapping
+	app.orderMappingMux.Unlock()
+
+	if app.mappingStore != nil {
+		if err := app.mappingStore.Save(clOrdId, mapping); err != nil {
+			app.Logger.Error().Err(err).Str("clOrdId", clOrdId).Msg("Could not persist the client session of the order")
+		}
+	}
+
+	if app.cluster != nil {
+		if err := app.cluster.mapOrder(clOrdId, sessionID); err != nil {
+			return quickfix.NewMessageRejectError(err.Error(), int(tag.BusinessRejectReason), nil)
+		}
+		if !connected {
+			return app.cluster.forwardToExchange(msg, sessionID)
+		}
+	}
+
+	app.toExchange(msg, target)
+
+	return app.forward(msg, sessionID, target)
 }
 
 /////////////// Exchange messages
 
 func (app *Bridge) onExecutionReportExchange(msg *quickfix.Message, sessionID quickfix.SessionID) quickfix.MessageRejectError {
+	app.Positions.OnExecutionReport(msg)
 	return app.forwardExchangeMessageToClient(msg, sessionID)
 }
 
@@ -164,24 +299,45 @@ func (app *Bridge) onMassCancelReportExchange(msg *quickfix.Message, sessionID q
 }
 
 func (app *Bridge) onQuoteStatusReportExchange(msg *quickfix.Message, sessionID quickfix.SessionID) quickfix.MessageRejectError {
-	return app.unhandledMessage(msg, sessionID)
+	return app.forwardExchangeMessageToClient(msg, sessionID)
 }
 
 func (app *Bridge) forwardExchangeMessageToClient(msg *quickfix.Message, sessionID quickfix.SessionID) quickfix.MessageRejectError {
-	clOrdId, err := msg.Body.GetString(tag.ClOrdID)
-	if err != nil {
-		return quickfix.NewMessageRejectError("Missing ClOrdID", int(tag.BusinessRejectReason), nil)
+	app.toClient(msg, sessionID)
+
+	clOrdId, rerr := routingID(msg)
+	if rerr != nil {
+		return rerr
 	}
 
-	clientSessionID, found := app.orderMapping[clOrdId]
-	if !found {
+	app.orderMappingMux.RLock()
+	mapping, found := app.orderMapping[clOrdId]
+	app.orderMappingMux.RUnlock()
+
+	if !found && app.cluster != nil {
+		return app.cluster.forwardToClient(clOrdId, msg, sessionID)
+	} else if !found {
 		app.Logger.Warn().Str("clOrdId", clOrdId).Str("session", sessionID.String()).Msg("No client session found for ClOrdID")
 		return nil
 	}
 
-	if err := quickfix.SendToTarget(msg, clientSessionID); err != nil {
+	return app.forward(msg, sessionID, mapping.Session)
+}
+
+// forward sends the message received on a session to another one.
+func (app *Bridge) forward(msg *quickfix.Message, from, to quickfix.SessionID) quickfix.MessageRejectError {
+	ctx, span := tracing.StartMessage(context.Background(), "fix receive", tracing.SpanKindServer, msg, from)
+	defer span.Finish()
+
+	_, send := tracing.StartMessage(ctx, "fix send", tracing.SpanKindProducer, msg, to)
+	defer send.Finish()
+
+	if err := quickfix.SendToTarget(msg, to); err != nil {
+		send.RecordError(err)
 		return quickfix.NewMessageRejectError(err.Error(), int(tag.BusinessRejectReason), nil)
 	}
+	app.dropCopy(msg)
+
 	return nil
 }
 
@@ -189,12 +345,19 @@ func (app *Bridge) forwardExchangeMessageToClient(msg *quickfix.Message, session
 
 func (app *Bridge) onBusinessMessageReject(msg *quickfix.Message, sessionID quickfix.SessionID) quickfix.MessageRejectError {
 	if sessionID.IsFIXT() {
-		if len(app.connectedExchanges) == 0 {
+		target, connected := app.exchange()
+		switch {
+		case connected:
+			if err := quickfix.SendToTarget(msg, target); err != nil {
+				return quickfix.NewMessageRejectError(err.Error(), int(tag.BusinessRejectReason), nil)
+			}
+		case app.cluster != nil:
+			if rerr := app.cluster.forwardToExchange(msg, sessionID); rerr != nil {
+				return rerr
+			}
+		default:
 			return quickfix.NewMessageRejectError("No connected exchanges", int(tag.BusinessRejectReason), nil)
 		}
-		if err := quickfix.SendToTarget(msg, app.connectedExchanges[0]); err != nil {
-			return quickfix.NewMessageRejectError(err.Error(), int(tag.BusinessRejectReason), nil)
-		}
 	}
 	return app.unhandledMessage(msg, sessionID)
 }
@@ -0,0 +1,307 @@
+package application
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/tag"
+
+	"sylr.dev/fix/pkg/errors"
+	"sylr.dev/fix/pkg/tracing"
+)
+
+// BridgeClusterOptions describes how bridge instances share their order
+// mapping and their exchange sessions through NATS, so that client sessions
+// can be spread across them.
+type BridgeClusterOptions struct {
+	NATSURL string
+	// Subject is the prefix of the subjects the instances forward the
+	// messages to each other on.
+	Subject string
+	// Bucket is the JetStream key-value bucket mapping the ClOrdIDs to the
+	// client sessions, created if needed with entries expiring after TTL (0
+	// for never).
+	Bucket string
+	TTL    time.Duration
+	// Instance identifies the instance among the others, it defaults to the
+	// host name and the process ID.
+	Instance string
+	// Timeout is how long a message forwarded to another instance waits for
+	// it to be sent.
+	Timeout time.Duration
+}
+
+const (
+	bridgeClusterSessionHeader = "Fix-Session"
+	bridgeClusterErrorHeader   = "Fix-Error"
+	bridgeClusterExchangeQueue = "exchange"
+)
+
+// bridgeOrder is the client session of an order shared in the cluster.
+type bridgeOrder struct {
+	Instance string             `json:"instance"`
+	Session  quickfix.SessionID `json:"session"`
+}
+
+// bridgeCluster forwards the client messages to the instances connected to an
+// exchange when none is connected locally, and the exchange messages to the
+// instances the client sessions of their orders are connected to.
+type bridgeCluster struct {
+	app      *Bridge
+	conn     *nats.Conn
+	kv       nats.KeyValue
+	instance string
+	subject  string
+	timeout  time.Duration
+
+	exchangeSub *nats.Subscription
+	mux         sync.Mutex
+}
+
+// JoinCluster shares the order mapping and the exchange sessions of the bridge
+// with the other instances joining the cluster. It must be called before the
+// sessions are started.
+func (app *Bridge) JoinCluster(options *BridgeClusterOptions) error {
+	instance := options.Instance
+	if len(instance) == 0 {
+		hostname, _ := os.Hostname()
+		instance = fmt.Sprintf("%s-%d", strings.ReplaceAll(hostname, ".", "-"), os.Getpid())
+	}
+	if strings.ContainsAny(instance, ".*> \t") {
+		return fmt.Errorf("%w: bridge instance %q must be a single NATS subject token", errors.Options, instance)
+	}
+
+	conn, err := nats.Connect(options.NATSURL, nats.RetryOnFailedConnect(true))
+	if err != nil {
+		return err
+	}
+
+	js, err := conn.JetStream()
+	if err != nil {
+		conn.Close()
+		return err
+	}
+
+	kv, err := js.KeyValue(options.Bucket)
+	if err == nats.ErrBucketNotFound {
+		kv, err = js.CreateKeyValue(&nats.KeyValueConfig{
+			Bucket:      options.Bucket,
+			Description: "ClOrdIDs of the orders routed by the fix bridges",
+			TTL:         options.TTL,
+		})
+	}
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("bridge cluster bucket %s: %w", options.Bucket, err)
+	}
+
+	c := &bridgeCluster{
+		app:      app,
+		conn:     conn,
+		kv:       kv,
+		instance: instance,
+		subject:  options.Subject,
+		timeout:  options.Timeout,
+	}
+
+	if _, err := conn.Subscribe(c.instanceSubject(instance), c.onClientMessage); err != nil {
+		conn.Close()
+		return err
+	}
+
+	app.cluster = c
+	c.exchangesChanged()
+
+	app.Logger.Info().Msgf("Bridge instance %s joined the cluster on %s", instance, options.Subject)
+
+	return nil
+}
+
+func (c *bridgeCluster) close() {
+	c.conn.Drain()
+}
+
+func (c *bridgeCluster) instanceSubject(instance string) string {
+	return c.subject + ".instance." + instance
+}
+
+func (c *bridgeCluster) exchangeSubject() string {
+	return c.subject + "." + bridgeClusterExchangeQueue
+}
+
+// orderKey returns the key of the ClOrdID in the bucket, whose keys are
+// restricted to a few characters.
+func orderKey(clOrdID string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(clOrdID))
+}
+
+// exchangesChanged subscribes to the client messages forwarded by the other
+// instances while exchanges are connected to this one.
+func (c *bridgeCluster) exchangesChanged() {
+	_, connected := c.app.exchange()
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	var err error
+	switch {
+	case connected && c.exchangeSub == nil:
+		c.exchangeSub, err = c.conn.QueueSubscribe(c.exchangeSubject(), bridgeClusterExchangeQueue, c.onExchangeMessage)
+	case !connected && c.exchangeSub != nil:
+		err = c.exchangeSub.Unsubscribe()
+		c.exchangeSub = nil
+	}
+
+	if err != nil {
+		c.app.Logger.Error().Err(err).Msg("Bridge cluster exchange subscription")
+	}
+}
+
+// mapOrder shares the client session of the order with the other instances.
+func (c *bridgeCluster) mapOrder(clOrdID string, sessionID quickfix.SessionID) error {
+	value, err := json.Marshal(bridgeOrder{Instance: c.instance, Session: sessionID})
+	if err != nil {
+		return err
+	}
+
+	_, err = c.kv.Put(orderKey(clOrdID), value)
+
+	return err
+}
+
+// forwardToExchange forwards the client message to an instance connected to
+// an exchange.
+func (c *bridgeCluster) forwardToExchange(msg *quickfix.Message, sessionID quickfix.SessionID) quickfix.MessageRejectError {
+	return c.request(msg, sessionID, c.exchangeSubject(), nil)
+}
+
+// forwardToClient forwards the exchange message to the instance the client
+// session of its order is connected to.
+func (c *bridgeCluster) forwardToClient(clOrdID string, msg *quickfix.Message, sessionID quickfix.SessionID) quickfix.MessageRejectError {
+	entry, err := c.kv.Get(orderKey(clOrdID))
+	if err == nats.ErrKeyNotFound {
+		c.app.Logger.Warn().Str("clOrdId", clOrdID).Str("session", sessionID.String()).Msg("No client session found for ClOrdID")
+		return nil
+	} else if err != nil {
+		return quickfix.NewMessageRejectError(err.Error(), int(tag.BusinessRejectReason), nil)
+	}
+
+	var order bridgeOrder
+	if err := json.Unmarshal(entry.Value(), &order); err != nil {
+		return quickfix.NewMessageRejectError(err.Error(), int(tag.BusinessRejectReason), nil)
+	}
+
+	// The mapping of the orders of this instance is lost when it restarts
+	if order.Instance == c.instance {
+		return c.app.forward(msg, sessionID, order.Session)
+	}
+
+	return c.request(msg, sessionID, c.instanceSubject(order.Instance), &order.Session)
+}
+
+// request forwards the message to the instance subscribed to the subject and
+// waits for it to be sent.
+func (c *bridgeCluster) request(msg *quickfix.Message, from quickfix.SessionID, subject string, to *quickfix.SessionID) quickfix.MessageRejectError {
+	ctx, span := tracing.StartMessage(context.Background(), "fix receive", tracing.SpanKindServer, msg, from)
+	defer span.Finish()
+
+	ctx, publish := tracing.StartMessage(ctx, "nats publish", tracing.SpanKindProducer, msg, from)
+	defer publish.Finish()
+	publish.SetAttribute("messaging.system", "nats")
+	publish.SetAttribute("messaging.destination.name", subject)
+
+	request := nats.NewMsg(subject)
+	request.Data = []byte(msg.String())
+	if to != nil {
+		session, err := json.Marshal(to)
+		if err != nil {
+			return quickfix.NewMessageRejectError(err.Error(), int(tag.BusinessRejectReason), nil)
+		}
+		request.Header.Set(bridgeClusterSessionHeader, string(session))
+	}
+	tracing.Inject(ctx, request.Header)
+
+	reply, err := c.conn.RequestMsg(request, c.timeout)
+	if err == nats.ErrNoResponders && to == nil {
+		err = fmt.Errorf("No connected exchanges")
+	} else if err == nats.ErrNoResponders {
+		err = fmt.Errorf("Bridge instance of the client session is gone")
+	} else if err == nil && len(reply.Header.Get(bridgeClusterErrorHeader)) > 0 {
+		err = fmt.Errorf("%s", reply.Header.Get(bridgeClusterErrorHeader))
+	}
+
+	if err != nil {
+		publish.RecordError(err)
+		return quickfix.NewMessageRejectError(err.Error(), int(tag.BusinessRejectReason), nil)
+	}
+
+	return nil
+}
+
+// onExchangeMessage sends the client message forwarded by another instance to
+// an exchange.
+func (c *bridgeCluster) onExchangeMessage(request *nats.Msg) {
+	c.respond(request, func(msg *quickfix.Message) error {
+		target, ok := c.app.route(msg)
+		if !ok {
+			return fmt.Errorf("No connected exchanges")
+		}
+		c.app.toExchange(msg, target)
+
+		return quickfix.SendToTarget(msg, target)
+	})
+}
+
+// onClientMessage sends the exchange message forwarded by another instance to
+// the client session of its order.
+func (c *bridgeCluster) onClientMessage(request *nats.Msg) {
+	c.respond(request, func(msg *quickfix.Message) error {
+		var to quickfix.SessionID
+		if err := json.Unmarshal([]byte(request.Header.Get(bridgeClusterSessionHeader)), &to); err != nil {
+			return err
+		}
+
+		if err := quickfix.SendToTarget(msg, to); err != nil {
+			return err
+		}
+		c.app.dropCopy(msg)
+
+		return nil
+	})
+}
+
+func (c *bridgeCluster) respond(request *nats.Msg, send func(*quickfix.Message) error) {
+	ctx := tracing.Extract(context.Background(), request.Header)
+
+	msg := quickfix.NewMessage()
+	err := quickfix.ParseMessage(msg, bytes.NewBufferString(string(request.Data)))
+	if err == nil {
+		_, span := tracing.StartMessage(ctx, "nats receive", tracing.SpanKindConsumer, msg, quickfix.SessionID{})
+		span.SetAttribute("messaging.system", "nats")
+		span.SetAttribute("messaging.destination.name", request.Subject)
+
+		err = send(msg)
+		span.RecordError(err)
+		span.Finish()
+	}
+
+	reply := nats.NewMsg(request.Reply)
+	if err != nil {
+		c.app.Logger.Error().Err(err).Msgf("Bridge cluster message received on %s", request.Subject)
+		reply.Header.Set(bridgeClusterErrorHeader, err.Error())
+	}
+
+	if err := request.RespondMsg(reply); err != nil {
+		c.app.Logger.Error().Err(err).Msgf("Bridge cluster reply on %s", request.Subject)
+	}
+}
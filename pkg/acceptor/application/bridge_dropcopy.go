@@ -0,0 +1,115 @@
+package application
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/quickfixgo/enum"
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/quickfix/datadictionary"
+	"github.com/quickfixgo/tag"
+)
+
+var metricBridgeDropCopies = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "fix",
+		Subsystem: "bridge",
+		Name:      "drop_copies_total",
+		Help:      "Execution reports copied to the drop copy sessions",
+	},
+	[]string{"session"},
+)
+
+func init() {
+	prometheus.MustRegister(metricBridgeDropCopies)
+}
+
+// BridgeDropCopy copies the execution reports the bridge sends to the clients
+// to its sessions. The copies are sent with the sequence numbers of the drop
+// copy sessions and queued in their message store while they are logged out,
+// to be resent once they log back on.
+type BridgeDropCopy struct {
+	Sessions []quickfix.SessionID
+	// Scrub are the tags of the fields removed from the copies, the count tag
+	// of a repeating group removing the whole group, e.g. NoPartyIDs (453)
+	// for the parties. Tags inside repeating groups can't be given alone.
+	Scrub []int
+}
+
+// isDropCopy tells whether the session is a drop copy session.
+func (app *Bridge) isDropCopy(sessionID quickfix.SessionID) bool {
+	if app.DropCopy == nil {
+		return false
+	}
+	for _, s := range app.DropCopy.Sessions {
+		if s == sessionID {
+			return true
+		}
+	}
+	return false
+}
+
+// dropCopy sends a scrubbed copy of the execution report sent to a client to
+// the drop copy sessions.
+func (app *Bridge) dropCopy(msg *quickfix.Message) {
+	if app.DropCopy == nil || !msg.IsMsgTypeOf(string(enum.MsgType_EXECUTION_REPORT)) {
+		return
+	}
+
+	for _, sessionID := range app.DropCopy.Sessions {
+		dup := quickfix.NewMessage()
+		msg.CopyInto(dup)
+
+		app.scrub(dup)
+		if !sessionID.IsFIXT() {
+			dup.Header.Remove(tag.ApplVerID)
+			dup.Header.Remove(tag.CstmApplVerID)
+		}
+
+		if err := quickfix.SendToTarget(dup, sessionID); err != nil {
+			app.Logger.Error().Err(err).Str("session", sessionID.String()).Msg("Could not send the drop copy")
+			continue
+		}
+		metricBridgeDropCopies.WithLabelValues(sessionID.String()).Inc()
+	}
+}
+
+// scrub removes the fields of the Scrub tags from the message, along with the
+// fields of the repeating groups of the count tags, as the application data
+// dictionary defines them.
+func (app *Bridge) scrub(msg *quickfix.Message) {
+	var def *datadictionary.MessageDef
+	if app.AppDataDictionary != nil {
+		msgType, _ := msg.MsgType()
+		def = app.AppDataDictionary.Messages[msgType]
+	}
+
+	for _, field := range app.DropCopy.Scrub {
+		fields(msg, field).Remove(quickfix.Tag(field))
+
+		if def == nil {
+			continue
+		}
+		group, ok := def.Fields[field]
+		if !ok || !group.IsGroup() {
+			continue
+		}
+		for _, member := range groupTags(group) {
+			// Fields of the message outside of the group are kept
+			if _, ok := def.Fields[member]; !ok {
+				msg.Body.Remove(quickfix.Tag(member))
+			}
+		}
+	}
+}
+
+// groupTags returns the tags of the fields of the repeating group, the ones of
+// its nested groups included.
+func groupTags(group *datadictionary.FieldDef) []int {
+	var tags []int
+	for _, field := range group.Fields {
+		tags = append(tags, field.Tag())
+		if field.IsGroup() {
+			tags = append(tags, groupTags(field)...)
+		}
+	}
+	return tags
+}
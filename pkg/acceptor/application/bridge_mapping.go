@@ -0,0 +1,316 @@
+package application
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/quickfixgo/quickfix"
+
+	"sylr.dev/fix/pkg/errors"
+)
+
+// BridgeMappingDrivers are the stores the order mapping can be persisted in.
+var BridgeMappingDrivers = []string{"sqlite3", "postgres", "nats"}
+
+// BridgeMappingOptions describes where the bridge persists the client sessions
+// of the orders it routes, so that the execution reports received after a
+// restart still reach them.
+type BridgeMappingOptions struct {
+	// Driver is sqlite3, postgres or nats.
+	Driver string
+	// DSN is the data source name of the database, the path of the SQLite
+	// one, or the URL of the NATS JetStream server.
+	DSN string
+	// Bucket is the JetStream key-value bucket of the nats driver.
+	Bucket string
+	// TTL is the time the mapping of an order is kept for (0 for ever).
+	TTL time.Duration
+}
+
+// bridgeMapping is the client session of an order routed by the bridge and
+// the exchange session it was sent to, when sent by this instance.
+type bridgeMapping struct {
+	Session  quickfix.SessionID `json:"session"`
+	Exchange quickfix.SessionID `json:"exchange"`
+	Time     time.Time          `json:"time"`
+}
+
+// bridgeMappingStore is where the order mapping is persisted, Load returning
+// the mapping not expired yet.
+type bridgeMappingStore interface {
+	Load(since time.Time) (map[string]bridgeMapping, error)
+	Save(clOrdID string, mapping bridgeMapping) error
+	Expire(before time.Time) error
+	Close() error
+}
+
+// PersistOrderMapping persists the order mapping in the store of the options,
+// loading the mapping saved by the previous runs of the bridge.
+func (app *Bridge) PersistOrderMapping(options *BridgeMappingOptions) error {
+	var store bridgeMappingStore
+	var err error
+	switch options.Driver {
+	case "sqlite3", "postgres":
+		store, err = openSQLBridgeMapping(options.Driver, options.DSN)
+	case "nats":
+		store, err = openNATSBridgeMapping(options.DSN, options.Bucket, options.TTL)
+	default:
+		err = fmt.Errorf("%w: unsupported order mapping driver %s, must be one of %s", errors.Options, options.Driver, strings.Join(BridgeMappingDrivers, ", "))
+	}
+	if err != nil {
+		return err
+	}
+
+	var since time.Time
+	if options.TTL > 0 {
+		since = time.Now().Add(-options.TTL)
+	}
+	mapping, err := store.Load(since)
+	if err != nil {
+		store.Close()
+		return fmt.Errorf("bridge order mapping: %w", err)
+	}
+
+	app.orderMappingMux.Lock()
+	for clOrdID, m := range mapping {
+		app.orderMapping[clOrdID] = m
+	}
+	app.orderMappingMux.Unlock()
+
+	app.mappingStore = store
+	app.mappingDone = make(chan struct{})
+	if options.TTL > 0 {
+		app.mappingWG.Add(1)
+		go app.expireOrderMapping(store, options.TTL, app.mappingDone)
+	}
+
+	app.Logger.Info().Msgf("Bridge order mapping of %d orders loaded from %s", len(mapping), options.Driver)
+
+	return nil
+}
+
+// expireOrderMapping drops the mapping of the orders older than the TTL, from
+// memory and from the store, every tenth of the TTL until done is closed.
+func (app *Bridge) expireOrderMapping(store bridgeMappingStore, ttl time.Duration, done <-chan struct{}) {
+	defer app.mappingWG.Done()
+
+	interval := ttl / 10
+	if interval < time.Minute {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		before := time.Now().Add(-ttl)
+
+		app.orderMappingMux.Lock()
+		for clOrdID, m := range app.orderMapping {
+			if m.Time.Before(before) {
+				delete(app.orderMapping, clOrdID)
+			}
+		}
+		app.orderMappingMux.Unlock()
+
+		if err := store.Expire(before); err != nil {
+			app.Logger.Error().Err(err).Msg("Could not expire the bridge order mapping")
+		}
+	}
+}
+
+// sqlBridgeMapping persists the order mapping in the bridge_orders table.
+type sqlBridgeMapping struct {
+	db     *sql.DB
+	driver string
+}
+
+const bridgeMappingSchema = `CREATE TABLE IF NOT EXISTS bridge_orders (
+	cl_ord_id TEXT PRIMARY KEY,
+	session TEXT NOT NULL,
+	exchange TEXT NOT NULL DEFAULT '',
+	time TIMESTAMP NOT NULL
+)`
+
+func openSQLBridgeMapping(driver, dsn string) (*sqlBridgeMapping, error) {
+	if driver == "sqlite3" {
+		dsn = os.ExpandEnv(dsn)
+		if err := os.MkdirAll(filepath.Dir(dsn), 0700); err != nil {
+			return nil, err
+		}
+		if !strings.Contains(dsn, "?") {
+			dsn += "?_busy_timeout=5000"
+		}
+	}
+
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := db.Exec(bridgeMappingSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("bridge order mapping: %w", err)
+	}
+
+	return &sqlBridgeMapping{db: db, driver: driver}, nil
+}
+
+// placeholder returns the nth placeholder of the queries of the driver.
+func (s *sqlBridgeMapping) placeholder(n int) string {
+	if s.driver == "postgres" {
+		return fmt.Sprintf("$%d", n)
+	}
+	return "?"
+}
+
+func (s *sqlBridgeMapping) Load(since time.Time) (map[string]bridgeMapping, error) {
+	rows, err := s.db.Query("SELECT cl_ord_id, session, exchange, time FROM bridge_orders WHERE time >= "+s.placeholder(1), since.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	mapping := make(map[string]bridgeMapping)
+	for rows.Next() {
+		var clOrdID, session, exchange string
+		var m bridgeMapping
+		if err := rows.Scan(&clOrdID, &session, &exchange, &m.Time); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(session), &m.Session); err != nil {
+			return nil, err
+		}
+		if len(exchange) > 0 {
+			if err := json.Unmarshal([]byte(exchange), &m.Exchange); err != nil {
+				return nil, err
+			}
+		}
+		mapping[clOrdID] = m
+	}
+
+	return mapping, rows.Err()
+}
+
+func (s *sqlBridgeMapping) Save(clOrdID string, m bridgeMapping) error {
+	session, err := json.Marshal(m.Session)
+	if err != nil {
+		return err
+	}
+	exchange, err := json.Marshal(m.Exchange)
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf(`INSERT INTO bridge_orders (cl_ord_id, session, exchange, time) VALUES (%s, %s, %s, %s)
+		ON CONFLICT (cl_ord_id) DO UPDATE SET session = excluded.session, exchange = excluded.exchange, time = excluded.time`,
+		s.placeholder(1), s.placeholder(2), s.placeholder(3), s.placeholder(4))
+	_, err = s.db.Exec(query, clOrdID, string(session), string(exchange), m.Time.UTC())
+
+	return err
+}
+
+func (s *sqlBridgeMapping) Expire(before time.Time) error {
+	_, err := s.db.Exec("DELETE FROM bridge_orders WHERE time < "+s.placeholder(1), before.UTC())
+	return err
+}
+
+func (s *sqlBridgeMapping) Close() error {
+	return s.db.Close()
+}
+
+// natsBridgeMapping persists the order mapping in a JetStream key-value
+// bucket, whose entries expire by themselves.
+type natsBridgeMapping struct {
+	conn *nats.Conn
+	kv   nats.KeyValue
+}
+
+func openNATSBridgeMapping(url, bucket string, ttl time.Duration) (*natsBridgeMapping, error) {
+	conn, err := nats.Connect(url, nats.RetryOnFailedConnect(true))
+	if err != nil {
+		return nil, err
+	}
+
+	js, err := conn.JetStream()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	kv, err := js.KeyValue(bucket)
+	if err == nats.ErrBucketNotFound {
+		kv, err = js.CreateKeyValue(&nats.KeyValueConfig{
+			Bucket:      bucket,
+			Description: "Client sessions of the orders routed by the fix bridge",
+			TTL:         ttl,
+		})
+	}
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("bridge order mapping bucket %s: %w", bucket, err)
+	}
+
+	return &natsBridgeMapping{conn: conn, kv: kv}, nil
+}
+
+func (n *natsBridgeMapping) Load(since time.Time) (map[string]bridgeMapping, error) {
+	watcher, err := n.kv.WatchAll(nats.IgnoreDeletes())
+	if err != nil {
+		return nil, err
+	}
+	defer watcher.Stop()
+
+	mapping := make(map[string]bridgeMapping)
+
+	// The initial values are followed by a nil entry
+	for entry := range watcher.Updates() {
+		if entry == nil {
+			break
+		}
+
+		clOrdID, err := base64.RawURLEncoding.DecodeString(entry.Key())
+		if err != nil {
+			continue
+		}
+		var m bridgeMapping
+		if err := json.Unmarshal(entry.Value(), &m); err != nil || m.Time.Before(since) {
+			continue
+		}
+		mapping[string(clOrdID)] = m
+	}
+
+	return mapping, nil
+}
+
+func (n *natsBridgeMapping) Save(clOrdID string, m bridgeMapping) error {
+	value, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	_, err = n.kv.Put(orderKey(clOrdID), value)
+
+	return err
+}
+
+// Expire does nothing, the bucket expiring its entries.
+func (n *natsBridgeMapping) Expire(before time.Time) error {
+	return nil
+}
+
+func (n *natsBridgeMapping) Close() error {
+	return n.conn.Drain()
+}
@@ -0,0 +1,197 @@
+package application
+
+import (
+	"path"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/tag"
+)
+
+var (
+	metricBridgeRoutedMessages = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "fix",
+			Subsystem: "bridge",
+			Name:      "routed_messages_total",
+			Help:      "Client messages sent to the exchange sessions, by routing rule",
+		},
+		[]string{"rule", "session"},
+	)
+	metricBridgeUnroutedMessages = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "fix",
+			Subsystem: "bridge",
+			Name:      "unrouted_messages_total",
+			Help:      "Client messages no exchange session was logged on for, by routing rule",
+		},
+		[]string{"rule"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(metricBridgeRoutedMessages, metricBridgeUnroutedMessages)
+}
+
+// Routing rules of the messages routed otherwise.
+const (
+	bridgeRouteDefault  = "default"
+	bridgeRouteOriginal = "original"
+)
+
+// BridgeRoute sends the client messages whose Symbol, Account and one of whose
+// PartyIDs match one of its path.Match patterns, the criteria left empty
+// matching every message, to its exchange sessions.
+type BridgeRoute struct {
+	Name     string
+	Symbols  []string
+	Accounts []string
+	Parties  []string
+	Sessions []quickfix.SessionID
+	// RoundRobin has the exchange sessions logged on take turns, the first
+	// one logged on getting the messages otherwise.
+	RoundRobin bool
+	// ToExchange rewrites the messages sent to the exchange sessions and
+	// ToClient the ones received from them.
+	ToExchange *BridgeTransform
+	ToClient   *BridgeTransform
+
+	next atomic.Uint64
+}
+
+func (r *BridgeRoute) matches(msg *quickfix.Message) bool {
+	if len(r.Symbols) > 0 && !matchAny(r.Symbols, bodyString(msg, tag.Symbol)) {
+		return false
+	}
+	if len(r.Accounts) > 0 && !matchAny(r.Accounts, bodyString(msg, tag.Account)) {
+		return false
+	}
+	if len(r.Parties) > 0 {
+		for _, party := range partyIDs(msg) {
+			if matchAny(r.Parties, party) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return true
+}
+
+// pick returns the exchange session of the route the message goes to among the
+// ones connected.
+func (r *BridgeRoute) pick(connected []quickfix.SessionID) (quickfix.SessionID, bool) {
+	var candidates []quickfix.SessionID
+	for _, s := range r.Sessions {
+		for _, c := range connected {
+			if s == c {
+				candidates = append(candidates, s)
+				break
+			}
+		}
+	}
+
+	if len(candidates) == 0 {
+		return quickfix.SessionID{}, false
+	} else if !r.RoundRobin {
+		return candidates[0], true
+	}
+
+	return candidates[(r.next.Add(1)-1)%uint64(len(candidates))], true
+}
+
+func matchAny(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, value); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func bodyString(msg *quickfix.Message, t quickfix.Tag) string {
+	value, _ := msg.Body.GetString(t)
+	return value
+}
+
+func partyIDs(msg *quickfix.Message) []string {
+	parties := quickfix.NewRepeatingGroup(tag.NoPartyIDs, quickfix.GroupTemplate{
+		quickfix.GroupElement(tag.PartyID),
+		quickfix.GroupElement(tag.PartyIDSource),
+		quickfix.GroupElement(tag.PartyRole),
+	})
+	if err := msg.Body.GetGroup(parties); err != nil {
+		return nil
+	}
+
+	ids := make([]string, 0, parties.Len())
+	for i := 0; i < parties.Len(); i++ {
+		if id, err := parties.Get(i).GetString(tag.PartyID); err == nil {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids
+}
+
+// route returns the exchange session the client message is sent to: the one
+// its original order was sent to for cancels and replaces, none if that one is
+// not logged on, the one of the first route it matches, or the default one.
+func (app *Bridge) route(msg *quickfix.Message) (quickfix.SessionID, bool) {
+	app.exchangesMux.RLock()
+	connected := append([]quickfix.SessionID(nil), app.connectedExchanges...)
+	app.exchangesMux.RUnlock()
+
+	if origClOrdID, err := msg.Body.GetString(tag.OrigClOrdID); err == nil {
+		app.orderMappingMux.RLock()
+		mapping, found := app.orderMapping[origClOrdID]
+		app.orderMappingMux.RUnlock()
+
+		// The original order was sent by this instance, don't send its cancels
+		// and replaces to an exchange session that never saw it
+		if found && mapping.Exchange != (quickfix.SessionID{}) {
+			for _, c := range connected {
+				if c == mapping.Exchange {
+					metricBridgeRoutedMessages.WithLabelValues(bridgeRouteOriginal, c.String()).Inc()
+					return c, true
+				}
+			}
+
+			metricBridgeUnroutedMessages.WithLabelValues(bridgeRouteOriginal).Inc()
+			app.Logger.Warn().Msgf("Exchange session %s of original order %s not logged on", mapping.Exchange, origClOrdID)
+			return quickfix.SessionID{}, false
+		}
+	}
+
+	for i, route := range app.Routes {
+		if !route.matches(msg) {
+			continue
+		}
+
+		name := route.Name
+		if len(name) == 0 {
+			name = "#" + strconv.Itoa(i+1)
+		}
+
+		target, ok := route.pick(connected)
+		if !ok {
+			metricBridgeUnroutedMessages.WithLabelValues(name).Inc()
+			app.Logger.Warn().Msgf("No exchange session of routing rule %s logged on", name)
+			return target, false
+		}
+
+		metricBridgeRoutedMessages.WithLabelValues(name, target.String()).Inc()
+		return target, true
+	}
+
+	target, ok := app.exchange()
+	if ok {
+		metricBridgeRoutedMessages.WithLabelValues(bridgeRouteDefault, target.String()).Inc()
+	} else {
+		metricBridgeUnroutedMessages.WithLabelValues(bridgeRouteDefault).Inc()
+	}
+
+	return target, ok
+}
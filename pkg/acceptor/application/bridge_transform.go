@@ -0,0 +1,136 @@
+package application
+
+import (
+	"github.com/quickfixgo/enum"
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/tag"
+)
+
+// HandlInst and the ExecTypes of the partial fills and fills are missing from
+// the FIX 5.0 SP2 tags and enums.
+const (
+	tagHandlInst        quickfix.Tag = 21
+	handlInstAutomated               = "1"
+	execTypePartialFill              = "1"
+	execTypeFill                     = "2"
+)
+
+// BridgeTransform rewrites the fields of the messages, outside of repeating
+// groups: Tags moves the values of fields to other tags, then Values replaces
+// the values of fields, by tag then value, Remove removes fields and Set sets
+// fields, the header ones in the header.
+type BridgeTransform struct {
+	Tags   map[int]int
+	Values map[int]map[string]string
+	Remove []int
+	Set    map[int]string
+}
+
+func (t *BridgeTransform) apply(msg *quickfix.Message) {
+	// Values are all read before being moved, so that tags can be swapped, and
+	// the fields moved to are overwritten rather than removed, FieldMap.Remove
+	// having the fields set again written twice.
+	moved := make(map[int]string, len(t.Tags))
+	targets := make(map[int]bool, len(t.Tags))
+	for from, to := range t.Tags {
+		if value, err := fields(msg, from).GetString(quickfix.Tag(from)); err == nil {
+			moved[from] = value
+		}
+		targets[to] = true
+	}
+	for from, value := range moved {
+		if !targets[from] {
+			fields(msg, from).Remove(quickfix.Tag(from))
+		}
+		to := t.Tags[from]
+		fields(msg, to).SetString(quickfix.Tag(to), value)
+	}
+
+	for field, values := range t.Values {
+		value, err := fields(msg, field).GetString(quickfix.Tag(field))
+		if err != nil {
+			continue
+		}
+		if replacement, ok := values[value]; ok {
+			fields(msg, field).SetString(quickfix.Tag(field), replacement)
+		}
+	}
+
+	for _, field := range t.Remove {
+		fields(msg, field).Remove(quickfix.Tag(field))
+	}
+
+	for field, value := range t.Set {
+		fields(msg, field).SetString(quickfix.Tag(field), value)
+	}
+}
+
+// fields returns the part of the message the field of the tag belongs to.
+func fields(msg *quickfix.Message, field int) *quickfix.FieldMap {
+	switch {
+	case quickfix.Tag(field).IsHeader():
+		return &msg.Header.FieldMap
+	case quickfix.Tag(field).IsTrailer():
+		return &msg.Trailer.FieldMap
+	default:
+		return &msg.Body.FieldMap
+	}
+}
+
+// exchangeRoute returns the first route sending messages to the exchange
+// session, whose transforms apply to the messages of the session.
+func (app *Bridge) exchangeRoute(exchange quickfix.SessionID) *BridgeRoute {
+	for _, route := range app.Routes {
+		for _, s := range route.Sessions {
+			if s == exchange {
+				return route
+			}
+		}
+	}
+
+	return nil
+}
+
+// toExchange converts the client message, of FIXT.1.1, to the version of the
+// exchange session it is sent to, then applies the ToExchange transform of the
+// route of the session.
+func (app *Bridge) toExchange(msg *quickfix.Message, exchange quickfix.SessionID) {
+	if !exchange.IsFIXT() {
+		msg.Header.Remove(tag.ApplVerID)
+		msg.Header.Remove(tag.CstmApplVerID)
+
+		// HandlInst is required by the NewOrderSingle of FIX 4.0 to 4.2
+		switch exchange.BeginString {
+		case quickfix.BeginStringFIX40, quickfix.BeginStringFIX41, quickfix.BeginStringFIX42:
+			if msg.IsMsgTypeOf(string(enum.MsgType_ORDER_SINGLE)) && !msg.Body.Has(tagHandlInst) {
+				msg.Body.SetString(tagHandlInst, handlInstAutomated)
+			}
+		}
+	}
+
+	if route := app.exchangeRoute(exchange); route != nil && route.ToExchange != nil {
+		route.ToExchange.apply(msg)
+	}
+}
+
+// toClient applies the ToClient transform of the route of the exchange session
+// to the message received from it, then converts it to FIXT.1.1.
+func (app *Bridge) toClient(msg *quickfix.Message, exchange quickfix.SessionID) {
+	if route := app.exchangeRoute(exchange); route != nil && route.ToClient != nil {
+		route.ToClient.apply(msg)
+	}
+
+	if exchange.IsFIXT() || !msg.IsMsgTypeOf(string(enum.MsgType_EXECUTION_REPORT)) {
+		return
+	}
+
+	// ExecTransType is gone since FIX 4.3 and the partial fills and fills
+	// became trades
+	msg.Body.Remove(tagExecTransType)
+	if execType, err := msg.Body.GetString(tag.ExecType); err == nil {
+		switch execType {
+		case execTypePartialFill, execTypeFill:
+			msg.Body.SetString(tag.ExecType, string(enum.ExecType_TRADE))
+		}
+	}
+}
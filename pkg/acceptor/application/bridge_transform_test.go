@@ -0,0 +1,242 @@
+package application
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/quickfixgo/quickfix"
+)
+
+// newTestMessage returns a message of the "tag=value|..." fields, the header
+// ones set in the header.
+func newTestMessage(t *testing.T, fieldsString string) *quickfix.Message {
+	t.Helper()
+
+	msg := quickfix.NewMessage()
+	for _, f := range strings.Split(strings.Trim(fieldsString, "|"), "|") {
+		tagString, value, ok := strings.Cut(f, "=")
+		tag, err := strconv.Atoi(tagString)
+		if !ok || err != nil {
+			t.Fatalf("invalid field %q", f)
+		}
+		fields(msg, tag).SetString(quickfix.Tag(tag), value)
+	}
+
+	return msg
+}
+
+// assertFields checks the fields of the message, an empty value meaning the
+// field must be missing.
+func assertFields(t *testing.T, msg *quickfix.Message, want map[int]string) {
+	t.Helper()
+
+	for tag, value := range want {
+		got, err := fields(msg, tag).GetString(quickfix.Tag(tag))
+		switch {
+		case len(value) == 0 && err == nil:
+			t.Errorf("tag %d: got %q, want none", tag, got)
+		case len(value) > 0 && err != nil:
+			t.Errorf("tag %d: got none, want %q", tag, value)
+		case got != value:
+			t.Errorf("tag %d: got %q, want %q", tag, got, value)
+		}
+	}
+
+	// Fields set again must not be written twice
+	raw := "\x01" + msg.String()
+	for tag := range want {
+		if n := strings.Count(raw, "\x01"+strconv.Itoa(tag)+"="); n > 1 {
+			t.Errorf("tag %d written %d times in %s", tag, n, strings.ReplaceAll(raw, "\x01", "|"))
+		}
+	}
+}
+
+func TestBridgeToExchange(t *testing.T) {
+	tests := []struct {
+		name     string
+		exchange string
+		msg      string
+		want     map[int]string
+	}{
+		{
+			name:     "NewOrderSingle to FIX.4.2",
+			exchange: quickfix.BeginStringFIX42,
+			msg:      "8=FIXT.1.1|35=D|1128=9|1129=custom|11=ID|55=EURUSD|54=1|38=100|40=1",
+			want:     map[int]string{21: "1", 1128: "", 1129: "", 11: "ID", 55: "EURUSD"},
+		},
+		{
+			name:     "NewOrderSingle with HandlInst to FIX.4.2",
+			exchange: quickfix.BeginStringFIX42,
+			msg:      "8=FIXT.1.1|35=D|1128=9|11=ID|21=3",
+			want:     map[int]string{21: "3", 1128: ""},
+		},
+		{
+			name:     "NewOrderSingle to FIX.4.4",
+			exchange: quickfix.BeginStringFIX44,
+			msg:      "8=FIXT.1.1|35=D|1128=9|11=ID",
+			want:     map[int]string{21: "", 1128: ""},
+		},
+		{
+			name:     "OrderCancelRequest to FIX.4.2",
+			exchange: quickfix.BeginStringFIX42,
+			msg:      "8=FIXT.1.1|35=F|1128=9|11=ID2|41=ID",
+			want:     map[int]string{21: "", 1128: "", 41: "ID"},
+		},
+		{
+			name:     "NewOrderSingle to FIXT.1.1",
+			exchange: quickfix.BeginStringFIXT11,
+			msg:      "8=FIXT.1.1|35=D|1128=9|11=ID",
+			want:     map[int]string{21: "", 1128: "9"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app := &Bridge{}
+			msg := newTestMessage(t, test.msg)
+			app.toExchange(msg, quickfix.SessionID{BeginString: test.exchange, SenderCompID: "BRIDGE", TargetCompID: "EXCHANGE"})
+			assertFields(t, msg, test.want)
+		})
+	}
+}
+
+func TestBridgeToClient(t *testing.T) {
+	tests := []struct {
+		name     string
+		exchange string
+		msg      string
+		want     map[int]string
+	}{
+		{
+			name:     "partial fill from FIX.4.2",
+			exchange: quickfix.BeginStringFIX42,
+			msg:      "8=FIX.4.2|35=8|20=0|150=1|39=1|11=ID|32=10|31=1.1",
+			want:     map[int]string{20: "", 150: "F", 39: "1", 32: "10"},
+		},
+		{
+			name:     "fill from FIX.4.2",
+			exchange: quickfix.BeginStringFIX42,
+			msg:      "8=FIX.4.2|35=8|20=0|150=2|39=2|11=ID",
+			want:     map[int]string{20: "", 150: "F", 39: "2"},
+		},
+		{
+			name:     "new from FIX.4.2",
+			exchange: quickfix.BeginStringFIX42,
+			msg:      "8=FIX.4.2|35=8|20=0|150=0|39=0|11=ID",
+			want:     map[int]string{20: "", 150: "0", 39: "0"},
+		},
+		{
+			name:     "order cancel reject from FIX.4.2",
+			exchange: quickfix.BeginStringFIX42,
+			msg:      "8=FIX.4.2|35=9|11=ID|41=ID0|39=2",
+			want:     map[int]string{11: "ID", 41: "ID0", 39: "2"},
+		},
+		{
+			name:     "fill from FIXT.1.1",
+			exchange: quickfix.BeginStringFIXT11,
+			msg:      "8=FIXT.1.1|35=8|150=2|11=ID",
+			want:     map[int]string{150: "2"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app := &Bridge{}
+			msg := newTestMessage(t, test.msg)
+			app.toClient(msg, quickfix.SessionID{BeginString: test.exchange, SenderCompID: "BRIDGE", TargetCompID: "EXCHANGE"})
+			assertFields(t, msg, test.want)
+		})
+	}
+}
+
+func TestBridgeTransformApply(t *testing.T) {
+	tests := []struct {
+		name      string
+		transform BridgeTransform
+		msg       string
+		want      map[int]string
+	}{
+		{
+			name:      "tags swap",
+			transform: BridgeTransform{Tags: map[int]int{1: 440, 440: 1}},
+			msg:       "8=FIX.4.2|35=D|1=ACCOUNT|440=CLEARING|11=ID",
+			want:      map[int]string{1: "CLEARING", 440: "ACCOUNT", 11: "ID"},
+		},
+		{
+			name:      "tags move",
+			transform: BridgeTransform{Tags: map[int]int{9730: 58}},
+			msg:       "8=FIX.4.2|35=8|9730=text|11=ID",
+			want:      map[int]string{9730: "", 58: "text"},
+		},
+		{
+			name:      "tags move of a missing field",
+			transform: BridgeTransform{Tags: map[int]int{9730: 58}},
+			msg:       "8=FIX.4.2|35=8|58=text",
+			want:      map[int]string{9730: "", 58: "text"},
+		},
+		{
+			name:      "tags move to the header",
+			transform: BridgeTransform{Tags: map[int]int{109: 115}},
+			msg:       "8=FIX.4.2|35=D|109=CLIENT",
+			want:      map[int]string{109: "", 115: "CLIENT"},
+		},
+		{
+			name:      "values remap",
+			transform: BridgeTransform{Values: map[int]map[string]string{59: {"6": "0"}, 54: {"5": "2"}}},
+			msg:       "8=FIX.4.2|35=D|59=6|54=1",
+			want:      map[int]string{59: "0", 54: "1"},
+		},
+		{
+			name:      "remove",
+			transform: BridgeTransform{Remove: []int{58, 115}},
+			msg:       "8=FIX.4.2|35=8|115=CLIENT|58=text|11=ID",
+			want:      map[int]string{58: "", 115: "", 11: "ID"},
+		},
+		{
+			name:      "set header and body fields",
+			transform: BridgeTransform{Set: map[int]string{115: "ACME", 116: "DESK", 1: "ACCOUNT"}},
+			msg:       "8=FIX.4.2|35=D|1=OTHER|11=ID",
+			want:      map[int]string{115: "ACME", 116: "DESK", 1: "ACCOUNT", 11: "ID"},
+		},
+		{
+			name: "all",
+			transform: BridgeTransform{
+				Tags:   map[int]int{1: 440, 440: 1},
+				Values: map[int]map[string]string{54: {"1": "2"}},
+				Remove: []int{58},
+				Set:    map[int]string{115: "ACME"},
+			},
+			msg:  "8=FIX.4.2|35=D|1=ACCOUNT|440=CLEARING|54=1|58=text",
+			want: map[int]string{1: "CLEARING", 440: "ACCOUNT", 54: "2", 58: "", 115: "ACME"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			msg := newTestMessage(t, test.msg)
+			test.transform.apply(msg)
+			assertFields(t, msg, test.want)
+		})
+	}
+}
+
+func TestBridgeTransformRoute(t *testing.T) {
+	exchange := quickfix.SessionID{BeginString: quickfix.BeginStringFIX42, SenderCompID: "BRIDGE", TargetCompID: "EXCHANGE"}
+	app := &Bridge{Routes: []*BridgeRoute{
+		{Sessions: []quickfix.SessionID{{BeginString: quickfix.BeginStringFIX42, SenderCompID: "BRIDGE", TargetCompID: "OTHER"}}},
+		{
+			Sessions:   []quickfix.SessionID{exchange},
+			ToExchange: &BridgeTransform{Set: map[int]string{115: "ACME"}},
+			ToClient:   &BridgeTransform{Remove: []int{115}},
+		},
+	}}
+
+	msg := newTestMessage(t, "8=FIXT.1.1|35=D|1128=9|11=ID")
+	app.toExchange(msg, exchange)
+	assertFields(t, msg, map[int]string{115: "ACME", 21: "1", 1128: ""})
+
+	msg = newTestMessage(t, "8=FIX.4.2|35=8|115=ACME|20=0|150=2|11=ID")
+	app.toClient(msg, exchange)
+	assertFields(t, msg, map[int]string{115: "", 20: "", 150: "F"})
+}
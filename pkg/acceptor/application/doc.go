@@ -0,0 +1,37 @@
+// Package application provides the quickfix applications behind the acceptor
+// commands, which can be embedded in other Go programs.
+//
+// Acceptor answers the orders it receives and publishes them on NATS, Bridge
+// routes the orders of its client sessions to its exchange sessions. Both are
+// served with acceptor.NewAcceptor and must be closed once stopped:
+//
+//	app, err := application.NewAcceptor(&application.AcceptorOptions{
+//		NATSEmbeded:      true,
+//		NATSURL:          nats.DefaultURL,
+//		NATSOrderSubject: "orders.{{ .Symbol }}",
+//	})
+//	if err != nil {
+//		return err
+//	}
+//	defer app.Close()
+//
+//	app.Logger = &logger
+//	app.TransportDataDictionary = transportDict
+//	app.AppDataDictionary = appDict
+//
+//	a, err := acceptor.NewAcceptor(app, settings, nil)
+//	if err != nil {
+//		return err
+//	}
+//	if err := a.Start(); err != nil {
+//		return err
+//	}
+//	defer a.Stop()
+package application
+
+import "github.com/quickfixgo/quickfix"
+
+var (
+	_ quickfix.Application = (*Acceptor)(nil)
+	_ quickfix.Application = (*Bridge)(nil)
+)
@@ -0,0 +1,203 @@
+package application
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/quickfixgo/enum"
+	"github.com/quickfixgo/field"
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/tag"
+
+	"sylr.dev/fix/pkg/replay"
+	"sylr.dev/fix/pkg/utils"
+)
+
+// marketDataSubscription identifies a MarketDataRequest subscribing to updates.
+type marketDataSubscription struct {
+	sessionID quickfix.SessionID
+	mdReqID   string
+}
+
+// marketDataReplay is the replay of the recorded market data to a subscription.
+type marketDataReplay struct {
+	cancel context.CancelFunc
+}
+
+func (app *Acceptor) onMarketDataRequest(request *quickfix.Message, sessionID quickfix.SessionID) quickfix.MessageRejectError {
+	mdReqID, ferr := request.Body.GetString(tag.MDReqID)
+	if ferr != nil {
+		return ferr
+	}
+
+	subType, ferr := request.Body.GetString(tag.SubscriptionRequestType)
+	if ferr != nil {
+		return ferr
+	}
+
+	subscription := marketDataSubscription{sessionID: sessionID, mdReqID: mdReqID}
+
+	switch enum.SubscriptionRequestType(subType) {
+	case enum.SubscriptionRequestType_DISABLE_PREVIOUS_SNAPSHOT_PLUS_UPDATE_REQUEST:
+		app.unsubscribe(subscription)
+		return nil
+	case enum.SubscriptionRequestType_SNAPSHOT, enum.SubscriptionRequestType_SNAPSHOT_PLUS_UPDATES:
+	default:
+		return quickfix.ValueIsIncorrect(tag.SubscriptionRequestType)
+	}
+
+	// The symbols of the NoRelatedSym group
+	symbols := make(map[string]bool)
+	for _, symbol := range (replay.Message{Raw: request.String()}).Values(int(tag.Symbol)) {
+		symbols[symbol] = true
+	}
+
+	messages := app.marketDataOf(symbols)
+	if len(messages) == 0 {
+		app.rejectMarketDataRequest(mdReqID, sessionID, enum.MDReqRejReason_UNKNOWN_SYMBOL, "No market data recorded for the symbols")
+		return nil
+	}
+
+	if enum.SubscriptionRequestType(subType) == enum.SubscriptionRequestType_SNAPSHOT {
+		go app.sendMarketDataSnapshots(messages, mdReqID, sessionID)
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &marketDataReplay{cancel: cancel}
+
+	app.marketDataMux.Lock()
+	if previous, ok := app.subscriptions[subscription]; ok {
+		previous.cancel()
+	}
+	app.subscriptions[subscription] = r
+	app.marketDataMux.Unlock()
+
+	go func() {
+		defer func() {
+			app.marketDataMux.Lock()
+			if app.subscriptions[subscription] == r {
+				delete(app.subscriptions, subscription)
+			}
+			app.marketDataMux.Unlock()
+			cancel()
+		}()
+
+		err := replay.Play(ctx, messages, app.marketDataSpeed, func(m replay.Message) error {
+			return app.sendMarketData(m, mdReqID, sessionID)
+		})
+		if err != nil && ctx.Err() == nil {
+			app.Logger.Error().Err(err).Msgf("%s: market data replay of %s", sessionID, mdReqID)
+		} else if err == nil {
+			app.Logger.Info().Msgf("%s: market data replay of %s done", sessionID, mdReqID)
+		}
+	}()
+
+	return nil
+}
+
+// marketDataOf returns the recorded market data of the symbols, all of it when
+// none is given. Incremental refreshes are kept when any of their entries is
+// of one of the symbols.
+func (app *Acceptor) marketDataOf(symbols map[string]bool) []replay.Message {
+	if len(symbols) == 0 {
+		return app.marketData
+	}
+
+	var messages []replay.Message
+	for _, m := range app.marketData {
+		for _, symbol := range m.Values(int(tag.Symbol)) {
+			if symbols[symbol] {
+				messages = append(messages, m)
+				break
+			}
+		}
+	}
+
+	return messages
+}
+
+// sendMarketDataSnapshots sends the first snapshot recorded for each symbol.
+func (app *Acceptor) sendMarketDataSnapshots(messages []replay.Message, mdReqID string, sessionID quickfix.SessionID) {
+	sent := make(map[string]bool)
+
+	for _, m := range messages {
+		if m.MsgType() != string(enum.MsgType_MARKET_DATA_SNAPSHOT_FULL_REFRESH) {
+			continue
+		}
+
+		symbols := m.Values(int(tag.Symbol))
+		if len(symbols) == 0 || sent[symbols[0]] {
+			continue
+		}
+		sent[symbols[0]] = true
+
+		if err := app.sendMarketData(m, mdReqID, sessionID); err != nil {
+			app.Logger.Error().Err(err).Msgf("%s: market data snapshot of %s", sessionID, mdReqID)
+			return
+		}
+	}
+
+	if len(sent) == 0 {
+		app.rejectMarketDataRequest(mdReqID, sessionID, enum.MDReqRejReason_UNKNOWN_SYMBOL, "No snapshot recorded for the symbols")
+	}
+}
+
+// sendMarketData sends the recorded message with the header of the session
+// and the MDReqID of the request.
+func (app *Acceptor) sendMarketData(m replay.Message, mdReqID string, sessionID quickfix.SessionID) error {
+	raw, err := utils.QuickFixRawMessageSetBodyLength(m.Raw)
+	if err != nil {
+		return err
+	}
+
+	parsed, err := utils.ParseQuickFixRawMessage(raw, app.TransportDataDictionary, app.AppDataDictionary)
+	if err != nil {
+		return fmt.Errorf("recorded message: %w", err)
+	}
+
+	// FieldMap.Remove leaves the tags in the order the fields are written in,
+	// the header is built again instead
+	message := utils.QuickFixMessageCopy(parsed, app.AppDataDictionary)
+	message.Header.Clear()
+	message.Header.SetField(tag.MsgType, field.NewMsgType(enum.MsgType(m.MsgType())))
+	message.Trailer.Clear()
+	message.Body.SetString(tag.MDReqID, mdReqID)
+
+	return quickfix.SendToTarget(message, sessionID)
+}
+
+func (app *Acceptor) rejectMarketDataRequest(mdReqID string, sessionID quickfix.SessionID, reason enum.MDReqRejReason, text string) {
+	message := quickfix.NewMessage()
+	message.Header.SetField(tag.MsgType, field.NewMsgType(enum.MsgType_MARKET_DATA_REQUEST_REJECT))
+	message.Body.SetString(tag.MDReqID, mdReqID)
+	message.Body.SetString(tag.MDReqRejReason, string(reason))
+	message.Body.SetString(tag.Text, text)
+
+	if err := quickfix.SendToTarget(message, sessionID); err != nil {
+		app.Logger.Error().Err(err).Msgf("%s: market data request reject of %s", sessionID, mdReqID)
+	}
+}
+
+func (app *Acceptor) unsubscribe(subscription marketDataSubscription) {
+	app.marketDataMux.Lock()
+	defer app.marketDataMux.Unlock()
+
+	if r, ok := app.subscriptions[subscription]; ok {
+		r.cancel()
+		delete(app.subscriptions, subscription)
+	}
+}
+
+// unsubscribeSession stops the replays of the session.
+func (app *Acceptor) unsubscribeSession(sessionID quickfix.SessionID) {
+	app.marketDataMux.Lock()
+	defer app.marketDataMux.Unlock()
+
+	for subscription, r := range app.subscriptions {
+		if subscription.sessionID == sessionID {
+			r.cancel()
+			delete(app.subscriptions, subscription)
+		}
+	}
+}
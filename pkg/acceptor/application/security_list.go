@@ -0,0 +1,122 @@
+package application
+
+import (
+	"github.com/google/uuid"
+	"github.com/quickfixgo/enum"
+	"github.com/quickfixgo/field"
+	"github.com/quickfixgo/fixt11"
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/tag"
+
+	"sylr.dev/fix/pkg/instrument"
+	"sylr.dev/fix/pkg/utils"
+)
+
+// Tags of the instruments missing from the tag package.
+const (
+	tagSecurityType      quickfix.Tag = 167
+	tagProduct           quickfix.Tag = 460
+	tagRoundLot          quickfix.Tag = 561
+	tagSecurityStatus    quickfix.Tag = 965
+	tagMinPriceIncrement quickfix.Tag = 969
+)
+
+// securityListGroupTemplate is the template of the NoRelatedSym group used
+// when the application data dictionary does not define it.
+var securityListGroupTemplate = quickfix.GroupTemplate{
+	quickfix.GroupElement(tag.Symbol),
+	quickfix.GroupElement(tag.SecurityID),
+	quickfix.GroupElement(tagProduct),
+	quickfix.GroupElement(tagSecurityType),
+	quickfix.GroupElement(tagSecurityStatus),
+	quickfix.GroupElement(tagMinPriceIncrement),
+	quickfix.GroupElement(tag.Currency),
+	quickfix.GroupElement(tagRoundLot),
+	quickfix.GroupElement(tag.SecurityTradingStatus),
+}
+
+func (app *Acceptor) onSecurityListRequest(request *quickfix.Message, sessionID quickfix.SessionID) quickfix.MessageRejectError {
+	reqID, ferr := request.Body.GetString(tag.SecurityReqID)
+	if ferr != nil {
+		return ferr
+	}
+
+	symbol, _ := request.Body.GetString(tag.Symbol)
+	product, _ := request.Body.GetString(tagProduct)
+
+	var instruments []instrument.Instrument
+	for _, i := range app.instruments {
+		if len(symbol) > 0 && i.Symbol != symbol {
+			continue
+		}
+		if len(product) > 0 && i.Product != product {
+			continue
+		}
+		instruments = append(instruments, i)
+	}
+
+	if err := app.sendSecurityList(request, sessionID, reqID, instruments); err != nil {
+		return quickfix.NewMessageRejectError(err.Error(), int(tag.BusinessRejectReason), nil)
+	}
+
+	return nil
+}
+
+// sendSecurityList answers the SecurityListRequest received on the session with
+// the instruments of the catalog, only setting the fields of the NoRelatedSym
+// group defined by the application data dictionary.
+func (app *Acceptor) sendSecurityList(request *quickfix.Message, sessionID quickfix.SessionID, reqID string, instruments []instrument.Instrument) error {
+	message := quickfix.NewMessage()
+	header := fixt11.NewHeader(&message.Header)
+
+	header.SetField(tag.MsgType, field.NewMsgType(enum.MsgType_SECURITY_LIST))
+	utils.QuickFixMessagePartSetString(&header, utils.MustNot(request.Header.GetString(tag.SenderCompID)), field.NewTargetCompID)
+	utils.QuickFixMessagePartSetString(&header, utils.MustNot(request.Header.GetString(tag.SenderSubID)), field.NewTargetSubID)
+	utils.QuickFixMessagePartSetString(&header, utils.MustNot(request.Header.GetString(tag.TargetCompID)), field.NewSenderCompID)
+	utils.QuickFixMessagePartSetString(&header, utils.MustNot(request.Header.GetString(tag.TargetSubID)), field.NewSenderSubID)
+
+	message.Body.SetString(tag.SecurityReqID, reqID)
+	message.Body.SetString(tag.SecurityResponseID, uuid.NewString())
+	message.Body.SetString(tag.SecurityRequestResult, string(enum.SecurityRequestResult_VALID_REQUEST))
+	message.Body.SetInt(tag.TotNoRelatedSym, len(instruments))
+	message.Body.SetBool(tag.LastFragment, true)
+
+	template := securityListGroupTemplate
+	if app.AppDataDictionary != nil {
+		if msgDef, ok := app.AppDataDictionary.Messages[string(enum.MsgType_SECURITY_LIST)]; ok {
+			if def, ok := msgDef.Fields[int(tag.NoRelatedSym)]; ok && def.IsGroup() {
+				template = utils.QuickFixGroupTemplate(def)
+			}
+		}
+	}
+
+	defined := make(map[quickfix.Tag]bool, len(template))
+	for _, element := range template {
+		defined[element.Tag()] = true
+	}
+
+	group := quickfix.NewRepeatingGroup(tag.NoRelatedSym, template)
+	for _, i := range instruments {
+		g := group.Add()
+		for t, value := range map[quickfix.Tag]string{
+			tag.Symbol:                i.Symbol,
+			tag.SecurityID:            i.SecurityID,
+			tagSecurityType:           i.SecurityType,
+			tagProduct:                i.Product,
+			tag.Currency:              i.Currency,
+			tagMinPriceIncrement:      i.TickSize,
+			tagRoundLot:               i.LotSize,
+			tag.SecurityTradingStatus: i.TradingStatus,
+			tagSecurityStatus:         i.SecurityStatus,
+		} {
+			if len(value) > 0 && defined[t] {
+				g.SetString(t, value)
+			}
+		}
+	}
+	if len(instruments) > 0 {
+		message.Body.SetGroup(group)
+	}
+
+	return quickfix.SendToTarget(message, sessionID)
+}
@@ -2,19 +2,30 @@ package application
 
 import (
 	"bytes"
+	"context"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"text/template"
 
 	natsd "github.com/nats-io/nats-server/v2/server"
 	"github.com/nats-io/nats.go"
 	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
 
 	"github.com/quickfixgo/enum"
 	"github.com/quickfixgo/field"
 	"github.com/quickfixgo/fixt11"
 	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/quickfix/datadictionary"
 	"github.com/quickfixgo/tag"
 
+	"sylr.dev/fix/pkg/acceptor/state"
 	"sylr.dev/fix/pkg/dict"
+	"sylr.dev/fix/pkg/health"
+	"sylr.dev/fix/pkg/instrument"
+	"sylr.dev/fix/pkg/replay"
+	"sylr.dev/fix/pkg/tracing"
 	"sylr.dev/fix/pkg/utils"
 )
 
@@ -22,6 +33,24 @@ type AcceptorOptions struct {
 	NATSEmbeded      bool
 	NATSURL          string
 	NATSOrderSubject string
+	// Instruments are the instruments SecurityListRequest messages are
+	// answered with, they are rejected when there are none.
+	Instruments []instrument.Instrument
+	// MarketData are the recorded messages whose snapshots and incremental
+	// refreshes MarketDataRequest messages are answered with, replayed at
+	// MarketDataSpeed times their original pace (0 for no pacing). They are
+	// rejected when there are none.
+	MarketData      []replay.Message
+	MarketDataSpeed float64
+	// State is the database the orders, executions and instruments are
+	// persisted in, if any. The instruments it holds are listed when no
+	// Instruments are given.
+	State *state.State
+	// AppDataDictionaries are the application dictionaries of the versions of
+	// the messages handled on FIXT.1.1 sessions when there are several, the
+	// acceptor validating them instead of quickfix. NewOrderSingle messages
+	// are handled in each of them.
+	AppDataDictionaries map[enum.ApplVerID]*datadictionary.DataDictionary
 }
 
 func NewAcceptor(options *AcceptorOptions) (*Acceptor, error) {
@@ -36,17 +65,51 @@ func NewAcceptor(options *AcceptorOptions) (*Acceptor, error) {
 	s := Acceptor{
 		NatsOrderSubject: tpl,
 		router:           quickfix.NewMessageRouter(),
+		instruments:      options.Instruments,
+		marketDataSpeed:  options.MarketDataSpeed,
+		subscriptions:    make(map[marketDataSubscription]*marketDataReplay),
+		state:            options.State,
+		appDictionaries:  options.AppDataDictionaries,
 	}
 
-	if options.NATSEmbeded {
-		s.natsServer, err = natsd.NewServer(&natsd.Options{})
-		s.natsServer.Start()
+	if s.state != nil {
+		lastExecID, err := s.state.LastExecID()
+		if err != nil {
+			return nil, err
+		}
+		s.execID.Store(lastExecID)
 
+		if len(s.instruments) > 0 {
+			err = s.state.SaveInstruments(s.instruments)
+		} else {
+			s.instruments, err = s.state.Instruments()
+		}
 		if err != nil {
 			return nil, err
 		}
 	}
 
+	utils.SetDecimalScales(s.instruments)
+
+	for _, m := range options.MarketData {
+		switch enum.MsgType(m.MsgType()) {
+		case enum.MsgType_MARKET_DATA_SNAPSHOT_FULL_REFRESH, enum.MsgType_MARKET_DATA_INCREMENTAL_REFRESH:
+			s.marketData = append(s.marketData, m)
+		}
+	}
+
+	if options.NATSEmbeded {
+		s.natsServer, err = natsd.NewServer(&natsd.Options{
+			// The command shuts the server down once interrupted
+			NoSigs: true,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		s.natsServer.Start()
+	}
+
 	natsOptions := []nats.Option{
 		nats.DontRandomize(),
 		nats.RetryOnFailedConnect(true),
@@ -58,6 +121,17 @@ func NewAcceptor(options *AcceptorOptions) (*Acceptor, error) {
 
 	//s.router.AddRoute(fix50sp2nos.Route(s.onNewOrderSingle))
 	s.router.AddRoute(quickfix.ApplVerIDFIX50SP2, string(enum.MsgType_ORDER_SINGLE), s.onNewOrderSingle)
+	if len(s.instruments) > 0 {
+		s.router.AddRoute(quickfix.ApplVerIDFIX50SP2, string(enum.MsgType_SECURITY_LIST_REQUEST), s.onSecurityListRequest)
+	}
+	if len(s.marketData) > 0 {
+		s.router.AddRoute(quickfix.ApplVerIDFIX50SP2, string(enum.MsgType_MARKET_DATA_REQUEST), s.onMarketDataRequest)
+	}
+	for applVerID := range s.appDictionaries {
+		if applVerID != enum.ApplVerID_FIX50SP2 {
+			s.router.AddRoute(routeVersion(applVerID), string(enum.MsgType_ORDER_SINGLE), s.onNewOrderSingle)
+		}
+	}
 
 	return &s, nil
 }
@@ -76,12 +150,28 @@ type Acceptor struct {
 
 	NatsOrderSubject *template.Template
 	router           *quickfix.MessageRouter
+	instruments      []instrument.Instrument
 	Settings         *quickfix.Settings
+
+	marketData      []replay.Message
+	marketDataSpeed float64
+	subscriptions   map[marketDataSubscription]*marketDataReplay
+	marketDataMux   sync.Mutex
+
+	state  *state.State
+	execID atomic.Int64
+
+	appDictionaries map[enum.ApplVerID]*datadictionary.DataDictionary
+	applVerIDs      map[quickfix.SessionID]enum.ApplVerID
+	applVerIDsMux   sync.Mutex
 }
 
+// Close closes the NATS connection and shuts the embedded NATS server down.
 func (app *Acceptor) Close() {
 	app.natsConn.Close()
-	app.natsServer.Shutdown()
+	if app.natsServer != nil {
+		app.natsServer.Shutdown()
+	}
 }
 
 // Notification of a session begin created.
@@ -92,11 +182,18 @@ func (app *Acceptor) OnCreate(sessionID quickfix.SessionID) {
 // Notification of a session successfully logging on.
 func (app *Acceptor) OnLogon(sessionID quickfix.SessionID) {
 	app.Logger.Debug().Msgf("Logon: %s", sessionID)
+	health.LoggedOn(sessionID)
 }
 
 // Notification of a session logging off or disconnecting.
 func (app *Acceptor) OnLogout(sessionID quickfix.SessionID) {
 	app.Logger.Debug().Msgf("Logout: %s", sessionID)
+	health.LoggedOut(sessionID)
+	app.unsubscribeSession(sessionID)
+
+	app.applVerIDsMux.Lock()
+	delete(app.applVerIDs, sessionID)
+	app.applVerIDsMux.Unlock()
 }
 
 // Notification of admin message being sent to target.
@@ -107,6 +204,15 @@ func (app *Acceptor) ToAdmin(message *quickfix.Message, sessionID quickfix.Sessi
 // Notification of admin message being received from target.
 func (app *Acceptor) FromAdmin(message *quickfix.Message, sessionID quickfix.SessionID) quickfix.MessageRejectError {
 	app.LogMessage(zerolog.TraceLevel, message, sessionID, false)
+
+	if err := app.validate(message, sessionID, true); err != nil {
+		return err
+	}
+
+	if msgType, _ := message.MsgType(); msgType == string(enum.MsgType_LOGON) {
+		app.onLogon(message, sessionID)
+	}
+
 	return nil
 }
 
@@ -119,10 +225,27 @@ func (app *Acceptor) ToApp(message *quickfix.Message, sessionID quickfix.Session
 // Notification of app message being received from target.
 func (app *Acceptor) FromApp(message *quickfix.Message, sessionID quickfix.SessionID) quickfix.MessageRejectError {
 	app.LogMessage(zerolog.TraceLevel, message, sessionID, true)
+
+	if err := app.validate(message, sessionID, false); err != nil {
+		return err
+	}
+
 	return app.router.Route(message, sessionID)
 }
 
-func (app *Acceptor) onNewOrderSingle(order *quickfix.Message, _ quickfix.SessionID) quickfix.MessageRejectError {
+func (app *Acceptor) onNewOrderSingle(order *quickfix.Message, sessionID quickfix.SessionID) quickfix.MessageRejectError {
+	ctx, span := tracing.StartMessage(context.Background(), "fix receive", tracing.SpanKindServer, order, sessionID)
+	defer span.Finish()
+
+	rerr := app.processNewOrderSingle(ctx, order, sessionID)
+	if rerr != nil {
+		span.RecordError(rerr)
+	}
+
+	return rerr
+}
+
+func (app *Acceptor) processNewOrderSingle(ctx context.Context, order *quickfix.Message, sessionID quickfix.SessionID) quickfix.MessageRejectError {
 	symbol, ferr := order.Body.GetString(tag.Symbol)
 	if ferr != nil {
 		return ferr
@@ -153,12 +276,17 @@ func (app *Acceptor) onNewOrderSingle(order *quickfix.Message, _ quickfix.Sessio
 		return quickfix.NewMessageRejectError(err.Error(), int(tag.BusinessRejectReason), nil)
 	}
 
-	err = app.natsConn.Publish(buf.String(), []byte(order.ToMessage().String()))
+	err = app.publish(ctx, buf.String(), order, sessionID)
 	if err != nil {
 		return quickfix.NewMessageRejectError(err.Error(), int(tag.BusinessRejectReason), nil)
 	}
 
-	err = app.sendExecutionReport(order, enum.OrdStatus_NEW)
+	app.recordOrder(order, sessionID, enum.OrdStatus_NEW)
+
+	_, reportSpan := tracing.Start(ctx, "fix send "+string(enum.MsgType_EXECUTION_REPORT), tracing.SpanKindProducer)
+	err = app.sendExecutionReport(order, sessionID, enum.OrdStatus_NEW)
+	reportSpan.RecordError(err)
+	reportSpan.Finish()
 	if err != nil {
 		return quickfix.NewMessageRejectError(err.Error(), int(tag.BusinessRejectReason), nil)
 	}
@@ -166,7 +294,28 @@ func (app *Acceptor) onNewOrderSingle(order *quickfix.Message, _ quickfix.Sessio
 	return nil
 }
 
-func (app *Acceptor) sendExecutionReport(order *quickfix.Message, status enum.OrdStatus) error {
+// publish publishes the message on NATS along with the trace context so that
+// consumers can link their spans to the ones of the FIX flow.
+func (app *Acceptor) publish(ctx context.Context, subject string, message *quickfix.Message, sessionID quickfix.SessionID) error {
+	ctx, span := tracing.StartMessage(ctx, "nats publish", tracing.SpanKindProducer, message, sessionID)
+	defer span.Finish()
+
+	span.SetAttribute("messaging.system", "nats")
+	span.SetAttribute("messaging.destination.name", subject)
+
+	msg := nats.NewMsg(subject)
+	msg.Data = []byte(message.ToMessage().String())
+	tracing.Inject(ctx, msg.Header)
+
+	err := app.natsConn.PublishMsg(msg)
+	span.RecordError(err)
+
+	return err
+}
+
+func (app *Acceptor) sendExecutionReport(order *quickfix.Message, sessionID quickfix.SessionID, status enum.OrdStatus) error {
+	execID := strconv.FormatInt(app.execID.Add(1), 10)
+
 	message := quickfix.NewMessage()
 	header := fixt11.NewHeader(&message.Header)
 
@@ -175,16 +324,46 @@ func (app *Acceptor) sendExecutionReport(order *quickfix.Message, status enum.Or
 	utils.QuickFixMessagePartSetString(&header, utils.MustNot(order.Header.GetString(tag.SenderSubID)), field.NewTargetSubID)
 	utils.QuickFixMessagePartSetString(&header, utils.MustNot(order.Header.GetString(tag.TargetCompID)), field.NewSenderCompID)
 	utils.QuickFixMessagePartSetString(&header, utils.MustNot(order.Header.GetString(tag.TargetSubID)), field.NewSenderSubID)
+	// Execution reports are of the version of their order
+	utils.QuickFixMessagePartSetString(&header, enum.ApplVerID(utils.MustNot(order.Header.GetString(tag.ApplVerID))), field.NewApplVerID)
 
 	utils.QuickFixMessagePartSetString(&message.Body, utils.MustNot(order.Body.GetString(tag.ClOrdID)), field.NewOrderID)
-	utils.QuickFixMessagePartSetString(&message.Body, "0", field.NewExecID)
+	utils.QuickFixMessagePartSetString(&message.Body, execID, field.NewExecID)
 	utils.QuickFixMessagePartSetString(&message.Body, enum.ExecType(status), field.NewExecType)
 	utils.QuickFixMessagePartSetString(&message.Body, status, field.NewOrdStatus)
 	utils.QuickFixMessagePartSetString(&message.Body, enum.Side(utils.MustNot(order.Body.GetString(tag.Side))), field.NewSide)
 	utils.QuickFixMessagePartSetString(&message.Body, utils.MustNot(order.Body.GetString(tag.ClOrdID)), field.NewClOrdID)
-	utils.QuickFixMessagePartSetDecimal(&message.Body, utils.MustNot(order.Body.GetString(tag.LeavesQty)), field.NewLeavesQty, 2)
-	utils.QuickFixMessagePartSetDecimal(&message.Body, utils.MustNot(order.Body.GetString(tag.CumQty)), field.NewCumQty, 2)
-	utils.QuickFixMessagePartSetDecimal(&message.Body, utils.MustNot(order.Body.GetString(tag.OrderQty)), field.NewOrderQty, 2)
 
-	return quickfix.Send(message)
+	symbol := utils.MustNot(order.Body.GetString(tag.Symbol))
+	utils.QuickFixMessagePartSetString(&message.Body, symbol, field.NewSymbol)
+
+	// New orders have neither LeavesQty nor CumQty, which are required
+	orderQty := utils.MustNot(order.Body.GetString(tag.OrderQty))
+	leavesQty, cumQty := utils.MustNot(order.Body.GetString(tag.LeavesQty)), utils.MustNot(order.Body.GetString(tag.CumQty))
+	if len(leavesQty) == 0 {
+		leavesQty = orderQty
+	}
+	if len(cumQty) == 0 {
+		cumQty = "0"
+	}
+	utils.QuickFixMessagePartSetDictionaryDecimal(&message.Body, app.AppDataDictionary, tag.LeavesQty, symbol, leavesQty, field.NewLeavesQty)
+	utils.QuickFixMessagePartSetDictionaryDecimal(&message.Body, app.AppDataDictionary, tag.CumQty, symbol, cumQty, field.NewCumQty)
+	utils.QuickFixMessagePartSetDictionaryDecimal(&message.Body, app.AppDataDictionary, tag.OrderQty, symbol, orderQty, field.NewOrderQty)
+
+	// Fields required by the earlier versions only
+	switch app.applVerID(order, sessionID) {
+	case enum.ApplVerID_FIX40, enum.ApplVerID_FIX41, enum.ApplVerID_FIX42:
+		message.Body.SetString(tagExecTransType, "0")
+		fallthrough
+	case enum.ApplVerID_FIX43, enum.ApplVerID_FIX44:
+		message.Body.Set(field.NewAvgPx(decimal.Zero, 0))
+	}
+
+	if err := quickfix.SendToTarget(message, sessionID); err != nil {
+		return err
+	}
+
+	app.recordExecution(order, sessionID, execID, status)
+
+	return nil
 }
@@ -0,0 +1,62 @@
+package application
+
+import (
+	"github.com/quickfixgo/enum"
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/tag"
+
+	"sylr.dev/fix/pkg/acceptor/state"
+	"sylr.dev/fix/pkg/utils"
+)
+
+// recordOrder persists the order received, if the acceptor has a state. The
+// OrderID of the orders is their ClOrdID.
+func (app *Acceptor) recordOrder(order *quickfix.Message, sessionID quickfix.SessionID, status enum.OrdStatus) {
+	if app.state == nil {
+		return
+	}
+
+	clOrdID := utils.MustNot(order.Body.GetString(tag.ClOrdID))
+	orderQty := utils.MustNot(order.Body.GetString(tag.OrderQty))
+
+	err := app.state.RecordOrder(state.Order{
+		Session:   sessionID.String(),
+		OrderID:   clOrdID,
+		ClOrdID:   clOrdID,
+		Symbol:    utils.MustNot(order.Body.GetString(tag.Symbol)),
+		Side:      utils.MustNot(order.Body.GetString(tag.Side)),
+		OrdType:   utils.MustNot(order.Body.GetString(tag.OrdType)),
+		Price:     utils.MustNot(order.Body.GetString(tag.Price)),
+		OrderQty:  orderQty,
+		OrdStatus: string(status),
+		CumQty:    "0",
+		LeavesQty: orderQty,
+	})
+	if err != nil {
+		app.Logger.Error().Err(err).Msgf("%s: recording order %s", sessionID, clOrdID)
+	}
+}
+
+// recordExecution persists the execution report sent for the order, if the
+// acceptor has a state.
+func (app *Acceptor) recordExecution(order *quickfix.Message, sessionID quickfix.SessionID, execID string, status enum.OrdStatus) {
+	if app.state == nil {
+		return
+	}
+
+	clOrdID := utils.MustNot(order.Body.GetString(tag.ClOrdID))
+
+	err := app.state.RecordExecution(state.Execution{
+		ExecID:    execID,
+		Session:   sessionID.String(),
+		OrderID:   clOrdID,
+		ClOrdID:   clOrdID,
+		Symbol:    utils.MustNot(order.Body.GetString(tag.Symbol)),
+		Side:      utils.MustNot(order.Body.GetString(tag.Side)),
+		ExecType:  string(enum.ExecType(status)),
+		OrdStatus: string(status),
+	})
+	if err != nil {
+		app.Logger.Error().Err(err).Msgf("%s: recording execution %s of order %s", sessionID, execID, clOrdID)
+	}
+}
@@ -0,0 +1,120 @@
+package application
+
+import (
+	"github.com/quickfixgo/enum"
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/quickfix/datadictionary"
+	"github.com/quickfixgo/tag"
+
+	"sylr.dev/fix/pkg/dict"
+)
+
+// tagExecTransType is only part of the execution reports up to FIX 4.2.
+const tagExecTransType quickfix.Tag = 20
+
+// rejectReasonValueIsIncorrect is used for the unsupported ApplVerIDs, the
+// FIXT.1.1 dictionary lacking InvalidUnsupportedApplicationVersion.
+const rejectReasonValueIsIncorrect = 5
+
+// routeVersion returns the version the router keys the routes of the messages
+// of the ApplVerID by.
+func routeVersion(applVerID enum.ApplVerID) string {
+	switch applVerID {
+	case enum.ApplVerID_FIX40, enum.ApplVerID_FIX41, enum.ApplVerID_FIX42, enum.ApplVerID_FIX43, enum.ApplVerID_FIX44:
+		version, _ := dict.SearchValue(dict.ApplVerIDs, applVerID)
+		return version
+	}
+
+	return string(applVerID)
+}
+
+// onLogon records the DefaultApplVerID of the counterparty, the version of the
+// application messages it sends without ApplVerID.
+func (app *Acceptor) onLogon(logon *quickfix.Message, sessionID quickfix.SessionID) {
+	applVerID, err := logon.Body.GetString(tag.DefaultApplVerID)
+	if err != nil {
+		return
+	}
+
+	app.applVerIDsMux.Lock()
+	defer app.applVerIDsMux.Unlock()
+
+	if app.applVerIDs == nil {
+		app.applVerIDs = make(map[quickfix.SessionID]enum.ApplVerID)
+	}
+	app.applVerIDs[sessionID] = enum.ApplVerID(applVerID)
+}
+
+// applVerID returns the ApplVerID of the application message received on the
+// session: the one of its header, else the DefaultApplVerID of the Logon of
+// the counterparty, else the one of the session.
+func (app *Acceptor) applVerID(message *quickfix.Message, sessionID quickfix.SessionID) enum.ApplVerID {
+	if applVerID, err := message.Header.GetString(tag.ApplVerID); err == nil {
+		return enum.ApplVerID(applVerID)
+	}
+
+	app.applVerIDsMux.Lock()
+	applVerID, ok := app.applVerIDs[sessionID]
+	app.applVerIDsMux.Unlock()
+	if ok {
+		return applVerID
+	}
+
+	if app.Settings != nil {
+		if settings, ok := app.Settings.SessionSettings()[sessionID]; ok {
+			if version, err := settings.Setting("DefaultApplVerID"); err == nil {
+				applVerID, _ = dict.ApplVerID(version)
+			}
+		}
+	}
+
+	return applVerID
+}
+
+// validate validates the message received on a session handling several
+// versions against the dictionary of its version, quickfix only knowing one.
+func (app *Acceptor) validate(message *quickfix.Message, sessionID quickfix.SessionID, admin bool) quickfix.MessageRejectError {
+	if len(app.appDictionaries) == 0 || app.TransportDataDictionary == nil {
+		return nil
+	}
+
+	var appDict *datadictionary.DataDictionary
+	if !admin {
+		applVerID := app.applVerID(message, sessionID)
+
+		var ok bool
+		if appDict, ok = app.appDictionaries[applVerID]; !ok {
+			refTagID := tag.ApplVerID
+			return quickfix.NewMessageRejectError("Unsupported ApplVerID "+string(applVerID), rejectReasonValueIsIncorrect, &refTagID)
+		}
+	}
+
+	return quickfix.NewValidator(app.validatorSettings(sessionID), appDict, app.TransportDataDictionary).Validate(message)
+}
+
+// validatorSettings returns the validation settings of the session, the
+// defaults of quickfix unless configured.
+func (app *Acceptor) validatorSettings(sessionID quickfix.SessionID) quickfix.ValidatorSettings {
+	validatorSettings := quickfix.ValidatorSettings{
+		CheckFieldsOutOfOrder: true,
+		RejectInvalidMessage:  true,
+	}
+
+	if app.Settings == nil {
+		return validatorSettings
+	}
+
+	settings, ok := app.Settings.SessionSettings()[sessionID]
+	if !ok {
+		return validatorSettings
+	}
+
+	if v, err := settings.BoolSetting("ValidateFieldsOutOfOrder"); err == nil {
+		validatorSettings.CheckFieldsOutOfOrder = v
+	}
+	if v, err := settings.BoolSetting("RejectInvalidMessage"); err == nil {
+		validatorSettings.RejectInvalidMessage = v
+	}
+
+	return validatorSettings
+}
@@ -18,7 +18,7 @@ func ValidateOptions(cmd *cobra.Command, args []string) error {
 	}
 
 	options := config.GetOptions()
-	conf, err := config.ReadYAML(options.Config, options.Interactive)
+	conf, err := config.ReadYAMLFiles(config.ConfigPaths(cmd.Flags().Changed("config")), options.Interactive)
 
 	if err != nil {
 		return fmt.Errorf("unable to read configuration: %w", err)
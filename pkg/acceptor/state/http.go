@@ -0,0 +1,108 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Handler returns the HTTP handler answering with the JSON arrays of the
+// state recorded:
+//
+//   - /orders and /executions, filtered with the session, symbol, orderID,
+//     since (a duration or an RFC 3339 time) and limit query parameters.
+//   - /instruments.
+func (s *State) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/orders", func(w http.ResponseWriter, r *http.Request) {
+		f, err := parseFilter(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		orders, err := s.Orders(f)
+		writeJSON(w, orders, err)
+	})
+	mux.HandleFunc("/executions", func(w http.ResponseWriter, r *http.Request) {
+		f, err := parseFilter(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		executions, err := s.Executions(f)
+		writeJSON(w, executions, err)
+	})
+	mux.HandleFunc("/instruments", func(w http.ResponseWriter, r *http.Request) {
+		instruments, err := s.Instruments()
+		writeJSON(w, instruments, err)
+	})
+
+	return mux
+}
+
+func parseFilter(r *http.Request) (Filter, error) {
+	query := r.URL.Query()
+
+	f := Filter{
+		Session: query.Get("session"),
+		Symbol:  query.Get("symbol"),
+		OrderID: query.Get("orderID"),
+	}
+
+	if since := query.Get("since"); len(since) > 0 {
+		if d, err := time.ParseDuration(since); err == nil {
+			f.Since = time.Now().Add(-d)
+		} else if f.Since, err = time.Parse(time.RFC3339, since); err != nil {
+			return f, err
+		}
+	}
+
+	if limit := query.Get("limit"); len(limit) > 0 {
+		var err error
+		if f.Limit, err = strconv.Atoi(limit); err != nil {
+			return f, err
+		}
+	}
+
+	return f, nil
+}
+
+func writeJSON[T any](w http.ResponseWriter, values []T, err error) {
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if values == nil {
+		values = []T{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(values)
+}
+
+// Serve serves the state over HTTP on the address until the context is done.
+func (s *State) Serve(ctx context.Context, listen string, logger *zerolog.Logger) error {
+	server := &http.Server{Addr: listen, Handler: s.Handler()}
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	logger.Info().Msgf("Serving acceptor state on %s", listen)
+
+	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,423 @@
+// Package state persists the simulated state of the acceptor, the orders it
+// received, the executions it reported and the instruments it lists, in a
+// SQLite or PostgreSQL database so that long-running simulation environments
+// survive restarts and can be inspected with SQL.
+//
+// The tables are created if needed:
+//
+//   - acceptor_orders, keyed by session and OrderID.
+//   - acceptor_executions, keyed by ExecID.
+//   - acceptor_instruments, keyed by symbol.
+package state
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"sylr.dev/fix/pkg/errors"
+	"sylr.dev/fix/pkg/instrument"
+)
+
+// Drivers are the database drivers supported.
+var Drivers = []string{"sqlite3", "postgres"}
+
+const schema = `
+CREATE TABLE IF NOT EXISTS acceptor_orders (
+	session TEXT NOT NULL,
+	order_id TEXT NOT NULL,
+	cl_ord_id TEXT NOT NULL,
+	symbol TEXT NOT NULL DEFAULT '',
+	side TEXT NOT NULL DEFAULT '',
+	ord_type TEXT NOT NULL DEFAULT '',
+	price TEXT NOT NULL DEFAULT '',
+	order_qty TEXT NOT NULL DEFAULT '',
+	ord_status TEXT NOT NULL DEFAULT '',
+	cum_qty TEXT NOT NULL DEFAULT '',
+	leaves_qty TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (session, order_id)
+);
+CREATE INDEX IF NOT EXISTS acceptor_orders_created_at ON acceptor_orders (created_at);
+CREATE TABLE IF NOT EXISTS acceptor_executions (
+	exec_id TEXT PRIMARY KEY,
+	seq BIGINT NOT NULL,
+	session TEXT NOT NULL,
+	order_id TEXT NOT NULL,
+	cl_ord_id TEXT NOT NULL,
+	symbol TEXT NOT NULL DEFAULT '',
+	side TEXT NOT NULL DEFAULT '',
+	exec_type TEXT NOT NULL DEFAULT '',
+	ord_status TEXT NOT NULL DEFAULT '',
+	last_qty TEXT NOT NULL DEFAULT '',
+	last_px TEXT NOT NULL DEFAULT '',
+	cum_qty TEXT NOT NULL DEFAULT '',
+	leaves_qty TEXT NOT NULL DEFAULT '',
+	time TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS acceptor_executions_order ON acceptor_executions (session, order_id);
+CREATE TABLE IF NOT EXISTS acceptor_instruments (
+	symbol TEXT PRIMARY KEY,
+	security_id TEXT NOT NULL DEFAULT '',
+	security_type TEXT NOT NULL DEFAULT '',
+	product TEXT NOT NULL DEFAULT '',
+	currency TEXT NOT NULL DEFAULT '',
+	tick_size TEXT NOT NULL DEFAULT '',
+	lot_size TEXT NOT NULL DEFAULT '',
+	trading_status TEXT NOT NULL DEFAULT '',
+	security_status TEXT NOT NULL DEFAULT '',
+	updated_at TIMESTAMP NOT NULL
+);
+`
+
+// Order is an order received by the acceptor.
+type Order struct {
+	Session   string    `json:"session"`
+	OrderID   string    `json:"orderID"`
+	ClOrdID   string    `json:"clOrdID"`
+	Symbol    string    `json:"symbol,omitempty"`
+	Side      string    `json:"side,omitempty"`
+	OrdType   string    `json:"ordType,omitempty"`
+	Price     string    `json:"price,omitempty"`
+	OrderQty  string    `json:"orderQty,omitempty"`
+	OrdStatus string    `json:"ordStatus,omitempty"`
+	CumQty    string    `json:"cumQty,omitempty"`
+	LeavesQty string    `json:"leavesQty,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Execution is an execution report sent by the acceptor, a trade when its
+// LastQty is set.
+type Execution struct {
+	ExecID    string    `json:"execID"`
+	Session   string    `json:"session"`
+	OrderID   string    `json:"orderID"`
+	ClOrdID   string    `json:"clOrdID"`
+	Symbol    string    `json:"symbol,omitempty"`
+	Side      string    `json:"side,omitempty"`
+	ExecType  string    `json:"execType,omitempty"`
+	OrdStatus string    `json:"ordStatus,omitempty"`
+	LastQty   string    `json:"lastQty,omitempty"`
+	LastPx    string    `json:"lastPx,omitempty"`
+	CumQty    string    `json:"cumQty,omitempty"`
+	LeavesQty string    `json:"leavesQty,omitempty"`
+	Time      time.Time `json:"time"`
+}
+
+// Filter restricts the orders and executions listed. Empty fields match
+// everything.
+type Filter struct {
+	Session string
+	Symbol  string
+	OrderID string
+	Since   time.Time
+	Limit   int
+}
+
+// State is the database the state of the acceptor is persisted in.
+type State struct {
+	db     *sql.DB
+	driver string
+	mux    sync.Mutex
+}
+
+// Open opens the database, creating its tables if needed. The data source
+// name of the sqlite3 driver is the path of the database.
+func Open(driver, dsn string) (*State, error) {
+	switch driver {
+	case "sqlite3":
+		dsn = os.ExpandEnv(dsn)
+		if err := os.MkdirAll(filepath.Dir(dsn), 0700); err != nil {
+			return nil, err
+		}
+		if !strings.Contains(dsn, "?") {
+			dsn += "?_busy_timeout=5000"
+		}
+	case "postgres":
+	default:
+		return nil, fmt.Errorf("%w: unsupported state driver %s, must be one of %s", errors.Options, driver, strings.Join(Drivers, ", "))
+	}
+
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	// PostgreSQL does not run several statements in a prepared query
+	for _, statement := range strings.Split(schema, ";") {
+		if len(strings.TrimSpace(statement)) == 0 {
+			continue
+		}
+		if _, err := db.Exec(statement); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("acceptor state: %w", err)
+		}
+	}
+
+	return &State{db: db, driver: driver}, nil
+}
+
+// Close closes the database.
+func (s *State) Close() error {
+	return s.db.Close()
+}
+
+// rebind replaces the ? placeholders of the query by the $n ones of
+// PostgreSQL.
+func (s *State) rebind(query string) string {
+	if s.driver != "postgres" {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+func (s *State) exec(query string, args ...any) error {
+	_, err := s.db.Exec(s.rebind(query), args...)
+	return err
+}
+
+// RecordOrder records an order received, replacing the one of the session
+// having the same OrderID.
+func (s *State) RecordOrder(o Order) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	now := time.Now().UTC()
+
+	return s.exec(`INSERT INTO acceptor_orders (session, order_id, cl_ord_id, symbol, side, ord_type, price, order_qty, ord_status, cum_qty, leaves_qty, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (session, order_id) DO UPDATE SET
+			cl_ord_id = excluded.cl_ord_id,
+			symbol = excluded.symbol,
+			side = excluded.side,
+			ord_type = excluded.ord_type,
+			price = excluded.price,
+			order_qty = excluded.order_qty,
+			ord_status = excluded.ord_status,
+			cum_qty = excluded.cum_qty,
+			leaves_qty = excluded.leaves_qty,
+			updated_at = excluded.updated_at`,
+		o.Session, o.OrderID, o.ClOrdID, o.Symbol, o.Side, o.OrdType, o.Price, o.OrderQty, o.OrdStatus, o.CumQty, o.LeavesQty, now, now)
+}
+
+// RecordExecution records an execution report sent and updates the status
+// and quantities of its order.
+func (s *State) RecordExecution(e Execution) error {
+	seq, err := strconv.ParseInt(e.ExecID, 10, 64)
+	if err != nil {
+		seq = 0
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	if e.Time.IsZero() {
+		e.Time = now
+	}
+
+	if _, err := tx.Exec(s.rebind(`INSERT INTO acceptor_executions (exec_id, seq, session, order_id, cl_ord_id, symbol, side, exec_type, ord_status, last_qty, last_px, cum_qty, leaves_qty, time)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+		e.ExecID, seq, e.Session, e.OrderID, e.ClOrdID, e.Symbol, e.Side, e.ExecType, e.OrdStatus, e.LastQty, e.LastPx, e.CumQty, e.LeavesQty, e.Time.UTC()); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(s.rebind(`UPDATE acceptor_orders SET
+			ord_status = ?,
+			cum_qty = COALESCE(NULLIF(?, ''), cum_qty),
+			leaves_qty = COALESCE(NULLIF(?, ''), leaves_qty),
+			updated_at = ?
+		WHERE session = ? AND order_id = ?`),
+		e.OrdStatus, e.CumQty, e.LeavesQty, now, e.Session, e.OrderID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// LastExecID returns the greatest numeric ExecID recorded, for the ones sent
+// after a restart not to collide with them.
+func (s *State) LastExecID() (int64, error) {
+	var last sql.NullInt64
+	if err := s.db.QueryRow(`SELECT MAX(seq) FROM acceptor_executions`).Scan(&last); err != nil {
+		return 0, err
+	}
+
+	return last.Int64, nil
+}
+
+// where returns the WHERE clause of the filter and its arguments.
+func (f Filter) where() (string, []any) {
+	var clauses []string
+	var args []any
+
+	if len(f.Session) > 0 {
+		clauses = append(clauses, "session = ?")
+		args = append(args, f.Session)
+	}
+	if len(f.Symbol) > 0 {
+		clauses = append(clauses, "symbol = ?")
+		args = append(args, f.Symbol)
+	}
+	if len(f.OrderID) > 0 {
+		clauses = append(clauses, "order_id = ?")
+		args = append(args, f.OrderID)
+	}
+
+	if len(clauses) == 0 {
+		return "", nil
+	}
+
+	return " WHERE " + strings.Join(clauses, " AND "), args
+}
+
+func (f Filter) limit() string {
+	if f.Limit <= 0 {
+		return ""
+	}
+
+	return fmt.Sprintf(" LIMIT %d", f.Limit)
+}
+
+// Orders returns the orders matching the filter, latest first.
+func (s *State) Orders(f Filter) ([]Order, error) {
+	where, args := f.where()
+	if !f.Since.IsZero() {
+		where = joinClause(where, "created_at >= ?")
+		args = append(args, f.Since.UTC())
+	}
+
+	rows, err := s.db.Query(s.rebind(`SELECT session, order_id, cl_ord_id, symbol, side, ord_type, price, order_qty, ord_status, cum_qty, leaves_qty, created_at, updated_at
+		FROM acceptor_orders`+where+` ORDER BY created_at DESC`+f.limit()), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orders []Order
+	for rows.Next() {
+		var o Order
+		if err := rows.Scan(&o.Session, &o.OrderID, &o.ClOrdID, &o.Symbol, &o.Side, &o.OrdType, &o.Price, &o.OrderQty, &o.OrdStatus, &o.CumQty, &o.LeavesQty, &o.CreatedAt, &o.UpdatedAt); err != nil {
+			return nil, err
+		}
+		orders = append(orders, o)
+	}
+
+	return orders, rows.Err()
+}
+
+// Executions returns the executions matching the filter, latest first.
+func (s *State) Executions(f Filter) ([]Execution, error) {
+	where, args := f.where()
+	if !f.Since.IsZero() {
+		where = joinClause(where, "time >= ?")
+		args = append(args, f.Since.UTC())
+	}
+
+	rows, err := s.db.Query(s.rebind(`SELECT exec_id, session, order_id, cl_ord_id, symbol, side, exec_type, ord_status, last_qty, last_px, cum_qty, leaves_qty, time
+		FROM acceptor_executions`+where+` ORDER BY seq DESC, time DESC`+f.limit()), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var executions []Execution
+	for rows.Next() {
+		var e Execution
+		if err := rows.Scan(&e.ExecID, &e.Session, &e.OrderID, &e.ClOrdID, &e.Symbol, &e.Side, &e.ExecType, &e.OrdStatus, &e.LastQty, &e.LastPx, &e.CumQty, &e.LeavesQty, &e.Time); err != nil {
+			return nil, err
+		}
+		executions = append(executions, e)
+	}
+
+	return executions, rows.Err()
+}
+
+func joinClause(where, clause string) string {
+	if len(where) == 0 {
+		return " WHERE " + clause
+	}
+
+	return where + " AND " + clause
+}
+
+// SaveInstruments records the instruments listed, replacing the ones having
+// the same symbols.
+func (s *State) SaveInstruments(instruments []instrument.Instrument) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+
+	for _, i := range instruments {
+		if _, err := tx.Exec(s.rebind(`INSERT INTO acceptor_instruments (symbol, security_id, security_type, product, currency, tick_size, lot_size, trading_status, security_status, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (symbol) DO UPDATE SET
+				security_id = excluded.security_id,
+				security_type = excluded.security_type,
+				product = excluded.product,
+				currency = excluded.currency,
+				tick_size = excluded.tick_size,
+				lot_size = excluded.lot_size,
+				trading_status = excluded.trading_status,
+				security_status = excluded.security_status,
+				updated_at = excluded.updated_at`),
+			i.Symbol, i.SecurityID, i.SecurityType, i.Product, i.Currency, i.TickSize, i.LotSize, i.TradingStatus, i.SecurityStatus, now); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Instruments returns the instruments recorded, sorted by symbol.
+func (s *State) Instruments() ([]instrument.Instrument, error) {
+	rows, err := s.db.Query(`SELECT symbol, security_id, security_type, product, currency, tick_size, lot_size, trading_status, security_status
+		FROM acceptor_instruments ORDER BY symbol`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var instruments []instrument.Instrument
+	for rows.Next() {
+		var i instrument.Instrument
+		if err := rows.Scan(&i.Symbol, &i.SecurityID, &i.SecurityType, &i.Product, &i.Currency, &i.TickSize, &i.LotSize, &i.TradingStatus, &i.SecurityStatus); err != nil {
+			return nil, err
+		}
+		instruments = append(instruments, i)
+	}
+
+	return instruments, rows.Err()
+}
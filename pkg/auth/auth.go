@@ -0,0 +1,336 @@
+// Package auth authenticates the Logon messages of the initiator sessions
+// beyond the Username and Password, with the providers configured for them.
+//
+// The built-in providers are:
+//
+//   - hmac-sha256, which signs the values of SignedTags (SendingTime by
+//     default), joined with Separator, with Secret and places the signature,
+//     base64 or hex encoded, in Tag (RawData by default, RawDataLength being
+//     set along).
+//   - api-key, which places APIKey in Tag.
+//   - tags, which sets Tags in order.
+//
+// Other providers can be added with Register.
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/quickfixgo/enum"
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/tag"
+	"github.com/rs/zerolog"
+
+	"sylr.dev/fix/pkg/errors"
+)
+
+// SettingKey is the session setting holding the providers of a session,
+// encoded as JSON.
+const SettingKey = "AuthProviders"
+
+// Tags missing from the tag package.
+const (
+	tagRawDataLength quickfix.Tag = 95
+	tagRawData       quickfix.Tag = 96
+)
+
+// Provider is the configuration of an authentication provider of a session.
+// Secret, APIKey and the values of Tags are expanded with the environment.
+type Provider struct {
+	Provider string `yaml:"Provider" json:"provider"`
+	// Tag is the tag the signature or the API key is placed in
+	Tag int `yaml:"Tag,omitempty" json:"tag,omitempty"`
+	// Header places the tags in the header of the message instead of its body
+	Header bool `yaml:"Header,omitempty" json:"header,omitempty"`
+
+	Secret         string `yaml:"Secret,omitempty" json:"secret,omitempty"`
+	SecretEncoding string `yaml:"SecretEncoding,omitempty" json:"secretEncoding,omitempty"`
+	SignedTags     []int  `yaml:"SignedTags,omitempty" json:"signedTags,omitempty"`
+	Separator      string `yaml:"Separator,omitempty" json:"separator,omitempty"`
+	Encoding       string `yaml:"Encoding,omitempty" json:"encoding,omitempty"`
+
+	APIKey string `yaml:"APIKey,omitempty" json:"apiKey,omitempty"`
+
+	Tags []Tag `yaml:"Tags,omitempty" json:"tags,omitempty"`
+}
+
+// Tag is a tag set by the tags provider.
+type Tag struct {
+	Tag   int    `yaml:"Tag" json:"tag"`
+	Value string `yaml:"Value" json:"value"`
+}
+
+// Authenticator adds the credentials of a provider to a Logon message.
+type Authenticator interface {
+	Authenticate(logon *quickfix.Message) error
+}
+
+// AuthenticatorFunc is a function implementing Authenticator.
+type AuthenticatorFunc func(logon *quickfix.Message) error
+
+func (f AuthenticatorFunc) Authenticate(logon *quickfix.Message) error {
+	return f(logon)
+}
+
+var (
+	providers = make(map[string]func(Provider) (Authenticator, error))
+	mux       sync.RWMutex
+)
+
+func init() {
+	Register("hmac-sha256", newHMACSHA256)
+	Register("api-key", newAPIKey)
+	Register("tags", newTags)
+}
+
+// Register makes a provider available under the name.
+func Register(name string, provider func(Provider) (Authenticator, error)) {
+	mux.Lock()
+	defer mux.Unlock()
+
+	providers[name] = provider
+}
+
+// Names returns the names of the providers registered, sorted.
+func Names() []string {
+	mux.RLock()
+	defer mux.RUnlock()
+
+	return names()
+}
+
+func names() []string {
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// New returns the authenticators of the providers, in order.
+func New(configs []Provider) ([]Authenticator, error) {
+	mux.RLock()
+	defer mux.RUnlock()
+
+	authenticators := make([]Authenticator, 0, len(configs))
+	for _, config := range configs {
+		provider, ok := providers[config.Provider]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown auth provider %q, expected one of %s", errors.Config, config.Provider, strings.Join(names(), ", "))
+		}
+
+		authenticator, err := provider(config)
+		if err != nil {
+			return nil, fmt.Errorf("%w: auth provider %s: %s", errors.Config, config.Provider, err)
+		}
+
+		authenticators = append(authenticators, authenticator)
+	}
+
+	return authenticators, nil
+}
+
+// Authenticate applies the authenticators to the Logon message, in order.
+func Authenticate(logon *quickfix.Message, authenticators []Authenticator) error {
+	for _, authenticator := range authenticators {
+		if err := authenticator.Authenticate(logon); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// FromSessionSettings returns the authenticators of the SettingKey setting of
+// the session, none when it has none.
+func FromSessionSettings(settings *quickfix.SessionSettings) ([]Authenticator, error) {
+	if !settings.HasSetting(SettingKey) {
+		return nil, nil
+	}
+
+	value, err := settings.Setting(SettingKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var configs []Provider
+	if err := json.Unmarshal([]byte(value), &configs); err != nil {
+		return nil, fmt.Errorf("%w: %s: %s", errors.Config, SettingKey, err)
+	}
+
+	return New(configs)
+}
+
+// set sets the tag in the header or the body of the message.
+func set(message *quickfix.Message, header bool, t quickfix.Tag, value string) {
+	if header {
+		message.Header.SetString(t, value)
+	} else {
+		message.Body.SetString(t, value)
+	}
+}
+
+// get returns the value of the tag, looked up in the header then in the body.
+func get(message *quickfix.Message, t quickfix.Tag) string {
+	if value, err := message.Header.GetString(t); err == nil {
+		return value
+	}
+
+	value, _ := message.Body.GetString(t)
+
+	return value
+}
+
+func newHMACSHA256(config Provider) (Authenticator, error) {
+	secret := os.ExpandEnv(config.Secret)
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("no Secret given")
+	}
+
+	var key []byte
+	switch config.SecretEncoding {
+	case "", "raw":
+		key = []byte(secret)
+	case "base64":
+		var err error
+		if key, err = base64.StdEncoding.DecodeString(secret); err != nil {
+			return nil, fmt.Errorf("Secret: %w", err)
+		}
+	case "hex":
+		var err error
+		if key, err = hex.DecodeString(secret); err != nil {
+			return nil, fmt.Errorf("Secret: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown SecretEncoding %q, expected raw, base64 or hex", config.SecretEncoding)
+	}
+
+	encode := base64.StdEncoding.EncodeToString
+	switch config.Encoding {
+	case "", "base64":
+	case "hex":
+		encode = hex.EncodeToString
+	default:
+		return nil, fmt.Errorf("unknown Encoding %q, expected base64 or hex", config.Encoding)
+	}
+
+	signed := make([]quickfix.Tag, 0, len(config.SignedTags))
+	for _, t := range config.SignedTags {
+		signed = append(signed, quickfix.Tag(t))
+	}
+	if len(signed) == 0 {
+		signed = append(signed, tag.SendingTime)
+	}
+
+	target := quickfix.Tag(config.Tag)
+	if target == 0 {
+		target = tagRawData
+	}
+
+	return AuthenticatorFunc(func(logon *quickfix.Message) error {
+		values := make([]string, len(signed))
+		for i, t := range signed {
+			values[i] = get(logon, t)
+		}
+
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(strings.Join(values, config.Separator)))
+		signature := encode(mac.Sum(nil))
+
+		if target == tagRawData {
+			set(logon, config.Header, tagRawDataLength, fmt.Sprint(len(signature)))
+		}
+		set(logon, config.Header, target, signature)
+
+		return nil
+	}), nil
+}
+
+func newAPIKey(config Provider) (Authenticator, error) {
+	key := os.ExpandEnv(config.APIKey)
+	if len(key) == 0 {
+		return nil, fmt.Errorf("no APIKey given")
+	} else if config.Tag == 0 {
+		return nil, fmt.Errorf("no Tag given")
+	}
+
+	return AuthenticatorFunc(func(logon *quickfix.Message) error {
+		set(logon, config.Header, quickfix.Tag(config.Tag), key)
+		return nil
+	}), nil
+}
+
+func newTags(config Provider) (Authenticator, error) {
+	if len(config.Tags) == 0 {
+		return nil, fmt.Errorf("no Tags given")
+	}
+
+	for _, t := range config.Tags {
+		if t.Tag <= 0 {
+			return nil, fmt.Errorf("invalid tag %d", t.Tag)
+		}
+	}
+
+	return AuthenticatorFunc(func(logon *quickfix.Message) error {
+		for _, t := range config.Tags {
+			set(logon, config.Header, quickfix.Tag(t.Tag), os.ExpandEnv(t.Value))
+		}
+		return nil
+	}), nil
+}
+
+// Application authenticates the Logon messages sent by the sessions having a
+// SettingKey setting.
+type Application struct {
+	quickfix.Application
+
+	authenticators map[quickfix.SessionID][]Authenticator
+	logger         *zerolog.Logger
+}
+
+// NewApplication returns the application wrapped with the authenticators of
+// the sessions, or as is when no session has any. The Logon messages which can
+// not be authenticated are logged to logger, if any, and sent as is.
+func NewApplication(app quickfix.Application, settings *quickfix.Settings, logger *zerolog.Logger) (quickfix.Application, error) {
+	authenticators := make(map[quickfix.SessionID][]Authenticator)
+
+	for sessionID, sessionSettings := range settings.SessionSettings() {
+		a, err := FromSessionSettings(sessionSettings)
+		if err != nil {
+			return nil, err
+		} else if len(a) > 0 {
+			authenticators[sessionID] = a
+		}
+	}
+
+	if len(authenticators) == 0 {
+		return app, nil
+	}
+
+	return &Application{Application: app, authenticators: authenticators, logger: logger}, nil
+}
+
+// ToAdmin authenticates the Logon messages once the application has handled
+// them, so that the signatures cover the Username and Password it sets.
+func (a *Application) ToAdmin(message *quickfix.Message, sessionID quickfix.SessionID) {
+	a.Application.ToAdmin(message, sessionID)
+
+	if msgType, err := message.MsgType(); err != nil || enum.MsgType(msgType) != enum.MsgType_LOGON {
+		return
+	}
+
+	if err := Authenticate(message, a.authenticators[sessionID]); err != nil && a.logger != nil {
+		a.logger.Error().Err(err).Msgf("quickfix(%s): logon authentication", sessionID)
+	}
+}
@@ -13,7 +13,7 @@ func Context(cmd *cobra.Command, args []string, toComplete string) ([]string, co
 	options := config.GetOptions()
 	fixConfig := config.GetConfig()
 
-	if conf, err := config.ReadYAMLNoAge(options.Config); err == nil {
+	if conf, err := config.ReadYAMLFilesNoAge(config.ConfigPaths(cmd.Flags().Changed("config"))); err == nil {
 		*fixConfig = *conf
 	} else {
 		if options.Verbose > 0 {
@@ -34,7 +34,7 @@ func Acceptor(cmd *cobra.Command, args []string, toComplete string) ([]string, c
 	options := config.GetOptions()
 	fixConfig := config.GetConfig()
 
-	if conf, err := config.ReadYAMLNoAge(options.Config); err == nil {
+	if conf, err := config.ReadYAMLFilesNoAge(config.ConfigPaths(cmd.Flags().Changed("config"))); err == nil {
 		*fixConfig = *conf
 	} else {
 		if options.Verbose > 0 {
@@ -55,7 +55,7 @@ func Initiator(cmd *cobra.Command, args []string, toComplete string) ([]string,
 	options := config.GetOptions()
 	fixConfig := config.GetConfig()
 
-	if conf, err := config.ReadYAMLNoAge(options.Config); err == nil {
+	if conf, err := config.ReadYAMLFilesNoAge(config.ConfigPaths(cmd.Flags().Changed("config"))); err == nil {
 		*fixConfig = *conf
 	} else {
 		if options.Verbose > 0 {
@@ -76,7 +76,7 @@ func Session(cmd *cobra.Command, args []string, toComplete string) ([]string, co
 	options := config.GetOptions()
 	fixConfig := config.GetConfig()
 
-	if conf, err := config.ReadYAMLNoAge(options.Config); err == nil {
+	if conf, err := config.ReadYAMLFilesNoAge(config.ConfigPaths(cmd.Flags().Changed("config"))); err == nil {
 		*fixConfig = *conf
 	} else {
 		if options.Verbose > 0 {
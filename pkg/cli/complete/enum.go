@@ -0,0 +1,79 @@
+package complete
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/quickfix/datadictionary"
+	"github.com/spf13/cobra"
+
+	"sylr.dev/fix/config"
+	"sylr.dev/fix/pkg/utils"
+)
+
+// dictionaryEnum returns the completions of a flag whose values are those of
+// the field in the application dictionary of the selected session, so that
+// the enums of the venue are completed. Values also found in known are
+// completed with their names there, the other ones with their description in
+// the dictionary. The known names are returned when there is no dictionary
+// or the field has no enum in it.
+func dictionaryEnum[T ~string](cmd *cobra.Command, field quickfix.Tag, known map[string]T) []string {
+	fieldType, ok := dictionaryField(cmd, field)
+	if !ok || len(fieldType.Enums) == 0 {
+		return utils.PrettyOptionValues(known)
+	}
+
+	names := make(map[string][]string, len(known))
+	for name, value := range known {
+		names[string(value)] = append(names[string(value)], strings.ToLower(name))
+	}
+
+	completions := make([]string, 0, len(fieldType.Enums))
+	for value, e := range fieldType.Enums {
+		if n, ok := names[value]; ok {
+			completions = append(completions, n...)
+		} else if len(e.Description) > 0 {
+			completions = append(completions, strings.ToLower(e.Description))
+		}
+	}
+	sort.Strings(completions)
+
+	return completions
+}
+
+// dictionaryField returns the definition of the field in the application
+// dictionary of the session given with --session or of the first session of
+// the current context.
+func dictionaryField(cmd *cobra.Command, field quickfix.Tag) (*datadictionary.FieldType, bool) {
+	options := config.GetOptions()
+	fixConfig := config.GetConfig()
+
+	conf, err := config.ReadYAMLFilesNoAge(config.ConfigPaths(cmd.Flags().Changed("config")))
+	if err != nil {
+		return nil, false
+	}
+	*fixConfig = *conf
+
+	var session *config.Session
+	if len(options.Session) > 0 {
+		session, err = config.GetSession(options.Session)
+	} else if context, cerr := config.GetCurrentContext(); cerr == nil {
+		var sessions []*config.Session
+		if sessions, err = context.GetSessions(); err == nil && len(sessions) > 0 {
+			session = sessions[0]
+		}
+	}
+	if err != nil || session == nil {
+		return nil, false
+	}
+
+	_, appDict, err := session.GetFIXDictionaries()
+	if err != nil || appDict == nil {
+		return nil, false
+	}
+
+	fieldType, ok := appDict.FieldTypeByTag[int(field)]
+
+	return fieldType, ok
+}
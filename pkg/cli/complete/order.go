@@ -1,6 +1,7 @@
 package complete
 
 import (
+	"github.com/quickfixgo/tag"
 	"github.com/spf13/cobra"
 
 	"sylr.dev/fix/pkg/dict"
@@ -8,33 +9,37 @@ import (
 )
 
 func OrderSide(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-	return utils.PrettyOptionValues(dict.OrderSides), cobra.ShellCompDirectiveNoFileComp
+	return dictionaryEnum(cmd, tag.Side, dict.OrderSides), cobra.ShellCompDirectiveNoFileComp
 }
 
 func OrderType(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-	return utils.PrettyOptionValues(dict.OrderTypes), cobra.ShellCompDirectiveNoFileComp
+	return dictionaryEnum(cmd, tag.OrdType, dict.OrderTypes), cobra.ShellCompDirectiveNoFileComp
 }
 
 func OrderTimeInForce(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-	return utils.PrettyOptionValues(dict.OrderTimeInForces), cobra.ShellCompDirectiveNoFileComp
+	return dictionaryEnum(cmd, tag.TimeInForce, dict.OrderTimeInForces), cobra.ShellCompDirectiveNoFileComp
 }
 
 func OrderPartyIDSource(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-	return utils.PrettyOptionValues(dict.PartyIDSources), cobra.ShellCompDirectiveNoFileComp
+	return dictionaryEnum(cmd, tag.PartyIDSource, dict.PartyIDSources), cobra.ShellCompDirectiveNoFileComp
 }
 
 func OrderPartySubIDTypes(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-	return utils.PrettyOptionValues(dict.PartySubIDTypes), cobra.ShellCompDirectiveNoFileComp
+	return dictionaryEnum(cmd, tag.PartySubIDType, dict.PartySubIDTypes), cobra.ShellCompDirectiveNoFileComp
 }
 
 func OrderPartyIDRole(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-	return utils.PrettyOptionValues(dict.PartyRoles), cobra.ShellCompDirectiveNoFileComp
+	return dictionaryEnum(cmd, tag.PartyRole, dict.PartyRoles), cobra.ShellCompDirectiveNoFileComp
 }
 
 func OrderPartyRoleQualifier(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-	return utils.PrettyOptionValues(dict.PartyRoleQualifiers), cobra.ShellCompDirectiveNoFileComp
+	return dictionaryEnum(cmd, tag.PartyRoleQualifier, dict.PartyRoleQualifiers), cobra.ShellCompDirectiveNoFileComp
 }
 
 func OrderOriginationRole(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-	return utils.PrettyOptionValues(dict.OrderOriginations), cobra.ShellCompDirectiveNoFileComp
+	return dictionaryEnum(cmd, tag.OrderOrigination, dict.OrderOriginations), cobra.ShellCompDirectiveNoFileComp
+}
+
+func MassCancelRequestType(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return utils.PrettyOptionValues(dict.MassCancelRequestTypes), cobra.ShellCompDirectiveNoFileComp
 }
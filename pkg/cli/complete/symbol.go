@@ -0,0 +1,120 @@
+package complete
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"sylr.dev/fix/config"
+)
+
+const defaultSymbolCacheTTL = time.Hour
+
+// FetchSymbols requests the symbols known by the venue of the context. It is
+// set by the initiator package, which can not be imported from here.
+var FetchSymbols func(context *config.Context) ([]string, error)
+
+// Symbol completes the symbols of the venue of the current context when the
+// SymbolCompletion of its initiator is enabled. The symbols are cached for
+// SymbolCompletion.CacheTTL (1h by default).
+func Symbol(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	options := config.GetOptions()
+	fixConfig := config.GetConfig()
+
+	if conf, err := config.ReadYAMLFilesNoAge(config.ConfigPaths(cmd.Flags().Changed("config"))); err == nil {
+		*fixConfig = *conf
+	} else {
+		if options.Verbose > 0 {
+			fmt.Fprintf(os.Stdout, "%s\n", err)
+		}
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	context, err := config.GetCurrentContext()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	initiator, err := context.GetInitiator()
+	if err != nil || !initiator.SymbolCompletion.Enabled || FetchSymbols == nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	ttl := initiator.SymbolCompletion.CacheTTL
+	if ttl == 0 {
+		ttl = defaultSymbolCacheTTL
+	}
+
+	symbols, err := cachedSymbols(context, ttl)
+	if err != nil {
+		if options.Verbose > 0 {
+			fmt.Fprintf(os.Stdout, "%s\n", err)
+		}
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	completions := make([]string, 0, len(symbols))
+	for _, symbol := range symbols {
+		if strings.HasPrefix(strings.ToLower(symbol), strings.ToLower(toComplete)) {
+			completions = append(completions, symbol)
+		}
+	}
+
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+func SymbolCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "fix", "symbols"), nil
+}
+
+// cachedSymbols returns the symbols of the context from the cache if they are
+// younger than ttl, otherwise it fetches and caches them. A negative ttl
+// disables the cache.
+func cachedSymbols(context *config.Context, ttl time.Duration) ([]string, error) {
+	dir, err := SymbolCacheDir()
+	if err != nil {
+		return nil, err
+	}
+
+	// The cache is specific to the context and to the sessions it uses.
+	key := sha256.Sum256([]byte(context.Name + "\x00" + context.Initiator + "\x00" + strings.Join(context.Sessions, "\x00")))
+	file := filepath.Join(dir, hex.EncodeToString(key[:])+".json")
+
+	if ttl > 0 {
+		if info, err := os.Stat(file); err == nil && time.Since(info.ModTime()) < ttl {
+			if b, err := os.ReadFile(file); err == nil {
+				var symbols []string
+				if err := json.Unmarshal(b, &symbols); err == nil {
+					return symbols, nil
+				}
+			}
+		}
+	}
+
+	symbols, err := FetchSymbols(context)
+	if err != nil {
+		return nil, err
+	}
+
+	if ttl > 0 {
+		if b, err := json.Marshal(symbols); err == nil {
+			if err := os.MkdirAll(dir, 0o700); err == nil {
+				_ = os.WriteFile(file, b, 0o600)
+			}
+		}
+	}
+
+	return symbols, nil
+}
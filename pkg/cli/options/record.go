@@ -0,0 +1,89 @@
+package options
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/quickfixgo/quickfix/datadictionary"
+	"github.com/spf13/cobra"
+
+	"sylr.dev/fix/pkg/errors"
+	"sylr.dev/fix/pkg/recorder"
+	"sylr.dev/fix/pkg/utils"
+)
+
+type RecordOptions struct {
+	dir         string
+	maxSize     string
+	rotateEvery time.Duration
+	maxBackups  int
+	flushEvery  time.Duration
+	sync        string
+
+	maxSizeBytes int64
+}
+
+func NewRecordOptions(command *cobra.Command) *RecordOptions {
+	opt := &RecordOptions{}
+
+	command.Flags().StringVar(&opt.dir, "record", "", "Record the market data received into rotating files of this directory")
+	command.Flags().StringVar(&opt.maxSize, "record-max-size", "100MB", "Size above which the recorded files are rotated (0 for no limit)")
+	command.Flags().DurationVar(&opt.rotateEvery, "record-rotate-every", time.Hour, "Age above which the recorded files are rotated (0 for never)")
+	command.Flags().IntVar(&opt.maxBackups, "record-max-backups", 0, "Number of rotated recorded files kept (0 for all)")
+	command.Flags().DurationVar(&opt.flushEvery, "record-flush-every", time.Second, "Longest time the recorded messages are buffered (0 for none)")
+	command.Flags().StringVar(&opt.sync, "record-sync", recorder.SyncFlush, "When the recorded files are synced to the disk ("+strings.Join(recorder.SyncPolicies, ", ")+")")
+
+	command.RegisterFlagCompletionFunc("record", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return nil, cobra.ShellCompDirectiveFilterDirs
+	})
+	command.RegisterFlagCompletionFunc("record-max-size", cobra.NoFileCompletions)
+	command.RegisterFlagCompletionFunc("record-rotate-every", cobra.NoFileCompletions)
+	command.RegisterFlagCompletionFunc("record-max-backups", cobra.NoFileCompletions)
+	command.RegisterFlagCompletionFunc("record-flush-every", cobra.NoFileCompletions)
+	command.RegisterFlagCompletionFunc("record-sync", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return recorder.SyncPolicies, cobra.ShellCompDirectiveNoFileComp
+	})
+
+	return opt
+}
+
+func (o *RecordOptions) Validate() error {
+	if len(o.dir) == 0 {
+		return nil
+	}
+
+	size, err := humanize.ParseBytes(o.maxSize)
+	if err != nil {
+		return fmt.Errorf("%w: invalid --record-max-size `%s`: %v", errors.Options, o.maxSize, err)
+	}
+	o.maxSizeBytes = int64(size)
+
+	if o.rotateEvery < 0 || o.flushEvery < 0 || o.maxBackups < 0 {
+		return fmt.Errorf("%w: --record-rotate-every, --record-flush-every and --record-max-backups can not be negative", errors.Options)
+	}
+
+	if utils.Search(recorder.SyncPolicies, strings.ToLower(o.sync)) < 0 {
+		return fmt.Errorf("%w: unknown --record-sync `%s` (%s)", errors.Options, o.sync, strings.Join(recorder.SyncPolicies, ", "))
+	}
+
+	return nil
+}
+
+// Recorder returns the recorder of the options, whose files are named after the
+// prefix, nil when --record is not given.
+func (o *RecordOptions) Recorder(prefix string, transportDict, appDict *datadictionary.DataDictionary) (*recorder.Recorder, error) {
+	if len(o.dir) == 0 {
+		return nil, nil
+	}
+
+	return recorder.New(o.dir, recorder.Options{
+		Prefix:      prefix,
+		MaxSize:     o.maxSizeBytes,
+		RotateEvery: o.rotateEvery,
+		MaxBackups:  o.maxBackups,
+		FlushEvery:  o.flushEvery,
+		Sync:        strings.ToLower(o.sync),
+	}, transportDict, appDict)
+}
@@ -0,0 +1,307 @@
+package conformance
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/quickfixgo/enum"
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/tag"
+
+	"sylr.dev/fix/config"
+	"sylr.dev/fix/config/credentials"
+	"sylr.dev/fix/pkg/auth"
+	"sylr.dev/fix/pkg/initiator"
+	"sylr.dev/fix/pkg/report"
+)
+
+// Tags missing from the tag package.
+const (
+	tagDefaultApplVerID = 1137
+)
+
+// errDisconnected is returned when the counterparty closed the connection.
+var errDisconnected = fmt.Errorf("disconnected by the counterparty")
+
+// transcript records the messages exchanged on all the connections of a test.
+type transcript struct {
+	mux       sync.Mutex
+	exchanges []report.Exchange
+}
+
+func (t *transcript) add(conn int, sent bool, message *quickfix.Message) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	t.exchanges = append(t.exchanges, report.NewExchange(message, sent, conn))
+}
+
+// client is a bare FIX connection which, unlike quickfix sessions, lets the
+// tests send messages with arbitrary sequence numbers.
+type client struct {
+	id         int
+	conn       net.Conn
+	session    *config.Session
+	transcript *transcript
+	// authenticators authenticate the Logon messages sent
+	authenticators []auth.Authenticator
+
+	// seqNum is the sequence number of the next message sent.
+	seqNum   int
+	received chan *quickfix.Message
+}
+
+func dial(id int, initiatorConfig *config.Initiator, session *config.Session, t *transcript, timeout time.Duration) (*client, error) {
+	authenticators, err := auth.New(session.Auth)
+	if err != nil {
+		return nil, err
+	}
+
+	initiatorConfig = session.TLS.ApplyTo(initiatorConfig)
+	address := net.JoinHostPort(initiatorConfig.SocketConnectHost, strconv.Itoa(initiatorConfig.SocketConnectPort))
+	dialer := &net.Dialer{Timeout: timeout}
+
+	var conn net.Conn
+	if initiatorConfig.SocketUseSSL {
+		var tlsConfig *tls.Config
+		if tlsConfig, err = initiator.TLSConfig(initiatorConfig); err != nil {
+			return nil, err
+		}
+		conn, err = tls.DialWithDialer(dialer, "tcp", address, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", address)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c := &client{
+		id:         id,
+		conn:       conn,
+		session:    session,
+		transcript: t,
+		seqNum:     1,
+		received:   make(chan *quickfix.Message, 64),
+
+		authenticators: authenticators,
+	}
+
+	go c.read()
+
+	return c, nil
+}
+
+// read parses the messages received until the connection is closed.
+func (c *client) read() {
+	defer close(c.received)
+
+	reader := bufio.NewReader(c.conn)
+	for {
+		raw, err := readRawMessage(reader)
+		if err != nil {
+			return
+		}
+
+		message := quickfix.NewMessage()
+		if err := quickfix.ParseMessage(message, bytes.NewBuffer(raw)); err != nil {
+			continue
+		}
+
+		c.transcript.add(c.id, false, message)
+		c.received <- message
+	}
+}
+
+// readRawMessage reads a message using its BodyLength.
+func readRawMessage(reader *bufio.Reader) ([]byte, error) {
+	// 8=BeginString
+	beginString, err := reader.ReadBytes('\001')
+	if err != nil {
+		return nil, err
+	}
+
+	// 9=BodyLength
+	bodyLength, err := reader.ReadBytes('\001')
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(bodyLength, []byte("9=")) {
+		return nil, fmt.Errorf("unexpected field %q", bodyLength)
+	}
+	length, err := strconv.Atoi(string(bodyLength[2 : len(bodyLength)-1]))
+	if err != nil {
+		return nil, err
+	}
+
+	// Body and 10=CheckSum
+	rest := make([]byte, length+7)
+	if _, err := io.ReadFull(reader, rest); err != nil {
+		return nil, err
+	}
+
+	raw := make([]byte, 0, len(beginString)+len(bodyLength)+len(rest))
+	raw = append(raw, beginString...)
+	raw = append(raw, bodyLength...)
+
+	return append(raw, rest...), nil
+}
+
+func (c *client) close() {
+	c.conn.Close()
+}
+
+// newMessage returns a message of the given type with the session header.
+func (c *client) newMessage(msgType enum.MsgType) *quickfix.Message {
+	message := quickfix.NewMessage()
+	message.Header.SetString(tag.BeginString, c.session.BeginString)
+	message.Header.SetString(tag.MsgType, string(msgType))
+	message.Header.SetString(tag.SenderCompID, c.session.SenderCompID)
+	message.Header.SetString(tag.TargetCompID, c.session.TargetCompID)
+	if len(c.session.SenderSubID) > 0 {
+		message.Header.SetString(tag.SenderSubID, c.session.SenderSubID)
+	}
+	if len(c.session.TargetSubID) > 0 {
+		message.Header.SetString(tag.TargetSubID, c.session.TargetSubID)
+	}
+
+	return message
+}
+
+// send sends the message with the next sequence number.
+func (c *client) send(message *quickfix.Message) error {
+	err := c.sendWithSeqNum(message, c.seqNum)
+	c.seqNum++
+
+	return err
+}
+
+// sendWithSeqNum sends the message with the given sequence number, leaving the
+// next sequence number untouched.
+func (c *client) sendWithSeqNum(message *quickfix.Message, seqNum int) error {
+	message.Header.SetInt(tag.MsgSeqNum, seqNum)
+	message.Header.SetString(tag.SendingTime, time.Now().UTC().Format("20060102-15:04:05.000"))
+
+	if isMsgType(enum.MsgType_LOGON)(message) {
+		if err := auth.Authenticate(message, c.authenticators); err != nil {
+			return err
+		}
+	}
+
+	c.transcript.add(c.id, true, message)
+	_, err := c.conn.Write([]byte(message.String()))
+
+	return err
+}
+
+// logon sends a Logon resetting the sequence numbers of both sides.
+func (c *client) logon() error {
+	heartBtInt := c.session.HeartBtInt
+	if heartBtInt == 0 {
+		heartBtInt = 30
+	}
+
+	logon := c.newMessage(enum.MsgType_LOGON)
+	encryptMethod := string(enum.EncryptMethod_NONE_OTHER)
+	if len(c.session.EncryptMethod) > 0 {
+		encryptMethod = c.session.EncryptMethod
+	}
+	logon.Body.SetString(tag.EncryptMethod, encryptMethod)
+	logon.Body.SetInt(tag.HeartBtInt, heartBtInt)
+	logon.Body.SetBool(tag.ResetSeqNumFlag, true)
+	if len(c.session.DefaultApplVerID) > 0 {
+		logon.Body.SetString(tagDefaultApplVerID, c.session.DefaultApplVerID)
+	}
+	creds, err := credentials.Get(c.session.Username, c.session.Password, c.session.Credentials)
+	if err != nil {
+		return err
+	}
+	if len(creds.Username) > 0 {
+		logon.Body.SetString(tag.Username, creds.Username)
+	}
+	if len(creds.Password) > 0 {
+		logon.Body.SetString(tag.Password, creds.Password)
+	}
+
+	for t, value := range c.session.LogonFields {
+		logon.Body.SetString(quickfix.Tag(t), value)
+	}
+
+	c.seqNum = 1
+
+	return c.send(logon)
+}
+
+// logout sends a Logout and waits for the counterparty to answer or to close
+// the connection.
+func (c *client) logout(timeout time.Duration) {
+	if err := c.send(c.newMessage(enum.MsgType_LOGOUT)); err == nil {
+		_, _ = c.waitFor(timeout, isMsgType(enum.MsgType_LOGOUT))
+	}
+	c.close()
+}
+
+// waitFor returns the first message received matching the predicate, the
+// others being discarded.
+func (c *client) waitFor(timeout time.Duration, match func(*quickfix.Message) bool) (*quickfix.Message, error) {
+	deadline := time.After(timeout)
+
+	for {
+		select {
+		case <-deadline:
+			return nil, fmt.Errorf("no response within %s", timeout)
+		case message, ok := <-c.received:
+			if !ok {
+				return nil, errDisconnected
+			}
+			if match(message) {
+				return message, nil
+			}
+		}
+	}
+}
+
+// waitForDisconnection waits for the counterparty to close the connection and
+// returns the messages it sent in the meantime.
+func (c *client) waitForDisconnection(timeout time.Duration) ([]*quickfix.Message, error) {
+	deadline := time.After(timeout)
+	var messages []*quickfix.Message
+
+	for {
+		select {
+		case <-deadline:
+			return messages, fmt.Errorf("connection still open after %s", timeout)
+		case message, ok := <-c.received:
+			if !ok {
+				return messages, nil
+			}
+			messages = append(messages, message)
+		}
+	}
+}
+
+func isMsgType(msgTypes ...enum.MsgType) func(*quickfix.Message) bool {
+	return func(message *quickfix.Message) bool {
+		msgType, err := message.MsgType()
+		if err != nil {
+			return false
+		}
+		for _, t := range msgTypes {
+			if msgType == string(t) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func msgTypeOf(message *quickfix.Message) enum.MsgType {
+	msgType, _ := message.MsgType()
+	return enum.MsgType(msgType)
+}
@@ -0,0 +1,193 @@
+// Package conformance runs session level tests against a FIX acceptor.
+//
+// The tests do not go through quickfix but through bare connections which
+// allow to send messages with arbitrary sequence numbers. Every test starts
+// with a Logon resetting the sequence numbers of both sides (ResetSeqNumFlag),
+// which the counterparty must therefore accept.
+package conformance
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/quickfixgo/enum"
+	"github.com/quickfixgo/tag"
+
+	"sylr.dev/fix/config"
+	"sylr.dev/fix/pkg/report"
+)
+
+// Statuses of the tests.
+const (
+	StatusPass = report.StatusPass
+	StatusFail = report.StatusFail
+)
+
+// Test is a session level test.
+type Test struct {
+	Name        string
+	Description string
+	run         func(t *T) error
+}
+
+// T is the environment of a running test.
+type T struct {
+	initiator  *config.Initiator
+	session    *config.Session
+	timeout    time.Duration
+	transcript *transcript
+	conns      []*client
+}
+
+// dial opens a new connection to the counterparty, closed at the end of the
+// test.
+func (t *T) dial() (*client, error) {
+	c, err := dial(len(t.conns)+1, t.initiator, t.session, t.transcript, t.timeout)
+	if err != nil {
+		return nil, err
+	}
+	t.conns = append(t.conns, c)
+
+	return c, nil
+}
+
+// logon opens a new connection and logs on.
+func (t *T) logon() (*client, error) {
+	c, err := t.dial()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.logon(); err != nil {
+		return nil, err
+	}
+
+	if _, err := c.waitFor(t.timeout, isMsgType(logonMsgType)); err != nil {
+		return nil, logonError(err)
+	}
+
+	return c, nil
+}
+
+// Result is the outcome of a test.
+type Result struct {
+	Test        string            `json:"test"`
+	Description string            `json:"description"`
+	Status      string            `json:"status"`
+	Started     time.Time         `json:"started"`
+	Duration    time.Duration     `json:"duration"`
+	Details     string            `json:"details,omitempty"`
+	Exchanges   []report.Exchange `json:"exchanges"`
+}
+
+// Run runs the test against the acceptor of the initiator configuration.
+func (test Test) Run(initiatorConfig *config.Initiator, session *config.Session, timeout time.Duration) *Result {
+	t := &T{
+		initiator:  initiatorConfig,
+		session:    session,
+		timeout:    timeout,
+		transcript: &transcript{},
+	}
+
+	result := &Result{Test: test.Name, Description: test.Description, Started: time.Now()}
+
+	err := test.run(t)
+
+	// Log out the connections still open
+	for _, c := range t.conns {
+		c.logout(timeout)
+	}
+
+	result.Duration = time.Since(result.Started)
+	result.Exchanges = t.transcript.exchanges
+
+	if err != nil {
+		result.Status = StatusFail
+		result.Details = err.Error()
+	} else {
+		result.Status = StatusPass
+	}
+
+	return result
+}
+
+// Resynchronize logs on resetting the sequence numbers and logs out cleanly,
+// leaving the counterparty in a known state after the tests. It returns the
+// next sender and target sequence numbers of the session.
+func Resynchronize(initiatorConfig *config.Initiator, session *config.Session, timeout time.Duration) (int, int, error) {
+	t := &T{
+		initiator:  initiatorConfig,
+		session:    session,
+		timeout:    timeout,
+		transcript: &transcript{},
+	}
+
+	c, err := t.logon()
+	if err != nil {
+		return 0, 0, err
+	}
+	defer c.close()
+
+	if err := c.send(c.newMessage(enum.MsgType_LOGOUT)); err != nil {
+		return 0, 0, err
+	}
+
+	logout, err := c.waitFor(timeout, isMsgType(enum.MsgType_LOGOUT))
+	if err != nil {
+		return 0, 0, fmt.Errorf("logout: %w", err)
+	}
+
+	seqNum, err := logout.Header.GetInt(tag.MsgSeqNum)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return c.seqNum, seqNum + 1, nil
+}
+
+// Tests returns the tests of the battery, optionally restricted to the given
+// names.
+func Tests(names ...string) ([]Test, bool) {
+	if len(names) == 0 {
+		return tests, true
+	}
+
+	var selected []Test
+	for _, name := range names {
+		found := false
+		for _, test := range tests {
+			if test.Name == name {
+				selected = append(selected, test)
+				found = true
+			}
+		}
+		if !found {
+			return nil, false
+		}
+	}
+
+	return selected, true
+}
+
+// TestNames returns the names of all the tests.
+func TestNames() []string {
+	names := make([]string, len(tests))
+	for i, test := range tests {
+		names[i] = test.Name
+	}
+
+	return names
+}
+
+// Section returns the result as a section of a report.
+func (r *Result) Section() report.Section {
+	return report.Section{
+		Name:        r.Test,
+		Description: r.Description,
+		Status:      r.Status,
+		Details:     r.Details,
+		Started:     r.Started,
+		Duration:    r.Duration,
+		Exchanges:   r.Exchanges,
+	}
+}
@@ -0,0 +1,317 @@
+package conformance
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/quickfixgo/enum"
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/tag"
+)
+
+const logonMsgType = enum.MsgType_LOGON
+
+var tests = []Test{
+	{
+		Name:        "logon",
+		Description: "A Logon resetting the sequence numbers is answered with a Logon which resets them too",
+		run:         testLogon,
+	},
+	{
+		Name:        "test-request",
+		Description: "A TestRequest is answered with a Heartbeat echoing its TestReqID",
+		run:         testTestRequest,
+	},
+	{
+		Name:        "sequence-gap",
+		Description: "A sequence number higher than expected triggers a ResendRequest of the missing messages",
+		run:         testSequenceGap,
+	},
+	{
+		Name:        "resend-request",
+		Description: "A ResendRequest is answered with a SequenceReset-GapFill or with the messages resent as possible duplicates",
+		run:         testResendRequest,
+	},
+	{
+		Name:        "duplicate",
+		Description: "A possible duplicate of an already received message is ignored",
+		run:         testDuplicate,
+	},
+	{
+		Name:        "sequence-too-low",
+		Description: "A sequence number lower than expected without PossDupFlag ends the session",
+		run:         testSequenceTooLow,
+	},
+	{
+		Name:        "logon-first",
+		Description: "A connection whose first message is not a Logon is closed",
+		run:         testLogonFirst,
+	},
+	{
+		Name:        "logon-race",
+		Description: "A Logon on a second connection while the session is logged on is refused and the session kept",
+		run:         testLogonRace,
+	},
+	{
+		Name:        "logout",
+		Description: "A Logout is acknowledged with a Logout",
+		run:         testLogout,
+	},
+}
+
+func logonError(err error) error {
+	return fmt.Errorf("logon: %w", err)
+}
+
+// describe returns the message type and the text of a message.
+func describe(message *quickfix.Message) string {
+	s := string(msgTypeOf(message))
+	if text, err := message.Body.GetString(tag.Text); err == nil && len(text) > 0 {
+		s += fmt.Sprintf(" (%s)", text)
+	}
+
+	return s
+}
+
+// testRequest sends a TestRequest and waits for the matching Heartbeat, any
+// Logout or Reject received in the meantime being an error.
+func testRequest(c *client, timeout time.Duration) error {
+	id := uuid.NewString()
+
+	request := c.newMessage(enum.MsgType_TEST_REQUEST)
+	request.Body.SetString(tag.TestReqID, id)
+	if err := c.send(request); err != nil {
+		return err
+	}
+
+	message, err := c.waitFor(timeout, func(message *quickfix.Message) bool {
+		switch msgTypeOf(message) {
+		case enum.MsgType_HEARTBEAT:
+			reqID, _ := message.Body.GetString(tag.TestReqID)
+			return reqID == id
+		case enum.MsgType_LOGOUT, enum.MsgType_REJECT:
+			return true
+		}
+		return false
+	})
+	if err != nil {
+		return fmt.Errorf("test request: %w", err)
+	}
+	if msgTypeOf(message) != enum.MsgType_HEARTBEAT {
+		return fmt.Errorf("test request: received %s", describe(message))
+	}
+
+	return nil
+}
+
+func testLogon(t *T) error {
+	c, err := t.dial()
+	if err != nil {
+		return err
+	}
+
+	if err := c.logon(); err != nil {
+		return err
+	}
+
+	message, err := c.waitFor(t.timeout, isMsgType(enum.MsgType_LOGON, enum.MsgType_LOGOUT))
+	if err != nil {
+		return logonError(err)
+	}
+	if msgTypeOf(message) == enum.MsgType_LOGOUT {
+		return logonError(fmt.Errorf("received %s", describe(message)))
+	}
+
+	if reset, err := message.Body.GetBool(tag.ResetSeqNumFlag); err != nil || !reset {
+		return fmt.Errorf("ResetSeqNumFlag not set in the Logon response")
+	}
+	if seqNum, _ := message.Header.GetInt(tag.MsgSeqNum); seqNum != 1 {
+		return fmt.Errorf("Logon response has sequence number %d instead of 1", seqNum)
+	}
+
+	return nil
+}
+
+func testTestRequest(t *T) error {
+	c, err := t.logon()
+	if err != nil {
+		return err
+	}
+
+	return testRequest(c, t.timeout)
+}
+
+func testSequenceGap(t *T) error {
+	c, err := t.logon()
+	if err != nil {
+		return err
+	}
+
+	// Skip 3 sequence numbers
+	expected := c.seqNum
+	c.seqNum += 3
+	if err := c.send(c.newMessage(enum.MsgType_HEARTBEAT)); err != nil {
+		return err
+	}
+
+	message, err := c.waitFor(t.timeout, isMsgType(enum.MsgType_RESEND_REQUEST, enum.MsgType_LOGOUT))
+	if err != nil {
+		return fmt.Errorf("resend request: %w", err)
+	}
+	if msgTypeOf(message) == enum.MsgType_LOGOUT {
+		return fmt.Errorf("received %s instead of a ResendRequest", describe(message))
+	}
+
+	if begin, _ := message.Body.GetInt(tag.BeginSeqNo); begin != expected {
+		return fmt.Errorf("ResendRequest starts at %d instead of %d", begin, expected)
+	}
+
+	return nil
+}
+
+func testResendRequest(t *T) error {
+	c, err := t.logon()
+	if err != nil {
+		return err
+	}
+
+	// Have the counterparty send one more message
+	if err := testRequest(c, t.timeout); err != nil {
+		return err
+	}
+
+	request := c.newMessage(enum.MsgType_RESEND_REQUEST)
+	request.Body.SetInt(tag.BeginSeqNo, 1)
+	request.Body.SetInt(tag.EndSeqNo, 0)
+	if err := c.send(request); err != nil {
+		return err
+	}
+
+	message, err := c.waitFor(t.timeout, func(message *quickfix.Message) bool {
+		if msgTypeOf(message) == enum.MsgType_LOGOUT {
+			return true
+		}
+		if msgTypeOf(message) == enum.MsgType_SEQUENCE_RESET {
+			gapFill, _ := message.Body.GetBool(tag.GapFillFlag)
+			return gapFill
+		}
+		possDup, _ := message.Header.GetBool(tag.PossDupFlag)
+		return possDup
+	})
+	if err != nil {
+		return fmt.Errorf("resend: %w", err)
+	}
+	if msgTypeOf(message) == enum.MsgType_LOGOUT {
+		return fmt.Errorf("received %s instead of the resent messages", describe(message))
+	}
+
+	return nil
+}
+
+func testDuplicate(t *T) error {
+	c, err := t.logon()
+	if err != nil {
+		return err
+	}
+
+	heartbeat := c.newMessage(enum.MsgType_HEARTBEAT)
+	if err := c.send(heartbeat); err != nil {
+		return err
+	}
+	sendingTime, _ := heartbeat.Header.GetString(tag.SendingTime)
+
+	duplicate := c.newMessage(enum.MsgType_HEARTBEAT)
+	duplicate.Header.SetBool(tag.PossDupFlag, true)
+	duplicate.Header.SetString(tag.OrigSendingTime, sendingTime)
+	if err := c.sendWithSeqNum(duplicate, c.seqNum-1); err != nil {
+		return err
+	}
+
+	// The session must still be up
+	return testRequest(c, t.timeout)
+}
+
+func testSequenceTooLow(t *T) error {
+	c, err := t.logon()
+	if err != nil {
+		return err
+	}
+
+	if err := c.send(c.newMessage(enum.MsgType_HEARTBEAT)); err != nil {
+		return err
+	}
+	if err := c.sendWithSeqNum(c.newMessage(enum.MsgType_HEARTBEAT), c.seqNum-1); err != nil {
+		return err
+	}
+
+	_, err = c.waitFor(t.timeout, isMsgType(enum.MsgType_LOGOUT))
+	if err != nil && !errors.Is(err, errDisconnected) {
+		return fmt.Errorf("session not ended: %w", err)
+	}
+
+	return nil
+}
+
+func testLogonFirst(t *T) error {
+	c, err := t.dial()
+	if err != nil {
+		return err
+	}
+
+	if err := c.send(c.newMessage(enum.MsgType_HEARTBEAT)); err != nil {
+		return err
+	}
+
+	messages, err := c.waitForDisconnection(t.timeout)
+	for _, message := range messages {
+		if msgTypeOf(message) != enum.MsgType_LOGOUT {
+			return fmt.Errorf("received %s", describe(message))
+		}
+	}
+
+	return err
+}
+
+func testLogonRace(t *T) error {
+	first, err := t.logon()
+	if err != nil {
+		return err
+	}
+
+	second, err := t.dial()
+	if err != nil {
+		return err
+	}
+	if err := second.logon(); err != nil {
+		return err
+	}
+
+	if message, err := second.waitFor(t.timeout, isMsgType(enum.MsgType_LOGON)); err == nil {
+		return fmt.Errorf("second logon accepted with %s", describe(message))
+	}
+
+	if err := testRequest(first, t.timeout); err != nil {
+		return fmt.Errorf("first connection: %w", err)
+	}
+
+	return nil
+}
+
+func testLogout(t *T) error {
+	c, err := t.logon()
+	if err != nil {
+		return err
+	}
+
+	if err := c.send(c.newMessage(enum.MsgType_LOGOUT)); err != nil {
+		return err
+	}
+
+	if _, err := c.waitFor(t.timeout, isMsgType(enum.MsgType_LOGOUT)); err != nil {
+		return fmt.Errorf("logout: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,260 @@
+// Package daemon runs several long running workloads in one process.
+//
+// The workloads are described by a YAML manifest:
+//
+//	workloads:
+//	  - name: feed-a-validator
+//	    kind: validator
+//	    context: feed-a
+//	    symbols: [EURUSD, GBPUSD]
+//	  - name: feed-b-recorder
+//	    kind: recorder
+//	    context: feed-b
+//	    symbols: [EURUSD]
+//	    file: /var/lib/fix/feed-b.log
+//	    rotateEvery: 24h
+//	  - name: metrics
+//	    kind: metrics
+//	    listen: :9090
+//
+// Each workload runs in its own goroutine and is restarted according to its
+// restart policy when it ends. The workloads share the logger, every line
+// being labelled with the name of the workload, and the prometheus registry.
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+
+	"sylr.dev/fix/pkg/errors"
+
+	yaml "sylr.dev/yaml/v3"
+)
+
+// Restart policies of the workloads.
+const (
+	RestartAlways    = "always"
+	RestartOnFailure = "on-failure"
+	RestartNever     = "never"
+)
+
+// RestartPolicies lists the restart policies.
+var RestartPolicies = []string{RestartAlways, RestartOnFailure, RestartNever}
+
+// DefaultRestartDelay is the time waited before restarting a workload which
+// does not set one.
+const DefaultRestartDelay = 5 * time.Second
+
+var (
+	metricWorkloadUp = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "fix",
+			Subsystem: "daemon",
+			Name:      "workload_up",
+			Help:      "Whether the workload is running",
+		},
+		[]string{"workload", "kind"},
+	)
+	metricWorkloadRestarts = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "fix",
+			Subsystem: "daemon",
+			Name:      "workload_restarts_total",
+			Help:      "Number of times the workload has been restarted",
+		},
+		[]string{"workload", "kind"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(metricWorkloadUp, metricWorkloadRestarts)
+}
+
+// RunFunc runs a workload until it ends or the context is canceled.
+type RunFunc func(ctx context.Context, w *Workload, logger *zerolog.Logger) error
+
+var kinds = map[string]RunFunc{}
+
+// Register makes a kind of workload available to the manifests.
+func Register(kind string, run RunFunc) {
+	kinds[kind] = run
+}
+
+// Kinds returns the kinds of workloads available.
+func Kinds() []string {
+	names := make([]string, 0, len(kinds))
+	for kind := range kinds {
+		names = append(names, kind)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Manifest lists the workloads of the daemon.
+type Manifest struct {
+	Workloads []*Workload `yaml:"workloads"`
+}
+
+// Workload is one of the applications run by the daemon. The settings used
+// depend on its kind.
+type Workload struct {
+	Name         string        `yaml:"name"`
+	Kind         string        `yaml:"kind"`
+	Context      string        `yaml:"context"`
+	Restart      string        `yaml:"restart"`
+	RestartDelay time.Duration `yaml:"restartDelay"`
+
+	// Market data workloads
+	Symbols      []string `yaml:"symbols"`
+	TradeHistory bool     `yaml:"tradeHistory"`
+
+	// Recorder
+	File        string        `yaml:"file"`
+	RotateEvery time.Duration `yaml:"rotateEvery"`
+	MaxSize     int64         `yaml:"maxSize"`
+	MaxBackups  int           `yaml:"maxBackups"`
+
+	// Metrics server
+	Listen string `yaml:"listen"`
+}
+
+// Load reads the manifest of a YAML file.
+func Load(path string) (*Manifest, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manifest{}
+	if err := yaml.Unmarshal(b, m); err != nil {
+		return nil, fmt.Errorf("%w: %s: %s", errors.Options, path, err)
+	}
+
+	if len(m.Workloads) == 0 {
+		return nil, fmt.Errorf("%w: %s: no workload", errors.Options, path)
+	}
+
+	names := make(map[string]bool)
+	for i, w := range m.Workloads {
+		if len(w.Name) == 0 {
+			w.Name = fmt.Sprintf("%s-%d", w.Kind, i+1)
+		}
+		if names[w.Name] {
+			return nil, fmt.Errorf("%w: %s: duplicate workload name %s", errors.Options, path, w.Name)
+		}
+		names[w.Name] = true
+
+		if _, ok := kinds[w.Kind]; !ok {
+			return nil, fmt.Errorf("%w: %s: workload %s: unknown kind `%s`, expected one of %s", errors.Options, path, w.Name, w.Kind, strings.Join(Kinds(), ", "))
+		}
+
+		switch w.Restart {
+		case "":
+			w.Restart = RestartOnFailure
+		case RestartAlways, RestartOnFailure, RestartNever:
+		default:
+			return nil, fmt.Errorf("%w: %s: workload %s: unknown restart policy `%s`, expected one of %s", errors.Options, path, w.Name, w.Restart, strings.Join(RestartPolicies, ", "))
+		}
+
+		if w.RestartDelay <= 0 {
+			w.RestartDelay = DefaultRestartDelay
+		}
+	}
+
+	return m, nil
+}
+
+// restarts tells whether the workload must be restarted after it ended with
+// err.
+func (w *Workload) restarts(err error) bool {
+	switch w.Restart {
+	case RestartAlways:
+		return true
+	case RestartOnFailure:
+		return err != nil
+	default:
+		return false
+	}
+}
+
+// Run runs the workloads of the manifest until they all end for good or the
+// context is canceled. It returns an error if some of them failed.
+func Run(ctx context.Context, m *Manifest, logger *zerolog.Logger) error {
+	var wg sync.WaitGroup
+	var mux sync.Mutex
+	var failed []string
+
+	for _, w := range m.Workloads {
+		wg.Add(1)
+		go func(w *Workload) {
+			defer wg.Done()
+
+			if err := supervise(ctx, w, logger); err != nil {
+				mux.Lock()
+				failed = append(failed, w.Name)
+				mux.Unlock()
+			}
+		}(w)
+	}
+
+	wg.Wait()
+
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		return fmt.Errorf("%d workload(s) failed: %s", len(failed), strings.Join(failed, ", "))
+	}
+
+	return nil
+}
+
+// supervise runs the workload and restarts it according to its policy. It
+// returns the error of the last run.
+func supervise(ctx context.Context, w *Workload, logger *zerolog.Logger) error {
+	l := logger.With().Str("workload", w.Name).Logger()
+	run := kinds[w.Kind]
+	up := metricWorkloadUp.WithLabelValues(w.Name, w.Kind)
+	restarts := metricWorkloadRestarts.WithLabelValues(w.Name, w.Kind)
+
+	for {
+		l.Info().Msgf("Starting %s workload", w.Kind)
+
+		up.Set(1)
+		err := run(ctx, w, &l)
+		up.Set(0)
+
+		if ctx.Err() != nil {
+			l.Info().Msg("Workload stopped")
+			return nil
+		}
+
+		if err != nil {
+			l.Error().Err(err).Msg("Workload failed")
+		} else {
+			l.Info().Msg("Workload ended")
+		}
+
+		if !w.restarts(err) {
+			return err
+		}
+
+		l.Info().Msgf("Restarting workload in %s", w.RestartDelay)
+
+		select {
+		case <-ctx.Done():
+			l.Info().Msg("Workload stopped")
+			return nil
+		case <-time.After(w.RestartDelay):
+		}
+
+		restarts.Inc()
+	}
+}
@@ -0,0 +1,50 @@
+package daemon
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/rs/zerolog"
+
+	"sylr.dev/fix/pkg/health"
+)
+
+// DefaultMetricsListen is the address the metrics workloads listen on when
+// they do not set one.
+const DefaultMetricsListen = ":8080"
+
+func init() {
+	Register("metrics", runMetrics)
+}
+
+// runMetrics serves the metrics of all the workloads over HTTP.
+func runMetrics(ctx context.Context, w *Workload, logger *zerolog.Logger) error {
+	listen := w.Listen
+	if len(listen) == 0 {
+		listen = DefaultMetricsListen
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	health.Register(mux)
+
+	server := &http.Server{Addr: listen, Handler: mux}
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	logger.Info().Msgf("Serving metrics on %s", listen)
+
+	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+
+	return nil
+}
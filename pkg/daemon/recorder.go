@@ -0,0 +1,158 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/quickfixgo/enum"
+	"github.com/quickfixgo/field"
+	"github.com/quickfixgo/fixt11"
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/tag"
+	"github.com/rs/zerolog"
+
+	"sylr.dev/fix/pkg/errors"
+	"sylr.dev/fix/pkg/initiator"
+	"sylr.dev/fix/pkg/initiator/application"
+	"sylr.dev/fix/pkg/utils"
+)
+
+var errMarketDataRejected = fmt.Errorf("%w: market data request rejected", errors.Fix)
+
+func init() {
+	Register("recorder", runRecorder)
+}
+
+// runRecorder subscribes to the market data of the symbols and appends the raw
+// messages received to the file, one per line.
+func runRecorder(ctx context.Context, w *Workload, logger *zerolog.Logger) error {
+	if len(w.File) == 0 {
+		return fmt.Errorf("%w: no file given", errors.Options)
+	}
+	if len(w.Symbols) == 0 {
+		return errors.OptionsNoSymbolGiven
+	}
+
+	s, err := newSession(w)
+	if err != nil {
+		return err
+	}
+
+	app := application.NewInitiator().Configure(application.Options{
+		Logger:                  logger,
+		Settings:                s.settings,
+		TransportDataDictionary: s.transportDict,
+		AppDataDictionary:       s.appDict,
+	})
+
+	init, sessionId, err := s.connect(ctx, app, app.Connected, logger)
+	if err != nil {
+		app.Stop()
+		return err
+	}
+
+	defer func() {
+		app.Stop()
+		initiator.Stop(init, s.settings)
+	}()
+
+	file := &utils.RotatingFile{
+		Path:        w.File,
+		MaxSize:     w.MaxSize,
+		RotateEvery: w.RotateEvery,
+		MaxBackups:  w.MaxBackups,
+	}
+	defer file.Close()
+
+	if err := quickfix.SendToTarget(marketDataRequest(w, s), sessionId); err != nil {
+		return err
+	}
+
+	logger.Info().Msgf("Recording market data of %d symbol(s) into %s", len(w.Symbols), w.File)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case _, ok := <-app.ToAppMessages:
+			if !ok {
+				return errors.FixLogout
+			}
+
+		case message, ok := <-app.FromAppMessages:
+			if !ok {
+				return errors.FixLogout
+			}
+
+			if rejected(message) {
+				if err := utils.QuickFixRejectError(message, errMarketDataRejected); err != nil {
+					return err
+				}
+				text, _ := message.Body.GetString(tag.Text)
+				return fmt.Errorf("%w: %s", errMarketDataRejected, text)
+			}
+
+			if _, err := file.Write([]byte(message.String() + "\n")); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// rejected tells whether the message rejects the market data request.
+func rejected(message *quickfix.Message) bool {
+	msgType, _ := message.MsgType()
+	switch enum.MsgType(msgType) {
+	case enum.MsgType_MARKET_DATA_REQUEST_REJECT:
+		return true
+	case enum.MsgType_BUSINESS_MESSAGE_REJECT:
+		refMsgType, _ := message.Body.GetString(tag.RefMsgType)
+		return enum.MsgType(refMsgType) == enum.MsgType_MARKET_DATA_REQUEST
+	}
+
+	return false
+}
+
+// marketDataRequest builds the subscription to the bids, offers and trades of
+// the symbols of the workload.
+func marketDataRequest(w *Workload, s *session) *quickfix.Message {
+	message := quickfix.NewMessage()
+	header := fixt11.NewHeader(&message.Header)
+
+	header.Set(field.NewMsgType(enum.MsgType_MARKET_DATA_REQUEST))
+	message.Body.Set(field.NewMDReqID(uuid.NewString()))
+	message.Body.Set(field.NewSubscriptionRequestType(enum.SubscriptionRequestType_SNAPSHOT_PLUS_UPDATES))
+	message.Body.Set(field.NewMarketDepth(0))
+	message.Body.Set(field.NewMDUpdateType(enum.MDUpdateType_INCREMENTAL_REFRESH))
+
+	entryTypes := quickfix.NewRepeatingGroup(
+		tag.NoMDEntryTypes,
+		quickfix.GroupTemplate{
+			quickfix.GroupElement(tag.MDEntryType),
+		},
+	)
+	for _, t := range []enum.MDEntryType{enum.MDEntryType_BID, enum.MDEntryType_OFFER, enum.MDEntryType_TRADE} {
+		entryTypes.Add().Set(field.NewMDEntryType(t))
+	}
+	message.Body.SetGroup(entryTypes)
+
+	relatedSym := quickfix.NewRepeatingGroup(
+		tag.NoRelatedSym,
+		quickfix.GroupTemplate{
+			quickfix.GroupElement(tag.Symbol),
+		},
+	)
+	for _, symbol := range w.Symbols {
+		relatedSym.Add().Set(field.NewSymbol(symbol))
+	}
+	message.Body.SetGroup(relatedSym)
+
+	utils.QuickFixMessagePartSetString(&message.Header, s.config.TargetCompID, field.NewTargetCompID)
+	utils.QuickFixMessagePartSetString(&message.Header, s.config.TargetSubID, field.NewTargetSubID)
+	utils.QuickFixMessagePartSetString(&message.Header, s.config.SenderCompID, field.NewSenderCompID)
+	utils.QuickFixMessagePartSetString(&message.Header, s.config.SenderSubID, field.NewSenderSubID)
+
+	return message
+}
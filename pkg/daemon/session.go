@@ -0,0 +1,83 @@
+package daemon
+
+import (
+	"context"
+	"time"
+
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/quickfix/datadictionary"
+	"github.com/rs/zerolog"
+
+	"sylr.dev/fix/config"
+	"sylr.dev/fix/pkg/initiator"
+)
+
+// session is the initiator session of a workload, the one of its context or of
+// the current context if it has none.
+type session struct {
+	config        *config.Session
+	initiator     *config.Initiator
+	settings      *quickfix.Settings
+	timeout       time.Duration
+	transportDict *datadictionary.DataDictionary
+	appDict       *datadictionary.DataDictionary
+}
+
+func newSession(w *Workload) (*session, error) {
+	options := config.GetOptions()
+
+	var context *config.Context
+	var err error
+	if len(w.Context) > 0 {
+		context, err = config.GetContext(w.Context)
+	} else {
+		context, err = config.GetCurrentContext()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := context.GetSessions()
+	if err != nil {
+		return nil, err
+	}
+
+	s := &session{config: sessions[0]}
+
+	if s.initiator, err = context.GetInitiator(); err != nil {
+		return nil, err
+	}
+	if s.transportDict, s.appDict, err = s.config.GetFIXDictionaries(); err != nil {
+		return nil, err
+	}
+	if s.settings, err = context.ToQuickFixInitiatorSettings(); err != nil {
+		return nil, err
+	}
+
+	// Choose right timeout cli option > config > default value (5s)
+	if options.Timeout != time.Duration(0) {
+		s.timeout = options.Timeout
+	} else if s.initiator.SocketTimeout != time.Duration(0) {
+		s.timeout = s.initiator.SocketTimeout
+	} else {
+		s.timeout = 5 * time.Second
+	}
+
+	return s, nil
+}
+
+// connect starts an initiator for the application and waits for the session
+// to be logged on.
+func (s *session) connect(ctx context.Context, app quickfix.Application, connected chan quickfix.SessionID, logger *zerolog.Logger) (*quickfix.Initiator, quickfix.SessionID, error) {
+	return initiator.Connect(ctx, app, s.settings, s.quickfixLogger(logger), connected, s.timeout, s.initiator.Reconnect)
+}
+
+// quickfixLogger returns the logger of the quickfix events, nil unless they
+// are logged.
+func (s *session) quickfixLogger(logger *zerolog.Logger) *zerolog.Logger {
+	if !config.GetOptions().QuickFixLogging {
+		return nil
+	}
+
+	return logger
+}
@@ -0,0 +1,72 @@
+//go:build validator
+// +build validator
+
+package daemon
+
+import (
+	"context"
+
+	"github.com/rs/zerolog"
+
+	"sylr.dev/fix/pkg/errors"
+	"sylr.dev/fix/pkg/initiator"
+	"sylr.dev/fix/pkg/initiator/application"
+)
+
+func init() {
+	Register("validator", runValidator)
+}
+
+// runValidator validates the market data of the symbols, all the symbols of
+// the venue if none is given.
+func runValidator(ctx context.Context, w *Workload, logger *zerolog.Logger) error {
+	s, err := newSession(w)
+	if err != nil {
+		return err
+	}
+
+	options := application.MarketDataValidatorOptions{
+		Symbols:      w.Symbols,
+		TradeHistory: w.TradeHistory,
+	}
+
+	app := application.NewMarketDataValidator(ctx, logger, options, s.timeout).Configure(application.Options{
+		Logger:                  logger,
+		Settings:                s.settings,
+		TransportDataDictionary: s.transportDict,
+		AppDataDictionary:       s.appDict,
+	})
+
+	// quickfix logs the session on again by itself, the validator subscribing
+	// after each logon
+	init, err := initiator.Initiate(app, s.settings, s.quickfixLogger(logger))
+	if err != nil {
+		return err
+	}
+
+	if err := init.Start(); err != nil {
+		return err
+	}
+
+	defer func() {
+		app.Stop()
+		initiator.Stop(init, s.settings)
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case err := <-app.Errors:
+			return err
+
+		case msg, ok := <-app.AppInfoChan:
+			if !ok {
+				return errors.FixLogout
+			}
+
+			logger.Info().Str("msg", msg).Msgf("Marketdata validator event")
+		}
+	}
+}
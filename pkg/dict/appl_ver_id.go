@@ -0,0 +1,32 @@
+package dict
+
+import "github.com/quickfixgo/enum"
+
+// ApplVerIDs are the ApplVerIDs of the BeginStrings of the application
+// versions, DefaultApplVerID settings holding either.
+var ApplVerIDs = map[string]enum.ApplVerID{
+	"FIX.2.7":    enum.ApplVerID_FIX27,
+	"FIX.3.0":    enum.ApplVerID_FIX30,
+	"FIX.4.0":    enum.ApplVerID_FIX40,
+	"FIX.4.1":    enum.ApplVerID_FIX41,
+	"FIX.4.2":    enum.ApplVerID_FIX42,
+	"FIX.4.3":    enum.ApplVerID_FIX43,
+	"FIX.4.4":    enum.ApplVerID_FIX44,
+	"FIX.5.0":    enum.ApplVerID_FIX50,
+	"FIX.5.0SP1": enum.ApplVerID_FIX50SP1,
+	"FIX.5.0SP2": enum.ApplVerID_FIX50SP2,
+}
+
+// ApplVerID returns the ApplVerID of the application version given either as
+// a BeginString or as an ApplVerID.
+func ApplVerID(version string) (enum.ApplVerID, error) {
+	if applVerID, ok := ApplVerIDs[version]; ok {
+		return applVerID, nil
+	}
+
+	if _, err := SearchValue(ApplVerIDs, enum.ApplVerID(version)); err != nil {
+		return "", err
+	}
+
+	return enum.ApplVerID(version), nil
+}
@@ -0,0 +1,176 @@
+package dict
+
+// FIXMLMessages maps the message names of the data dictionaries to their FIXML
+// element names.
+var FIXMLMessages = map[string]string{
+	"BusinessMessageReject":         "BizMsgRej",
+	"ExecutionReport":               "ExecRpt",
+	"MarketDataIncrementalRefresh":  "MktDataInc",
+	"MarketDataRequest":             "MktDataReq",
+	"MarketDataRequestReject":       "MktDataReqRej",
+	"MarketDataSnapshotFullRefresh": "MktDataFull",
+	"NewOrderSingle":                "Order",
+	"OrderCancelReject":             "OrdCxlRej",
+	"OrderCancelReplaceRequest":     "OrdCxlRplcReq",
+	"OrderCancelRequest":            "OrdCxlReq",
+	"OrderMassCancelReport":         "OrdMassCxlRpt",
+	"OrderMassCancelRequest":        "OrdMassCxlReq",
+	"OrderStatusRequest":            "OrdStatReq",
+	"Quote":                         "Quote",
+	"QuoteCancel":                   "QuotCxl",
+	"QuoteRequest":                  "QuotReq",
+	"QuoteStatusReport":             "QuotStatRpt",
+	"SecurityList":                  "SecList",
+	"SecurityListRequest":           "SecListReq",
+	"SecurityStatus":                "SecStat",
+	"SecurityStatusRequest":         "SecStatReq",
+	"TradeCaptureReport":            "TrdCaptRpt",
+	"TradingSessionStatus":          "TrdgSesStat",
+	"TradingSessionStatusRequest":   "TrdgSesStatReq",
+}
+
+// FIXMLComponents maps the component names of the data dictionaries to their
+// FIXML element names.
+var FIXMLComponents = map[string]string{
+	"CommissionData":       "Comm",
+	"Instrument":           "Instrmt",
+	"InstrmtGrp":           "Instrmt",
+	"InstrmtLegGrp":        "Leg",
+	"InstrmtMDReqGrp":      "InstReq",
+	"InstrumentLeg":        "Leg",
+	"MDFullGrp":            "Full",
+	"MDIncGrp":             "Inc",
+	"MDReqGrp":             "Req",
+	"OrderQtyData":         "OrdQty",
+	"Parties":              "Pty",
+	"PtysSubGrp":           "Sub",
+	"QuotCxlEntriesGrp":    "QuotCxlEntry",
+	"SecAltIDGrp":          "AID",
+	"SecListGrp":           "SecL",
+	"Stipulations":         "Stip",
+	"TrdgSesGrp":           "TrdSes",
+	"UndInstrmtGrp":        "Undly",
+	"UnderlyingInstrument": "Undly",
+}
+
+// FIXMLHeaderFields maps the standard header field names to their FIXML
+// attribute names.
+var FIXMLHeaderFields = map[string]string{
+	"DeliverToCompID":  "D2ID",
+	"DeliverToSubID":   "D2Sub",
+	"MsgSeqNum":        "SeqNum",
+	"OnBehalfOfCompID": "OBID",
+	"OnBehalfOfSubID":  "OBSub",
+	"OrigSendingTime":  "OrigSnt",
+	"PossDupFlag":      "PosDup",
+	"PossResend":       "PosRsnd",
+	"SenderCompID":     "SID",
+	"SenderLocationID": "SLoc",
+	"SenderSubID":      "SSub",
+	"SendingTime":      "Snt",
+	"TargetCompID":     "TID",
+	"TargetLocationID": "TLoc",
+	"TargetSubID":      "TSub",
+}
+
+// FIXMLFields maps the field names of the data dictionaries to their FIXML
+// attribute names.
+var FIXMLFields = map[string]string{
+	"Account":                 "Acct",
+	"AvgPx":                   "AvgPx",
+	"BidPx":                   "BidPx",
+	"BidSize":                 "BidSz",
+	"BusinessRejectReason":    "BizRejRsn",
+	"BusinessRejectRefID":     "RefID",
+	"CFICode":                 "CFI",
+	"ClOrdID":                 "ID",
+	"ContractMultiplier":      "Mult",
+	"CumQty":                  "CumQty",
+	"Currency":                "Ccy",
+	"CxlRejReason":            "CxlRejRsn",
+	"CxlRejResponseTo":        "CxlRejRspTo",
+	"ExecID":                  "ExecID",
+	"ExecInst":                "ExecInst",
+	"ExecType":                "ExecTyp",
+	"ExpireDate":              "ExpireDt",
+	"ExpireTime":              "ExpireTm",
+	"HandlInst":               "HandlInst",
+	"LastFragment":            "LastFragment",
+	"LastMkt":                 "LastMkt",
+	"LastPx":                  "LastPx",
+	"LastQty":                 "LastQty",
+	"LeavesQty":               "LeavesQty",
+	"MarketDepth":             "MktDepth",
+	"MassCancelRequestType":   "ReqTyp",
+	"MassCancelResponse":      "Rsp",
+	"MaturityDate":            "MatDt",
+	"MaturityMonthYear":       "MMY",
+	"MaxFloor":                "MaxFloor",
+	"MDEntryDate":             "Dt",
+	"MDEntryID":               "ID",
+	"MDEntryPx":               "Px",
+	"MDEntrySize":             "Sz",
+	"MDEntryTime":             "Tm",
+	"MDEntryType":             "Typ",
+	"MDReqID":                 "ReqID",
+	"MDUpdateAction":          "UpdtAct",
+	"MDUpdateType":            "UpdtTyp",
+	"MinQty":                  "MinQty",
+	"OfferPx":                 "OfrPx",
+	"OfferSize":               "OfrSz",
+	"OrderID":                 "OrdID",
+	"OrderQty":                "Qty",
+	"OrdRejReason":            "RejRsn",
+	"OrdStatus":               "Stat",
+	"OrdType":                 "Typ",
+	"OrigClOrdID":             "OrigID",
+	"PartyID":                 "ID",
+	"PartyIDSource":           "Src",
+	"PartyRole":               "R",
+	"PartyRoleQualifier":      "Qual",
+	"PartySubID":              "ID",
+	"PartySubIDType":          "Typ",
+	"Price":                   "Px",
+	"PriceType":               "PxTyp",
+	"Product":                 "Prod",
+	"PutOrCall":               "PutCall",
+	"QuoteCancelType":         "CxlTyp",
+	"QuoteID":                 "QID",
+	"QuoteReqID":              "ReqID",
+	"QuoteStatus":             "Stat",
+	"QuoteType":               "QTyp",
+	"RefMsgType":              "RefMsgTyp",
+	"RefSeqNum":               "RefSeqNum",
+	"SecondaryOrderID":        "OrdID2",
+	"SecurityAltID":           "AltID",
+	"SecurityAltIDSource":     "AltIDSrc",
+	"SecurityDesc":            "Desc",
+	"SecurityExchange":        "Exch",
+	"SecurityID":              "ID",
+	"SecurityIDSource":        "Src",
+	"SecurityListRequestType": "ListReqTyp",
+	"SecurityReqID":           "ReqID",
+	"SecurityRequestResult":   "ReqRslt",
+	"SecurityResponseID":      "RspID",
+	"SecurityStatusReqID":     "StatReqID",
+	"SecurityTradingStatus":   "TrdgStat",
+	"SecurityType":            "SecTyp",
+	"SettlType":               "SettlTyp",
+	"Side":                    "Side",
+	"StopPx":                  "StopPx",
+	"StrikePrice":             "StrkPx",
+	"SubscriptionRequestType": "SubReqTyp",
+	"Symbol":                  "Sym",
+	"Text":                    "Txt",
+	"TimeInForce":             "TmInForce",
+	"TotNoRelatedSym":         "TotNoReltdSym",
+	"TradeDate":               "TrdDt",
+	"TradingSessionID":        "SesID",
+	"TradingSessionSubID":     "SesSub",
+	"TradSesMode":             "Mode",
+	"TradSesReqID":            "ReqID",
+	"TradSesStatus":           "Stat",
+	"TransactTime":            "TxnTm",
+	"TrdMatchID":              "MtchID",
+	"ValidUntilTime":          "ValidUntilTm",
+}
@@ -148,3 +148,18 @@ var OrderOriginations = map[string]enum.OrderOrigination{
 	"A_FOREIGN_DEALER_EQUIVALENT":                   enum.OrderOrigination_ORDER_RECEIVED_FROM_A_FOREIGN_DEALER_EQUIVALENT,
 	"AN_EXECUTION_ONLY_SERVICE":                     enum.OrderOrigination_ORDER_RECEIVED_FROM_AN_EXECUTION_ONLY_SERVICE,
 }
+
+var MassCancelRequestTypes = map[string]enum.MassCancelRequestType{
+	"SYMBOL":        enum.MassCancelRequestType_CANCEL_ORDERS_FOR_A_SECURITY,
+	"SECURITY_TYPE": enum.MassCancelRequestType_CANCEL_ORDERS_FOR_A_SECURITYTYPE,
+	"ALL":           enum.MassCancelRequestType_CANCEL_ALL_ORDERS,
+}
+
+func MassCancelRequestTypeStringToEnum(t string) (enum.MassCancelRequestType, error) {
+	t = strings.ToUpper(t)
+	if e, ok := MassCancelRequestTypes[t]; ok {
+		return e, nil
+	}
+
+	return "", fmt.Errorf("unkown mass cancel request type")
+}
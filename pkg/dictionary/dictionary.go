@@ -0,0 +1,114 @@
+package dictionary
+
+import (
+	"fmt"
+
+	"github.com/quickfixgo/quickfix/datadictionary"
+	"github.com/spf13/cobra"
+
+	"sylr.dev/fix/config"
+	"sylr.dev/fix/pkg/cli/complete"
+	"sylr.dev/fix/pkg/errors"
+)
+
+// ValidateDictionaryOptions reads the configuration unless the data
+// dictionaries are given with --app-dictionary, in which case commands working
+// offline do not require any configuration.
+func ValidateDictionaryOptions(cmd *cobra.Command, args []string) error {
+	options := config.GetOptions()
+
+	if len(options.AppDictionary) > 0 {
+		if len(options.Context) > 0 || len(options.Session) > 0 {
+			return fmt.Errorf("%w: can't use --context/--session with --app-dictionary", errors.Options)
+		}
+		return nil
+	}
+
+	return ValidateOptions(cmd, args)
+}
+
+// GetFIXDictionaries returns the data dictionaries given with
+// --transport-dictionary/--app-dictionary or the ones of the selected session,
+// which is either the one given with --session or the first session of the
+// current context.
+func GetFIXDictionaries() (*datadictionary.DataDictionary, *datadictionary.DataDictionary, error) {
+	options := config.GetOptions()
+
+	if len(options.AppDictionary) > 0 {
+		session := config.Session{
+			TransportDataDictionary: options.TransportDictionary,
+			AppDataDictionary:       options.AppDictionary,
+		}
+		if len(session.TransportDataDictionary) == 0 {
+			session.TransportDataDictionary = options.AppDictionary
+		}
+
+		return session.GetFIXDictionaries()
+	}
+
+	session, err := selectedSession()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s := *session
+	if len(options.TransportDictionary) > 0 {
+		s.TransportDataDictionary = options.TransportDictionary
+	}
+
+	return s.GetFIXDictionaries()
+}
+
+func selectedSession() (*config.Session, error) {
+	options := config.GetOptions()
+
+	if len(options.Session) > 0 {
+		return config.GetSession(options.Session)
+	}
+
+	context, err := config.GetCurrentContext()
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := context.GetSessions()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sessions) == 0 {
+		return nil, errors.ConfigContextNoSession
+	}
+
+	return sessions[0], nil
+}
+
+func AddPersistentFlags(cmd *cobra.Command) {
+	options := config.GetOptions()
+
+	cmd.PersistentFlags().StringVar(&options.Context, "context", "", "Context whose first session dictionaries to use")
+	cmd.PersistentFlags().StringVar(&options.Session, "session", "", "Session whose dictionaries to use")
+
+	AddDictionaryPersistentFlags(cmd)
+}
+
+// AddDictionaryPersistentFlags only adds the flags allowing to give the data
+// dictionaries files, for commands which select the session by other means.
+func AddDictionaryPersistentFlags(cmd *cobra.Command) {
+	options := config.GetOptions()
+
+	cmd.PersistentFlags().StringVar(&options.TransportDictionary, "transport-dictionary", "", "Transport data dictionary (defaults to the app dictionary)")
+	cmd.PersistentFlags().StringVar(&options.AppDictionary, "app-dictionary", "", "Application data dictionary (no configuration needed)")
+}
+
+func AddPersistentFlagCompletions(cmd *cobra.Command) error {
+	if err := cmd.RegisterFlagCompletionFunc("context", complete.Context); err != nil {
+		return err
+	}
+
+	if err := cmd.RegisterFlagCompletionFunc("session", complete.Session); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -6,45 +6,61 @@ import (
 )
 
 var (
-	Is = errors.Is
-	As = errors.As
+	Is   = errors.Is
+	As   = errors.As
+	Join = errors.Join
 )
 
 var (
-	Config                          = errors.New("configuration")
-	ConfigAcceptorNotFound          = fmt.Errorf("%w: acceptor not found", Config)
-	ConfigAlreadyExists             = fmt.Errorf("%w: already exists", Config)
-	ConfigCanNotBeCreated           = fmt.Errorf("%w: file can not be created", Config)
-	ConfigContextMultipleSessions   = fmt.Errorf("%w: multiple sessions in initiator context", Config)
-	ConfigContextNoSession          = fmt.Errorf("%w: context has no session", Config)
-	ConfigContextNotFound           = fmt.Errorf("%w: context not found", Config)
-	ConfigDuplicateContextName      = fmt.Errorf("%w: duplicate context name", Config)
-	ConfigDuplicateInitiatorName    = fmt.Errorf("%w: duplicate acceptor name", Config)
-	ConfigDuplicateSessionName      = fmt.Errorf("%w: duplicate session name", Config)
-	ConfigInitiatorNotFound         = fmt.Errorf("%w: initiator not found", Config)
-	ConfigSessionNotFound           = fmt.Errorf("%w: session not found", Config)
-	ConfigSessionNotInContext       = fmt.Errorf("%w: session name not in context", Config)
-	ConnectionTimeout               = errors.New("connection timeout")
-	Fix                             = errors.New("FIX")
-	FixLogout                       = fmt.Errorf("%w: logout received", Fix)
-	FixOrderCanceled                = fmt.Errorf("%w: canceled order", Fix)
-	FixOrderRejected                = fmt.Errorf("%w: rejected order", Fix)
-	FixVersionNotImplemented        = fmt.Errorf("%w: version not implemented", Fix)
-	FixOrderStatusUnknown           = fmt.Errorf("%w: unknown order status", Fix)
-	NotImplemented                  = errors.New("not implemented")
-	Options                         = errors.New("options")
-	OptionsInvalidMarketPrice       = fmt.Errorf("%w: can't give price for market order", Options)
-	OptionsNoSymbolGiven            = fmt.Errorf("%w: no symbol given", Options)
-	OptionsNoTypeGiven              = fmt.Errorf("%w: no type given", Options)
-	OptionsNoPriceGiven             = fmt.Errorf("%w: no price given", Options)
-	OptionsInconsistentValues       = fmt.Errorf("%w: inconsistent values", Options)
-	OptionOrderSideUnknown          = fmt.Errorf("%w: unknown order side", Options)
-	OptionOrderTypeUnknown          = fmt.Errorf("%w: unknown order type", Options)
-	OptionOrderOriginationUnknown   = fmt.Errorf("%w: unknown order origination", Options)
-	OptionOrderAttributeTypeUnkonwn = fmt.Errorf("%w: unknown order attribute type", Options)
-	OptionOrderRoleUnknown          = fmt.Errorf("%w: unknown order role", Options)
-	OptionOrderRoleQualifierUnknown = fmt.Errorf("%w: unknown order role qualifier", Options)
-	OptionOrderIDSourceUnknown      = fmt.Errorf("%w: unknown order id source", Options)
-	OptionPartySubIDTypeUnknown     = fmt.Errorf("%w: unknown party sub id type", Options)
-	ResponseTimeout                 = errors.New("timeout while waiting for response")
+	Config                           = errors.New("configuration")
+	ConfigAcceptorNotFound           = fmt.Errorf("%w: acceptor not found", Config)
+	ConfigAlreadyExists              = fmt.Errorf("%w: already exists", Config)
+	ConfigCanNotBeCreated            = fmt.Errorf("%w: file can not be created", Config)
+	ConfigContextMultipleSessions    = fmt.Errorf("%w: multiple sessions in initiator context", Config)
+	ConfigContextNoSession           = fmt.Errorf("%w: context has no session", Config)
+	ConfigContextNotFound            = fmt.Errorf("%w: context not found", Config)
+	ConfigDictionary                 = fmt.Errorf("%w: dictionary", Config)
+	ConfigDictionaryChecksumMismatch = fmt.Errorf("%w: checksum mismatch", ConfigDictionary)
+	ConfigDuplicateContextName       = fmt.Errorf("%w: duplicate context name", Config)
+	ConfigDuplicateInitiatorName     = fmt.Errorf("%w: duplicate acceptor name", Config)
+	ConfigDuplicateSessionName       = fmt.Errorf("%w: duplicate session name", Config)
+	ConfigExtendsCycle               = fmt.Errorf("%w: extends cycle", Config)
+	ConfigExtendsNotFound            = fmt.Errorf("%w: extended item not found", Config)
+	ConfigInitiatorNotFound          = fmt.Errorf("%w: initiator not found", Config)
+	ConfigInvalid                    = fmt.Errorf("%w: invalid file", Config)
+	ConfigSessionNotFound            = fmt.Errorf("%w: session not found", Config)
+	ConfigSessionNotInContext        = fmt.Errorf("%w: session name not in context", Config)
+	ConfigStoreNotPersistent         = fmt.Errorf("%w: no persistent message store", Config)
+	ConfigUnknownKey                 = fmt.Errorf("%w: unknown key", Config)
+	ConnectionTimeout                = errors.New("connection timeout")
+	Fix                              = errors.New("FIX")
+	FixLogout                        = fmt.Errorf("%w: logout received", Fix)
+	FixMessageInvalid                = fmt.Errorf("%w: invalid message", Fix)
+	FixMessageParse                  = fmt.Errorf("%w: unable to parse message", Fix)
+	FixOrderCanceled                 = fmt.Errorf("%w: canceled order", Fix)
+	FixOrderRejected                 = fmt.Errorf("%w: rejected order", Fix)
+	FixVersionNotImplemented         = fmt.Errorf("%w: version not implemented", Fix)
+	FixOrderStatusUnknown            = fmt.Errorf("%w: unknown order status", Fix)
+	HA                               = errors.New("high availability")
+	HALeadershipLost                 = fmt.Errorf("%w: leadership lost", HA)
+	NotImplemented                   = errors.New("not implemented")
+	Options                          = errors.New("options")
+	OptionsInvalidMarketPrice        = fmt.Errorf("%w: can't give price for market order", Options)
+	OptionsNoSymbolGiven             = fmt.Errorf("%w: no symbol given", Options)
+	OptionsNoTypeGiven               = fmt.Errorf("%w: no type given", Options)
+	OptionsNoPriceGiven              = fmt.Errorf("%w: no price given", Options)
+	OptionsNoSecurityTypeGiven       = fmt.Errorf("%w: no security type given", Options)
+	OptionsInconsistentValues        = fmt.Errorf("%w: inconsistent values", Options)
+	OptionOrderSideUnknown           = fmt.Errorf("%w: unknown order side", Options)
+	OptionOrderTypeUnknown           = fmt.Errorf("%w: unknown order type", Options)
+	OptionOrderOriginationUnknown    = fmt.Errorf("%w: unknown order origination", Options)
+	OptionOrderAttributeTypeUnkonwn  = fmt.Errorf("%w: unknown order attribute type", Options)
+	OptionOrderRoleUnknown           = fmt.Errorf("%w: unknown order role", Options)
+	OptionOrderRoleQualifierUnknown  = fmt.Errorf("%w: unknown order role qualifier", Options)
+	OptionOrderIDSourceUnknown       = fmt.Errorf("%w: unknown order id source", Options)
+	OptionPartySubIDTypeUnknown      = fmt.Errorf("%w: unknown party sub id type", Options)
+	OptionMassCancelRequestUnknown   = fmt.Errorf("%w: unknown mass cancel request type", Options)
+	ResponseTimeout                  = errors.New("timeout while waiting for response")
+	RiskLimitExceeded                = errors.New("risk limit exceeded")
+	Throttled                        = errors.New("send rate limit exceeded")
 )
@@ -0,0 +1,129 @@
+package errors
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// FixRejected is wrapped by the Reject errors.
+var FixRejected = fmt.Errorf("%w: message rejected", Fix)
+
+// SessionRejectReasons are the names of the SessionRejectReason values.
+var SessionRejectReasons = map[int]string{
+	0:  "InvalidTagNumber",
+	1:  "RequiredTagMissing",
+	2:  "TagNotDefinedForMessageType",
+	3:  "UndefinedTag",
+	4:  "TagSpecifiedWithoutValue",
+	5:  "ValueIsIncorrect",
+	6:  "IncorrectDataFormatForValue",
+	7:  "DecryptionProblem",
+	8:  "SignatureProblem",
+	9:  "CompIDProblem",
+	10: "SendingTimeAccuracyProblem",
+	11: "InvalidMsgType",
+	12: "XMLValidationError",
+	13: "TagAppearsMoreThanOnce",
+	14: "TagSpecifiedOutOfRequiredOrder",
+	15: "RepeatingGroupFieldsOutOfOrder",
+	16: "IncorrectNumInGroupCountForRepeatingGroup",
+	17: "NonDataValueIncludesFieldDelimiter",
+	18: "InvalidUnsupportedApplicationVersion",
+	99: "Other",
+}
+
+// BusinessRejectReasons are the names of the BusinessRejectReason values.
+var BusinessRejectReasons = map[int]string{
+	0:  "Other",
+	1:  "UnknownID",
+	2:  "UnknownSecurity",
+	3:  "UnsupportedMessageType",
+	4:  "ApplicationNotAvailable",
+	5:  "ConditionallyRequiredFieldMissing",
+	6:  "NotAuthorized",
+	7:  "DeliverToFirmNotAvailableAtThisTime",
+	18: "InvalidPriceIncrement",
+}
+
+// Reject is the error of a Reject or a BusinessMessageReject received in
+// response to a message sent. It wraps FixRejected and Err, the error of the
+// request which was rejected, if any.
+type Reject struct {
+	Err error
+	// Business is true for BusinessMessageReject messages, false for session
+	// level Reject ones.
+	Business            bool
+	RefSeqNum           int
+	RefMsgType          string
+	RefTagID            *int
+	BusinessRejectRefID string
+	// SessionRejectReason is the reason of Reject messages and
+	// BusinessRejectReason the one of BusinessMessageReject messages, nil
+	// when not given.
+	SessionRejectReason  *int
+	BusinessRejectReason *int
+	Text                 string
+}
+
+// Reason returns the name of the reason of the reject, empty if not given.
+func (e *Reject) Reason() string {
+	reason, names := e.SessionRejectReason, SessionRejectReasons
+	if e.Business {
+		reason, names = e.BusinessRejectReason, BusinessRejectReasons
+	}
+
+	if reason == nil {
+		return ""
+	}
+	if name, ok := names[*reason]; ok {
+		return name
+	}
+
+	return strconv.Itoa(*reason)
+}
+
+func (e *Reject) Error() string {
+	var b strings.Builder
+
+	if e.Err != nil {
+		b.WriteString(e.Err.Error())
+	} else {
+		b.WriteString(FixRejected.Error())
+	}
+
+	if e.Business {
+		b.WriteString(": BusinessMessageReject")
+	} else {
+		b.WriteString(": Reject")
+	}
+
+	if e.RefSeqNum > 0 {
+		fmt.Fprintf(&b, " of message %d", e.RefSeqNum)
+	}
+	if len(e.RefMsgType) > 0 {
+		fmt.Fprintf(&b, " (%s)", e.RefMsgType)
+	}
+	if len(e.BusinessRejectRefID) > 0 {
+		fmt.Fprintf(&b, " %s", e.BusinessRejectRefID)
+	}
+	if e.RefTagID != nil {
+		fmt.Fprintf(&b, " on tag %d", *e.RefTagID)
+	}
+	if reason := e.Reason(); len(reason) > 0 {
+		fmt.Fprintf(&b, ": %s", reason)
+	}
+	if len(e.Text) > 0 {
+		fmt.Fprintf(&b, ": %s", e.Text)
+	}
+
+	return b.String()
+}
+
+func (e *Reject) Unwrap() []error {
+	if e.Err != nil {
+		return []error{FixRejected, e.Err}
+	}
+
+	return []error{FixRejected}
+}
@@ -0,0 +1,274 @@
+package fuzz
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/quickfix/datadictionary"
+
+	"sylr.dev/fix/pkg/utils"
+)
+
+// Mutations applied to make near-valid messages.
+const (
+	MutationNone            = "none"
+	MutationMissingRequired = "missing-required"
+	MutationInvalidEnum     = "invalid-enum"
+	MutationWrongType       = "wrong-type"
+	MutationBoundary        = "boundary"
+	MutationUnknownTag      = "unknown-tag"
+)
+
+var Mutations = []string{
+	MutationMissingRequired,
+	MutationInvalidEnum,
+	MutationWrongType,
+	MutationBoundary,
+	MutationUnknownTag,
+}
+
+// Generator generates random messages out of a data dictionary.
+type Generator struct {
+	dict *datadictionary.DataDictionary
+	rand *rand.Rand
+
+	// OptionalRatio is the probability for an optional field, component or
+	// group to be set.
+	OptionalRatio float64
+	// MutationRatio is the probability for a message to be mutated into a
+	// near-valid one.
+	MutationRatio float64
+	// MaxGroupInstances is the maximum number of instances of the repeating
+	// groups.
+	MaxGroupInstances int
+}
+
+func NewGenerator(dict *datadictionary.DataDictionary, seed int64) *Generator {
+	return &Generator{
+		dict:              dict,
+		rand:              rand.New(rand.NewSource(seed)),
+		OptionalRatio:     0.2,
+		MutationRatio:     0.2,
+		MaxGroupInstances: 3,
+	}
+}
+
+// Generate returns a random message of the given type along with the mutation
+// applied to it.
+func (g *Generator) Generate(msgType string) (*quickfix.Message, string, error) {
+	msgDef, ok := g.dict.Messages[msgType]
+	if !ok {
+		return nil, "", fmt.Errorf("unknown message type `%s`", msgType)
+	}
+
+	mutation := MutationNone
+	if g.rand.Float64() < g.MutationRatio {
+		mutation = Mutations[g.rand.Intn(len(Mutations))]
+	}
+
+	message := quickfix.NewMessage()
+	message.Header.SetString(35, msgType)
+
+	s := state{mutation: mutation}
+	if mutation == MutationMissingRequired {
+		if required := requiredTags(msgDef.Parts); len(required) > 0 {
+			s.skipTag = required[g.rand.Intn(len(required))]
+		}
+	}
+
+	g.fill(&message.Body.FieldMap, msgDef.Parts, &s)
+
+	// Mutations which could not be applied to any field are applied to the
+	// message itself.
+	if !s.applied {
+		switch mutation {
+		case MutationUnknownTag, MutationInvalidEnum, MutationWrongType, MutationBoundary:
+			message.Body.SetString(quickfix.Tag(9000+g.rand.Intn(999)), g.randomString(8))
+			mutation = MutationUnknownTag
+		case MutationMissingRequired:
+			mutation = MutationNone
+		}
+	}
+
+	return message, mutation, nil
+}
+
+type state struct {
+	mutation string
+	skipTag  int
+	applied  bool
+}
+
+func (g *Generator) fill(fieldMap *quickfix.FieldMap, parts []datadictionary.MessagePart, s *state) {
+	for _, part := range parts {
+		if !part.Required() && g.rand.Float64() >= g.OptionalRatio {
+			continue
+		}
+
+		switch p := part.(type) {
+		case *datadictionary.FieldDef:
+			if p.Tag() == s.skipTag {
+				s.applied = true
+				continue
+			}
+
+			if p.IsGroup() {
+				group := quickfix.NewRepeatingGroup(quickfix.Tag(p.Tag()), utils.QuickFixGroupTemplate(p))
+				instances := 1 + g.rand.Intn(g.MaxGroupInstances)
+				delimiter := p.Fields[0]
+				for i := 0; i < instances; i++ {
+					instance := group.Add()
+					g.fill(&instance.FieldMap, p.Parts, s)
+
+					// The delimiter is mandatory even when optional.
+					if !instance.Has(quickfix.Tag(delimiter.Tag())) && !delimiter.IsGroup() {
+						if value, ok := g.value(delimiter.FieldType, &state{applied: true}); ok {
+							instance.SetString(quickfix.Tag(delimiter.Tag()), value)
+						}
+					}
+				}
+				fieldMap.SetGroup(group)
+				continue
+			}
+
+			value, ok := g.value(p.FieldType, s)
+			if ok {
+				fieldMap.SetString(quickfix.Tag(p.Tag()), value)
+			}
+
+		case datadictionary.Component:
+			g.fill(fieldMap, p.Parts(), s)
+		}
+	}
+}
+
+// value returns a random value for the field, mutated if the mutation of the
+// message has not been applied yet and can be applied to the field.
+func (g *Generator) value(fieldType *datadictionary.FieldType, s *state) (string, bool) {
+	switch fieldType.Type {
+	case "DATA", "XMLDATA", "LENGTH":
+		// These need a consistent length field.
+		return "", false
+	}
+
+	if !s.applied {
+		switch s.mutation {
+		case MutationInvalidEnum:
+			if len(fieldType.Enums) > 0 {
+				s.applied = true
+				return "~" + g.randomString(2), true
+			}
+		case MutationWrongType:
+			if isNumeric(fieldType.Type) {
+				s.applied = true
+				return g.randomString(4), true
+			}
+		case MutationBoundary:
+			if values := boundaryValues(fieldType.Type); len(fieldType.Enums) == 0 && len(values) > 0 {
+				s.applied = true
+				return values[g.rand.Intn(len(values))], true
+			}
+		}
+	}
+
+	if len(fieldType.Enums) > 0 {
+		values := make([]string, 0, len(fieldType.Enums))
+		for value := range fieldType.Enums {
+			values = append(values, value)
+		}
+		sort.Strings(values)
+		return values[g.rand.Intn(len(values))], true
+	}
+
+	now := time.Now().UTC()
+
+	switch fieldType.Type {
+	case "INT", "SEQNUM", "NUMINGROUP", "DAYOFMONTH":
+		return strconv.Itoa(1 + g.rand.Intn(28)), true
+	case "FLOAT", "PRICE", "QTY", "AMT", "PERCENTAGE", "PRICEOFFSET":
+		return strconv.FormatFloat(float64(1+g.rand.Intn(100000))/100, 'f', 2, 64), true
+	case "CHAR":
+		return string(rune('A' + g.rand.Intn(26))), true
+	case "BOOLEAN":
+		return []string{"Y", "N"}[g.rand.Intn(2)], true
+	case "UTCTIMESTAMP", "TZTIMESTAMP":
+		return now.Format("20060102-15:04:05.000"), true
+	case "UTCDATEONLY", "UTCDATE", "LOCALMKTDATE", "DATE":
+		return now.Format("20060102"), true
+	case "UTCTIMEONLY", "TZTIMEONLY", "TIME":
+		return now.Format("15:04:05"), true
+	case "MONTHYEAR":
+		return now.Format("200601"), true
+	case "CURRENCY":
+		return []string{"EUR", "USD", "GBP", "JPY"}[g.rand.Intn(4)], true
+	case "EXCHANGE":
+		return []string{"XPAR", "XLON", "XNYS"}[g.rand.Intn(3)], true
+	case "COUNTRY":
+		return []string{"FR", "GB", "US"}[g.rand.Intn(3)], true
+	}
+
+	return g.randomString(1 + g.rand.Intn(12)), true
+}
+
+func (g *Generator) randomString(n int) string {
+	const letters = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		b.WriteByte(letters[g.rand.Intn(len(letters))])
+	}
+
+	return b.String()
+}
+
+func isNumeric(typ string) bool {
+	switch typ {
+	case "INT", "SEQNUM", "NUMINGROUP", "DAYOFMONTH", "FLOAT", "PRICE", "QTY", "AMT", "PERCENTAGE", "PRICEOFFSET":
+		return true
+	}
+
+	return false
+}
+
+func boundaryValues(typ string) []string {
+	switch typ {
+	case "INT", "SEQNUM", "DAYOFMONTH":
+		return []string{"0", "-1", "2147483647", "-2147483648", "9223372036854775808"}
+	case "FLOAT", "PRICE", "QTY", "AMT", "PERCENTAGE", "PRICEOFFSET":
+		return []string{"0", "-0.00000001", "0.000000000001", "999999999999999.99", "-999999999999999.99"}
+	case "UTCTIMESTAMP", "TZTIMESTAMP":
+		return []string{"19700101-00:00:00.000", "99991231-23:59:59.999", "20240229-23:59:60.000"}
+	case "UTCDATEONLY", "UTCDATE", "LOCALMKTDATE", "DATE":
+		return []string{"19700101", "99991231", "20230229"}
+	case "STRING", "MULTIPLEVALUESTRING", "MULTIPLESTRINGVALUE", "MULTIPLECHARVALUE":
+		return []string{strings.Repeat("X", 1024), " ", "é"}
+	}
+
+	return nil
+}
+
+// requiredTags returns the tags of the required fields which are not nested
+// in optional components or groups.
+func requiredTags(parts []datadictionary.MessagePart) []int {
+	var tags []int
+
+	for _, part := range parts {
+		if !part.Required() {
+			continue
+		}
+
+		switch p := part.(type) {
+		case *datadictionary.FieldDef:
+			tags = append(tags, p.Tag())
+		case datadictionary.Component:
+			tags = append(tags, requiredTags(p.Parts())...)
+		}
+	}
+
+	return tags
+}
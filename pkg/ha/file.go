@@ -0,0 +1,58 @@
+package ha
+
+import (
+	"os"
+)
+
+// fileLock is an exclusive lock on a file, flock(2) on unix and LockFileEx on
+// Windows, which the instance holding it writes its name to.
+type fileLock struct {
+	path     string
+	instance string
+	file     *os.File
+}
+
+func newFileLock(path, instance string) *fileLock {
+	return &fileLock{path: os.ExpandEnv(path), instance: instance}
+}
+
+func (l *fileLock) Acquire() (bool, error) {
+	if l.file != nil {
+		return true, nil
+	}
+
+	file, err := os.OpenFile(l.path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return false, err
+	}
+
+	if locked, err := lockFile(file); err != nil || !locked {
+		file.Close()
+		return false, err
+	}
+
+	if err := file.Truncate(0); err == nil {
+		_, _ = file.WriteAt([]byte(l.instance+"\n"), 0)
+	}
+	l.file = file
+
+	return true, nil
+}
+
+func (l *fileLock) Release() error {
+	if l.file == nil {
+		return nil
+	}
+
+	err := unlockFile(l.file)
+	if cerr := l.file.Close(); err == nil {
+		err = cerr
+	}
+	l.file = nil
+
+	return err
+}
+
+func (l *fileLock) Close() error {
+	return l.Release()
+}
@@ -0,0 +1,23 @@
+//go:build unix
+
+package ha
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFile takes the flock(2) lock of the file, without waiting, and tells
+// whether it got it.
+func lockFile(file *os.File) (bool, error) {
+	err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if err == syscall.EWOULDBLOCK {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+func unlockFile(file *os.File) error {
+	return syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+}
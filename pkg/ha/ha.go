@@ -0,0 +1,208 @@
+// Package ha runs instances of a command active/standby: the instances compete
+// for a lock, held in a NATS JetStream key value bucket or as a lock file on
+// shared storage, and only the one holding it, the leader, holds the FIX
+// sessions. The sessions being kept in a shared message store, a standby
+// taking over after the leader died goes on with their sequence numbers.
+//
+// The NATS lock is a lease the leader renews, expiring when it is not renewed
+// for a while. The leader steps down once it could not renew it for two thirds
+// of the lease, renewals included, before any standby can take over. The file
+// lock is an flock(2) lock, LockFileEx on Windows, released by the system when
+// the leader dies.
+package ha
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"sylr.dev/fix/pkg/errors"
+)
+
+// DefaultLease is the time a NATS lock is held for without being renewed.
+const DefaultLease = 10 * time.Second
+
+// renewalsPerLease is the number of times the lock is renewed per lease, each
+// renewal waiting for that part of the lease at most, so that the leader can
+// retry a few times and still step down before the lock expires.
+const renewalsPerLease = 6
+
+// Options describes the lock the instances compete for, either NATSURL or
+// LockFile being given.
+type Options struct {
+	NATSURL string
+	// Bucket is the JetStream key value bucket of the lock, created if needed
+	// with entries expiring after Lease.
+	Bucket string
+	// Key of the lock in the bucket, instances of different groups using
+	// different keys.
+	Key   string
+	Lease time.Duration
+
+	LockFile string
+
+	// Instance identifies the instance among the others, it defaults to the
+	// host name and the process ID.
+	Instance string
+}
+
+// Lock is a lock only one of the instances can hold at a time.
+type Lock interface {
+	// Acquire takes the lock, or renews it when already held, and tells
+	// whether the instance holds it.
+	Acquire() (bool, error)
+	// Release gives up the lock if held.
+	Release() error
+	Close() error
+}
+
+// NewLock returns the lock of the options.
+func NewLock(options *Options) (Lock, error) {
+	instance := options.Instance
+	if len(instance) == 0 {
+		hostname, _ := os.Hostname()
+		instance = fmt.Sprintf("%s-%d", strings.ReplaceAll(hostname, ".", "-"), os.Getpid())
+	}
+
+	switch {
+	case len(options.NATSURL) > 0 && len(options.LockFile) > 0:
+		return nil, fmt.Errorf("%w: a NATS URL and a lock file can't be both given", errors.Options)
+	case len(options.NATSURL) > 0:
+		return newNATSLock(options, instance)
+	case len(options.LockFile) > 0:
+		return newFileLock(options.LockFile, instance), nil
+	default:
+		return nil, fmt.Errorf("%w: neither a NATS URL nor a lock file given", errors.Options)
+	}
+}
+
+// Elector elects the instance leader once it acquired the lock and keeps the
+// lock held afterwards.
+type Elector struct {
+	lock   Lock
+	lease  time.Duration
+	logger *zerolog.Logger
+
+	lost     chan struct{}
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     sync.WaitGroup
+}
+
+// NewElector returns an elector of the lock of the options.
+func NewElector(options *Options, logger *zerolog.Logger) (*Elector, error) {
+	lock, err := NewLock(options)
+	if err != nil {
+		return nil, err
+	}
+
+	lease := options.Lease
+	if lease <= 0 {
+		lease = DefaultLease
+	}
+
+	return &Elector{
+		lock:   lock,
+		lease:  lease,
+		logger: logger,
+		lost:   make(chan struct{}),
+		stop:   make(chan struct{}),
+	}, nil
+}
+
+// Campaign blocks until the instance is the leader, in which case it keeps
+// renewing the lock until Resign is called, or until ctx is done, in which case
+// it returns the error of ctx.
+func (e *Elector) Campaign(ctx context.Context) error {
+	ticker := time.NewTicker(e.lease / 3)
+	defer ticker.Stop()
+
+	for {
+		start := time.Now()
+		held, err := e.lock.Acquire()
+		if err != nil {
+			e.logger.Warn().Err(err).Msg("Could not acquire the leader lock")
+		} else if held {
+			e.logger.Info().Msg("Elected leader")
+			e.done.Add(1)
+			go e.renew(start)
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// renew renews the lock, acquired by a call started at renewed, until
+// resigning, closing Lost once the lock is taken by another instance or could
+// not be renewed for two thirds of the lease.
+func (e *Elector) renew(renewed time.Time) {
+	defer e.done.Done()
+
+	interval := e.lease / renewalsPerLease
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-e.stop:
+			return
+		case <-ticker.C:
+		}
+
+		// Step down before the lease expires and a standby takes over, unless
+		// a renewal waiting as long as it can still ends in time
+		if time.Since(renewed)+interval > e.lease*2/3 {
+			e.logger.Error().Msgf("Leader lock not renewed for %s", time.Since(renewed).Round(time.Millisecond))
+			close(e.lost)
+			return
+		}
+
+		// The lease runs from the request at the latest
+		start := time.Now()
+		held, err := e.lock.Acquire()
+		switch {
+		case err != nil:
+			e.logger.Warn().Err(err).Msg("Could not renew the leader lock")
+		case !held:
+			e.logger.Error().Msg("Leader lock taken by another instance")
+			close(e.lost)
+			return
+		default:
+			renewed = start
+		}
+	}
+}
+
+// Lost is closed once the instance is no longer the leader.
+func (e *Elector) Lost() <-chan struct{} {
+	return e.lost
+}
+
+// Resign stops renewing the lock and releases it, unless lost already, so that
+// a standby takes over right away.
+func (e *Elector) Resign() error {
+	e.stopOnce.Do(func() { close(e.stop) })
+	e.done.Wait()
+
+	var err error
+	select {
+	case <-e.lost:
+	default:
+		err = e.lock.Release()
+	}
+	if cerr := e.lock.Close(); err == nil {
+		err = cerr
+	}
+
+	return err
+}
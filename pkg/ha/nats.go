@@ -0,0 +1,114 @@
+package ha
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nats-io/nats.go"
+
+	"sylr.dev/fix/pkg/errors"
+)
+
+// natsLock is a lease on a key of a JetStream key value bucket, the key
+// expiring with the entries of the bucket when the lease is not renewed.
+type natsLock struct {
+	conn     *nats.Conn
+	kv       nats.KeyValue
+	key      string
+	instance string
+	revision uint64
+}
+
+func newNATSLock(options *Options, instance string) (*natsLock, error) {
+	lease := options.Lease
+	if lease <= 0 {
+		lease = DefaultLease
+	}
+
+	conn, err := nats.Connect(options.NATSURL, nats.RetryOnFailedConnect(true))
+	if err != nil {
+		return nil, err
+	}
+
+	// A renewal must not wait longer than its turn
+	js, err := conn.JetStream(nats.MaxWait(lease / renewalsPerLease))
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	kv, err := js.KeyValue(options.Bucket)
+	if err == nats.ErrBucketNotFound {
+		kv, err = js.CreateKeyValue(&nats.KeyValueConfig{
+			Bucket:      options.Bucket,
+			Description: "Leaders of the fix instances",
+			TTL:         lease,
+		})
+	}
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("leader lock bucket %s: %w", options.Bucket, err)
+	}
+
+	return &natsLock{
+		conn:     conn,
+		kv:       kv,
+		key:      natsKey(options.Key),
+		instance: instance,
+	}, nil
+}
+
+// natsKey returns the key with the characters NATS keys do not allow replaced.
+func natsKey(key string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', strings.ContainsRune("-_=.", r):
+			return r
+		default:
+			return '_'
+		}
+	}, key)
+}
+
+func (l *natsLock) Acquire() (bool, error) {
+	if l.revision > 0 {
+		revision, err := l.kv.Update(l.key, []byte(l.instance), l.revision)
+		if err == nil {
+			l.revision = revision
+			return true, nil
+		}
+
+		// The key was updated by another instance after it expired
+		var apiErr *nats.APIError
+		if !errors.As(err, &apiErr) || apiErr.ErrorCode != nats.JSErrCodeStreamWrongLastSequence {
+			return false, err
+		}
+		l.revision = 0
+	}
+
+	revision, err := l.kv.Create(l.key, []byte(l.instance))
+	if errors.Is(err, nats.ErrKeyExists) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	l.revision = revision
+
+	return true, nil
+}
+
+func (l *natsLock) Release() error {
+	if l.revision == 0 {
+		return nil
+	}
+
+	err := l.kv.Delete(l.key, nats.LastRevision(l.revision))
+	l.revision = 0
+
+	return err
+}
+
+func (l *natsLock) Close() error {
+	l.conn.Close()
+	return nil
+}
@@ -0,0 +1,113 @@
+// Package health follows the FIX sessions logged on and serves the liveness
+// and readiness probes of the long-running commands.
+//
+//	/livez   answers ok as long as the command runs (also served as /healthz)
+//	/readyz  answers the sessions logged on, or 503 when there is none
+package health
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/quickfixgo/quickfix"
+	"github.com/spf13/cobra"
+
+	"sylr.dev/fix/config"
+	"sylr.dev/fix/pkg/errors"
+)
+
+var (
+	sessions    = make(map[string]bool)
+	sessionsMux sync.RWMutex
+)
+
+// LoggedOn records that the session is logged on.
+func LoggedOn(sessionID quickfix.SessionID) {
+	sessionsMux.Lock()
+	defer sessionsMux.Unlock()
+
+	sessions[sessionID.String()] = true
+}
+
+// LoggedOut records that the session logged out or disconnected.
+func LoggedOut(sessionID quickfix.SessionID) {
+	sessionsMux.Lock()
+	defer sessionsMux.Unlock()
+
+	delete(sessions, sessionID.String())
+}
+
+// Sessions returns the sessions logged on, sorted.
+func Sessions() []string {
+	sessionsMux.RLock()
+	defer sessionsMux.RUnlock()
+
+	names := make([]string, 0, len(sessions))
+	for name := range sessions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Ready tells whether a session at least is logged on.
+func Ready() bool {
+	sessionsMux.RLock()
+	defer sessionsMux.RUnlock()
+
+	return len(sessions) > 0
+}
+
+func livez(w http.ResponseWriter, r *http.Request) {
+	io.WriteString(w, "ok\n")
+}
+
+func readyz(w http.ResponseWriter, r *http.Request) {
+	names := Sessions()
+	if len(names) == 0 {
+		http.Error(w, "no session logged on", http.StatusServiceUnavailable)
+		return
+	}
+
+	for _, name := range names {
+		fmt.Fprintf(w, "%s logged on\n", name)
+	}
+}
+
+// Register adds the probes to the mux.
+func Register(mux *http.ServeMux) {
+	mux.HandleFunc("/livez", livez)
+	mux.HandleFunc("/healthz", livez)
+	mux.HandleFunc("/readyz", readyz)
+}
+
+// AddMetricsFlags adds --metrics-listen to the long-running command, serving
+// its metrics and probes on their own address.
+func AddMetricsFlags(cmd *cobra.Command) {
+	options := config.GetOptions()
+
+	cmd.Flags().StringVar(&options.MetricsListen, "metrics-listen", "", "Address to serve /metrics, /livez and /readyz on (e.g. :9090), whatever --metrics and --port")
+}
+
+// Listen serves the metrics and the probes on the address, returning once
+// listening so that an unusable address fails the command.
+func Listen(address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return fmt.Errorf("%w: --metrics-listen: %s", errors.Options, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	Register(mux)
+
+	go http.Serve(listener, mux)
+
+	return nil
+}
@@ -7,7 +7,6 @@ import (
 
 	"github.com/quickfixgo/enum"
 	"github.com/quickfixgo/quickfix"
-	"github.com/quickfixgo/tag"
 
 	"sylr.dev/fix/pkg/dict"
 	"sylr.dev/fix/pkg/utils"
@@ -16,6 +15,7 @@ import (
 func NewCancelOrder() *CancelOrder {
 	o := CancelOrder{
 		Connected:       make(chan quickfix.SessionID),
+		Disconnected:    make(chan quickfix.SessionID, 1),
 		FromAppMessages: make(chan *quickfix.Message, 1),
 	}
 
@@ -23,19 +23,32 @@ func NewCancelOrder() *CancelOrder {
 }
 
 type CancelOrder struct {
-	utils.QuickFixAppMessageLogger
+	utils.BaseApplication
 
-	Settings        *quickfix.Settings
 	Connected       chan quickfix.SessionID
 	FromAppMessages chan *quickfix.Message
 	stopped         bool
 	mux             sync.RWMutex
+	latencies       latencies
+
+	// Resumable keeps the chans open when the session drops, which is then
+	// notified through Disconnected, so that the command can reconnect.
+	Resumable    bool
+	Disconnected chan quickfix.SessionID
+}
+
+// Configure sets the logger, the settings and the dictionaries of the
+// application.
+func (app *CancelOrder) Configure(options Options) *CancelOrder {
+	options.apply(&app.BaseApplication)
+	return app
 }
 
 // Stop ensures the app chans are emptied so that quickfix can carry on with
 // the LOGOUT process correctly.
 func (app *CancelOrder) Stop() {
 	app.Logger.Debug().Msgf("Stopping CancelOrder application")
+	app.Requests.Close()
 
 	app.mux.Lock()
 	defer app.mux.Unlock()
@@ -64,34 +77,23 @@ func (app *CancelOrder) OnLogon(sessionID quickfix.SessionID) {
 func (app *CancelOrder) OnLogout(sessionID quickfix.SessionID) {
 	app.Logger.Debug().Msgf("Logout: %s", sessionID)
 
+	if app.Resumable {
+		select {
+		case app.Disconnected <- sessionID:
+		default:
+		}
+		return
+	}
+
 	close(app.Connected)
 	close(app.FromAppMessages)
 }
 
 // Notification of admin message being sent to target.
 func (app *CancelOrder) ToAdmin(message *quickfix.Message, sessionID quickfix.SessionID) {
+	app.PrepareLogon(message, sessionID)
 	app.LogMessage(zerolog.TraceLevel, message, sessionID, true)
-
-	typ, err := message.MsgType()
-	if err != nil {
-		app.Logger.Error().Msgf("Message type error: %s", err)
-	}
-
-	// Logon
-	if err == nil && typ == string(enum.MsgType_LOGON) {
-		sets := app.Settings.SessionSettings()
-		if session, ok := sets[sessionID]; ok {
-			if username, err := session.Setting("Username"); err == nil && len(username) > 0 {
-				app.Logger.Debug().Msg("Username injected in logon message")
-				message.Header.SetField(tag.Username, quickfix.FIXString(username))
-			}
-			if password, err := session.Setting("Password"); err == nil && len(password) > 0 {
-				app.Logger.Debug().Msg("Password injected in logon message")
-				message.Header.SetField(tag.Password, quickfix.FIXString(password))
-			}
-		}
-	}
-
+	app.latencies.ToAdmin(message, sessionID)
 }
 
 func (app *CancelOrder) treatMessageByType(message *quickfix.Message, f func(enum.MsgType, *quickfix.Message)) {
@@ -106,6 +108,7 @@ func (app *CancelOrder) treatMessageByType(message *quickfix.Message, f func(enu
 // Notification of admin message being received from target.
 func (app *CancelOrder) FromAdmin(message *quickfix.Message, sessionID quickfix.SessionID) quickfix.MessageRejectError {
 	app.LogMessage(zerolog.TraceLevel, message, sessionID, false)
+	app.latencies.FromAdmin(message, sessionID)
 
 	app.treatMessageByType(message, func(msgType enum.MsgType, _ *quickfix.Message) {
 		if msgType == enum.MsgType_REJECT {
@@ -118,13 +121,22 @@ func (app *CancelOrder) FromAdmin(message *quickfix.Message, sessionID quickfix.
 
 // Notification of app message being sent to target.
 func (app *CancelOrder) ToApp(message *quickfix.Message, sessionID quickfix.SessionID) error {
+	// Routing
+	if session, ok := app.Settings.SessionSettings()[sessionID]; ok {
+		utils.QuickFixMessagePartSetRouting(&message.Header, session)
+	}
+
 	app.LogMessage(zerolog.TraceLevel, message, sessionID, true)
+	app.Requests.Sent(message, sessionID)
+	app.latencies.ToApp(message, sessionID)
 	return nil
 }
 
 // Notification of app message being received from target.
 func (app *CancelOrder) FromApp(message *quickfix.Message, sessionID quickfix.SessionID) quickfix.MessageRejectError {
 	app.LogMessage(zerolog.TraceLevel, message, sessionID, false)
+	app.Requests.Received(message, sessionID)
+	app.latencies.FromApp(message, sessionID)
 
 	app.mux.RLock()
 	if app.stopped {
@@ -140,6 +152,10 @@ func (app *CancelOrder) FromApp(message *quickfix.Message, sessionID quickfix.Se
 		case enum.MsgType_ORDER_CANCEL_REJECT:
 			fallthrough
 		case enum.MsgType_ORDER_MASS_CANCEL_REPORT:
+			fallthrough
+		case enum.MsgType_QUOTE_STATUS_REPORT:
+			fallthrough
+		case enum.MsgType_BUSINESS_MESSAGE_REJECT:
 			app.FromAppMessages <- message
 		default:
 			typeName, err := dict.SearchValue(dict.MessageTypes, msgType)
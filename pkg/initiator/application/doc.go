@@ -0,0 +1,39 @@
+// Package application provides the quickfix applications behind the initiator
+// commands, which can be embedded in other Go programs.
+//
+// An application is created with its constructor, configured with Options and
+// started with initiator.Connect. The messages it handles are then read from
+// its exported chans until the session logs out or the application is stopped:
+//
+//	app := application.NewNewOrder().Configure(application.Options{
+//		Logger:                  &logger,
+//		Settings:                settings,
+//		TransportDataDictionary: transportDict,
+//		AppDataDictionary:       appDict,
+//	})
+//
+//	init, sessionID, err := initiator.Connect(app, settings, nil, app.Connected, timeout, config.ReconnectPolicy{})
+//	if err != nil {
+//		app.Stop()
+//		return err
+//	}
+//	defer init.Stop()
+//	defer app.Stop()
+//
+//	if err := quickfix.SendToTarget(order, sessionID); err != nil {
+//		return err
+//	}
+//
+//	for message := range app.FromAppMessages {
+//		// Execution reports and cancel rejects of the order
+//	}
+//
+// NewOrder follows the lifecycle of the orders (PendingNew, New,
+// PartiallyFilled, then Filled, Canceled or Rejected ...) in its
+// OrderStateMachine, late execution reports never moving an order backwards.
+// Set its Transitions chan to receive the state changes along with the
+// messages.
+//
+// The settings and the dictionaries are built from the configuration with
+// config.Context.ToQuickFixInitiatorSettings and config.Session.GetFIXDictionaries.
+package application
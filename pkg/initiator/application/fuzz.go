@@ -0,0 +1,74 @@
+package application
+
+import (
+	"github.com/quickfixgo/enum"
+	"github.com/quickfixgo/quickfix"
+	"github.com/rs/zerolog"
+)
+
+func NewFuzz() *Fuzz {
+	app := Fuzz{
+		Initiator:         *NewInitiator(),
+		FromAdminMessages: make(chan *quickfix.Message, 1),
+	}
+
+	return &app
+}
+
+// Fuzz is an initiator application which, on top of the application messages,
+// forwards the session level rejects triggered by the messages sent.
+type Fuzz struct {
+	Initiator
+
+	FromAdminMessages chan *quickfix.Message
+}
+
+// Configure sets the logger, the settings and the dictionaries of the
+// application.
+func (app *Fuzz) Configure(options Options) *Fuzz {
+	app.Initiator.Configure(options)
+	return app
+}
+
+// Stop ensures the app chans are emptied so that quickfix can carry on with
+// the LOGOUT process correctly.
+func (app *Fuzz) Stop() {
+	app.Initiator.Stop()
+
+	app.mux.Lock()
+	defer app.mux.Unlock()
+
+	for len(app.FromAdminMessages) > 0 {
+		<-app.FromAdminMessages
+	}
+}
+
+// Notification of a session logging off or disconnecting.
+func (app *Fuzz) OnLogout(sessionID quickfix.SessionID) {
+	app.Initiator.OnLogout(sessionID)
+
+	app.mux.Lock()
+	defer app.mux.Unlock()
+
+	close(app.FromAdminMessages)
+}
+
+// Notification of admin message being received from target.
+func (app *Fuzz) FromAdmin(message *quickfix.Message, sessionID quickfix.SessionID) quickfix.MessageRejectError {
+	app.LogMessage(zerolog.TraceLevel, message, sessionID, false)
+
+	if typ, err := message.MsgType(); err != nil || typ != string(enum.MsgType_REJECT) {
+		return nil
+	}
+
+	app.mux.RLock()
+	if app.stopped {
+		app.mux.RUnlock()
+		return nil
+	}
+	app.mux.RUnlock()
+
+	app.FromAdminMessages <- message
+
+	return nil
+}
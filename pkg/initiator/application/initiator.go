@@ -5,9 +5,7 @@ import (
 
 	"github.com/rs/zerolog"
 
-	"github.com/quickfixgo/enum"
 	"github.com/quickfixgo/quickfix"
-	"github.com/quickfixgo/tag"
 
 	"sylr.dev/fix/pkg/utils"
 )
@@ -23,22 +21,30 @@ func NewInitiator() *Initiator {
 }
 
 type Initiator struct {
-	utils.QuickFixAppMessageLogger
+	utils.BaseApplication
 
 	SessionID quickfix.SessionID
 
-	Settings        *quickfix.Settings
 	Connected       chan quickfix.SessionID
 	FromAppMessages chan *quickfix.Message
 	ToAppMessages   chan *quickfix.Message
 	stopped         bool
 	mux             sync.RWMutex
+	latencies       latencies
+}
+
+// Configure sets the logger, the settings and the dictionaries of the
+// application.
+func (app *Initiator) Configure(options Options) *Initiator {
+	options.apply(&app.BaseApplication)
+	return app
 }
 
 // Stop ensures the app chans are emptied so that quickfix can carry on with
 // the LOGOUT process correctly.
 func (app *Initiator) Stop() {
 	app.Logger.Debug().Msgf("Stopping Initiator application")
+	app.Requests.Close()
 
 	app.mux.Lock()
 	defer app.mux.Unlock()
@@ -84,44 +90,30 @@ func (app *Initiator) ToAdmin(message *quickfix.Message, sessionID quickfix.Sess
 	app.mux.Lock()
 	defer app.mux.Unlock()
 
-	typ, err := message.MsgType()
-	if err != nil {
-		app.Logger.Error().Msgf("Message type error: %s", err)
-	}
-
-	// Logon
-	if err == nil && typ == string(enum.MsgType_LOGON) {
-		sets := app.Settings.SessionSettings()
-		if session, ok := sets[sessionID]; ok {
-			if session.HasSetting("Username") {
-				username, err := session.Setting("Username")
-				if err == nil && len(username) > 0 {
-					app.Logger.Debug().Msg("Username injected in logon message")
-					message.Header.SetField(tag.Username, quickfix.FIXString(username))
-				}
-			}
-			if session.HasSetting("Password") {
-				password, err := session.Setting("Password")
-				if err == nil && len(password) > 0 {
-					app.Logger.Debug().Msg("Password injected in logon message")
-					message.Header.SetField(tag.Password, quickfix.FIXString(password))
-				}
-			}
-		}
-	}
+	app.PrepareLogon(message, sessionID)
+	app.PrepareLogout(message)
 
 	app.LogMessage(zerolog.TraceLevel, message, sessionID, true)
+	app.latencies.ToAdmin(message, sessionID)
 }
 
 // Notification of admin message being received from target.
 func (app *Initiator) FromAdmin(message *quickfix.Message, sessionID quickfix.SessionID) quickfix.MessageRejectError {
 	app.LogMessage(zerolog.TraceLevel, message, sessionID, false)
+	app.latencies.FromAdmin(message, sessionID)
 	return nil
 }
 
 // Notification of app message being sent to target.
 func (app *Initiator) ToApp(message *quickfix.Message, sessionID quickfix.SessionID) error {
+	// Routing
+	if session, ok := app.Settings.SessionSettings()[sessionID]; ok {
+		utils.QuickFixMessagePartSetRouting(&message.Header, session)
+	}
+
 	app.LogMessage(zerolog.InfoLevel, message, sessionID, true)
+	app.Requests.Sent(message, sessionID)
+	app.latencies.ToApp(message, sessionID)
 
 	app.mux.RLock()
 	if app.stopped {
@@ -138,6 +130,8 @@ func (app *Initiator) ToApp(message *quickfix.Message, sessionID quickfix.Sessio
 // Notification of app message being received from target.
 func (app *Initiator) FromApp(message *quickfix.Message, sessionID quickfix.SessionID) quickfix.MessageRejectError {
 	app.LogMessage(zerolog.InfoLevel, message, sessionID, false)
+	app.Requests.Received(message, sessionID)
+	app.latencies.FromApp(message, sessionID)
 
 	app.mux.RLock()
 	if app.stopped {
@@ -0,0 +1,171 @@
+package application
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/quickfixgo/enum"
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/tag"
+
+	"sylr.dev/fix/pkg/tracing"
+)
+
+var (
+	latencyBuckets = prometheus.ExponentialBuckets(0.0005, 2, 16)
+
+	metricInitiatorRequestRoundTrip = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "fix",
+			Subsystem: "initiator",
+			Name:      "request_round_trip_seconds",
+			Help:      "Time between an application request sent and its first response",
+			Buckets:   latencyBuckets,
+		},
+		[]string{"session", "msg_type"},
+	)
+	metricInitiatorTransportRoundTrip = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "fix",
+			Subsystem: "initiator",
+			Name:      "transport_round_trip_seconds",
+			Help:      "Time between a Logon, TestRequest or Logout sent and its acknowledgement",
+			Buckets:   latencyBuckets,
+		},
+		[]string{"session", "msg_type"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(
+		metricInitiatorRequestRoundTrip,
+		metricInitiatorTransportRoundTrip,
+	)
+}
+
+// maxPendingLatencies bounds the requests awaiting a response, those which
+// never get one being forgotten when it is reached.
+const maxPendingLatencies = 65536
+
+type pendingLatency struct {
+	msgType string
+	sent    time.Time
+}
+
+// latencies measures the time the counterparty takes to answer the requests
+// sent, related to their responses by their correlation id, and to acknowledge
+// the transport messages. The zero value is ready to use.
+type latencies struct {
+	requests  map[string]pendingLatency
+	transport map[string]pendingLatency
+	mux       sync.Mutex
+}
+
+// transportKey returns the key relating a transport message to its
+// acknowledgement: a Logon and a Logout are acknowledged by the same message,
+// a TestRequest by the Heartbeat carrying its TestReqID.
+func transportKey(message *quickfix.Message, sending bool) (string, bool) {
+	msgType, err := message.MsgType()
+	if err != nil {
+		return "", false
+	}
+
+	switch enum.MsgType(msgType) {
+	case enum.MsgType_LOGON, enum.MsgType_LOGOUT:
+		return msgType, true
+	case enum.MsgType_TEST_REQUEST:
+		if !sending {
+			return "", false
+		}
+	case enum.MsgType_HEARTBEAT:
+		if sending {
+			return "", false
+		}
+	default:
+		return "", false
+	}
+
+	testReqID, err := message.Body.GetString(tag.TestReqID)
+	if err != nil || len(testReqID) == 0 {
+		return "", false
+	}
+
+	return string(enum.MsgType_TEST_REQUEST) + testReqID, true
+}
+
+func (l *latencies) add(pending *map[string]pendingLatency, key, msgType string) {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	if *pending == nil || len(*pending) >= maxPendingLatencies {
+		*pending = make(map[string]pendingLatency)
+	}
+	(*pending)[key] = pendingLatency{msgType: msgType, sent: time.Now()}
+}
+
+func (l *latencies) remove(pending map[string]pendingLatency, key string) (pendingLatency, bool) {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	p, ok := pending[key]
+	if ok {
+		delete(pending, key)
+	}
+
+	return p, ok
+}
+
+// ToAdmin starts measuring the acknowledgement of the transport message.
+func (l *latencies) ToAdmin(message *quickfix.Message, sessionID quickfix.SessionID) {
+	key, ok := transportKey(message, true)
+	if !ok {
+		return
+	}
+
+	msgType, _ := message.MsgType()
+
+	// A new connection forgets the messages of the previous one
+	if enum.MsgType(msgType) == enum.MsgType_LOGON {
+		l.mux.Lock()
+		l.transport = nil
+		l.mux.Unlock()
+	}
+
+	l.add(&l.transport, key, msgType)
+}
+
+// FromAdmin observes the time the transport message acknowledged took.
+func (l *latencies) FromAdmin(message *quickfix.Message, sessionID quickfix.SessionID) {
+	key, ok := transportKey(message, false)
+	if !ok {
+		return
+	}
+
+	if p, ok := l.remove(l.transport, key); ok {
+		metricInitiatorTransportRoundTrip.WithLabelValues(sessionID.String(), p.msgType).Observe(time.Since(p.sent).Seconds())
+	}
+}
+
+// ToApp starts measuring the round trip of the request.
+func (l *latencies) ToApp(message *quickfix.Message, sessionID quickfix.SessionID) {
+	id := tracing.CorrelationID(message)
+	if len(id) == 0 {
+		return
+	}
+
+	msgType, _ := message.MsgType()
+	l.add(&l.requests, id, msgType)
+}
+
+// FromApp observes the round trip of the request the message answers.
+func (l *latencies) FromApp(message *quickfix.Message, sessionID quickfix.SessionID) {
+	id := tracing.CorrelationID(message)
+	if len(id) == 0 {
+		return
+	}
+
+	if p, ok := l.remove(l.requests, id); ok {
+		metricInitiatorRequestRoundTrip.WithLabelValues(sessionID.String(), p.msgType).Observe(time.Since(p.sent).Seconds())
+	}
+}
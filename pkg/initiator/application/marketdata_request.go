@@ -7,6 +7,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/iancoleman/strcase"
 	"github.com/olekukonko/tablewriter"
 	"github.com/quickfixgo/field"
@@ -18,6 +19,7 @@ import (
 	"github.com/quickfixgo/quickfix/datadictionary"
 	"github.com/quickfixgo/tag"
 
+	"sylr.dev/fix/pkg/recorder"
 	"sylr.dev/fix/pkg/utils"
 )
 
@@ -30,6 +32,7 @@ func NewMarketDataRequest(printData, printNews bool) *MarketDataRequest {
 		router:          quickfix.NewMessageRouter(),
 		printData:       printData,
 		printNews:       printNews,
+		subscriptions:   make(map[string]*quickfix.Message),
 	}
 
 	mdr.router.AddRoute(quickfix.ApplVerIDFIX50SP2, string(enum.MsgType_MARKET_DATA_INCREMENTAL_REFRESH), mdr.onMarketDataIncrementalRefresh)
@@ -40,24 +43,50 @@ func NewMarketDataRequest(printData, printNews bool) *MarketDataRequest {
 }
 
 type MarketDataRequest struct {
-	utils.QuickFixAppMessageLogger
+	utils.BaseApplication
 
-	Settings        *quickfix.Settings
 	Connected       chan quickfix.SessionID
 	FromAppMessages chan quickfix.Messagable
 	stopped         bool
 	mux             sync.RWMutex
+	latencies       latencies
 	router          *quickfix.MessageRouter
 	printData       bool
 	printNews       bool
+	loggedOnBefore  bool
+	closed          bool
+	subscriptions   map[string]*quickfix.Message
+
+	// OnResubscribe is called for each subscription sent again after a new
+	// logon or with Resubscribe, for the state maintained for the previous
+	// MDReqID to be reset.
+	OnResubscribe func(previous, mdReqID string)
+
+	// Book, when set, is updated with the refreshes received.
+	Book *OrderBook
+
+	// Recorder, when set, records the refreshes received.
+	Recorder *recorder.Recorder
+
+	// Resumable keeps the chans open when the session drops, the snapshot
+	// requests being sent again on logon like the subscriptions.
+	Resumable bool
 }
 
 var _ quickfix.Application = (*MarketDataRequest)(nil)
 
+// Configure sets the logger, the settings and the dictionaries of the
+// application.
+func (app *MarketDataRequest) Configure(options Options) *MarketDataRequest {
+	options.apply(&app.BaseApplication)
+	return app
+}
+
 // Stop ensures the app chans are emptied so that quickfix can carry on with
 // the LOGOUT process correctly.
 func (app *MarketDataRequest) Stop() {
 	app.Logger.Debug().Msgf("Stopping MarketDataRequest application")
+	app.Requests.Close()
 
 	app.mux.Lock()
 	defer app.mux.Unlock()
@@ -79,51 +108,123 @@ func (app *MarketDataRequest) OnCreate(sessionID quickfix.SessionID) {
 func (app *MarketDataRequest) OnLogon(sessionID quickfix.SessionID) {
 	app.Logger.Debug().Msgf("Logon: %s", sessionID)
 
-	app.Connected <- sessionID
+	app.mux.Lock()
+	again := app.loggedOnBefore
+	app.loggedOnBefore = true
+	app.mux.Unlock()
+
+	if !again {
+		app.Connected <- sessionID
+		return
+	}
+
+	// The subscriptions ended with the previous session
+	go func() {
+		if err := app.Resubscribe(sessionID, false); err != nil {
+			app.Logger.Error().Err(err).Msg("Could not subscribe again")
+		}
+	}()
 }
 
 // Notification of a session logging off or disconnecting.
 func (app *MarketDataRequest) OnLogout(sessionID quickfix.SessionID) {
 	app.Logger.Debug().Msgf("Logout: %s", sessionID)
 
-	close(app.Connected)
-	close(app.FromAppMessages)
+	app.mux.Lock()
+	defer app.mux.Unlock()
+
+	// Wait for quickfix to log on again when there are subscriptions to
+	// restore
+	if !app.stopped && (len(app.subscriptions) > 0 || app.Resumable) {
+		app.Logger.Warn().Msgf("Session dropped, %d subscription(s) will be sent again on logon", len(app.subscriptions))
+		return
+	}
+
+	if !app.closed {
+		app.closed = true
+		close(app.Connected)
+		close(app.FromAppMessages)
+	}
 }
 
-// Notification of admin message being sent to target.
-func (app *MarketDataRequest) ToAdmin(message *quickfix.Message, sessionID quickfix.SessionID) {
-	typ, err := message.MsgType()
+// Resubscribe sends the current subscriptions again with new MDReqIDs, first
+// disabling them when unsubscribe is true.
+func (app *MarketDataRequest) Resubscribe(sessionID quickfix.SessionID, unsubscribe bool) error {
+	app.mux.Lock()
+	subscriptions := make(map[string]*quickfix.Message, len(app.subscriptions))
+	for mdReqID, subscription := range app.subscriptions {
+		subscriptions[mdReqID] = subscription
+		delete(app.subscriptions, mdReqID)
+	}
+	app.mux.Unlock()
+
+	for previous, subscription := range subscriptions {
+		if unsubscribe {
+			unsubscription := utils.QuickFixMessageCopy(subscription, app.AppDataDictionary)
+			unsubscription.Body.Set(field.NewSubscriptionRequestType(enum.SubscriptionRequestType_DISABLE_PREVIOUS_SNAPSHOT_PLUS_UPDATE_REQUEST))
+			if err := quickfix.SendToTarget(unsubscription, sessionID); err != nil {
+				return err
+			}
+		}
+
+		mdReqID := uuid.NewString()
+		resubscription := utils.QuickFixMessageCopy(subscription, app.AppDataDictionary)
+		resubscription.Body.Set(field.NewMDReqID(mdReqID))
+
+		if app.OnResubscribe != nil {
+			app.OnResubscribe(previous, mdReqID)
+		}
+
+		app.Logger.Info().Msgf("Subscribing again with MDReqID %s (previously %s)", mdReqID, previous)
+		if err := quickfix.SendToTarget(resubscription, sessionID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// trackSubscription keeps the MarketDataRequest subscriptions sent so that
+// they can be sent again.
+func (app *MarketDataRequest) trackSubscription(message *quickfix.Message) {
+	if typ, _ := message.MsgType(); typ != string(enum.MsgType_MARKET_DATA_REQUEST) {
+		return
+	}
+
+	mdReqID, err := message.Body.GetString(tag.MDReqID)
 	if err != nil {
-		app.Logger.Error().Msgf("Message type error: %s", err)
+		return
 	}
+	subType, _ := message.Body.GetString(tag.SubscriptionRequestType)
 
-	// Logon
-	if err == nil && typ == string(enum.MsgType_LOGON) {
-		sets := app.Settings.SessionSettings()
-		if session, ok := sets[sessionID]; ok {
-			if session.HasSetting("Username") {
-				username, err := session.Setting("Username")
-				if err == nil && len(username) > 0 {
-					app.Logger.Debug().Msg("Username injected in logon message")
-					message.Header.SetField(tag.Username, quickfix.FIXString(username))
-				}
-			}
-			if session.HasSetting("Password") {
-				password, err := session.Setting("Password")
-				if err == nil && len(password) > 0 {
-					app.Logger.Debug().Msg("Password injected in logon message")
-					message.Header.SetField(tag.Password, quickfix.FIXString(password))
-				}
-			}
+	app.mux.Lock()
+	defer app.mux.Unlock()
+
+	switch enum.SubscriptionRequestType(subType) {
+	case enum.SubscriptionRequestType_SNAPSHOT_PLUS_UPDATES:
+		app.subscriptions[mdReqID] = utils.QuickFixMessageCopy(message, app.AppDataDictionary)
+	case enum.SubscriptionRequestType_SNAPSHOT:
+		// Pending until the application stops, once the snapshot received
+		if app.Resumable {
+			app.subscriptions[mdReqID] = utils.QuickFixMessageCopy(message, app.AppDataDictionary)
 		}
+	case enum.SubscriptionRequestType_DISABLE_PREVIOUS_SNAPSHOT_PLUS_UPDATE_REQUEST:
+		delete(app.subscriptions, mdReqID)
 	}
+}
+
+// Notification of admin message being sent to target.
+func (app *MarketDataRequest) ToAdmin(message *quickfix.Message, sessionID quickfix.SessionID) {
+	app.PrepareLogon(message, sessionID)
 
 	app.LogMessage(zerolog.TraceLevel, message, sessionID, true)
+	app.latencies.ToAdmin(message, sessionID)
 }
 
 // Notification of admin message being received from target.
 func (app *MarketDataRequest) FromAdmin(message *quickfix.Message, sessionID quickfix.SessionID) quickfix.MessageRejectError {
 	app.LogMessage(zerolog.TraceLevel, message, sessionID, false)
+	app.latencies.FromAdmin(message, sessionID)
 
 	typ, err := message.MsgType()
 	if err != nil {
@@ -140,16 +241,45 @@ func (app *MarketDataRequest) FromAdmin(message *quickfix.Message, sessionID qui
 
 // Notification of app message being sent to target.
 func (app *MarketDataRequest) ToApp(message *quickfix.Message, sessionID quickfix.SessionID) error {
+	// Routing
+	if session, ok := app.Settings.SessionSettings()[sessionID]; ok {
+		utils.QuickFixMessagePartSetRouting(&message.Header, session)
+	}
+
 	app.LogMessage(zerolog.TraceLevel, message, sessionID, true)
+	app.Requests.Sent(message, sessionID)
+	app.latencies.ToApp(message, sessionID)
+	app.trackSubscription(message)
 	return nil
 }
 
 // Notification of app message being received from target.
 func (app *MarketDataRequest) FromApp(message *quickfix.Message, sessionID quickfix.SessionID) quickfix.MessageRejectError {
 	app.LogMessage(zerolog.TraceLevel, message, sessionID, false)
+	app.Requests.Received(message, sessionID)
+	app.latencies.FromApp(message, sessionID)
+	recordMarketData(app.Recorder, app.Logger, message, sessionID)
 	return app.router.Route(message, sessionID)
 }
 
+// recordMarketData records the message with the recorder, if any, when it is a
+// snapshot or an incremental refresh.
+func recordMarketData(rec *recorder.Recorder, logger *zerolog.Logger, message *quickfix.Message, sessionID quickfix.SessionID) {
+	if rec == nil {
+		return
+	}
+
+	switch msgType, _ := message.MsgType(); enum.MsgType(msgType) {
+	case enum.MsgType_MARKET_DATA_SNAPSHOT_FULL_REFRESH, enum.MsgType_MARKET_DATA_INCREMENTAL_REFRESH:
+	default:
+		return
+	}
+
+	if err := rec.Record(message, sessionID); err != nil {
+		logger.Error().Err(err).Msg("Could not record the market data")
+	}
+}
+
 func (app *MarketDataRequest) onMarketDataSnapshotFullRefresh(msg *quickfix.Message, sessionID quickfix.SessionID) quickfix.MessageRejectError {
 	group := quickfix.NewRepeatingGroup(
 		tag.NoMDEntries,
@@ -167,8 +297,18 @@ func (app *MarketDataRequest) onMarketDataSnapshotFullRefresh(msg *quickfix.Mess
 	)
 	msg.Body.GetGroup(group)
 
+	if app.Book != nil {
+		if err := app.Book.Apply(msg); err != nil {
+			app.Logger.Error().Err(err).Msg("Could not update the order book")
+		}
+	}
+
 	if app.printData {
-		printFIX50NoMDEntriesFull(group, msg, app.AppDataDictionary)
+		if len(app.OutputFormat) == 0 || app.OutputFormat == utils.OutputFormatTable {
+			printFIX50NoMDEntriesFull(group, msg, app.AppDataDictionary)
+		} else {
+			app.WriteMessage(os.Stdout, msg)
+		}
 	}
 
 	app.mux.RLock()
@@ -205,8 +345,18 @@ func (app *MarketDataRequest) onMarketDataIncrementalRefresh(msg *quickfix.Messa
 	)
 	msg.Body.GetGroup(group)
 
+	if app.Book != nil {
+		if err := app.Book.Apply(msg); err != nil {
+			app.Logger.Error().Err(err).Msg("Could not update the order book")
+		}
+	}
+
 	if app.printData {
-		printFIX50NoMDEntriesInc(group, app.AppDataDictionary)
+		if len(app.OutputFormat) == 0 || app.OutputFormat == utils.OutputFormatTable {
+			printFIX50NoMDEntriesInc(group, app.AppDataDictionary)
+		} else {
+			app.WriteMessage(os.Stdout, msg)
+		}
 	}
 
 	app.mux.RLock()
@@ -373,11 +523,15 @@ func printFIX50NoMDEntriesFull(group *quickfix.RepeatingGroup, msg *quickfix.Mes
 		price, err = s.GetString(tag.MDEntryPx)
 		if err != nil {
 			price = nilstr
+		} else {
+			price = utils.QuickFixFormatDecimal(dict, tag.MDEntryPx, symbol, price)
 		}
 
 		size, err = s.GetString(tag.MDEntrySize)
 		if err != nil {
 			size = nilstr
+		} else {
+			size = utils.QuickFixFormatDecimal(dict, tag.MDEntrySize, symbol, size)
 		}
 
 		stringDate, errDate := s.GetString(tag.MDEntryDate)
@@ -386,13 +540,13 @@ func printFIX50NoMDEntriesFull(group *quickfix.RepeatingGroup, msg *quickfix.Mes
 		if errDate == nil && errTime == nil {
 			timeDate, _ := time.Parse("20060102", stringDate)
 			timeTime, _ := time.Parse("15:04:05.999999999", stringTime)
-			tim = utils.CombineDateAndTime(timeDate, timeTime).Format(time.RFC3339Nano)
+			tim = utils.FormatTime(utils.CombineDateAndTime(timeDate, timeTime))
 		} else if errDate == nil {
 			timeDate, _ := time.Parse("20060102", stringDate)
-			tim = timeDate.Format("2006-01-02")
+			tim = utils.FormatDate(timeDate)
 		} else if errTime == nil {
 			timeTime, _ := time.Parse("15:04:05.999999999", stringTime)
-			tim = timeTime.Format("15:04:05.999")
+			tim = utils.FormatTimeOnly(timeTime)
 		}
 
 		table.Append([]string{fmt.Sprintf("%s %s", typSign, symbol), orderTradeID, typ, price, size, tim})
@@ -400,7 +554,7 @@ func printFIX50NoMDEntriesFull(group *quickfix.RepeatingGroup, msg *quickfix.Mes
 
 	last, err := msg.Body.GetTime(tag.LastUpdateTime)
 	if err == nil {
-		table.SetFooter([]string{"", "", "", "", "Last Time", last.Format(time.RFC3339Nano)})
+		table.SetFooter([]string{"", "", "", "", "Last Time", utils.FormatTime(last)})
 	}
 
 	table.Render()
@@ -464,11 +618,15 @@ func printFIX50NoMDEntriesInc(group *quickfix.RepeatingGroup, dict *datadictiona
 		price, err = s.GetString(tag.MDEntryPx)
 		if err != nil {
 			price = nilstr
+		} else {
+			price = utils.QuickFixFormatDecimal(dict, tag.MDEntryPx, symbol, price)
 		}
 
 		size, err = s.GetString(tag.MDEntrySize)
 		if err != nil {
 			size = nilstr
+		} else {
+			size = utils.QuickFixFormatDecimal(dict, tag.MDEntrySize, symbol, size)
 		}
 
 		stringDate, errDate := s.GetString(tag.MDEntryDate)
@@ -477,13 +635,13 @@ func printFIX50NoMDEntriesInc(group *quickfix.RepeatingGroup, dict *datadictiona
 		if errDate == nil && errTime == nil {
 			timeDate, _ := time.Parse("20060102", stringDate)
 			timeTime, _ := time.Parse("15:04:05.999999999", stringTime)
-			tim = utils.CombineDateAndTime(timeDate, timeTime).Format(time.RFC3339Nano)
+			tim = utils.FormatTime(utils.CombineDateAndTime(timeDate, timeTime))
 		} else if errDate == nil {
 			timeDate, _ := time.Parse("20060102", stringDate)
-			tim = timeDate.Format("2006-01-02")
+			tim = utils.FormatDate(timeDate)
 		} else if errTime == nil {
 			timeTime, _ := time.Parse("15:04:05.999999999", stringTime)
-			tim = timeTime.Format("15:04:05.999")
+			tim = utils.FormatTimeOnly(timeTime)
 		}
 
 		table.Append([]string{fmt.Sprintf("%s %s", typSign, symbol), orderTradeID, action, typ, price, size, tim})
@@ -4,6 +4,7 @@
 package application
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"sync"
@@ -23,6 +24,8 @@ import (
 
 	"sylr.dev/fix/pkg/dict"
 	"sylr.dev/fix/pkg/errors"
+	"sylr.dev/fix/pkg/health"
+	"sylr.dev/fix/pkg/recorder"
 	"sylr.dev/fix/pkg/utils"
 )
 
@@ -113,15 +116,21 @@ func init() {
 		metricMarketDataValidatorConnection)
 }
 
-func NewMarketDataValidator(logger *zerolog.Logger, options MarketDataValidatorOptions, timeout time.Duration) *MarketDataValidator {
+var _ App = (*MarketDataValidator)(nil)
+
+// NewMarketDataValidator returns a validator which stops subscribing once the
+// context is cancelled.
+func NewMarketDataValidator(ctx context.Context, logger *zerolog.Logger, options MarketDataValidatorOptions, timeout time.Duration) *MarketDataValidator {
 	mdr := MarketDataValidator{
 		AppInfoChan:          make(chan string),
 		SecurityListResponse: make(chan *quickfix.Message),
+		Errors:               make(chan error, 1),
 		Validator: &Validator{
 			secList: make(map[string]*Orders),
 			logger:  logger,
 		},
 		router:  quickfix.NewMessageRouter(),
+		ctx:     ctx,
 		options: options,
 		timeout: timeout,
 	}
@@ -164,26 +173,43 @@ type MarketDataValidatorOptions struct {
 }
 
 type MarketDataValidator struct {
-	utils.QuickFixAppMessageLogger
+	utils.BaseApplication
 
-	Settings             *quickfix.Settings
 	AppInfoChan          chan string
 	SecurityListResponse chan *quickfix.Message
+	Errors               chan error
 	stopped              bool
 	mux                  sync.RWMutex
+	latencies            latencies
 	router               *quickfix.MessageRouter
+	ctx                  context.Context
 	options              MarketDataValidatorOptions
 	timeout              time.Duration
+	sessionID            quickfix.SessionID
+	loggedOn             bool
+	subscription         *quickfix.Message
+	fragments            securityListFragments
 
 	Validator *Validator
+
+	// Recorder, when set, records the refreshes received.
+	Recorder *recorder.Recorder
 }
 
 var _ quickfix.Application = (*MarketDataValidator)(nil)
 
+// Configure sets the logger, the settings and the dictionaries of the
+// application.
+func (app *MarketDataValidator) Configure(options Options) *MarketDataValidator {
+	options.apply(&app.BaseApplication)
+	return app
+}
+
 // Stop ensures the app chans are emptied so that quickfix can carry on with
 // the LOGOUT process correctly.
 func (app *MarketDataValidator) Stop() {
 	app.Logger.Debug().Msgf("Stopping MarketDataValidator application")
+	app.Requests.Close()
 
 	app.mux.Lock()
 	defer app.mux.Unlock()
@@ -202,12 +228,21 @@ func (app *MarketDataValidator) OnCreate(sessionID quickfix.SessionID) {
 func (app *MarketDataValidator) OnLogon(sessionID quickfix.SessionID) {
 	app.Logger.Debug().Msgf("Logon: %s", sessionID)
 	metricMarketDataValidatorConnection.WithLabelValues(sessionID.String()).Set(1)
+	health.LoggedOn(sessionID)
+
+	app.mux.Lock()
+	app.sessionID = sessionID
+	app.loggedOn = true
+	app.mux.Unlock()
 
 	app.AppInfoChan <- "Connected"
 	go func() {
 		if err := app.subscribe(sessionID); err != nil {
 			app.Logger.Error().Err(err).Msgf("Error while subscribing")
-			panic(err)
+			select {
+			case app.Errors <- err:
+			default:
+			}
 		}
 	}()
 }
@@ -215,11 +250,18 @@ func (app *MarketDataValidator) OnLogon(sessionID quickfix.SessionID) {
 // Notification of a session logging off or disconnecting.
 func (app *MarketDataValidator) OnLogout(sessionID quickfix.SessionID) {
 	app.Logger.Debug().Msgf("Logout: %s", sessionID)
+	health.LoggedOut(sessionID)
 	if app.stopped {
 		return
 	}
 	metricMarketDataValidatorConnection.WithLabelValues(sessionID.String()).Set(0)
 
+	// Subscriptions end with the session
+	app.mux.Lock()
+	app.loggedOn = false
+	app.subscription = nil
+	app.mux.Unlock()
+
 	for security, _ := range app.Validator.secList {
 		cleanSecurityMetrics(security)
 		delete(app.Validator.secList, security)
@@ -233,50 +275,38 @@ func (app *MarketDataValidator) OnLogout(sessionID quickfix.SessionID) {
 
 // Notification of admin message being sent to target.
 func (app *MarketDataValidator) ToAdmin(message *quickfix.Message, sessionID quickfix.SessionID) {
-	typ, err := message.MsgType()
-	if err != nil {
-		app.Logger.Error().Msgf("Message type error: %s", err)
-	}
-
-	// Logon
-	if err == nil && typ == string(enum.MsgType_LOGON) {
-		sets := app.Settings.SessionSettings()
-		if session, ok := sets[sessionID]; ok {
-			if session.HasSetting("Username") {
-				username, err := session.Setting("Username")
-				if err == nil && len(username) > 0 {
-					app.Logger.Debug().Msg("Username injected in logon message")
-					message.Header.SetField(tag.Username, quickfix.FIXString(username))
-				}
-			}
-			if session.HasSetting("Password") {
-				password, err := session.Setting("Password")
-				if err == nil && len(password) > 0 {
-					app.Logger.Debug().Msg("Password injected in logon message")
-					message.Header.SetField(tag.Password, quickfix.FIXString(password))
-				}
-			}
-		}
-	}
+	app.PrepareLogon(message, sessionID)
 
 	app.LogMessage(zerolog.TraceLevel, message, sessionID, true)
+	app.latencies.ToAdmin(message, sessionID)
 }
 
 // Notification of admin message being received from target.
 func (app *MarketDataValidator) FromAdmin(message *quickfix.Message, sessionID quickfix.SessionID) quickfix.MessageRejectError {
 	app.LogMessage(zerolog.TraceLevel, message, sessionID, false)
+	app.latencies.FromAdmin(message, sessionID)
 	return nil
 }
 
 // Notification of app message being sent to target.
 func (app *MarketDataValidator) ToApp(message *quickfix.Message, sessionID quickfix.SessionID) error {
+	// Routing
+	if session, ok := app.Settings.SessionSettings()[sessionID]; ok {
+		utils.QuickFixMessagePartSetRouting(&message.Header, session)
+	}
+
 	app.LogMessage(zerolog.TraceLevel, message, sessionID, true)
+	app.Requests.Sent(message, sessionID)
+	app.latencies.ToApp(message, sessionID)
 	return nil
 }
 
 // Notification of app message being received from target.
 func (app *MarketDataValidator) FromApp(message *quickfix.Message, sessionID quickfix.SessionID) quickfix.MessageRejectError {
 	app.LogMessage(zerolog.TraceLevel, message, sessionID, false)
+	app.Requests.Received(message, sessionID)
+	app.latencies.FromApp(message, sessionID)
+	recordMarketData(app.Recorder, app.Logger, message, sessionID)
 
 	msgType, err := message.MsgType()
 	if err != nil {
@@ -289,7 +319,19 @@ func (app *MarketDataValidator) FromApp(message *quickfix.Message, sessionID qui
 		app.AppInfoChan <- "Received BusinessMessageReject"
 		return nil
 	case string(enum.MsgType_SECURITY_LIST):
-		app.SecurityListResponse <- message
+		// Only pass the whole response once all its fragments are received
+		whole, err := app.fragments.add(message, app.AppDataDictionary)
+		if err != nil {
+			app.Logger.Error().Msgf("Unable to reassemble security list: %s", err)
+			return nil
+		}
+		if whole == nil {
+			return nil
+		}
+		select {
+		case app.SecurityListResponse <- whole:
+		case <-app.ctx.Done():
+		}
 		return nil
 	case string(enum.MsgType_NEWS):
 		if txt, err := message.Body.GetString(tag.Text); err != nil {
@@ -305,6 +347,22 @@ func (app *MarketDataValidator) FromApp(message *quickfix.Message, sessionID qui
 	return app.router.Route(message, sessionID)
 }
 
+// Replay validates the recorded message as if it was received on the session,
+// the books of the symbols of the options being tracked from the first one on.
+// The FIXT messages without ApplVerID are taken to be of FIX 5.0 SP2, there
+// being no session to tell their version.
+func (app *MarketDataValidator) Replay(message *quickfix.Message, sessionID quickfix.SessionID) quickfix.MessageRejectError {
+	if len(app.Validator.secList) == 0 {
+		app.Validator.secList = createSecurityList(app.options.Symbols)
+	}
+
+	if beginString, err := message.Header.GetString(tag.BeginString); err == nil && beginString == quickfix.BeginStringFIXT11 && !message.Header.Has(tag.ApplVerID) {
+		message.Header.SetString(tag.ApplVerID, string(enum.ApplVerID_FIX50SP2))
+	}
+
+	return app.FromApp(message, sessionID)
+}
+
 func (app *MarketDataValidator) onMarketDataSnapshotFullRefresh(msg marketdatasnapshotfullrefresh.MarketDataSnapshotFullRefresh, sessionID quickfix.SessionID) quickfix.MessageRejectError {
 	app.Logger.Info().Msg("Received snapshot full refresh")
 
@@ -523,6 +581,10 @@ func (app *MarketDataValidator) onMarketDataIncrementalRefresh(msg marketdatainc
 }
 
 func (app *MarketDataValidator) subscribe(sessionId quickfix.SessionID) error {
+	if err := app.ctx.Err(); err != nil {
+		return err
+	}
+
 	// Prepare market data request
 	marketDataRequest, err := app.buildSubscriptionMessage(sessionId)
 	if err != nil {
@@ -530,14 +592,43 @@ func (app *MarketDataValidator) subscribe(sessionId quickfix.SessionID) error {
 	}
 
 	// Send the order
-	err = quickfix.SendToTarget(marketDataRequest, sessionId)
-	if err != nil {
-		panic(err)
+	if err := quickfix.SendToTarget(marketDataRequest, sessionId); err != nil {
+		return err
 	}
+
+	app.mux.Lock()
+	app.subscription = marketDataRequest
+	app.mux.Unlock()
+
 	return nil
 }
 
-func (app *MarketDataValidator) buildSubscriptionMessage(sessionId quickfix.SessionID) (quickfix.Messagable, error) {
+// Resubscribe cancels the market data subscription of the session, if logged
+// on, and subscribes again with a new MDReqID, the order books being rebuilt
+// from the snapshots received.
+func (app *MarketDataValidator) Resubscribe() error {
+	app.mux.RLock()
+	sessionID, loggedOn, subscription := app.sessionID, app.loggedOn, app.subscription
+	app.mux.RUnlock()
+
+	if !loggedOn {
+		return nil
+	}
+
+	if subscription != nil {
+		unsubscription := quickfix.NewMessage()
+		subscription.CopyInto(unsubscription)
+		unsubscription.Body.Set(field.NewSubscriptionRequestType(enum.SubscriptionRequestType_DISABLE_PREVIOUS_SNAPSHOT_PLUS_UPDATE_REQUEST))
+
+		if err := quickfix.SendToTarget(unsubscription, sessionID); err != nil {
+			return err
+		}
+	}
+
+	return app.subscribe(sessionID)
+}
+
+func (app *MarketDataValidator) buildSubscriptionMessage(sessionId quickfix.SessionID) (*quickfix.Message, error) {
 	mdReqID := field.NewMDReqID(uuid.NewString())
 	subReqType := field.NewSubscriptionRequestType(enum.SubscriptionRequestType_SNAPSHOT_PLUS_UPDATES)
 	marketDepth := field.NewMarketDepth(0)
@@ -601,6 +692,8 @@ func (app *MarketDataValidator) loadSymbolsFromFix(sessionId quickfix.SessionID)
 	var responseMessage *quickfix.Message
 
 	select {
+	case <-app.ctx.Done():
+		return nil, app.ctx.Err()
 	case <-time.After(app.timeout):
 		return nil, errors.ResponseTimeout
 	case responseMessage = <-app.SecurityListResponse:
@@ -7,45 +7,71 @@ import (
 
 	"github.com/quickfixgo/enum"
 	"github.com/quickfixgo/quickfix"
-	"github.com/quickfixgo/tag"
 
 	"sylr.dev/fix/pkg/dict"
+	"sylr.dev/fix/pkg/positions"
 	"sylr.dev/fix/pkg/utils"
 )
 
 func NewNewOrder() *NewOrder {
 	sod := NewOrder{
 		Connected:       make(chan quickfix.SessionID),
+		Disconnected:    make(chan quickfix.SessionID, 1),
 		FromAppMessages: make(chan *quickfix.Message, 1),
+		Orders:          NewOrderStateMachine(),
 	}
 
 	return &sod
 }
 
 type NewOrder struct {
-	utils.QuickFixAppMessageLogger
+	utils.BaseApplication
 
-	Settings        *quickfix.Settings
 	Connected       chan quickfix.SessionID
 	FromAppMessages chan *quickfix.Message
 	stopped         bool
 	mux             sync.RWMutex
+	latencies       latencies
+
+	// Resumable keeps the chans open when the session drops, which is then
+	// notified through Disconnected, so that the command can reconnect.
+	Resumable    bool
+	Disconnected chan quickfix.SessionID
+
+	// Orders follows the lifecycle of the orders sent and received.
+	Orders *OrderStateMachine
+	// Transitions, when set, receives the transitions of the orders caused by
+	// the messages received, before the messages are sent on FromAppMessages.
+	Transitions chan OrderTransition
+	// Positions, when set, nets the fills of the execution reports received.
+	Positions *positions.Book
+}
+
+// Configure sets the logger, the settings and the dictionaries of the
+// application.
+func (app *NewOrder) Configure(options Options) *NewOrder {
+	options.apply(&app.BaseApplication)
+	return app
 }
 
 // Stop ensures the app chans are emptied so that quickfix can carry on with
 // the LOGOUT process correctly.
 func (app *NewOrder) Stop() {
 	app.Logger.Debug().Msgf("Stopping NewOrder application")
+	app.Requests.Close()
 
 	app.mux.Lock()
 	defer app.mux.Unlock()
 
 	app.stopped = true
 
-	// Empty the channel to avoid blocking
+	// Empty the channels to avoid blocking
 	for len(app.FromAppMessages) > 0 {
 		<-app.FromAppMessages
 	}
+	for len(app.Transitions) > 0 {
+		<-app.Transitions
+	}
 }
 
 // Notification of a session begin created.
@@ -64,56 +90,66 @@ func (app *NewOrder) OnLogon(sessionID quickfix.SessionID) {
 func (app *NewOrder) OnLogout(sessionID quickfix.SessionID) {
 	app.Logger.Debug().Msgf("Logout: %s", sessionID)
 
+	if app.Resumable {
+		select {
+		case app.Disconnected <- sessionID:
+		default:
+		}
+		return
+	}
+
 	close(app.Connected)
 	close(app.FromAppMessages)
 }
 
 // Notification of admin message being sent to target.
 func (app *NewOrder) ToAdmin(message *quickfix.Message, sessionID quickfix.SessionID) {
-	typ, err := message.MsgType()
-	if err != nil {
-		app.Logger.Error().Msgf("Message type error: %s", err)
-	}
-
-	// Logon
-	if err == nil && typ == string(enum.MsgType_LOGON) {
-		sets := app.Settings.SessionSettings()
-		if session, ok := sets[sessionID]; ok {
-			if session.HasSetting("Username") {
-				username, err := session.Setting("Username")
-				if err == nil && len(username) > 0 {
-					app.Logger.Debug().Msg("Username injected in logon message")
-					message.Header.SetField(tag.Username, quickfix.FIXString(username))
-				}
-			}
-			if session.HasSetting("Password") {
-				password, err := session.Setting("Password")
-				if err == nil && len(password) > 0 {
-					app.Logger.Debug().Msg("Password injected in logon message")
-					message.Header.SetField(tag.Password, quickfix.FIXString(password))
-				}
-			}
-		}
-	}
+	app.PrepareLogon(message, sessionID)
 
 	app.LogMessage(zerolog.TraceLevel, message, sessionID, true)
+	app.latencies.ToAdmin(message, sessionID)
 }
 
 // Notification of admin message being received from target.
 func (app *NewOrder) FromAdmin(message *quickfix.Message, sessionID quickfix.SessionID) quickfix.MessageRejectError {
 	app.LogMessage(zerolog.TraceLevel, message, sessionID, false)
+	app.latencies.FromAdmin(message, sessionID)
+
+	if typ, _ := message.MsgType(); typ != string(enum.MsgType_REJECT) {
+		return nil
+	}
+
+	app.mux.RLock()
+	if app.stopped {
+		app.mux.RUnlock()
+		return nil
+	}
+	app.mux.RUnlock()
+
+	app.FromAppMessages <- message
+
 	return nil
 }
 
 // Notification of app message being sent to target.
 func (app *NewOrder) ToApp(message *quickfix.Message, sessionID quickfix.SessionID) error {
+	// Routing
+	if session, ok := app.Settings.SessionSettings()[sessionID]; ok {
+		utils.QuickFixMessagePartSetRouting(&message.Header, session)
+	}
+
 	app.LogMessage(zerolog.TraceLevel, message, sessionID, true)
+	app.Requests.Sent(message, sessionID)
+	app.latencies.ToApp(message, sessionID)
+	app.Orders.Sent(message)
 	return nil
 }
 
 // Notification of app message being received from target.
 func (app *NewOrder) FromApp(message *quickfix.Message, sessionID quickfix.SessionID) quickfix.MessageRejectError {
 	app.LogMessage(zerolog.TraceLevel, message, sessionID, false)
+	app.Requests.Received(message, sessionID)
+	app.latencies.FromApp(message, sessionID)
 
 	typ, err := message.MsgType()
 	if err != nil {
@@ -129,10 +165,15 @@ func (app *NewOrder) FromApp(message *quickfix.Message, sessionID quickfix.Sessi
 
 	switch enum.MsgType(typ) {
 	case enum.MsgType_EXECUTION_REPORT:
+		app.Positions.OnExecutionReport(message)
+		app.transition(message)
 		app.FromAppMessages <- message
 	case enum.MsgType_QUOTE_STATUS_REPORT:
 		app.FromAppMessages <- message
 	case enum.MsgType_ORDER_CANCEL_REJECT:
+		app.transition(message)
+		app.FromAppMessages <- message
+	case enum.MsgType_BUSINESS_MESSAGE_REJECT:
 		app.FromAppMessages <- message
 	default:
 		typName, err := dict.SearchValue(dict.MessageTypes, enum.MsgType(typ))
@@ -145,3 +186,17 @@ func (app *NewOrder) FromApp(message *quickfix.Message, sessionID quickfix.Sessi
 
 	return nil
 }
+
+// transition applies the message received to the state of its order.
+func (app *NewOrder) transition(message *quickfix.Message) {
+	t, ok := app.Orders.Received(message)
+	if !ok {
+		return
+	}
+
+	app.Logger.Debug().Msgf("Order %s: %s -> %s", t.ClOrdID, t.From, t.To)
+
+	if app.Transitions != nil {
+		app.Transitions <- t
+	}
+}
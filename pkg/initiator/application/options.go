@@ -0,0 +1,53 @@
+package application
+
+import (
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/quickfix/datadictionary"
+	"github.com/rs/zerolog"
+
+	"sylr.dev/fix/pkg/utils"
+)
+
+// App is a quickfix application of an initiator which must be stopped once
+// the caller is done reading its chans.
+type App interface {
+	quickfix.Application
+
+	// Stop empties the chans of the application so that quickfix can carry
+	// on with the logout.
+	Stop()
+}
+
+var (
+	_ App = (*Initiator)(nil)
+	_ App = (*NewOrder)(nil)
+	_ App = (*CancelOrder)(nil)
+	_ App = (*MarketDataRequest)(nil)
+	_ App = (*SecurityList)(nil)
+	_ App = (*SecurityStatusRequest)(nil)
+	_ App = (*TradingSessionStatusRequest)(nil)
+	_ App = (*Fuzz)(nil)
+)
+
+// Options holds what every application needs. Messages are logged with
+// Logger, a disabled logger being used if nil, and decoded with the
+// dictionaries when written with OutputFormat.
+type Options struct {
+	Logger                  *zerolog.Logger
+	Settings                *quickfix.Settings
+	TransportDataDictionary *datadictionary.DataDictionary
+	AppDataDictionary       *datadictionary.DataDictionary
+	OutputFormat            string
+}
+
+func (o Options) apply(app *utils.BaseApplication) {
+	app.Logger = o.Logger
+	if app.Logger == nil {
+		nop := zerolog.Nop()
+		app.Logger = &nop
+	}
+	app.TransportDataDictionary = o.TransportDataDictionary
+	app.AppDataDictionary = o.AppDataDictionary
+	app.OutputFormat = o.OutputFormat
+	app.Settings = o.Settings
+}
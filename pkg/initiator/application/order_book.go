@@ -0,0 +1,390 @@
+package application
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/quickfixgo/enum"
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/quickfix/datadictionary"
+	"github.com/quickfixgo/tag"
+	"github.com/shopspring/decimal"
+
+	"sylr.dev/fix/pkg/utils"
+)
+
+var mdEntriesGroupTemplate = quickfix.GroupTemplate{
+	quickfix.GroupElement(tag.MDUpdateAction),
+	quickfix.GroupElement(tag.MDEntryType),
+	quickfix.GroupElement(tag.MDEntryID),
+	quickfix.GroupElement(tag.Symbol),
+	quickfix.GroupElement(tag.MDEntryPx),
+	quickfix.GroupElement(tag.MDEntrySize),
+	quickfix.GroupElement(tag.MDEntryDate),
+	quickfix.GroupElement(tag.MDEntryTime),
+	quickfix.GroupElement(tag.TradeCondition),
+	quickfix.GroupElement(tag.OrderID),
+	quickfix.GroupElement(tag.TradeID),
+	quickfix.GroupElement(tag.OrdType),
+	quickfix.GroupElement(tag.OpenCloseSettlFlag),
+	quickfix.GroupElement(tag.Text),
+}
+
+// MDEntriesGroupTemplate returns the template of the NoMDEntries group of the
+// market data refresh messages, the one of the application data dictionary
+// when it defines it.
+func MDEntriesGroupTemplate(appDict *datadictionary.DataDictionary, msgType string) quickfix.GroupTemplate {
+	if appDict != nil {
+		if msgDef, ok := appDict.Messages[msgType]; ok {
+			if def, ok := msgDef.Fields[int(tag.NoMDEntries)]; ok && def.IsGroup() {
+				return utils.QuickFixGroupTemplate(def)
+			}
+		}
+	}
+
+	// The entries of the snapshots start with their MDEntryType
+	if msgType == string(enum.MsgType_MARKET_DATA_SNAPSHOT_FULL_REFRESH) {
+		return mdEntriesGroupTemplate[1:]
+	}
+
+	return mdEntriesGroupTemplate
+}
+
+// BookLevel is a price level of a side of an order book.
+type BookLevel struct {
+	Price decimal.Decimal
+	Size  decimal.Decimal
+	// Orders is the number of entries of the level, 1 for the feeds sending
+	// price levels rather than orders.
+	Orders int
+}
+
+// BookTrade is the last trade of a symbol.
+type BookTrade struct {
+	Price decimal.Decimal
+	Size  decimal.Decimal
+	Time  time.Time
+}
+
+// BookView is the state of the order book of a symbol at a point in time.
+type BookView struct {
+	Symbol    string
+	Bids      []BookLevel
+	Offers    []BookLevel
+	LastTrade *BookTrade
+	Updated   time.Time
+}
+
+// Spread returns the difference between the best offer and the best bid, false
+// when a side is empty.
+func (v BookView) Spread() (decimal.Decimal, bool) {
+	if len(v.Bids) == 0 || len(v.Offers) == 0 {
+		return decimal.Zero, false
+	}
+
+	return v.Offers[0].Price.Sub(v.Bids[0].Price), true
+}
+
+// bookEntry is a bid or an offer of a symbol book.
+type bookEntry struct {
+	side  enum.MDEntryType
+	price decimal.Decimal
+	size  decimal.Decimal
+}
+
+type symbolBook struct {
+	// entries are keyed by their OrderID or MDEntryID, or by their side and
+	// price for the feeds sending price levels.
+	entries   map[string]bookEntry
+	lastTrade *BookTrade
+	updated   time.Time
+}
+
+// OrderBook maintains the order books of the symbols out of the market data
+// snapshots and incremental refreshes received.
+type OrderBook struct {
+	AppDataDictionary *datadictionary.DataDictionary
+
+	mux   sync.RWMutex
+	books map[string]*symbolBook
+}
+
+func NewOrderBook() *OrderBook {
+	return &OrderBook{
+		books: make(map[string]*symbolBook),
+	}
+}
+
+// Apply updates the order books with the MarketDataSnapshotFullRefresh or the
+// MarketDataIncrementalRefresh message, a snapshot replacing the book of its
+// symbol. Other messages are ignored.
+func (b *OrderBook) Apply(msg *quickfix.Message) error {
+	msgType, err := msg.MsgType()
+	if err != nil {
+		return err
+	}
+
+	snapshot := false
+	switch enum.MsgType(msgType) {
+	case enum.MsgType_MARKET_DATA_SNAPSHOT_FULL_REFRESH:
+		snapshot = true
+	case enum.MsgType_MARKET_DATA_INCREMENTAL_REFRESH:
+	default:
+		return nil
+	}
+
+	group := quickfix.NewRepeatingGroup(tag.NoMDEntries, MDEntriesGroupTemplate(b.AppDataDictionary, msgType))
+	if err := msg.Body.GetGroup(group); err != nil {
+		return err
+	}
+
+	symbol, _ := msg.Body.GetString(tag.Symbol)
+
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	now := time.Now()
+
+	if snapshot {
+		b.books[symbol] = &symbolBook{entries: make(map[string]bookEntry)}
+	}
+
+	// The entries without Symbol are of the symbol of the previous one
+	entrySymbol := symbol
+	for i := 0; i < group.Len(); i++ {
+		entry := group.Get(i)
+
+		if s, err := entry.GetString(tag.Symbol); err == nil {
+			entrySymbol = s
+		}
+
+		book, ok := b.books[entrySymbol]
+		if !ok {
+			book = &symbolBook{entries: make(map[string]bookEntry)}
+			b.books[entrySymbol] = book
+		}
+		book.updated = now
+
+		action := enum.MDUpdateAction_NEW
+		if !snapshot {
+			if a, err := entry.GetString(tag.MDUpdateAction); err == nil {
+				action = enum.MDUpdateAction(a)
+			}
+		}
+
+		entryType, err := entry.GetString(tag.MDEntryType)
+		if err != nil && action != enum.MDUpdateAction_DELETE {
+			continue
+		}
+
+		price, _ := decimalField(entry, tag.MDEntryPx)
+		size, _ := decimalField(entry, tag.MDEntrySize)
+
+		switch enum.MDEntryType(entryType) {
+		case enum.MDEntryType_TRADE:
+			if action == enum.MDUpdateAction_DELETE {
+				continue
+			}
+			book.lastTrade = &BookTrade{Price: price, Size: size, Time: entryTime(entry, now)}
+			continue
+		case enum.MDEntryType_BID, enum.MDEntryType_OFFER:
+		default:
+			if action != enum.MDUpdateAction_DELETE {
+				continue
+			}
+		}
+
+		key := entryKey(entry, enum.MDEntryType(entryType), price)
+
+		switch action {
+		case enum.MDUpdateAction_NEW, enum.MDUpdateAction_CHANGE, enum.MDUpdateAction_OVERLAY:
+			if previous, ok := book.entries[key]; ok && action == enum.MDUpdateAction_CHANGE && !entry.Has(tag.MDEntryPx) {
+				price = previous.price
+			}
+			book.entries[key] = bookEntry{side: enum.MDEntryType(entryType), price: price, size: size}
+		case enum.MDUpdateAction_DELETE:
+			delete(book.entries, key)
+		}
+	}
+
+	return nil
+}
+
+// Symbols returns the sorted symbols of the order books.
+func (b *OrderBook) Symbols() []string {
+	b.mux.RLock()
+	defer b.mux.RUnlock()
+
+	symbols := make([]string, 0, len(b.books))
+	for symbol := range b.books {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	return symbols
+}
+
+// View returns the order book of the symbol, its depth best levels of each side
+// (all of them when depth is 0), false when no market data was received for it.
+func (b *OrderBook) View(symbol string, depth int) (BookView, bool) {
+	b.mux.RLock()
+	defer b.mux.RUnlock()
+
+	book, ok := b.books[symbol]
+	if !ok {
+		return BookView{Symbol: symbol}, false
+	}
+
+	levels := map[enum.MDEntryType]map[string]*BookLevel{
+		enum.MDEntryType_BID:   {},
+		enum.MDEntryType_OFFER: {},
+	}
+	for _, entry := range book.entries {
+		price := entry.price.String()
+		level, ok := levels[entry.side][price]
+		if !ok {
+			level = &BookLevel{Price: entry.price}
+			levels[entry.side][price] = level
+		}
+		level.Size = level.Size.Add(entry.size)
+		level.Orders++
+	}
+
+	view := BookView{
+		Symbol:  symbol,
+		Bids:    sortedLevels(levels[enum.MDEntryType_BID], true, depth),
+		Offers:  sortedLevels(levels[enum.MDEntryType_OFFER], false, depth),
+		Updated: book.updated,
+	}
+	if book.lastTrade != nil {
+		trade := *book.lastTrade
+		view.LastTrade = &trade
+	}
+
+	return view, true
+}
+
+func sortedLevels(levels map[string]*BookLevel, descending bool, depth int) []BookLevel {
+	sorted := make([]BookLevel, 0, len(levels))
+	for _, level := range levels {
+		sorted = append(sorted, *level)
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		if descending {
+			return sorted[i].Price.GreaterThan(sorted[j].Price)
+		}
+		return sorted[i].Price.LessThan(sorted[j].Price)
+	})
+
+	if depth > 0 && len(sorted) > depth {
+		sorted = sorted[:depth]
+	}
+
+	return sorted
+}
+
+// entryKey returns the key of the entry in its symbol book: its OrderID or its
+// MDEntryID, else its side and price.
+func entryKey(entry *quickfix.Group, side enum.MDEntryType, price decimal.Decimal) string {
+	if id, err := entry.GetString(tag.OrderID); err == nil && len(id) > 0 {
+		return "order:" + id
+	}
+	if id, err := entry.GetString(tag.MDEntryID); err == nil && len(id) > 0 {
+		return "entry:" + id
+	}
+
+	return "level:" + string(side) + ":" + price.String()
+}
+
+func decimalField(entry *quickfix.Group, t quickfix.Tag) (decimal.Decimal, error) {
+	value, rerr := entry.GetString(t)
+	if rerr != nil {
+		return decimal.Zero, rerr
+	}
+
+	return decimal.NewFromString(value)
+}
+
+// entryTime returns the MDEntryDate and MDEntryTime of the entry, now when it
+// has none.
+func entryTime(entry *quickfix.Group, now time.Time) time.Time {
+	stringTime, err := entry.GetString(tag.MDEntryTime)
+	if err != nil {
+		return now
+	}
+
+	t, perr := time.Parse("15:04:05.999999999", stringTime)
+	if perr != nil {
+		return now
+	}
+
+	date := now.UTC()
+	if stringDate, err := entry.GetString(tag.MDEntryDate); err == nil {
+		if d, err := time.Parse("20060102", stringDate); err == nil {
+			date = d
+		}
+	}
+
+	return utils.CombineDateAndTime(date, t)
+}
+
+// Render writes the order books as tables of their depth best levels of each
+// side (all of them when depth is 0), with their spread and last trade.
+func (b *OrderBook) Render(w io.Writer, depth int) {
+	for i, symbol := range b.Symbols() {
+		view, _ := b.View(symbol, depth)
+
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+
+		fmt.Fprintf(w, "%s", symbol)
+		if spread, ok := view.Spread(); ok {
+			fmt.Fprintf(w, " - spread %s", b.price(symbol, spread))
+		}
+		if trade := view.LastTrade; trade != nil {
+			fmt.Fprintf(w, " - last trade %s @ %s at %s", b.size(symbol, trade.Size), b.price(symbol, trade.Price), utils.FormatTimeOnly(trade.Time))
+		}
+		fmt.Fprintf(w, " - updated at %s\n", utils.FormatTimeOnly(view.Updated))
+
+		table := tablewriter.NewWriter(w)
+		table.SetHeader([]string{"ORDERS", "BID SIZE", "BID", "OFFER", "OFFER SIZE", "ORDERS"})
+		table.SetBorders(tablewriter.Border{Left: false, Top: false, Right: false, Bottom: true})
+		table.SetColumnSeparator(" ")
+		table.SetCenterSeparator("-")
+		table.SetAutoWrapText(false)
+		table.SetAlignment(tablewriter.ALIGN_RIGHT)
+
+		for j := 0; j < len(view.Bids) || j < len(view.Offers); j++ {
+			row := make([]string, 6)
+			if j < len(view.Bids) {
+				bid := view.Bids[j]
+				row[0] = strconv.Itoa(bid.Orders)
+				row[1] = b.size(symbol, bid.Size)
+				row[2] = b.price(symbol, bid.Price)
+			}
+			if j < len(view.Offers) {
+				offer := view.Offers[j]
+				row[3] = b.price(symbol, offer.Price)
+				row[4] = b.size(symbol, offer.Size)
+				row[5] = strconv.Itoa(offer.Orders)
+			}
+			table.Append(row)
+		}
+		table.Render()
+	}
+}
+
+func (b *OrderBook) price(symbol string, value decimal.Decimal) string {
+	return utils.QuickFixFormatDecimal(b.AppDataDictionary, tag.MDEntryPx, symbol, value.String())
+}
+
+func (b *OrderBook) size(symbol string, value decimal.Decimal) string {
+	return utils.QuickFixFormatDecimal(b.AppDataDictionary, tag.MDEntrySize, symbol, value.String())
+}
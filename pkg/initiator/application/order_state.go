@@ -0,0 +1,303 @@
+package application
+
+import (
+	"sync"
+
+	"github.com/quickfixgo/enum"
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/tag"
+	"github.com/shopspring/decimal"
+
+	"sylr.dev/fix/pkg/ordertracker"
+)
+
+// OrderState is the state of an order in its lifecycle.
+type OrderState string
+
+const (
+	OrderStatePendingNew      OrderState = "PendingNew"
+	OrderStateNew             OrderState = "New"
+	OrderStatePartiallyFilled OrderState = "PartiallyFilled"
+	OrderStatePendingCancel   OrderState = "PendingCancel"
+	OrderStatePendingReplace  OrderState = "PendingReplace"
+	OrderStateFilled          OrderState = "Filled"
+	OrderStateCanceled        OrderState = "Canceled"
+	OrderStateRejected        OrderState = "Rejected"
+	OrderStateExpired         OrderState = "Expired"
+	OrderStateDoneForDay      OrderState = "DoneForDay"
+)
+
+// rank orders the states along the lifecycle, reports leading to a lower
+// rank than the one of the current state being late.
+func (s OrderState) rank() int {
+	switch s {
+	case OrderStatePendingNew:
+		return 0
+	case OrderStateNew, OrderStatePendingCancel, OrderStatePendingReplace:
+		return 1
+	case OrderStatePartiallyFilled:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// Final tells whether the order can no longer change.
+func (s OrderState) Final() bool {
+	return s.rank() == 3
+}
+
+// pending tells whether the state waits for the answer to a cancel or
+// replace request.
+func (s OrderState) pending() bool {
+	return s == OrderStatePendingCancel || s == OrderStatePendingReplace
+}
+
+// OrderStateFromOrdStatus returns the state of an order with the given
+// OrdStatus, the working ones with filled quantity being partially filled.
+func OrderStateFromOrdStatus(status enum.OrdStatus, cumQty decimal.Decimal) OrderState {
+	switch status {
+	case enum.OrdStatus_PENDING_NEW:
+		return OrderStatePendingNew
+	case enum.OrdStatus_PARTIALLY_FILLED:
+		return OrderStatePartiallyFilled
+	case enum.OrdStatus_FILLED:
+		return OrderStateFilled
+	case enum.OrdStatus_PENDING_CANCEL:
+		return OrderStatePendingCancel
+	case enum.OrdStatus_PENDING_REPLACE:
+		return OrderStatePendingReplace
+	case enum.OrdStatus_CANCELED:
+		return OrderStateCanceled
+	case enum.OrdStatus_REJECTED:
+		return OrderStateRejected
+	case enum.OrdStatus_EXPIRED:
+		return OrderStateExpired
+	case enum.OrdStatus_DONE_FOR_DAY:
+		return OrderStateDoneForDay
+	}
+
+	// New, Replaced, Stopped, Suspended, Calculated, AcceptedForBidding
+	if cumQty.IsPositive() {
+		return OrderStatePartiallyFilled
+	}
+
+	return OrderStateNew
+}
+
+// TrackedOrderState returns the state of an order recorded by the order
+// tracker, empty when no execution report has been received for it.
+func TrackedOrderState(order *ordertracker.Order) OrderState {
+	if len(order.OrdStatus) == 0 {
+		return ""
+	}
+
+	cumQty, _ := decimal.NewFromString(order.CumQty)
+
+	return OrderStateFromOrdStatus(enum.OrdStatus(order.OrdStatus), cumQty)
+}
+
+// OrderLifecycle is the state of an order followed by an OrderStateMachine.
+type OrderLifecycle struct {
+	ClOrdID   string
+	OrderID   string
+	State     OrderState
+	CumQty    decimal.Decimal
+	LeavesQty decimal.Decimal
+
+	// beforePending is the state to go back to when the cancel or replace
+	// request is rejected
+	beforePending OrderState
+}
+
+// OrderTransition is a change of the state of an order, or a new fill when
+// From and To are both PartiallyFilled.
+type OrderTransition struct {
+	ClOrdID string
+	OrderID string
+	From    OrderState
+	To      OrderState
+	CumQty  decimal.Decimal
+	// Message is the message which led to the transition, nil for the
+	// requests sent
+	Message *quickfix.Message
+}
+
+// OrderStateMachine follows the lifecycle of orders, keyed by ClOrdID, from
+// the requests sent and the execution reports and cancel rejects received.
+//
+// Execution reports may be received out of order, a late report, whose CumQty
+// is lower than the one of the order or whose state comes before the current
+// one, never moves an order backwards. Final states are never left.
+type OrderStateMachine struct {
+	orders map[string]*OrderLifecycle
+	mux    sync.RWMutex
+}
+
+// NewOrderStateMachine returns an empty OrderStateMachine.
+func NewOrderStateMachine() *OrderStateMachine {
+	return &OrderStateMachine{
+		orders: make(map[string]*OrderLifecycle),
+	}
+}
+
+// Get returns a copy of the lifecycle of the order.
+func (m *OrderStateMachine) Get(clOrdID string) (OrderLifecycle, bool) {
+	m.mux.RLock()
+	defer m.mux.RUnlock()
+
+	order, ok := m.orders[clOrdID]
+	if !ok {
+		return OrderLifecycle{}, false
+	}
+
+	return *order, true
+}
+
+// Sent follows the order of a NewOrderSingle, OrderCancelRequest or
+// OrderCancelReplaceRequest sent.
+func (m *OrderStateMachine) Sent(message *quickfix.Message) (OrderTransition, bool) {
+	msgType, _ := message.MsgType()
+	clOrdID, _ := message.Body.GetString(tag.ClOrdID)
+	origClOrdID, _ := message.Body.GetString(tag.OrigClOrdID)
+
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	switch enum.MsgType(msgType) {
+	case enum.MsgType_ORDER_SINGLE:
+		if _, ok := m.orders[clOrdID]; ok || len(clOrdID) == 0 {
+			return OrderTransition{}, false
+		}
+		m.orders[clOrdID] = &OrderLifecycle{ClOrdID: clOrdID, State: OrderStatePendingNew}
+
+		return OrderTransition{ClOrdID: clOrdID, To: OrderStatePendingNew}, true
+
+	case enum.MsgType_ORDER_CANCEL_REQUEST, enum.MsgType_ORDER_CANCEL_REPLACE_REQUEST:
+		order, ok := m.orders[origClOrdID]
+		if !ok || order.State.Final() {
+			return OrderTransition{}, false
+		}
+
+		// The request is answered with its ClOrdID
+		if len(clOrdID) > 0 {
+			m.orders[clOrdID] = order
+		}
+
+		to := OrderStatePendingCancel
+		if enum.MsgType(msgType) == enum.MsgType_ORDER_CANCEL_REPLACE_REQUEST {
+			to = OrderStatePendingReplace
+		}
+
+		return m.transition(order, to, order.CumQty, nil), true
+	}
+
+	return OrderTransition{}, false
+}
+
+// Received applies an ExecutionReport or an OrderCancelReject to the order it
+// refers to, orders not sent by the application being followed from then on.
+// It returns the transition of the order, if any.
+func (m *OrderStateMachine) Received(message *quickfix.Message) (OrderTransition, bool) {
+	msgType, _ := message.MsgType()
+	clOrdID, _ := message.Body.GetString(tag.ClOrdID)
+	origClOrdID, _ := message.Body.GetString(tag.OrigClOrdID)
+	orderID, _ := message.Body.GetString(tag.OrderID)
+	ordStatus, _ := message.Body.GetString(tag.OrdStatus)
+
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	order, ok := m.orders[clOrdID]
+	if !ok {
+		order, ok = m.orders[origClOrdID]
+	}
+
+	switch enum.MsgType(msgType) {
+	case enum.MsgType_ORDER_CANCEL_REJECT:
+		if !ok || !order.State.pending() {
+			return OrderTransition{}, false
+		}
+
+		to := order.beforePending
+		if len(ordStatus) > 0 {
+			to = OrderStateFromOrdStatus(enum.OrdStatus(ordStatus), order.CumQty)
+		}
+
+		return m.transition(order, to, order.CumQty, message), true
+
+	case enum.MsgType_EXECUTION_REPORT:
+		if len(ordStatus) == 0 {
+			return OrderTransition{}, false
+		}
+
+		if !ok {
+			if len(clOrdID) == 0 {
+				return OrderTransition{}, false
+			}
+			order = &OrderLifecycle{ClOrdID: clOrdID, State: OrderStatePendingNew}
+			m.orders[clOrdID] = order
+		} else if len(clOrdID) > 0 {
+			// Replaced orders are answered with the new ClOrdID
+			m.orders[clOrdID] = order
+		}
+
+		if len(orderID) > 0 {
+			order.OrderID = orderID
+		}
+
+		cumQty := order.CumQty
+		if value, err := message.Body.GetString(tag.CumQty); err == nil {
+			if qty, err := decimal.NewFromString(value); err == nil {
+				cumQty = qty
+			}
+		}
+		if value, err := message.Body.GetString(tag.LeavesQty); err == nil && !cumQty.LessThan(order.CumQty) {
+			if qty, err := decimal.NewFromString(value); err == nil {
+				order.LeavesQty = qty
+			}
+		}
+
+		to := OrderStateFromOrdStatus(enum.OrdStatus(ordStatus), cumQty)
+
+		if order.State.Final() || cumQty.LessThan(order.CumQty) {
+			return OrderTransition{}, false
+		}
+
+		current := order.State
+		if current.pending() {
+			current = order.beforePending
+		}
+		if !to.pending() && to.rank() < current.rank() {
+			return OrderTransition{}, false
+		}
+
+		if to == order.State && cumQty.Equal(order.CumQty) {
+			return OrderTransition{}, false
+		}
+
+		return m.transition(order, to, cumQty, message), true
+	}
+
+	return OrderTransition{}, false
+}
+
+func (m *OrderStateMachine) transition(order *OrderLifecycle, to OrderState, cumQty decimal.Decimal, message *quickfix.Message) OrderTransition {
+	t := OrderTransition{
+		ClOrdID: order.ClOrdID,
+		OrderID: order.OrderID,
+		From:    order.State,
+		To:      to,
+		CumQty:  cumQty,
+		Message: message,
+	}
+
+	if to.pending() && !order.State.pending() {
+		order.beforePending = order.State
+	}
+
+	order.State = to
+	order.CumQty = cumQty
+
+	return t
+}
@@ -7,7 +7,6 @@ import (
 	"github.com/quickfixgo/field"
 	"github.com/quickfixgo/fixt11"
 	"github.com/quickfixgo/quickfix"
-	"github.com/quickfixgo/tag"
 	"github.com/rs/zerolog"
 
 	"sylr.dev/fix/pkg/dict"
@@ -24,19 +23,28 @@ func NewSecurityList() *SecurityList {
 }
 
 type SecurityList struct {
-	utils.QuickFixAppMessageLogger
+	utils.BaseApplication
 
-	Settings        *quickfix.Settings
 	Connected       chan quickfix.SessionID
 	FromAppMessages chan *quickfix.Message
 	stopped         bool
 	mux             sync.RWMutex
+	latencies       latencies
+	fragments       securityListFragments
+}
+
+// Configure sets the logger, the settings and the dictionaries of the
+// application.
+func (app *SecurityList) Configure(options Options) *SecurityList {
+	options.apply(&app.BaseApplication)
+	return app
 }
 
 // Stop ensures the app chans are emptied so that quickfix can carry on with
 // the LOGOUT process correctly.
 func (app *SecurityList) Stop() {
 	app.Logger.Debug().Msgf("Stopping SecurityList application")
+	app.Requests.Close()
 
 	app.mux.Lock()
 	defer app.mux.Unlock()
@@ -71,38 +79,16 @@ func (app *SecurityList) OnLogout(sessionID quickfix.SessionID) {
 
 // Notification of admin message being sent to target.
 func (app *SecurityList) ToAdmin(message *quickfix.Message, sessionID quickfix.SessionID) {
-	typ, err := message.MsgType()
-	if err != nil {
-		app.Logger.Error().Msgf("Message type error: %s", err)
-	}
-
-	// Logon
-	if err == nil && typ == string(enum.MsgType_LOGON) {
-		sets := app.Settings.SessionSettings()
-		if session, ok := sets[sessionID]; ok {
-			if session.HasSetting("Username") {
-				username, err := session.Setting("Username")
-				if err == nil && len(username) > 0 {
-					app.Logger.Debug().Msg("Username injected in logon message")
-					message.Header.SetField(tag.Username, quickfix.FIXString(username))
-				}
-			}
-			if session.HasSetting("Password") {
-				password, err := session.Setting("Password")
-				if err == nil && len(password) > 0 {
-					app.Logger.Debug().Msg("Password injected in logon message")
-					message.Header.SetField(tag.Password, quickfix.FIXString(password))
-				}
-			}
-		}
-	}
+	app.PrepareLogon(message, sessionID)
 
 	app.LogMessage(zerolog.TraceLevel, message, sessionID, true)
+	app.latencies.ToAdmin(message, sessionID)
 }
 
 // Notification of admin message being received from target.
 func (app *SecurityList) FromAdmin(message *quickfix.Message, sessionID quickfix.SessionID) quickfix.MessageRejectError {
 	app.LogMessage(zerolog.TraceLevel, message, sessionID, false)
+	app.latencies.FromAdmin(message, sessionID)
 
 	typ, err := message.MsgType()
 	if err != nil {
@@ -126,13 +112,22 @@ func (app *SecurityList) FromAdmin(message *quickfix.Message, sessionID quickfix
 
 // Notification of app message being sent to target.
 func (app *SecurityList) ToApp(message *quickfix.Message, sessionID quickfix.SessionID) error {
+	// Routing
+	if session, ok := app.Settings.SessionSettings()[sessionID]; ok {
+		utils.QuickFixMessagePartSetRouting(&message.Header, session)
+	}
+
 	app.LogMessage(zerolog.TraceLevel, message, sessionID, true)
+	app.Requests.Sent(message, sessionID)
+	app.latencies.ToApp(message, sessionID)
 	return nil
 }
 
 // Notification of app message being received from target.
 func (app *SecurityList) FromApp(message *quickfix.Message, sessionID quickfix.SessionID) quickfix.MessageRejectError {
 	app.LogMessage(zerolog.TraceLevel, message, sessionID, false)
+	app.Requests.Received(message, sessionID)
+	app.latencies.FromApp(message, sessionID)
 
 	typ, err := message.MsgType()
 	if err != nil {
@@ -148,6 +143,14 @@ func (app *SecurityList) FromApp(message *quickfix.Message, sessionID quickfix.S
 
 	switch enum.MsgType(typ) {
 	case enum.MsgType_SECURITY_LIST:
+		// Only pass the whole response once all its fragments are received
+		whole, err := app.fragments.add(message, app.AppDataDictionary)
+		if err != nil {
+			app.Logger.Error().Msgf("Unable to reassemble security list: %s", err)
+		} else if whole != nil {
+			app.FromAppMessages <- whole
+		}
+	case enum.MsgType_SECURITY_LIST_UPDATE_REPORT:
 		app.FromAppMessages <- message
 	default:
 		typName, err := dict.SearchValue(dict.MessageTypes, enum.MsgType(typ))
@@ -0,0 +1,76 @@
+package application
+
+import (
+	"sync"
+
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/quickfix/datadictionary"
+	"github.com/quickfixgo/tag"
+)
+
+// securityListFragments reassembles the SecurityList responses split by the
+// venues across several messages with TotNoRelatedSym and LastFragment.
+type securityListFragments struct {
+	pending map[string][]*quickfix.Message
+	mux     sync.Mutex
+}
+
+// add adds a SecurityList message to the fragments of its request. It returns
+// the whole response, with all the instruments in one NoRelatedSym group, once
+// the last fragment has been received.
+func (f *securityListFragments) add(message *quickfix.Message, appDict *datadictionary.DataDictionary) (*quickfix.Message, error) {
+	reqID, _ := message.Body.GetString(tag.SecurityReqID)
+
+	f.mux.Lock()
+	defer f.mux.Unlock()
+
+	if f.pending == nil {
+		f.pending = make(map[string][]*quickfix.Message)
+	}
+
+	fragments := append(f.pending[reqID], message)
+
+	var instruments []*quickfix.Group
+	for _, fragment := range fragments {
+		fragmentInstruments, err := SecurityListInstruments(fragment, appDict)
+		if err != nil {
+			delete(f.pending, reqID)
+			return nil, err
+		}
+		instruments = append(instruments, fragmentInstruments...)
+	}
+	count := len(instruments)
+
+	if !lastSecurityListFragment(message, count) {
+		f.pending[reqID] = fragments
+		return nil, nil
+	}
+	delete(f.pending, reqID)
+
+	if len(fragments) == 1 {
+		return message, nil
+	}
+
+	// The instruments of the first fragment are replaced by all the ones
+	first, _ := SecurityListInstruments(fragments[0], appDict)
+	whole := securityListMessage(fragments[0], first, instruments, appDict)
+	whole.Body.SetInt(tag.TotNoRelatedSym, count)
+	whole.Body.SetBool(tag.LastFragment, true)
+
+	return whole, nil
+}
+
+// lastSecurityListFragment tells whether the message is the last fragment of
+// a response, count being the number of instruments received so far.
+func lastSecurityListFragment(message *quickfix.Message, count int) bool {
+	if message.Body.Has(tag.LastFragment) {
+		last, err := message.Body.GetBool(tag.LastFragment)
+		return err != nil || last
+	}
+
+	if total, err := message.Body.GetInt(tag.TotNoRelatedSym); err == nil {
+		return count >= total
+	}
+
+	return true
+}
@@ -0,0 +1,116 @@
+package application
+
+import (
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/quickfix/datadictionary"
+	"github.com/quickfixgo/tag"
+
+	"sylr.dev/fix/pkg/utils"
+)
+
+// Tags of the instruments missing from the tag package.
+const (
+	TagSecurityType      quickfix.Tag = 167
+	TagRoundLot          quickfix.Tag = 561
+	TagMinTradeVol       quickfix.Tag = 562
+	TagProduct           quickfix.Tag = 460
+	TagSecurityStatus    quickfix.Tag = 965
+	TagMinPriceIncrement quickfix.Tag = 969
+	TagListUpdateAction  quickfix.Tag = 1324
+)
+
+// securityListGroupTemplate is the template of the NoRelatedSym group used
+// when the application data dictionary does not define it.
+var securityListGroupTemplate = quickfix.GroupTemplate{
+	quickfix.GroupElement(tag.Symbol),
+	quickfix.GroupElement(tag.SecurityID),
+	quickfix.GroupElement(tag.SecurityIDSource),
+	quickfix.GroupElement(TagProduct),
+	quickfix.GroupElement(TagSecurityType),
+	quickfix.GroupElement(TagSecurityStatus),
+	quickfix.GroupElement(TagMinPriceIncrement),
+	quickfix.GroupElement(tag.Currency),
+	quickfix.GroupElement(TagRoundLot),
+	quickfix.GroupElement(TagMinTradeVol),
+	quickfix.GroupElement(tag.SecurityTradingStatus),
+	quickfix.GroupElement(TagListUpdateAction),
+}
+
+// SecurityListGroupTemplate returns the template of the NoRelatedSym group of
+// the SecurityList or SecurityListUpdateReport messages, the one of the
+// application data dictionary when it defines it.
+func SecurityListGroupTemplate(appDict *datadictionary.DataDictionary, msgType string) quickfix.GroupTemplate {
+	if appDict != nil {
+		if msgDef, ok := appDict.Messages[msgType]; ok {
+			if def, ok := msgDef.Fields[int(tag.NoRelatedSym)]; ok && def.IsGroup() {
+				return utils.QuickFixGroupTemplate(def)
+			}
+		}
+	}
+
+	return securityListGroupTemplate
+}
+
+// SecurityListInstruments returns the instances of the NoRelatedSym group of a
+// SecurityList or SecurityListUpdateReport message.
+func SecurityListInstruments(message *quickfix.Message, appDict *datadictionary.DataDictionary) ([]*quickfix.Group, error) {
+	if !message.Body.Has(tag.NoRelatedSym) {
+		return nil, nil
+	}
+
+	msgType, _ := message.MsgType()
+	group := quickfix.NewRepeatingGroup(tag.NoRelatedSym, SecurityListGroupTemplate(appDict, msgType))
+	if err := message.Body.GetGroup(group); err != nil {
+		return nil, err
+	}
+
+	instruments := make([]*quickfix.Group, 0, group.Len())
+	for i := 0; i < group.Len(); i++ {
+		instruments = append(instruments, group.Get(i))
+	}
+
+	return instruments, nil
+}
+
+// FilterSecurityList returns a copy of the SecurityList or
+// SecurityListUpdateReport message only holding the instruments kept.
+func FilterSecurityList(message *quickfix.Message, appDict *datadictionary.DataDictionary, keep func(*quickfix.Group) bool) (*quickfix.Message, error) {
+	instruments, err := SecurityListInstruments(message, appDict)
+	if err != nil {
+		return nil, err
+	}
+
+	kept := make([]*quickfix.Group, 0, len(instruments))
+	for _, instrument := range instruments {
+		if keep(instrument) {
+			kept = append(kept, instrument)
+		}
+	}
+
+	return securityListMessage(message, instruments, kept, appDict), nil
+}
+
+// securityListMessage returns a copy of the message whose NoRelatedSym group
+// holds the given instruments, the ones of the message being removed.
+func securityListMessage(message *quickfix.Message, removed, instruments []*quickfix.Group, appDict *datadictionary.DataDictionary) *quickfix.Message {
+	msgType, _ := message.MsgType()
+
+	result := quickfix.NewMessage()
+	message.CopyInto(result)
+
+	// The fields of the group instances are also held by the body of a
+	// parsed message
+	for _, instrument := range removed {
+		for _, t := range instrument.Tags() {
+			result.Body.Remove(t)
+		}
+	}
+
+	related := quickfix.NewRepeatingGroup(tag.NoRelatedSym, SecurityListGroupTemplate(appDict, msgType))
+	for _, instrument := range instruments {
+		instrument.CopyInto(&related.Add().FieldMap)
+	}
+	result.Body.SetGroup(related)
+
+	return result
+}
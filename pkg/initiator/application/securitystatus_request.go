@@ -7,7 +7,6 @@ import (
 
 	"github.com/quickfixgo/enum"
 	"github.com/quickfixgo/quickfix"
-	"github.com/quickfixgo/tag"
 
 	"sylr.dev/fix/pkg/dict"
 	"sylr.dev/fix/pkg/utils"
@@ -23,19 +22,27 @@ func NewSecurityStatusRequest() *SecurityStatusRequest {
 }
 
 type SecurityStatusRequest struct {
-	utils.QuickFixAppMessageLogger
+	utils.BaseApplication
 
-	Settings        *quickfix.Settings
 	Connected       chan quickfix.SessionID
 	FromAppMessages chan *quickfix.Message
 	stopped         bool
 	mux             sync.RWMutex
+	latencies       latencies
+}
+
+// Configure sets the logger, the settings and the dictionaries of the
+// application.
+func (app *SecurityStatusRequest) Configure(options Options) *SecurityStatusRequest {
+	options.apply(&app.BaseApplication)
+	return app
 }
 
 // Stop ensures the app chans are emptied so that quickfix can carry on with
 // the LOGOUT process correctly.
 func (app *SecurityStatusRequest) Stop() {
 	app.Logger.Debug().Msgf("Stopping SecurityStatusRequest application")
+	app.Requests.Close()
 
 	app.mux.Lock()
 	defer app.mux.Unlock()
@@ -70,38 +77,16 @@ func (app *SecurityStatusRequest) OnLogout(sessionID quickfix.SessionID) {
 
 // Notification of admin message being sent to target.
 func (app *SecurityStatusRequest) ToAdmin(message *quickfix.Message, sessionID quickfix.SessionID) {
-	typ, err := message.MsgType()
-	if err != nil {
-		app.Logger.Error().Msgf("Message type error: %s", err)
-	}
-
-	// Logon
-	if err == nil && typ == string(enum.MsgType_LOGON) {
-		sets := app.Settings.SessionSettings()
-		if session, ok := sets[sessionID]; ok {
-			if session.HasSetting("Username") {
-				username, err := session.Setting("Username")
-				if err == nil && len(username) > 0 {
-					app.Logger.Debug().Msg("Username injected in logon message")
-					message.Header.SetField(tag.Username, quickfix.FIXString(username))
-				}
-			}
-			if session.HasSetting("Password") {
-				password, err := session.Setting("Password")
-				if err == nil && len(password) > 0 {
-					app.Logger.Debug().Msg("Password injected in logon message")
-					message.Header.SetField(tag.Password, quickfix.FIXString(password))
-				}
-			}
-		}
-	}
+	app.PrepareLogon(message, sessionID)
 
 	app.LogMessage(zerolog.TraceLevel, message, sessionID, true)
+	app.latencies.ToAdmin(message, sessionID)
 }
 
 // Notification of admin message being received from target.
 func (app *SecurityStatusRequest) FromAdmin(message *quickfix.Message, sessionID quickfix.SessionID) quickfix.MessageRejectError {
 	app.LogMessage(zerolog.TraceLevel, message, sessionID, false)
+	app.latencies.FromAdmin(message, sessionID)
 
 	typ, err := message.MsgType()
 	if err != nil {
@@ -118,13 +103,22 @@ func (app *SecurityStatusRequest) FromAdmin(message *quickfix.Message, sessionID
 
 // Notification of app message being sent to target.
 func (app *SecurityStatusRequest) ToApp(message *quickfix.Message, sessionID quickfix.SessionID) error {
+	// Routing
+	if session, ok := app.Settings.SessionSettings()[sessionID]; ok {
+		utils.QuickFixMessagePartSetRouting(&message.Header, session)
+	}
+
 	app.LogMessage(zerolog.TraceLevel, message, sessionID, true)
+	app.Requests.Sent(message, sessionID)
+	app.latencies.ToApp(message, sessionID)
 	return nil
 }
 
 // Notification of app message being received from target.
 func (app *SecurityStatusRequest) FromApp(message *quickfix.Message, sessionID quickfix.SessionID) quickfix.MessageRejectError {
 	app.LogMessage(zerolog.TraceLevel, message, sessionID, false)
+	app.Requests.Received(message, sessionID)
+	app.latencies.FromApp(message, sessionID)
 
 	typ, err := message.MsgType()
 	if err != nil {
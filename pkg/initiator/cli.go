@@ -2,17 +2,39 @@ package initiator
 
 import (
 	"fmt"
+	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
 
 	"sylr.dev/fix/config"
 	"sylr.dev/fix/pkg/cli/complete"
 	"sylr.dev/fix/pkg/errors"
+	"sylr.dev/fix/pkg/instrument"
+	"sylr.dev/fix/pkg/utils"
 )
 
+// MultiSessionsAnnotation is the annotation that commands which are able to run
+// all the sessions of a context at once must set.
+const MultiSessionsAnnotation = "fix/multi-sessions"
+
+// SessionNames returns the sessions given with --session, comma separated.
+func SessionNames() []string {
+	options := config.GetOptions()
+
+	var names []string
+	for _, name := range strings.Split(options.Session, ",") {
+		if name = strings.TrimSpace(name); len(name) > 0 {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
 func ValidateOptions(cmd *cobra.Command, args []string) error {
 	options := config.GetOptions()
-	conf, err := config.ReadYAML(options.Config, options.Interactive)
+	conf, err := config.ReadYAMLFiles(config.ConfigPaths(cmd.Flags().Changed("config")), options.Interactive)
 
 	if err != nil {
 		return fmt.Errorf("unable to read configuration: %w", err)
@@ -32,9 +54,13 @@ func ValidateOptions(cmd *cobra.Command, args []string) error {
 	// Initialize the context name with the config current-context value
 	contextName := fixConfig.CurrentContext
 
+	if options.AllSessions && len(options.Session) > 0 {
+		return fmt.Errorf("%w: can't use --session with --all-sessions", errors.Options)
+	}
+
 	if len(options.Context) > 0 {
-		if len(options.Initiator) > 0 || len(options.Session) > 0 {
-			return fmt.Errorf("%w: can't use --initiator/--session with --context", errors.Options)
+		if len(options.Initiator) > 0 {
+			return fmt.Errorf("%w: can't use --initiator with --context", errors.Options)
 		}
 		contextName = options.Context
 	} else if len(contextName) == 0 {
@@ -48,7 +74,7 @@ func ValidateOptions(cmd *cobra.Command, args []string) error {
 		fixConfig.Contexts = append(fixConfig.Contexts, &config.Context{
 			Name:      contextName,
 			Initiator: options.Initiator,
-			Sessions:  []string{options.Session},
+			Sessions:  SessionNames(),
 		})
 		(*options).Context = contextName
 	}
@@ -63,31 +89,71 @@ func ValidateOptions(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	// --session selects sessions of the context
+	if names := SessionNames(); len(names) > 0 {
+		selected, err := context.SelectSessions(names...)
+		if err != nil {
+			return err
+		}
+		context.Sessions = selected.Sessions
+	}
+
 	sessions, err := context.GetSessions()
 	if err != nil {
 		return err
-	} else {
-		switch len(sessions) {
-		case 0:
-			return errors.ConfigContextNoSession
-		case 1:
-			// OK
-		default:
-			if cmd.Name() != "probe" {
-				return errors.ConfigContextMultipleSessions
-			}
+	}
+
+	executed := executedCommand(cmd)
+	_, multi := executed.Annotations[MultiSessionsAnnotation]
+
+	if options.ExitOnDisconnect {
+		setExitOnDisconnect(executed)
+	}
+
+	switch {
+	case len(sessions) == 0:
+		return errors.ConfigContextNoSession
+	case options.AllSessions && !multi:
+		return fmt.Errorf("%w: %s does not handle several sessions", errors.Options, executed.CommandPath())
+	case len(sessions) > 1 && !multi:
+		return fmt.Errorf("%w: select one with --session", errors.ConfigContextMultipleSessions)
+	}
+
+	for _, session := range sessions {
+		if len(session.InstrumentCatalog) == 0 {
+			continue
+		}
+
+		instruments, err := instrument.Load(os.ExpandEnv(session.InstrumentCatalog))
+		if err != nil {
+			return fmt.Errorf("session %s instrument catalog: %w", session.Name, err)
 		}
+
+		utils.SetDecimalScales(instruments)
 	}
 
 	return nil
 }
 
+// executedCommand returns the command being executed, cmd or one of its
+// subcommands, the options being validated by the parents of the commands.
+func executedCommand(cmd *cobra.Command) *cobra.Command {
+	for _, child := range cmd.Commands() {
+		if executed := executedCommand(child); executed.CalledAs() != "" {
+			return executed
+		}
+	}
+
+	return cmd
+}
+
 func AddPersistentFlags(cmd *cobra.Command) {
 	options := config.GetOptions()
 
 	cmd.PersistentFlags().StringVar(&options.Context, "context", "", "Context to use")
 	cmd.PersistentFlags().StringVar(&options.Initiator, "initiator", "", "Initiator to use (can't be used with --context)")
-	cmd.PersistentFlags().StringVar(&options.Session, "session", "", "Session to use (can't be used with --context)")
+	cmd.PersistentFlags().StringVar(&options.Session, "session", "", "Sessions to use, comma separated (sessions of --context or of --initiator)")
+	cmd.PersistentFlags().BoolVar(&options.AllSessions, "all-sessions", false, "Use all the sessions of the context at once (commands handling several sessions only)")
 	cmd.PersistentFlags().DurationVar(&options.Timeout, "timeout", 0, "Duration for timeouts")
 	cmd.PersistentFlags().BoolVar(&options.QuickFixLogging, "quickfix-logging", false, "Enable quickfix logging")
 }
@@ -0,0 +1,157 @@
+package initiator
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/quickfixgo/enum"
+	"github.com/quickfixgo/quickfix"
+	"github.com/spf13/cobra"
+
+	"sylr.dev/fix/config"
+	"sylr.dev/fix/pkg/errors"
+)
+
+var (
+	exitOnDisconnect    context.CancelCauseFunc
+	exitOnDisconnectErr error
+	exitOnDisconnectMux sync.Mutex
+)
+
+// AddExitOnDisconnectFlag adds --exit-on-disconnect to the long-running
+// command, which otherwise waits for quickfix to log on again when its
+// sessions drop.
+func AddExitOnDisconnectFlag(cmd *cobra.Command) {
+	options := config.GetOptions()
+
+	cmd.Flags().BoolVar(&options.ExitOnDisconnect, "exit-on-disconnect", false, "Exit as soon as a session drops instead of waiting for it to log on again")
+}
+
+// setExitOnDisconnect makes the context of the command be canceled once a
+// session drops.
+func setExitOnDisconnect(cmd *cobra.Command) {
+	ctx, cancel := context.WithCancelCause(cmd.Context())
+	cmd.SetContext(ctx)
+
+	exitOnDisconnectMux.Lock()
+	exitOnDisconnect = cancel
+	exitOnDisconnectMux.Unlock()
+}
+
+// DisconnectError returns the error of the session whose drop ended the
+// command run with --exit-on-disconnect, if any.
+func DisconnectError() error {
+	exitOnDisconnectMux.Lock()
+	defer exitOnDisconnectMux.Unlock()
+
+	return exitOnDisconnectErr
+}
+
+func disconnected(sessionID quickfix.SessionID) {
+	exitOnDisconnectMux.Lock()
+	defer exitOnDisconnectMux.Unlock()
+
+	if exitOnDisconnect == nil || exitOnDisconnectErr != nil {
+		return
+	}
+
+	exitOnDisconnectErr = fmt.Errorf("%w: %s dropped", errors.FixLogout, sessionID)
+	config.GetLogger().Warn().Msgf("Session %s dropped, exiting", sessionID)
+	exitOnDisconnect(exitOnDisconnectErr)
+}
+
+// disconnectApplication tells the sessions which drop, logged out by the
+// counterparty or disconnected, from the ones the command logs out itself.
+type disconnectApplication struct {
+	quickfix.Application
+
+	sessions map[quickfix.SessionID]*disconnectState
+	mux      sync.Mutex
+}
+
+type disconnectState struct {
+	loggedOn   bool
+	logoutIn   bool
+	loggingOut bool
+}
+
+func newDisconnectApplication(app quickfix.Application) quickfix.Application {
+	exitOnDisconnectMux.Lock()
+	defer exitOnDisconnectMux.Unlock()
+
+	if exitOnDisconnect == nil {
+		return app
+	}
+
+	return &disconnectApplication{
+		Application: app,
+		sessions:    make(map[quickfix.SessionID]*disconnectState),
+	}
+}
+
+func (a *disconnectApplication) state(sessionID quickfix.SessionID) *disconnectState {
+	state, ok := a.sessions[sessionID]
+	if !ok {
+		state = &disconnectState{}
+		a.sessions[sessionID] = state
+	}
+
+	return state
+}
+
+func isLogout(message *quickfix.Message) bool {
+	msgType, err := message.MsgType()
+	return err == nil && enum.MsgType(msgType) == enum.MsgType_LOGOUT
+}
+
+func (a *disconnectApplication) OnLogon(sessionID quickfix.SessionID) {
+	a.mux.Lock()
+	a.sessions[sessionID] = &disconnectState{loggedOn: true}
+	a.mux.Unlock()
+
+	a.Application.OnLogon(sessionID)
+}
+
+func (a *disconnectApplication) OnLogout(sessionID quickfix.SessionID) {
+	a.mux.Lock()
+	state := a.state(sessionID)
+	dropped := state.loggedOn && !state.loggingOut
+	state.loggedOn = false
+	a.mux.Unlock()
+
+	a.Application.OnLogout(sessionID)
+
+	if dropped {
+		disconnected(sessionID)
+	}
+}
+
+// ToAdmin notes the Logout sent first, the command logging the session out.
+func (a *disconnectApplication) ToAdmin(message *quickfix.Message, sessionID quickfix.SessionID) {
+	if isLogout(message) {
+		a.mux.Lock()
+		state := a.state(sessionID)
+		if !state.logoutIn {
+			state.loggingOut = true
+		}
+		a.mux.Unlock()
+	}
+
+	a.Application.ToAdmin(message, sessionID)
+}
+
+// FromAdmin notes the Logout received first, the counterparty logging the
+// session out.
+func (a *disconnectApplication) FromAdmin(message *quickfix.Message, sessionID quickfix.SessionID) quickfix.MessageRejectError {
+	if isLogout(message) {
+		a.mux.Lock()
+		state := a.state(sessionID)
+		if !state.loggingOut {
+			state.logoutIn = true
+		}
+		a.mux.Unlock()
+	}
+
+	return a.Application.FromAdmin(message, sessionID)
+}
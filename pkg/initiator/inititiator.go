@@ -1,34 +1,48 @@
 package initiator
 
 import (
-	"fmt"
-
 	"github.com/quickfixgo/quickfix"
-	"github.com/quickfixgo/quickfix/store/sql"
 	"github.com/rs/zerolog"
 
+	"sylr.dev/fix/pkg/auth"
+	"sylr.dev/fix/pkg/risk"
+	"sylr.dev/fix/pkg/store"
 	"sylr.dev/fix/pkg/utils"
 )
 
 func Initiate(app quickfix.Application, settings *quickfix.Settings, logger *zerolog.Logger) (*quickfix.Initiator, error) {
-	var msgStoreFactory quickfix.MessageStoreFactory
-
-	if settings.GlobalSettings().HasSetting("SQLStoreDriver") {
-		driver, err := settings.GlobalSettings().Setting("SQLStoreDriver")
-		if err != nil {
-			return nil, err
-		}
-		switch driver {
-		case "sqlite3", "postgres":
-			msgStoreFactory = sql.NewStoreFactory(settings)
-		default:
-			return nil, fmt.Errorf("Unsupported SQLStoreDriver: %s", driver)
-		}
+	msgStoreFactory, err := store.NewMessageStoreFactory(settings)
+	if err != nil {
+		return nil, err
 	}
 
-	if msgStoreFactory == nil {
-		msgStoreFactory = quickfix.NewMemoryStoreFactory()
+	app = newDisconnectApplication(app)
+
+	app, err = auth.NewApplication(app, settings, logger)
+	if err != nil {
+		return nil, err
 	}
 
-	return quickfix.NewInitiator(app, msgStoreFactory, settings, utils.NewQuickFixLogFactory(logger))
+	app, err = utils.NewQuickFixThrottledApplication(app, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	// Orders are checked before waiting for the throttle
+	app, err = risk.NewApplication(app, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	return quickfix.NewInitiator(app, msgStoreFactory, settings, utils.NewQuickFixSessionLogFactory(logger, settings))
+}
+
+// Stop stops the initiator, closes the log files of its sessions and
+// unregisters them so that they can be created again by a new initiator.
+func Stop(init *quickfix.Initiator, settings *quickfix.Settings) {
+	init.Stop()
+	utils.CloseQuickFixSessionLogs(settings)
+	for sessionId := range settings.SessionSettings() {
+		_ = quickfix.UnregisterSession(sessionId)
+	}
 }
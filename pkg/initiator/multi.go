@@ -0,0 +1,237 @@
+package initiator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/quickfixgo/quickfix"
+	"github.com/rs/zerolog"
+
+	"sylr.dev/fix/config"
+	"sylr.dev/fix/pkg/errors"
+	"sylr.dev/fix/pkg/utils"
+)
+
+// App is the interface the initiator applications must implement to be started
+// by InitiateContext.
+type App interface {
+	quickfix.Application
+	Stop()
+}
+
+// SessionApp ties an application to the session of the context it has been
+// created for.
+type SessionApp[T App] struct {
+	Name      string
+	Session   *config.Session
+	Settings  *quickfix.Settings
+	App       T
+	Initiator *quickfix.Initiator
+}
+
+// Labeled is a value received from one of the sessions of a context.
+type Labeled[V any] struct {
+	Session string
+	Value   V
+}
+
+// Sessions holds the applications of all the sessions of a context.
+type Sessions[T App] struct {
+	Apps []*SessionApp[T]
+}
+
+// InitiateContext creates one initiator per session of the context. Each
+// session has its own application created with newApp.
+func InitiateContext[T App](context *config.Context, logger *zerolog.Logger, newApp func(*config.Session, *quickfix.Settings) (T, error)) (*Sessions[T], error) {
+	sessions, err := context.GetSessions()
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Sessions[T]{}
+
+	for i, contextSingleSession := range context.Split() {
+		session := sessions[i]
+
+		settings, err := contextSingleSession.ToQuickFixInitiatorSettings()
+		if err != nil {
+			return nil, err
+		}
+
+		app, err := newApp(session, settings)
+		if err != nil {
+			return nil, err
+		}
+
+		var sessionLogger *zerolog.Logger
+		if logger != nil {
+			l := logger.With().Str("session", session.Name).Logger()
+			sessionLogger = &l
+		}
+
+		init, err := Initiate(app, settings, sessionLogger)
+		if err != nil {
+			return nil, err
+		}
+
+		s.Apps = append(s.Apps, &SessionApp[T]{
+			Name:      session.Name,
+			Session:   session,
+			Settings:  settings,
+			App:       app,
+			Initiator: init,
+		})
+	}
+
+	return s, nil
+}
+
+// Start starts all the initiators.
+func (s *Sessions[T]) Start() error {
+	for _, a := range s.Apps {
+		if err := a.Initiator.Start(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Stop stops all the applications and their initiators, closing the log
+// files of their sessions.
+func (s *Sessions[T]) Stop() {
+	var wg sync.WaitGroup
+
+	for _, a := range s.Apps {
+		wg.Add(1)
+		go func(a *SessionApp[T]) {
+			defer wg.Done()
+			a.App.Stop()
+			a.Initiator.Stop()
+			utils.CloseQuickFixSessionLogs(a.Settings)
+		}(a)
+	}
+
+	wg.Wait()
+}
+
+// Get returns the application of the given session.
+func (s *Sessions[T]) Get(name string) (*SessionApp[T], bool) {
+	for _, a := range s.Apps {
+		if a.Name == name {
+			return a, true
+		}
+	}
+
+	return nil, false
+}
+
+// Multiplex merges a channel of every application into a single one whose
+// values are labeled with the session name. The returned channel is closed
+// once all the application channels are closed.
+func Multiplex[T App, V any](s *Sessions[T], channel func(T) chan V) <-chan Labeled[V] {
+	out := make(chan Labeled[V], len(s.Apps))
+
+	var wg sync.WaitGroup
+	for _, a := range s.Apps {
+		wg.Add(1)
+		go func(name string, in chan V) {
+			defer wg.Done()
+			for v := range in {
+				out <- Labeled[V]{Session: name, Value: v}
+			}
+		}(a.Name, channel(a.App))
+	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+
+	return out
+}
+
+// Result is the outcome of a command run on one of the sessions of a context.
+type Result[V any] struct {
+	Session string
+	Value   V
+	Err     error
+}
+
+// FanOut runs fn concurrently for each session of the context, with a copy of
+// the context having only this session, and returns their results in the order
+// of the sessions.
+func FanOut[V any](context *config.Context, fn func(*config.Context) (V, error)) []Result[V] {
+	contexts := context.Split()
+	results := make([]Result[V], len(contexts))
+
+	var wg sync.WaitGroup
+	for i, c := range contexts {
+		wg.Add(1)
+		go func(i int, c *config.Context) {
+			defer wg.Done()
+			value, err := fn(c)
+			results[i] = Result[V]{Session: c.Sessions[0], Value: value, Err: err}
+		}(i, c)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// ResultsError returns the errors of the results prefixed with their session,
+// nil if all of them succeeded.
+func ResultsError[V any](results []Result[V]) error {
+	var errs []error
+	for _, r := range results {
+		if r.Err != nil {
+			errs = append(errs, fmt.Errorf("session %s: %w", r.Session, r.Err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// WriteResults writes the results as JSON lines or as a table whose columns,
+// after the session and before the error, are given by header and row.
+func WriteResults[V any](w io.Writer, format string, results []Result[V], header []string, row func(V) []string) error {
+	if format == utils.OutputFormatJSON {
+		encoder := json.NewEncoder(w)
+		for _, r := range results {
+			line := struct {
+				Session string `json:"session"`
+				Result  V      `json:"result"`
+				Error   string `json:"error,omitempty"`
+			}{Session: r.Session, Result: r.Value}
+			if r.Err != nil {
+				line.Error = r.Err.Error()
+			}
+			if err := encoder.Encode(line); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	table := tablewriter.NewWriter(w)
+	table.SetHeader(append(append([]string{"SESSION"}, header...), "ERROR"))
+	table.SetBorders(tablewriter.Border{Left: false, Top: false, Right: false, Bottom: true})
+	table.SetColumnSeparator(" ")
+	table.SetCenterSeparator("-")
+	table.SetAutoWrapText(false)
+
+	for _, r := range results {
+		var errString string
+		if r.Err != nil {
+			errString = r.Err.Error()
+		}
+		table.Append(append(append([]string{r.Session}, row(r.Value)...), errString))
+	}
+
+	table.Render()
+
+	return nil
+}
@@ -0,0 +1,130 @@
+package initiator
+
+import (
+	"context"
+	"time"
+
+	"github.com/quickfixgo/quickfix"
+	"github.com/rs/zerolog"
+
+	"sylr.dev/fix/config"
+	"sylr.dev/fix/pkg/errors"
+)
+
+// Connect creates and starts an initiator then waits for the session to be
+// logged on. If the session is not logged on within timeout, the initiator is
+// stopped and a new attempt is made according to the reconnect policy. The
+// initiator is stopped if no attempt succeeds or if the context is canceled.
+func Connect(ctx context.Context, app quickfix.Application, settings *quickfix.Settings, quickfixLogger *zerolog.Logger, connected chan quickfix.SessionID, timeout time.Duration, policy config.ReconnectPolicy) (*quickfix.Initiator, quickfix.SessionID, error) {
+	logger := config.GetLogger()
+
+	for attempt := 0; ; attempt++ {
+		init, err := Initiate(app, settings, quickfixLogger)
+		if err != nil {
+			return nil, quickfix.SessionID{}, err
+		}
+
+		// Start session
+		if err = init.Start(); err != nil {
+			return nil, quickfix.SessionID{}, err
+		}
+
+		// Wait for session connection
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+		case <-time.After(timeout):
+			err = errors.ConnectionTimeout
+		case sessionId, ok := <-connected:
+			if !ok {
+				init.Stop()
+				return nil, quickfix.SessionID{}, errors.FixLogout
+			}
+			return init, sessionId, nil
+		}
+
+		if closed := stopDraining(init, settings, connected); closed {
+			// The session logged on then out, the app can't be reused
+			return nil, quickfix.SessionID{}, errors.FixLogout
+		}
+
+		if attempt >= policy.MaxRetries || ctx.Err() != nil {
+			return nil, quickfix.SessionID{}, err
+		}
+
+		delay := policy.Delay(attempt)
+		logger.Warn().Err(err).Msgf("Connection attempt %d/%d failed, retrying in %s", attempt+1, policy.MaxRetries+1, delay)
+		if err := sleep(ctx, delay); err != nil {
+			return nil, quickfix.SessionID{}, err
+		}
+	}
+}
+
+// Reconnect waits for the session that dropped to log on again. The initiator
+// is kept, reconnecting by itself every ReconnectInterval with the same session
+// and message store, so that the sequence numbers go on and the counterparty
+// resends the messages missed meanwhile. Each reconnection is given the first
+// delay of the policy and the timeout to log on, up to MaxRetries+1 times, the
+// error being returned if none succeeds or if the context is canceled. The
+// logouts of the failed logons notified on disconnected meanwhile are dropped.
+func Reconnect(ctx context.Context, connected, disconnected chan quickfix.SessionID, timeout time.Duration, policy config.ReconnectPolicy) (quickfix.SessionID, error) {
+	logger := config.GetLogger()
+
+	logger.Warn().Msg("Session dropped, waiting for it to reconnect")
+
+	for attempt := 0; ; attempt++ {
+		select {
+		case <-ctx.Done():
+			return quickfix.SessionID{}, ctx.Err()
+		case sessionId, ok := <-connected:
+			if !ok {
+				return quickfix.SessionID{}, errors.FixLogout
+			}
+			logger.Info().Msgf("Session %s reconnected", sessionId)
+			select {
+			case <-disconnected:
+			default:
+			}
+			return sessionId, nil
+		case <-time.After(policy.Delay(0) + timeout):
+		}
+
+		if attempt >= policy.MaxRetries {
+			return quickfix.SessionID{}, errors.ConnectionTimeout
+		}
+		logger.Warn().Msgf("Session not reconnected yet (%d/%d)", attempt+1, policy.MaxRetries+1)
+	}
+}
+
+// stopDraining stops the initiator while receiving from connected, so that a
+// session logging on at the same moment does not block in OnLogon and keep the
+// initiator from stopping. It tells whether connected got closed meanwhile.
+func stopDraining(init *quickfix.Initiator, settings *quickfix.Settings, connected chan quickfix.SessionID) (closed bool) {
+	stopped := make(chan struct{})
+	go func() {
+		Stop(init, settings)
+		close(stopped)
+	}()
+
+	for {
+		select {
+		case <-stopped:
+			return closed
+		case _, ok := <-connected:
+			if !ok {
+				closed = true
+				connected = nil
+			}
+		}
+	}
+}
+
+// sleep waits for the duration unless the context is canceled beforehand.
+func sleep(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
@@ -0,0 +1,118 @@
+package initiator
+
+import (
+	stdcontext "context"
+	"fmt"
+	"time"
+
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/tag"
+	"github.com/rs/zerolog"
+
+	"sylr.dev/fix/config"
+	"sylr.dev/fix/pkg/cli/complete"
+	"sylr.dev/fix/pkg/errors"
+	"sylr.dev/fix/pkg/initiator/application"
+)
+
+func init() {
+	complete.FetchSymbols = FetchSymbols
+}
+
+// FetchSymbols logs on the first session of the context and returns the
+// symbols of the SecurityList sent in response to a SecurityListRequest.
+func FetchSymbols(context *config.Context) ([]string, error) {
+	options := config.GetOptions()
+
+	initiatorConfig, err := context.GetInitiator()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(context.Sessions) == 0 {
+		return nil, errors.ConfigContextNoSession
+	}
+
+	// Make a copy of the context which has only one session.
+	contextSingleSession := *context
+	contextSingleSession.Sessions = context.Sessions[:1]
+
+	settings, err := contextSingleSession.ToQuickFixInitiatorSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	var timeout time.Duration
+	if options.Timeout != time.Duration(0) {
+		timeout = options.Timeout
+	} else if initiatorConfig.SocketTimeout != time.Duration(0) {
+		timeout = initiatorConfig.SocketTimeout
+	} else {
+		timeout = 5 * time.Second
+	}
+
+	// Nothing must be written while completing
+	logger := zerolog.Nop()
+
+	app := application.NewSecurityList()
+	app.Logger = &logger
+	app.Settings = settings
+
+	init, sessionID, err := Connect(stdcontext.Background(), app, settings, nil, app.Connected, timeout, config.ReconnectPolicy{})
+	if err != nil {
+		app.Stop()
+		return nil, err
+	}
+
+	defer func() {
+		app.Stop()
+		init.Stop()
+	}()
+
+	if sessionID.BeginString != quickfix.BeginStringFIXT11 {
+		return nil, errors.FixVersionNotImplemented
+	}
+
+	request, err := application.BuildSecurityListRequestFix50Sp2Message("symbol")
+	if err != nil {
+		return nil, err
+	}
+
+	if err = quickfix.SendToTarget(request, sessionID); err != nil {
+		return nil, err
+	}
+
+	var response *quickfix.Message
+	select {
+	case <-time.After(timeout):
+		return nil, errors.ResponseTimeout
+	case response = <-app.FromAppMessages:
+	}
+
+	if response == nil {
+		return nil, errors.FixLogout
+	}
+	if msgType, _ := response.MsgType(); msgType == "3" {
+		return nil, fmt.Errorf("%w: security list request rejected", errors.Fix)
+	}
+
+	group := quickfix.NewRepeatingGroup(
+		tag.NoRelatedSym,
+		quickfix.GroupTemplate{
+			quickfix.GroupElement(tag.Symbol),
+		})
+	if err := response.Body.GetGroup(group); err != nil {
+		return nil, err
+	}
+
+	symbols := make([]string, 0, group.Len())
+	for i := 0; i < group.Len(); i++ {
+		symbol, err := group.Get(i).GetString(tag.Symbol)
+		if err != nil {
+			return nil, fmt.Errorf("error while getting symbol: %w", err)
+		}
+		symbols = append(symbols, symbol)
+	}
+
+	return symbols, nil
+}
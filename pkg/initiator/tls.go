@@ -0,0 +1,45 @@
+package initiator
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"sylr.dev/fix/config"
+)
+
+// TLSConfig builds the TLS configuration quickfix uses to connect to the
+// acceptor of the initiator.
+func TLSConfig(initiatorConfig *config.Initiator) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         initiatorConfig.SocketServerName,
+		InsecureSkipVerify: initiatorConfig.SocketInsecureSkipVerify,
+	}
+	if len(tlsConfig.ServerName) == 0 {
+		tlsConfig.ServerName = initiatorConfig.SocketConnectHost
+	}
+
+	if len(initiatorConfig.SocketCertificateFile) > 0 || len(initiatorConfig.SocketPrivateKeyFile) > 0 {
+		cert, err := tls.LoadX509KeyPair(initiatorConfig.SocketCertificateFile, initiatorConfig.SocketPrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if len(initiatorConfig.SocketCAFile) > 0 {
+		pem, err := os.ReadFile(initiatorConfig.SocketCAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("unable to parse %s", initiatorConfig.SocketCAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return tlsConfig, nil
+}
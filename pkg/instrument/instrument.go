@@ -0,0 +1,199 @@
+// Package instrument reads and writes instrument catalogs.
+//
+// A catalog is the normalized list of the instruments of a venue, as written
+// by `fix list security -o json|csv`, that other commands read instead of
+// requesting the security list of the venue. It is either a JSON array:
+//
+//	[
+//	  {"symbol": "EURUSD", "securityID": "1", "product": "4", "currency": "EUR", "tickSize": "0.00001"}
+//	]
+//
+// or a CSV file with a header line naming the columns, in any order:
+//
+//	symbol,securityID,product,currency,tickSize
+//	EURUSD,1,4,EUR,0.00001
+//
+// Enum fields (securityType, product, tradingStatus, securityStatus) hold FIX
+// values, not descriptions.
+package instrument
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"sylr.dev/fix/pkg/errors"
+)
+
+// Instrument is an instrument of a catalog.
+type Instrument struct {
+	Symbol         string `json:"symbol"`
+	SecurityID     string `json:"securityID,omitempty"`
+	SecurityType   string `json:"securityType,omitempty"`
+	Product        string `json:"product,omitempty"`
+	Currency       string `json:"currency,omitempty"`
+	TickSize       string `json:"tickSize,omitempty"`
+	LotSize        string `json:"lotSize,omitempty"`
+	TradingStatus  string `json:"tradingStatus,omitempty"`
+	SecurityStatus string `json:"securityStatus,omitempty"`
+}
+
+// CSVHeader is the header of the catalogs written by WriteCSV.
+var CSVHeader = []string{"symbol", "securityID", "securityType", "product", "currency", "tickSize", "lotSize", "tradingStatus", "securityStatus"}
+
+func (i *Instrument) record() []string {
+	return []string{i.Symbol, i.SecurityID, i.SecurityType, i.Product, i.Currency, i.TickSize, i.LotSize, i.TradingStatus, i.SecurityStatus}
+}
+
+func (i *Instrument) set(column, value string) bool {
+	switch column {
+	case "symbol":
+		i.Symbol = value
+	case "securityID":
+		i.SecurityID = value
+	case "securityType":
+		i.SecurityType = value
+	case "product":
+		i.Product = value
+	case "currency":
+		i.Currency = value
+	case "tickSize":
+		i.TickSize = value
+	case "lotSize":
+		i.LotSize = value
+	case "tradingStatus":
+		i.TradingStatus = value
+	case "securityStatus":
+		i.SecurityStatus = value
+	default:
+		return false
+	}
+
+	return true
+}
+
+// WriteJSON writes the catalog as an indented JSON array.
+func WriteJSON(w io.Writer, instruments []Instrument) error {
+	if instruments == nil {
+		instruments = []Instrument{}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(instruments)
+}
+
+// WriteCSV writes the catalog as CSV, with a header line.
+func WriteCSV(w io.Writer, instruments []Instrument) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write(CSVHeader); err != nil {
+		return err
+	}
+	for i := range instruments {
+		if err := writer.Write(instruments[i].record()); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+
+	return writer.Error()
+}
+
+// ReadJSON reads a catalog written as a JSON array.
+func ReadJSON(r io.Reader) ([]Instrument, error) {
+	var instruments []Instrument
+	if err := json.NewDecoder(r).Decode(&instruments); err != nil {
+		return nil, err
+	}
+
+	return instruments, validate(instruments)
+}
+
+// ReadCSV reads a catalog written as CSV, whose header line names the columns.
+func ReadCSV(r io.Reader) ([]Instrument, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	for _, column := range header {
+		if !(&Instrument{}).set(column, "") {
+			return nil, fmt.Errorf("unknown column %q, expected one of %s", column, strings.Join(CSVHeader, ", "))
+		}
+	}
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	instruments := make([]Instrument, 0, len(records))
+	for _, record := range records {
+		var instrument Instrument
+		for i, value := range record {
+			instrument.set(header[i], value)
+		}
+		instruments = append(instruments, instrument)
+	}
+
+	return instruments, validate(instruments)
+}
+
+// Load reads the catalog of the file, as CSV when its extension is .csv and
+// as JSON otherwise.
+func Load(path string) ([]Instrument, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errors.Options, err)
+	}
+	defer file.Close()
+
+	var instruments []Instrument
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		instruments, err = ReadCSV(file)
+	} else {
+		instruments, err = ReadJSON(file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: instrument catalog %s: %s", errors.Options, path, err)
+	}
+
+	return instruments, nil
+}
+
+// Symbols returns the symbols of the catalog, in its order and without
+// duplicates.
+func Symbols(instruments []Instrument) []string {
+	seen := make(map[string]bool, len(instruments))
+	symbols := make([]string, 0, len(instruments))
+	for _, instrument := range instruments {
+		if seen[instrument.Symbol] {
+			continue
+		}
+		seen[instrument.Symbol] = true
+		symbols = append(symbols, instrument.Symbol)
+	}
+
+	return symbols
+}
+
+func validate(instruments []Instrument) error {
+	for i, instrument := range instruments {
+		if len(instrument.Symbol) == 0 {
+			return fmt.Errorf("instrument %d has no symbol", i+1)
+		}
+	}
+
+	return nil
+}
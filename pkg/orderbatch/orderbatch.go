@@ -0,0 +1,223 @@
+// Package orderbatch reads batches of orders to submit in bulk.
+//
+// A batch is either a JSON array, or JSON objects one after the other:
+//
+//	[
+//	  {"side": "buy", "type": "limit", "symbol": "EURUSD", "quantity": 10, "price": "1.1",
+//	   "parties": [{"id": "TRADER1", "source": "proprietary", "role": "executing_trader"}]}
+//	]
+//
+// or a CSV file with a header line naming the columns, in any order:
+//
+//	side,type,symbol,quantity,price,parties
+//	buy,limit,EURUSD,10,1.1,TRADER1:proprietary:executing_trader
+//
+// Sides, types, expiries, party ID sources and party roles are given as the
+// values of the corresponding options of `fix new order`. The parties of a
+// CSV line are separated by spaces, each of them being written as
+// id:source:role.
+package orderbatch
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/shopspring/decimal"
+
+	"sylr.dev/fix/pkg/errors"
+)
+
+// Order is an order of a batch.
+type Order struct {
+	ClOrdID  string          `json:"clOrdID,omitempty"`
+	Side     string          `json:"side"`
+	Type     string          `json:"type"`
+	Symbol   string          `json:"symbol"`
+	Quantity decimal.Decimal `json:"quantity"`
+	Price    decimal.Decimal `json:"price"`
+	Expiry   string          `json:"expiry,omitempty"`
+	Parties  []Party         `json:"parties,omitempty"`
+}
+
+// Party is a party of an order.
+type Party struct {
+	ID     string `json:"id"`
+	Source string `json:"source"`
+	Role   string `json:"role"`
+}
+
+// CSVHeader are the columns of the CSV batches.
+var CSVHeader = []string{"clOrdID", "side", "type", "symbol", "quantity", "price", "expiry", "parties"}
+
+func (o *Order) set(column, value string) error {
+	var err error
+
+	switch column {
+	case "clOrdID":
+		o.ClOrdID = value
+	case "side":
+		o.Side = value
+	case "type":
+		o.Type = value
+	case "symbol":
+		o.Symbol = value
+	case "quantity":
+		if len(value) > 0 {
+			o.Quantity, err = decimal.NewFromString(value)
+		}
+	case "price":
+		if len(value) > 0 {
+			o.Price, err = decimal.NewFromString(value)
+		}
+	case "expiry":
+		o.Expiry = value
+	case "parties":
+		o.Parties, err = parseParties(value)
+	default:
+		return fmt.Errorf("unknown column %q, expected one of %s", column, strings.Join(CSVHeader, ", "))
+	}
+
+	if err != nil {
+		return fmt.Errorf("%s: %w", column, err)
+	}
+
+	return nil
+}
+
+func parseParties(value string) ([]Party, error) {
+	var parties []Party
+	for _, p := range strings.Fields(value) {
+		fields := strings.Split(p, ":")
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("invalid party %q, expected id:source:role", p)
+		}
+		parties = append(parties, Party{ID: fields[0], Source: fields[1], Role: fields[2]})
+	}
+
+	return parties, nil
+}
+
+// ReadJSON reads a batch written as a JSON array or as JSON objects one after
+// the other.
+func ReadJSON(r io.Reader) ([]Order, error) {
+	reader := bufio.NewReader(r)
+
+	// Skip the spaces to tell an array from a stream of objects
+	for {
+		b, err := reader.Peek(1)
+		if err == io.EOF {
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		}
+		if !strings.ContainsRune(" \t\r\n", rune(b[0])) {
+			break
+		}
+		reader.ReadByte()
+	}
+
+	decoder := json.NewDecoder(reader)
+	decoder.DisallowUnknownFields()
+
+	var orders []Order
+	if b, _ := reader.Peek(1); b[0] == '[' {
+		if err := decoder.Decode(&orders); err != nil {
+			return nil, err
+		}
+		return orders, validate(orders)
+	}
+
+	for {
+		var order Order
+		if err := decoder.Decode(&order); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("order %d: %w", len(orders)+1, err)
+		}
+		orders = append(orders, order)
+	}
+
+	return orders, validate(orders)
+}
+
+// ReadCSV reads a batch written as CSV, whose header line names the columns.
+func ReadCSV(r io.Reader) ([]Order, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	for _, column := range header {
+		if err := (&Order{}).set(column, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	orders := make([]Order, 0, len(records))
+	for i, record := range records {
+		var order Order
+		for j, value := range record {
+			if err := order.set(header[j], value); err != nil {
+				return nil, fmt.Errorf("order %d: %w", i+1, err)
+			}
+		}
+		orders = append(orders, order)
+	}
+
+	return orders, validate(orders)
+}
+
+// Load reads the batch of the file, as CSV when its extension is .csv and as
+// JSON otherwise.
+func Load(path string) ([]Order, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errors.Options, err)
+	}
+	defer file.Close()
+
+	var orders []Order
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		orders, err = ReadCSV(file)
+	} else {
+		orders, err = ReadJSON(file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: order file %s: %s", errors.Options, path, err)
+	}
+
+	return orders, nil
+}
+
+func validate(orders []Order) error {
+	for i, order := range orders {
+		switch {
+		case len(order.Side) == 0:
+			return fmt.Errorf("order %d has no side", i+1)
+		case len(order.Type) == 0:
+			return fmt.Errorf("order %d has no type", i+1)
+		case len(order.Symbol) == 0:
+			return fmt.Errorf("order %d has no symbol", i+1)
+		case !order.Quantity.IsPositive():
+			return fmt.Errorf("order %d has no quantity", i+1)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,25 @@
+package ordertracker
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"sylr.dev/fix/pkg/errors"
+)
+
+var databasePath string
+
+func AddPersistentFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVar(&databasePath, "db", DefaultPath(), "Order tracker database")
+}
+
+// OpenSelected opens the database given with --db to look up its orders.
+func OpenSelected() (*Tracker, error) {
+	tracker, err := OpenExisting(databasePath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: order tracker: %s", errors.Options, err)
+	}
+
+	return tracker, nil
+}
@@ -0,0 +1,146 @@
+package ordertracker
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"sylr.dev/fix/pkg/parquet"
+)
+
+// exportColumn is a column of the executions exported.
+type exportColumn struct {
+	name  string
+	typ   parquet.ColumnType
+	value func(e *Execution) string
+}
+
+var exportColumns = []exportColumn{
+	{"time", parquet.Timestamp, func(e *Execution) string { return e.Time.UTC().Format(time.RFC3339Nano) }},
+	{"session", parquet.String, func(e *Execution) string { return e.Session }},
+	{"clOrdID", parquet.String, func(e *Execution) string { return e.ClOrdID }},
+	{"orderID", parquet.String, func(e *Execution) string { return e.OrderID }},
+	{"execID", parquet.String, func(e *Execution) string { return e.ExecID }},
+	{"symbol", parquet.String, func(e *Execution) string { return e.Symbol }},
+	{"side", parquet.String, func(e *Execution) string { return e.SideName() }},
+	{"execType", parquet.String, func(e *Execution) string { return e.ExecType }},
+	{"ordStatus", parquet.String, func(e *Execution) string { return e.OrdStatus }},
+	{"lastQty", parquet.Double, func(e *Execution) string { return e.LastQty }},
+	{"lastPx", parquet.Double, func(e *Execution) string { return e.LastPx }},
+	{"cumQty", parquet.Double, func(e *Execution) string { return e.CumQty }},
+	{"avgPx", parquet.Double, func(e *Execution) string { return e.AvgPx }},
+}
+
+// ExportColumns are the columns the executions can be exported with, in their
+// default order.
+func ExportColumns() []string {
+	names := make([]string, len(exportColumns))
+	for i, c := range exportColumns {
+		names[i] = c.name
+	}
+
+	return names
+}
+
+// ValidateExportColumns checks that the columns can be exported.
+func ValidateExportColumns(names []string) error {
+	_, err := selectExportColumns(names)
+	return err
+}
+
+func selectExportColumns(names []string) ([]exportColumn, error) {
+	if len(names) == 0 {
+		return exportColumns, nil
+	}
+
+	columns := make([]exportColumn, 0, len(names))
+names:
+	for _, name := range names {
+		for _, c := range exportColumns {
+			if strings.EqualFold(c.name, name) {
+				columns = append(columns, c)
+				continue names
+			}
+		}
+
+		return nil, fmt.Errorf("unknown column %q, expected one of %s", name, strings.Join(ExportColumns(), ", "))
+	}
+
+	return columns, nil
+}
+
+// WriteExecutionsCSV writes the executions as CSV, with a header line, with
+// the given columns or all of them.
+func WriteExecutionsCSV(w io.Writer, executions []*Execution, names []string) error {
+	columns, err := selectExportColumns(names)
+	if err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(w)
+
+	record := make([]string, len(columns))
+	for i, c := range columns {
+		record[i] = c.name
+	}
+	if err := writer.Write(record); err != nil {
+		return err
+	}
+
+	for _, e := range executions {
+		for i, c := range columns {
+			record[i] = c.value(e)
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+
+	return writer.Error()
+}
+
+// WriteExecutionsParquet writes the executions as a Parquet file with the
+// given columns or all of them, quantities and prices being doubles.
+func WriteExecutionsParquet(w io.Writer, executions []*Execution, names []string) error {
+	columns, err := selectExportColumns(names)
+	if err != nil {
+		return err
+	}
+
+	schema := make([]parquet.Column, len(columns))
+	for i, c := range columns {
+		schema[i] = parquet.Column{Name: c.name, Type: c.typ}
+	}
+
+	writer := parquet.NewWriter(w, schema)
+
+	row := make([]interface{}, len(columns))
+	for _, e := range executions {
+		for i, c := range columns {
+			row[i] = nil
+
+			switch c.typ {
+			case parquet.Timestamp:
+				row[i] = e.Time
+			case parquet.Double:
+				if f, err := strconv.ParseFloat(c.value(e), 64); err == nil {
+					row[i] = f
+				}
+			default:
+				if value := c.value(e); len(value) > 0 {
+					row[i] = value
+				}
+			}
+		}
+
+		if err := writer.Write(row...); err != nil {
+			return err
+		}
+	}
+
+	return writer.Close()
+}
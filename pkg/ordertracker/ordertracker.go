@@ -0,0 +1,597 @@
+// Package ordertracker records the orders sent and the execution reports
+// received on the sessions in a SQLite database, so that they can be looked up
+// afterwards from any shell with `fix orders`.
+//
+// Orders are identified by the ClOrdID of the NewOrderSingle which created
+// them, the cancel and replace requests and the responses referring to later
+// ClOrdIDs through their OrigClOrdID being attached to the same order.
+package ordertracker
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/quickfixgo/enum"
+
+	"sylr.dev/fix/pkg/dict"
+)
+
+// DefaultPath is the database orders are looked up in by default.
+func DefaultPath() string {
+	return filepath.Join("$HOME", ".fix", "orders.db")
+}
+
+const schema = `
+CREATE TABLE IF NOT EXISTS orders (
+	cl_ord_id TEXT PRIMARY KEY,
+	session TEXT NOT NULL,
+	order_id TEXT NOT NULL DEFAULT '',
+	symbol TEXT NOT NULL DEFAULT '',
+	side TEXT NOT NULL DEFAULT '',
+	ord_type TEXT NOT NULL DEFAULT '',
+	price TEXT NOT NULL DEFAULT '',
+	order_qty TEXT NOT NULL DEFAULT '',
+	ord_status TEXT NOT NULL DEFAULT '',
+	cum_qty TEXT NOT NULL DEFAULT '',
+	leaves_qty TEXT NOT NULL DEFAULT '',
+	avg_px TEXT NOT NULL DEFAULT '',
+	text TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS orders_created_at ON orders (created_at);
+CREATE INDEX IF NOT EXISTS orders_order_id ON orders (order_id);
+CREATE TABLE IF NOT EXISTS order_aliases (
+	cl_ord_id TEXT PRIMARY KEY,
+	root TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS order_messages (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	root TEXT NOT NULL,
+	session TEXT NOT NULL,
+	direction TEXT NOT NULL,
+	msg_type TEXT NOT NULL,
+	seq_num INTEGER NOT NULL,
+	cl_ord_id TEXT NOT NULL DEFAULT '',
+	exec_type TEXT NOT NULL DEFAULT '',
+	ord_status TEXT NOT NULL DEFAULT '',
+	last_qty TEXT NOT NULL DEFAULT '',
+	last_px TEXT NOT NULL DEFAULT '',
+	exec_id TEXT NOT NULL DEFAULT '',
+	cum_qty TEXT NOT NULL DEFAULT '',
+	avg_px TEXT NOT NULL DEFAULT '',
+	time TIMESTAMP NOT NULL,
+	raw TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS order_messages_root ON order_messages (root);
+`
+
+// columns are the columns added to the tables since their creation.
+var columns = []struct {
+	table, name, definition string
+}{
+	{"orders", "avg_px", "TEXT NOT NULL DEFAULT ''"},
+	{"order_messages", "last_qty", "TEXT NOT NULL DEFAULT ''"},
+	{"order_messages", "last_px", "TEXT NOT NULL DEFAULT ''"},
+	{"order_messages", "exec_id", "TEXT NOT NULL DEFAULT ''"},
+	{"order_messages", "cum_qty", "TEXT NOT NULL DEFAULT ''"},
+	{"order_messages", "avg_px", "TEXT NOT NULL DEFAULT ''"},
+}
+
+// migrate adds to the tables of a database created by a previous version the
+// columns it misses.
+func migrate(db *sql.DB) error {
+	for _, column := range columns {
+		var count int
+		err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, column.table, column.name).Scan(&count)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+
+		if _, err := db.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s`, column.table, column.name, column.definition)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Order is an order recorded.
+type Order struct {
+	ClOrdID   string    `json:"clOrdID"`
+	Session   string    `json:"session"`
+	OrderID   string    `json:"orderID,omitempty"`
+	Symbol    string    `json:"symbol,omitempty"`
+	Side      string    `json:"side,omitempty"`
+	OrdType   string    `json:"ordType,omitempty"`
+	Price     string    `json:"price,omitempty"`
+	OrderQty  string    `json:"orderQty,omitempty"`
+	OrdStatus string    `json:"ordStatus,omitempty"`
+	CumQty    string    `json:"cumQty,omitempty"`
+	LeavesQty string    `json:"leavesQty,omitempty"`
+	AvgPx     string    `json:"avgPx,omitempty"`
+	Text      string    `json:"text,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// SideName returns the name of the side of the order.
+func (o *Order) SideName() string {
+	return sideName(o.Side)
+}
+
+// TypeName returns the name of the type of the order.
+func (o *Order) TypeName() string {
+	if name, ok := dict.OrderTypesReversed[enum.OrdType(o.OrdType)]; ok {
+		return name
+	}
+
+	return o.OrdType
+}
+
+// Message is a message of an order recorded.
+type Message struct {
+	Session   string    `json:"session"`
+	Direction string    `json:"direction"`
+	MsgType   string    `json:"msgType"`
+	SeqNum    int       `json:"seqNum"`
+	ClOrdID   string    `json:"clOrdID,omitempty"`
+	ExecType  string    `json:"execType,omitempty"`
+	OrdStatus string    `json:"ordStatus,omitempty"`
+	LastQty   string    `json:"lastQty,omitempty"`
+	LastPx    string    `json:"lastPx,omitempty"`
+	Time      time.Time `json:"time"`
+	Raw       string    `json:"raw"`
+}
+
+// Execution is a fill of an order recorded.
+type Execution struct {
+	ClOrdID   string    `json:"clOrdID"`
+	OrderID   string    `json:"orderID,omitempty"`
+	ExecID    string    `json:"execID,omitempty"`
+	Session   string    `json:"session"`
+	Symbol    string    `json:"symbol,omitempty"`
+	Side      string    `json:"side,omitempty"`
+	ExecType  string    `json:"execType,omitempty"`
+	OrdStatus string    `json:"ordStatus,omitempty"`
+	LastQty   string    `json:"lastQty"`
+	LastPx    string    `json:"lastPx,omitempty"`
+	CumQty    string    `json:"cumQty,omitempty"`
+	AvgPx     string    `json:"avgPx,omitempty"`
+	Time      time.Time `json:"time"`
+}
+
+// SideName returns the name of the side of the order filled.
+func (e *Execution) SideName() string {
+	return sideName(e.Side)
+}
+
+func sideName(side string) string {
+	if name, ok := dict.OrderSidesReversed[enum.Side(side)]; ok {
+		return name
+	}
+
+	return side
+}
+
+// Tracker records the orders in a SQLite database.
+type Tracker struct {
+	db  *sql.DB
+	mux sync.Mutex
+}
+
+var (
+	trackers    = make(map[string]*Tracker)
+	trackersMux sync.Mutex
+)
+
+// Open opens the database, creating it if needed. The sessions sharing a
+// database share its Tracker, which must not be closed.
+func Open(path string) (*Tracker, error) {
+	path = os.ExpandEnv(path)
+
+	trackersMux.Lock()
+	defer trackersMux.Unlock()
+
+	if t, ok := trackers[path]; ok {
+		return t, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+
+	db, err := openDatabase(path)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Tracker{db: db}
+	trackers[path] = t
+
+	return t, nil
+}
+
+// OpenExisting opens an existing database for the orders to be looked up, the
+// Tracker returned being closed once done with.
+func OpenExisting(path string) (*Tracker, error) {
+	path = os.ExpandEnv(path)
+
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+
+	db, err := openDatabase(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Tracker{db: db}, nil
+}
+
+func openDatabase(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite3", path+"?_busy_timeout=5000")
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("order tracker %s: %w", path, err)
+	}
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("order tracker %s: %w", path, err)
+	}
+
+	return db, nil
+}
+
+// Close closes the database.
+func (t *Tracker) Close() error {
+	return t.db.Close()
+}
+
+// trackedFields are the fields of the messages recorded.
+type trackedFields struct {
+	msgType     string
+	seqNum      int
+	clOrdID     string
+	origClOrdID string
+	orderID     string
+	execID      string
+	symbol      string
+	side        string
+	ordType     string
+	price       string
+	orderQty    string
+	ordStatus   string
+	execType    string
+	cumQty      string
+	leavesQty   string
+	avgPx       string
+	lastQty     string
+	lastPx      string
+	text        string
+}
+
+func parseTrackedFields(s []byte) trackedFields {
+	var f trackedFields
+
+	for len(s) > 0 {
+		field := s
+		if i := bytes.IndexByte(s, '\001'); i >= 0 {
+			field, s = s[:i], s[i+1:]
+		} else {
+			s = nil
+		}
+
+		eq := bytes.IndexByte(field, '=')
+		if eq < 0 {
+			continue
+		}
+		value := string(field[eq+1:])
+
+		switch string(field[:eq]) {
+		case "35":
+			f.msgType = value
+		case "34":
+			f.seqNum, _ = strconv.Atoi(value)
+		case "11":
+			f.clOrdID = value
+		case "41":
+			f.origClOrdID = value
+		case "37":
+			f.orderID = value
+		case "17":
+			f.execID = value
+		case "55":
+			f.symbol = value
+		case "54":
+			f.side = value
+		case "40":
+			f.ordType = value
+		case "44":
+			f.price = value
+		case "38":
+			f.orderQty = value
+		case "39":
+			f.ordStatus = value
+		case "150":
+			f.execType = value
+		case "14":
+			f.cumQty = value
+		case "151":
+			f.leavesQty = value
+		case "6":
+			f.avgPx = value
+		case "32":
+			f.lastQty = value
+		case "31":
+			f.lastPx = value
+		case "58":
+			f.text = value
+		}
+	}
+
+	return f
+}
+
+// Record records the raw message of the session if it is a NewOrderSingle, an
+// OrderCancelRequest or an OrderCancelReplaceRequest sent ("out") or an
+// ExecutionReport or an OrderCancelReject received ("in").
+func (t *Tracker) Record(session, direction string, s []byte) error {
+	f := parseTrackedFields(s)
+
+	switch {
+	case direction == "out" && (f.msgType == "D" || f.msgType == "F" || f.msgType == "G"):
+	case direction == "in" && (f.msgType == "8" || f.msgType == "9"):
+	default:
+		return nil
+	}
+
+	if len(f.clOrdID) == 0 {
+		return nil
+	}
+
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	tx, err := t.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	root, err := resolveRoot(tx, f)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+
+	if _, err := tx.Exec(`INSERT OR IGNORE INTO order_aliases (cl_ord_id, root) VALUES (?, ?)`, f.clOrdID, root); err != nil {
+		return err
+	}
+
+	// Orders not sent through the tracker are recorded from their first
+	// execution report
+	if _, err := tx.Exec(`INSERT OR IGNORE INTO orders (cl_ord_id, session, symbol, side, ord_type, price, order_qty, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		root, session, f.symbol, f.side, f.ordType, f.price, f.orderQty, now, now); err != nil {
+		return err
+	}
+
+	if f.msgType == "8" {
+		if _, err := tx.Exec(`UPDATE orders SET
+			order_id = COALESCE(NULLIF(?, ''), order_id),
+			price = COALESCE(NULLIF(?, ''), price),
+			order_qty = COALESCE(NULLIF(?, ''), order_qty),
+			ord_status = COALESCE(NULLIF(?, ''), ord_status),
+			cum_qty = COALESCE(NULLIF(?, ''), cum_qty),
+			leaves_qty = COALESCE(NULLIF(?, ''), leaves_qty),
+			avg_px = COALESCE(NULLIF(?, ''), avg_px),
+			text = ?,
+			updated_at = ?
+			WHERE cl_ord_id = ?`,
+			f.orderID, f.price, f.orderQty, f.ordStatus, f.cumQty, f.leavesQty, f.avgPx, f.text, now, root); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(`INSERT INTO order_messages (root, session, direction, msg_type, seq_num, cl_ord_id, exec_type, ord_status, last_qty, last_px, exec_id, cum_qty, avg_px, time, raw)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		root, session, direction, f.msgType, f.seqNum, f.clOrdID, f.execType, f.ordStatus, f.lastQty, f.lastPx, f.execID, f.cumQty, f.avgPx, now, strings.ReplaceAll(string(s), "\001", "|")); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// resolveRoot returns the ClOrdID of the order the message refers to.
+func resolveRoot(tx *sql.Tx, f trackedFields) (string, error) {
+	for _, id := range []string{f.clOrdID, f.origClOrdID} {
+		if len(id) == 0 {
+			continue
+		}
+
+		var root string
+		err := tx.QueryRow(`SELECT root FROM order_aliases WHERE cl_ord_id = ?`, id).Scan(&root)
+		if err == nil {
+			return root, nil
+		} else if err != sql.ErrNoRows {
+			return "", err
+		}
+	}
+
+	if len(f.origClOrdID) > 0 && f.msgType != "D" {
+		return f.origClOrdID, nil
+	}
+
+	return f.clOrdID, nil
+}
+
+// ListOptions are the criteria of the orders listed.
+type ListOptions struct {
+	Session string
+	Symbol  string
+	Since   time.Time
+	// Until, when not zero, excludes the orders created from then on
+	Until time.Time
+	Limit int
+	// Working only lists the orders which have not reached a final OrdStatus
+	Working bool
+}
+
+// finalOrdStatuses are the OrdStatus of the orders which can no longer change:
+// Filled, DoneForDay, Canceled, Rejected and Expired.
+const finalOrdStatuses = `('2', '3', '4', '8', 'C')`
+
+// List returns the orders matching the options, the most recent first.
+func (t *Tracker) List(options ListOptions) ([]*Order, error) {
+	query := `SELECT ` + orderColumns + ` FROM orders WHERE created_at >= ?`
+	args := []interface{}{options.Since.UTC()}
+
+	if len(options.Session) > 0 {
+		query += ` AND session = ?`
+		args = append(args, options.Session)
+	}
+	if len(options.Symbol) > 0 {
+		query += ` AND symbol = ?`
+		args = append(args, options.Symbol)
+	}
+	if !options.Until.IsZero() {
+		query += ` AND created_at < ?`
+		args = append(args, options.Until.UTC())
+	}
+	if options.Working {
+		query += ` AND ord_status NOT IN ` + finalOrdStatuses
+	}
+
+	query += ` ORDER BY created_at DESC`
+	if options.Limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, options.Limit)
+	}
+
+	rows, err := t.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orders []*Order
+	for rows.Next() {
+		order, err := scanOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+
+	return orders, rows.Err()
+}
+
+// Find returns the order with the given ClOrdID, one of the ClOrdIDs of its
+// cancel or replace requests, or OrderID.
+func (t *Tracker) Find(id string) (*Order, error) {
+	row := t.db.QueryRow(`SELECT `+orderColumns+` FROM orders
+		WHERE cl_ord_id = COALESCE((SELECT root FROM order_aliases WHERE cl_ord_id = ?), ?) OR order_id = ?
+		ORDER BY created_at DESC LIMIT 1`, id, id, id)
+
+	order, err := scanOrder(row)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("unknown order %s", id)
+	}
+
+	return order, err
+}
+
+// Messages returns the messages of the order in the order they were recorded.
+func (t *Tracker) Messages(order *Order) ([]*Message, error) {
+	rows, err := t.db.Query(`SELECT session, direction, msg_type, seq_num, cl_ord_id, exec_type, ord_status, last_qty, last_px, time, raw
+		FROM order_messages WHERE root = ? ORDER BY id`, order.ClOrdID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []*Message
+	for rows.Next() {
+		m := &Message{}
+		if err := rows.Scan(&m.Session, &m.Direction, &m.MsgType, &m.SeqNum, &m.ClOrdID, &m.ExecType, &m.OrdStatus, &m.LastQty, &m.LastPx, &m.Time, &m.Raw); err != nil {
+			return nil, err
+		}
+		messages = append(messages, m)
+	}
+
+	return messages, rows.Err()
+}
+
+// Executions returns the fills of the orders matching the options, received
+// between Since and Until, the most recent first.
+func (t *Tracker) Executions(options ListOptions) ([]*Execution, error) {
+	query := `SELECT m.root, o.order_id, m.exec_id, m.session, o.symbol, o.side, m.exec_type, m.ord_status, m.last_qty, m.last_px, m.cum_qty, m.avg_px, m.time
+		FROM order_messages m JOIN orders o ON o.cl_ord_id = m.root
+		WHERE m.msg_type = '8' AND m.last_qty NOT IN ('', '0') AND m.time >= ?`
+	args := []interface{}{options.Since.UTC()}
+
+	if len(options.Session) > 0 {
+		query += ` AND m.session = ?`
+		args = append(args, options.Session)
+	}
+	if len(options.Symbol) > 0 {
+		query += ` AND o.symbol = ?`
+		args = append(args, options.Symbol)
+	}
+	if !options.Until.IsZero() {
+		query += ` AND m.time < ?`
+		args = append(args, options.Until.UTC())
+	}
+
+	query += ` ORDER BY m.id DESC`
+	if options.Limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, options.Limit)
+	}
+
+	rows, err := t.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var executions []*Execution
+	for rows.Next() {
+		e := &Execution{}
+		if err := rows.Scan(&e.ClOrdID, &e.OrderID, &e.ExecID, &e.Session, &e.Symbol, &e.Side, &e.ExecType, &e.OrdStatus, &e.LastQty, &e.LastPx, &e.CumQty, &e.AvgPx, &e.Time); err != nil {
+			return nil, err
+		}
+		executions = append(executions, e)
+	}
+
+	return executions, rows.Err()
+}
+
+const orderColumns = `cl_ord_id, session, order_id, symbol, side, ord_type, price, order_qty, ord_status, cum_qty, leaves_qty, avg_px, text, created_at, updated_at`
+
+func scanOrder(row interface{ Scan(...interface{}) error }) (*Order, error) {
+	o := &Order{}
+	err := row.Scan(&o.ClOrdID, &o.Session, &o.OrderID, &o.Symbol, &o.Side, &o.OrdType, &o.Price, &o.OrderQty,
+		&o.OrdStatus, &o.CumQty, &o.LeavesQty, &o.AvgPx, &o.Text, &o.CreatedAt, &o.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return o, nil
+}
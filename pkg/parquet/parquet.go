@@ -0,0 +1,344 @@
+// Package parquet writes flat tables as Apache Parquet files.
+//
+// It only implements what exporting tables needs: optional columns of strings,
+// doubles and timestamps, written uncompressed with the PLAIN encoding in a
+// single row group. The rows are buffered until the writer is closed.
+package parquet
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// ColumnType is the type of the values of a column.
+type ColumnType int
+
+const (
+	// String columns hold UTF-8 strings.
+	String ColumnType = iota
+	// Double columns hold float64 values.
+	Double
+	// Timestamp columns hold time.Time values, stored as UTC microseconds.
+	Timestamp
+)
+
+// Column describes a column of the file.
+type Column struct {
+	Name string
+	Type ColumnType
+}
+
+// Parquet physical types, converted types, encodings and thrift compact
+// protocol types, see parquet-format's parquet.thrift.
+const (
+	typeInt64     = 2
+	typeDouble    = 5
+	typeByteArray = 6
+
+	convertedUTF8            = 0
+	convertedTimestampMicros = 10
+
+	repetitionOptional = 1
+
+	encodingPlain = 0
+	encodingRLE   = 3
+
+	pageTypeData = 0
+
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+var magic = []byte("PAR1")
+
+// Writer writes a Parquet file.
+type Writer struct {
+	w       io.Writer
+	columns []Column
+	// defined tells, for each column, which of the values are not null
+	defined [][]bool
+	values  [][]byte
+	rows    int
+}
+
+// NewWriter returns a Writer of a file with the given columns.
+func NewWriter(w io.Writer, columns []Column) *Writer {
+	return &Writer{
+		w:       w,
+		columns: columns,
+		defined: make([][]bool, len(columns)),
+		values:  make([][]byte, len(columns)),
+	}
+}
+
+// Write adds a row, with one value per column: a string for String columns, a
+// float64 for Double ones and a time.Time for Timestamp ones. Nil values, and
+// zero times, are null.
+func (pw *Writer) Write(row ...interface{}) error {
+	if len(row) != len(pw.columns) {
+		return fmt.Errorf("parquet: %d values given for %d columns", len(row), len(pw.columns))
+	}
+
+	for i, value := range row {
+		if t, ok := value.(time.Time); value == nil || ok && t.IsZero() {
+			pw.defined[i] = append(pw.defined[i], false)
+			continue
+		}
+
+		var b []byte
+		switch pw.columns[i].Type {
+		case String:
+			s, ok := value.(string)
+			if !ok {
+				return fmt.Errorf("parquet: column %s: %T is not a string", pw.columns[i].Name, value)
+			}
+			b = binary.LittleEndian.AppendUint32(pw.values[i], uint32(len(s)))
+			b = append(b, s...)
+		case Double:
+			f, ok := value.(float64)
+			if !ok {
+				return fmt.Errorf("parquet: column %s: %T is not a float64", pw.columns[i].Name, value)
+			}
+			b = binary.LittleEndian.AppendUint64(pw.values[i], math.Float64bits(f))
+		case Timestamp:
+			t, ok := value.(time.Time)
+			if !ok {
+				return fmt.Errorf("parquet: column %s: %T is not a time.Time", pw.columns[i].Name, value)
+			}
+			b = binary.LittleEndian.AppendUint64(pw.values[i], uint64(t.UnixMicro()))
+		}
+
+		pw.values[i] = b
+		pw.defined[i] = append(pw.defined[i], true)
+	}
+
+	pw.rows++
+
+	return nil
+}
+
+// Close writes the file, the underlying writer being left open.
+func (pw *Writer) Close() error {
+	var file []byte
+	file = append(file, magic...)
+
+	chunks := make([][]byte, len(pw.columns))
+	var totalSize int64
+
+	for i, column := range pw.columns {
+		levels := definitionLevels(pw.defined[i])
+
+		page := binary.LittleEndian.AppendUint32(nil, uint32(len(levels)))
+		page = append(page, levels...)
+		page = append(page, pw.values[i]...)
+
+		var header thriftWriter
+		header.i32(1, pageTypeData)
+		header.i32(2, int32(len(page)))
+		header.i32(3, int32(len(page)))
+		header.beginStruct(5)
+		header.i32(1, int32(pw.rows))
+		header.i32(2, encodingPlain)
+		header.i32(3, encodingRLE)
+		header.i32(4, encodingRLE)
+		header.endStruct()
+		header.stop()
+
+		offset := int64(len(file))
+		size := int64(len(header.b) + len(page))
+		totalSize += size
+
+		file = append(file, header.b...)
+		file = append(file, page...)
+
+		var chunk thriftWriter
+		chunk.i64(2, offset)
+		chunk.beginStruct(3)
+		chunk.i32(1, column.physicalType())
+		chunk.listI32(2, encodingPlain, encodingRLE)
+		chunk.listBinary(3, column.Name)
+		chunk.i32(4, 0) // UNCOMPRESSED
+		chunk.i64(5, int64(pw.rows))
+		chunk.i64(6, size)
+		chunk.i64(7, size)
+		chunk.i64(9, offset)
+		chunk.endStruct()
+		chunk.stop()
+		chunks[i] = chunk.b
+	}
+
+	var footer thriftWriter
+	footer.i32(1, 1)
+
+	footer.beginList(2, thriftStruct, len(pw.columns)+1)
+	footer.binary(4, "schema")
+	footer.i32(5, int32(len(pw.columns)))
+	footer.stop()
+	for _, column := range pw.columns {
+		footer.i32(1, column.physicalType())
+		footer.i32(3, repetitionOptional)
+		footer.binary(4, column.Name)
+		switch column.Type {
+		case String:
+			footer.i32(6, convertedUTF8)
+		case Timestamp:
+			footer.i32(6, convertedTimestampMicros)
+		}
+		footer.stop()
+	}
+	footer.endList()
+
+	footer.i64(3, int64(pw.rows))
+
+	footer.beginList(4, thriftStruct, 1)
+	footer.beginList(1, thriftStruct, len(chunks))
+	for _, chunk := range chunks {
+		footer.b = append(footer.b, chunk...)
+	}
+	footer.endList()
+	footer.i64(2, totalSize)
+	footer.i64(3, int64(pw.rows))
+	footer.stop()
+	footer.endList()
+
+	footer.binary(6, "sylr.dev/fix")
+	footer.stop()
+
+	file = append(file, footer.b...)
+	file = binary.LittleEndian.AppendUint32(file, uint32(len(footer.b)))
+	file = append(file, magic...)
+
+	_, err := pw.w.Write(file)
+
+	return err
+}
+
+func (c Column) physicalType() int32 {
+	switch c.Type {
+	case Double:
+		return typeDouble
+	case Timestamp:
+		return typeInt64
+	default:
+		return typeByteArray
+	}
+}
+
+// definitionLevels encodes the definition levels of an optional column, 1 for
+// the values and 0 for the nulls, as a single bit-packed run of the RLE/bit
+// packing hybrid encoding, padded to a multiple of 8 values.
+func definitionLevels(defined []bool) []byte {
+	groups := (len(defined) + 7) / 8
+
+	b := binary.AppendUvarint(nil, uint64(groups)<<1|1)
+	packed := make([]byte, groups)
+	for i, d := range defined {
+		if d {
+			packed[i/8] |= 1 << (i % 8)
+		}
+	}
+
+	return append(b, packed...)
+}
+
+// thriftWriter encodes structs with the thrift compact protocol, which the
+// Parquet metadata uses.
+type thriftWriter struct {
+	b []byte
+	// last is the id of the last field written in each of the structs being
+	// written, field ids being encoded as deltas
+	last []int16
+}
+
+func (t *thriftWriter) field(id int16, typ byte) {
+	if len(t.last) == 0 {
+		t.last = append(t.last, 0)
+	}
+
+	last := &t.last[len(t.last)-1]
+	if delta := id - *last; delta > 0 && delta <= 15 {
+		t.b = append(t.b, byte(delta)<<4|typ)
+	} else {
+		t.b = append(t.b, typ)
+		t.b = binary.AppendVarint(t.b, int64(id))
+	}
+	*last = id
+}
+
+func (t *thriftWriter) i32(id int16, v int32) {
+	t.field(id, thriftI32)
+	t.b = binary.AppendVarint(t.b, int64(v))
+}
+
+func (t *thriftWriter) i64(id int16, v int64) {
+	t.field(id, thriftI64)
+	t.b = binary.AppendVarint(t.b, v)
+}
+
+func (t *thriftWriter) binary(id int16, s string) {
+	t.field(id, thriftBinary)
+	t.b = binary.AppendUvarint(t.b, uint64(len(s)))
+	t.b = append(t.b, s...)
+}
+
+func (t *thriftWriter) listHeader(typ byte, size int) {
+	if size < 15 {
+		t.b = append(t.b, byte(size)<<4|typ)
+	} else {
+		t.b = append(t.b, 0xf0|typ)
+		t.b = binary.AppendUvarint(t.b, uint64(size))
+	}
+}
+
+func (t *thriftWriter) listI32(id int16, values ...int32) {
+	t.field(id, thriftList)
+	t.listHeader(thriftI32, len(values))
+	for _, v := range values {
+		t.b = binary.AppendVarint(t.b, int64(v))
+	}
+}
+
+func (t *thriftWriter) listBinary(id int16, values ...string) {
+	t.field(id, thriftList)
+	t.listHeader(thriftBinary, len(values))
+	for _, v := range values {
+		t.b = binary.AppendUvarint(t.b, uint64(len(v)))
+		t.b = append(t.b, v...)
+	}
+}
+
+// beginList starts a list of structs, each of them being ended by stop.
+func (t *thriftWriter) beginList(id int16, typ byte, size int) {
+	t.field(id, thriftList)
+	t.listHeader(typ, size)
+	t.last = append(t.last, 0)
+}
+
+func (t *thriftWriter) endList() {
+	t.last = t.last[:len(t.last)-1]
+}
+
+func (t *thriftWriter) beginStruct(id int16) {
+	t.field(id, thriftStruct)
+	t.last = append(t.last, 0)
+}
+
+func (t *thriftWriter) endStruct() {
+	t.b = append(t.b, 0)
+	t.last = t.last[:len(t.last)-1]
+}
+
+// stop ends a struct of a list, or the top level one, the field ids of the
+// next struct of the list starting over.
+func (t *thriftWriter) stop() {
+	t.b = append(t.b, 0)
+	if len(t.last) > 0 {
+		t.last[len(t.last)-1] = 0
+	}
+}
@@ -0,0 +1,107 @@
+package parquet
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	pq "github.com/parquet-go/parquet-go"
+)
+
+// TestWriter reads the file written back with another implementation.
+func TestWriter(t *testing.T) {
+	columns := []Column{
+		{Name: "time", Type: Timestamp},
+		{Name: "symbol", Type: String},
+		{Name: "price", Type: Double},
+	}
+	now := time.Date(2024, 4, 17, 9, 30, 0, 123456000, time.UTC)
+	rows := [][]interface{}{
+		{now, "EURUSD", 1.0825},
+		{time.Time{}, "", nil},
+		{now.Add(time.Second), nil, -0.5},
+	}
+	// More rows than a byte of definition levels holds
+	for i := 0; i < 10; i++ {
+		rows = append(rows, []interface{}{now, "GBPUSD", float64(i)})
+	}
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf, columns)
+	for _, row := range rows {
+		if err := w.Write(row...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := pq.OpenFile(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := f.NumRows(); got != int64(len(rows)) {
+		t.Fatalf("got %d rows, want %d", got, len(rows))
+	}
+
+	fields := f.Schema().Fields()
+	if len(fields) != len(columns) {
+		t.Fatalf("got %d columns, want %d", len(fields), len(columns))
+	}
+	for i, field := range fields {
+		if field.Name() != columns[i].Name || !field.Optional() {
+			t.Errorf("column %d: got %s optional %t, want optional %s", i, field.Name(), field.Optional(), columns[i].Name)
+		}
+	}
+	if typ := fields[0].Type().LogicalType(); typ == nil || typ.Timestamp == nil {
+		t.Errorf("time column: got logical type %v, want a timestamp", typ)
+	}
+	if typ := fields[1].Type().LogicalType(); typ == nil || typ.UTF8 == nil {
+		t.Errorf("symbol column: got logical type %v, want a string", typ)
+	}
+
+	read := make([]pq.Row, len(rows)+1)
+	reader := f.RowGroups()[0].Rows()
+	defer reader.Close()
+	n, err := reader.ReadRows(read)
+	if err != nil && err != io.EOF {
+		t.Fatal(err)
+	}
+	if n != len(rows) {
+		t.Fatalf("read %d rows, want %d", n, len(rows))
+	}
+
+	for i, row := range rows {
+		values := make([]pq.Value, len(columns))
+		for _, value := range read[i] {
+			values[value.Column()] = value
+		}
+
+		for j, want := range row {
+			got := values[j]
+			if tm, ok := want.(time.Time); want == nil || ok && tm.IsZero() {
+				if !got.IsNull() {
+					t.Errorf("row %d column %s: got %v, want null", i, columns[j].Name, got)
+				}
+				continue
+			}
+
+			switch want := want.(type) {
+			case time.Time:
+				if got.IsNull() || got.Int64() != want.UnixMicro() {
+					t.Errorf("row %d column %s: got %v, want %d", i, columns[j].Name, got, want.UnixMicro())
+				}
+			case string:
+				if got.IsNull() || got.String() != want {
+					t.Errorf("row %d column %s: got %v, want %q", i, columns[j].Name, got, want)
+				}
+			case float64:
+				if got.IsNull() || got.Double() != want {
+					t.Errorf("row %d column %s: got %v, want %v", i, columns[j].Name, got, want)
+				}
+			}
+		}
+	}
+}
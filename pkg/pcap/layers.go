@@ -0,0 +1,175 @@
+package pcap
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+)
+
+const (
+	etherTypeIPv4 = 0x0800
+	etherTypeIPv6 = 0x86dd
+	etherTypeVLAN = 0x8100
+	etherTypeQinQ = 0x88a8
+
+	ipProtocolTCP = 6
+)
+
+const (
+	tcpFlagFIN = 0x01
+	tcpFlagSYN = 0x02
+	tcpFlagRST = 0x04
+)
+
+// Endpoint is an IP address and a TCP port.
+type Endpoint struct {
+	IP   string
+	Port uint16
+}
+
+func (e Endpoint) String() string {
+	return net.JoinHostPort(e.IP, fmt.Sprint(e.Port))
+}
+
+// Flow is one direction of a TCP connection.
+type Flow struct {
+	Src Endpoint
+	Dst Endpoint
+}
+
+func (f Flow) String() string {
+	return f.Src.String() + " -> " + f.Dst.String()
+}
+
+// Reverse returns the other direction of the connection.
+func (f Flow) Reverse() Flow {
+	return Flow{Src: f.Dst, Dst: f.Src}
+}
+
+// Segment is a TCP segment.
+type Segment struct {
+	Flow    Flow
+	Seq     uint32
+	SYN     bool
+	FIN     bool
+	RST     bool
+	Payload []byte
+}
+
+// DecodeTCP decodes the TCP segment held by the packet. It returns false if
+// the packet does not hold a TCP segment.
+func DecodeTCP(packet *Packet) (*Segment, bool) {
+	data := packet.Data
+
+	var etherType uint16
+
+	switch packet.LinkType {
+	case LinkTypeEthernet:
+		if len(data) < 14 {
+			return nil, false
+		}
+		etherType, data = binary.BigEndian.Uint16(data[12:14]), data[14:]
+		for etherType == etherTypeVLAN || etherType == etherTypeQinQ {
+			if len(data) < 4 {
+				return nil, false
+			}
+			etherType, data = binary.BigEndian.Uint16(data[2:4]), data[4:]
+		}
+
+	case LinkTypeLinuxSLL:
+		if len(data) < 16 {
+			return nil, false
+		}
+		etherType, data = binary.BigEndian.Uint16(data[14:16]), data[16:]
+
+	case LinkTypeNull:
+		if len(data) < 4 {
+			return nil, false
+		}
+		// The address family is in the host byte order of the capturing
+		// machine, IPv6 has several values depending on the OS.
+		switch binary.LittleEndian.Uint32(data[0:4]) {
+		case 2, 0x02000000:
+			etherType = etherTypeIPv4
+		default:
+			etherType = etherTypeIPv6
+		}
+		data = data[4:]
+
+	case LinkTypeRaw, LinkTypeIPv4, LinkTypeIPv6:
+		if len(data) < 1 {
+			return nil, false
+		}
+		etherType = etherTypeIPv4
+		if data[0]>>4 == 6 {
+			etherType = etherTypeIPv6
+		}
+
+	default:
+		return nil, false
+	}
+
+	var src, dst net.IP
+
+	switch etherType {
+	case etherTypeIPv4:
+		if len(data) < 20 || data[0]>>4 != 4 {
+			return nil, false
+		}
+		headerLength := int(data[0]&0x0f) * 4
+		totalLength := int(binary.BigEndian.Uint16(data[2:4]))
+		// Fragmented packets are not supported
+		if data[9] != ipProtocolTCP || binary.BigEndian.Uint16(data[6:8])&0x3fff != 0 {
+			return nil, false
+		}
+		if headerLength < 20 || totalLength < headerLength || len(data) < headerLength {
+			return nil, false
+		}
+		// Ethernet frames can be padded
+		if totalLength < len(data) {
+			data = data[:totalLength]
+		}
+		src, dst = net.IP(data[12:16]), net.IP(data[16:20])
+		data = data[headerLength:]
+
+	case etherTypeIPv6:
+		if len(data) < 40 || data[0]>>4 != 6 {
+			return nil, false
+		}
+		// Extension headers are not supported
+		if data[6] != ipProtocolTCP {
+			return nil, false
+		}
+		payloadLength := int(binary.BigEndian.Uint16(data[4:6]))
+		src, dst = net.IP(data[8:24]), net.IP(data[24:40])
+		data = data[40:]
+		if payloadLength < len(data) {
+			data = data[:payloadLength]
+		}
+
+	default:
+		return nil, false
+	}
+
+	if len(data) < 20 {
+		return nil, false
+	}
+
+	offset := int(data[12]>>4) * 4
+	if offset < 20 || offset > len(data) {
+		return nil, false
+	}
+	flags := data[13]
+
+	return &Segment{
+		Flow: Flow{
+			Src: Endpoint{IP: src.String(), Port: binary.BigEndian.Uint16(data[0:2])},
+			Dst: Endpoint{IP: dst.String(), Port: binary.BigEndian.Uint16(data[2:4])},
+		},
+		Seq:     binary.BigEndian.Uint32(data[4:8]),
+		SYN:     flags&tcpFlagSYN != 0,
+		FIN:     flags&tcpFlagFIN != 0,
+		RST:     flags&tcpFlagRST != 0,
+		Payload: data[offset:],
+	}, true
+}
@@ -0,0 +1,270 @@
+// Package pcap reads packet captures (pcap and pcapng) and reassembles the TCP
+// streams they hold in order to extract the FIX messages exchanged.
+package pcap
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"sylr.dev/fix/pkg/errors"
+)
+
+// Link types supported.
+const (
+	LinkTypeNull     = 0
+	LinkTypeEthernet = 1
+	LinkTypeRaw      = 101
+	LinkTypeLinuxSLL = 113
+	LinkTypeIPv4     = 228
+	LinkTypeIPv6     = 229
+)
+
+const (
+	pcapMagicMicro        = 0xa1b2c3d4
+	pcapMagicNano         = 0xa1b23c4d
+	pcapngSectionHeader   = 0x0a0d0d0a
+	pcapngByteOrderMagic  = 0x1a2b3c4d
+	pcapngInterfaceDesc   = 0x00000001
+	pcapngEnhancedPacket  = 0x00000006
+	pcapngSimplePacket    = 0x00000003
+	pcapngOptionTSResol   = 9
+	pcapngOptionEndOfOpts = 0
+)
+
+// Packet is a captured packet.
+type Packet struct {
+	Time     time.Time
+	LinkType int
+	Data     []byte
+}
+
+type pcapngInterface struct {
+	linkType int
+	// units of the timestamps per second
+	resolution uint64
+}
+
+// Reader reads the packets of a pcap or pcapng capture.
+type Reader struct {
+	r     io.Reader
+	order binary.ByteOrder
+	ng    bool
+
+	// pcap
+	linkType int
+	nano     bool
+
+	// pcapng
+	interfaces []pcapngInterface
+}
+
+// IsCapture tells whether the bytes are the beginning of a pcap or a pcapng
+// capture, at least 4 of them being needed.
+func IsCapture(magic []byte) bool {
+	if len(magic) < 4 {
+		return false
+	}
+
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		switch order.Uint32(magic) {
+		case pcapngSectionHeader, pcapMagicMicro, pcapMagicNano:
+			return true
+		}
+	}
+
+	return false
+}
+
+// NewReader reads the header of the capture.
+func NewReader(r io.Reader) (*Reader, error) {
+	reader := &Reader{r: r}
+
+	var magic [4]byte
+	if _, err := io.ReadFull(r, magic[:]); err != nil {
+		return nil, err
+	}
+
+	if binary.LittleEndian.Uint32(magic[:]) == pcapngSectionHeader {
+		reader.ng = true
+		if err := reader.readSectionHeader(); err != nil {
+			return nil, err
+		}
+		return reader, nil
+	}
+
+	switch {
+	case binary.LittleEndian.Uint32(magic[:]) == pcapMagicMicro:
+		reader.order = binary.LittleEndian
+	case binary.LittleEndian.Uint32(magic[:]) == pcapMagicNano:
+		reader.order, reader.nano = binary.LittleEndian, true
+	case binary.BigEndian.Uint32(magic[:]) == pcapMagicMicro:
+		reader.order = binary.BigEndian
+	case binary.BigEndian.Uint32(magic[:]) == pcapMagicNano:
+		reader.order, reader.nano = binary.BigEndian, true
+	default:
+		return nil, fmt.Errorf("%w: not a pcap or pcapng capture", errors.Options)
+	}
+
+	var header [20]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	reader.linkType = int(reader.order.Uint32(header[16:20]) & 0x0fffffff)
+
+	return reader, nil
+}
+
+// Next returns the next packet of the capture or io.EOF.
+func (r *Reader) Next() (*Packet, error) {
+	if r.ng {
+		return r.nextNG()
+	}
+
+	var header [16]byte
+	if _, err := io.ReadFull(r.r, header[:]); err != nil {
+		return nil, err
+	}
+
+	sec := int64(r.order.Uint32(header[0:4]))
+	frac := int64(r.order.Uint32(header[4:8]))
+	length := r.order.Uint32(header[8:12])
+
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r.r, data); err != nil {
+		return nil, err
+	}
+
+	if !r.nano {
+		frac *= 1000
+	}
+
+	return &Packet{Time: time.Unix(sec, frac).UTC(), LinkType: r.linkType, Data: data}, nil
+}
+
+// readSectionHeader reads the section header block whose type has already
+// been read.
+func (r *Reader) readSectionHeader() error {
+	var lengthAndMagic [8]byte
+	if _, err := io.ReadFull(r.r, lengthAndMagic[:]); err != nil {
+		return err
+	}
+
+	switch {
+	case binary.LittleEndian.Uint32(lengthAndMagic[4:]) == pcapngByteOrderMagic:
+		r.order = binary.LittleEndian
+	case binary.BigEndian.Uint32(lengthAndMagic[4:]) == pcapngByteOrderMagic:
+		r.order = binary.BigEndian
+	default:
+		return fmt.Errorf("%w: invalid pcapng section header", errors.Options)
+	}
+
+	length := r.order.Uint32(lengthAndMagic[:4])
+	if length < 12 {
+		return fmt.Errorf("%w: invalid pcapng block length", errors.Options)
+	}
+
+	// Skip the rest of the block
+	_, err := io.CopyN(io.Discard, r.r, int64(length)-12)
+	r.interfaces = nil
+
+	return err
+}
+
+func (r *Reader) nextNG() (*Packet, error) {
+	for {
+		var header [8]byte
+		if _, err := io.ReadFull(r.r, header[:]); err != nil {
+			return nil, err
+		}
+
+		typ := r.order.Uint32(header[0:4])
+		if typ == pcapngSectionHeader {
+			if err := r.readSectionHeader(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		length := r.order.Uint32(header[4:8])
+		if length < 12 {
+			return nil, fmt.Errorf("%w: invalid pcapng block length", errors.Options)
+		}
+
+		body := make([]byte, length-8)
+		if _, err := io.ReadFull(r.r, body); err != nil {
+			return nil, err
+		}
+		// Remove the trailing block length
+		body = body[:len(body)-4]
+
+		switch typ {
+		case pcapngInterfaceDesc:
+			if len(body) < 8 {
+				return nil, fmt.Errorf("%w: invalid pcapng interface block", errors.Options)
+			}
+			r.interfaces = append(r.interfaces, pcapngInterface{
+				linkType:   int(r.order.Uint16(body[0:2])),
+				resolution: r.tsResolution(body[8:]),
+			})
+
+		case pcapngEnhancedPacket:
+			if len(body) < 20 {
+				return nil, fmt.Errorf("%w: invalid pcapng packet block", errors.Options)
+			}
+			id := int(r.order.Uint32(body[0:4]))
+			if id >= len(r.interfaces) {
+				return nil, fmt.Errorf("%w: unknown pcapng interface %d", errors.Options, id)
+			}
+			iface := r.interfaces[id]
+
+			ts := uint64(r.order.Uint32(body[4:8]))<<32 | uint64(r.order.Uint32(body[8:12]))
+			captured := int(r.order.Uint32(body[12:16]))
+			if 20+captured > len(body) {
+				return nil, fmt.Errorf("%w: truncated pcapng packet block", errors.Options)
+			}
+
+			sec := ts / iface.resolution
+			nsec := (ts % iface.resolution) * uint64(time.Second) / iface.resolution
+
+			return &Packet{
+				Time:     time.Unix(int64(sec), int64(nsec)).UTC(),
+				LinkType: iface.linkType,
+				Data:     body[20 : 20+captured],
+			}, nil
+
+		case pcapngSimplePacket:
+			if len(r.interfaces) == 0 || len(body) < 4 {
+				continue
+			}
+			return &Packet{LinkType: r.interfaces[0].linkType, Data: body[4:]}, nil
+		}
+	}
+}
+
+// tsResolution returns the timestamps resolution given in the options of an
+// interface description block, microseconds by default.
+func (r *Reader) tsResolution(options []byte) uint64 {
+	for len(options) >= 4 {
+		code := r.order.Uint16(options[0:2])
+		length := int(r.order.Uint16(options[2:4]))
+		if code == pcapngOptionEndOfOpts || 4+length > len(options) {
+			break
+		}
+
+		if code == pcapngOptionTSResol && length >= 1 {
+			v := options[4]
+			if v&0x80 != 0 {
+				return uint64(math.Pow(2, float64(v&0x7f)))
+			}
+			return uint64(math.Pow(10, float64(v)))
+		}
+
+		// Options are padded to 32 bits
+		options = options[4+(length+3)&^3:]
+	}
+
+	return 1000000
+}
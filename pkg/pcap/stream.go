@@ -0,0 +1,221 @@
+package pcap
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// maxPendingSegments is the number of out of order segments kept for a stream
+// before giving up on the missing data and resuming after the gap.
+const maxPendingSegments = 256
+
+var (
+	fixBeginString = []byte("8=FIX")
+	fixCheckSum    = regexp.MustCompile("\x0110=[0-9]{3}\x01")
+)
+
+// Message is a FIX message extracted from a TCP stream.
+type Message struct {
+	// Time of the packet which completed the message
+	Time time.Time
+	Flow Flow
+	Raw  string
+}
+
+type stream struct {
+	started bool
+	next    uint32
+	pending map[uint32][]byte
+	buf     []byte
+}
+
+// Assembler reassembles the TCP streams and extracts the FIX messages they
+// carry. Retransmitted and out of order segments are handled using the
+// sequence numbers.
+type Assembler struct {
+	streams map[Flow]*stream
+	// Gaps counts the data missing from the capture which had to be skipped.
+	Gaps int
+}
+
+func NewAssembler() *Assembler {
+	return &Assembler{
+		streams: make(map[Flow]*stream),
+	}
+}
+
+// Add adds the segment of the packet to its stream and returns the messages
+// it completes.
+func (a *Assembler) Add(t time.Time, segment *Segment) []Message {
+	if segment.RST {
+		delete(a.streams, segment.Flow)
+		return nil
+	}
+
+	s, ok := a.streams[segment.Flow]
+	if !ok || segment.SYN {
+		s = &stream{pending: make(map[uint32][]byte)}
+		a.streams[segment.Flow] = s
+	}
+
+	seq := segment.Seq
+	if segment.SYN {
+		seq++
+		s.started, s.next = true, seq
+	} else if !s.started {
+		// The capture started in the middle of the connection
+		s.started, s.next = true, seq
+	}
+
+	if len(segment.Payload) == 0 {
+		return nil
+	}
+
+	if diff := int32(seq - s.next); diff > 0 {
+		if previous, ok := s.pending[seq]; !ok || len(previous) < len(segment.Payload) {
+			s.pending[seq] = append([]byte(nil), segment.Payload...)
+		}
+		if len(s.pending) <= maxPendingSegments {
+			return nil
+		}
+		a.skipGap(s)
+	} else {
+		s.append(seq, segment.Payload)
+	}
+
+	s.drainPending()
+
+	raws, rest := extractFIXMessages(s.buf)
+	s.buf = rest
+
+	messages := make([]Message, 0, len(raws))
+	for _, raw := range raws {
+		messages = append(messages, Message{Time: t, Flow: segment.Flow, Raw: raw})
+	}
+
+	return messages
+}
+
+// skipGap resumes the stream at the first pending segment, dropping the
+// incomplete message being reassembled.
+func (a *Assembler) skipGap(s *stream) {
+	first := true
+	var lowest uint32
+	for seq := range s.pending {
+		if first || int32(seq-lowest) < 0 {
+			lowest, first = seq, false
+		}
+	}
+
+	a.Gaps++
+	s.next = lowest
+	s.buf = nil
+}
+
+// append appends the payload starting at seq, ignoring the bytes already
+// received.
+func (s *stream) append(seq uint32, payload []byte) {
+	overlap := int(int32(s.next - seq))
+	if overlap >= len(payload) {
+		return
+	}
+
+	s.buf = append(s.buf, payload[overlap:]...)
+	s.next += uint32(len(payload) - overlap)
+}
+
+func (s *stream) drainPending() {
+	for progress := true; progress; {
+		progress = false
+		for seq, payload := range s.pending {
+			if int32(seq-s.next) > 0 {
+				continue
+			}
+			delete(s.pending, seq)
+			s.append(seq, payload)
+			progress = true
+		}
+	}
+}
+
+// extractFIXMessages returns the complete FIX messages found in buf and what
+// remains to be completed. Anything before a BeginString is discarded.
+func extractFIXMessages(buf []byte) ([]string, []byte) {
+	var messages []string
+
+	for {
+		start := bytes.Index(buf, fixBeginString)
+		if start < 0 {
+			// Keep what could be the beginning of a BeginString
+			if len(buf) >= len(fixBeginString) {
+				buf = buf[len(buf)-len(fixBeginString)+1:]
+			}
+			return messages, buf
+		}
+		buf = buf[start:]
+
+		end, complete := fixMessageEnd(buf)
+		if !complete {
+			return messages, buf
+		}
+		if end < 0 {
+			// Not a message, look for the next one
+			buf = buf[1:]
+			continue
+		}
+
+		messages = append(messages, string(buf[:end]))
+		buf = buf[end:]
+	}
+}
+
+// fixMessageEnd returns the end of the message starting at the beginning of
+// buf or -1 if it is not a FIX message. complete is false if more data is
+// needed to tell.
+func fixMessageEnd(buf []byte) (end int, complete bool) {
+	beginStringEnd := bytes.IndexByte(buf, '\001')
+	if beginStringEnd < 0 {
+		return 0, len(buf) > 32
+	}
+
+	lengthField := buf[beginStringEnd+1:]
+	if len(lengthField) < 2 {
+		return 0, false
+	}
+	if !bytes.HasPrefix(lengthField, []byte("9=")) {
+		return -1, true
+	}
+
+	lengthEnd := bytes.IndexByte(lengthField, '\001')
+	if lengthEnd < 0 {
+		return 0, len(lengthField) > 16
+	}
+
+	bodyStart := beginStringEnd + 1 + lengthEnd + 1
+
+	length, err := strconv.Atoi(string(lengthField[2:lengthEnd]))
+	if err == nil && length >= 0 {
+		bodyEnd := bodyStart + length
+		if len(buf) < bodyEnd+7 {
+			return 0, false
+		}
+		// The trailer is where the BodyLength says it is
+		if bytes.HasPrefix(buf[bodyEnd:], []byte("10=")) && buf[bodyEnd+6] == '\001' {
+			return bodyEnd + 7, true
+		}
+	}
+
+	// Wrong BodyLength, fall back on the first CheckSum field
+	if loc := fixCheckSum.FindIndex(buf[bodyStart-1:]); loc != nil {
+		return bodyStart - 1 + loc[1], true
+	}
+
+	// Another message starts before this one is terminated
+	if bytes.Contains(buf[1:], fixBeginString) {
+		return -1, true
+	}
+
+	return 0, false
+}
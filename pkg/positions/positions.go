@@ -0,0 +1,362 @@
+// Package positions nets the fills of the execution reports per account and
+// symbol.
+//
+// Positions are signed, short ones being negative, and their average price is
+// the one of the fills which opened them: fills reducing a position realize
+// profit and loss against it and leave it unchanged, fills reversing a
+// position open the new one at their price. Fills are deduplicated on their
+// ExecID and trade cancels (ExecType H) remove the fill their ExecRefID refers
+// to, the position being netted again from the remaining fills.
+//
+// With --metrics, the positions are exposed as fix_position_quantity,
+// fix_position_average_price and fix_position_realized_pnl, labelled by
+// account and symbol.
+package positions
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/quickfixgo/enum"
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/tag"
+	"github.com/shopspring/decimal"
+
+	"sylr.dev/fix/pkg/utils"
+)
+
+// Values and tags missing from the enum and tag packages.
+const (
+	// execTypePartialFill and execTypeFill are the ExecType of the fills up
+	// to FIX 4.2, replaced by Trade (F) since.
+	execTypePartialFill enum.ExecType = "1"
+	execTypeFill        enum.ExecType = "2"
+
+	tagExecRefID quickfix.Tag = 19
+)
+
+var (
+	metricPositionQuantity = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "fix",
+			Subsystem: "position",
+			Name:      "quantity",
+			Help:      "Net quantity of the position, negative when short",
+		},
+		[]string{"account", "symbol"},
+	)
+	metricPositionAveragePrice = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "fix",
+			Subsystem: "position",
+			Name:      "average_price",
+			Help:      "Average price of the fills of the position",
+		},
+		[]string{"account", "symbol"},
+	)
+	metricPositionRealizedPnL = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "fix",
+			Subsystem: "position",
+			Name:      "realized_pnl",
+			Help:      "Profit and loss realized by the fills reducing the position, in price currency",
+		},
+		[]string{"account", "symbol"},
+	)
+	metricPositionFills = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "fix",
+			Subsystem: "position",
+			Name:      "fills_total",
+			Help:      "Number of fills applied to the position",
+		},
+		[]string{"account", "symbol"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(metricPositionQuantity, metricPositionAveragePrice, metricPositionRealizedPnL, metricPositionFills)
+}
+
+// Position is the net position of an account in a symbol.
+type Position struct {
+	Account     string          `json:"account,omitempty"`
+	Symbol      string          `json:"symbol"`
+	Quantity    decimal.Decimal `json:"quantity"`
+	AvgPx       decimal.Decimal `json:"avgPx"`
+	RealizedPnL decimal.Decimal `json:"realizedPnL"`
+	Bought      decimal.Decimal `json:"bought"`
+	Sold        decimal.Decimal `json:"sold"`
+	Fills       int             `json:"fills"`
+}
+
+type key struct {
+	account, symbol string
+}
+
+// fill is a fill applied to a position.
+type fill struct {
+	key      key
+	quantity decimal.Decimal
+	price    decimal.Decimal
+	canceled bool
+}
+
+// position is a position with the fills it was netted from, replayed when one
+// of them is canceled.
+type position struct {
+	Position
+	fills []*fill
+}
+
+// Book holds the positions. Its methods can be called on a nil Book, which
+// keeps no position.
+type Book struct {
+	positions map[key]*position
+	// fills are the fills applied, by ExecID
+	fills map[string]*fill
+	mux   sync.RWMutex
+}
+
+// NewBook returns a Book without positions.
+func NewBook() *Book {
+	return &Book{
+		positions: make(map[key]*position),
+		fills:     make(map[string]*fill),
+	}
+}
+
+// OnExecutionReport applies the fill of the execution report, if any. It
+// tells whether a position changed.
+func (b *Book) OnExecutionReport(message *quickfix.Message) bool {
+	if b == nil {
+		return false
+	}
+
+	execType, _ := message.Body.GetString(tag.ExecType)
+	execID, _ := message.Body.GetString(tag.ExecID)
+
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	if len(execID) > 0 {
+		if _, ok := b.fills[execID]; ok {
+			return false
+		}
+	}
+
+	switch enum.ExecType(execType) {
+	case enum.ExecType_TRADE, execTypePartialFill, execTypeFill:
+	case enum.ExecType_TRADE_CANCEL:
+		refID, _ := message.Body.GetString(tagExecRefID)
+		f, ok := b.fills[refID]
+		if !ok || f.canceled {
+			return false
+		}
+		f.canceled = true
+		b.replay(f.key)
+		return true
+	default:
+		return false
+	}
+
+	symbol, _ := message.Body.GetString(tag.Symbol)
+	account, _ := message.Body.GetString(tag.Account)
+	side, _ := message.Body.GetString(tag.Side)
+	lastQty, err := decimalField(message, tag.LastQty)
+	if err != nil || !lastQty.IsPositive() {
+		return false
+	}
+	lastPx, err := decimalField(message, tag.LastPx)
+	if err != nil {
+		return false
+	}
+
+	switch enum.Side(side) {
+	case enum.Side_BUY, enum.Side_BUY_MINUS:
+	case enum.Side_SELL, enum.Side_SELL_PLUS, enum.Side_SELL_SHORT, enum.Side_SELL_SHORT_EXEMPT:
+		lastQty = lastQty.Neg()
+	default:
+		return false
+	}
+
+	f := b.add(key{account: account, symbol: symbol}, lastQty, lastPx)
+	if len(execID) > 0 {
+		b.fills[execID] = f
+	}
+
+	return true
+}
+
+// Fill applies a fill of the quantity, negative for sells, at the price.
+func (b *Book) Fill(account, symbol string, quantity, price decimal.Decimal) {
+	if b == nil {
+		return
+	}
+
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	b.add(key{account: account, symbol: symbol}, quantity, price)
+}
+
+// add applies a new fill to its position.
+func (b *Book) add(k key, quantity, price decimal.Decimal) *fill {
+	p, ok := b.positions[k]
+	if !ok {
+		p = &position{Position: Position{Account: k.account, Symbol: k.symbol}}
+		b.positions[k] = p
+	}
+
+	f := &fill{key: k, quantity: quantity, price: price}
+	p.fills = append(p.fills, f)
+	p.apply(f)
+	p.observe()
+	metricPositionFills.WithLabelValues(k.account, k.symbol).Inc()
+
+	return f
+}
+
+// replay nets the fills of the position again, without the canceled ones.
+func (b *Book) replay(k key) {
+	p := b.positions[k]
+	p.Position = Position{Account: k.account, Symbol: k.symbol}
+
+	for _, f := range p.fills {
+		if !f.canceled {
+			p.apply(f)
+		}
+	}
+
+	p.observe()
+}
+
+// apply nets the fill into the position.
+func (p *position) apply(f *fill) {
+	p.Fills++
+	if f.quantity.IsPositive() {
+		p.Bought = p.Bought.Add(f.quantity)
+	} else {
+		p.Sold = p.Sold.Sub(f.quantity)
+	}
+
+	switch {
+	case p.Quantity.IsZero() || p.Quantity.Sign() == f.quantity.Sign():
+		// Opening or increasing
+		total := p.Quantity.Abs().Add(f.quantity.Abs())
+		p.AvgPx = p.Quantity.Abs().Mul(p.AvgPx).Add(f.quantity.Abs().Mul(f.price)).Div(total)
+		p.Quantity = p.Quantity.Add(f.quantity)
+
+	default:
+		// Reducing, closing or reversing
+		closed := decimal.Min(p.Quantity.Abs(), f.quantity.Abs())
+		pnl := f.price.Sub(p.AvgPx).Mul(closed)
+		if p.Quantity.IsNegative() {
+			pnl = pnl.Neg()
+		}
+		p.RealizedPnL = p.RealizedPnL.Add(pnl)
+
+		previous := p.Quantity
+		p.Quantity = p.Quantity.Add(f.quantity)
+		if p.Quantity.IsZero() {
+			p.AvgPx = decimal.Zero
+		} else if p.Quantity.Sign() != previous.Sign() {
+			p.AvgPx = f.price
+		}
+	}
+}
+
+// observe sets the metrics of the position.
+func (p *position) observe() {
+	quantity, _ := p.Quantity.Float64()
+	avgPx, _ := p.AvgPx.Float64()
+	pnl, _ := p.RealizedPnL.Float64()
+	metricPositionQuantity.WithLabelValues(p.Account, p.Symbol).Set(quantity)
+	metricPositionAveragePrice.WithLabelValues(p.Account, p.Symbol).Set(avgPx)
+	metricPositionRealizedPnL.WithLabelValues(p.Account, p.Symbol).Set(pnl)
+}
+
+// Positions returns a copy of the positions, sorted by account and symbol.
+func (b *Book) Positions() []Position {
+	if b == nil {
+		return nil
+	}
+
+	b.mux.RLock()
+	defer b.mux.RUnlock()
+
+	positions := make([]Position, 0, len(b.positions))
+	for _, p := range b.positions {
+		positions = append(positions, p.Position)
+	}
+
+	sort.Slice(positions, func(i, j int) bool {
+		if positions[i].Account != positions[j].Account {
+			return positions[i].Account < positions[j].Account
+		}
+		return positions[i].Symbol < positions[j].Symbol
+	})
+
+	return positions
+}
+
+// Write writes the summary of the positions in the output format, as JSON or
+// as a table.
+func (b *Book) Write(w io.Writer, format string) error {
+	if format == utils.OutputFormatJSON {
+		return b.WriteJSON(w)
+	}
+
+	b.WriteTable(w)
+
+	return nil
+}
+
+// WriteTable writes the summary of the positions as a table.
+func (b *Book) WriteTable(w io.Writer) {
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"ACCOUNT", "SYMBOL", "POSITION", "AVGPX", "BOUGHT", "SOLD", "REALIZED PNL", "FILLS"})
+	table.SetBorders(tablewriter.Border{Left: false, Top: false, Right: false, Bottom: true})
+	table.SetColumnSeparator(" ")
+	table.SetCenterSeparator("-")
+	table.SetAutoWrapText(false)
+
+	for _, p := range b.Positions() {
+		table.Append([]string{
+			p.Account,
+			p.Symbol,
+			p.Quantity.String(),
+			p.AvgPx.String(),
+			p.Bought.String(),
+			p.Sold.String(),
+			p.RealizedPnL.String(),
+			strconv.Itoa(p.Fills),
+		})
+	}
+
+	table.Render()
+}
+
+// WriteJSON writes the positions as a JSON array.
+func (b *Book) WriteJSON(w io.Writer) error {
+	positions := b.Positions()
+	if positions == nil {
+		positions = []Position{}
+	}
+
+	return json.NewEncoder(w).Encode(positions)
+}
+
+func decimalField(message *quickfix.Message, t quickfix.Tag) (decimal.Decimal, error) {
+	value, err := message.Body.GetString(t)
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	return decimal.NewFromString(value)
+}
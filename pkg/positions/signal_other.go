@@ -0,0 +1,13 @@
+//go:build !unix
+
+package positions
+
+import (
+	"os"
+)
+
+// SummarySignals returns the signals asking for the positions to be written,
+// none as there is no SIGUSR1, the positions being only written on exit.
+func SummarySignals() []os.Signal {
+	return nil
+}
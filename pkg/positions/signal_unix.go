@@ -0,0 +1,13 @@
+//go:build unix
+
+package positions
+
+import (
+	"os"
+	"syscall"
+)
+
+// SummarySignals returns the signals asking for the positions to be written.
+func SummarySignals() []os.Signal {
+	return []os.Signal{syscall.SIGUSR1}
+}
@@ -0,0 +1,292 @@
+// Package recorder writes the messages received by a session to rotating
+// files of a directory, in two formats which can both be replayed:
+//
+//   - raw/<prefix>-<start>.log, the raw messages prefixed with the RFC 3339
+//     time they were received at, as in the LogFile of the sessions.
+//   - json/<prefix>-<start>.jsonl, JSON lines with the time, the raw message
+//     and its fields, as in the MessageLog of the sessions.
+//
+// Messages are buffered and written to the files every FlushEvery, the files
+// being synced to the disk according to the Sync policy.
+package recorder
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/quickfix/datadictionary"
+
+	"sylr.dev/fix/pkg/errors"
+	"sylr.dev/fix/pkg/utils"
+)
+
+const (
+	// SyncNone leaves the syncing of the files to the operating system.
+	SyncNone = "none"
+	// SyncFlush syncs the files each time the buffered messages are written.
+	SyncFlush = "flush"
+	// SyncAlways writes and syncs the files after every message.
+	SyncAlways = "always"
+)
+
+var SyncPolicies = []string{SyncNone, SyncFlush, SyncAlways}
+
+// maxBuffered is the size of the buffered messages above which they are written
+// without waiting for FlushEvery.
+const maxBuffered = 1 << 20
+
+// Options are the rotation, buffering and sync settings of a recorder.
+type Options struct {
+	// Prefix starts the names of the files, "messages" if empty.
+	Prefix string
+	// MaxSize is the size in bytes above which the files are rotated, 0
+	// meaning no limit.
+	MaxSize int64
+	// RotateEvery is the age above which the files are rotated, 0 meaning
+	// never.
+	RotateEvery time.Duration
+	// MaxBackups is the number of rotated files kept, 0 meaning all of them.
+	MaxBackups int
+	// FlushEvery is the longest time messages are buffered before being
+	// written, 0 meaning they are written as they are recorded.
+	FlushEvery time.Duration
+	// Sync is the sync policy, SyncFlush if empty.
+	Sync string
+}
+
+// Recorder records the messages into the files of a directory.
+type Recorder struct {
+	options       Options
+	transportDict *datadictionary.DataDictionary
+	appDict       *datadictionary.DataDictionary
+
+	mux    sync.Mutex
+	raw    *file
+	json   *file
+	err    error
+	done   chan struct{}
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// file is a rotating file and the lines buffered for it.
+type file struct {
+	*utils.RotatingFile
+	buf bytes.Buffer
+}
+
+// line is a JSON line of the json files.
+type line struct {
+	Time    time.Time `json:"time"`
+	Session string    `json:"session"`
+	MsgType string    `json:"msgType,omitempty"`
+	SeqNum  int       `json:"seqNum,omitempty"`
+	Message *fields   `json:"message,omitempty"`
+	Raw     string    `json:"raw"`
+}
+
+type fields struct {
+	Header  []*utils.QuickFixField `json:"header"`
+	Body    []*utils.QuickFixField `json:"body"`
+	Trailer []*utils.QuickFixField `json:"trailer"`
+}
+
+// New returns a recorder writing into the directory, whose messages are
+// described with the dictionaries, if given.
+func New(dir string, options Options, transportDict, appDict *datadictionary.DataDictionary) (*Recorder, error) {
+	switch options.Sync {
+	case "":
+		options.Sync = SyncFlush
+	case SyncNone, SyncFlush, SyncAlways:
+	default:
+		return nil, fmt.Errorf("%w: unknown sync policy `%s` (%s)", errors.Options, options.Sync, strings.Join(SyncPolicies, ", "))
+	}
+	if len(options.Prefix) == 0 {
+		options.Prefix = "messages"
+	}
+
+	name := fmt.Sprintf("%s-%s", options.Prefix, time.Now().UTC().Format("20060102T150405"))
+
+	r := &Recorder{
+		options:       options,
+		transportDict: transportDict,
+		appDict:       appDict,
+		raw:           &file{RotatingFile: options.rotatingFile(filepath.Join(dir, "raw", name+".log"))},
+		json:          &file{RotatingFile: options.rotatingFile(filepath.Join(dir, "json", name+".jsonl"))},
+		done:          make(chan struct{}),
+	}
+
+	for _, f := range []*file{r.raw, r.json} {
+		if err := os.MkdirAll(filepath.Dir(f.Path), 0700); err != nil {
+			return nil, err
+		}
+	}
+
+	if options.FlushEvery > 0 && options.Sync != SyncAlways {
+		r.wg.Add(1)
+		go r.flushEvery(options.FlushEvery)
+	}
+
+	return r, nil
+}
+
+func (o Options) rotatingFile(path string) *utils.RotatingFile {
+	return &utils.RotatingFile{
+		Path:        path,
+		MaxSize:     o.MaxSize,
+		RotateEvery: o.RotateEvery,
+		MaxBackups:  o.MaxBackups,
+	}
+}
+
+// Record records the message received on the session. It returns the error
+// which occurred while writing the messages previously recorded, if any.
+func (r *Recorder) Record(message *quickfix.Message, sessionID quickfix.SessionID) error {
+	now := time.Now().UTC()
+	raw := message.String()
+
+	l := line{
+		Time:    now,
+		Session: sessionID.String(),
+		Raw:     strings.ReplaceAll(raw, "\001", "|"),
+	}
+	l.MsgType, _ = message.MsgType()
+	l.SeqNum, _ = message.Header.GetInt(34)
+
+	header, body, trailer := utils.QuickFixMessageFields(message, r.transportDict, r.appDict)
+	l.Message = &fields{Header: header, Body: body, Trailer: trailer}
+
+	var b bytes.Buffer
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(l); err != nil {
+		return err
+	}
+
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if r.err != nil {
+		return r.err
+	}
+	if r.closed {
+		return os.ErrClosed
+	}
+
+	fmt.Fprintf(&r.raw.buf, "%s <- %s\n", now.Format(time.RFC3339Nano), raw)
+	r.json.buf.Write(b.Bytes())
+
+	if r.options.FlushEvery <= 0 || r.options.Sync == SyncAlways || r.json.buf.Len() > maxBuffered {
+		r.err = r.flush()
+	}
+
+	return r.err
+}
+
+// Flush writes the buffered messages to the files.
+func (r *Recorder) Flush() error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if r.err == nil {
+		r.err = r.flush()
+	}
+
+	return r.err
+}
+
+// Close writes the buffered messages and closes the files.
+func (r *Recorder) Close() error {
+	r.mux.Lock()
+	if r.closed {
+		r.mux.Unlock()
+		return r.err
+	}
+	r.closed = true
+	close(r.done)
+	r.mux.Unlock()
+
+	r.wg.Wait()
+
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	err := r.err
+	if err == nil {
+		err = r.flush()
+	}
+	for _, f := range []*file{r.raw, r.json} {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}
+
+	return err
+}
+
+// Rotate writes the buffered messages and rotates the files.
+func (r *Recorder) Rotate() error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if r.err == nil {
+		r.err = r.flush()
+	}
+	if r.err != nil {
+		return r.err
+	}
+
+	for _, f := range []*file{r.raw, r.json} {
+		if err := f.Rotate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *Recorder) flushEvery(period time.Duration) {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.done:
+			return
+		case <-ticker.C:
+			r.Flush()
+		}
+	}
+}
+
+// flush writes the lines buffered for each file at once, for them not to be
+// split by a rotation, and syncs the files according to the policy.
+func (r *Recorder) flush() error {
+	for _, f := range []*file{r.raw, r.json} {
+		if f.buf.Len() == 0 {
+			continue
+		}
+
+		if _, err := f.Write(f.buf.Bytes()); err != nil {
+			return err
+		}
+		f.buf.Reset()
+
+		if r.options.Sync != SyncNone {
+			if err := f.Sync(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,227 @@
+// Package replay reads the FIX messages recorded in packet captures, message
+// logs or session log files and plays them back with their original pacing.
+//
+// The formats are told apart by their content:
+//
+//   - pcap and pcapng captures, the messages of all the TCP streams being
+//     timed by the packet completing them.
+//   - JSON lines with the time and raw fields, as written to the MessageLog of
+//     the sessions.
+//   - lines holding a raw message, as written to the LogFile of the sessions,
+//     timed by the RFC 3339 timestamp they start with, if any.
+package replay
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"sylr.dev/fix/pkg/errors"
+	"sylr.dev/fix/pkg/pcap"
+	"sylr.dev/fix/pkg/utils"
+)
+
+// Message is a recorded message.
+type Message struct {
+	// Time the message was recorded at, zero when unknown
+	Time time.Time
+	Raw  string
+}
+
+// MsgType returns the MsgType of the message.
+func (m Message) MsgType() string {
+	values := m.Values(35)
+	if len(values) == 0 {
+		return ""
+	}
+
+	return values[0]
+}
+
+// Values returns the values of the tag in the message, in order.
+func (m Message) Values(tag int) []string {
+	prefix := fmt.Sprintf("\001%d=", tag)
+
+	var values []string
+	for raw := m.Raw; ; {
+		i := strings.Index(raw, prefix)
+		if i < 0 {
+			return values
+		}
+		raw = raw[i+len(prefix):]
+
+		end := strings.IndexByte(raw, '\001')
+		if end < 0 {
+			end = len(raw)
+		}
+		values = append(values, raw[:end])
+		raw = raw[end:]
+	}
+}
+
+// Load reads the messages recorded in the file or in the files of the
+// directory, which are read in the order of their names. The messages are
+// returned in the order they were recorded.
+func Load(path string) ([]Message, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := []string{path}
+	if info.IsDir() {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+
+		paths = paths[:0]
+		for _, entry := range entries {
+			if !entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+				paths = append(paths, filepath.Join(path, entry.Name()))
+			}
+		}
+		sort.Strings(paths)
+	}
+
+	var messages []Message
+	for _, p := range paths {
+		m, err := loadFile(p)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", p, err)
+		}
+		messages = append(messages, m...)
+	}
+
+	// Files of a directory may overlap
+	sort.SliceStable(messages, func(i, j int) bool {
+		return messages[i].Time.Before(messages[j].Time)
+	})
+
+	return messages, nil
+}
+
+func loadFile(path string) ([]Message, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	r := bufio.NewReader(file)
+	if magic, err := r.Peek(4); err == nil && pcap.IsCapture(magic) {
+		return readCapture(r)
+	}
+
+	return readLines(r)
+}
+
+func readCapture(r io.Reader) ([]Message, error) {
+	reader, err := pcap.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []Message
+	assembler := pcap.NewAssembler()
+
+	for {
+		packet, err := reader.Next()
+		if err == io.EOF {
+			return messages, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		segment, ok := pcap.DecodeTCP(packet)
+		if !ok {
+			continue
+		}
+
+		for _, m := range assembler.Add(packet.Time, segment) {
+			messages = append(messages, Message{Time: m.Time, Raw: m.Raw})
+		}
+	}
+}
+
+// logLine is a line of a MessageLog.
+type logLine struct {
+	Time time.Time `json:"time"`
+	Raw  string    `json:"raw"`
+}
+
+func readLines(r io.Reader) ([]Message, error) {
+	var messages []Message
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+
+		var m Message
+		if strings.HasPrefix(line, "{") {
+			var l logLine
+			if err := json.Unmarshal([]byte(line), &l); err != nil {
+				return nil, fmt.Errorf("%w: line %d: %s", errors.FixMessageParse, n, err)
+			}
+			m = Message{Time: l.Time, Raw: utils.QuickFixRawMessage(l.Raw)}
+		} else {
+			m.Raw = utils.QuickFixRawMessage(line)
+			if fields := strings.Fields(line); len(fields) > 0 {
+				m.Time, _ = time.Parse(time.RFC3339Nano, fields[0])
+			}
+		}
+
+		if len(m.Raw) > 0 {
+			messages = append(messages, m)
+		}
+	}
+
+	return messages, scanner.Err()
+}
+
+// Play calls fn with the messages, waiting between them for the time which
+// separated them when recorded divided by speed. They are played without
+// waiting when speed is 0 or when their time is unknown. It stops when the
+// context is done or fn returns an error.
+func Play(ctx context.Context, messages []Message, speed float64, fn func(Message) error) error {
+	start := time.Now()
+	var first time.Time
+
+	for _, m := range messages {
+		if speed > 0 && !m.Time.IsZero() {
+			if first.IsZero() {
+				first = m.Time
+			}
+
+			at := start.Add(time.Duration(float64(m.Time.Sub(first)) / speed))
+			if wait := time.Until(at); wait > 0 {
+				timer := time.NewTimer(wait)
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return ctx.Err()
+				case <-timer.C:
+				}
+			}
+		}
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if err := fn(m); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,155 @@
+// Package report renders the results of scenario and conformance runs as
+// self-contained HTML documents which can be attached to venue certification
+// paperwork.
+package report
+
+import (
+	"embed"
+	"html/template"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/quickfixgo/enum"
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/quickfix/datadictionary"
+	"github.com/quickfixgo/tag"
+
+	"sylr.dev/fix/pkg/dict"
+	"sylr.dev/fix/pkg/utils"
+)
+
+//go:embed templates/*
+var templates embed.FS
+
+// Statuses of sections and steps.
+const (
+	StatusPass = "pass"
+	StatusFail = "fail"
+	StatusSkip = "skip"
+)
+
+// Exchange is a message sent or received during a run. Conn numbers the
+// connections when there are several of them.
+type Exchange struct {
+	Time    time.Time         `json:"time"`
+	Sent    bool              `json:"sent"`
+	Conn    int               `json:"conn,omitempty"`
+	Raw     string            `json:"raw"`
+	Message *quickfix.Message `json:"-"`
+}
+
+// NewExchange records a message sent or received now.
+func NewExchange(message *quickfix.Message, sent bool, conn int) Exchange {
+	return Exchange{Time: time.Now(), Sent: sent, Conn: conn, Raw: message.String(), Message: message}
+}
+
+// Report is the result of a run.
+type Report struct {
+	Title     string
+	Context   string
+	Session   string
+	Generated time.Time
+	Sections  []Section
+}
+
+// Section is a scenario or a test of the run.
+type Section struct {
+	Name        string
+	Description string
+	Status      string
+	Details     string
+	Started     time.Time
+	Duration    time.Duration
+	Steps       []Step
+	Exchanges   []Exchange
+}
+
+// Step is a step of a scenario.
+type Step struct {
+	Name      string
+	Kind      string
+	Status    string
+	Details   string
+	Started   time.Time
+	Duration  time.Duration
+	Exchanges []Exchange
+}
+
+// Passed returns the number of sections which passed.
+func (r *Report) Passed() int {
+	n := 0
+	for _, s := range r.Sections {
+		if s.Status == StatusPass {
+			n++
+		}
+	}
+
+	return n
+}
+
+// WriteHTML renders the report as an HTML document, messages being decoded with
+// the dictionaries.
+func (r *Report) WriteHTML(w io.Writer, transportDict, appDict *datadictionary.DataDictionary) error {
+	funcs := template.FuncMap{
+		"time":     utils.FormatTime,
+		"duration": utils.FormatLatency,
+		"upper":    strings.ToUpper,
+		"raw": func(raw string) string {
+			return strings.ReplaceAll(raw, "\001", "|")
+		},
+		"name": func(message *quickfix.Message) string {
+			return messageName(message, appDict)
+		},
+		"seqnum": func(message *quickfix.Message) string {
+			seqNum, _ := message.Header.GetString(tag.MsgSeqNum)
+			return seqNum
+		},
+		"fields": func(message *quickfix.Message) []*utils.QuickFixField {
+			header, body, trailer := utils.QuickFixMessageFields(message, transportDict, appDict)
+			fields := append(append(header, body...), trailer...)
+			return fields
+		},
+	}
+
+	tmpl, err := template.New("report.html.tmpl").Funcs(funcs).ParseFS(templates, "templates/report.html.tmpl")
+	if err != nil {
+		return err
+	}
+
+	return tmpl.Execute(w, r)
+}
+
+func messageName(message *quickfix.Message, appDict *datadictionary.DataDictionary) string {
+	msgType, err := message.MsgType()
+	if err != nil {
+		return "?"
+	}
+
+	if appDict != nil {
+		if def, ok := appDict.Messages[msgType]; ok {
+			return def.Name
+		}
+	}
+	if desc := utils.MapSearch(dict.MessageTypes, enum.MsgType(msgType)); desc != nil {
+		return *desc
+	}
+
+	return msgType
+}
+
+// WriteHTMLFile renders the report as an HTML document into the file.
+func (r *Report) WriteHTMLFile(path string, transportDict, appDict *datadictionary.DataDictionary) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := r.WriteHTML(f, transportDict, appDict); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,102 @@
+package risk
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/quickfixgo/quickfix"
+
+	"sylr.dev/fix/config"
+	"sylr.dev/fix/pkg/errors"
+)
+
+var (
+	// sessionCheckers are the checkers of the sessions of the last
+	// initiators started, by session, like the quickfix sessions are.
+	sessionCheckers    = make(map[quickfix.SessionID]*Checker)
+	sessionCheckersMux sync.RWMutex
+)
+
+// SessionChecker returns the checker of the orders sent on the session, nil
+// when the session has no risk limits, so that the commands can request the
+// market data prices their orders are checked against.
+func SessionChecker(sessionID quickfix.SessionID) *Checker {
+	sessionCheckersMux.RLock()
+	defer sessionCheckersMux.RUnlock()
+
+	return sessionCheckers[sessionID]
+}
+
+// Application checks the orders sent on the sessions having a RiskLimits
+// setting from ToApp, whichever way they are sent, and feeds the checkers the
+// market data they receive.
+type Application struct {
+	quickfix.Application
+
+	checkers map[quickfix.SessionID]*Checker
+}
+
+// NewApplication returns the application wrapped with the checkers of the
+// sessions, or as is when no session has risk limits.
+func NewApplication(app quickfix.Application, settings *quickfix.Settings) (quickfix.Application, error) {
+	checkers := make(map[quickfix.SessionID]*Checker)
+
+	for sessionID, sessionSettings := range settings.SessionSettings() {
+		if !sessionSettings.HasSetting(config.RiskLimitsSetting) {
+			continue
+		}
+
+		value, err := sessionSettings.Setting(config.RiskLimitsSetting)
+		if err != nil {
+			return nil, err
+		}
+
+		var limits config.RiskLimits
+		if err := json.Unmarshal([]byte(value), &limits); err != nil {
+			return nil, fmt.Errorf("%w: session %s: %s: %s", errors.Config, sessionID, config.RiskLimitsSetting, err)
+		}
+
+		if checker := NewChecker(limits); checker != nil {
+			checkers[sessionID] = checker
+		}
+	}
+
+	sessionCheckersMux.Lock()
+	for sessionID := range settings.SessionSettings() {
+		if checker, ok := checkers[sessionID]; ok {
+			sessionCheckers[sessionID] = checker
+		} else {
+			delete(sessionCheckers, sessionID)
+		}
+	}
+	sessionCheckersMux.Unlock()
+
+	if len(checkers) == 0 {
+		return app, nil
+	}
+
+	return &Application{Application: app, checkers: checkers}, nil
+}
+
+// ToApp checks the message against the limits of its session before handing
+// it to the application. Refused messages are neither stored nor sent, the
+// error being returned by quickfix.SendToTarget.
+func (a *Application) ToApp(message *quickfix.Message, sessionID quickfix.SessionID) error {
+	if err := a.checkers[sessionID].Check(message); err != nil {
+		return err
+	}
+
+	return a.Application.ToApp(message, sessionID)
+}
+
+// FromApp records the market data prices of the message before handing it to
+// the application, unless it answers a MarketDataRequest of the checker of its
+// session, which the application knows nothing of.
+func (a *Application) FromApp(message *quickfix.Message, sessionID quickfix.SessionID) quickfix.MessageRejectError {
+	if a.checkers[sessionID].OnMessage(message) {
+		return nil
+	}
+
+	return a.Application.FromApp(message, sessionID)
+}
@@ -0,0 +1,498 @@
+// Package risk checks the orders against the pre-trade limits of a context
+// before they are sent.
+//
+// Checks are run on NewOrderSingle and OrderCancelReplaceRequest messages: the
+// order quantity, the notional (quantity times price, the last market data
+// price being used for market orders), the distance of the price from the last
+// market data price and the restricted symbols. The market data prices are
+// taken from the MarketDataSnapshotFullRefresh and
+// MarketDataIncrementalRefresh messages received, the last trade being
+// preferred to the middle of the best bid and offer.
+//
+// The initiators are wrapped with an Application checking the orders sent on
+// the sessions having risk limits, whichever command or socket sends them.
+package risk
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/quickfixgo/enum"
+	"github.com/quickfixgo/field"
+	"github.com/quickfixgo/fixt11"
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/tag"
+	"github.com/shopspring/decimal"
+
+	"sylr.dev/fix/config"
+	"sylr.dev/fix/pkg/errors"
+)
+
+// tagBusinessRejectRefID is missing from the tag package.
+const tagBusinessRejectRefID quickfix.Tag = 379
+
+// Checker checks the orders against the limits. Its methods can be called on
+// a nil Checker, which accepts every order.
+type Checker struct {
+	limits     config.RiskLimits
+	restricted map[string]struct{}
+
+	// prices are the last market data prices, by symbol
+	prices map[string]decimal.Decimal
+	// requests are the symbols of the MarketDataRequests sent, by MDReqID
+	// and by MsgSeqNum, and rejects the reasons they were rejected for, by
+	// symbol
+	requests map[string]string
+	seqNums  map[int]string
+	rejects  map[string]string
+	waiters  map[string][]chan struct{}
+	mux      sync.Mutex
+}
+
+// NewChecker returns a Checker of the limits, nil when none is set.
+func NewChecker(limits config.RiskLimits) *Checker {
+	if !limits.IsSet() {
+		return nil
+	}
+
+	c := &Checker{
+		limits:     limits,
+		restricted: make(map[string]struct{}, len(limits.RestrictedSymbols)),
+		prices:     make(map[string]decimal.Decimal),
+		requests:   make(map[string]string),
+		seqNums:    make(map[int]string),
+		rejects:    make(map[string]string),
+		waiters:    make(map[string][]chan struct{}),
+	}
+
+	for _, symbol := range limits.RestrictedSymbols {
+		c.restricted[strings.ToUpper(symbol)] = struct{}{}
+	}
+
+	return c
+}
+
+// Check returns an error explaining which limit the order trips, if any.
+// Messages other than NewOrderSingle and OrderCancelReplaceRequest, and the
+// ones resent, are not checked. It must be called with the messages about to
+// be sent, once their MsgSeqNum is set.
+func (c *Checker) Check(message *quickfix.Message) error {
+	if c == nil {
+		return nil
+	}
+
+	msgType, _ := message.MsgType()
+	switch enum.MsgType(msgType) {
+	case enum.MsgType_ORDER_SINGLE, enum.MsgType_ORDER_CANCEL_REPLACE_REQUEST:
+	case enum.MsgType_MARKET_DATA_REQUEST:
+		// Rejects may only refer to the request by its MsgSeqNum
+		mdReqID, _ := message.Body.GetString(tag.MDReqID)
+		seqNum, err := message.Header.GetInt(tag.MsgSeqNum)
+		c.mux.Lock()
+		if _, ok := c.requests[mdReqID]; ok && err == nil {
+			c.seqNums[seqNum] = mdReqID
+		}
+		c.mux.Unlock()
+		return nil
+	default:
+		return nil
+	}
+
+	// Orders resent were checked when first sent
+	if possDup, err := message.Header.GetBool(tag.PossDupFlag); err == nil && possDup {
+		return nil
+	}
+
+	symbol, _ := message.Body.GetString(tag.Symbol)
+	if _, ok := c.restricted[strings.ToUpper(symbol)]; ok {
+		return fmt.Errorf("%w: RestrictedSymbols: %s is restricted", errors.RiskLimitExceeded, symbol)
+	}
+
+	quantity, err := decimalField(message, tag.OrderQty)
+	if err != nil {
+		return fmt.Errorf("%w: can't read order quantity: %s", errors.RiskLimitExceeded, err)
+	}
+
+	if limit := decimal.NewFromFloat(c.limits.MaxOrderQuantity); limit.IsPositive() && quantity.GreaterThan(limit) {
+		return fmt.Errorf("%w: MaxOrderQuantity: order quantity %s is above %s", errors.RiskLimitExceeded, quantity, limit)
+	}
+
+	price, priceErr := decimalField(message, tag.Price)
+	reference, known := c.ReferencePrice(symbol)
+
+	noMarketData := "no market data for " + symbol
+	c.mux.Lock()
+	if reason, ok := c.rejects[symbol]; ok {
+		noMarketData += ", request rejected"
+		if len(reason) > 0 {
+			noMarketData += ": " + reason
+		}
+	}
+	c.mux.Unlock()
+
+	if limit := decimal.NewFromFloat(c.limits.MaxNotional); limit.IsPositive() {
+		notionalPrice := price
+		if priceErr != nil {
+			if !known {
+				return fmt.Errorf("%w: MaxNotional: no price to compute the notional, %s", errors.RiskLimitExceeded, noMarketData)
+			}
+			notionalPrice = reference
+		}
+
+		if notional := quantity.Mul(notionalPrice).Abs(); notional.GreaterThan(limit) {
+			return fmt.Errorf("%w: MaxNotional: notional %s is above %s", errors.RiskLimitExceeded, notional, limit)
+		}
+	}
+
+	if collar := decimal.NewFromFloat(c.limits.PriceCollarPercent); collar.IsPositive() && priceErr == nil {
+		if !known || reference.IsZero() {
+			return fmt.Errorf("%w: PriceCollarPercent: no price to check against, %s", errors.RiskLimitExceeded, noMarketData)
+		}
+
+		distance := price.Sub(reference).Abs().Div(reference.Abs()).Mul(decimal.NewFromInt(100))
+		if distance.GreaterThan(collar) {
+			return fmt.Errorf("%w: PriceCollarPercent: price %s is %s%% away from the market price %s, above %s%%", errors.RiskLimitExceeded, price, distance.StringFixed(2), reference, collar)
+		}
+	}
+
+	return nil
+}
+
+// NeedsReferencePrice tells whether the market data price of the symbol is
+// required to check an order, with a price or not, and is not known yet.
+func (c *Checker) NeedsReferencePrice(symbol string, priced bool) bool {
+	if c == nil {
+		return false
+	}
+
+	// The order is refused anyway
+	if _, ok := c.restricted[strings.ToUpper(symbol)]; ok {
+		return false
+	}
+
+	if _, ok := c.ReferencePrice(symbol); ok {
+		return false
+	}
+
+	if priced {
+		return c.limits.PriceCollarPercent > 0
+	}
+
+	return c.limits.MaxNotional > 0
+}
+
+// ReferencePrice returns the last market data price of the symbol.
+func (c *Checker) ReferencePrice(symbol string) (decimal.Decimal, bool) {
+	if c == nil {
+		return decimal.Zero, false
+	}
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	price, ok := c.prices[symbol]
+
+	return price, ok
+}
+
+// SetReferencePrice sets the market data price of the symbol.
+func (c *Checker) SetReferencePrice(symbol string, price decimal.Decimal) {
+	if c == nil {
+		return
+	}
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	c.prices[symbol] = price
+	delete(c.rejects, symbol)
+	c.wake(symbol)
+}
+
+// wake ends the waits for the price of the symbol, c.mux being held.
+func (c *Checker) wake(symbol string) {
+	for _, waiter := range c.waiters[symbol] {
+		close(waiter)
+	}
+	delete(c.waiters, symbol)
+}
+
+// WaitReferencePrice waits for the market data price of the symbol to be known,
+// or for its request to be rejected, telling whether it is known.
+func (c *Checker) WaitReferencePrice(ctx context.Context, symbol string, timeout time.Duration) bool {
+	if c == nil {
+		return false
+	}
+
+	c.mux.Lock()
+	if _, ok := c.prices[symbol]; ok {
+		c.mux.Unlock()
+		return true
+	}
+	waiter := make(chan struct{})
+	c.waiters[symbol] = append(c.waiters[symbol], waiter)
+	c.mux.Unlock()
+
+	select {
+	case <-waiter:
+	case <-time.After(timeout):
+	case <-ctx.Done():
+	}
+
+	_, ok := c.ReferencePrice(symbol)
+
+	return ok
+}
+
+// RequestReferencePrice sends a MarketDataRequest of the symbol on the session
+// when its price is needed to check an order, with a price or not, and waits
+// for it up to timeout. The order is refused by Check when it is not received.
+func (c *Checker) RequestReferencePrice(ctx context.Context, session config.Session, sessionID quickfix.SessionID, symbol string, priced bool, timeout time.Duration) error {
+	if !c.NeedsReferencePrice(symbol, priced) {
+		return nil
+	}
+
+	request, err := MarketDataRequest(session, symbol)
+	if err != nil {
+		return err
+	}
+
+	mdReqID, _ := request.Body.GetString(tag.MDReqID)
+	c.mux.Lock()
+	c.requests[mdReqID] = symbol
+	c.mux.Unlock()
+
+	if err := quickfix.SendToTarget(request, sessionID); err != nil {
+		return err
+	}
+
+	c.WaitReferencePrice(ctx, symbol, timeout)
+
+	// The request is over, answered or not
+	c.mux.Lock()
+	c.forget(mdReqID)
+	c.mux.Unlock()
+
+	return nil
+}
+
+// forget removes the MarketDataRequest from the ones sent, c.mux being held.
+func (c *Checker) forget(mdReqID string) {
+	delete(c.requests, mdReqID)
+	for seqNum, id := range c.seqNums {
+		if id == mdReqID {
+			delete(c.seqNums, seqNum)
+		}
+	}
+}
+
+// OnMessage records the prices of the market data message, or ends the wait
+// for the price the reject refers to, telling whether the message answers a
+// MarketDataRequest sent by RequestReferencePrice.
+func (c *Checker) OnMessage(message *quickfix.Message) bool {
+	msgType, _ := message.MsgType()
+	switch enum.MsgType(msgType) {
+	case enum.MsgType_MARKET_DATA_SNAPSHOT_FULL_REFRESH, enum.MsgType_MARKET_DATA_INCREMENTAL_REFRESH:
+		return c.OnMarketData(message)
+	case enum.MsgType_MARKET_DATA_REQUEST_REJECT, enum.MsgType_BUSINESS_MESSAGE_REJECT:
+		return c.OnMarketDataReject(message)
+	}
+
+	return false
+}
+
+// OnMarketDataReject ends the wait for the price requested by the
+// MarketDataRequest the MarketDataRequestReject or BusinessMessageReject
+// message refers to, telling whether it refers to one.
+func (c *Checker) OnMarketDataReject(message *quickfix.Message) bool {
+	if c == nil {
+		return false
+	}
+
+	text, _ := message.Body.GetString(tag.Text)
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	mdReqID, err := message.Body.GetString(tag.MDReqID)
+	if err != nil {
+		mdReqID, _ = message.Body.GetString(tagBusinessRejectRefID)
+	}
+	if _, ok := c.requests[mdReqID]; !ok {
+		refSeqNum, _ := message.Body.GetInt(tag.RefSeqNum)
+		mdReqID = c.seqNums[refSeqNum]
+	}
+
+	symbol, ok := c.requests[mdReqID]
+	if !ok {
+		return false
+	}
+
+	c.forget(mdReqID)
+	c.rejects[symbol] = text
+	c.wake(symbol)
+
+	return true
+}
+
+// OnMarketData records the prices of the MarketDataSnapshotFullRefresh or
+// MarketDataIncrementalRefresh message, telling whether it answers a
+// MarketDataRequest sent by RequestReferencePrice.
+func (c *Checker) OnMarketData(message *quickfix.Message) bool {
+	if c == nil {
+		return false
+	}
+
+	// Groups are delimited by the first tag of their template
+	template := quickfix.GroupTemplate{
+		quickfix.GroupElement(tag.MDEntryType),
+		quickfix.GroupElement(tag.MDEntryPx),
+		quickfix.GroupElement(tag.MDEntrySize),
+		quickfix.GroupElement(tag.OrderID),
+		quickfix.GroupElement(tag.OrdType),
+		quickfix.GroupElement(tag.TradeID),
+		quickfix.GroupElement(tag.MDEntryDate),
+		quickfix.GroupElement(tag.MDEntryTime),
+		quickfix.GroupElement(tag.TradeCondition),
+		quickfix.GroupElement(tag.OpenCloseSettlFlag),
+		quickfix.GroupElement(tag.Symbol),
+		quickfix.GroupElement(tag.Text),
+	}
+	if msgType, _ := message.MsgType(); enum.MsgType(msgType) == enum.MsgType_MARKET_DATA_INCREMENTAL_REFRESH {
+		template = append(quickfix.GroupTemplate{quickfix.GroupElement(tag.MDUpdateAction)}, template...)
+	}
+
+	group := quickfix.NewRepeatingGroup(tag.NoMDEntries, template)
+	requested := false
+	if mdReqID, err := message.Body.GetString(tag.MDReqID); err == nil {
+		c.mux.Lock()
+		_, requested = c.requests[mdReqID]
+		c.forget(mdReqID)
+		c.mux.Unlock()
+	}
+
+	if err := message.Body.GetGroup(group); err != nil {
+		return requested
+	}
+
+	type book struct {
+		trade, bid, offer decimal.Decimal
+	}
+
+	bodySymbol, _ := message.Body.GetString(tag.Symbol)
+	books := make(map[string]*book)
+	symbols := []string{}
+
+	for i := 0; i < group.Len(); i++ {
+		entry := group.Get(i)
+
+		if action, err := entry.GetString(tag.MDUpdateAction); err == nil && enum.MDUpdateAction(action) == enum.MDUpdateAction_DELETE {
+			continue
+		}
+
+		symbol, err := entry.GetString(tag.Symbol)
+		if err != nil {
+			symbol = bodySymbol
+		}
+		if len(symbol) == 0 {
+			continue
+		}
+
+		value, err := entry.GetString(tag.MDEntryPx)
+		if err != nil {
+			continue
+		}
+		price, perr := decimal.NewFromString(value)
+		if perr != nil {
+			continue
+		}
+
+		b, ok := books[symbol]
+		if !ok {
+			b = &book{}
+			books[symbol] = b
+			symbols = append(symbols, symbol)
+		}
+
+		entryType, _ := entry.GetString(tag.MDEntryType)
+		switch enum.MDEntryType(entryType) {
+		case enum.MDEntryType_TRADE:
+			b.trade = price
+		case enum.MDEntryType_BID:
+			if b.bid.IsZero() || price.GreaterThan(b.bid) {
+				b.bid = price
+			}
+		case enum.MDEntryType_OFFER:
+			if b.offer.IsZero() || price.LessThan(b.offer) {
+				b.offer = price
+			}
+		}
+	}
+
+	for _, symbol := range symbols {
+		b := books[symbol]
+		switch {
+		case !b.trade.IsZero():
+			c.SetReferencePrice(symbol, b.trade)
+		case !b.bid.IsZero() && !b.offer.IsZero():
+			c.SetReferencePrice(symbol, b.bid.Add(b.offer).Div(decimal.NewFromInt(2)))
+		case !b.bid.IsZero():
+			c.SetReferencePrice(symbol, b.bid)
+		case !b.offer.IsZero():
+			c.SetReferencePrice(symbol, b.offer)
+		}
+	}
+
+	return requested
+}
+
+// MarketDataRequest returns a MarketDataRequest of a snapshot of the last
+// trade and the best bid and offer of the symbol.
+func MarketDataRequest(session config.Session, symbol string) (*quickfix.Message, error) {
+	if session.BeginString != quickfix.BeginStringFIXT11 {
+		return nil, errors.FixVersionNotImplemented
+	}
+
+	message := quickfix.NewMessage()
+	header := fixt11.NewHeader(&message.Header)
+	header.Set(field.NewMsgType(enum.MsgType_MARKET_DATA_REQUEST))
+
+	message.Body.Set(field.NewMDReqID(uuid.NewString()))
+	message.Body.Set(field.NewSubscriptionRequestType(enum.SubscriptionRequestType_SNAPSHOT))
+	message.Body.Set(field.NewMarketDepth(1))
+
+	entryTypes := quickfix.NewRepeatingGroup(
+		tag.NoMDEntryTypes,
+		quickfix.GroupTemplate{
+			quickfix.GroupElement(tag.MDEntryType),
+		},
+	)
+	for _, t := range []enum.MDEntryType{enum.MDEntryType_BID, enum.MDEntryType_OFFER, enum.MDEntryType_TRADE} {
+		entryTypes.Add().Set(field.NewMDEntryType(t))
+	}
+	message.Body.SetGroup(entryTypes)
+
+	relatedSym := quickfix.NewRepeatingGroup(
+		tag.NoRelatedSym,
+		quickfix.GroupTemplate{
+			quickfix.GroupElement(tag.Symbol),
+		},
+	)
+	relatedSym.Add().Set(field.NewSymbol(symbol))
+	message.Body.SetGroup(relatedSym)
+
+	return message, nil
+}
+
+func decimalField(message *quickfix.Message, t quickfix.Tag) (decimal.Decimal, error) {
+	value, err := message.Body.GetString(t)
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	return decimal.NewFromString(value)
+}
@@ -0,0 +1,180 @@
+package scenario
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/quickfix/datadictionary"
+	"github.com/quickfixgo/tag"
+
+	"sylr.dev/fix/pkg/utils"
+)
+
+// messageFields returns the header, body and trailer fields of the message.
+func messageFields(message *quickfix.Message, transportDict, appDict *datadictionary.DataDictionary) []*utils.QuickFixField {
+	header, body, trailer := utils.QuickFixMessageFields(message, transportDict, appDict)
+
+	fields := make([]*utils.QuickFixField, 0, len(header)+len(body)+len(trailer))
+	fields = append(fields, header...)
+	fields = append(fields, body...)
+
+	return append(fields, trailer...)
+}
+
+// findField returns the first field given by name or tag, looking into the
+// repeating groups if it is not a top level field.
+func findField(fields []*utils.QuickFixField, key string) *utils.QuickFixField {
+	t, err := strconv.Atoi(key)
+	is := func(f *utils.QuickFixField) bool {
+		if err == nil {
+			return f.Tag == t
+		}
+		return f.Name == key
+	}
+
+	for _, f := range fields {
+		if is(f) {
+			return f
+		}
+	}
+
+	for _, f := range fields {
+		for _, group := range f.Groups {
+			if found := findField(group, key); found != nil {
+				return found
+			}
+		}
+	}
+
+	return nil
+}
+
+// valueIs tells whether the value of the field is the expected one, which can
+// also be given by enum name or, for the MsgType, by message name.
+func valueIs(f *utils.QuickFixField, expected string, appDict *datadictionary.DataDictionary) bool {
+	if f.Value == expected || (len(f.Description) > 0 && strings.EqualFold(f.Description, expected)) {
+		return true
+	}
+
+	if f.Tag == int(tag.MsgType) && appDict != nil {
+		if def, ok := appDict.Messages[f.Value]; ok && strings.EqualFold(def.Name, expected) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matches evaluates the predicate against the field, which is nil when the
+// message does not have it.
+func (p Predicate) matches(f *utils.QuickFixField, vars Vars, appDict *datadictionary.DataDictionary) (bool, error) {
+	if p.Exists != nil && *p.Exists != (f != nil) {
+		return false, nil
+	}
+
+	if p.Equals == nil && p.Not == nil && len(p.Regex) == 0 && len(p.In) == 0 {
+		return true, nil
+	}
+
+	if f == nil {
+		// Only the inequality holds for a missing field
+		return p.Equals == nil && len(p.Regex) == 0 && len(p.In) == 0, nil
+	}
+
+	if p.Equals != nil {
+		expected, err := vars.Expand(*p.Equals)
+		if err != nil {
+			return false, err
+		}
+		if !valueIs(f, expected, appDict) {
+			return false, nil
+		}
+	}
+
+	if p.Not != nil {
+		unexpected, err := vars.Expand(*p.Not)
+		if err != nil {
+			return false, err
+		}
+		if valueIs(f, unexpected, appDict) {
+			return false, nil
+		}
+	}
+
+	if len(p.Regex) > 0 {
+		expr, err := vars.Expand(p.Regex)
+		if err != nil {
+			return false, err
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return false, err
+		}
+		if !re.MatchString(f.Value) {
+			return false, nil
+		}
+	}
+
+	if len(p.In) > 0 {
+		in := false
+		for _, v := range p.In {
+			expected, err := vars.Expand(v)
+			if err != nil {
+				return false, err
+			}
+			if valueIs(f, expected, appDict) {
+				in = true
+				break
+			}
+		}
+		if !in {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// match tells whether the message satisfies all the predicates of the step, in
+// which case the captured fields are stored into vars.
+func (e *Expect) match(message *quickfix.Message, vars Vars, transportDict, appDict *datadictionary.DataDictionary) (bool, error) {
+	fields := messageFields(message, transportDict, appDict)
+
+	for key, predicate := range e.Fields {
+		ok, err := predicate.matches(findField(fields, key), vars, appDict)
+		if err != nil {
+			return false, fmt.Errorf("%s: %w", key, err)
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+
+	for name, key := range e.Capture {
+		if f := findField(fields, key); f != nil {
+			vars[name] = f.Value
+		}
+	}
+
+	return true, nil
+}
+
+// describe returns the predicates in a human readable form.
+func (e *Expect) describe() string {
+	keys := make([]string, 0, len(e.Fields))
+	for key := range e.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		parts = append(parts, fmt.Sprintf("%s %s", key, e.Fields[key].describe()))
+	}
+
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,238 @@
+package scenario
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/quickfixgo/enum"
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/quickfix/datadictionary"
+	"github.com/quickfixgo/tag"
+
+	"sylr.dev/fix/pkg/dict"
+	"sylr.dev/fix/pkg/report"
+	"sylr.dev/fix/pkg/utils"
+
+	yaml "sylr.dev/yaml/v3"
+)
+
+// Statuses of the steps.
+const (
+	StatusPass = report.StatusPass
+	StatusFail = report.StatusFail
+	StatusSkip = report.StatusSkip
+)
+
+// DefaultTimeout is the timeout of the expect steps which do not set one.
+const DefaultTimeout = 5 * time.Second
+
+// Runner runs scenarios over a logged on session.
+type Runner struct {
+	SessionID quickfix.SessionID
+	// Received carries every message received on the session.
+	Received                <-chan report.Exchange
+	TransportDataDictionary *datadictionary.DataDictionary
+	AppDataDictionary       *datadictionary.DataDictionary
+	// Timeout of the expect steps which do not set one, DefaultTimeout if 0.
+	Timeout time.Duration
+}
+
+// Result is the outcome of a scenario run.
+type Result struct {
+	Scenario string       `json:"scenario"`
+	Passed   bool         `json:"passed"`
+	Steps    []StepResult `json:"steps"`
+}
+
+// StepResult is the outcome of a step. Exchanges are the messages sent or
+// received by the step, the one matched by an expect step being the last.
+type StepResult struct {
+	Name      string            `json:"name"`
+	Kind      string            `json:"kind"`
+	Status    string            `json:"status"`
+	Started   time.Time         `json:"started"`
+	Duration  time.Duration     `json:"duration"`
+	Details   string            `json:"details,omitempty"`
+	Exchanges []report.Exchange `json:"exchanges,omitempty"`
+}
+
+// Run runs the steps of the scenario in order, the steps following a failed
+// one are skipped. Canceling the context fails the running step.
+func (r *Runner) Run(ctx context.Context, s *Scenario) *Result {
+	result := &Result{Scenario: s.Name, Passed: true}
+	vars := Vars{}
+	failed := false
+
+	for name, value := range s.Vars {
+		expanded, err := vars.Expand(value)
+		if err != nil {
+			result.Passed = false
+			result.Steps = append(result.Steps, StepResult{Name: "vars", Kind: "vars", Status: StatusFail, Started: time.Now(), Details: err.Error()})
+			failed = true
+			break
+		}
+		vars[name] = expanded
+	}
+
+	for _, step := range s.Steps {
+		res := StepResult{Name: step.Name, Kind: step.Kind(), Started: time.Now()}
+
+		if failed {
+			res.Status = StatusSkip
+			result.Steps = append(result.Steps, res)
+			continue
+		}
+
+		var err error
+		switch res.Kind {
+		case "send":
+			res.Exchanges, res.Details, err = r.send(&step.Send, vars)
+		case "expect":
+			res.Exchanges, res.Details, err = r.expect(ctx, step.Expect, vars)
+		default:
+			err = r.sleep(ctx, step.Sleep)
+		}
+		res.Duration = time.Since(res.Started)
+
+		if err != nil {
+			res.Status = StatusFail
+			res.Details = err.Error()
+			result.Passed = false
+			failed = true
+		} else {
+			res.Status = StatusPass
+		}
+
+		result.Steps = append(result.Steps, res)
+	}
+
+	return result
+}
+
+func (r *Runner) send(node *yaml.Node, vars Vars) ([]report.Exchange, string, error) {
+	expanded, err := vars.expandNode(node)
+	if err != nil {
+		return nil, "", err
+	}
+
+	b, err := yaml.Marshal(expanded)
+	if err != nil {
+		return nil, "", err
+	}
+
+	messages, err := utils.QuickFixMessagesFromYAML(b, r.TransportDataDictionary, r.AppDataDictionary)
+	if err != nil {
+		return nil, "", err
+	} else if len(messages) != 1 {
+		return nil, "", fmt.Errorf("a send step must hold exactly one message")
+	}
+
+	message := messages[0]
+	if err := quickfix.SendToTarget(message, r.SessionID); err != nil {
+		return nil, "", err
+	}
+
+	return []report.Exchange{report.NewExchange(message, true, 0)}, r.describeMessage(message), nil
+}
+
+func (r *Runner) expect(ctx context.Context, e *Expect, vars Vars) ([]report.Exchange, string, error) {
+	timeout := e.Timeout
+	if timeout == 0 {
+		timeout = r.Timeout
+	}
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+
+	deadline := time.After(timeout)
+	var exchanges []report.Exchange
+
+	for {
+		select {
+		case <-ctx.Done():
+			return exchanges, "", ctx.Err()
+
+		case <-deadline:
+			return exchanges, "", fmt.Errorf("no message with %s received within %s, %d message(s) discarded", e.describe(), timeout, len(exchanges))
+
+		case exchange, ok := <-r.Received:
+			if !ok {
+				return exchanges, "", fmt.Errorf("session logged out")
+			}
+			exchanges = append(exchanges, exchange)
+
+			matched, err := e.match(exchange.Message, vars, r.TransportDataDictionary, r.AppDataDictionary)
+			if err != nil {
+				return exchanges, "", err
+			}
+			if !matched {
+				continue
+			}
+
+			return exchanges, r.describeMessage(exchange.Message), nil
+		}
+	}
+}
+
+func (r *Runner) sleep(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
+
+// describeMessage returns the message name and sequence number.
+func (r *Runner) describeMessage(message *quickfix.Message) string {
+	msgType, _ := message.MsgType()
+	name := msgType
+
+	if r.AppDataDictionary != nil {
+		if def, ok := r.AppDataDictionary.Messages[msgType]; ok {
+			name = def.Name
+		}
+	}
+	if name == msgType {
+		if desc := utils.MapSearch(dict.MessageTypes, enum.MsgType(msgType)); desc != nil {
+			name = *desc
+		}
+	}
+
+	if seqNum, err := message.Header.GetInt(tag.MsgSeqNum); err == nil {
+		return fmt.Sprintf("%s seq %d", name, seqNum)
+	}
+
+	return name
+}
+
+// Section returns the result as a section of a report.
+func (r *Result) Section() report.Section {
+	section := report.Section{Name: r.Scenario, Status: report.StatusPass}
+	if !r.Passed {
+		section.Status = report.StatusFail
+	}
+
+	for _, step := range r.Steps {
+		if section.Started.IsZero() {
+			section.Started = step.Started
+		}
+		section.Duration += step.Duration
+		if step.Status == StatusFail {
+			section.Details = fmt.Sprintf("%s: %s", step.Name, step.Details)
+		}
+
+		section.Steps = append(section.Steps, report.Step{
+			Name:      step.Name,
+			Kind:      step.Kind,
+			Status:    step.Status,
+			Details:   step.Details,
+			Started:   step.Started,
+			Duration:  step.Duration,
+			Exchanges: step.Exchanges,
+		})
+	}
+
+	return section
+}
@@ -0,0 +1,220 @@
+// Package scenario runs scripted send/expect exchanges over a FIX session.
+//
+// A scenario is a YAML document listing steps which either send a message,
+// expect a message matching tag predicates within a timeout or sleep:
+//
+//	name: Limit order acknowledged
+//	vars:
+//	  clordid: ${uuid}
+//	steps:
+//	  - name: send limit order
+//	    send:
+//	      Header:
+//	        MsgType: NewOrderSingle
+//	      Body:
+//	        ClOrdID: ${clordid}
+//	        Symbol: EURUSD
+//	        ...
+//	  - name: order acknowledged
+//	    expect:
+//	      timeout: 5s
+//	      fields:
+//	        MsgType: ExecutionReport
+//	        ClOrdID: ${clordid}
+//	        OrdStatus: {in: [NEW, PARTIALLY_FILLED]}
+//	        Text: {exists: false}
+//	      capture:
+//	        orderid: OrderID
+//	  - sleep: 1s
+//
+// Messages to send follow the format of `fix encode`. Variables are referenced
+// with ${name}, they are either defined in vars, captured from the fields of an
+// expected message, built-in (uuid, now) or environment variables.
+package scenario
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"sylr.dev/fix/pkg/errors"
+
+	yaml "sylr.dev/yaml/v3"
+)
+
+// Scenario is a named list of steps.
+type Scenario struct {
+	Name  string            `yaml:"name"`
+	Vars  map[string]string `yaml:"vars"`
+	Steps []Step            `yaml:"steps"`
+}
+
+// Step is one of send, expect or sleep.
+type Step struct {
+	Name   string        `yaml:"name"`
+	Send   yaml.Node     `yaml:"send"`
+	Expect *Expect       `yaml:"expect"`
+	Sleep  time.Duration `yaml:"sleep"`
+}
+
+// Expect describes the message expected by a step. Received messages which do
+// not match are discarded.
+type Expect struct {
+	Timeout time.Duration        `yaml:"timeout"`
+	Fields  map[string]Predicate `yaml:"fields"`
+	Capture map[string]string    `yaml:"capture"`
+}
+
+// Predicate is a condition on the value of a field. A scalar is an equality.
+type Predicate struct {
+	Equals *string  `yaml:"equals"`
+	Not    *string  `yaml:"not"`
+	Regex  string   `yaml:"regex"`
+	Exists *bool    `yaml:"exists"`
+	In     []string `yaml:"in"`
+}
+
+func (p *Predicate) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		value := node.Value
+		p.Equals = &value
+		return nil
+	}
+
+	type predicate Predicate
+	return node.Decode((*predicate)(p))
+}
+
+// Kind returns the kind of the step: send, expect or sleep.
+func (s Step) Kind() string {
+	switch {
+	case s.Send.Kind != 0:
+		return "send"
+	case s.Expect != nil:
+		return "expect"
+	default:
+		return "sleep"
+	}
+}
+
+// Load reads the scenario of a YAML file.
+func Load(path string) (*Scenario, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Scenario{}
+	if err := yaml.Unmarshal(b, s); err != nil {
+		return nil, fmt.Errorf("%w: %s: %s", errors.Options, path, err)
+	}
+
+	if len(s.Name) == 0 {
+		s.Name = path
+	}
+
+	for i, step := range s.Steps {
+		kinds := 0
+		for _, set := range []bool{step.Send.Kind != 0, step.Expect != nil, step.Sleep > 0} {
+			if set {
+				kinds++
+			}
+		}
+		if kinds != 1 {
+			return nil, fmt.Errorf("%w: %s: step %d must have exactly one of send, expect or sleep", errors.Options, path, i+1)
+		}
+		if len(step.Name) == 0 {
+			s.Steps[i].Name = fmt.Sprintf("%s #%d", step.Kind(), i+1)
+		}
+	}
+
+	return s, nil
+}
+
+var variableRegexp = regexp.MustCompile(`\$\{([A-Za-z0-9_.-]+)\}`)
+
+// Vars holds the variables of a scenario run.
+type Vars map[string]string
+
+// Expand replaces the variables referenced in s.
+func (v Vars) Expand(s string) (string, error) {
+	var err error
+
+	expanded := variableRegexp.ReplaceAllStringFunc(s, func(ref string) string {
+		name := ref[2 : len(ref)-1]
+		if value, ok := v[name]; ok {
+			return value
+		}
+		switch name {
+		case "uuid":
+			return uuid.NewString()
+		case "now":
+			return time.Now().UTC().Format("20060102-15:04:05.000")
+		}
+		if value, ok := os.LookupEnv(name); ok {
+			return value
+		}
+		if err == nil {
+			err = fmt.Errorf("%w: undefined variable %s", errors.Options, name)
+		}
+		return ref
+	})
+
+	return expanded, err
+}
+
+// expandNode returns a copy of the node with the variables of its scalars
+// expanded.
+func (v Vars) expandNode(node *yaml.Node) (*yaml.Node, error) {
+	n := *node
+
+	if n.Kind == yaml.ScalarNode {
+		value, err := v.Expand(n.Value)
+		if err != nil {
+			return nil, err
+		}
+		n.Value = value
+		return &n, nil
+	}
+
+	n.Content = make([]*yaml.Node, len(node.Content))
+	for i, child := range node.Content {
+		c, err := v.expandNode(child)
+		if err != nil {
+			return nil, err
+		}
+		n.Content[i] = c
+	}
+
+	return &n, nil
+}
+
+// describe returns the predicate in a human readable form.
+func (p Predicate) describe() string {
+	var parts []string
+	if p.Equals != nil {
+		parts = append(parts, fmt.Sprintf("= %s", *p.Equals))
+	}
+	if p.Not != nil {
+		parts = append(parts, fmt.Sprintf("!= %s", *p.Not))
+	}
+	if len(p.Regex) > 0 {
+		parts = append(parts, fmt.Sprintf("~ %s", p.Regex))
+	}
+	if p.Exists != nil {
+		if *p.Exists {
+			parts = append(parts, "exists")
+		} else {
+			parts = append(parts, "absent")
+		}
+	}
+	if len(p.In) > 0 {
+		parts = append(parts, fmt.Sprintf("in [%s]", strings.Join(p.In, ", ")))
+	}
+
+	return strings.Join(parts, ", ")
+}
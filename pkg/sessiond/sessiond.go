@@ -0,0 +1,455 @@
+// Package sessiond lets the commands send their messages on a session held by
+// another process, `fix session hold --serve`, instead of logging on
+// themselves, which takes seconds with most venues.
+//
+// The session is served on a Unix socket. Clients write a JSON Request per line
+// and read a JSON Response per line for each of them:
+//
+//	{"raw": "8=FIXT.1.1\u00019=...", "expect": ["35=8", "11=abc"], "timeout": 5000000000}
+//	{"raw": "8=FIXT.1.1\u00019=...\u000135=8..."}
+//
+// The message sent gets the header of the session. The response is the first
+// application message received after it which holds the expected fields, any
+// one with Wait, none otherwise. When the message sent has ids (ClOrdID,
+// QuoteReqID, MDReqID...), messages with other ids are not its response.
+//
+// Requests with a Command rather manage the session: "status" answers the
+// session held and "logout" logs it out, with Text, ending the holding process.
+//
+// The commands expecting a single response to their request get a Client from
+// Served when their session is held, and Exchange their request through it
+// with their application as if they had logged on themselves.
+package sessiond
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/quickfix/datadictionary"
+	"github.com/quickfixgo/tag"
+	"github.com/rs/zerolog"
+
+	"sylr.dev/fix/pkg/errors"
+	"sylr.dev/fix/pkg/utils"
+)
+
+// DefaultTimeout is how long requests not giving a timeout wait for their
+// response.
+const DefaultTimeout = 5 * time.Second
+
+// DefaultSocket returns the socket the session of the context is served on by
+// default.
+func DefaultSocket(context string) string {
+	return filepath.Join(os.ExpandEnv("$HOME"), ".fix", "sessions", context+".sock")
+}
+
+// Commands managing the session.
+const (
+	CommandStatus = "status"
+	CommandLogout = "logout"
+)
+
+// Request is a message to send on the session.
+type Request struct {
+	Raw string `json:"raw"`
+	// Command manages the session instead of sending Raw on it.
+	Command string `json:"command,omitempty"`
+	// Text is the Text of the Logout of the logout command.
+	Text string `json:"text,omitempty"`
+	// Expect are the Field=Value predicates the response must match, implying
+	// Wait.
+	Expect  []string      `json:"expect,omitempty"`
+	Wait    bool          `json:"wait,omitempty"`
+	Timeout time.Duration `json:"timeout,omitempty"`
+}
+
+// Response is the answer to a Request.
+type Response struct {
+	// Raw is the response received, if waited for.
+	Raw   string `json:"raw,omitempty"`
+	Error string `json:"error,omitempty"`
+	// Session is the session held.
+	Session string `json:"session,omitempty"`
+}
+
+// Server serves a session on a Unix socket.
+type Server struct {
+	transportDict *datadictionary.DataDictionary
+	appDict       *datadictionary.DataDictionary
+	logger        *zerolog.Logger
+
+	waiters    map[chan *quickfix.Message]struct{}
+	waitersMux sync.Mutex
+
+	onLogout func(text string)
+}
+
+// NewServer returns a server of a session using the dictionaries.
+func NewServer(transportDict, appDict *datadictionary.DataDictionary, logger *zerolog.Logger) *Server {
+	return &Server{
+		transportDict: transportDict,
+		appDict:       appDict,
+		logger:        logger,
+		waiters:       make(map[chan *quickfix.Message]struct{}),
+	}
+}
+
+// OnLogout sets the function logging the session out on the logout command.
+func (s *Server) OnLogout(logout func(text string)) {
+	s.onLogout = logout
+}
+
+// Received hands the application message received on the session to the
+// requests waiting for their response.
+func (s *Server) Received(message *quickfix.Message) {
+	s.waitersMux.Lock()
+	defer s.waitersMux.Unlock()
+
+	for waiter := range s.waiters {
+		select {
+		case waiter <- message:
+		default:
+		}
+	}
+}
+
+// Serve serves the session on the socket until the context is done.
+func (s *Server) Serve(ctx context.Context, path string, sessionID quickfix.SessionID) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	// The socket of a process which did not exit cleanly
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+		return fmt.Errorf("%w: session already served on %s", errors.Options, path)
+	}
+	os.Remove(path)
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		listener.Close()
+		return err
+	}
+
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	s.logger.Info().Msgf("Serving session %s on %s", sessionID, path)
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		go s.serveConn(ctx, conn, sessionID)
+	}
+}
+
+func (s *Server) serveConn(ctx context.Context, conn net.Conn, sessionID quickfix.SessionID) {
+	defer conn.Close()
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	encoder := json.NewEncoder(conn)
+
+	for scanner.Scan() {
+		var request Request
+		var response Response
+
+		if err := json.Unmarshal(scanner.Bytes(), &request); err != nil {
+			response.Error = err.Error()
+		} else if len(request.Command) > 0 {
+			response.Session = sessionID.String()
+			if err := s.command(request); err != nil {
+				response.Error = err.Error()
+			}
+		} else if raw, err := s.handle(ctx, request, sessionID); err != nil {
+			response.Error = err.Error()
+		} else {
+			response.Raw = raw
+		}
+
+		if err := encoder.Encode(response); err != nil {
+			return
+		}
+	}
+}
+
+// command runs the command of the request.
+func (s *Server) command(request Request) error {
+	switch request.Command {
+	case CommandStatus:
+		return nil
+	case CommandLogout:
+		if s.onLogout == nil {
+			return fmt.Errorf("%w: the session can not be logged out", errors.NotImplemented)
+		}
+		s.onLogout(request.Text)
+		return nil
+	default:
+		return fmt.Errorf("%w: unknown command %q", errors.Options, request.Command)
+	}
+}
+
+// handle sends the message of the request and waits for its response.
+func (s *Server) handle(ctx context.Context, request Request, sessionID quickfix.SessionID) (string, error) {
+	raw, err := utils.QuickFixRawMessageSetBodyLength(utils.QuickFixRawMessage(request.Raw))
+	if err != nil {
+		return "", err
+	}
+
+	parsed, err := utils.ParseQuickFixRawMessage(raw, s.transportDict, s.appDict)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", errors.FixMessageParse, err)
+	}
+
+	matchers, err := utils.ParseQuickFixFieldMatchers(request.Expect, s.transportDict, s.appDict)
+	if err != nil {
+		return "", err
+	}
+
+	var waiter chan *quickfix.Message
+	if request.Wait || len(matchers) > 0 {
+		waiter = make(chan *quickfix.Message, 64)
+
+		s.waitersMux.Lock()
+		s.waiters[waiter] = struct{}{}
+		s.waitersMux.Unlock()
+
+		defer func() {
+			s.waitersMux.Lock()
+			delete(s.waiters, waiter)
+			s.waitersMux.Unlock()
+		}()
+	}
+
+	// Responses to the other requests served concurrently are skipped
+	ids := utils.QuickFixMessageIDs(parsed)
+
+	// Drop the raw bytes of the parsed message for it to be built again with
+	// the session header
+	if err := quickfix.SendToTarget(utils.QuickFixMessageCopy(parsed, s.appDict), sessionID); err != nil {
+		return "", err
+	}
+
+	if waiter == nil {
+		return "", nil
+	}
+
+	timeout := request.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	deadline := time.After(timeout)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return "", errors.FixLogout
+
+		case <-deadline:
+			return "", errors.ResponseTimeout
+
+		case message := <-waiter:
+			if len(ids) > 0 && !utils.QuickFixMessageCorrelates(message, ids...) {
+				continue
+			}
+			if utils.QuickFixMessageMatches(message, matchers) {
+				return message.String(), nil
+			}
+		}
+	}
+}
+
+// Client sends messages on a session served on a socket.
+type Client struct {
+	conn      net.Conn
+	scanner   *bufio.Scanner
+	sessionID quickfix.SessionID
+}
+
+// Dial connects to the session served on the socket.
+func Dial(path string) (*Client, error) {
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	return &Client{conn: conn, scanner: scanner}, nil
+}
+
+// Served returns a client of the session of the settings served on the socket,
+// or else on the default socket of the context when it exists, nil when the
+// session is not served there, the command then logging on by itself. Only the
+// errors of the socket given are returned.
+func Served(socket, context string, settings *quickfix.Settings) (*Client, error) {
+	explicit := len(socket) > 0
+	if !explicit {
+		socket = DefaultSocket(context)
+		if _, err := os.Stat(socket); err != nil {
+			return nil, nil
+		}
+	}
+
+	var sessionID quickfix.SessionID
+	for id := range settings.SessionSettings() {
+		sessionID = id
+	}
+
+	client, err := Dial(socket)
+	if err == nil {
+		client.sessionID = sessionID
+
+		var held string
+		if held, err = client.Status(); err == nil && held != sessionID.String() {
+			err = fmt.Errorf("%w: %s serves session %s, not %s", errors.Options, socket, held, sessionID)
+		}
+		if err != nil {
+			client.Close()
+		}
+	}
+
+	switch {
+	case err == nil:
+		return client, nil
+	case explicit:
+		return nil, err
+	default:
+		return nil, nil
+	}
+}
+
+// SessionID returns the session of the settings the client was returned by
+// Served for.
+func (c *Client) SessionID() quickfix.SessionID {
+	return c.sessionID
+}
+
+// Exchange sends the message on the session and hands its response, parsed
+// with the dictionaries, to the application, as if the application held the
+// session itself: its ToApp is called with the message before it is sent and
+// its FromApp with the response.
+func (c *Client) Exchange(app quickfix.Application, message quickfix.Messagable, timeout time.Duration, transportDict, appDict *datadictionary.DataDictionary) error {
+	msg := message.ToMessage()
+	msg.Header.SetString(tag.BeginString, c.sessionID.BeginString)
+
+	if err := app.ToApp(msg, c.sessionID); err != nil {
+		return err
+	}
+
+	raw, err := c.Send(Request{Raw: msg.String(), Wait: true, Timeout: timeout})
+	if err != nil {
+		return err
+	}
+
+	response, err := utils.ParseQuickFixRawMessage(raw, transportDict, appDict)
+	if err != nil {
+		return fmt.Errorf("%w: %s", errors.FixMessageParse, err)
+	}
+
+	if rerr := app.FromApp(response, c.sessionID); rerr != nil {
+		return rerr
+	}
+
+	return nil
+}
+
+// Close closes the connection to the session.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Send sends the request and returns the raw response, empty when the request
+// does not wait for one.
+func (c *Client) Send(request Request) (string, error) {
+	response, err := c.do(request)
+	if err != nil {
+		return "", err
+	}
+
+	return response.Raw, nil
+}
+
+// Status returns the session held.
+func (c *Client) Status() (string, error) {
+	response, err := c.do(Request{Command: CommandStatus})
+	if err != nil {
+		return "", err
+	}
+
+	return response.Session, nil
+}
+
+// Logout logs the session held out with the text and returns it.
+func (c *Client) Logout(text string) (string, error) {
+	response, err := c.do(Request{Command: CommandLogout, Text: text})
+	if err != nil {
+		return "", err
+	}
+
+	return response.Session, nil
+}
+
+func (c *Client) do(request Request) (Response, error) {
+	var response Response
+
+	line, err := json.Marshal(request)
+	if err != nil {
+		return response, err
+	}
+
+	if _, err := c.conn.Write(append(line, '\n')); err != nil {
+		return response, err
+	}
+
+	if !c.scanner.Scan() {
+		if err := c.scanner.Err(); err != nil {
+			return response, err
+		}
+		return response, errors.FixLogout
+	}
+
+	if err := json.Unmarshal(c.scanner.Bytes(), &response); err != nil {
+		return response, err
+	}
+
+	switch response.Error {
+	case "":
+	case errors.ResponseTimeout.Error():
+		return response, errors.ResponseTimeout
+	case errors.FixLogout.Error():
+		return response, errors.FixLogout
+	default:
+		// The refusals of the holding process stay comparable
+		for _, refusal := range []error{errors.RiskLimitExceeded, errors.Throttled} {
+			if rest, ok := strings.CutPrefix(response.Error, refusal.Error()); ok {
+				return response, fmt.Errorf("%w%s", refusal, strings.TrimSpace(rest))
+			}
+		}
+		return response, fmt.Errorf("%s", strings.TrimSpace(response.Error))
+	}
+
+	return response, nil
+}
@@ -0,0 +1,76 @@
+// Package shutdown stops the long-running commands once interrupted, step by
+// step and within a timeout: the sessions are logged out first, quickfix
+// waiting for the counterparties to confirm their Logout up to the
+// LogoutTimeout of the sessions, then what the command holds is flushed and
+// closed.
+package shutdown
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+
+	"sylr.dev/fix/pkg/errors"
+)
+
+// DefaultTimeout is the time the steps of a shutdown have by default.
+const DefaultTimeout = 10 * time.Second
+
+// Step is a step of a shutdown.
+type Step struct {
+	// Name tells what the step does, e.g. "log out the sessions".
+	Name string
+	Run  func() error
+}
+
+// Stop returns a step running a Stop() method which can't fail.
+func Stop(name string, stop func()) Step {
+	return Step{
+		Name: name,
+		Run: func() error {
+			stop()
+			return nil
+		},
+	}
+}
+
+// AddFlags adds --shutdown-timeout to the command.
+func AddFlags(cmd *cobra.Command, timeout *time.Duration) {
+	cmd.Flags().DurationVar(timeout, "shutdown-timeout", DefaultTimeout, "Time the sessions have to log out and the command to flush its state once interrupted")
+}
+
+// Run runs the steps one after the other. The errors of the steps are joined
+// while the remaining steps go on, but the shutdown is given up once timeout
+// elapsed, the step running then being abandoned to the exit of the process.
+func Run(logger *zerolog.Logger, timeout time.Duration, steps ...Step) error {
+	logger.Info().Msgf("Shutting down")
+
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	var errs []error
+	for _, step := range steps {
+		start := time.Now()
+		done := make(chan error, 1)
+		go func(step Step) {
+			done <- step.Run()
+		}(step)
+
+		select {
+		case err := <-done:
+			if err != nil {
+				logger.Error().Err(err).Msgf("Could not %s", step.Name)
+				errs = append(errs, fmt.Errorf("%s: %w", step.Name, err))
+				continue
+			}
+			logger.Debug().Msgf("Shutdown: %s took %s", step.Name, time.Since(start).Round(time.Millisecond))
+
+		case <-deadline.C:
+			return fmt.Errorf("%w: could not %s within the shutdown timeout of %s", errors.ResponseTimeout, step.Name, timeout)
+		}
+	}
+
+	return errors.Join(errs...)
+}
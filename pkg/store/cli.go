@@ -0,0 +1,82 @@
+package store
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"sylr.dev/fix/config"
+	"sylr.dev/fix/pkg/cli/complete"
+	"sylr.dev/fix/pkg/errors"
+)
+
+func ValidateOptions(cmd *cobra.Command, args []string) error {
+	options := config.GetOptions()
+	conf, err := config.ReadYAMLFiles(config.ConfigPaths(cmd.Flags().Changed("config")), options.Interactive)
+
+	if err != nil {
+		return fmt.Errorf("unable to read configuration: %w", err)
+	}
+
+	err = conf.Validate()
+	if err != nil {
+		return err
+	}
+
+	// Retrieve the global config pointer
+	fixConfig := config.GetConfig()
+
+	// Set the config retrieved in the config file into the global config pointer
+	*fixConfig = *conf
+
+	_, err = config.GetCurrentContext()
+
+	return err
+}
+
+// SelectedSessions returns the sessions of the current context, only the one
+// given with --session if any.
+func SelectedSessions() ([]*Session, error) {
+	options := config.GetOptions()
+
+	context, err := config.GetCurrentContext()
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := ContextSessions(context)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(options.Session) == 0 {
+		return sessions, nil
+	}
+
+	for _, session := range sessions {
+		if session.Name == options.Session {
+			return []*Session{session}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", errors.ConfigSessionNotInContext, options.Session)
+}
+
+func AddPersistentFlags(cmd *cobra.Command) {
+	options := config.GetOptions()
+
+	cmd.PersistentFlags().StringVar(&options.Context, "context", "", "Context whose sessions stores to use")
+	cmd.PersistentFlags().StringVar(&options.Session, "session", "", "Only use the store of this session of the context")
+}
+
+func AddPersistentFlagCompletions(cmd *cobra.Command) error {
+	if err := cmd.RegisterFlagCompletionFunc("context", complete.Context); err != nil {
+		return err
+	}
+
+	if err := cmd.RegisterFlagCompletionFunc("session", complete.Session); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package store
+
+import (
+	"fmt"
+
+	"github.com/nats-io/nats.go"
+)
+
+// jetStreamKV keeps the sessions in a key value bucket of NATS JetStream,
+// created when it does not exist.
+type jetStreamKV struct {
+	conn *nats.Conn
+	kv   nats.KeyValue
+}
+
+func dialJetStream(url, bucket string) (*jetStreamKV, error) {
+	conn, err := nats.Connect(url, nats.Timeout(timeout))
+	if err != nil {
+		return nil, err
+	}
+
+	js, err := conn.JetStream(nats.MaxWait(timeout))
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	kv, err := js.KeyValue(bucket)
+	if err == nats.ErrBucketNotFound {
+		kv, err = js.CreateKeyValue(&nats.KeyValueConfig{
+			Bucket:      bucket,
+			Description: "Sequence numbers and messages of the fix sessions",
+		})
+	}
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("store bucket %s: %w", bucket, err)
+	}
+
+	return &jetStreamKV{conn: conn, kv: kv}, nil
+}
+
+func (j *jetStreamKV) get(key string) ([]byte, error) {
+	entry, err := j.kv.Get(key)
+	if err == nats.ErrKeyNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return entry.Value(), nil
+}
+
+func (j *jetStreamKV) put(key string, value []byte) error {
+	_, err := j.kv.Put(key, value)
+	return err
+}
+
+func (j *jetStreamKV) deletePrefix(prefix string) error {
+	watcher, err := j.kv.Watch(prefix+">", nats.IgnoreDeletes(), nats.MetaOnly())
+	if err != nil {
+		return err
+	}
+	defer watcher.Stop()
+
+	// The initial values are followed by a nil entry
+	for entry := range watcher.Updates() {
+		if entry == nil {
+			break
+		}
+		if err := j.kv.Purge(entry.Key()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (j *jetStreamKV) close() error {
+	j.conn.Close()
+	return nil
+}
@@ -0,0 +1,238 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/quickfixgo/quickfix"
+
+	"sylr.dev/fix/config"
+)
+
+// timeout is the time the jetstream and redis stores have to connect and to
+// answer each of their requests.
+const timeout = 10 * time.Second
+
+const (
+	defaultBucket = "fix-store"
+	defaultPrefix = "fix"
+)
+
+// kv is the key value storage the jetstream and redis stores keep the sessions
+// in, get returning nil when there is no value for the key.
+type kv interface {
+	get(key string) ([]byte, error)
+	put(key string, value []byte) error
+	deletePrefix(prefix string) error
+	close() error
+}
+
+// kvSession is the value holding the sequence numbers of a session.
+type kvSession struct {
+	NextSenderMsgSeqNum int       `json:"nextSenderMsgSeqNum"`
+	NextTargetMsgSeqNum int       `json:"nextTargetMsgSeqNum"`
+	CreationTime        time.Time `json:"creationTime"`
+}
+
+type kvStoreFactory struct {
+	dial   func() (kv, error)
+	prefix string
+}
+
+// newKVStoreFactory returns the factory of the StoreType message stores, that
+// several processes can share.
+func newKVStoreFactory(settings *quickfix.SessionSettings) (quickfix.MessageStoreFactory, error) {
+	typ, err := settings.Setting(config.StoreTypeSetting)
+	if err != nil {
+		return nil, err
+	}
+	dsn, err := settings.Setting(config.StoreDataSourceNameSetting)
+	if err != nil {
+		return nil, err
+	}
+
+	f := kvStoreFactory{prefix: defaultPrefix}
+	if settings.HasSetting(config.StorePrefixSetting) {
+		f.prefix, _ = settings.Setting(config.StorePrefixSetting)
+	}
+
+	switch typ {
+	case config.StoreTypeJetStream:
+		bucket := defaultBucket
+		if settings.HasSetting(config.StoreBucketSetting) {
+			bucket, _ = settings.Setting(config.StoreBucketSetting)
+		}
+		f.dial = func() (kv, error) { return dialJetStream(dsn, bucket) }
+	case config.StoreTypeRedis:
+		f.dial = func() (kv, error) { return dialRedis(dsn) }
+	default:
+		return nil, fmt.Errorf("Unsupported %s: %s", config.StoreTypeSetting, typ)
+	}
+
+	return f, nil
+}
+
+func (f kvStoreFactory) Create(sessionID quickfix.SessionID) (quickfix.MessageStore, error) {
+	kv, err := f.dial()
+	if err != nil {
+		return nil, err
+	}
+
+	s := &kvStore{
+		kv:  kv,
+		key: f.prefix + "." + sessionKey(sessionID),
+	}
+	if s.cache, err = quickfix.NewMemoryStoreFactory().Create(sessionID); err != nil {
+		kv.close()
+		return nil, err
+	}
+	if err := s.Refresh(); err != nil {
+		kv.close()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// sessionKey returns the session ID as a key token, the characters NATS keys
+// do not allow replaced.
+func sessionKey(sessionID quickfix.SessionID) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, sessionID.String())
+}
+
+// kvStore is a message store keeping the sequence numbers of the session under
+// <prefix>.<session>.session and its messages under
+// <prefix>.<session>.messages.<seqnum>, cached in memory like the quickfix sql
+// store does.
+type kvStore struct {
+	kv    kv
+	key   string
+	cache quickfix.MessageStore
+}
+
+func (s *kvStore) messageKey(seqNum int) string {
+	return s.key + ".messages." + strconv.Itoa(seqNum)
+}
+
+func (s *kvStore) save() error {
+	value, err := json.Marshal(kvSession{
+		NextSenderMsgSeqNum: s.cache.NextSenderMsgSeqNum(),
+		NextTargetMsgSeqNum: s.cache.NextTargetMsgSeqNum(),
+		CreationTime:        s.cache.CreationTime(),
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.kv.put(s.key+".session", value)
+}
+
+func (s *kvStore) Reset() error {
+	if err := s.cache.Reset(); err != nil {
+		return err
+	}
+	if err := s.kv.deletePrefix(s.key + ".messages."); err != nil {
+		return err
+	}
+
+	return s.save()
+}
+
+func (s *kvStore) Refresh() error {
+	value, err := s.kv.get(s.key + ".session")
+	if err != nil {
+		return err
+	} else if value == nil {
+		return s.Reset()
+	}
+
+	var session kvSession
+	if err := json.Unmarshal(value, &session); err != nil {
+		return fmt.Errorf("%s.session: %w", s.key, err)
+	}
+
+	if err := s.cache.Reset(); err != nil {
+		return err
+	}
+	s.cache.SetCreationTime(session.CreationTime)
+	if err := s.cache.SetNextSenderMsgSeqNum(session.NextSenderMsgSeqNum); err != nil {
+		return err
+	}
+
+	return s.cache.SetNextTargetMsgSeqNum(session.NextTargetMsgSeqNum)
+}
+
+func (s *kvStore) NextSenderMsgSeqNum() int {
+	return s.cache.NextSenderMsgSeqNum()
+}
+
+func (s *kvStore) NextTargetMsgSeqNum() int {
+	return s.cache.NextTargetMsgSeqNum()
+}
+
+func (s *kvStore) SetNextSenderMsgSeqNum(next int) error {
+	if err := s.cache.SetNextSenderMsgSeqNum(next); err != nil {
+		return err
+	}
+	return s.save()
+}
+
+func (s *kvStore) SetNextTargetMsgSeqNum(next int) error {
+	if err := s.cache.SetNextTargetMsgSeqNum(next); err != nil {
+		return err
+	}
+	return s.save()
+}
+
+func (s *kvStore) IncrNextSenderMsgSeqNum() error {
+	return s.SetNextSenderMsgSeqNum(s.cache.NextSenderMsgSeqNum() + 1)
+}
+
+func (s *kvStore) IncrNextTargetMsgSeqNum() error {
+	return s.SetNextTargetMsgSeqNum(s.cache.NextTargetMsgSeqNum() + 1)
+}
+
+func (s *kvStore) CreationTime() time.Time {
+	return s.cache.CreationTime()
+}
+
+func (s *kvStore) SetCreationTime(_ time.Time) {}
+
+func (s *kvStore) SaveMessage(seqNum int, msg []byte) error {
+	return s.kv.put(s.messageKey(seqNum), msg)
+}
+
+func (s *kvStore) SaveMessageAndIncrNextSenderMsgSeqNum(seqNum int, msg []byte) error {
+	if err := s.SaveMessage(seqNum, msg); err != nil {
+		return err
+	}
+	return s.IncrNextSenderMsgSeqNum()
+}
+
+func (s *kvStore) GetMessages(beginSeqNum, endSeqNum int) ([][]byte, error) {
+	var msgs [][]byte
+	for seqNum := beginSeqNum; seqNum <= endSeqNum; seqNum++ {
+		msg, err := s.kv.get(s.messageKey(seqNum))
+		if err != nil {
+			return nil, err
+		} else if msg != nil {
+			msgs = append(msgs, msg)
+		}
+	}
+
+	return msgs, nil
+}
+
+func (s *kvStore) Close() error {
+	return s.kv.close()
+}
@@ -0,0 +1,188 @@
+package store
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// redisKV keeps the sessions in Redis, speaking just enough of its protocol
+// for the store.
+type redisKV struct {
+	conn   net.Conn
+	reader *bufio.Reader
+	mux    sync.Mutex
+}
+
+// dialRedis connects to the redis:// or rediss:// (TLS) URL, the password and
+// the database number of which are used when given.
+func dialRedis(dsn string) (*redisKV, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	host := u.Host
+	if len(u.Port()) == 0 {
+		host = net.JoinHostPort(u.Hostname(), "6379")
+	}
+
+	dialer := &net.Dialer{Timeout: timeout}
+	var conn net.Conn
+	switch u.Scheme {
+	case "redis":
+		conn, err = dialer.Dial("tcp", host)
+	case "rediss":
+		conn, err = tls.DialWithDialer(dialer, "tcp", host, &tls.Config{ServerName: u.Hostname()})
+	default:
+		return nil, fmt.Errorf("redis store URL scheme must be redis or rediss, not %q", u.Scheme)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	r := &redisKV{conn: conn, reader: bufio.NewReader(conn)}
+
+	if password, ok := u.User.Password(); ok {
+		args := []string{"AUTH", password}
+		if username := u.User.Username(); len(username) > 0 {
+			args = []string{"AUTH", username, password}
+		}
+		if _, err := r.do(args...); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	if db := strings.Trim(u.Path, "/"); len(db) > 0 {
+		if _, err := r.do("SELECT", db); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+// do sends the command and returns its reply.
+func (r *redisKV) do(args ...string) (any, error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if err := r.conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := io.WriteString(r.conn, b.String()); err != nil {
+		return nil, err
+	}
+
+	return r.read()
+}
+
+func (r *redisKV) read() (any, error) {
+	line, err := r.reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if len(line) == 0 {
+		return nil, fmt.Errorf("redis: empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, fmt.Errorf("redis: %s", line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(r.reader, buf); err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		items := make([]any, n)
+		for i := range items {
+			if items[i], err = r.read(); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("redis: unexpected reply %q", line)
+	}
+}
+
+func (r *redisKV) get(key string) ([]byte, error) {
+	reply, err := r.do("GET", key)
+	if err != nil || reply == nil {
+		return nil, err
+	}
+
+	return reply.([]byte), nil
+}
+
+func (r *redisKV) put(key string, value []byte) error {
+	_, err := r.do("SET", key, string(value))
+	return err
+}
+
+func (r *redisKV) deletePrefix(prefix string) error {
+	cursor := "0"
+	for {
+		reply, err := r.do("SCAN", cursor, "MATCH", prefix+"*", "COUNT", "1000")
+		if err != nil {
+			return err
+		}
+
+		items, ok := reply.([]any)
+		if !ok || len(items) != 2 {
+			return fmt.Errorf("redis: unexpected SCAN reply")
+		}
+		next, _ := items[0].([]byte)
+		keys, _ := items[1].([]any)
+
+		if len(keys) > 0 {
+			args := []string{"DEL"}
+			for _, key := range keys {
+				k, _ := key.([]byte)
+				args = append(args, string(k))
+			}
+			if _, err := r.do(args...); err != nil {
+				return err
+			}
+		}
+
+		cursor = string(next)
+		if cursor == "0" {
+			return nil
+		}
+	}
+}
+
+func (r *redisKV) close() error {
+	return r.conn.Close()
+}
@@ -0,0 +1,122 @@
+package store
+
+import (
+	"fmt"
+
+	"github.com/quickfixgo/quickfix"
+	qconfig "github.com/quickfixgo/quickfix/config"
+	"github.com/quickfixgo/quickfix/store/file"
+	"github.com/quickfixgo/quickfix/store/sql"
+
+	"sylr.dev/fix/config"
+	"sylr.dev/fix/pkg/errors"
+)
+
+// NewMessageStoreFactory returns the message store factory configured in the
+// settings: JetStream or Redis when a StoreType is set, SQL when a
+// SQLStoreDriver is set, file when a FileStorePath is set and memory otherwise.
+func NewMessageStoreFactory(settings *quickfix.Settings) (quickfix.MessageStoreFactory, error) {
+	global := settings.GlobalSettings()
+
+	if global.HasSetting(config.StoreTypeSetting) {
+		return newKVStoreFactory(global)
+	}
+
+	if global.HasSetting(qconfig.SQLStoreDriver) {
+		driver, err := global.Setting(qconfig.SQLStoreDriver)
+		if err != nil {
+			return nil, err
+		}
+		switch driver {
+		case "sqlite3", "postgres":
+			return sql.NewStoreFactory(settings), nil
+		default:
+			return nil, fmt.Errorf("Unsupported SQLStoreDriver: %s", driver)
+		}
+	}
+
+	if global.HasSetting(qconfig.FileStorePath) {
+		return file.NewStoreFactory(settings), nil
+	}
+
+	return quickfix.NewMemoryStoreFactory(), nil
+}
+
+// IsPersistent tells whether the messages stores configured in the settings
+// survive the process.
+func IsPersistent(settings *quickfix.Settings) bool {
+	global := settings.GlobalSettings()
+
+	return global.HasSetting(qconfig.SQLStoreDriver) || global.HasSetting(qconfig.FileStorePath) ||
+		global.HasSetting(config.StoreTypeSetting)
+}
+
+// IsShared tells whether the message stores configured in the settings can be
+// shared by several processes, which the sql, jetstream and redis ones can.
+func IsShared(settings *quickfix.Settings) bool {
+	global := settings.GlobalSettings()
+
+	return global.HasSetting(qconfig.SQLStoreDriver) || global.HasSetting(config.StoreTypeSetting)
+}
+
+// Session is a session of a context along with its quickfix settings.
+type Session struct {
+	Name      string
+	Session   *config.Session
+	SessionID quickfix.SessionID
+	Settings  *quickfix.Settings
+	Acceptor  bool
+}
+
+// ContextSessions returns the sessions of the context, which can either be an
+// initiator or an acceptor one.
+func ContextSessions(context *config.Context) ([]*Session, error) {
+	sessions, err := context.GetSessions()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*Session
+
+	for i, session := range sessions {
+		// Make a copy of the context which has only one session.
+		contextSingleSession := *context
+		contextSingleSession.Sessions = context.Sessions[i : i+1]
+
+		var settings *quickfix.Settings
+		if len(context.Acceptor) > 0 {
+			settings, err = contextSingleSession.ToQuickFixAcceptorSettings()
+		} else {
+			settings, err = contextSingleSession.ToQuickFixInitiatorSettings()
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for sessionID := range settings.SessionSettings() {
+			result = append(result, &Session{
+				Name:      session.Name,
+				Session:   session,
+				SessionID: sessionID,
+				Settings:  settings,
+				Acceptor:  len(context.Acceptor) > 0,
+			})
+		}
+	}
+
+	return result, nil
+}
+
+// Open opens the persistent message store of the session.
+func (s *Session) Open() (quickfix.MessageStore, error) {
+	if !IsPersistent(s.Settings) {
+		return nil, fmt.Errorf("%w: session %s", errors.ConfigStoreNotPersistent, s.Name)
+	}
+
+	factory, err := NewMessageStoreFactory(s.Settings)
+	if err != nil {
+		return nil, err
+	}
+
+	return factory.Create(s.SessionID)
+}
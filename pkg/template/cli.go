@@ -0,0 +1,23 @@
+package template
+
+import (
+	"github.com/spf13/cobra"
+
+	"sylr.dev/fix/config"
+)
+
+func AddPersistentFlags(cmd *cobra.Command) {
+	options := config.GetOptions()
+
+	cmd.PersistentFlags().StringVar(&options.Templates, "templates", DefaultDir(), "Directory of the message templates")
+}
+
+// CompleteName completes the names of the templates of the directory given
+// with --templates.
+func CompleteName(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	return Names(config.GetOptions().Templates), cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,237 @@
+// Package template renders user-defined message templates.
+//
+// A template is a message described in the format of `fix encode`, stored in
+// a YAML file of the templates directory, in which placeholders are expanded
+// with text/template before it is encoded:
+//
+//	# Limit order on a symbol
+//	Header:
+//	  MsgType: NewOrderSingle
+//	Body:
+//	  ClOrdID: {{uuid}}
+//	  Symbol: {{.Symbol}}
+//	  Side: {{.Side}}
+//	  TransactTime: {{now}}
+//
+// Values are given by name ({{.Symbol}}), {{uuid}} generates a new UUID,
+// {{now}} is the current time as a FIX UTC timestamp and {{env "NAME"}} reads
+// an environment variable. The leading comment lines describe the template.
+package template
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+	"text/template/parse"
+	"time"
+
+	"github.com/google/uuid"
+
+	"sylr.dev/fix/pkg/errors"
+)
+
+// Extension is the extension of the template files.
+const Extension = ".yaml"
+
+// DefaultDir returns the directory templates are read from by default,
+// fix/templates in the user configuration directory.
+func DefaultDir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return filepath.Join("$HOME", ".config", "fix", "templates")
+	}
+
+	return filepath.Join(dir, "fix", "templates")
+}
+
+// Template is a message template.
+type Template struct {
+	Name         string   `json:"name"`
+	Path         string   `json:"path"`
+	Description  string   `json:"description,omitempty"`
+	Placeholders []string `json:"placeholders"`
+
+	tmpl *texttemplate.Template
+}
+
+var funcs = texttemplate.FuncMap{
+	"uuid": uuid.NewString,
+	"now": func() string {
+		return time.Now().UTC().Format("20060102-15:04:05.000")
+	},
+	"env": os.Getenv,
+}
+
+// List returns the templates of the directory sorted by name.
+func List(dir string) ([]*Template, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*"+Extension))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	templates := make([]*Template, 0, len(paths))
+	for _, path := range paths {
+		t, err := parseFile(path)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, t)
+	}
+
+	return templates, nil
+}
+
+// Names returns the names of the templates of the directory.
+func Names(dir string) []string {
+	paths, _ := filepath.Glob(filepath.Join(dir, "*"+Extension))
+
+	names := make([]string, 0, len(paths))
+	for _, path := range paths {
+		names = append(names, strings.TrimSuffix(filepath.Base(path), Extension))
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Load reads the template of the directory with the given name.
+func Load(dir, name string) (*Template, error) {
+	path := filepath.Join(dir, name+Extension)
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("%w: unknown template %s, expected one of %s", errors.Options, name, strings.Join(Names(dir), ", "))
+	}
+
+	return parseFile(path)
+}
+
+func parseFile(path string) (*Template, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSuffix(filepath.Base(path), Extension)
+
+	tmpl, err := texttemplate.New(name).Funcs(funcs).Option("missingkey=error").Parse(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %s", errors.Options, path, err)
+	}
+
+	t := &Template{
+		Name:         name,
+		Path:         path,
+		Description:  description(content),
+		Placeholders: placeholders(tmpl.Tree.Root),
+		tmpl:         tmpl,
+	}
+
+	return t, nil
+}
+
+// description returns the leading comment lines of the template.
+func description(content []byte) string {
+	var lines []string
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "#") {
+			break
+		}
+		lines = append(lines, strings.TrimSpace(strings.TrimPrefix(line, "#")))
+	}
+
+	return strings.Join(lines, " ")
+}
+
+// placeholders returns the names of the values referenced by the template.
+func placeholders(root parse.Node) []string {
+	seen := make(map[string]bool)
+
+	var walk func(node parse.Node)
+	walk = func(node parse.Node) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, child := range n.Nodes {
+				walk(child)
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe)
+		case *parse.PipeNode:
+			if n == nil {
+				return
+			}
+			for _, cmd := range n.Cmds {
+				walk(cmd)
+			}
+		case *parse.CommandNode:
+			for _, arg := range n.Args {
+				walk(arg)
+			}
+		case *parse.FieldNode:
+			seen[n.Ident[0]] = true
+		case *parse.IfNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.RangeNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.WithNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		}
+	}
+	walk(root)
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Render expands the placeholders of the template with the values, which must
+// give all the ones referenced.
+func (t *Template) Render(values map[string]string) ([]byte, error) {
+	var missing []string
+	for _, name := range t.Placeholders {
+		if _, ok := values[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w: template %s: missing value(s) for %s", errors.Options, t.Name, strings.Join(missing, ", "))
+	}
+
+	var b bytes.Buffer
+	if err := t.tmpl.Execute(&b, values); err != nil {
+		return nil, fmt.Errorf("%w: template %s: %s", errors.Options, t.Name, err)
+	}
+
+	return b.Bytes(), nil
+}
+
+// ParseValues parses the Name=Value pairs giving the values of placeholders.
+func ParseValues(pairs []string) (map[string]string, error) {
+	values := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok || len(name) == 0 {
+			return nil, fmt.Errorf("%w: --set %s: expected Name=Value", errors.Options, pair)
+		}
+		values[name] = value
+	}
+
+	return values, nil
+}
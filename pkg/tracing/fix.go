@@ -0,0 +1,133 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/tag"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var errNoResponse = errors.New("no response")
+
+// correlationTags are the fields relating responses to their request.
+var correlationTags = []quickfix.Tag{
+	tag.ClOrdID,
+	tag.MDReqID,
+	tag.SecurityReqID,
+	tag.SecurityStatusReqID,
+	quickfix.Tag(335), // TradSesReqID
+	tag.QuoteID,
+	quickfix.Tag(131), // QuoteReqID
+	tag.MassStatusReqID,
+}
+
+// CorrelationID returns the identifier relating the message to its request
+// or responses, if any.
+func CorrelationID(message *quickfix.Message) string {
+	for _, t := range correlationTags {
+		if id, err := message.Body.GetString(t); err == nil && len(id) > 0 {
+			return id
+		}
+	}
+
+	return ""
+}
+
+// StartMessage starts a span about a FIX message, named after the operation
+// and the message type.
+func StartMessage(ctx context.Context, operation string, kind trace.SpanKind, message *quickfix.Message, sessionID quickfix.SessionID) (context.Context, *Span) {
+	msgType, _ := message.MsgType()
+
+	ctx, span := Start(ctx, operation+" "+msgType, kind)
+	if span == nil {
+		return ctx, nil
+	}
+
+	seqNum, _ := message.Header.GetString(tag.MsgSeqNum)
+
+	span.SetAttribute("fix.session", sessionID.String())
+	span.SetAttribute("fix.msg_type", msgType)
+	span.SetAttribute("fix.seq_num", seqNum)
+	span.SetAttribute("fix.correlation_id", CorrelationID(message))
+
+	return ctx, span
+}
+
+// Requests traces the requests sent on a session: the span of a request starts
+// when it is sent and ends when its first response, related to it by its
+// correlation id, is received. The zero value is ready to use.
+type Requests struct {
+	pending map[string]*Span
+	mux     sync.Mutex
+}
+
+// Sent starts the span of the request.
+func (r *Requests) Sent(message *quickfix.Message, sessionID quickfix.SessionID) {
+	if !Enabled() {
+		return
+	}
+
+	ctx, span := StartMessage(context.Background(), "fix request", SpanKindClient, message, sessionID)
+	_, send := StartMessage(ctx, "fix send", SpanKindProducer, message, sessionID)
+	send.Finish()
+
+	id := CorrelationID(message)
+	if len(id) == 0 {
+		span.Finish()
+		return
+	}
+
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if r.pending == nil {
+		r.pending = make(map[string]*Span)
+	}
+	if previous, ok := r.pending[id]; ok {
+		previous.Finish()
+	}
+	r.pending[id] = span
+}
+
+// Received records the response as a child of its request span, which ends.
+func (r *Requests) Received(message *quickfix.Message, sessionID quickfix.SessionID) {
+	if !Enabled() {
+		return
+	}
+
+	ctx := context.Background()
+	id := CorrelationID(message)
+
+	r.mux.Lock()
+	request, ok := r.pending[id]
+	if ok {
+		delete(r.pending, id)
+	}
+	r.mux.Unlock()
+
+	if ok {
+		ctx = trace.ContextWithSpanContext(ctx, request.span.SpanContext())
+	}
+
+	_, span := StartMessage(ctx, "fix response", SpanKindConsumer, message, sessionID)
+	span.Finish()
+
+	if ok {
+		request.Finish()
+	}
+}
+
+// Close ends the spans of the requests which never got a response.
+func (r *Requests) Close() {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	for id, span := range r.pending {
+		span.RecordError(errNoResponse)
+		span.Finish()
+		delete(r.pending, id)
+	}
+}
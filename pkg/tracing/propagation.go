@@ -0,0 +1,37 @@
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/propagation"
+)
+
+var propagator = propagation.TraceContext{}
+
+// Carrier is implemented by the message headers the trace context is
+// propagated into, e.g. nats.Header or http.Header.
+type Carrier interface {
+	Get(key string) string
+	Set(key, value string)
+}
+
+// textMapCarrier adapts a Carrier to the propagators, which only need its keys
+// to be the ones they propagate.
+type textMapCarrier struct {
+	Carrier
+}
+
+func (c textMapCarrier) Keys() []string {
+	return propagator.Fields()
+}
+
+// Inject writes the span context carried by ctx into the carrier as a W3C
+// traceparent header.
+func Inject(ctx context.Context, carrier Carrier) {
+	propagator.Inject(ctx, textMapCarrier{carrier})
+}
+
+// Extract returns a copy of ctx carrying the span context found in the carrier.
+func Extract(ctx context.Context, carrier Carrier) context.Context {
+	return propagator.Extract(ctx, textMapCarrier{carrier})
+}
@@ -0,0 +1,174 @@
+// Package tracing records OpenTelemetry spans of the FIX flows and exports them
+// to an OTLP/HTTP collector. Trace context is propagated with the W3C
+// traceparent header so that spans of a message can be linked across the
+// initiator, the acceptor/bridge and the NATS consumers.
+package tracing
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Span kinds.
+const (
+	SpanKindInternal = trace.SpanKindInternal
+	SpanKindServer   = trace.SpanKindServer
+	SpanKindClient   = trace.SpanKindClient
+	SpanKindProducer = trace.SpanKindProducer
+	SpanKindConsumer = trace.SpanKindConsumer
+)
+
+const tracerName = "sylr.dev/fix"
+
+// shutdownTimeout is the time the pending spans have to be exported on
+// shutdown.
+const shutdownTimeout = 5 * time.Second
+
+// Logger is the logger warned when the spans can't be exported.
+var Logger *zerolog.Logger
+
+// Span is a timed operation of a trace. Spans are no-ops when tracing is not
+// enabled so they can be used unconditionally.
+type Span struct {
+	span trace.Span
+}
+
+// SetAttribute sets an attribute of the span.
+func (s *Span) SetAttribute(key, value string) {
+	if s == nil || len(value) == 0 {
+		return
+	}
+
+	s.span.SetAttributes(attribute.String(key, value))
+}
+
+// RecordError marks the span as failed.
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+
+	s.span.RecordError(err)
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+// Finish ends the span and hands it to the exporter.
+func (s *Span) Finish() {
+	if s == nil {
+		return
+	}
+
+	s.span.End()
+}
+
+// Start starts a span, child of the one carried by ctx if any, and returns a
+// context carrying it. It returns a nil span when tracing is not enabled.
+func Start(ctx context.Context, name string, kind trace.SpanKind) (context.Context, *Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	provider := getProvider()
+	if provider == nil {
+		return ctx, nil
+	}
+
+	ctx, span := provider.Tracer(tracerName).Start(ctx, name, trace.WithSpanKind(kind))
+
+	return ctx, &Span{span: span}
+}
+
+var (
+	globalProvider *sdktrace.TracerProvider
+	globalMux      sync.RWMutex
+)
+
+func getProvider() *sdktrace.TracerProvider {
+	globalMux.RLock()
+	defer globalMux.RUnlock()
+
+	return globalProvider
+}
+
+// Enabled tells whether spans are recorded.
+func Enabled() bool {
+	return getProvider() != nil
+}
+
+// Init enables tracing, spans being exported to the OTLP/HTTP collector
+// listening at endpoint (e.g. http://localhost:4318).
+func Init(endpoint, serviceName string) error {
+	url := strings.TrimRight(endpoint, "/")
+	if !strings.HasSuffix(url, "/v1/traces") {
+		url += "/v1/traces"
+	}
+
+	exporter, err := otlptracehttp.New(context.Background(), otlptracehttp.WithEndpointURL(url))
+	if err != nil {
+		return err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(serviceName))),
+	)
+
+	// Only warn of the first failure not to flood the logs while the
+	// collector is down
+	var warnOnce sync.Once
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		if Logger == nil {
+			return
+		}
+		warned := true
+		warnOnce.Do(func() {
+			Logger.Warn().Err(err).Msg("Could not export the spans")
+			warned = false
+		})
+		if warned {
+			Logger.Debug().Err(err).Msg("Could not export the spans")
+		}
+	}))
+
+	globalMux.Lock()
+	defer globalMux.Unlock()
+
+	if globalProvider != nil {
+		shutdown(globalProvider)
+	}
+	globalProvider = provider
+
+	return nil
+}
+
+// Shutdown exports the pending spans and disables tracing.
+func Shutdown() {
+	globalMux.Lock()
+	defer globalMux.Unlock()
+
+	if globalProvider != nil {
+		shutdown(globalProvider)
+		globalProvider = nil
+	}
+}
+
+func shutdown(provider *sdktrace.TracerProvider) {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := provider.Shutdown(ctx); err != nil {
+		otel.Handle(err)
+	}
+}
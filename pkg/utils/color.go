@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/quickfixgo/enum"
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/quickfix/datadictionary"
+
+	"sylr.dev/fix/pkg/dict"
+)
+
+const (
+	colorReset  = "\x1b[0m"
+	colorBold   = "\x1b[1m"
+	colorDim    = "\x1b[2m"
+	colorGreen  = "\x1b[32m"
+	colorYellow = "\x1b[33m"
+	colorBlue   = "\x1b[34m"
+	colorCyan   = "\x1b[36m"
+)
+
+// ColorOutput tells whether the messages written to the console are colorized.
+// It is enabled when writing to a terminal unless --no-color is given or the
+// NO_COLOR environment variable is set.
+var ColorOutput bool
+
+func colorize(color, s string) string {
+	return color + s + colorReset
+}
+
+// QuickFixRawMessageColorized formats the raw message with colorized tags,
+// values and enum names, prefixed with the direction arrow and the message
+// type, which is padded so that the fields of successive messages are aligned.
+func QuickFixRawMessageColorized(message *quickfix.Message, transportDict, appDict *datadictionary.DataDictionary, sending bool) string {
+	arrow := colorize(colorBold+colorBlue, "<-")
+	if sending {
+		arrow = colorize(colorBold+colorGreen, "->")
+	}
+
+	msgType, _ := message.MsgType()
+	name := ""
+	if appDict != nil {
+		if def, ok := appDict.Messages[msgType]; ok {
+			name = def.Name
+		}
+	}
+	if len(name) == 0 {
+		if desc := MapSearch(dict.MessageTypes, enum.MsgType(msgType)); desc != nil {
+			name = *desc
+		}
+	}
+
+	header, body, trailer := QuickFixMessageFields(message, transportDict, appDict)
+
+	var parts []string
+	for _, fields := range [][]*QuickFixField{header, body, trailer} {
+		parts = appendQuickFixFieldsColorized(parts, fields)
+	}
+
+	return fmt.Sprintf("%s %s %s %s", arrow,
+		colorize(colorBold, fmt.Sprintf("%-2s", msgType)),
+		colorize(colorYellow, fmt.Sprintf("%-28s", name)),
+		strings.Join(parts, colorize(colorDim, "|")))
+}
+
+func appendQuickFixFieldsColorized(parts []string, fields []*QuickFixField) []string {
+	for _, field := range fields {
+		part := colorize(colorCyan, strconv.Itoa(field.Tag)) + "=" + field.Value
+		if len(field.Description) > 0 {
+			part += colorize(colorYellow, "("+field.Description+")")
+		}
+		parts = append(parts, part)
+
+		for _, group := range field.Groups {
+			parts = appendQuickFixFieldsColorized(parts, group)
+		}
+	}
+
+	return parts
+}
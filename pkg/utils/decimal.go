@@ -0,0 +1,125 @@
+package utils
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/quickfix/datadictionary"
+	"github.com/shopspring/decimal"
+
+	"sylr.dev/fix/pkg/instrument"
+)
+
+// instrumentScales are the number of decimals of the tick and lot sizes of an
+// instrument, -1 when unknown.
+type instrumentScales struct {
+	price    int32
+	quantity int32
+}
+
+var (
+	decimalScales    = make(map[string]instrumentScales)
+	decimalScalesMux sync.RWMutex
+)
+
+// SetDecimalScales sets the instruments whose tick and lot sizes give the
+// number of decimals their prices and quantities are written with.
+func SetDecimalScales(instruments []instrument.Instrument) {
+	decimalScalesMux.Lock()
+	defer decimalScalesMux.Unlock()
+
+	for _, i := range instruments {
+		decimalScales[i.Symbol] = instrumentScales{
+			price:    stringDecimalScale(i.TickSize),
+			quantity: stringDecimalScale(i.LotSize),
+		}
+	}
+}
+
+func stringDecimalScale(value string) int32 {
+	d, err := decimal.NewFromString(value)
+	if err != nil {
+		return -1
+	}
+
+	return DecimalScale(d)
+}
+
+// DecimalScale returns the number of decimals of the value, trailing zeros
+// excluded.
+func DecimalScale(value decimal.Decimal) int32 {
+	s := value.String()
+
+	i := strings.IndexByte(s, '.')
+	if i < 0 {
+		return 0
+	}
+
+	return int32(len(s) - i - 1)
+}
+
+// QuickFixDecimalScale returns the number of decimals the value of the field
+// is written with given its type in the dictionary: the ones of the tick size
+// of the symbol for PRICE and PRICEOFFSET fields and of its lot size for QTY
+// ones, unless the value has more, which are kept.
+func QuickFixDecimalScale(dict *datadictionary.DataDictionary, t quickfix.Tag, symbol string, value decimal.Decimal) int32 {
+	scale := DecimalScale(value)
+
+	if dict == nil {
+		return scale
+	}
+
+	fieldType, ok := dict.FieldTypeByTag[int(t)]
+	if !ok {
+		return scale
+	}
+
+	decimalScalesMux.RLock()
+	scales, ok := decimalScales[symbol]
+	decimalScalesMux.RUnlock()
+	if !ok {
+		return scale
+	}
+
+	var instrumentScale int32 = -1
+	switch fieldType.Type {
+	case "PRICE", "PRICEOFFSET":
+		instrumentScale = scales.price
+	case "QTY":
+		instrumentScale = scales.quantity
+	}
+
+	if instrumentScale > scale {
+		return instrumentScale
+	}
+
+	return scale
+}
+
+// QuickFixFormatDecimal formats the value of the field with the decimals of
+// QuickFixDecimalScale, returning it unchanged when it is not a decimal.
+func QuickFixFormatDecimal(dict *datadictionary.DataDictionary, t quickfix.Tag, symbol, value string) string {
+	d, err := decimal.NewFromString(value)
+	if err != nil {
+		return value
+	}
+
+	return d.StringFixed(QuickFixDecimalScale(dict, t, symbol, d))
+}
+
+// QuickFixDecimal returns the value of the field with its QuickFixDecimalScale,
+// to be given to the field constructors:
+//
+//	field.NewPrice(utils.QuickFixDecimal(appDict, tag.Price, symbol, price))
+func QuickFixDecimal(dict *datadictionary.DataDictionary, t quickfix.Tag, symbol string, value decimal.Decimal) (decimal.Decimal, int32) {
+	return value, QuickFixDecimalScale(dict, t, symbol, value)
+}
+
+// QuickFixMessagePartSetDictionaryDecimal is QuickFixMessagePartSetDecimal
+// with the QuickFixDecimalScale of the field.
+func QuickFixMessagePartSetDictionaryDecimal[T quickfix.FieldWriter, T2 ~string](setter QuickFixMessagePartSetter, dict *datadictionary.DataDictionary, t quickfix.Tag, symbol string, value T2, f func(decimal.Decimal, int32) T) {
+	if len(value) > 0 {
+		setter.Set(f(QuickFixDecimal(dict, t, symbol, MustNot(decimal.NewFromString(string(value))))))
+	}
+}
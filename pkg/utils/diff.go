@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LineDiff returns a diff of the lines of a and b where removed lines are
+// prefixed with "-", added lines with "+" and unchanged lines with " ".
+func LineDiff(a, b string) []string {
+	la := strings.Split(strings.TrimSuffix(a, "\n"), "\n")
+	lb := strings.Split(strings.TrimSuffix(b, "\n"), "\n")
+
+	// Longest common subsequence table.
+	lcs := make([][]int, len(la)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(lb)+1)
+	}
+	for i := len(la) - 1; i >= 0; i-- {
+		for j := len(lb) - 1; j >= 0; j-- {
+			if la[i] == lb[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	diff := make([]string, 0, len(la)+len(lb))
+	i, j := 0, 0
+	for i < len(la) && j < len(lb) {
+		switch {
+		case la[i] == lb[j]:
+			diff = append(diff, fmt.Sprintf(" %s", la[i]))
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			diff = append(diff, fmt.Sprintf("-%s", la[i]))
+			i++
+		default:
+			diff = append(diff, fmt.Sprintf("+%s", lb[j]))
+			j++
+		}
+	}
+	for ; i < len(la); i++ {
+		diff = append(diff, fmt.Sprintf("-%s", la[i]))
+	}
+	for ; j < len(lb); j++ {
+		diff = append(diff, fmt.Sprintf("+%s", lb[j]))
+	}
+
+	return diff
+}
@@ -0,0 +1,154 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Latencies is a set of latency measurements.
+type Latencies []time.Duration
+
+// LatencySummary sums up a set of latencies. Durations are in nanoseconds
+// when encoded in JSON.
+type LatencySummary struct {
+	Count int           `json:"count"`
+	Min   time.Duration `json:"min"`
+	Mean  time.Duration `json:"mean"`
+	P50   time.Duration `json:"p50"`
+	P95   time.Duration `json:"p95"`
+	P99   time.Duration `json:"p99"`
+	Max   time.Duration `json:"max"`
+}
+
+// LatencyBucket is a bucket of a latency histogram, From is inclusive and To
+// exclusive except for the last bucket.
+type LatencyBucket struct {
+	From  time.Duration `json:"from"`
+	To    time.Duration `json:"to"`
+	Count int           `json:"count"`
+}
+
+func (l Latencies) sorted() Latencies {
+	s := make(Latencies, len(l))
+	copy(s, l)
+	sort.Slice(s, func(i, j int) bool { return s[i] < s[j] })
+
+	return s
+}
+
+// percentile returns the p-th percentile (0 < p <= 100) of sorted latencies
+// using the nearest rank method.
+func (l Latencies) percentile(p float64) time.Duration {
+	if len(l) == 0 {
+		return 0
+	}
+
+	rank := int(math.Ceil(p / 100 * float64(len(l))))
+	if rank < 1 {
+		rank = 1
+	}
+
+	return l[rank-1]
+}
+
+// Percentile returns the p-th percentile (0 < p <= 100) of the latencies.
+func (l Latencies) Percentile(p float64) time.Duration {
+	return l.sorted().percentile(p)
+}
+
+// Summary returns the count, min, mean, max and main percentiles of the
+// latencies.
+func (l Latencies) Summary() LatencySummary {
+	if len(l) == 0 {
+		return LatencySummary{}
+	}
+
+	s := l.sorted()
+
+	var total time.Duration
+	for _, d := range s {
+		total += d
+	}
+
+	return LatencySummary{
+		Count: len(s),
+		Min:   s[0],
+		Mean:  total / time.Duration(len(s)),
+		P50:   s.percentile(50),
+		P95:   s.percentile(95),
+		P99:   s.percentile(99),
+		Max:   s[len(s)-1],
+	}
+}
+
+// Histogram splits the range of the latencies into buckets of equal width.
+func (l Latencies) Histogram(buckets int) []LatencyBucket {
+	if len(l) == 0 || buckets < 1 {
+		return nil
+	}
+
+	s := l.sorted()
+	min, max := s[0], s[len(s)-1]
+
+	width := (max - min) / time.Duration(buckets)
+	if width <= 0 {
+		return []LatencyBucket{{From: min, To: max, Count: len(s)}}
+	}
+
+	histogram := make([]LatencyBucket, buckets)
+	for i := range histogram {
+		histogram[i].From = min + time.Duration(i)*width
+		histogram[i].To = min + time.Duration(i+1)*width
+	}
+	histogram[buckets-1].To = max
+
+	for _, d := range s {
+		i := int((d - min) / width)
+		if i >= buckets {
+			i = buckets - 1
+		}
+		histogram[i].Count++
+	}
+
+	return histogram
+}
+
+// WriteLatencyHistogram draws the histogram with bars of at most width
+// characters.
+func WriteLatencyHistogram(w io.Writer, histogram []LatencyBucket, width int) {
+	highest := 0
+	for _, b := range histogram {
+		if b.Count > highest {
+			highest = b.Count
+		}
+	}
+	if highest == 0 {
+		return
+	}
+
+	for _, b := range histogram {
+		bar := strings.Repeat("#", b.Count*width/highest)
+		if b.Count > 0 && len(bar) == 0 {
+			bar = "."
+		}
+		fmt.Fprintf(w, "%12s - %-12s %-*s %d\n", FormatLatency(b.From), FormatLatency(b.To), width, bar, b.Count)
+	}
+}
+
+// FormatLatency rounds a latency to a precision suited to its magnitude.
+func FormatLatency(d time.Duration) string {
+	switch {
+	case d >= time.Second:
+		return d.Round(time.Millisecond).String()
+	case d >= time.Millisecond:
+		return d.Round(time.Microsecond).String()
+	case d >= time.Microsecond:
+		return d.Round(time.Nanosecond * 10).String()
+	default:
+		return d.String()
+	}
+}
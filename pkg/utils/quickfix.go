@@ -1,27 +1,25 @@
 package utils
 
 import (
+	"encoding/csv"
 	"fmt"
 	"io"
-	"os"
 	"strconv"
 	"strings"
 
-	"github.com/hashicorp/go-set"
 	"github.com/olekukonko/tablewriter"
 	"github.com/quickfixgo/enum"
+	"github.com/quickfixgo/field"
+	"github.com/quickfixgo/tag"
 	"github.com/rs/zerolog"
 	"github.com/shopspring/decimal"
 	"sylr.dev/fix/pkg/dict"
+	"sylr.dev/fix/pkg/tracing"
 
 	"github.com/quickfixgo/quickfix"
 	"github.com/quickfixgo/quickfix/datadictionary"
 )
 
-var (
-	tagFilter = set.From[int]([]int{1724, 453, 447, 448, 452, 2376})
-)
-
 type QuickFixMessagePartSetter interface {
 	Set(field quickfix.FieldWriter) *quickfix.FieldMap
 }
@@ -39,10 +37,89 @@ func QuickFixMessagePartSetDecimal[T quickfix.FieldWriter, T2 ~string](setter Qu
 	}
 }
 
+// QuickFixMessagePartSetRouting sets the routing fields defined in the session
+// settings (OnBehalfOfCompID, DeliverToCompID) into the given header.
+func QuickFixMessagePartSetRouting(setter QuickFixMessagePartSetter, settings *quickfix.SessionSettings) {
+	if settings == nil {
+		return
+	}
+
+	if value, err := settings.Setting("OnBehalfOfCompID"); err == nil {
+		QuickFixMessagePartSetString(setter, value, field.NewOnBehalfOfCompID)
+	}
+
+	if value, err := settings.Setting("DeliverToCompID"); err == nil {
+		QuickFixMessagePartSetString(setter, value, field.NewDeliverToCompID)
+	}
+}
+
+// quickFixCorrelationTags are the fields correlating the responses with the
+// messages they answer.
+var quickFixCorrelationTags = []quickfix.Tag{
+	tag.ClOrdID,
+	tag.OrigClOrdID,
+	tag.OrderID,
+	quickfix.Tag(131), // QuoteReqID
+	tag.QuoteID,
+	tag.MDReqID,
+}
+
+// QuickFixMessageCorrelates tells whether the ClOrdID, OrigClOrdID, OrderID,
+// QuoteReqID, QuoteID or MDReqID of the message is one of the given ids.
+// Messages with none of these fields are considered correlated.
+func QuickFixMessageCorrelates(message *quickfix.Message, ids ...string) bool {
+	found := false
+	for _, t := range quickFixCorrelationTags {
+		value, err := message.Body.GetString(t)
+		if err != nil {
+			continue
+		}
+		found = true
+		for _, id := range ids {
+			if len(id) > 0 && value == id {
+				return true
+			}
+		}
+	}
+
+	return !found
+}
+
+// QuickFixMessageIDs returns the ids of the message QuickFixMessageCorrelates
+// correlates its responses with.
+func QuickFixMessageIDs(message *quickfix.Message) []string {
+	var ids []string
+	for _, t := range quickFixCorrelationTags {
+		if value, err := message.Body.GetString(t); err == nil && len(value) > 0 {
+			ids = append(ids, value)
+		}
+	}
+
+	return ids
+}
+
+// Output formats of the messages written by WriteMessage.
+const (
+	OutputFormatTable = "table"
+	OutputFormatJSON  = "json"
+	OutputFormatFIXML = "fixml"
+	OutputFormatRaw   = "raw"
+	OutputFormatYAML  = "yaml"
+	OutputFormatCSV   = "csv"
+)
+
+var OutputFormats = []string{OutputFormatTable, OutputFormatJSON, OutputFormatYAML, OutputFormatCSV, OutputFormatFIXML, OutputFormatRaw}
+
 type QuickFixAppMessageLogger struct {
 	Logger                  *zerolog.Logger
 	TransportDataDictionary *datadictionary.DataDictionary
 	AppDataDictionary       *datadictionary.DataDictionary
+	OutputFormat            string
+
+	// Requests traces the requests sent and their responses.
+	Requests tracing.Requests
+
+	csvHeaderWritten bool
 }
 
 func (app *QuickFixAppMessageLogger) LogMessageType(message *quickfix.Message, sessionID quickfix.SessionID, log string) {
@@ -64,6 +141,11 @@ func (app *QuickFixAppMessageLogger) LogMessage(level zerolog.Level, message *qu
 		return
 	}
 
+	if ColorOutput {
+		app.Logger.WithLevel(level).Msg(QuickFixRawMessageColorized(message, app.TransportDataDictionary, app.AppDataDictionary, sending))
+		return
+	}
+
 	formatStr := "<- %s %s"
 	if sending {
 		formatStr = "-> %s %s"
@@ -71,69 +153,185 @@ func (app *QuickFixAppMessageLogger) LogMessage(level zerolog.Level, message *qu
 	app.Logger.WithLevel(level).Msgf(formatStr, "Raw", strings.Replace(message.String(), "\001", "|", -1))
 }
 
+// WriteMessage writes the message in the output format of the app, the body
+// as a table by default. The message is recorded as the result of the command.
+func (app *QuickFixAppMessageLogger) WriteMessage(w io.Writer, message *quickfix.Message) {
+	RecordResultMessage(message, app.TransportDataDictionary, app.AppDataDictionary)
+
+	switch app.OutputFormat {
+	case OutputFormatJSON:
+		app.WriteMessageAsJSON(w, message)
+	case OutputFormatYAML:
+		app.WriteMessageAsYAML(w, message)
+	case OutputFormatCSV:
+		app.WriteMessageAsCSV(w, message)
+	case OutputFormatFIXML:
+		app.WriteMessageAsFIXML(w, message)
+	case OutputFormatRaw:
+		app.WriteMessageAsRaw(w, message)
+	default:
+		app.WriteMessageBodyAsTable(w, message)
+	}
+}
+
+// WriteMessageAsJSON writes the message using the FIX JSON encoding.
+func (app *QuickFixAppMessageLogger) WriteMessageAsJSON(w io.Writer, message *quickfix.Message) {
+	b, err := QuickFixMessageToJSON(message, app.TransportDataDictionary, app.AppDataDictionary)
+	if err != nil {
+		app.Logger.Error().Err(err).Msg("Unable to encode message as JSON")
+		return
+	}
+
+	fmt.Fprintf(w, "%s\n", b)
+}
+
+// WriteMessageAsYAML writes the message as a YAML document.
+func (app *QuickFixAppMessageLogger) WriteMessageAsYAML(w io.Writer, message *quickfix.Message) {
+	b, err := QuickFixMessageToYAML(message, app.TransportDataDictionary, app.AppDataDictionary)
+	if err != nil {
+		app.Logger.Error().Err(err).Msg("Unable to encode message as YAML")
+		return
+	}
+
+	fmt.Fprintf(w, "---\n%s", b)
+}
+
+// WriteMessageAsCSV writes the body fields of the message as CSV records, the
+// header being written before the first message only.
+func (app *QuickFixAppMessageLogger) WriteMessageAsCSV(w io.Writer, message *quickfix.Message) {
+	records := QuickFixMessageCSVRecords(message, app.TransportDataDictionary, app.AppDataDictionary)
+
+	if err := writeQuickFixCSV(csv.NewWriter(w), !app.csvHeaderWritten, records); err != nil {
+		app.Logger.Error().Err(err).Msg("Unable to encode message as CSV")
+		return
+	}
+
+	app.csvHeaderWritten = true
+}
+
+// WriteMessageAsFIXML writes the message as a FIXML document.
+func (app *QuickFixAppMessageLogger) WriteMessageAsFIXML(w io.Writer, message *quickfix.Message) {
+	b, err := QuickFixMessageToFIXML(message, app.TransportDataDictionary, app.AppDataDictionary)
+	if err != nil {
+		app.Logger.Error().Err(err).Msg("Unable to encode message as FIXML")
+		return
+	}
+
+	fmt.Fprintf(w, "%s\n", b)
+}
+
+// WriteMessageAsRaw writes the raw message, each tag and value being annotated
+// with its data dictionary name and description.
+func (app *QuickFixAppMessageLogger) WriteMessageAsRaw(w io.Writer, message *quickfix.Message) {
+	header, body, trailer := QuickFixMessageFields(message, app.TransportDataDictionary, app.AppDataDictionary)
+
+	var parts []string
+	for _, fields := range [][]*QuickFixField{header, body, trailer} {
+		parts = appendQuickFixFieldsAnnotated(parts, fields)
+	}
+
+	fmt.Fprintf(w, "%s\n", strings.Join(parts, "|"))
+}
+
+func appendQuickFixFieldsAnnotated(parts []string, fields []*QuickFixField) []string {
+	for _, field := range fields {
+		part := strconv.Itoa(field.Tag)
+		if len(field.Name) > 0 {
+			part += fmt.Sprintf("(%s)", field.Name)
+		}
+		part += "=" + field.Value
+		if len(field.Description) > 0 {
+			part += fmt.Sprintf("(%s)", field.Description)
+		}
+		parts = append(parts, part)
+
+		for _, group := range field.Groups {
+			parts = appendQuickFixFieldsAnnotated(parts, group)
+		}
+	}
+
+	return parts
+}
+
 func (app *QuickFixAppMessageLogger) WriteMessageBodyAsTable(w io.Writer, message *quickfix.Message) {
+	table := newQuickFixMessageTable(w)
+
+	fields := QuickFixMessageBodyFields(message, app.TransportDataDictionary, app.AppDataDictionary)
+	appendQuickFixFieldsToTable(table, fields, 0, false)
+
+	table.Render()
+}
+
+// WriteMessageAsTable writes the header, the body and the trailer of the
+// message as a table.
+func (app *QuickFixAppMessageLogger) WriteMessageAsTable(w io.Writer, message *quickfix.Message) {
+	table := newQuickFixMessageTable(w)
+
+	header, body, trailer := QuickFixMessageFields(message, app.TransportDataDictionary, app.AppDataDictionary)
+	for _, fields := range [][]*QuickFixField{header, body, trailer} {
+		appendQuickFixFieldsToTable(table, fields, 0, false)
+	}
+
+	table.Render()
+}
+
+func newQuickFixMessageTable(w io.Writer) *tablewriter.Table {
 	table := tablewriter.NewWriter(w)
 	table.SetHeader([]string{"TAG", "DESCRIPTION", "VALUES"})
-	table.SetBorders(tablewriter.Border{false, false, false, true})
+	table.SetBorders(tablewriter.Border{Left: false, Top: false, Right: false, Bottom: true})
 	table.SetColumnSeparator(" ")
 	table.SetCenterSeparator("-")
 	table.SetColumnAlignment([]int{tablewriter.ALIGN_RIGHT, tablewriter.ALIGN_LEFT, tablewriter.ALIGN_LEFT})
 	table.SetColWidth(42)
 
-	var line []string
-
-	fields := strings.Split(message.String(), "\001")
+	return table
+}
 
-	for _, field := range fields[:len(fields)-2] {
-		eqIdx := strings.Index(field, "=")
-		if eqIdx == -1 {
-			fmt.Fprintf(os.Stderr, "Misformed field: %s\n", field)
-			continue
+// appendQuickFixFieldsToTable appends the fields to the table, the fields of
+// the repeating group instances being indented under their count field and
+// the first row of each instance being marked with a dash. The fields of the
+// component blocks are indented under a row naming the component.
+func appendQuickFixFieldsToTable(table *tablewriter.Table, fields []*QuickFixField, depth int, instance bool) {
+	first := instance
+	indent := func(depth int) string {
+		if first {
+			first = false
+			return strings.Repeat("  ", depth-1) + "- "
 		}
-		fieldTag := field[:eqIdx]
-		tag, err := strconv.Atoi(fieldTag)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Bad tag value in field: %s\n", field)
-			continue
+		return strings.Repeat("  ", depth)
+	}
+
+	var components []string
+	for _, field := range fields {
+		common := 0
+		for common < len(components) && common < len(field.Components) && components[common] == field.Components[common] {
+			common++
 		}
-		if tagFilter.Contains(tag) {
-			continue
+		for i := common; i < len(field.Components); i++ {
+			table.Append([]string{"", indent(depth+i) + field.Components[i], ""})
 		}
+		components = field.Components
 
-		value := field[eqIdx+1:]
-
-		if app.TransportDataDictionary != nil {
-			if _, found := app.TransportDataDictionary.Header.Fields[tag]; found {
-				continue
-			}
+		description := field.Name
+		if len(description) == 0 {
+			description = "<unknown>"
 		}
 
-		var tagDescription = "<unknown>"
-		if app.AppDataDictionary != nil {
-			if _, found := app.AppDataDictionary.Trailer.Fields[tag]; found {
-				continue
-			}
-			tagField, tok := app.AppDataDictionary.FieldTypeByTag[tag]
-			if tok {
-				tagDescription = tagField.Name()
-			}
-			if len(tagField.Enums) > 0 {
-				if en, ok := tagField.Enums[value]; ok {
-					value += fmt.Sprintf(" (%s)", en.Description)
-				}
-			}
+		value, _ := FormatQuickFixTime(field.Type, field.Value)
+		if len(field.Description) > 0 {
+			value += fmt.Sprintf(" (%s)", field.Description)
 		}
 
-		line = []string{
-			fieldTag,
-			tagDescription,
+		table.Append([]string{
+			strconv.Itoa(field.Tag),
+			indent(depth+len(components)) + description,
 			value,
-		}
+		})
 
-		table.Append(line)
+		for _, group := range field.Groups {
+			appendQuickFixFieldsToTable(table, group, depth+len(components)+1, true)
+		}
 	}
-
-	table.Render()
 }
 
 func MapSearch[K comparable, V comparable](m map[K]V, search V) *K {
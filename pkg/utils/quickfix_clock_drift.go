@@ -0,0 +1,140 @@
+package utils
+
+import (
+	"bytes"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/quickfixgo/quickfix"
+	"github.com/rs/zerolog"
+)
+
+// DefaultMaxClockDrift is the clock drift above which a warning is logged when
+// the session has no MaxClockDrift setting.
+const DefaultMaxClockDrift = time.Second
+
+// ClockDriftLogger is the logger warned when the clock drift of a session
+// exceeds its maximum.
+var ClockDriftLogger *zerolog.Logger
+
+var (
+	metricSessionClockDrift = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "fix",
+			Subsystem: "session",
+			Name:      "clock_drift_seconds",
+			Help:      "Difference between the local time and the SendingTime of the last message received",
+		},
+		[]string{"session"},
+	)
+	metricSessionClockDriftExceeded = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "fix",
+			Subsystem: "session",
+			Name:      "clock_drift_exceeded_total",
+			Help:      "Number of messages received with a clock drift above the maximum of the session",
+		},
+		[]string{"session"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(metricSessionClockDrift, metricSessionClockDriftExceeded)
+}
+
+var sendingTimePrefix = []byte("\00152=")
+
+// quickFixClockDrift measures the difference between the local clock and the
+// SendingTime of the messages received on a session. Transit time is part of
+// it, a positive drift meaning the counterparty clock is behind.
+type quickFixClockDrift struct {
+	session  string
+	max      time.Duration
+	exceeded bool
+	mux      sync.Mutex
+}
+
+func newQuickFixClockDrift(sessionID quickfix.SessionID, settings *quickfix.SessionSettings) (*quickFixClockDrift, error) {
+	max, err := maxClockDrift(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	return &quickFixClockDrift{session: sessionID.String(), max: max}, nil
+}
+
+// maxClockDrift returns the MaxClockDrift setting of the session, the default
+// one if it has none.
+func maxClockDrift(settings *quickfix.SessionSettings) (time.Duration, error) {
+	if settings == nil || !settings.HasSetting("MaxClockDrift") {
+		return DefaultMaxClockDrift, nil
+	}
+
+	value, err := settings.Setting("MaxClockDrift")
+	if err != nil {
+		return 0, err
+	}
+
+	return time.ParseDuration(value)
+}
+
+// setMax changes the maximum of the clock drift.
+func (d *quickFixClockDrift) setMax(max time.Duration) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	d.max = max
+}
+
+func (d *quickFixClockDrift) observe(s []byte) {
+	if d == nil {
+		return
+	}
+
+	received := time.Now()
+
+	i := bytes.Index(s, sendingTimePrefix)
+	if i < 0 {
+		return
+	}
+	value := s[i+len(sendingTimePrefix):]
+	if j := bytes.IndexByte(value, '\001'); j >= 0 {
+		value = value[:j]
+	}
+
+	var sendingTime quickfix.FIXUTCTimestamp
+	if err := sendingTime.Read(value); err != nil {
+		return
+	}
+
+	drift := received.Sub(sendingTime.Time)
+	metricSessionClockDrift.WithLabelValues(d.session).Set(drift.Seconds())
+
+	abs := drift
+	if abs < 0 {
+		abs = -abs
+	}
+
+	direction := "behind"
+	if drift < 0 {
+		direction = "ahead"
+	}
+
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	// Only warn when the drift crosses the maximum not to flood the logs
+	if abs > d.max {
+		metricSessionClockDriftExceeded.WithLabelValues(d.session).Inc()
+		if !d.exceeded && ClockDriftLogger != nil {
+			ClockDriftLogger.Warn().Msgf("%s: counterparty clock is %s %s, above the %s maximum, messages may be rejected", d.session, FormatLatency(abs), direction, FormatLatency(d.max))
+		}
+		d.exceeded = true
+	} else if d.exceeded {
+		if ClockDriftLogger != nil {
+			ClockDriftLogger.Info().Msgf("%s: counterparty clock is %s %s, back under the %s maximum", d.session, FormatLatency(abs), direction, FormatLatency(d.max))
+		}
+		d.exceeded = false
+	}
+}
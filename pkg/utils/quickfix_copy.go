@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/quickfix/datadictionary"
+)
+
+// QuickFixMessageCopy returns a copy of the message that can be modified and
+// sent again. Message.CopyInto only copies the first field of the repeating
+// groups, they are copied whole when the application data dictionary defines
+// them for the message type, their fields being removed from the top level of
+// the body where parsed messages also hold them.
+func QuickFixMessageCopy(message *quickfix.Message, appDict *datadictionary.DataDictionary) *quickfix.Message {
+	result := quickfix.NewMessage()
+	message.CopyInto(result)
+
+	if appDict == nil {
+		return result
+	}
+
+	msgType, _ := message.MsgType()
+	msgDef, ok := appDict.Messages[msgType]
+	if !ok {
+		return result
+	}
+
+	for _, t := range message.Body.Tags() {
+		def, ok := msgDef.Fields[int(t)]
+		if !ok || !def.IsGroup() {
+			continue
+		}
+
+		tags := make(map[quickfix.Tag]bool)
+		group, err := copyQuickFixGroup(&message.Body.FieldMap, def, tags)
+		if err != nil {
+			continue
+		}
+
+		for gt := range tags {
+			result.Body.Remove(gt)
+		}
+		result.Body.SetGroup(group)
+	}
+
+	return result
+}
+
+// copyQuickFixGroup reads the group from the field map and copies its
+// instances, those read holding all the fields following them, collecting
+// the tags of their fields.
+func copyQuickFixGroup(fieldMap *quickfix.FieldMap, def *datadictionary.FieldDef, tags map[quickfix.Tag]bool) (*quickfix.RepeatingGroup, error) {
+	t := quickfix.Tag(def.Tag())
+
+	group := quickfix.NewRepeatingGroup(t, QuickFixGroupTemplate(def))
+	if err := fieldMap.GetGroup(group); err != nil {
+		return nil, err
+	}
+
+	result := quickfix.NewRepeatingGroup(t, QuickFixGroupTemplate(def))
+	for i := 0; i < group.Len(); i++ {
+		instance := group.Get(i)
+		copied := result.Add()
+		instance.CopyInto(&copied.FieldMap)
+
+		for _, it := range instance.Tags() {
+			tags[it] = true
+		}
+
+		for _, child := range def.Fields {
+			if !child.IsGroup() || !instance.Has(quickfix.Tag(child.Tag())) {
+				continue
+			}
+
+			nested, err := copyQuickFixGroup(&instance.FieldMap, child, tags)
+			if err != nil {
+				return nil, err
+			}
+			copied.SetGroup(nested)
+		}
+	}
+
+	return result, nil
+}
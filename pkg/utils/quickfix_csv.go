@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"encoding/csv"
+	"strconv"
+
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/quickfix/datadictionary"
+)
+
+// QuickFixCSVHeader is the header of the records written by
+// QuickFixMessageCSVRecords.
+var QuickFixCSVHeader = []string{"msgSeqNum", "msgType", "tag", "name", "value", "description"}
+
+// QuickFixMessageCSVRecords returns one record per field of the message body,
+// fields of repeating groups following their count field.
+func QuickFixMessageCSVRecords(message *quickfix.Message, transportDict, appDict *datadictionary.DataDictionary) [][]string {
+	seqNum, _ := message.Header.GetString(34)
+	msgType, _ := message.MsgType()
+
+	var records [][]string
+	var walk func(fields []*QuickFixField)
+	walk = func(fields []*QuickFixField) {
+		for _, field := range fields {
+			records = append(records, []string{seqNum, msgType, strconv.Itoa(field.Tag), field.Name, field.Value, field.Description})
+			for _, group := range field.Groups {
+				walk(group)
+			}
+		}
+	}
+
+	walk(QuickFixMessageBodyFields(message, transportDict, appDict))
+
+	return records
+}
+
+func writeQuickFixCSV(w *csv.Writer, header bool, records [][]string) error {
+	if header {
+		if err := w.Write(QuickFixCSVHeader); err != nil {
+			return err
+		}
+	}
+
+	if err := w.WriteAll(records); err != nil {
+		return err
+	}
+
+	return w.Error()
+}
@@ -0,0 +1,257 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/quickfix/datadictionary"
+
+	"sylr.dev/fix/pkg/errors"
+
+	yaml "sylr.dev/yaml/v3"
+)
+
+// QuickFixMessagesFromYAML builds messages out of their structured description,
+// which follows the FIX JSON encoding: Header, Body and Trailer mappings keyed
+// by field names or tag numbers, repeating groups being sequences of mappings.
+// The content can either be YAML, possibly holding several documents, or JSON
+// with one message per line as written by `-o json`. The MsgType can be given
+// by value or by message name.
+func QuickFixMessagesFromYAML(content []byte, transportDict, appDict *datadictionary.DataDictionary) ([]*quickfix.Message, error) {
+	var docs [][]byte
+
+	if trimmed := bytes.TrimSpace(content); len(trimmed) > 0 && trimmed[0] == '{' {
+		scanner := bufio.NewScanner(bytes.NewReader(content))
+		scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+		for scanner.Scan() {
+			if line := bytes.TrimSpace(scanner.Bytes()); len(line) > 0 {
+				docs = append(docs, append([]byte{}, line...))
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	} else {
+		docs = append(docs, content)
+	}
+
+	var messages []*quickfix.Message
+
+	for _, doc := range docs {
+		decoder := yaml.NewDecoder(bytes.NewReader(doc))
+
+		for {
+			var node yaml.Node
+			err := decoder.Decode(&node)
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				return nil, err
+			}
+
+			message, err := quickFixMessageFromNode(&node, transportDict, appDict)
+			if err != nil {
+				return nil, err
+			}
+			messages = append(messages, message)
+		}
+	}
+
+	return messages, nil
+}
+
+func quickFixMessageFromNode(node *yaml.Node, transportDict, appDict *datadictionary.DataDictionary) (*quickfix.Message, error) {
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	if node.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%w: line %d: message must be a mapping", errors.FixMessageParse, node.Line)
+	}
+
+	e := quickFixEncoder{dicts: []*datadictionary.DataDictionary{appDict, transportDict}}
+	message := quickfix.NewMessage()
+
+	parts := map[string]*yaml.Node{}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		switch key := node.Content[i].Value; key {
+		case "Header", "Body", "Trailer":
+			parts[key] = node.Content[i+1]
+		default:
+			return nil, fmt.Errorf("%w: line %d: unknown message part %q", errors.FixMessageParse, node.Content[i].Line, key)
+		}
+	}
+
+	var headerDefs, trailerDefs, bodyDefs map[int]*datadictionary.FieldDef
+	if transportDict != nil {
+		if transportDict.Header != nil {
+			headerDefs = transportDict.Header.Fields
+		}
+		if transportDict.Trailer != nil {
+			trailerDefs = transportDict.Trailer.Fields
+		}
+	}
+
+	if header, ok := parts["Header"]; ok {
+		if err := e.fill(&message.Header.FieldMap, header, headerDefs); err != nil {
+			return nil, err
+		}
+	}
+
+	msgType, err := message.MsgType()
+	if err != nil {
+		return nil, fmt.Errorf("%w: no MsgType in header", errors.FixMessageParse)
+	}
+
+	if appDict != nil {
+		msgDef, ok := appDict.Messages[msgType]
+		if !ok {
+			// MsgType given by message name
+			for _, def := range appDict.Messages {
+				if def.Name == msgType {
+					msgDef, ok = def, true
+					message.Header.SetString(35, def.MsgType)
+					break
+				}
+			}
+		}
+		if ok {
+			bodyDefs = msgDef.Fields
+		}
+	}
+
+	if body, ok := parts["Body"]; ok {
+		if err := e.fill(&message.Body.FieldMap, body, bodyDefs); err != nil {
+			return nil, err
+		}
+	}
+
+	if trailer, ok := parts["Trailer"]; ok {
+		if err := e.fill(&message.Trailer.FieldMap, trailer, trailerDefs); err != nil {
+			return nil, err
+		}
+	}
+
+	return message, nil
+}
+
+type quickFixEncoder struct {
+	dicts []*datadictionary.DataDictionary
+}
+
+// fill sets the fields of the mapping node into the field map.
+func (e quickFixEncoder) fill(fieldMap *quickfix.FieldMap, node *yaml.Node, defs map[int]*datadictionary.FieldDef) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("%w: line %d: expected a mapping", errors.FixMessageParse, node.Line)
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+
+		tag, err := e.tag(key)
+		if err != nil {
+			return err
+		}
+
+		switch value.Kind {
+		case yaml.ScalarNode:
+			// BodyLength and CheckSum are computed when the message is built.
+			if tag == 9 || tag == 10 {
+				continue
+			}
+			fieldMap.SetString(quickfix.Tag(tag), value.Value)
+
+		case yaml.SequenceNode:
+			group, err := e.group(tag, value, defs)
+			if err != nil {
+				return err
+			}
+			fieldMap.SetGroup(group)
+
+		default:
+			return fmt.Errorf("%w: line %d: unexpected value for %q", errors.FixMessageParse, value.Line, key.Value)
+		}
+	}
+
+	return nil
+}
+
+// group builds a repeating group, its template coming from the data
+// dictionary or, when unknown, from the order of the instances fields.
+func (e quickFixEncoder) group(tag int, node *yaml.Node, defs map[int]*datadictionary.FieldDef) (*quickfix.RepeatingGroup, error) {
+	var template quickfix.GroupTemplate
+	var childDefs map[int]*datadictionary.FieldDef
+
+	if def, ok := defs[tag]; ok && def.IsGroup() {
+		template = QuickFixGroupTemplate(def)
+		childDefs = make(map[int]*datadictionary.FieldDef, len(def.Fields))
+		for _, child := range def.Fields {
+			childDefs[child.Tag()] = child
+		}
+	} else {
+		seen := map[int]bool{}
+		for _, instance := range node.Content {
+			for i := 0; i+1 < len(instance.Content); i += 2 {
+				child, err := e.tag(instance.Content[i])
+				if err != nil {
+					return nil, err
+				}
+				if seen[child] {
+					continue
+				}
+				seen[child] = true
+				if instance.Content[i+1].Kind == yaml.SequenceNode {
+					template = append(template, quickfix.NewRepeatingGroup(quickfix.Tag(child), nil))
+				} else {
+					template = append(template, quickfix.GroupElement(quickfix.Tag(child)))
+				}
+			}
+		}
+	}
+
+	group := quickfix.NewRepeatingGroup(quickfix.Tag(tag), template)
+	for _, instance := range node.Content {
+		if err := e.fill(&group.Add().FieldMap, instance, childDefs); err != nil {
+			return nil, err
+		}
+	}
+
+	return group, nil
+}
+
+// QuickFixGroupTemplate returns the template of the repeating group defined
+// in the data dictionary.
+func QuickFixGroupTemplate(def *datadictionary.FieldDef) quickfix.GroupTemplate {
+	template := make(quickfix.GroupTemplate, 0, len(def.Fields))
+
+	for _, child := range def.Fields {
+		if child.IsGroup() {
+			template = append(template, quickfix.NewRepeatingGroup(quickfix.Tag(child.Tag()), QuickFixGroupTemplate(child)))
+		} else {
+			template = append(template, quickfix.GroupElement(quickfix.Tag(child.Tag())))
+		}
+	}
+
+	return template
+}
+
+// tag returns the tag of a field given by number or by name.
+func (e quickFixEncoder) tag(key *yaml.Node) (int, error) {
+	if tag, err := strconv.Atoi(key.Value); err == nil {
+		return tag, nil
+	}
+
+	for _, dict := range e.dicts {
+		if dict == nil {
+			continue
+		}
+		if fieldType, ok := dict.FieldTypeByName[key.Value]; ok {
+			return fieldType.Tag(), nil
+		}
+	}
+
+	return 0, fmt.Errorf("%w: line %d: unknown field %q", errors.FixMessageParse, key.Line, key.Value)
+}
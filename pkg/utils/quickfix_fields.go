@@ -0,0 +1,231 @@
+package utils
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/go-set"
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/quickfix/datadictionary"
+)
+
+// QuickFixField is a field of a FIX message. Repeating group count fields hold
+// the fields of each group instance. Components are the names of the component
+// blocks the field belongs to within its message or group instance, outermost
+// first.
+type QuickFixField struct {
+	Tag         int                `json:"tag"`
+	Name        string             `json:"name,omitempty"`
+	Value       string             `json:"value"`
+	Type        string             `json:"type,omitempty"`
+	Description string             `json:"description,omitempty"`
+	Components  []string           `json:"components,omitempty"`
+	Groups      [][]*QuickFixField `json:"groups,omitempty"`
+}
+
+// IsGroup tells whether the field is the count field of a repeating group.
+func (f QuickFixField) IsGroup() bool {
+	return f.Groups != nil
+}
+
+type quickFixTagValue struct {
+	tag   int
+	value string
+}
+
+// quickFixMessageFields splits a raw message into its header, body and
+// trailer fields, in the order they appear on the wire.
+func quickFixMessageFields(message *quickfix.Message) (header, body, trailer []quickFixTagValue) {
+	var tvs []quickFixTagValue
+
+	for _, field := range strings.Split(message.String(), "\001") {
+		if len(field) == 0 {
+			continue
+		}
+		eqIdx := strings.Index(field, "=")
+		if eqIdx == -1 {
+			continue
+		}
+		tag, err := strconv.Atoi(field[:eqIdx])
+		if err != nil {
+			continue
+		}
+		tvs = append(tvs, quickFixTagValue{tag: tag, value: field[eqIdx+1:]})
+	}
+
+	start := 0
+	for start < len(tvs) && message.Header.Has(quickfix.Tag(tvs[start].tag)) {
+		start++
+	}
+
+	end := len(tvs)
+	for end > start && message.Trailer.Has(quickfix.Tag(tvs[end-1].tag)) {
+		end--
+	}
+
+	return tvs[:start], tvs[start:end], tvs[end:]
+}
+
+// QuickFixMessageFields returns the header, body and trailer fields of the
+// message. The repeating groups defined in the data dictionaries are nested
+// under their count field. Without dictionary all fields are returned flat.
+func QuickFixMessageFields(message *quickfix.Message, transportDict, appDict *datadictionary.DataDictionary) (header, body, trailer []*QuickFixField) {
+	headerTVs, bodyTVs, trailerTVs := quickFixMessageFields(message)
+	dicts := []*datadictionary.DataDictionary{appDict, transportDict}
+
+	var headerDef, bodyDef, trailerDef *datadictionary.MessageDef
+	if transportDict != nil {
+		headerDef, trailerDef = transportDict.Header, transportDict.Trailer
+	}
+	if appDict != nil {
+		if msgType, err := message.MsgType(); err == nil {
+			bodyDef = appDict.Messages[msgType]
+		}
+	}
+
+	header = parseQuickFixFields(headerTVs, headerDef, dicts)
+	body = parseQuickFixFields(bodyTVs, bodyDef, dicts)
+	trailer = parseQuickFixFields(trailerTVs, trailerDef, dicts)
+
+	return header, body, trailer
+}
+
+// QuickFixMessageBodyFields returns the body fields of the message. The
+// repeating groups defined in the application data dictionary are nested
+// under their count field. Without dictionary all fields are returned flat.
+func QuickFixMessageBodyFields(message *quickfix.Message, transportDict, appDict *datadictionary.DataDictionary) []*QuickFixField {
+	_, body, _ := QuickFixMessageFields(message, transportDict, appDict)
+
+	return body
+}
+
+func parseQuickFixFields(tvs []quickFixTagValue, def *datadictionary.MessageDef, dicts []*datadictionary.DataDictionary) []*QuickFixField {
+	p := quickFixFieldParser{tvs: tvs, dicts: dicts}
+
+	var defs map[int]*datadictionary.FieldDef
+	var components map[int][]string
+	if def != nil {
+		defs = def.Fields
+		components = quickFixComponents(def.Parts, nil, nil)
+	}
+
+	var fields []*QuickFixField
+	for p.pos < len(p.tvs) {
+		fields = append(fields, p.field(defs, components))
+	}
+
+	return fields
+}
+
+// quickFixComponents maps the tags of the parts, repeating groups included
+// but not their fields, to the component blocks they belong to. Components
+// made of a single repeating group are left out, the group being enough to
+// tell them apart.
+func quickFixComponents(parts []datadictionary.MessagePart, path []string, components map[int][]string) map[int][]string {
+	if components == nil {
+		components = make(map[int][]string)
+	}
+
+	for _, part := range parts {
+		switch p := part.(type) {
+		case *datadictionary.FieldDef:
+			if len(path) > 0 {
+				components[p.Tag()] = path
+			}
+
+		case datadictionary.Component:
+			if cparts := p.Parts(); len(cparts) == 1 {
+				if group, ok := cparts[0].(*datadictionary.FieldDef); ok && group.IsGroup() {
+					quickFixComponents(cparts, path, components)
+					continue
+				}
+			}
+
+			// Full slice expression so that sibling components don't share
+			// the backing array of their path
+			quickFixComponents(p.Parts(), append(path[:len(path):len(path)], p.Name()), components)
+		}
+	}
+
+	return components
+}
+
+type quickFixFieldParser struct {
+	tvs   []quickFixTagValue
+	pos   int
+	dicts []*datadictionary.DataDictionary
+}
+
+// field consumes the next field and, if it is a repeating group count field,
+// the fields of the group instances.
+func (p *quickFixFieldParser) field(defs map[int]*datadictionary.FieldDef, components map[int][]string) *QuickFixField {
+	tv := p.tvs[p.pos]
+	p.pos++
+
+	field := p.describe(tv)
+	field.Components = components[tv.tag]
+
+	def, ok := defs[tv.tag]
+	if !ok || !def.IsGroup() {
+		return field
+	}
+
+	count, err := strconv.Atoi(tv.value)
+	if err != nil {
+		return field
+	}
+
+	childDefs := make(map[int]*datadictionary.FieldDef, len(def.Fields))
+	for _, child := range def.Fields {
+		childDefs[child.Tag()] = child
+	}
+	childTags := set.From[int](keys(childDefs))
+	childComponents := quickFixComponents(def.Parts, nil, nil)
+	delimiter := def.Fields[0].Tag()
+
+	field.Groups = make([][]*QuickFixField, 0, count)
+	for i := 0; i < count && p.pos < len(p.tvs) && p.tvs[p.pos].tag == delimiter; i++ {
+		var instance []*QuickFixField
+		instance = append(instance, p.field(childDefs, childComponents))
+
+		for p.pos < len(p.tvs) && p.tvs[p.pos].tag != delimiter && childTags.Contains(p.tvs[p.pos].tag) {
+			instance = append(instance, p.field(childDefs, childComponents))
+		}
+
+		field.Groups = append(field.Groups, instance)
+	}
+
+	return field
+}
+
+func (p *quickFixFieldParser) describe(tv quickFixTagValue) *QuickFixField {
+	field := &QuickFixField{Tag: tv.tag, Value: tv.value}
+
+	for _, dict := range p.dicts {
+		if dict == nil {
+			continue
+		}
+		fieldType, ok := dict.FieldTypeByTag[tv.tag]
+		if !ok {
+			continue
+		}
+
+		field.Name = fieldType.Name()
+		field.Type = fieldType.Type
+		if en, ok := fieldType.Enums[tv.value]; ok {
+			field.Description = en.Description
+		}
+		break
+	}
+
+	return field
+}
+
+func keys[K comparable, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	return keys
+}
@@ -0,0 +1,202 @@
+package utils
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/quickfix/datadictionary"
+
+	"sylr.dev/fix/pkg/dict"
+)
+
+type fixmlElement struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Children []*fixmlElement
+}
+
+func newFIXMLElement(name string) *fixmlElement {
+	return &fixmlElement{XMLName: xml.Name{Local: name}}
+}
+
+func (e *fixmlElement) isEmpty() bool {
+	return len(e.Attrs) == 0 && len(e.Children) == 0
+}
+
+// QuickFixMessageToFIXML encodes the message as FIXML. Messages, components
+// and fields are named after their FIXML abbreviations when known and after
+// their data dictionary names otherwise. Fields which are not part of the
+// message definition are appended to the message element, the ones unknown to
+// the data dictionaries being named Tag<number>.
+func QuickFixMessageToFIXML(message *quickfix.Message, transportDict, appDict *datadictionary.DataDictionary) ([]byte, error) {
+	if appDict == nil {
+		return nil, fmt.Errorf("FIXML encoding requires an application data dictionary")
+	}
+
+	msgType, err := message.MsgType()
+	if err != nil {
+		return nil, err
+	}
+
+	msgDef, ok := appDict.Messages[msgType]
+	if !ok {
+		return nil, fmt.Errorf("unknown message type `%s`", msgType)
+	}
+
+	header, body, _ := QuickFixMessageFields(message, transportDict, appDict)
+	e := fixmlEncoder{dicts: []*datadictionary.DataDictionary{appDict, transportDict}}
+
+	version := fmt.Sprintf("%d.%d", appDict.Major, appDict.Minor)
+	if appDict.ServicePack > 0 {
+		version = fmt.Sprintf("%s SP%d", version, appDict.ServicePack)
+	}
+
+	root := newFIXMLElement("FIXML")
+	root.Attrs = []xml.Attr{
+		{Name: xml.Name{Local: "xmlns"}, Value: "http://www.fixprotocol.org/FIXML-" + strings.ReplaceAll(strings.ReplaceAll(version, ".", "-"), " ", "-")},
+		{Name: xml.Name{Local: "v"}, Value: version},
+	}
+
+	msg := newFIXMLElement(fixmlName(dict.FIXMLMessages, msgDef.Name))
+	root.Children = append(root.Children, msg)
+
+	hdr := newFIXMLElement("Hdr")
+	for _, field := range header {
+		switch field.Tag {
+		case 8, 9, 35: // BeginString, BodyLength, MsgType
+			continue
+		}
+		hdr.Attrs = append(hdr.Attrs, e.attr(dict.FIXMLHeaderFields, field))
+	}
+	if !hdr.isEmpty() {
+		msg.Children = append(msg.Children, hdr)
+	}
+
+	scope := fixmlScope(body)
+	e.fill(msg, msgDef.Parts, scope)
+
+	// Fields not part of the message definition
+	for _, field := range body {
+		if _, ok := scope[field.Tag]; ok {
+			msg.Attrs = append(msg.Attrs, e.attr(dict.FIXMLFields, field))
+		}
+	}
+
+	return xml.MarshalIndent(root, "", "  ")
+}
+
+type fixmlEncoder struct {
+	dicts []*datadictionary.DataDictionary
+}
+
+// fill adds the fields of the scope described by the parts to the element and
+// removes them from the scope.
+func (e fixmlEncoder) fill(elem *fixmlElement, parts []datadictionary.MessagePart, scope map[int]*QuickFixField) {
+	for _, part := range parts {
+		switch p := part.(type) {
+		case *datadictionary.FieldDef:
+			field, ok := scope[p.Tag()]
+			if !ok {
+				continue
+			}
+			delete(scope, p.Tag())
+
+			if !p.IsGroup() {
+				elem.Attrs = append(elem.Attrs, e.attr(dict.FIXMLFields, field))
+				continue
+			}
+
+			name := fixmlName(dict.FIXMLComponents, strings.TrimPrefix(p.Name(), "No"))
+			elem.Children = append(elem.Children, e.instances(name, p, field)...)
+
+		case datadictionary.Component:
+			// Components made of a single repeating group are rendered as one
+			// element per group instance.
+			if cparts := p.Parts(); len(cparts) == 1 {
+				if group, ok := cparts[0].(*datadictionary.FieldDef); ok && group.IsGroup() {
+					if field, ok := scope[group.Tag()]; ok {
+						delete(scope, group.Tag())
+						elem.Children = append(elem.Children, e.instances(fixmlName(dict.FIXMLComponents, p.Name()), group, field)...)
+					}
+					continue
+				}
+			}
+
+			child := newFIXMLElement(fixmlName(dict.FIXMLComponents, p.Name()))
+			e.fill(child, p.Parts(), scope)
+			if !child.isEmpty() {
+				elem.Children = append(elem.Children, child)
+			}
+		}
+	}
+}
+
+func (e fixmlEncoder) instances(name string, group *datadictionary.FieldDef, field *QuickFixField) []*fixmlElement {
+	elems := make([]*fixmlElement, 0, len(field.Groups))
+
+	for _, instance := range field.Groups {
+		elem := newFIXMLElement(name)
+		e.fill(elem, group.Parts, fixmlScope(instance))
+		elems = append(elems, elem)
+	}
+
+	return elems
+}
+
+func (e fixmlEncoder) attr(names map[string]string, field *QuickFixField) xml.Attr {
+	name := "Tag" + strconv.Itoa(field.Tag)
+	if len(field.Name) > 0 {
+		name = fixmlName(names, field.Name)
+	}
+
+	return xml.Attr{Name: xml.Name{Local: name}, Value: e.value(field)}
+}
+
+// value converts the FIX date and time values to their XML Schema format.
+func (e fixmlEncoder) value(field *QuickFixField) string {
+	for _, d := range e.dicts {
+		if d == nil {
+			continue
+		}
+		fieldType, ok := d.FieldTypeByTag[field.Tag]
+		if !ok {
+			continue
+		}
+
+		v := field.Value
+		switch fieldType.Type {
+		case "UTCTIMESTAMP", "TZTIMESTAMP":
+			// YYYYMMDD-HH:MM:SS[.sss] -> YYYY-MM-DDTHH:MM:SS[.sss]
+			if len(v) >= 17 && v[8] == '-' {
+				return v[0:4] + "-" + v[4:6] + "-" + v[6:8] + "T" + v[9:]
+			}
+		case "UTCDATEONLY", "UTCDATE", "LOCALMKTDATE":
+			if len(v) == 8 {
+				return v[0:4] + "-" + v[4:6] + "-" + v[6:8]
+			}
+		}
+		break
+	}
+
+	return field.Value
+}
+
+func fixmlScope(fields []*QuickFixField) map[int]*QuickFixField {
+	scope := make(map[int]*QuickFixField, len(fields))
+	for _, field := range fields {
+		scope[field.Tag] = field
+	}
+
+	return scope
+}
+
+func fixmlName(names map[string]string, name string) string {
+	if abbr, ok := names[name]; ok {
+		return abbr
+	}
+
+	return name
+}
@@ -0,0 +1,323 @@
+package utils
+
+import (
+	"bytes"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/quickfixgo/quickfix"
+)
+
+var (
+	metricSessionSeqNum = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "fix",
+			Subsystem: "session",
+			Name:      "seqnum",
+			Help:      "MsgSeqNum of the last message received (in) or sent (out)",
+		},
+		[]string{"session", "direction"},
+	)
+	metricSessionExpectedSeqNum = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "fix",
+			Subsystem: "session",
+			Name:      "expected_seqnum",
+			Help:      "MsgSeqNum expected for the next message received (in) or sent (out)",
+		},
+		[]string{"session", "direction"},
+	)
+	metricSessionSeqNumGaps = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "fix",
+			Subsystem: "session",
+			Name:      "seqnum_gaps_total",
+			Help:      "Number of messages received (in) or sent (out) with another MsgSeqNum than the expected one",
+		},
+		[]string{"session", "direction"},
+	)
+	metricSessionHeartbeatInterval = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "fix",
+			Subsystem: "session",
+			Name:      "heartbeat_interval_seconds",
+			Help:      "HeartBtInt of the last Logon exchanged",
+		},
+		[]string{"session"},
+	)
+	metricSessionHeartbeatsMissed = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "fix",
+			Subsystem: "session",
+			Name:      "heartbeats_missed_total",
+			Help:      "Number of times the counterparty stayed silent for more than the heartbeat interval",
+		},
+		[]string{"session"},
+	)
+	metricSessionTestRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "fix",
+			Subsystem: "session",
+			Name:      "test_requests_total",
+			Help:      "Number of TestRequest messages sent",
+		},
+		[]string{"session"},
+	)
+	metricSessionTestRequestRoundTrip = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "fix",
+			Subsystem: "session",
+			Name:      "test_request_round_trip_seconds",
+			Help:      "Time between the last TestRequest sent and the Heartbeat answering it",
+		},
+		[]string{"session"},
+	)
+	metricSessionLastMessageAgeDesc = prometheus.NewDesc(
+		"fix_session_last_message_age_seconds",
+		"Time since the last message received (in) or sent (out)",
+		[]string{"session", "direction"},
+		nil,
+	)
+)
+
+func init() {
+	prometheus.MustRegister(
+		metricSessionSeqNum,
+		metricSessionExpectedSeqNum,
+		metricSessionSeqNumGaps,
+		metricSessionHeartbeatInterval,
+		metricSessionHeartbeatsMissed,
+		metricSessionTestRequests,
+		metricSessionTestRequestRoundTrip,
+		quickFixSessionAgeCollector{},
+	)
+}
+
+// heartbeatTolerance is the part of the heartbeat interval the counterparty
+// may be late by before a heartbeat is considered missed, like quickfix does
+// before sending a TestRequest.
+const heartbeatTolerance = 0.2
+
+var (
+	quickFixSessionHealths    = make(map[string]*quickFixSessionHealth)
+	quickFixSessionHealthsMux sync.Mutex
+)
+
+// quickFixSessionHealth follows the sequence numbers, the heartbeats and the
+// test requests of the messages exchanged on a session.
+type quickFixSessionHealth struct {
+	session      string
+	lastIn       time.Time
+	lastOut      time.Time
+	expectedIn   int
+	expectedOut  int
+	heartBtInt   time.Duration
+	testRequests map[string]time.Time
+	mux          sync.Mutex
+}
+
+func newQuickFixSessionHealth(sessionID quickfix.SessionID) *quickFixSessionHealth {
+	h := &quickFixSessionHealth{
+		session:      sessionID.String(),
+		testRequests: make(map[string]time.Time),
+	}
+
+	quickFixSessionHealthsMux.Lock()
+	quickFixSessionHealths[h.session] = h
+	quickFixSessionHealthsMux.Unlock()
+
+	return h
+}
+
+// quickFixHealthFields holds the fields of a raw message the health of the
+// session depends on.
+type quickFixHealthFields struct {
+	msgType    string
+	seqNum     int
+	possDup    bool
+	reset      bool
+	newSeqNo   int
+	gapFill    bool
+	beginSeqNo int
+	endSeqNo   int
+	heartBtInt int
+	testReqID  string
+}
+
+func parseQuickFixHealthFields(s []byte) quickFixHealthFields {
+	var f quickFixHealthFields
+
+	for len(s) > 0 {
+		field := s
+		if i := bytes.IndexByte(s, '\001'); i >= 0 {
+			field, s = s[:i], s[i+1:]
+		} else {
+			s = nil
+		}
+
+		eq := bytes.IndexByte(field, '=')
+		if eq < 0 {
+			continue
+		}
+		value := string(field[eq+1:])
+
+		switch string(field[:eq]) {
+		case "35":
+			f.msgType = value
+		case "34":
+			f.seqNum, _ = strconv.Atoi(value)
+		case "43":
+			f.possDup = value == "Y"
+		case "141":
+			f.reset = value == "Y"
+		case "36":
+			f.newSeqNo, _ = strconv.Atoi(value)
+		case "123":
+			f.gapFill = value == "Y"
+		case "7":
+			f.beginSeqNo, _ = strconv.Atoi(value)
+		case "16":
+			f.endSeqNo, _ = strconv.Atoi(value)
+		case "108":
+			f.heartBtInt, _ = strconv.Atoi(value)
+		case "112":
+			f.testReqID = value
+		}
+	}
+
+	return f
+}
+
+// sequence checks the MsgSeqNum of a message against the expected one and
+// returns the next expected one.
+func (h *quickFixSessionHealth) sequence(direction string, f quickFixHealthFields, expected int) int {
+	if f.seqNum == 0 {
+		return expected
+	}
+
+	// Resent messages do not move the sequence forward
+	if f.possDup {
+		return expected
+	}
+
+	if f.msgType == "A" && f.reset {
+		expected = 1
+	}
+
+	metricSessionSeqNum.WithLabelValues(h.session, direction).Set(float64(f.seqNum))
+
+	if expected > 0 && f.seqNum != expected {
+		metricSessionSeqNumGaps.WithLabelValues(h.session, direction).Inc()
+	}
+
+	if f.seqNum >= expected {
+		expected = f.seqNum + 1
+	}
+
+	// SequenceReset
+	if f.msgType == "4" && f.newSeqNo > 0 {
+		expected = f.newSeqNo
+	}
+
+	metricSessionExpectedSeqNum.WithLabelValues(h.session, direction).Set(float64(expected))
+
+	return expected
+}
+
+func (h *quickFixSessionHealth) logon(f quickFixHealthFields) {
+	if f.msgType != "A" || f.heartBtInt <= 0 {
+		return
+	}
+
+	h.heartBtInt = time.Duration(f.heartBtInt) * time.Second
+	metricSessionHeartbeatInterval.WithLabelValues(h.session).Set(float64(f.heartBtInt))
+}
+
+func (h *quickFixSessionHealth) observeIncoming(s []byte) {
+	if h == nil {
+		return
+	}
+
+	now := time.Now()
+	f := parseQuickFixHealthFields(s)
+
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	if !h.lastIn.IsZero() && h.heartBtInt > 0 && f.msgType != "A" {
+		max := h.heartBtInt + time.Duration(float64(h.heartBtInt)*heartbeatTolerance)
+		if now.Sub(h.lastIn) > max {
+			metricSessionHeartbeatsMissed.WithLabelValues(h.session).Inc()
+		}
+	}
+	h.lastIn = now
+
+	h.logon(f)
+	h.expectedIn = h.sequence("in", f, h.expectedIn)
+	h.resend("in", f)
+
+	// Heartbeat answering a TestRequest
+	if f.msgType == "0" && len(f.testReqID) > 0 {
+		if sent, ok := h.testRequests[f.testReqID]; ok {
+			metricSessionTestRequestRoundTrip.WithLabelValues(h.session).Set(now.Sub(sent).Seconds())
+			delete(h.testRequests, f.testReqID)
+		}
+	}
+}
+
+func (h *quickFixSessionHealth) observeOutgoing(s []byte) {
+	if h == nil {
+		return
+	}
+
+	now := time.Now()
+	f := parseQuickFixHealthFields(s)
+
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	h.lastOut = now
+
+	h.logon(f)
+	h.expectedOut = h.sequence("out", f, h.expectedOut)
+	h.resend("out", f)
+
+	if f.msgType == "1" && len(f.testReqID) > 0 {
+		metricSessionTestRequests.WithLabelValues(h.session).Inc()
+
+		// Unanswered test requests end with a disconnection, only keep the
+		// last ones
+		if len(h.testRequests) > 16 {
+			h.testRequests = make(map[string]time.Time)
+		}
+		h.testRequests[f.testReqID] = now
+	}
+}
+
+// quickFixSessionAgeCollector exposes the time since the last messages of
+// the sessions, computed when collected.
+type quickFixSessionAgeCollector struct{}
+
+func (quickFixSessionAgeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- metricSessionLastMessageAgeDesc
+}
+
+func (quickFixSessionAgeCollector) Collect(ch chan<- prometheus.Metric) {
+	quickFixSessionHealthsMux.Lock()
+	defer quickFixSessionHealthsMux.Unlock()
+
+	now := time.Now()
+
+	for _, h := range quickFixSessionHealths {
+		h.mux.Lock()
+		for direction, last := range map[string]time.Time{"in": h.lastIn, "out": h.lastOut} {
+			if last.IsZero() {
+				continue
+			}
+			ch <- prometheus.MustNewConstMetric(metricSessionLastMessageAgeDesc, prometheus.GaugeValue, now.Sub(last).Seconds(), h.session, direction)
+		}
+		h.mux.Unlock()
+	}
+}
@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/quickfix/datadictionary"
+)
+
+// quickFixJSONSkippedTags are the session level tags which are not carried by
+// the FIX JSON encoding as they only make sense for the tag=value one.
+var quickFixJSONSkippedTags = map[int]bool{
+	9:  true, // BodyLength
+	10: true, // CheckSum
+}
+
+// quickFixJSONObject encodes fields as a JSON object keyed by field names
+// while preserving the order in which they appear in the message.
+type quickFixJSONObject []*QuickFixField
+
+func (o quickFixJSONObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+
+	buf.WriteByte('{')
+	first := true
+	for _, field := range o {
+		if quickFixJSONSkippedTags[field.Tag] {
+			continue
+		}
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+
+		name := field.Name
+		if len(name) == 0 {
+			name = strconv.Itoa(field.Tag)
+		}
+
+		key, err := json.Marshal(name)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+
+		var value []byte
+		if field.IsGroup() {
+			instances := make([]quickFixJSONObject, 0, len(field.Groups))
+			for _, group := range field.Groups {
+				instances = append(instances, quickFixJSONObject(group))
+			}
+			value, err = json.Marshal(instances)
+		} else {
+			value, err = json.Marshal(field.Value)
+		}
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+// QuickFixMessageToJSON encodes the message following the FIX Trading
+// Community JSON encoding: fields are keyed by their data dictionary names
+// and repeating groups are arrays of objects keyed by their count field.
+// Fields unknown to the dictionaries are keyed by their tag number.
+func QuickFixMessageToJSON(message *quickfix.Message, transportDict, appDict *datadictionary.DataDictionary) ([]byte, error) {
+	header, body, trailer := QuickFixMessageFields(message, transportDict, appDict)
+
+	return json.Marshal(struct {
+		Header  quickFixJSONObject
+		Body    quickFixJSONObject
+		Trailer quickFixJSONObject
+	}{
+		Header:  header,
+		Body:    body,
+		Trailer: trailer,
+	})
+}
+
+// QuickFixMessageToStructuredJSON encodes the message as the lists of the
+// fields of its header, body and trailer, each with its tag number, raw value
+// and, when known to the dictionaries, its name, type and enum description.
+// Repeating group count fields hold the fields of each of their instances.
+// Unlike QuickFixMessageToJSON, every field of the message is kept.
+func QuickFixMessageToStructuredJSON(message *quickfix.Message, transportDict, appDict *datadictionary.DataDictionary) ([]byte, error) {
+	header, body, trailer := QuickFixMessageFields(message, transportDict, appDict)
+
+	return json.Marshal(struct {
+		Header  []*QuickFixField `json:"header"`
+		Body    []*QuickFixField `json:"body"`
+		Trailer []*QuickFixField `json:"trailer"`
+	}{
+		Header:  header,
+		Body:    body,
+		Trailer: trailer,
+	})
+}
@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/quickfix/datadictionary"
+
+	"sylr.dev/fix/pkg/errors"
+)
+
+// QuickFixLintIssue is an issue found in a raw message.
+type QuickFixLintIssue struct {
+	Tag    int    `json:"tag,omitempty"`
+	Reason string `json:"reason"`
+	Text   string `json:"text"`
+}
+
+// QuickFixLint validates the raw message: its BodyLength and CheckSum and,
+// using the data dictionaries, its required fields, its enum values and the
+// ordering of its fields and repeating groups. As the dictionary validation
+// stops at the first error, at most one issue of that kind is reported.
+func QuickFixLint(raw string, transportDict, appDict *datadictionary.DataDictionary) []QuickFixLintIssue {
+	var issues []QuickFixLintIssue
+
+	lengthStart, lengthEnd, _, _, err := quickFixRawBodyBounds(raw)
+	if err != nil {
+		return append(issues, QuickFixLintIssue{Reason: "Malformed", Text: err.Error()})
+	}
+
+	if sum, err := QuickFixRawCheckSum(raw); err == nil {
+		idx := strings.LastIndex(raw, "\00110=")
+		value := strings.TrimSuffix(raw[idx+4:], "\001")
+		if expected := fmt.Sprintf("%03d", sum); value != expected {
+			issues = append(issues, QuickFixLintIssue{
+				Tag:    10,
+				Reason: "CheckSum",
+				Text:   fmt.Sprintf("CheckSum is %s, expected %s", value, expected),
+			})
+		}
+	}
+
+	if length, err := QuickFixRawBodyLength(raw); err == nil && raw[lengthStart:lengthEnd] != strconv.Itoa(length) {
+		issues = append(issues, QuickFixLintIssue{
+			Tag:    9,
+			Reason: "BodyLength",
+			Text:   fmt.Sprintf("BodyLength is %s, expected %d", raw[lengthStart:lengthEnd], length),
+		})
+		raw, _ = QuickFixRawMessageSetBodyLength(raw)
+	}
+
+	message, err := ParseQuickFixRawMessage(raw, transportDict, appDict)
+	if err != nil {
+		return append(issues, QuickFixLintIssue{Reason: "Malformed", Text: err.Error()})
+	}
+
+	validator := quickfix.NewValidator(quickfix.ValidatorSettings{
+		CheckFieldsOutOfOrder: true,
+		RejectInvalidMessage:  true,
+	}, appDict, transportDict)
+
+	if rej := validator.Validate(message); rej != nil {
+		issue := QuickFixLintIssue{
+			Reason: quickFixRejectReason(rej.RejectReason()),
+			Text:   rej.Error(),
+		}
+		if tag := rej.RefTagID(); tag != nil {
+			issue.Tag = int(*tag)
+		}
+		issues = append(issues, issue)
+	}
+
+	return issues
+}
+
+func quickFixRejectReason(reason int) string {
+	if name, ok := errors.SessionRejectReasons[reason]; ok {
+		return name
+	}
+
+	return strconv.Itoa(reason)
+}
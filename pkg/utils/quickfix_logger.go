@@ -2,26 +2,43 @@ package utils
 
 import (
 	"fmt"
+	"os"
+	"sync"
+	"time"
 
 	"github.com/quickfixgo/quickfix"
 	"github.com/rs/zerolog"
 )
 
 type quickFixLog struct {
-	prefix string
-	logger *zerolog.Logger
+	prefix     string
+	logger     *zerolog.Logger
+	file       *quickFixLogFile
+	messageLog *quickFixMessageLog
+	orders     *quickFixOrderTracker
+	clockDrift *quickFixClockDrift
+	health     *quickFixSessionHealth
 }
 
 func (l quickFixLog) OnIncoming(s []byte) {
 	if l.logger != nil {
 		l.logger.Trace().Msgf("quickfix(%s, incoming): %s", l.prefix, s)
 	}
+	l.file.write("<-", s)
+	l.messageLog.write("in", s)
+	l.orders.record("in", s)
+	l.clockDrift.observe(s)
+	l.health.observeIncoming(s)
 }
 
 func (l quickFixLog) OnOutgoing(s []byte) {
 	if l.logger != nil {
 		l.logger.Trace().Msgf("quickfix(%s, outgoing): %s", l.prefix, s)
 	}
+	l.file.write("->", s)
+	l.messageLog.write("out", s)
+	l.orders.record("out", s)
+	l.health.observeOutgoing(s)
 }
 
 func (l quickFixLog) OnEvent(s string) {
@@ -34,8 +51,147 @@ func (l quickFixLog) OnEventf(format string, a ...interface{}) {
 	l.OnEvent(fmt.Sprintf(format, a...))
 }
 
+// close closes the files the log writes the messages of its session to.
+func (l quickFixLog) close() {
+	l.file.close()
+	if l.messageLog != nil {
+		l.messageLog.file.Close()
+	}
+	l.orders.close()
+}
+
+// quickFixLogFile writes the raw messages of a session into a dedicated file.
+type quickFixLogFile struct {
+	path string
+	file *os.File
+	mux  sync.Mutex
+}
+
+func openQuickFixLogFile(path string) (*quickFixLogFile, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	return &quickFixLogFile{path: path, file: file}, nil
+}
+
+func (f *quickFixLogFile) write(direction string, s []byte) {
+	if f == nil {
+		return
+	}
+
+	f.mux.Lock()
+	defer f.mux.Unlock()
+
+	fmt.Fprintf(f.file, "%s %s %s\n", time.Now().UTC().Format(time.RFC3339Nano), direction, s)
+}
+
+func (f *quickFixLogFile) close() {
+	if f == nil {
+		return
+	}
+
+	f.mux.Lock()
+	defer f.mux.Unlock()
+
+	f.file.Close()
+}
+
+// reopen closes the file and opens its path again, so that the messages go to
+// a new file once the previous one has been moved away.
+func (f *quickFixLogFile) reopen() error {
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+
+	f.mux.Lock()
+	defer f.mux.Unlock()
+
+	f.file.Close()
+	f.file = file
+
+	return nil
+}
+
+var (
+	quickFixSessionLogs    = make(map[string]quickFixLog)
+	quickFixSessionLogsMux sync.Mutex
+
+	logFile *RotatingFile
+)
+
+// SetLogFile sets the file the logs are written to, rotated with the logs of
+// the sessions.
+func SetLogFile(f *RotatingFile) {
+	quickFixSessionLogsMux.Lock()
+	defer quickFixSessionLogsMux.Unlock()
+
+	logFile = f
+}
+
+// RotateQuickFixLogs reopens the LogFile of the sessions, which external tools
+// like logrotate move away, and rotates their MessageLogPath file along with
+// the file given to SetLogFile.
+func RotateQuickFixLogs() error {
+	quickFixSessionLogsMux.Lock()
+	defer quickFixSessionLogsMux.Unlock()
+
+	if logFile != nil {
+		if err := logFile.Rotate(); err != nil {
+			return err
+		}
+	}
+
+	for _, log := range quickFixSessionLogs {
+		if log.file != nil {
+			if err := log.file.reopen(); err != nil {
+				return err
+			}
+		}
+		if log.messageLog != nil {
+			if err := log.messageLog.file.Rotate(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// ReloadQuickFixSettings applies to the sessions created the settings which
+// can change without restarting them: MaxClockDrift and the rotation of the
+// MessageLogPath file.
+func ReloadQuickFixSettings(settings *quickfix.Settings) error {
+	quickFixSessionLogsMux.Lock()
+	defer quickFixSessionLogsMux.Unlock()
+
+	for sessionID, session := range settings.SessionSettings() {
+		log, ok := quickFixSessionLogs[sessionID.String()]
+		if !ok {
+			continue
+		}
+
+		max, err := maxClockDrift(session)
+		if err != nil {
+			return err
+		}
+		log.clockDrift.setMax(max)
+
+		if log.messageLog != nil {
+			if err := configureMessageLogFile(log.messageLog.file, session); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
 type quickfixLogFactory struct {
-	logger *zerolog.Logger
+	logger   *zerolog.Logger
+	settings *quickfix.Settings
 }
 
 func (q quickfixLogFactory) Create() (quickfix.Log, error) {
@@ -45,10 +201,90 @@ func (q quickfixLogFactory) Create() (quickfix.Log, error) {
 
 func (q quickfixLogFactory) CreateSessionLog(sessionID quickfix.SessionID) (quickfix.Log, error) {
 	log := quickFixLog{prefix: sessionID.String(), logger: q.logger}
+
+	var session *quickfix.SessionSettings
+	if q.settings != nil {
+		session = q.settings.SessionSettings()[sessionID]
+	}
+
+	clockDrift, err := newQuickFixClockDrift(sessionID, session)
+	if err != nil {
+		return nil, err
+	}
+	log.clockDrift = clockDrift
+	log.health = newQuickFixSessionHealth(sessionID)
+
+	if session == nil {
+		return log, nil
+	}
+
+	if session.HasSetting("LogFile") {
+		path, err := session.Setting("LogFile")
+		if err != nil {
+			return nil, err
+		}
+
+		if log.file, err = openQuickFixLogFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if session.HasSetting("MessageLogPath") {
+		messageLog, err := newQuickFixMessageLog(sessionID, session)
+		if err != nil {
+			return nil, err
+		}
+
+		log.messageLog = messageLog
+	}
+
+	if session.HasSetting("OrderTrackerPath") {
+		orders, err := newQuickFixOrderTracker(sessionID, session, q.logger)
+		if err != nil {
+			return nil, err
+		}
+
+		log.orders = orders
+	}
+
+	quickFixSessionLogsMux.Lock()
+	// The log of the session created by a previous initiator or acceptor
+	if previous, ok := quickFixSessionLogs[sessionID.String()]; ok {
+		previous.close()
+	}
+	quickFixSessionLogs[sessionID.String()] = log
+	quickFixSessionLogsMux.Unlock()
+
 	return log, nil
 }
 
+// CloseQuickFixSessionLogs closes the files of the logs of the sessions of the
+// settings, once they are stopped.
+func CloseQuickFixSessionLogs(settings *quickfix.Settings) {
+	quickFixSessionLogsMux.Lock()
+	defer quickFixSessionLogsMux.Unlock()
+
+	for sessionID := range settings.SessionSettings() {
+		if log, ok := quickFixSessionLogs[sessionID.String()]; ok {
+			log.close()
+			delete(quickFixSessionLogs, sessionID.String())
+		}
+	}
+}
+
 // NewQuickFixLogFactory creates an instance of LogFactory that writes messages and events to stdout.
 func NewQuickFixLogFactory(logger *zerolog.Logger) quickfix.LogFactory {
 	return quickfixLogFactory{logger: logger}
 }
+
+// NewQuickFixSessionLogFactory creates an instance of LogFactory that writes
+// messages and events to stdout and which also appends the raw messages of the
+// sessions having a LogFile setting to that file and writes them as JSON lines
+// to the MessageLogPath file of the sessions having one. The orders of the
+// sessions having an OrderTrackerPath setting are recorded in that database.
+// The clock drift of the messages received is monitored against the
+// MaxClockDrift of the sessions and the health of the sessions is exposed as
+// metrics.
+func NewQuickFixSessionLogFactory(logger *zerolog.Logger, settings *quickfix.Settings) quickfix.LogFactory {
+	return quickfixLogFactory{logger: logger, settings: settings}
+}
@@ -0,0 +1,130 @@
+package utils
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/quickfixgo/enum"
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/tag"
+	"github.com/rs/zerolog"
+
+	"sylr.dev/fix/config/credentials"
+)
+
+// Session settings of the fields the Logon messages carry, LogonFieldsSetting
+// holding the fields by tag encoded as JSON.
+const (
+	LogonEncryptMethodSetting = "EncryptMethod"
+	LogonFieldsSetting        = "LogonFields"
+)
+
+// BaseApplication is what the initiator applications have in common: it logs
+// their messages and fills the Logon messages of their sessions.
+type BaseApplication struct {
+	QuickFixAppMessageLogger
+
+	Settings *quickfix.Settings
+	// LogoutText is the Text of the Logout messages sent.
+	LogoutText string
+}
+
+// PrepareLogon fills the message, if it is a Logon, with what the settings of
+// the session tell it must carry. It is meant to be called from ToAdmin.
+func (app *BaseApplication) PrepareLogon(message *quickfix.Message, sessionID quickfix.SessionID) {
+	InjectLogonCredentials(message, app.Settings, sessionID, app.Logger)
+	InjectLogonFields(message, app.Settings, sessionID, app.Logger)
+}
+
+// PrepareLogout sets LogoutText, if any, as the Text of the message if it is a
+// Logout. It is meant to be called from ToAdmin.
+func (app *BaseApplication) PrepareLogout(message *quickfix.Message) {
+	if len(app.LogoutText) == 0 {
+		return
+	}
+
+	if typ, err := message.MsgType(); err == nil && enum.MsgType(typ) == enum.MsgType_LOGOUT {
+		message.Body.SetField(tag.Text, quickfix.FIXString(app.LogoutText))
+	}
+}
+
+// InjectLogonCredentials sets the Username and Password of the session in the
+// message if it is a Logon, fetched from the credentials provider of the
+// session if it has one, none being set when the provider failed. Other
+// messages are left untouched.
+func InjectLogonCredentials(message *quickfix.Message, settings *quickfix.Settings, sessionID quickfix.SessionID, logger *zerolog.Logger) {
+	session := logonSessionSettings(message, settings, sessionID)
+	if session == nil {
+		return
+	}
+
+	creds, err := credentials.FromSessionSettings(session)
+	if err != nil {
+		logger.Error().Err(err).Msg("Could not get the logon credentials")
+		return
+	}
+	if len(creds.Username) > 0 {
+		logger.Debug().Msg("Username injected in logon message")
+		message.Header.SetField(tag.Username, quickfix.FIXString(creds.Username))
+	}
+	if len(creds.Password) > 0 {
+		logger.Debug().Msg("Password injected in logon message")
+		message.Header.SetField(tag.Password, quickfix.FIXString(creds.Password))
+	}
+}
+
+// InjectLogonFields sets the EncryptMethod and the custom fields of the
+// session in the body of the message if it is a Logon. Other messages are left
+// untouched.
+func InjectLogonFields(message *quickfix.Message, settings *quickfix.Settings, sessionID quickfix.SessionID, logger *zerolog.Logger) {
+	session := logonSessionSettings(message, settings, sessionID)
+	if session == nil {
+		return
+	}
+
+	if session.HasSetting(LogonEncryptMethodSetting) {
+		if method, err := session.Setting(LogonEncryptMethodSetting); err == nil && len(method) > 0 {
+			message.Body.SetField(tag.EncryptMethod, quickfix.FIXString(method))
+		}
+	}
+
+	if !session.HasSetting(LogonFieldsSetting) {
+		return
+	}
+
+	value, err := session.Setting(LogonFieldsSetting)
+	if err != nil {
+		return
+	}
+
+	var fields map[int]string
+	if err := json.Unmarshal([]byte(value), &fields); err != nil {
+		logger.Error().Err(err).Msgf("Could not read the %s setting", LogonFieldsSetting)
+		return
+	}
+
+	tags := make([]int, 0, len(fields))
+	for t := range fields {
+		tags = append(tags, t)
+	}
+	sort.Ints(tags)
+
+	for _, t := range tags {
+		message.Body.SetField(quickfix.Tag(t), quickfix.FIXString(fields[t]))
+	}
+	logger.Debug().Msgf("Logon fields %v injected in logon message", tags)
+}
+
+// logonSessionSettings returns the settings of the session if the message is
+// a Logon, nil otherwise.
+func logonSessionSettings(message *quickfix.Message, settings *quickfix.Settings, sessionID quickfix.SessionID) *quickfix.SessionSettings {
+	if settings == nil {
+		return nil
+	}
+
+	if typ, err := message.MsgType(); err != nil || enum.MsgType(typ) != enum.MsgType_LOGON {
+		return nil
+	}
+
+	return settings.SessionSettings()[sessionID]
+}
@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/quickfix/datadictionary"
+
+	"sylr.dev/fix/pkg/errors"
+)
+
+// QuickFixFieldMatcher matches the messages holding a field with a given
+// value, in their header, body or trailer.
+type QuickFixFieldMatcher struct {
+	Tag   quickfix.Tag
+	Value string
+}
+
+// ParseQuickFixFieldMatchers parses Field=Value expressions. Fields are given
+// by tag number or by name, values by value or by enum description, and the
+// MsgType also by message name when the dictionaries know them.
+func ParseQuickFixFieldMatchers(exprs []string, transportDict, appDict *datadictionary.DataDictionary) ([]QuickFixFieldMatcher, error) {
+	dicts := []*datadictionary.DataDictionary{appDict, transportDict}
+	matchers := make([]QuickFixFieldMatcher, 0, len(exprs))
+
+	for _, expr := range exprs {
+		field, value, ok := strings.Cut(expr, "=")
+		if !ok || len(field) == 0 {
+			return nil, fmt.Errorf("%w: %s: expected Field=Value", errors.Options, expr)
+		}
+
+		tag, err := strconv.Atoi(field)
+		if err != nil {
+			tag = 0
+			for _, dict := range dicts {
+				if dict == nil {
+					continue
+				}
+				if fieldType, ok := dict.FieldTypeByName[field]; ok {
+					tag = fieldType.Tag()
+					break
+				}
+			}
+			if tag == 0 {
+				return nil, fmt.Errorf("%w: %s: unknown field %q", errors.Options, expr, field)
+			}
+		}
+
+		matchers = append(matchers, QuickFixFieldMatcher{
+			Tag:   quickfix.Tag(tag),
+			Value: quickFixMatcherValue(quickfix.Tag(tag), value, dicts),
+		})
+	}
+
+	return matchers, nil
+}
+
+// quickFixMatcherValue returns the value of the field described by its enum
+// description or, for the MsgType, by the message name.
+func quickFixMatcherValue(tag quickfix.Tag, value string, dicts []*datadictionary.DataDictionary) string {
+	for _, dict := range dicts {
+		if dict == nil {
+			continue
+		}
+
+		if tag == 35 {
+			for _, def := range dict.Messages {
+				if def.Name == value {
+					return def.MsgType
+				}
+			}
+		}
+
+		fieldType, ok := dict.FieldTypeByTag[int(tag)]
+		if !ok {
+			continue
+		}
+		if _, ok := fieldType.Enums[value]; ok {
+			return value
+		}
+		for _, enum := range fieldType.Enums {
+			if strings.EqualFold(enum.Description, value) {
+				return enum.Value
+			}
+		}
+	}
+
+	return value
+}
+
+// Match tells whether the message holds the field with the value.
+func (m QuickFixFieldMatcher) Match(message *quickfix.Message) bool {
+	for _, fieldMap := range []*quickfix.FieldMap{&message.Header.FieldMap, &message.Body.FieldMap, &message.Trailer.FieldMap} {
+		if value, err := fieldMap.GetString(m.Tag); err == nil {
+			return value == m.Value
+		}
+	}
+
+	return false
+}
+
+func (m QuickFixFieldMatcher) String() string {
+	return fmt.Sprintf("%d=%s", m.Tag, m.Value)
+}
+
+// QuickFixMessageMatches tells whether the message is matched by all the
+// matchers.
+func QuickFixMessageMatches(message *quickfix.Message, matchers []QuickFixFieldMatcher) bool {
+	for _, m := range matchers {
+		if !m.Match(message) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,173 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/quickfixgo/quickfix"
+	qconfig "github.com/quickfixgo/quickfix/config"
+	"github.com/quickfixgo/quickfix/datadictionary"
+)
+
+var (
+	messageLogDicts    = make(map[string]*datadictionary.DataDictionary)
+	messageLogDictsMux sync.Mutex
+)
+
+// quickFixMessageLogEntry is the JSON line written for every message.
+type quickFixMessageLogEntry struct {
+	Time        time.Time                  `json:"time"`
+	Session     string                     `json:"session"`
+	Direction   string                     `json:"direction"`
+	MsgType     string                     `json:"msgType,omitempty"`
+	SeqNum      int                        `json:"seqNum,omitempty"`
+	SendingTime string                     `json:"sendingTime,omitempty"`
+	Message     *quickFixMessageLogMessage `json:"message,omitempty"`
+	Raw         string                     `json:"raw"`
+}
+
+type quickFixMessageLogMessage struct {
+	Header  quickFixJSONObject
+	Body    quickFixJSONObject
+	Trailer quickFixJSONObject
+}
+
+// quickFixMessageLog writes the messages of a session as structured JSON lines
+// into a rotating file, independently of the console logging.
+type quickFixMessageLog struct {
+	session       string
+	transportDict *datadictionary.DataDictionary
+	appDict       *datadictionary.DataDictionary
+	mux           sync.Mutex
+	file          *RotatingFile
+	encoder       *json.Encoder
+}
+
+func newQuickFixMessageLog(sessionID quickfix.SessionID, settings *quickfix.SessionSettings) (*quickFixMessageLog, error) {
+	path, err := settings.Setting("MessageLogPath")
+	if err != nil {
+		return nil, err
+	}
+
+	file := &RotatingFile{Path: path}
+	if err := configureMessageLogFile(file, settings); err != nil {
+		return nil, err
+	}
+
+	log := &quickFixMessageLog{
+		session: sessionID.String(),
+		file:    file,
+		encoder: json.NewEncoder(file),
+	}
+
+	// Messages are decoded with the dictionaries of the session when it has some.
+	if log.transportDict, err = messageLogDict(settings, qconfig.TransportDataDictionary); err != nil {
+		return nil, err
+	}
+	if log.appDict, err = messageLogDict(settings, qconfig.AppDataDictionary); err != nil {
+		return nil, err
+	}
+
+	return log, nil
+}
+
+// configureMessageLogFile sets the rotation of the file from the MessageLog
+// settings of the session.
+func configureMessageLogFile(file *RotatingFile, settings *quickfix.SessionSettings) error {
+	var maxSize int64
+	var rotateEvery time.Duration
+	var maxBackups int
+
+	if settings.HasSetting("MessageLogMaxSize") {
+		size, err := settings.IntSetting("MessageLogMaxSize")
+		if err != nil {
+			return err
+		}
+		maxSize = int64(size) * 1024 * 1024
+	}
+
+	if settings.HasSetting("MessageLogRotateEvery") {
+		seconds, err := settings.IntSetting("MessageLogRotateEvery")
+		if err != nil {
+			return err
+		}
+		rotateEvery = time.Duration(seconds) * time.Second
+	}
+
+	if settings.HasSetting("MessageLogMaxBackups") {
+		var err error
+		if maxBackups, err = settings.IntSetting("MessageLogMaxBackups"); err != nil {
+			return err
+		}
+	}
+
+	file.mux.Lock()
+	defer file.mux.Unlock()
+
+	file.MaxSize = maxSize
+	file.RotateEvery = rotateEvery
+	file.MaxBackups = maxBackups
+
+	return nil
+}
+
+func messageLogDict(settings *quickfix.SessionSettings, setting string) (*datadictionary.DataDictionary, error) {
+	if !settings.HasSetting(setting) {
+		return nil, nil
+	}
+
+	path, err := settings.Setting(setting)
+	if err != nil {
+		return nil, err
+	}
+
+	messageLogDictsMux.Lock()
+	defer messageLogDictsMux.Unlock()
+
+	if dict, ok := messageLogDicts[path]; ok {
+		return dict, nil
+	}
+
+	dict, err := datadictionary.Parse(path)
+	if err != nil {
+		return nil, err
+	}
+	messageLogDicts[path] = dict
+
+	return dict, nil
+}
+
+func (l *quickFixMessageLog) write(direction string, s []byte) {
+	if l == nil {
+		return
+	}
+
+	entry := quickFixMessageLogEntry{
+		Time:      time.Now().UTC(),
+		Session:   l.session,
+		Direction: direction,
+		Raw:       strings.ReplaceAll(string(s), "\001", "|"),
+	}
+
+	message := quickfix.NewMessage()
+	if err := quickfix.ParseMessage(message, bytes.NewBuffer(append([]byte{}, s...))); err == nil {
+		entry.MsgType, _ = message.MsgType()
+		entry.SeqNum, _ = message.Header.GetInt(34)
+		entry.SendingTime, _ = message.Header.GetString(52)
+
+		header, body, trailer := QuickFixMessageFields(message, l.transportDict, l.appDict)
+		entry.Message = &quickFixMessageLogMessage{
+			Header:  header,
+			Body:    body,
+			Trailer: trailer,
+		}
+	}
+
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	l.encoder.Encode(entry)
+}
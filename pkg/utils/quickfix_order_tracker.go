@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"github.com/quickfixgo/quickfix"
+	"github.com/rs/zerolog"
+
+	"sylr.dev/fix/pkg/ordertracker"
+)
+
+// quickFixOrderTracker records the orders of a session in the database of its
+// OrderTrackerPath setting.
+type quickFixOrderTracker struct {
+	session string
+	tracker *ordertracker.Tracker
+	logger  *zerolog.Logger
+}
+
+func newQuickFixOrderTracker(sessionID quickfix.SessionID, settings *quickfix.SessionSettings, logger *zerolog.Logger) (*quickFixOrderTracker, error) {
+	path, err := settings.Setting("OrderTrackerPath")
+	if err != nil {
+		return nil, err
+	}
+
+	tracker, err := ordertracker.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &quickFixOrderTracker{session: sessionID.String(), tracker: tracker, logger: logger}, nil
+}
+
+// record records the message, failing to do so never failing the session.
+func (t *quickFixOrderTracker) record(direction string, s []byte) {
+	if t == nil {
+		return
+	}
+
+	if err := t.tracker.Record(t.session, direction, s); err != nil && t.logger != nil {
+		t.logger.Warn().Err(err).Msgf("quickfix(%s): order tracker", t.session)
+	}
+}
+
+func (t *quickFixOrderTracker) close() {
+	if t == nil {
+		return
+	}
+
+	if err := t.tracker.Close(); err != nil && t.logger != nil {
+		t.logger.Warn().Err(err).Msgf("quickfix(%s): order tracker", t.session)
+	}
+}
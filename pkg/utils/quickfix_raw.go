@@ -0,0 +1,127 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/quickfix/datadictionary"
+
+	"sylr.dev/fix/pkg/errors"
+)
+
+// QuickFixRawMessage extracts the raw FIX message contained in a line, which
+// may be prefixed by anything (e.g. log timestamps) and whose fields may be
+// delimited by SOH, pipes or carets. It returns an empty string if the line
+// does not contain any message.
+func QuickFixRawMessage(line string) string {
+	idx := strings.Index(line, "8=FIX")
+	if idx < 0 {
+		return ""
+	}
+
+	raw := strings.TrimRight(line[idx:], " \t\r\n")
+	if !strings.Contains(raw, "\001") {
+		raw = strings.NewReplacer("^A", "\001", "|", "\001").Replace(raw)
+	}
+	if !strings.HasSuffix(raw, "\001") {
+		raw += "\001"
+	}
+
+	return raw
+}
+
+// ReadQuickFixRawMessages reads all the raw FIX messages of the reader, one
+// per line, skipping the lines which do not contain any.
+func ReadQuickFixRawMessages(r io.Reader) ([]string, error) {
+	var messages []string
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	for scanner.Scan() {
+		if raw := QuickFixRawMessage(scanner.Text()); len(raw) > 0 {
+			messages = append(messages, raw)
+		}
+	}
+
+	return messages, scanner.Err()
+}
+
+// ParseQuickFixRawMessage parses a raw FIX message using the given data
+// dictionaries.
+func ParseQuickFixRawMessage(raw string, transportDict, appDict *datadictionary.DataDictionary) (*quickfix.Message, error) {
+	message := quickfix.NewMessage()
+
+	if err := quickfix.ParseMessageWithDataDictionary(message, bytes.NewBufferString(raw), transportDict, appDict); err != nil {
+		return nil, err
+	}
+
+	return message, nil
+}
+
+// quickFixRawBodyBounds returns the offsets of the BodyLength value and of the
+// body, which starts after the BodyLength field and ends before the CheckSum
+// field.
+func quickFixRawBodyBounds(raw string) (lengthStart, lengthEnd, bodyStart, bodyEnd int, err error) {
+	beginEnd := strings.Index(raw, "\001")
+	if beginEnd < 0 || !strings.HasPrefix(raw[beginEnd+1:], "9=") {
+		return 0, 0, 0, 0, fmt.Errorf("%w: BodyLength must be the second field", errors.FixMessageParse)
+	}
+
+	lengthStart = beginEnd + 3
+	lengthEnd = strings.Index(raw[lengthStart:], "\001")
+	if lengthEnd < 0 {
+		return 0, 0, 0, 0, fmt.Errorf("%w: unterminated BodyLength", errors.FixMessageParse)
+	}
+	lengthEnd += lengthStart
+	bodyStart = lengthEnd + 1
+
+	bodyEnd = strings.LastIndex(raw, "\00110=")
+	if bodyEnd < bodyStart {
+		return 0, 0, 0, 0, fmt.Errorf("%w: CheckSum must be the last field", errors.FixMessageParse)
+	}
+	bodyEnd++
+
+	return lengthStart, lengthEnd, bodyStart, bodyEnd, nil
+}
+
+// QuickFixRawBodyLength computes the BodyLength of the raw message.
+func QuickFixRawBodyLength(raw string) (int, error) {
+	_, _, bodyStart, bodyEnd, err := quickFixRawBodyBounds(raw)
+	if err != nil {
+		return 0, err
+	}
+
+	return bodyEnd - bodyStart, nil
+}
+
+// QuickFixRawCheckSum computes the CheckSum of the raw message.
+func QuickFixRawCheckSum(raw string) (int, error) {
+	_, _, _, bodyEnd, err := quickFixRawBodyBounds(raw)
+	if err != nil {
+		return 0, err
+	}
+
+	sum := 0
+	for i := 0; i < bodyEnd; i++ {
+		sum += int(raw[i])
+	}
+
+	return sum % 256, nil
+}
+
+// QuickFixRawMessageSetBodyLength returns the raw message with its BodyLength
+// set to the actual length of its body.
+func QuickFixRawMessageSetBodyLength(raw string) (string, error) {
+	lengthStart, lengthEnd, bodyStart, bodyEnd, err := quickFixRawBodyBounds(raw)
+	if err != nil {
+		return raw, err
+	}
+
+	return raw[:lengthStart] + strconv.Itoa(bodyEnd-bodyStart) + raw[lengthEnd:], nil
+}
@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"github.com/quickfixgo/enum"
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/tag"
+
+	"sylr.dev/fix/pkg/errors"
+)
+
+const tagBusinessRejectRefID quickfix.Tag = 379
+
+// QuickFixRejectError returns the errors.Reject wrapping err of the message if
+// it is a Reject or a BusinessMessageReject, nil otherwise.
+func QuickFixRejectError(message *quickfix.Message, err error) error {
+	msgType, _ := message.MsgType()
+
+	var reject errors.Reject
+	switch enum.MsgType(msgType) {
+	case enum.MsgType_REJECT:
+	case enum.MsgType_BUSINESS_MESSAGE_REJECT:
+		reject.Business = true
+	default:
+		return nil
+	}
+
+	reject.Err = err
+	reject.RefSeqNum, _ = message.Body.GetInt(tag.RefSeqNum)
+	reject.RefMsgType, _ = message.Body.GetString(tag.RefMsgType)
+	reject.BusinessRejectRefID, _ = message.Body.GetString(tagBusinessRejectRefID)
+	reject.Text, _ = message.Body.GetString(tag.Text)
+
+	if v, err := message.Body.GetInt(tag.RefTagID); err == nil {
+		reject.RefTagID = &v
+	}
+	if v, err := message.Body.GetInt(tag.SessionRejectReason); err == nil {
+		reject.SessionRejectReason = &v
+	}
+	if v, err := message.Body.GetInt(tag.BusinessRejectReason); err == nil {
+		reject.BusinessRejectReason = &v
+	}
+
+	return &reject
+}
+
+// QuickFixResponseClOrdID returns the ClOrdID an execution report, a cancel
+// reject or a reject responds to and whether it is a rejection. Rejects which
+// do not give the ClOrdID are matched with the sequence number they refer to
+// in seqNums, the ClOrdIDs of the requests by sequence number.
+func QuickFixResponseClOrdID(message *quickfix.Message, seqNums map[int]string) (string, bool) {
+	msgType, err := message.MsgType()
+	if err != nil {
+		return "", false
+	}
+
+	switch enum.MsgType(msgType) {
+	case enum.MsgType_EXECUTION_REPORT:
+		id, _ := message.Body.GetString(tag.ClOrdID)
+		status, _ := message.Body.GetString(tag.OrdStatus)
+		return id, enum.OrdStatus(status) == enum.OrdStatus_REJECTED
+	case enum.MsgType_ORDER_CANCEL_REJECT:
+		id, _ := message.Body.GetString(tag.ClOrdID)
+		return id, true
+	case enum.MsgType_BUSINESS_MESSAGE_REJECT, enum.MsgType_REJECT:
+		if id, err := message.Body.GetString(tagBusinessRejectRefID); err == nil {
+			return id, true
+		}
+		if seqNum, err := message.Body.GetInt(tag.RefSeqNum); err == nil {
+			return seqNums[seqNum], true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,206 @@
+package utils
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+)
+
+// ResendLogger is the logger warned of the resend requests and the sequence
+// resets exchanged on the sessions.
+var ResendLogger *zerolog.Logger
+
+// Types of the resend events.
+const (
+	ResendEventResendRequest = "ResendRequest"
+	ResendEventGapFill       = "GapFill"
+	ResendEventSequenceReset = "SequenceReset"
+)
+
+// maxResendEvents is the number of resend events kept for the result of the
+// command, the following ones being only counted.
+const maxResendEvents = 20
+
+var (
+	metricSessionResendRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "fix",
+			Subsystem: "session",
+			Name:      "resend_requests_total",
+			Help:      "Number of ResendRequest messages received (in) or sent (out)",
+		},
+		[]string{"session", "direction"},
+	)
+	metricSessionGapFills = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "fix",
+			Subsystem: "session",
+			Name:      "gap_fills_total",
+			Help:      "Number of SequenceReset-GapFill messages received (in) or sent (out)",
+		},
+		[]string{"session", "direction"},
+	)
+	metricSessionGapFilledMessages = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "fix",
+			Subsystem: "session",
+			Name:      "gap_filled_messages_total",
+			Help:      "Number of messages skipped by the SequenceReset-GapFill messages received (in) or sent (out)",
+		},
+		[]string{"session", "direction"},
+	)
+	metricSessionSequenceResets = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "fix",
+			Subsystem: "session",
+			Name:      "sequence_resets_total",
+			Help:      "Number of SequenceReset-Reset messages received (in) or sent (out)",
+		},
+		[]string{"session", "direction"},
+	)
+	metricSessionResentMessages = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "fix",
+			Subsystem: "session",
+			Name:      "resent_messages_total",
+			Help:      "Number of messages received (in) or sent (out) again with PossDupFlag",
+		},
+		[]string{"session", "direction"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(
+		metricSessionResendRequests,
+		metricSessionGapFills,
+		metricSessionGapFilledMessages,
+		metricSessionSequenceResets,
+		metricSessionResentMessages,
+	)
+}
+
+// ResendEvent is a resend request, a gap fill or a sequence reset exchanged on
+// a session.
+type ResendEvent struct {
+	Time      time.Time `json:"time"`
+	Session   string    `json:"session"`
+	Direction string    `json:"direction"`
+	Type      string    `json:"type"`
+	// BeginSeqNo and EndSeqNo are the range of the messages to resend or
+	// skipped, EndSeqNo being 0 for all the messages up to the last one
+	BeginSeqNo int `json:"beginSeqNo"`
+	EndSeqNo   int `json:"endSeqNo"`
+}
+
+func (e ResendEvent) String() string {
+	end := "the last one"
+	if e.EndSeqNo > 0 {
+		end = fmt.Sprint(e.EndSeqNo)
+	}
+
+	switch {
+	case e.Type == ResendEventResendRequest && e.Direction == "in":
+		return fmt.Sprintf("%s: counterparty requested the resend of messages %d to %s", e.Session, e.BeginSeqNo, end)
+	case e.Type == ResendEventResendRequest:
+		return fmt.Sprintf("%s: requested the resend of messages %d to %s, some were missed", e.Session, e.BeginSeqNo, end)
+	case e.Type == ResendEventGapFill && e.Direction == "in":
+		return fmt.Sprintf("%s: counterparty skipped messages %d to %s with a gap fill, they will not be resent", e.Session, e.BeginSeqNo, end)
+	case e.Type == ResendEventGapFill:
+		return fmt.Sprintf("%s: skipped messages %d to %s with a gap fill", e.Session, e.BeginSeqNo, end)
+	case e.Direction == "in":
+		return fmt.Sprintf("%s: counterparty reset the sequence to %d, messages may have been lost", e.Session, e.EndSeqNo+1)
+	default:
+		return fmt.Sprintf("%s: reset the sequence to %d", e.Session, e.EndSeqNo+1)
+	}
+}
+
+var (
+	resendEvents        []ResendEvent
+	resendEventsDropped int
+	resendEventsMux     sync.Mutex
+)
+
+// ResendEvents returns the first resend events which occurred on the sessions
+// and the number of the following ones.
+func ResendEvents() ([]ResendEvent, int) {
+	resendEventsMux.Lock()
+	defer resendEventsMux.Unlock()
+
+	events := make([]ResendEvent, len(resendEvents))
+	copy(events, resendEvents)
+
+	return events, resendEventsDropped
+}
+
+// ResendWarnings returns the resend events which occurred on the sessions as
+// warnings for the user, as they often explain missing responses.
+func ResendWarnings() []string {
+	events, dropped := ResendEvents()
+
+	var warnings []string
+	for _, e := range events {
+		warnings = append(warnings, e.String())
+	}
+	if dropped > 0 {
+		warnings = append(warnings, fmt.Sprintf("%d more resend events", dropped))
+	}
+
+	return warnings
+}
+
+func recordResendEvent(e ResendEvent) {
+	if ResendLogger != nil {
+		ResendLogger.Warn().Msg(e.String())
+	}
+
+	resendEventsMux.Lock()
+	defer resendEventsMux.Unlock()
+
+	if len(resendEvents) < maxResendEvents {
+		resendEvents = append(resendEvents, e)
+	} else {
+		resendEventsDropped++
+	}
+}
+
+// resend records the resend requests, gap fills and sequence resets among the
+// messages of the session, and counts the messages resent.
+func (h *quickFixSessionHealth) resend(direction string, f quickFixHealthFields) {
+	e := ResendEvent{Time: time.Now(), Session: h.session, Direction: direction}
+
+	switch f.msgType {
+	case "2":
+		metricSessionResendRequests.WithLabelValues(h.session, direction).Inc()
+		e.Type = ResendEventResendRequest
+		e.BeginSeqNo, e.EndSeqNo = f.beginSeqNo, f.endSeqNo
+
+	case "4":
+		if f.newSeqNo <= 0 {
+			return
+		}
+
+		if f.gapFill {
+			metricSessionGapFills.WithLabelValues(h.session, direction).Inc()
+			if skipped := f.newSeqNo - f.seqNum; skipped > 0 {
+				metricSessionGapFilledMessages.WithLabelValues(h.session, direction).Add(float64(skipped))
+			}
+			e.Type = ResendEventGapFill
+			e.BeginSeqNo, e.EndSeqNo = f.seqNum, f.newSeqNo-1
+		} else {
+			metricSessionSequenceResets.WithLabelValues(h.session, direction).Inc()
+			e.Type = ResendEventSequenceReset
+			e.EndSeqNo = f.newSeqNo - 1
+		}
+
+	default:
+		if f.possDup {
+			metricSessionResentMessages.WithLabelValues(h.session, direction).Inc()
+		}
+		return
+	}
+
+	recordResendEvent(e)
+}
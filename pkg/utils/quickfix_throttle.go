@@ -0,0 +1,228 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/quickfixgo/enum"
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/tag"
+	"golang.org/x/time/rate"
+
+	"sylr.dev/fix/pkg/errors"
+)
+
+// Throttle policies.
+const (
+	ThrottlePolicyBlock = "block"
+	ThrottlePolicyFail  = "fail"
+)
+
+var (
+	metricSessionThrottleDelay = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "fix",
+			Subsystem: "session",
+			Name:      "throttle_delay_seconds_total",
+			Help:      "Time the application messages waited to be sent within the throttle of the session",
+		},
+		[]string{"session"},
+	)
+	metricSessionThrottleRefused = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "fix",
+			Subsystem: "session",
+			Name:      "throttle_refused_total",
+			Help:      "Number of application messages refused by the throttle of the session",
+		},
+		[]string{"session"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(metricSessionThrottleDelay, metricSessionThrottleRefused)
+}
+
+// quickFixOrderMsgTypes are the types of the messages counted as orders by the
+// OrdersPerSecond throttle.
+var quickFixOrderMsgTypes = []enum.MsgType{
+	enum.MsgType_ORDER_SINGLE,
+	enum.MsgType_ORDER_CANCEL_REQUEST,
+	enum.MsgType_ORDER_CANCEL_REPLACE_REQUEST,
+	enum.MsgType_ORDER_LIST,
+	enum.MsgType_NEW_ORDER_CROSS,
+	enum.MsgType_CROSS_ORDER_CANCEL_REQUEST,
+	enum.MsgType_CROSS_ORDER_CANCEL_REPLACE_REQUEST,
+	enum.MsgType_NEW_ORDER_MULTILEG,
+	enum.MsgType_MULTILEG_ORDER_CANCEL_REPLACE,
+	enum.MsgType_ORDER_MASS_CANCEL_REQUEST,
+}
+
+// quickFixThrottle limits the rate of the application messages sent on a
+// session.
+type quickFixThrottle struct {
+	session  string
+	fail     bool
+	messages *rate.Limiter
+	orders   *rate.Limiter
+}
+
+func newQuickFixThrottle(sessionID quickfix.SessionID, settings *quickfix.SessionSettings) (*quickFixThrottle, error) {
+	messages, err := throttleLimiter(settings, "ThrottleMessagesPerSecond")
+	if err != nil {
+		return nil, err
+	}
+
+	orders, err := throttleLimiter(settings, "ThrottleOrdersPerSecond")
+	if err != nil {
+		return nil, err
+	}
+
+	if messages == nil && orders == nil {
+		return nil, nil
+	}
+
+	policy := ThrottlePolicyBlock
+	if settings.HasSetting("ThrottlePolicy") {
+		if policy, err = settings.Setting("ThrottlePolicy"); err != nil {
+			return nil, err
+		}
+	}
+
+	switch policy {
+	case ThrottlePolicyBlock, ThrottlePolicyFail:
+	default:
+		return nil, fmt.Errorf("%w: session %s: unknown throttle policy %q, expected %s or %s", errors.Config, sessionID, policy, ThrottlePolicyBlock, ThrottlePolicyFail)
+	}
+
+	return &quickFixThrottle{
+		session:  sessionID.String(),
+		fail:     policy == ThrottlePolicyFail,
+		messages: messages,
+		orders:   orders,
+	}, nil
+}
+
+// throttleLimiter returns a limiter of the rate of the setting, nil when the
+// session has none. The burst being of one message, the rate is never exceeded
+// over any window of time.
+func throttleLimiter(settings *quickfix.SessionSettings, key string) (*rate.Limiter, error) {
+	if !settings.HasSetting(key) {
+		return nil, nil
+	}
+
+	value, err := settings.Setting(key)
+	if err != nil {
+		return nil, err
+	}
+
+	perSecond, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %s", errors.Config, key, err)
+	} else if perSecond <= 0 {
+		return nil, nil
+	}
+
+	return rate.NewLimiter(rate.Limit(perSecond), 1), nil
+}
+
+// wait waits for the message to be sent within the rates, or tells why it
+// can't be with the fail policy. Messages resent are neither throttled nor
+// counted.
+func (t *quickFixThrottle) wait(message *quickfix.Message) error {
+	if possDup, err := message.Header.GetBool(tag.PossDupFlag); err == nil && possDup {
+		return nil
+	}
+
+	limiters := []*rate.Limiter{t.messages}
+	if msgType, err := message.MsgType(); err == nil && Search(quickFixOrderMsgTypes, enum.MsgType(msgType)) >= 0 {
+		limiters = append(limiters, t.orders)
+	}
+
+	now := time.Now()
+	reservations := make([]*rate.Reservation, 0, len(limiters))
+	var delay time.Duration
+
+	for _, limiter := range limiters {
+		if limiter == nil {
+			continue
+		}
+
+		r := limiter.ReserveN(now, 1)
+		reservations = append(reservations, r)
+		if d := r.DelayFrom(now); d > delay {
+			delay = d
+		}
+	}
+
+	if delay == 0 {
+		return nil
+	}
+
+	if t.fail {
+		for _, r := range reservations {
+			r.CancelAt(now)
+		}
+		metricSessionThrottleRefused.WithLabelValues(t.session).Inc()
+
+		return fmt.Errorf("%w: session %s, retry in %s", errors.Throttled, t.session, delay.Round(time.Millisecond))
+	}
+
+	metricSessionThrottleDelay.WithLabelValues(t.session).Add(delay.Seconds())
+	time.Sleep(delay)
+
+	// quickfix sets the SendingTime before calling ToApp
+	var sendingTime quickfix.FIXUTCTimestamp
+	if err := message.Header.GetField(tag.SendingTime, &sendingTime); err == nil {
+		sendingTime.Time = time.Now()
+		message.Header.SetField(tag.SendingTime, sendingTime)
+	}
+
+	return nil
+}
+
+// QuickFixThrottledApplication throttles the application messages sent by the
+// sessions having ThrottleMessagesPerSecond or ThrottleOrdersPerSecond
+// settings, from ToApp which quickfix calls with the send lock of the session
+// held.
+type QuickFixThrottledApplication struct {
+	quickfix.Application
+
+	throttles map[quickfix.SessionID]*quickFixThrottle
+}
+
+// NewQuickFixThrottledApplication returns the application wrapped with the
+// throttles of the sessions, or as is when no session is throttled.
+func NewQuickFixThrottledApplication(app quickfix.Application, settings *quickfix.Settings) (quickfix.Application, error) {
+	throttles := make(map[quickfix.SessionID]*quickFixThrottle)
+
+	for sessionID, sessionSettings := range settings.SessionSettings() {
+		throttle, err := newQuickFixThrottle(sessionID, sessionSettings)
+		if err != nil {
+			return nil, err
+		} else if throttle != nil {
+			throttles[sessionID] = throttle
+		}
+	}
+
+	if len(throttles) == 0 {
+		return app, nil
+	}
+
+	return &QuickFixThrottledApplication{Application: app, throttles: throttles}, nil
+}
+
+// ToApp waits for the message to be allowed by the throttle of its session
+// before handing it to the application. Refused messages are not sent, the
+// error being returned by quickfix.SendToTarget.
+func (a *QuickFixThrottledApplication) ToApp(message *quickfix.Message, sessionID quickfix.SessionID) error {
+	if throttle, ok := a.throttles[sessionID]; ok {
+		if err := throttle.wait(message); err != nil {
+			return err
+		}
+	}
+
+	return a.Application.ToApp(message, sessionID)
+}
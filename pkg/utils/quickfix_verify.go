@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// QuickFixRawCheck is the verification of the BodyLength or of the CheckSum
+// of a raw message. Offsets are byte offsets in the message, fields being
+// delimited by SOH.
+type QuickFixRawCheck struct {
+	Tag      int    `json:"tag"`
+	Field    string `json:"field"`
+	Offset   int    `json:"offset"`
+	Value    string `json:"value"`
+	Expected string `json:"expected"`
+	Valid    bool   `json:"valid"`
+	Text     string `json:"text"`
+}
+
+// QuickFixRawVerify recomputes the BodyLength and the CheckSum of the raw
+// message and compares them to the ones it holds, explaining which bytes
+// they are computed on and where the declared BodyLength ends the body.
+func QuickFixRawVerify(raw string) ([]QuickFixRawCheck, error) {
+	lengthStart, lengthEnd, bodyStart, bodyEnd, err := quickFixRawBodyBounds(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	length := bodyEnd - bodyStart
+	lengthCheck := QuickFixRawCheck{
+		Tag:      9,
+		Field:    "BodyLength",
+		Offset:   lengthStart,
+		Value:    raw[lengthStart:lengthEnd],
+		Expected: strconv.Itoa(length),
+		Text:     fmt.Sprintf("body spans bytes %d to %d", bodyStart, bodyEnd-1),
+	}
+	lengthCheck.Valid = lengthCheck.Value == lengthCheck.Expected
+
+	if !lengthCheck.Valid {
+		if declared, err := strconv.Atoi(lengthCheck.Value); err != nil || declared < 0 {
+			lengthCheck.Text += ", declared value is not a length"
+		} else {
+			end := bodyStart + declared
+			lengthCheck.Text += fmt.Sprintf(", declared value ends it at byte %d", end-1)
+			if tag := quickFixRawFieldAt(raw, end-1); len(tag) > 0 {
+				lengthCheck.Text += fmt.Sprintf(" in field %s", tag)
+			}
+		}
+	}
+
+	checkSumStart := bodyEnd + 3
+	value := strings.TrimSuffix(raw[checkSumStart:], "\001")
+	sum, _ := QuickFixRawCheckSum(raw)
+
+	checkSumCheck := QuickFixRawCheck{
+		Tag:      10,
+		Field:    "CheckSum",
+		Offset:   checkSumStart,
+		Value:    value,
+		Expected: fmt.Sprintf("%03d", sum),
+		Text:     fmt.Sprintf("sum of bytes 0 to %d modulo 256", bodyEnd-1),
+	}
+	checkSumCheck.Valid = checkSumCheck.Value == checkSumCheck.Expected
+
+	// Fixing the BodyLength changes the CheckSum
+	if !lengthCheck.Valid {
+		fixed, _ := QuickFixRawMessageSetBodyLength(raw)
+		if fixedSum, err := QuickFixRawCheckSum(fixed); err == nil {
+			checkSumCheck.Text += fmt.Sprintf(", %03d once BodyLength is fixed", fixedSum)
+		}
+	}
+
+	return []QuickFixRawCheck{lengthCheck, checkSumCheck}, nil
+}
+
+// quickFixRawFieldAt returns the tag of the field holding the byte at offset.
+func quickFixRawFieldAt(raw string, offset int) string {
+	if offset < 0 || offset >= len(raw) {
+		return ""
+	}
+
+	// A SOH belongs to the field it terminates
+	start := strings.LastIndex(raw[:offset], "\001") + 1
+
+	field := raw[start:]
+	if idx := strings.Index(field, "="); idx > 0 {
+		return field[:idx]
+	}
+
+	return ""
+}
@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"strconv"
+
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/quickfix/datadictionary"
+
+	yaml "sylr.dev/yaml/v3"
+)
+
+// quickFixYAMLNode returns a mapping node of the fields keyed by their names,
+// following the same layout as the FIX JSON encoding.
+func quickFixYAMLNode(fields []*QuickFixField) *yaml.Node {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+
+	for _, field := range fields {
+		if quickFixJSONSkippedTags[field.Tag] {
+			continue
+		}
+
+		name := field.Name
+		if len(name) == 0 {
+			name = strconv.Itoa(field.Tag)
+		}
+
+		var value *yaml.Node
+		if field.IsGroup() {
+			value = &yaml.Node{Kind: yaml.SequenceNode}
+			for _, group := range field.Groups {
+				value.Content = append(value.Content, quickFixYAMLNode(group))
+			}
+		} else {
+			value = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: field.Value}
+		}
+
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: name}, value)
+	}
+
+	return node
+}
+
+// QuickFixMessageToYAML encodes the message as a YAML document with the same
+// layout as QuickFixMessageToJSON, which can be read back by `fix encode`.
+func QuickFixMessageToYAML(message *quickfix.Message, transportDict, appDict *datadictionary.DataDictionary) ([]byte, error) {
+	header, body, trailer := QuickFixMessageFields(message, transportDict, appDict)
+
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	for _, part := range []struct {
+		name   string
+		fields []*QuickFixField
+	}{{"Header", header}, {"Body", body}, {"Trailer", trailer}} {
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: part.name}, quickFixYAMLNode(part.fields))
+	}
+
+	return yaml.Marshal(node)
+}
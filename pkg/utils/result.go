@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/quickfixgo/quickfix"
+	"github.com/quickfixgo/quickfix/datadictionary"
+
+	"sylr.dev/fix/pkg/errors"
+)
+
+const (
+	ResultStatusOK                = "ok"
+	ResultStatusError             = "error"
+	ResultStatusRejected          = "rejected"
+	ResultStatusCanceled          = "canceled"
+	ResultStatusTimeout           = "timeout"
+	ResultStatusConnectionTimeout = "connection_timeout"
+	ResultStatusLogout            = "logout"
+	ResultStatusConfig            = "config"
+)
+
+// resultExitCodes are the exit codes of the command statuses. They are part of
+// the documented interface of the command line and must not change.
+var resultExitCodes = map[string]int{
+	ResultStatusOK:                0,
+	ResultStatusError:             1,
+	ResultStatusRejected:          2,
+	ResultStatusCanceled:          3,
+	ResultStatusTimeout:           4,
+	ResultStatusConnectionTimeout: 5,
+	ResultStatusLogout:            6,
+	ResultStatusConfig:            7,
+}
+
+// Result is the outcome of a command. Its exit code is the one of the process
+// and it is printed as a single JSON line in quiet mode. Warnings tell about
+// the resends which occurred on the sessions of the command.
+type Result struct {
+	Status   string          `json:"status"`
+	ExitCode int             `json:"exitCode"`
+	Error    string          `json:"error,omitempty"`
+	Message  json.RawMessage `json:"message,omitempty"`
+	Warnings []string        `json:"warnings,omitempty"`
+}
+
+var (
+	resultMessage   json.RawMessage
+	resultMessageMu sync.Mutex
+)
+
+// RecordResultMessage records the message as the last response written by the
+// command, to be part of its result.
+func RecordResultMessage(message *quickfix.Message, transportDict, appDict *datadictionary.DataDictionary) {
+	b, err := QuickFixMessageToJSON(message, transportDict, appDict)
+	if err != nil {
+		return
+	}
+
+	resultMessageMu.Lock()
+	defer resultMessageMu.Unlock()
+
+	resultMessage = b
+}
+
+// NewResult returns the result of a command which returned err.
+func NewResult(err error) Result {
+	status := ResultStatusOK
+
+	switch {
+	case err == nil:
+	case errors.Is(err, errors.FixOrderRejected), errors.Is(err, errors.FixRejected):
+		status = ResultStatusRejected
+	case errors.Is(err, errors.FixOrderCanceled):
+		status = ResultStatusCanceled
+	case errors.Is(err, errors.ResponseTimeout):
+		status = ResultStatusTimeout
+	case errors.Is(err, errors.ConnectionTimeout):
+		status = ResultStatusConnectionTimeout
+	case errors.Is(err, errors.FixLogout):
+		status = ResultStatusLogout
+	case errors.Is(err, errors.Config):
+		status = ResultStatusConfig
+	default:
+		status = ResultStatusError
+	}
+
+	resultMessageMu.Lock()
+	defer resultMessageMu.Unlock()
+
+	result := Result{
+		Status:   status,
+		ExitCode: resultExitCodes[status],
+		Message:  resultMessage,
+		Warnings: ResendWarnings(),
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+
+	return result
+}
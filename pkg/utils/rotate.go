@@ -0,0 +1,166 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// RotatingFile is an io.WriteCloser which rotates the underlying file when it
+// exceeds MaxSize bytes or when it is older than RotateEvery. Rotated files are
+// renamed with a timestamp suffix and only the MaxBackups most recent ones are
+// kept. Zero values disable the corresponding behaviour.
+type RotatingFile struct {
+	Path        string
+	MaxSize     int64
+	RotateEvery time.Duration
+	MaxBackups  int
+
+	mux      sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+}
+
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	if f.shouldRotate(int64(len(p))) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+
+	return n, err
+}
+
+func (f *RotatingFile) Close() error {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+
+	err := f.file.Close()
+	f.file = nil
+
+	return err
+}
+
+// Sync commits the content written to the file to the disk.
+func (f *RotatingFile) Sync() error {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+
+	return f.file.Sync()
+}
+
+// Rotate rotates the file now unless it is empty.
+func (f *RotatingFile) Rotate() error {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+
+	if f.file == nil || f.size == 0 {
+		return nil
+	}
+
+	return f.rotate()
+}
+
+func (f *RotatingFile) shouldRotate(n int64) bool {
+	if f.size == 0 {
+		return false
+	}
+
+	if f.MaxSize > 0 && f.size+n > f.MaxSize {
+		return true
+	}
+
+	return f.RotateEvery > 0 && time.Since(f.openedAt) >= f.RotateEvery
+}
+
+func (f *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.Path), 0700); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(f.Path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	f.file = file
+	f.size = info.Size()
+	f.openedAt = info.ModTime()
+	if f.size == 0 {
+		f.openedAt = time.Now()
+	}
+
+	return nil
+}
+
+func (f *RotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	f.file = nil
+
+	backup := fmt.Sprintf("%s.%s", f.Path, time.Now().UTC().Format("20060102T150405.000000000"))
+	if err := os.Rename(f.Path, backup); err != nil {
+		return err
+	}
+
+	if err := f.prune(); err != nil {
+		return err
+	}
+
+	return f.open()
+}
+
+// prune removes the oldest rotated files beyond MaxBackups.
+func (f *RotatingFile) prune() error {
+	if f.MaxBackups <= 0 {
+		return nil
+	}
+
+	backups, err := filepath.Glob(f.Path + ".*")
+	if err != nil {
+		return err
+	}
+
+	// Timestamp suffixes sort chronologically.
+	sort.Strings(backups)
+
+	for len(backups) > f.MaxBackups {
+		if err := os.Remove(backups[0]); err != nil {
+			return err
+		}
+		backups = backups[1:]
+	}
+
+	return nil
+}
@@ -1,6 +1,123 @@
 package utils
 
-import "time"
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"sylr.dev/fix/pkg/errors"
+)
+
+const (
+	TimePrecisionAuto         = "auto"
+	TimePrecisionSeconds      = "s"
+	TimePrecisionMilliseconds = "ms"
+	TimePrecisionMicroseconds = "us"
+	TimePrecisionNanoseconds  = "ns"
+)
+
+var TimePrecisions = []string{
+	TimePrecisionAuto,
+	TimePrecisionSeconds,
+	TimePrecisionMilliseconds,
+	TimePrecisionMicroseconds,
+	TimePrecisionNanoseconds,
+}
+
+var timePrecisionFractions = map[string]string{
+	TimePrecisionAuto:         ".999999999",
+	TimePrecisionSeconds:      "",
+	TimePrecisionMilliseconds: ".000",
+	TimePrecisionMicroseconds: ".000000",
+	TimePrecisionNanoseconds:  ".000000000",
+}
+
+var (
+	// TimeLocation is the timezone the timestamps are printed in.
+	TimeLocation = time.UTC
+	// TimePrecision is the precision of the fractional seconds of the
+	// timestamps printed, auto removing trailing zeros.
+	TimePrecision = TimePrecisionAuto
+)
+
+// SetTimeRendering sets the timezone, UTC, local or an IANA name, and the
+// precision of the timestamps printed.
+func SetTimeRendering(timezone, precision string) error {
+	if _, ok := timePrecisionFractions[precision]; !ok {
+		return fmt.Errorf("%w: unknown time precision `%s`", errors.Options, precision)
+	}
+
+	var location *time.Location
+	switch strings.ToLower(timezone) {
+	case "", "utc":
+		location = time.UTC
+	case "local":
+		location = time.Local
+	default:
+		var err error
+		if location, err = time.LoadLocation(timezone); err != nil {
+			return fmt.Errorf("%w: unknown timezone `%s`", errors.Options, timezone)
+		}
+	}
+
+	TimeLocation, TimePrecision = location, precision
+
+	return nil
+}
+
+func timeOfDayLayout() string {
+	return "15:04:05" + timePrecisionFractions[TimePrecision]
+}
+
+// FormatTime formats the timestamp using the configured timezone and
+// precision.
+func FormatTime(t time.Time) string {
+	return t.In(TimeLocation).Format("2006-01-02T" + timeOfDayLayout() + "Z07:00")
+}
+
+// FormatTimeOnly formats the UTC time of day using the configured timezone
+// and precision. It is converted as if it was today's.
+func FormatTimeOnly(t time.Time) string {
+	return CombineDateAndTime(time.Now().UTC(), t).In(TimeLocation).Format(timeOfDayLayout())
+}
+
+// FormatDate formats the date.
+func FormatDate(t time.Time) string {
+	return t.Format("2006-01-02")
+}
+
+// ParseTime parses a timestamp given as RFC 3339 or a date given as
+// 2006-01-02, dates starting at midnight in the configured timezone.
+func ParseTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t, nil
+	}
+
+	return time.ParseInLocation("2006-01-02", value, TimeLocation)
+}
+
+// FormatQuickFixTime formats the value of a FIX field of type UTCTimestamp,
+// UTCTimeOnly or UTCDateOnly. It returns false if the field is not of one of
+// these types or its value can not be parsed.
+func FormatQuickFixTime(fieldType, value string) (string, bool) {
+	switch fieldType {
+	case "UTCTIMESTAMP":
+		// Fractional seconds are optional and parsed even if not in the layout.
+		if t, err := time.Parse("20060102-15:04:05", value); err == nil {
+			return FormatTime(t), true
+		}
+	case "UTCTIMEONLY":
+		if t, err := time.Parse("15:04:05", value); err == nil {
+			return FormatTimeOnly(t), true
+		}
+	case "UTCDATEONLY", "UTCDATE":
+		if t, err := time.Parse("20060102", value); err == nil {
+			return FormatDate(t), true
+		}
+	}
+
+	return value, false
+}
 
 func CombineDateAndTime(date time.Time, t time.Time) time.Time {
 	return time.Date(